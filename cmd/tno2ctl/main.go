@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+const usage = `Usage: tno2ctl [flags] <command> <thing> [args]
+
+Commands:
+  describe <thing>                  print ThingDescription
+  get      <thing> <property>       read property value
+  set      <thing> <property> <json> write property value
+  invoke   <thing> <action> [json]  invoke action and follow its progress
+  watch    <thing> <event>          print events until interrupted
+
+<thing> is context path the Thing is bound to, e.g. /01-basic-example
+
+Flags:
+`
+
+var (
+	serverURL = flag.String("server", "http://localhost:8080", "tno2 server URL")
+	output    = flag.String("o", "json", "output format: json or table")
+	token     = flag.String("token", "", "bearer token")
+	apiKey    = flag.String("api-key", "", "API key")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	client, err := newClient(args[1])
+	exitOnErr(err)
+
+	switch cmd := args[0]; {
+	case cmd == "describe":
+		td, err := client.GetDescription()
+		exitOnErr(err)
+		printDescription(td)
+	case cmd == "get" && len(args) == 3:
+		value, err := client.GetProperty(args[2])
+		exitOnErr(err)
+		printValue(value)
+	case cmd == "set" && len(args) == 4:
+		exitOnErr(client.SetProperty(args[2], parseJSON(args[3])))
+	case cmd == "invoke" && (len(args) == 3 || len(args) == 4):
+		var arg interface{}
+		if len(args) == 4 {
+			arg = parseJSON(args[3])
+		}
+		task, err := client.InvokeAction(args[2], arg)
+		exitOnErr(err)
+		exitOnErr(task.Watch(printTaskStatus))
+	case cmd == "watch" && len(args) == 3:
+		sub, err := client.AddListener(args[2], printEvent)
+		exitOnErr(err)
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt)
+		<-interrupted
+		sub.Close()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func newClient(thing string) (proxy.Client, error) {
+	uri := str.Concat(strings.TrimSuffix(*serverURL, "/"), "/", strings.TrimPrefix(thing, "/"))
+	client, err := proxy.NewHttpClient(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if *token != "" {
+		client.WithBearer(*token)
+	}
+	if *apiKey != "" {
+		client.WithAPIKey(*apiKey)
+	}
+
+	return client, nil
+}
+
+func parseJSON(arg string) interface{} {
+	var v interface{}
+
+	if err := json.Unmarshal([]byte(arg), &v); err != nil {
+		//not a JSON document, treat argument as plain string
+		return arg
+	}
+
+	return v
+}
+
+func printDescription(td *model.ThingDescription) {
+	if *output != "table" {
+		printJSON(td)
+		return
+	}
+
+	tw := table("KIND", "NAME", "TYPE", "WRITABLE", "HREF")
+	for _, p := range td.Properties {
+		fmt.Fprintf(tw, "property\t%s\t%s\t%t\t%s\n", p.Name, p.ValueType.Type, p.Writable, first(p.Hrefs))
+	}
+	for _, a := range td.Actions {
+		fmt.Fprintf(tw, "action\t%s\t%s\t-\t%s\n", a.Name, a.InputData.ValueType.Type, first(a.Hrefs))
+	}
+	for _, e := range td.Events {
+		fmt.Fprintf(tw, "event\t%s\t%s\t-\t%s\n", e.Name, e.ValueType.Type, first(e.Hrefs))
+	}
+	tw.Flush()
+}
+
+func printValue(value interface{}) {
+	obj, ok := value.(map[string]interface{})
+
+	if *output != "table" || !ok {
+		printJSON(value)
+		return
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tw := table("FIELD", "VALUE")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", k, compactJSON(obj[k]))
+	}
+	tw.Flush()
+}
+
+func printTaskStatus(status *server.TaskStatus) {
+	if *output != "table" {
+		printJSON(status)
+		return
+	}
+
+	fmt.Println(status.Timestamp.Format("15:04:05"), taskStatusName(status.Status), compactJSON(status.Data))
+}
+
+func printEvent(event *server.Event) {
+	if *output != "table" {
+		printJSON(event)
+		return
+	}
+
+	fmt.Println(event.Timestamp.Format("15:04:05"), event.Event, compactJSON(event.Data))
+}
+
+func taskStatusName(code server.TaskStatusCode) string {
+	switch code {
+	case server.TASK_SCHEDULED:
+		return "SCHEDULED"
+	case server.TASK_RUNNING:
+		return "RUNNING"
+	case server.TASK_DONE:
+		return "DONE"
+	case server.TASK_FAILED:
+		return "FAILED"
+	}
+
+	return "UNKNOWN"
+}
+
+func table(columns ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	return tw
+}
+
+func printJSON(v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+func compactJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func first(hrefs []string) string {
+	if len(hrefs) == 0 {
+		return ""
+	}
+
+	return hrefs[0]
+}
+
+func exitOnErr(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
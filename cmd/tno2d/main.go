@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/platform"
+)
+
+// tno2d runs platform declared in config file
+func main() {
+	cfgPath := flag.String("config", "tno2.json", "platform config file, JSON or TOML (*.toml)")
+	flag.Parse()
+
+	cfg, err := platform.LoadConfig(*cfgPath)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	log.Info("tno2d: starting platform from ", *cfgPath)
+	p := platform.NewPlatformFromConfig(cfg)
+
+	if interval, _ := cfg.WatchInterval(); interval > 0 {
+		p.WatchDescriptions(interval)
+	}
+
+	p.Start().Wait()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/conas/tno2/wot/gen"
+	"github.com/conas/tno2/wot/model"
+)
+
+// tno2gen generates typed Go server handler interface and client from ThingDescription,
+// .proto message definitions of its values with -proto, or OpenAPI and AsyncAPI documents of its bindings
+// with -openapi and -asyncapi
+func main() {
+	td := flag.String("td", "", "ThingDescription file")
+	pkg := flag.String("pkg", "main", "package of generated code")
+	out := flag.String("o", "", "output file, standard output when empty")
+	proto := flag.Bool("proto", false, "generate .proto messages instead of Go code")
+	openapi := flag.Bool("openapi", false, "generate OpenAPI document instead of Go code")
+	asyncapi := flag.Bool("asyncapi", false, "generate AsyncAPI document instead of Go code")
+	flag.Parse()
+
+	if *td == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	desc, err := model.Load("file://" + *td)
+	if err != nil {
+		fail(err)
+	}
+
+	generate := gen.Generate
+	if *proto {
+		generate = gen.GenerateProto
+	}
+	if *openapi {
+		generate = func(td *model.ThingDescription, pkg string) ([]byte, error) {
+			return json.MarshalIndent(gen.OpenAPI(td, nil), "", "  ")
+		}
+	}
+	if *asyncapi {
+		generate = func(td *model.ThingDescription, pkg string) ([]byte, error) {
+			return json.MarshalIndent(gen.AsyncAPI(td, nil), "", "  ")
+		}
+	}
+
+	src, err := generate(desc, *pkg)
+	if err != nil {
+		fail(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err = ioutil.WriteFile(*out, src, 0644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "tno2gen:", err)
+	os.Exit(1)
+}
@@ -11,7 +11,7 @@ import (
 var model = "file://../example-model.json"
 
 func main() {
-	p := platform.NewPlatform()
+	p := platform.NewPlatform("localhost")
 	p.AddFrontend("http-1", "HTTP", col.KV("port", 8080))
 	p.AddBackend("mqtt-1", "MQTT", col.KV("url", "tcp://localhost:1883"))
 	p.AddWotServer("example-dev", model, "/02-mqtt-example", "SIMPLE_URL_CODEC", "mqtt-1", []string{"http-1"})
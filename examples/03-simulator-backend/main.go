@@ -11,7 +11,7 @@ import (
 var model = "file://../example-model.json"
 
 func main() {
-	p := platform.NewPlatform()
+	p := platform.NewPlatform("localhost")
 	p.AddFrontend("http-1", "HTTP", col.KV("port", 8080))
 	p.AddBackend("sim-1", "SIMULATOR")
 	p.AddWotServer("example-dev", model, "/03-simulator", "SIMPLE_URL_CODEC", "sim-1", []string{"http-1"})
@@ -20,9 +20,8 @@ func newHandlers() *handlers {
 
 func (*handlers) Subscribe(subject string, cb interface{}) {
 	cbv := reflect.ValueOf(cb)
-	cbv.Call
 
-	fmt.Println("type %v", reflect.ValueOf(cb))
+	fmt.Printf("type %v\n", cbv.Type())
 }
 
 type Person struct{}
@@ -0,0 +1,897 @@
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+)
+
+// Package amqp implements client of AMQP 1.0 (OASIS standard) for brokers like ActiveMQ or Azure
+// Service Bus: one session of sender links waiting for outcome of every message and receiver links
+// accepting messages. SASL PLAIN and ANONYMOUS are supported, amqps connections use TLS. Transactions
+// and link recovery are not supported, client of lost connection has to connect again.
+
+const (
+	DEFAULT_PORT     = "5672"
+	DEFAULT_TLS_PORT = "5671"
+)
+
+const (
+	// MAX_FRAME is largest frame accepted, larger messages are transferred in multiple frames
+	MAX_FRAME = 64 * 1024
+	// LINK_CREDIT is number of messages receiver link accepts before granting more
+	LINK_CREDIT = 100
+	// CONNECT_TIMEOUT bounds handshakes of connection and links
+	CONNECT_TIMEOUT = 10 * time.Second
+)
+
+// descriptors of performatives, SASL frames, link terminus and delivery states
+const (
+	performativeOpen        uint64 = 0x10
+	performativeBegin       uint64 = 0x11
+	performativeAttach      uint64 = 0x12
+	performativeFlow        uint64 = 0x13
+	performativeTransfer    uint64 = 0x14
+	performativeDisposition uint64 = 0x15
+	performativeDetach      uint64 = 0x16
+	performativeEnd         uint64 = 0x17
+	performativeClose       uint64 = 0x18
+
+	saslMechanisms uint64 = 0x40
+	saslInit       uint64 = 0x41
+	saslOutcome    uint64 = 0x44
+
+	descriptorError  uint64 = 0x1d
+	descriptorSource uint64 = 0x28
+	descriptorTarget uint64 = 0x29
+
+	stateAccepted uint64 = 0x24
+	stateRejected uint64 = 0x25
+	stateReleased uint64 = 0x26
+	stateModified uint64 = 0x27
+)
+
+const (
+	frameAMQP = 0
+	frameSASL = 1
+
+	roleSender   = false
+	roleReceiver = true
+)
+
+var (
+	headerAMQP = []byte{'A', 'M', 'Q', 'P', 0, 1, 0, 0}
+	headerSASL = []byte{'A', 'M', 'Q', 'P', 3, 1, 0, 0}
+)
+
+var (
+	ErrClosed   = errors.New("AMQP connection closed")
+	ErrTimeout  = errors.New("AMQP operation timed out")
+	ErrReleased = errors.New("AMQP message released by broker")
+)
+
+// Error is error condition sent by peer, e.g. when link or connection is refused
+type Error struct {
+	Condition   Symbol
+	Description string
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return str.Concat("AMQP error ", e.Condition)
+	}
+
+	return str.Concat("AMQP error ", e.Condition, ": ", e.Description)
+}
+
+// Conn is connection with single session, links of the session are created by NewSender and NewReceiver
+type Conn struct {
+	l    *sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+
+	remoteMaxFrame uint32
+	handle         uint32
+	links          map[string]*link
+	remoteLinks    map[uint32]*link
+
+	deliveryID     uint32
+	nextOutgoingID uint32
+	nextIncomingID uint32
+	outcomes       map[uint32]chan error
+
+	done chan struct{}
+	err  error
+}
+
+// link is sender or receiver link attached to address
+type link struct {
+	name     string
+	handle   uint32
+	role     bool
+	address  string
+	attached chan error
+	detached chan struct{}
+
+	//sender credit and receiver delivery state
+	credit        uint32
+	deliveryCount uint32
+	creditChanged chan struct{}
+	handler       func(*Message)
+	partial       *bytes.Buffer
+	partialID     uint32
+	settled       bool
+	closing       bool
+}
+
+// Dial connects to amqp[s]://[user:password@]host[:port], user is authenticated by SASL PLAIN, anonymous
+// otherwise. ContainerID identifies client to broker.
+func Dial(rawurl, containerID string) (*Conn, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: CONNECT_TIMEOUT}
+
+	switch u.Scheme {
+	case "amqp":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), DEFAULT_PORT)
+		}
+		conn, err = dialer.Dial("tcp", u.Host)
+	case "amqps":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), DEFAULT_TLS_PORT)
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", u.Host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, errors.New(str.Concat("Unsupported AMQP URL scheme: ", u.Scheme))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		l:              &sync.Mutex{},
+		conn:           conn,
+		w:              bufio.NewWriter(conn),
+		remoteMaxFrame: 512,
+		links:          make(map[string]*link),
+		remoteLinks:    make(map[uint32]*link),
+		outcomes:       make(map[uint32]chan error),
+		done:           make(chan struct{}),
+	}
+
+	conn.SetDeadline(time.Now().Add(CONNECT_TIMEOUT))
+	r := bufio.NewReader(conn)
+
+	if err = c.authenticate(r, u); err == nil {
+		err = c.open(r, u.Hostname(), containerID)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	go c.read(r)
+
+	return c, nil
+}
+
+func (c *Conn) authenticate(r *bufio.Reader, u *url.URL) error {
+	if _, err := c.conn.Write(headerSASL); err != nil {
+		return err
+	}
+	if err := readHeader(r, headerSASL); err != nil {
+		return err
+	}
+
+	_, mechanisms, err := readFrame(r)
+	if err != nil {
+		return err
+	}
+	if descriptor(mechanisms) != saslMechanisms {
+		return errors.New("AMQP SASL mechanisms expected")
+	}
+
+	offered := make(map[Symbol]bool)
+	switch m := field(fields(mechanisms), 0).(type) {
+	case Symbol:
+		offered[m] = true
+	case []interface{}:
+		for _, s := range m {
+			if s, ok := s.(Symbol); ok {
+				offered[s] = true
+			}
+		}
+	}
+
+	var init []interface{}
+	if u.User != nil && offered["PLAIN"] {
+		password, _ := u.User.Password()
+		response := str.Concat("\x00", u.User.Username(), "\x00", password)
+		init = []interface{}{Symbol("PLAIN"), []byte(response), u.Hostname()}
+	} else if offered["ANONYMOUS"] {
+		init = []interface{}{Symbol("ANONYMOUS"), []byte{}, u.Hostname()}
+	} else {
+		return errors.New("AMQP broker offers no supported SASL mechanism")
+	}
+
+	if err = c.writeFrame(frameSASL, 0, &Described{Descriptor: saslInit, Value: init}, nil); err != nil {
+		return err
+	}
+
+	_, outcome, err := readFrame(r)
+	if err != nil {
+		return err
+	}
+	if descriptor(outcome) != saslOutcome {
+		return errors.New("AMQP SASL outcome expected")
+	}
+	if code, _ := field(fields(outcome), 0).(uint8); code != 0 {
+		return errors.New(str.Concat("AMQP authentication failed with code ", code))
+	}
+
+	return nil
+}
+
+// open opens connection and begins its session
+func (c *Conn) open(r *bufio.Reader, hostname, containerID string) error {
+	if _, err := c.conn.Write(headerAMQP); err != nil {
+		return err
+	}
+	if err := readHeader(r, headerAMQP); err != nil {
+		return err
+	}
+
+	open := []interface{}{containerID, hostname, uint32(MAX_FRAME), uint16(0)}
+	if err := c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeOpen, Value: open}, nil); err != nil {
+		return err
+	}
+
+	begin := []interface{}{nil, uint32(0), uint32(math32), uint32(math32)}
+	if err := c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeBegin, Value: begin}, nil); err != nil {
+		return err
+	}
+
+	var opened, begun bool
+	for !opened || !begun {
+		_, p, err := readFrame(r)
+		if err != nil {
+			return err
+		}
+
+		f := fields(p)
+		switch descriptor(p) {
+		case performativeOpen:
+			opened = true
+			if size, ok := field(f, 2).(uint32); ok && size >= 512 {
+				c.remoteMaxFrame = size
+			}
+			if idle, ok := field(f, 4).(uint32); ok && idle > 0 {
+				go c.keepAlive(time.Duration(idle) * time.Millisecond / 2)
+			}
+		case performativeBegin:
+			begun = true
+			next, _ := field(f, 1).(uint32)
+			c.nextIncomingID = next
+		case performativeClose:
+			return closeError(f)
+		}
+	}
+
+	return nil
+}
+
+// keepAlive sends empty frames, so broker requiring idle timeout does not close idle connection
+func (c *Conn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if c.writeFrame(frameAMQP, 0, nil, nil) != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// read handles frames of peer until connection fails or is closed
+func (c *Conn) read(r *bufio.Reader) {
+	var err error
+	defer func() {
+		c.shutdown(err)
+	}()
+
+	for {
+		var p *Described
+		var payload []byte
+		payload, p, err = readFrame(r)
+		if err != nil {
+			return
+		}
+
+		//empty frames keep connection alive
+		if p == nil {
+			continue
+		}
+
+		f := fields(p)
+		switch descriptor(p) {
+		case performativeAttach:
+			c.attached(f)
+		case performativeFlow:
+			c.flowed(f)
+		case performativeTransfer:
+			c.transferred(f, payload)
+		case performativeDisposition:
+			c.disposed(f)
+		case performativeDetach:
+			c.detached(f)
+		case performativeEnd, performativeClose:
+			err = closeError(f)
+			if err == nil {
+				err = ErrClosed
+			}
+			c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeClose, Value: []interface{}{}}, nil)
+			return
+		}
+	}
+}
+
+func (c *Conn) attached(f []interface{}) {
+	name, _ := field(f, 0).(string)
+	remote, _ := field(f, 1).(uint32)
+
+	c.l.Lock()
+	l, ok := c.links[name]
+	if ok {
+		c.remoteLinks[remote] = l
+	}
+	c.l.Unlock()
+
+	if !ok {
+		return
+	}
+
+	//refused link is attached without terminus of broker and detached with error right after
+	if (l.role == roleSender && field(f, 6) == nil) || (l.role == roleReceiver && field(f, 5) == nil) {
+		return
+	}
+
+	l.attached <- nil
+}
+
+func (c *Conn) flowed(f []interface{}) {
+	remote, ok := field(f, 4).(uint32)
+	if !ok {
+		return
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	l, ok := c.remoteLinks[remote]
+	if !ok || l.role != roleSender {
+		return
+	}
+
+	//credit of sender is delivery count of receiver plus its credit minus own delivery count
+	receiverCount, hasCount := field(f, 5).(uint32)
+	credit, _ := field(f, 6).(uint32)
+	if !hasCount {
+		receiverCount = 0
+	}
+	l.credit = receiverCount + credit - l.deliveryCount
+
+	select {
+	case l.creditChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Conn) transferred(f []interface{}, payload []byte) {
+	remote, _ := field(f, 0).(uint32)
+
+	c.l.Lock()
+	c.nextIncomingID++
+	l, ok := c.remoteLinks[remote]
+	c.l.Unlock()
+
+	if !ok || l.role != roleReceiver {
+		return
+	}
+
+	//first frame of delivery carries its ID, frames with more flag are continued by next frame
+	if id, ok := field(f, 1).(uint32); ok && l.partial == nil {
+		l.partialID = id
+		l.partial = &bytes.Buffer{}
+		l.settled, _ = field(f, 4).(bool)
+	}
+	if l.partial == nil {
+		return
+	}
+	l.partial.Write(payload)
+
+	if more, _ := field(f, 5).(bool); more {
+		return
+	}
+
+	data := l.partial.Bytes()
+	id, settled := l.partialID, l.settled
+	l.partial = nil
+
+	var state uint64 = stateAccepted
+	msg, err := decodeMessage(data)
+	if err != nil {
+		state = stateRejected
+	}
+
+	if !settled {
+		disposition := []interface{}{roleReceiver, id, nil, true, &Described{Descriptor: state, Value: []interface{}{}}}
+		c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeDisposition, Value: disposition}, nil)
+	}
+
+	c.l.Lock()
+	l.deliveryCount++
+	l.credit--
+	replenish := l.credit < LINK_CREDIT/2
+	c.l.Unlock()
+
+	if replenish {
+		c.grant(l)
+	}
+
+	if err == nil {
+		l.handler(msg)
+	}
+}
+
+func (c *Conn) disposed(f []interface{}) {
+	if role, _ := field(f, 0).(bool); role != roleReceiver {
+		return
+	}
+
+	first, _ := field(f, 1).(uint32)
+	last, ok := field(f, 2).(uint32)
+	if !ok {
+		last = first
+	}
+
+	var outcome error
+	if state, ok := field(f, 4).(*Described); ok {
+		switch descriptor(state) {
+		case stateRejected:
+			outcome = closeError(fields(state))
+			if outcome == nil {
+				outcome = &Error{Condition: "amqp:rejected"}
+			}
+		case stateReleased, stateModified:
+			outcome = ErrReleased
+		}
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	for id := first; ; id++ {
+		if ch, ok := c.outcomes[id]; ok {
+			delete(c.outcomes, id)
+			ch <- outcome
+		}
+		if id == last {
+			break
+		}
+	}
+}
+
+func (c *Conn) detached(f []interface{}) {
+	remote, _ := field(f, 0).(uint32)
+
+	c.l.Lock()
+	l, ok := c.remoteLinks[remote]
+	delete(c.remoteLinks, remote)
+	if ok {
+		delete(c.links, l.name)
+	}
+	closing := ok && l.closing
+	c.l.Unlock()
+
+	if !ok {
+		return
+	}
+
+	err := closeError(f)
+	if err == nil {
+		err = ErrClosed
+	}
+
+	select {
+	case l.attached <- err:
+	default:
+	}
+
+	//link detached by broker is detached by client too
+	if closed, _ := field(f, 1).(bool); closed && !closing {
+		c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeDetach, Value: []interface{}{l.handle, true}}, nil)
+	}
+	close(l.detached)
+}
+
+// closeError reads error field of close, end or detach performative or rejected state
+func closeError(f []interface{}) error {
+	for _, v := range f {
+		e, ok := v.(*Described)
+		if !ok || descriptor(e) != descriptorError {
+			continue
+		}
+
+		ef := fields(e)
+		condition, _ := field(ef, 0).(Symbol)
+		description, _ := field(ef, 1).(string)
+		return &Error{Condition: condition, Description: description}
+	}
+
+	return nil
+}
+
+func (c *Conn) shutdown(err error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	c.err = err
+	close(c.done)
+	c.conn.Close()
+
+	for id, ch := range c.outcomes {
+		delete(c.outcomes, id)
+		ch <- ErrClosed
+	}
+	for _, l := range c.links {
+		select {
+		case l.attached <- ErrClosed:
+		default:
+		}
+		close(l.detached)
+	}
+	c.links = make(map[string]*link)
+	c.remoteLinks = make(map[uint32]*link)
+}
+
+// Done is closed when connection is closed or lost, Err returns the reason
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Conn) Err() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.err
+}
+
+// Close closes connection, its links are detached
+func (c *Conn) Close() error {
+	c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeClose, Value: []interface{}{}}, nil)
+	c.shutdown(ErrClosed)
+
+	return nil
+}
+
+// attach attaches new link of role to address and waits until broker attaches it too
+func (c *Conn) attach(role bool, address string, handler func(*Message)) (*link, error) {
+	id, _ := sec.UUID4()
+
+	c.l.Lock()
+	select {
+	case <-c.done:
+		c.l.Unlock()
+		return nil, ErrClosed
+	default:
+	}
+
+	l := &link{
+		name:          str.Concat(address, "-", id),
+		handle:        c.handle,
+		role:          role,
+		address:       address,
+		attached:      make(chan error, 1),
+		detached:      make(chan struct{}),
+		creditChanged: make(chan struct{}, 1),
+		handler:       handler,
+	}
+	c.handle++
+	c.links[l.name] = l
+	c.l.Unlock()
+
+	source := &Described{Descriptor: descriptorSource, Value: []interface{}{address}}
+	target := &Described{Descriptor: descriptorTarget, Value: []interface{}{address}}
+
+	//deliveries are unsettled until receiver decides outcome, receiver settles first
+	attach := []interface{}{l.name, l.handle, role, uint8(0), uint8(0), source, target}
+	if role == roleSender {
+		attach = append(attach, nil, false, uint32(0))
+	}
+
+	if err := c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeAttach, Value: attach}, nil); err != nil {
+		return nil, err
+	}
+
+	select {
+	case err := <-l.attached:
+		if err != nil {
+			return nil, err
+		}
+	case <-time.After(CONNECT_TIMEOUT):
+		return nil, ErrTimeout
+	}
+
+	if role == roleReceiver {
+		l.credit = 0
+		c.grant(l)
+	}
+
+	return l, nil
+}
+
+// grant renews credit of receiver link to LINK_CREDIT
+func (c *Conn) grant(l *link) {
+	c.l.Lock()
+	l.credit = LINK_CREDIT
+	flow := []interface{}{c.nextIncomingID, uint32(math32), c.nextOutgoingID, uint32(math32), l.handle, l.deliveryCount, uint32(LINK_CREDIT)}
+	c.l.Unlock()
+
+	c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeFlow, Value: flow}, nil)
+}
+
+func (c *Conn) detach(l *link) error {
+	c.l.Lock()
+	_, ok := c.links[l.name]
+	l.closing = true
+	c.l.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	err := c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeDetach, Value: []interface{}{l.handle, true}}, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-l.detached:
+	case <-time.After(CONNECT_TIMEOUT):
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+// Sender sends messages to address
+type Sender struct {
+	c *Conn
+	l *link
+}
+
+// NewSender attaches sender link to address
+func (c *Conn) NewSender(address string) (*Sender, error) {
+	l, err := c.attach(roleSender, address, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sender{c: c, l: l}, nil
+}
+
+// Send sends message and waits until broker accepts it, message refused by broker is returned as error
+func (s *Sender) Send(msg *Message, timeout time.Duration) error {
+	data, err := msg.encode()
+	if err != nil {
+		return err
+	}
+
+	deadline := time.After(timeout)
+	c := s.c
+
+	//message is sent when receiver grants credit
+	for {
+		c.l.Lock()
+		credit := s.l.credit
+		c.l.Unlock()
+
+		if credit > 0 {
+			break
+		}
+
+		select {
+		case <-s.l.creditChanged:
+		case <-s.l.detached:
+			return ErrClosed
+		case <-deadline:
+			return ErrTimeout
+		}
+	}
+
+	outcome := make(chan error, 1)
+
+	c.l.Lock()
+	id := c.deliveryID
+	c.deliveryID++
+	s.l.credit--
+	s.l.deliveryCount++
+	c.outcomes[id] = outcome
+	maxPayload := int(c.remoteMaxFrame) - 256
+	c.l.Unlock()
+
+	tag := make([]byte, 4)
+	binary.BigEndian.PutUint32(tag, id)
+
+	//message larger than frame of broker is split to frames of one delivery
+	for first := true; first || len(data) > 0; first = false {
+		chunk := data
+		if len(chunk) > maxPayload {
+			chunk = chunk[:maxPayload]
+		}
+		data = data[len(chunk):]
+
+		transfer := []interface{}{s.l.handle, nil, nil, nil, false, len(data) > 0}
+		if first {
+			transfer = []interface{}{s.l.handle, id, tag, uint32(0), false, len(data) > 0}
+		}
+
+		if err = c.writeFrame(frameAMQP, 0, &Described{Descriptor: performativeTransfer, Value: transfer}, chunk); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case err = <-outcome:
+		return err
+	case <-deadline:
+		c.l.Lock()
+		delete(c.outcomes, id)
+		c.l.Unlock()
+		return ErrTimeout
+	}
+}
+
+// Close detaches sender link
+func (s *Sender) Close() error {
+	return s.c.detach(s.l)
+}
+
+// Receiver receives messages of address, they are accepted before handler is called
+type Receiver struct {
+	c *Conn
+	l *link
+}
+
+// NewReceiver attaches receiver link to address, handler is called by reading goroutine in order of messages
+func (c *Conn) NewReceiver(address string, handler func(*Message)) (*Receiver, error) {
+	l, err := c.attach(roleReceiver, address, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Receiver{c: c, l: l}, nil
+}
+
+// Close detaches receiver link
+func (r *Receiver) Close() error {
+	return r.c.detach(r.l)
+}
+
+// math32 is largest session window
+const math32 = 1<<32 - 1
+
+func (c *Conn) writeFrame(frameType uint8, channel uint16, body *Described, payload []byte) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := encode(&buf, body); err != nil {
+			return err
+		}
+	}
+	buf.Write(payload)
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+buf.Len()))
+	header[4] = 2
+	header[5] = frameType
+	binary.BigEndian.PutUint16(header[6:], channel)
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if frameType == frameAMQP && body != nil && descriptor(body) == performativeTransfer {
+		c.nextOutgoingID++
+	}
+
+	c.w.Write(header)
+	c.w.Write(buf.Bytes())
+	return c.w.Flush()
+}
+
+// readFrame reads frame, returns performative and payload following it. Empty frame has no performative.
+func readFrame(r *bufio.Reader) ([]byte, *Described, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	doff := int(header[4]) * 4
+	if size > MAX_FRAME || int(size) < doff || doff < 8 {
+		return nil, nil, errors.New(str.Concat("Invalid AMQP frame size: ", strconv.FormatUint(uint64(size), 10)))
+	}
+
+	frame := make([]byte, size-8)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, nil, err
+	}
+
+	body := frame[doff-8:]
+	if len(body) == 0 {
+		return nil, nil, nil
+	}
+
+	d := &decoder{data: body}
+	v, err := d.value()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, ok := v.(*Described)
+	if !ok {
+		return nil, nil, errors.New("AMQP performative expected")
+	}
+
+	return body[d.pos:], p, nil
+}
+
+func readHeader(r *bufio.Reader, expected []byte) error {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(header, expected) {
+		return errors.New(str.Concat("Unsupported AMQP protocol header: ", string(header[:4]), header[4:]))
+	}
+
+	return nil
+}
+
+func fields(d *Described) []interface{} {
+	if d == nil {
+		return nil
+	}
+
+	f, _ := d.Value.([]interface{})
+	return f
+}
@@ -0,0 +1,275 @@
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+)
+
+func TestCaseTypes(t *testing.T) {
+	stamp := time.Unix(1500000000, 123000000).UTC()
+	value := &Described{Descriptor: uint64(0x12), Value: []interface{}{
+		"link", uint32(7), true, uint8(1), nil, Symbol("sym"), []byte{1, 2}, int64(-5), 2.5, stamp,
+		map[string]interface{}{"k": "v"}, []Symbol{"PLAIN", "ANONYMOUS"}, uint64(1 << 40), strings.Repeat("x", 300),
+	}}
+
+	var buf bytes.Buffer
+	Equals("encoded", t, nil, encode(&buf, value))
+
+	decoded, err := decode(buf.Bytes())
+	Equals("decoded", t, nil, err)
+
+	d := decoded.(*Described)
+	f := fields(d)
+	Equals("descriptor", t, uint64(0x12), descriptor(d))
+	Equals("string", t, "link", f[0])
+	Equals("uint", t, uint32(7), f[1])
+	Equals("bool", t, true, f[2])
+	Equals("ubyte", t, uint8(1), f[3])
+	Equals("null", t, nil, f[4])
+	Equals("symbol", t, Symbol("sym"), f[5])
+	Equals("binary", t, true, bytes.Equal([]byte{1, 2}, f[6].([]byte)))
+	Equals("long", t, int64(-5), f[7])
+	Equals("double", t, 2.5, f[8])
+	Equals("timestamp", t, true, stamp.Equal(f[9].(time.Time)))
+	Equals("map", t, "v", f[10].(map[interface{}]interface{})["k"])
+	Equals("array", t, Symbol("ANONYMOUS"), f[11].([]interface{})[1])
+	Equals("ulong", t, uint64(1<<40), f[12])
+	Equals("str32", t, 300, len(f[13].(string)))
+
+	_, err = decode(buf.Bytes()[:buf.Len()-1])
+	Equals("truncated", t, errTruncated, err)
+}
+
+func TestCaseMessage(t *testing.T) {
+	m := &Message{
+		MessageID:   "rq-1",
+		Subject:     "property/temp",
+		ReplyTo:     "replies",
+		ContentType: "application/json",
+		Properties:  map[string]interface{}{"status": int32(200)},
+		Data:        []byte(`{"value":1}`),
+	}
+
+	data, err := m.encode()
+	Equals("encoded", t, nil, err)
+
+	decoded, err := decodeMessage(data)
+	Equals("decoded", t, nil, err)
+	Equals("id", t, "rq-1", decoded.MessageID)
+	Equals("subject", t, "property/temp", decoded.Subject)
+	Equals("reply to", t, "replies", decoded.ReplyTo)
+	Equals("content type", t, Symbol("application/json"), decoded.ContentType)
+	Equals("property", t, int32(200), decoded.Properties["status"])
+	Equals("data", t, `{"value":1}`, string(decoded.Data))
+}
+
+func TestCaseSendReceive(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	c, err := Dial(str.Concat("amqp://", b.Addr().String()), "test")
+	Equals("connected", t, nil, err)
+	defer c.Close()
+
+	received := make(chan *Message, 4)
+	r, err := c.NewReceiver("wot/dev-1", func(m *Message) { received <- m })
+	Equals("receiver", t, nil, err)
+
+	s, err := c.NewSender("wot/dev-1")
+	Equals("sender", t, nil, err)
+
+	Equals("sent", t, nil, s.Send(&Message{Subject: "td", Data: []byte("small")}, time.Second))
+
+	//larger than frame of broker
+	large := bytes.Repeat([]byte("x"), 2000)
+	Equals("sent large", t, nil, s.Send(&Message{Subject: "large", Data: large}, time.Second))
+
+	m := next(received)
+	Equals("subject", t, "td", m.Subject)
+	Equals("data", t, "small", string(m.Data))
+	Equals("large", t, 2000, len(next(received).Data))
+
+	rejecting, _ := c.NewSender("reject")
+	err = rejecting.Send(&Message{Data: []byte("x")}, time.Second)
+	var amqpErr *Error
+	Equals("rejected", t, true, errors.As(err, &amqpErr))
+	Equals("condition", t, Symbol("amqp:not-allowed"), amqpErr.Condition)
+
+	Equals("receiver closed", t, nil, r.Close())
+	Equals("sender closed", t, nil, s.Close())
+
+	c.Close()
+	_, err = c.NewSender("wot/dev-1")
+	Equals("closed", t, ErrClosed, err)
+}
+
+func TestCaseAuthentication(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	_, err := Dial(str.Concat("amqp://guest:wrong@", b.Addr().String()), "test")
+	Equals("refused", t, true, err != nil)
+
+	c, err := Dial(str.Concat("amqp://guest:guest@", b.Addr().String()), "test")
+	Equals("authenticated", t, nil, err)
+	c.Close()
+}
+
+func next(ch chan *Message) *Message {
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(time.Second):
+		return &Message{}
+	}
+}
+
+// broker is minimal AMQP peer of single client, messages are routed to receivers of the same address.
+// It accepts guest:guest or anonymous clients, refuses messages sent to "reject" and limits frames to 512 bytes.
+type broker struct {
+	net.Listener
+}
+
+func newBroker(t *testing.T) *broker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return &broker{Listener: ln}
+}
+
+type brokerLink struct {
+	handle  uint32
+	address string
+}
+
+func serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := &Conn{l: &sync.Mutex{}, w: bufio.NewWriter(conn)}
+	send := func(code uint64, f []interface{}, payload []byte) {
+		w.writeFrame(frameAMQP, 0, &Described{Descriptor: code, Value: f}, payload)
+	}
+
+	if readHeader(r, headerSASL) != nil {
+		return
+	}
+	conn.Write(headerSASL)
+	w.writeFrame(frameSASL, 0, &Described{Descriptor: saslMechanisms, Value: []interface{}{[]Symbol{"ANONYMOUS", "PLAIN"}}}, nil)
+
+	_, init, err := readFrame(r)
+	if err != nil {
+		return
+	}
+	code := uint8(0)
+	if mechanism := field(fields(init), 0); mechanism == Symbol("PLAIN") && string(field(fields(init), 1).([]byte)) != "\x00guest\x00guest" {
+		code = 1
+	}
+	w.writeFrame(frameSASL, 0, &Described{Descriptor: saslOutcome, Value: []interface{}{code}}, nil)
+	if code != 0 {
+		return
+	}
+
+	if readHeader(r, headerAMQP) != nil {
+		return
+	}
+	conn.Write(headerAMQP)
+
+	links := make(map[uint32]*brokerLink)
+	var handle, deliveryID uint32
+	var partial bytes.Buffer
+	var partialID interface{}
+
+	for {
+		payload, p, err := readFrame(r)
+		if err != nil {
+			return
+		}
+
+		f := fields(p)
+		switch descriptor(p) {
+		case performativeOpen:
+			send(performativeOpen, []interface{}{"broker", nil, uint32(512)}, nil)
+		case performativeBegin:
+			send(performativeBegin, []interface{}{uint16(0), uint32(1), uint32(math32), uint32(math32)}, nil)
+		case performativeAttach:
+			name := field(f, 0).(string)
+			remote := field(f, 1).(uint32)
+			role := field(f, 2).(bool)
+			address := field(fields(field(f, 5).(*Described)), 0).(string)
+			if role == roleSender {
+				address = field(fields(field(f, 6).(*Described)), 0).(string)
+			}
+
+			links[remote] = &brokerLink{handle: handle, address: address}
+			send(performativeAttach, []interface{}{name, handle, !role, uint8(0), uint8(0), field(f, 5), field(f, 6), nil, false, uint32(0)}, nil)
+			if role == roleSender {
+				send(performativeFlow, []interface{}{uint32(0), uint32(math32), uint32(1), uint32(math32), handle, uint32(0), uint32(10)}, nil)
+			}
+			handle++
+		case performativeTransfer:
+			if id := field(f, 1); id != nil {
+				partialID = id
+			}
+			partial.Write(payload)
+			if more, _ := field(f, 5).(bool); more {
+				continue
+			}
+
+			from := links[field(f, 0).(uint32)]
+			if from.address == "reject" {
+				rejected := &Described{Descriptor: stateRejected, Value: []interface{}{
+					&Described{Descriptor: descriptorError, Value: []interface{}{Symbol("amqp:not-allowed"), "refused"}},
+				}}
+				send(performativeDisposition, []interface{}{roleReceiver, partialID, nil, true, rejected}, nil)
+				partial.Reset()
+				continue
+			}
+
+			for _, l := range links {
+				if l.address == from.address && l != from {
+					send(performativeTransfer, []interface{}{l.handle, deliveryID, []byte{byte(deliveryID)}, uint32(0), false, false}, partial.Bytes())
+					deliveryID++
+				}
+			}
+			partial.Reset()
+
+			accepted := &Described{Descriptor: stateAccepted, Value: []interface{}{}}
+			send(performativeDisposition, []interface{}{roleReceiver, partialID, nil, true, accepted}, nil)
+		case performativeDetach:
+			l := links[field(f, 0).(uint32)]
+			delete(links, field(f, 0).(uint32))
+			send(performativeDetach, []interface{}{l.handle, true}, nil)
+		case performativeClose:
+			send(performativeClose, []interface{}{}, nil)
+			return
+		}
+	}
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,156 @@
+package amqp
+
+import (
+	"bytes"
+	"errors"
+)
+
+// descriptors of message sections
+const (
+	sectionHeader                uint64 = 0x70
+	sectionDeliveryAnnotations   uint64 = 0x71
+	sectionMessageAnnotations    uint64 = 0x72
+	sectionProperties            uint64 = 0x73
+	sectionApplicationProperties uint64 = 0x74
+	sectionData                  uint64 = 0x75
+	sectionSequence              uint64 = 0x76
+	sectionValue                 uint64 = 0x77
+	sectionFooter                uint64 = 0x78
+)
+
+// Message is AMQP message of properties, application properties and body. Body is sent as data
+// section, received amqp-value body of string or binary is read to Data too, other values to Value.
+type Message struct {
+	MessageID     interface{}
+	To            string
+	Subject       string
+	ReplyTo       string
+	CorrelationID interface{}
+	ContentType   Symbol
+	Properties    map[string]interface{}
+	Data          []byte
+	Value         interface{}
+}
+
+func (m *Message) encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	props := []interface{}{
+		m.MessageID,
+		nil,
+		optional(m.To),
+		optional(m.Subject),
+		optional(m.ReplyTo),
+		m.CorrelationID,
+	}
+	if m.ContentType != "" {
+		props = append(props, m.ContentType)
+	}
+
+	if err := encode(&buf, &Described{Descriptor: sectionProperties, Value: props}); err != nil {
+		return nil, err
+	}
+
+	if len(m.Properties) > 0 {
+		if err := encode(&buf, &Described{Descriptor: sectionApplicationProperties, Value: m.Properties}); err != nil {
+			return nil, err
+		}
+	}
+
+	body := &Described{Descriptor: sectionData, Value: m.Data}
+	if m.Data == nil {
+		body = &Described{Descriptor: sectionValue, Value: m.Value}
+	}
+	if err := encode(&buf, body); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// optional encodes empty string as null
+func optional(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+func decodeMessage(data []byte) (*Message, error) {
+	m := &Message{}
+	d := &decoder{data: data}
+
+	for d.more() {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		section, ok := v.(*Described)
+		if !ok {
+			return nil, errors.New("AMQP message section expected")
+		}
+
+		switch descriptor(section) {
+		case sectionProperties:
+			fields, _ := section.Value.([]interface{})
+			m.MessageID = field(fields, 0)
+			m.To, _ = field(fields, 2).(string)
+			m.Subject, _ = field(fields, 3).(string)
+			m.ReplyTo, _ = field(fields, 4).(string)
+			m.CorrelationID = field(fields, 5)
+			m.ContentType, _ = field(fields, 6).(Symbol)
+		case sectionApplicationProperties:
+			props, _ := section.Value.(map[interface{}]interface{})
+			m.Properties = make(map[string]interface{}, len(props))
+			for k, v := range props {
+				if key, ok := k.(string); ok {
+					m.Properties[key] = v
+				}
+			}
+		case sectionData:
+			b, _ := section.Value.([]byte)
+			m.Data = append(m.Data, b...)
+		case sectionValue:
+			switch v := section.Value.(type) {
+			case []byte:
+				m.Data = v
+			case string:
+				m.Data = []byte(v)
+			default:
+				m.Value = v
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// descriptor returns numeric descriptor of described value, symbolic descriptors are not used by peers
+// of this client and are returned as 0 as well as descriptor of missing value
+func descriptor(d *Described) uint64 {
+	if d == nil {
+		return 0
+	}
+
+	switch code := d.Descriptor.(type) {
+	case uint64:
+		return code
+	case uint32:
+		return uint64(code)
+	case uint8:
+		return uint64(code)
+	}
+
+	return 0
+}
+
+// field returns i-th field of described list, omitted trailing fields are nil
+func field(fields []interface{}, i int) interface{} {
+	if i < len(fields) {
+		return fields[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,521 @@
+package amqp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+)
+
+// AMQP 1.0 type system, values are encoded from and decoded to Go values:
+//
+//	null nil, boolean bool, ubyte uint8, ushort uint16, uint uint32, ulong uint64, byte int8, short int16,
+//	int int32, long int64, float float32, double float64, timestamp time.Time, binary []byte,
+//	string string, symbol Symbol, list []interface{}, map map[interface{}]interface{},
+//	array of symbols []Symbol, described type *Described
+//
+// Decoded arrays are []interface{}, decimal, char and uuid types are not supported.
+
+// Symbol is AMQP symbolic value, e.g. name of SASL mechanism
+type Symbol string
+
+// Described is value with descriptor, performatives and message sections are described lists
+type Described struct {
+	Descriptor interface{}
+	Value      interface{}
+}
+
+const (
+	typeDescribed  = 0x00
+	typeNull       = 0x40
+	typeTrue       = 0x41
+	typeFalse      = 0x42
+	typeUint0      = 0x43
+	typeUlong0     = 0x44
+	typeList0      = 0x45
+	typeUbyte      = 0x50
+	typeByte       = 0x51
+	typeSmallUint  = 0x52
+	typeSmallUlong = 0x53
+	typeSmallInt   = 0x54
+	typeSmallLong  = 0x55
+	typeBool       = 0x56
+	typeUshort     = 0x60
+	typeShort      = 0x61
+	typeUint       = 0x70
+	typeInt        = 0x71
+	typeFloat      = 0x72
+	typeUlong      = 0x80
+	typeLong       = 0x81
+	typeDouble     = 0x82
+	typeTimestamp  = 0x83
+	typeVbin8      = 0xa0
+	typeStr8       = 0xa1
+	typeSym8       = 0xa3
+	typeVbin32     = 0xb0
+	typeStr32      = 0xb1
+	typeSym32      = 0xb3
+	typeList8      = 0xc0
+	typeMap8       = 0xc1
+	typeList32     = 0xd0
+	typeMap32      = 0xd1
+	typeArray8     = 0xe0
+	typeArray32    = 0xf0
+)
+
+var errTruncated = errors.New("AMQP value truncated")
+
+// encode appends encoding of v to buf
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(typeNull)
+	case bool:
+		if v {
+			buf.WriteByte(typeTrue)
+		} else {
+			buf.WriteByte(typeFalse)
+		}
+	case uint8:
+		buf.WriteByte(typeUbyte)
+		buf.WriteByte(v)
+	case uint16:
+		buf.WriteByte(typeUshort)
+		binary.Write(buf, binary.BigEndian, v)
+	case uint32:
+		switch {
+		case v == 0:
+			buf.WriteByte(typeUint0)
+		case v < 256:
+			buf.WriteByte(typeSmallUint)
+			buf.WriteByte(uint8(v))
+		default:
+			buf.WriteByte(typeUint)
+			binary.Write(buf, binary.BigEndian, v)
+		}
+	case uint64:
+		switch {
+		case v == 0:
+			buf.WriteByte(typeUlong0)
+		case v < 256:
+			buf.WriteByte(typeSmallUlong)
+			buf.WriteByte(uint8(v))
+		default:
+			buf.WriteByte(typeUlong)
+			binary.Write(buf, binary.BigEndian, v)
+		}
+	case int8:
+		buf.WriteByte(typeByte)
+		buf.WriteByte(uint8(v))
+	case int16:
+		buf.WriteByte(typeShort)
+		binary.Write(buf, binary.BigEndian, v)
+	case int32:
+		buf.WriteByte(typeInt)
+		binary.Write(buf, binary.BigEndian, v)
+	case int64:
+		buf.WriteByte(typeLong)
+		binary.Write(buf, binary.BigEndian, v)
+	case int:
+		return encode(buf, int64(v))
+	case float32:
+		buf.WriteByte(typeFloat)
+		binary.Write(buf, binary.BigEndian, math.Float32bits(v))
+	case float64:
+		buf.WriteByte(typeDouble)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case time.Time:
+		buf.WriteByte(typeTimestamp)
+		binary.Write(buf, binary.BigEndian, v.UnixNano()/int64(time.Millisecond))
+	case []byte:
+		writeVariable(buf, typeVbin8, typeVbin32, v)
+	case string:
+		writeVariable(buf, typeStr8, typeStr32, []byte(v))
+	case Symbol:
+		writeVariable(buf, typeSym8, typeSym32, []byte(v))
+	case []Symbol:
+		return encodeSymbols(buf, v)
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteByte(typeList0)
+			return nil
+		}
+		return encodeCompound(buf, typeList32, len(v), v)
+	case map[interface{}]interface{}:
+		items := make([]interface{}, 0, 2*len(v))
+		for k, value := range v {
+			items = append(items, k, value)
+		}
+		return encodeCompound(buf, typeMap32, len(items), items)
+	case map[string]interface{}:
+		items := make([]interface{}, 0, 2*len(v))
+		for k, value := range v {
+			items = append(items, k, value)
+		}
+		return encodeCompound(buf, typeMap32, len(items), items)
+	case map[Symbol]interface{}:
+		items := make([]interface{}, 0, 2*len(v))
+		for k, value := range v {
+			items = append(items, k, value)
+		}
+		return encodeCompound(buf, typeMap32, len(items), items)
+	case *Described:
+		buf.WriteByte(typeDescribed)
+		if err := encode(buf, v.Descriptor); err != nil {
+			return err
+		}
+		return encode(buf, v.Value)
+	default:
+		return errors.New(str.Concat("Unsupported AMQP value: ", v))
+	}
+
+	return nil
+}
+
+func writeVariable(buf *bytes.Buffer, code8, code32 byte, data []byte) {
+	if len(data) < 256 {
+		buf.WriteByte(code8)
+		buf.WriteByte(uint8(len(data)))
+	} else {
+		buf.WriteByte(code32)
+		binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	}
+	buf.Write(data)
+}
+
+// encodeCompound encodes list or map in 32 bit form, size includes count
+func encodeCompound(buf *bytes.Buffer, code byte, count int, items []interface{}) error {
+	var body bytes.Buffer
+	for _, item := range items {
+		if err := encode(&body, item); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte(code)
+	binary.Write(buf, binary.BigEndian, uint32(body.Len()+4))
+	binary.Write(buf, binary.BigEndian, uint32(count))
+	buf.Write(body.Bytes())
+
+	return nil
+}
+
+// encodeSymbols encodes array of symbols, e.g. offered capabilities
+func encodeSymbols(buf *bytes.Buffer, symbols []Symbol) error {
+	var body bytes.Buffer
+	body.WriteByte(typeSym32)
+	for _, s := range symbols {
+		binary.Write(&body, binary.BigEndian, uint32(len(s)))
+		body.WriteString(string(s))
+	}
+
+	buf.WriteByte(typeArray32)
+	binary.Write(buf, binary.BigEndian, uint32(body.Len()+4))
+	binary.Write(buf, binary.BigEndian, uint32(len(symbols)))
+	buf.Write(body.Bytes())
+
+	return nil
+}
+
+// decoder reads values from encoded data
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) more() bool {
+	return d.pos < len(d.data)
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errTruncated
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *decoder) byte() (byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// size reads size of variable width value, 1 byte wide for codes 0xa_, 0xc_ and 0xe_, 4 bytes otherwise
+func (d *decoder) size(code byte) (int, error) {
+	if code&0xf0 == 0xa0 || code&0xf0 == 0xc0 || code&0xf0 == 0xe0 {
+		n, err := d.byte()
+		return int(n), err
+	}
+
+	n, err := d.uint32()
+	return int(n), err
+}
+
+func (d *decoder) value() (interface{}, error) {
+	code, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.valueOf(code)
+}
+
+func (d *decoder) valueOf(code byte) (interface{}, error) {
+	switch code {
+	case typeDescribed:
+		descriptor, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		return &Described{Descriptor: descriptor, Value: value}, nil
+	case typeNull:
+		return nil, nil
+	case typeTrue:
+		return true, nil
+	case typeFalse:
+		return false, nil
+	case typeUint0:
+		return uint32(0), nil
+	case typeUlong0:
+		return uint64(0), nil
+	case typeList0:
+		return []interface{}{}, nil
+	}
+
+	var width int
+	switch code & 0xf0 {
+	case 0x50:
+		width = 1
+	case 0x60:
+		width = 2
+	case 0x70:
+		width = 4
+	case 0x80:
+		width = 8
+	case 0x90:
+		width = 16
+	}
+
+	if width > 0 {
+		b, err := d.next(width)
+		if err != nil {
+			return nil, err
+		}
+		return fixed(code, b)
+	}
+
+	switch code {
+	case typeVbin8, typeVbin32, typeStr8, typeStr32, typeSym8, typeSym32:
+		n, err := d.size(code)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		switch code {
+		case typeStr8, typeStr32:
+			return string(b), nil
+		case typeSym8, typeSym32:
+			return Symbol(b), nil
+		}
+		return append([]byte(nil), b...), nil
+	case typeList8, typeList32, typeMap8, typeMap32:
+		return d.compound(code)
+	case typeArray8, typeArray32:
+		return d.array(code)
+	}
+
+	return nil, errors.New(str.Concat("Unsupported AMQP type code: ", int(code)))
+}
+
+func fixed(code byte, b []byte) (interface{}, error) {
+	switch code {
+	case typeUbyte:
+		return b[0], nil
+	case typeSmallUint:
+		return uint32(b[0]), nil
+	case typeSmallUlong:
+		return uint64(b[0]), nil
+	case typeByte:
+		return int8(b[0]), nil
+	case typeSmallInt:
+		return int32(int8(b[0])), nil
+	case typeSmallLong:
+		return int64(int8(b[0])), nil
+	case typeBool:
+		return b[0] != 0, nil
+	case typeUshort:
+		return binary.BigEndian.Uint16(b), nil
+	case typeShort:
+		return int16(binary.BigEndian.Uint16(b)), nil
+	case typeUint:
+		return binary.BigEndian.Uint32(b), nil
+	case typeInt:
+		return int32(binary.BigEndian.Uint32(b)), nil
+	case typeFloat:
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), nil
+	case typeUlong:
+		return binary.BigEndian.Uint64(b), nil
+	case typeLong:
+		return int64(binary.BigEndian.Uint64(b)), nil
+	case typeDouble:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case typeTimestamp:
+		ms := int64(binary.BigEndian.Uint64(b))
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+	}
+
+	return nil, errors.New(str.Concat("Unsupported AMQP type code: ", int(code)))
+}
+
+func (d *decoder) compound(code byte) (interface{}, error) {
+	size, err := d.size(code)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := d.next(size)
+	if err != nil {
+		return nil, err
+	}
+
+	inner := &decoder{data: body}
+	var count int
+	if code == typeList8 || code == typeMap8 {
+		n, err := inner.byte()
+		if err != nil {
+			return nil, err
+		}
+		count = int(n)
+	} else {
+		n, err := inner.uint32()
+		if err != nil {
+			return nil, err
+		}
+		count = int(n)
+	}
+
+	//count is bounded by size, every element takes at least one byte
+	if count > len(body) {
+		return nil, errTruncated
+	}
+
+	items := make([]interface{}, count)
+	for i := range items {
+		if items[i], err = inner.value(); err != nil {
+			return nil, err
+		}
+	}
+
+	if code == typeList8 || code == typeList32 {
+		return items, nil
+	}
+
+	if count%2 != 0 {
+		return nil, errors.New("AMQP map with odd number of items")
+	}
+
+	m := make(map[interface{}]interface{}, count/2)
+	for i := 0; i < count; i += 2 {
+		key := items[i]
+		if _, ok := key.([]byte); ok {
+			key = string(key.([]byte))
+		}
+		m[key] = items[i+1]
+	}
+
+	return m, nil
+}
+
+// array decodes array elements sharing one constructor
+func (d *decoder) array(code byte) (interface{}, error) {
+	size, err := d.size(code)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := d.next(size)
+	if err != nil {
+		return nil, err
+	}
+
+	inner := &decoder{data: body}
+	var count int
+	if code == typeArray8 {
+		n, err := inner.byte()
+		if err != nil {
+			return nil, err
+		}
+		count = int(n)
+	} else {
+		n, err := inner.uint32()
+		if err != nil {
+			return nil, err
+		}
+		count = int(n)
+	}
+
+	if count > len(body) {
+		return nil, errTruncated
+	}
+
+	element, err := inner.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptor interface{}
+	if element == typeDescribed {
+		if descriptor, err = inner.value(); err != nil {
+			return nil, err
+		}
+		if element, err = inner.byte(); err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]interface{}, count)
+	for i := range items {
+		v, err := inner.valueOf(element)
+		if err != nil {
+			return nil, err
+		}
+		if descriptor != nil {
+			v = &Described{Descriptor: descriptor, Value: v}
+		}
+		items[i] = v
+	}
+
+	return items, nil
+}
+
+// decode decodes single value of data
+func decode(data []byte) (interface{}, error) {
+	d := &decoder{data: data}
+	return d.value()
+}
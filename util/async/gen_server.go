@@ -38,7 +38,7 @@ func (gs *GenServer) Start() {
 
 func (gs *GenServer) panicHandler(err interface{}) {
 	log.Info(err)
-	gs.prom.Set(err)
+	gs.prom.Reject(PanicError(err))
 }
 
 func (gs *GenServer) Call(msgType MessageType, data interface{}) *Promise {
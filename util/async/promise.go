@@ -1,16 +1,33 @@
 package async
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
 // ----- Simple Promise
 
+var ErrTimeout = errors.New("Promise wait timeout")
+
+// Promise is settled once either with value (Set) or with error (Reject). It may be waited on
+// by any number of goroutines, all of them receive the same result.
 type Promise struct {
-	pch chan interface{}
+	done  chan struct{}
+	once  *sync.Once
+	value interface{}
+	err   error
 }
 
+// Run executes task asynchronously, panic of the task rejects the promise
 func Run(task func() interface{}) *Promise {
 	p := NewPromise()
 
 	go func() {
-		p.pch <- task()
+		defer p.recover()
+		p.Set(task())
 	}()
 
 	return p
@@ -18,26 +35,123 @@ func Run(task func() interface{}) *Promise {
 
 func NewPromise() *Promise {
 	return &Promise{
-		pch: make(chan interface{}, 1),
+		done: make(chan struct{}),
+		once: &sync.Once{},
 	}
 }
 
+// Then calls callback with promise value, rejection is passed to the returned promise without calling callback
 func (prev *Promise) Then(callback func(response interface{}) interface{}) *Promise {
 	next := NewPromise()
 
 	go func() {
-		next.pch <- callback(<-prev.pch)
+		defer next.recover()
+
+		value, err := prev.Wait()
+		if err != nil {
+			next.Reject(err)
+			return
+		}
+
+		next.Set(callback(value))
 	}()
 
 	return next
 }
 
+// Catch calls callback with rejection error, its result resolves returned promise.
+// Values of resolved promise are passed without calling callback.
+func (prev *Promise) Catch(callback func(err error) interface{}) *Promise {
+	next := NewPromise()
+
+	go func() {
+		defer next.recover()
+
+		value, err := prev.Wait()
+		if err != nil {
+			value = callback(err)
+		}
+
+		next.Set(value)
+	}()
+
+	return next
+}
+
+// Get waits for the promise and returns its value, or error the promise was rejected with
 func (p *Promise) Get() interface{} {
-	return <-p.pch
+	value, err := p.Wait()
+
+	if err != nil {
+		return err
+	}
+
+	return value
+}
+
+// Wait blocks until promise is settled
+func (p *Promise) Wait() (interface{}, error) {
+	<-p.done
+	return p.value, p.err
+}
+
+// WaitTimeout blocks until promise is settled or returns ErrTimeout after d
+func (p *Promise) WaitTimeout(d time.Duration) (interface{}, error) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-p.done:
+		return p.value, p.err
+	case <-timer.C:
+		return nil, ErrTimeout
+	}
+}
+
+// WaitCtx blocks until promise is settled or ctx is done
+func (p *Promise) WaitCtx(ctx context.Context) (interface{}, error) {
+	select {
+	case <-p.done:
+		return p.value, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Done is closed when promise is settled
+func (p *Promise) Done() <-chan struct{} {
+	return p.done
 }
 
+// Set resolves the promise with data, only first Set or Reject takes effect
 func (p *Promise) Set(data interface{}) {
-	p.pch <- data
+	p.once.Do(func() {
+		p.value = data
+		close(p.done)
+	})
+}
+
+// Reject settles the promise with err, only first Set or Reject takes effect
+func (p *Promise) Reject(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+	})
+}
+
+func (p *Promise) recover() {
+	if r := recover(); r != nil {
+		p.Reject(PanicError(r))
+	}
+}
+
+// PanicError converts recovered panic value to error
+func PanicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+
+	return fmt.Errorf("%v", r)
 }
 
 // ----- Promise With Progress Update
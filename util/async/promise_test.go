@@ -1,6 +1,11 @@
 package async
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
 
 func TestCase1(t *testing.T) {
 	r := Run(func() interface{} {
@@ -14,6 +19,45 @@ func TestCase1(t *testing.T) {
 	Equals("TestCase1", t, 14, r.(int))
 }
 
+func TestCaseWaitTimeout(t *testing.T) {
+	p := NewPromise()
+
+	_, err := p.WaitTimeout(10 * time.Millisecond)
+	Equals("TestCaseWaitTimeout", t, ErrTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.WaitCtx(ctx)
+	Equals("TestCaseWaitCtx", t, context.Canceled, err)
+
+	p.Set(1)
+	p.Set(2)
+	v, err := p.WaitTimeout(10 * time.Millisecond)
+	Equals("TestCaseFirstSetWins", t, 1, v)
+	Equals("TestCaseSecondWaiter", t, 1, p.Get())
+}
+
+func TestCaseRejection(t *testing.T) {
+	failure := errors.New("backend down")
+	called := false
+
+	r := Run(func() interface{} {
+		panic(failure)
+	}).Then(func(val interface{}) interface{} {
+		called = true
+		return val
+	}).Catch(func(err error) interface{} {
+		return err.Error()
+	}).Get()
+
+	Equals("TestCaseThenSkipped", t, false, called)
+	Equals("TestCaseCatch", t, "backend down", r)
+
+	p := NewPromise()
+	p.Reject(failure)
+	Equals("TestCaseGetRejected", t, failure, p.Get())
+}
+
 func Equals(assetName string, t *testing.T, expected, actual interface{}) {
 	if expected != actual {
 		t.Log("\nTest: ", assetName, "\nExpected:", expected, "\nReturned:", actual)
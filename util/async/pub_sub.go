@@ -1,111 +1,247 @@
 package async
 
-import "sync"
-
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// FANOUT_SHARDS is number of independently locked subscriber lists, so subscribe and unsubscribe
+// of one client does not stall publishing to others
+const FANOUT_SHARDS = 16
+
+// FANOUT_MAILBOX is number of events buffered per subscriber of high-rate streams, see NewBufferedFanOut
+const FANOUT_MAILBOX = 64
+
+// FANOUT_LOSSLESS_MAILBOX is number of events buffered per subscriber of lossless FanOut, see NewFanOut
+const FANOUT_LOSSLESS_MAILBOX = 4096
+
+// FanOut delivers published events to all subscribers. Publish never blocks: every subscriber has
+// its own mailbox drained into subscriber channel by dedicated goroutine. Mailbox of lossless FanOut
+// grows until subscriber catches up, subscriber stalled until its mailbox is full is removed. Mailbox of
+// buffered FanOut is bounded, when subscriber does not keep up and its mailbox is full, the oldest event
+// is dropped so the subscriber always gets the latest data.
 type FanOut struct {
-	out     map[int]chan<- interface{}
-	l       *sync.RWMutex
-	counter int
-	pool    []int
+	shards   [FANOUT_SHARDS]*shard
+	counter  int64
+	mailbox  int
+	lossless bool
+	dropped  uint64
+}
+
+type shard struct {
+	l    *sync.RWMutex
+	subs map[int]*subscriber
 }
 
+type subscriber struct {
+	out      chan<- interface{}
+	l        *sync.Mutex
+	mailbox  []interface{}
+	limit    int
+	lossless bool
+	ready    chan struct{}
+	quit     chan struct{}
+}
+
+// NewFanOut creates lossless FanOut, every subscriber gets every published event. Subscriber with
+// FANOUT_LOSSLESS_MAILBOX events pending is removed, see Removed.
 func NewFanOut() *FanOut {
-	return &FanOut{
-		out:  make(map[int]chan<- interface{}),
-		l:    &sync.RWMutex{},
-		pool: make([]int, 0),
+	return newFanOut(FANOUT_LOSSLESS_MAILBOX, true)
+}
+
+// NewBufferedFanOut creates FanOut buffering up to mailboxSize events per subscriber, oldest events
+// are dropped for slow subscribers. Use it for high-rate property and event streams only.
+func NewBufferedFanOut(mailboxSize int) *FanOut {
+	if mailboxSize < 1 {
+		mailboxSize = 1
 	}
+
+	return newFanOut(mailboxSize, false)
 }
 
+// newFanOut creates FanOut with subscriber mailboxes limited to mailboxSize events, full mailbox of
+// lossless FanOut removes subscriber, otherwise the oldest event is dropped
+func newFanOut(mailboxSize int, lossless bool) *FanOut {
+	fo := &FanOut{
+		mailbox:  mailboxSize,
+		lossless: lossless,
+		counter:  -1,
+	}
+
+	for i := range fo.shards {
+		fo.shards[i] = &shard{
+			l:    &sync.RWMutex{},
+			subs: make(map[int]*subscriber),
+		}
+	}
+
+	return fo
+}
+
+// AddSubscriber registers out channel and returns subscriber ID. IDs are never reused.
 func (fo *FanOut) AddSubscriber(out chan<- interface{}) int {
-	fo.l.Lock()
+	id := int(atomic.AddInt64(&fo.counter, 1))
+	sub := &subscriber{
+		out:      out,
+		l:        &sync.Mutex{},
+		limit:    fo.mailbox,
+		lossless: fo.lossless,
+		ready:    make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
 
-	id := fo.nextID()
-	fo.out[id] = out
+	s := fo.shard(id)
+	s.l.Lock()
+	s.subs[id] = sub
+	s.l.Unlock()
+
+	go sub.pump()
 
-	fo.l.Unlock()
 	return id
 }
 
-func (fo *FanOut) nextID() int {
-	//use id form pool if any available
-	if len(fo.pool) > 0 {
-		id := fo.pool[0]
-		fo.pool = deleteElement(fo.pool, 0)
-		return id
+func (fo *FanOut) shard(id int) *shard {
+	return fo.shards[id%FANOUT_SHARDS]
+}
+
+func (fo *FanOut) RemoveSubscriber(id int) {
+	s := fo.shard(id)
+
+	s.l.Lock()
+	sub, ok := s.subs[id]
+	delete(s.subs, id)
+	s.l.Unlock()
+
+	if ok {
+		close(sub.quit)
 	}
+}
 
-	for {
-		_, exists := fo.out[fo.counter]
-		if !exists {
-			break
-		}
-		fo.counter++
+// Removed returns channel closed once subscriber is removed, by RemoveSubscriber or because it did not
+// keep up with lossless FanOut
+func (fo *FanOut) Removed(id int) <-chan struct{} {
+	s := fo.shard(id)
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if sub, ok := s.subs[id]; ok {
+		return sub.quit
 	}
 
-	return fo.counter
+	removed := make(chan struct{})
+	close(removed)
+	return removed
 }
 
-func (fo *FanOut) RemoveSubscriber(id int) {
-	fo.l.Lock()
-	//TODO: investigate if close on channel can cause panic. If not move Unlock from defered
-	//to end of the method for performance reasons
-	defer fo.l.Unlock()
-
-	if _, ok := fo.out[id]; ok {
-		delete(fo.out, id)
-		fo.pool = append(fo.pool, id)
+func (fo *FanOut) RemoveAllSubscribes() {
+	for _, s := range fo.shards {
+		s.l.Lock()
+		subs := s.subs
+		s.subs = make(map[int]*subscriber)
+		s.l.Unlock()
+
+		for _, sub := range subs {
+			close(sub.quit)
+		}
 	}
 }
 
-func (fo *FanOut) RemoveAllSubscribes() {
-	fo.l.Lock()
-	//TODO: investigate if close on channel can cause panic. If not move Unlock from defered
-	//to end of the method for performance reasons
-	defer fo.l.Unlock()
+func (fo *FanOut) Len() int {
+	n := 0
 
-	fo.out = make(map[int]chan<- interface{})
-	fo.pool = make([]int, 0)
-	fo.counter = 0
+	for _, s := range fo.shards {
+		s.l.RLock()
+		n += len(s.subs)
+		s.l.RUnlock()
+	}
+
+	return n
 }
 
-func (fo *FanOut) Publish(event interface{}) {
-	go func() {
-		fo.l.RLock()
-		outCopy := mapClone(fo.out)
-		fo.l.RUnlock()
-
-		//non blocking message publish
-		for {
-			if len(outCopy) == 0 {
-				break
-			}
+// Dropped returns number of events dropped because of slow subscribers, events of lossless FanOut are
+// dropped only for subscribers removed as stalled
+func (fo *FanOut) Dropped() uint64 {
+	return atomic.LoadUint64(&fo.dropped)
+}
 
-			for k, out := range outCopy {
-				//TODO: should be publishing time limited so we break cycle even of not
-				//all subscribers were not sent messages to?
-				select {
-				case out <- event:
-					//TODO: What is the performance of remove element? If expensive implement
-					//another solution to tag published subscribers
-					delete(outCopy, k)
+func (fo *FanOut) Publish(event interface{}) {
+	for _, s := range fo.shards {
+		var overflowed []int
+
+		s.l.RLock()
+		for id, sub := range s.subs {
+			if !sub.offer(event) {
+				atomic.AddUint64(&fo.dropped, 1)
+				if sub.lossless {
+					overflowed = append(overflowed, id)
 				}
 			}
 		}
-	}()
+		s.l.RUnlock()
+
+		for _, id := range overflowed {
+			fo.RemoveSubscriber(id)
+		}
+	}
 }
 
-func mapClone(src map[int]chan<- interface{}) map[int]chan<- interface{} {
-	newMap := make(map[int]chan<- interface{})
+// offer puts event to mailbox, when mailbox is full the oldest event is discarded, or the event itself
+// for lossless subscriber which is then removed. Returns false when an event was discarded.
+func (sub *subscriber) offer(event interface{}) bool {
+	delivered := true
+
+	sub.l.Lock()
+	switch {
+	case len(sub.mailbox) < sub.limit:
+		sub.mailbox = append(sub.mailbox, event)
+	case sub.lossless:
+		delivered = false
+	default:
+		sub.mailbox[0] = nil
+		sub.mailbox = append(sub.mailbox[1:], event)
+		delivered = false
+	}
+	sub.l.Unlock()
 
-	for k, v := range src {
-		newMap[k] = v
+	select {
+	case sub.ready <- struct{}{}:
+	default:
 	}
 
-	return newMap
+	return delivered
+}
+
+// next takes the oldest event from mailbox
+func (sub *subscriber) next() (interface{}, bool) {
+	sub.l.Lock()
+	defer sub.l.Unlock()
+
+	if len(sub.mailbox) == 0 {
+		return nil, false
+	}
+
+	event := sub.mailbox[0]
+	sub.mailbox[0] = nil
+	sub.mailbox = sub.mailbox[1:]
+
+	return event, true
 }
 
-func deleteElement(s []int, i int) []int {
-	s[i] = s[len(s)-1]
-	return s[:len(s)-1]
+func (sub *subscriber) pump() {
+	for {
+		select {
+		case <-sub.ready:
+		case <-sub.quit:
+			return
+		}
+
+		for event, ok := sub.next(); ok; event, ok = sub.next() {
+			select {
+			case sub.out <- event:
+			case <-sub.quit:
+				return
+			}
+		}
+	}
 }
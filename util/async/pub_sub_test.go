@@ -1,60 +1,166 @@
 package async
 
 import (
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/conas/tno2/util/str"
 )
 
-func TestCaseFanOutIdRecycle(t *testing.T) {
+func TestCaseFanOutIds(t *testing.T) {
 	fo := NewFanOut()
-	assertFanOut("FanOutIdRecycle.0", t, fo, 0, 0, 0)
+	assertFanOut("FanOutIds.0", t, fo, 0)
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.1", t, fo, 0, 0, 1)
+	id0 := fo.AddSubscriber(make(chan interface{}))
+	id1 := fo.AddSubscriber(make(chan interface{}))
+	id2 := fo.AddSubscriber(make(chan interface{}))
+	assertFanOut("FanOutIds.1", t, fo, 3)
+	Equals("FanOutIds.ids", t, true, id0 != id1 && id1 != id2)
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.2", t, fo, 1, 0, 2)
+	fo.RemoveSubscriber(id1)
+	assertFanOut("FanOutIds.2", t, fo, 2)
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.3", t, fo, 2, 0, 3)
+	//removed IDs are not reused, stale RemoveSubscriber must not remove new subscriber
+	id3 := fo.AddSubscriber(make(chan interface{}))
+	Equals("FanOutIds.unique", t, true, id3 != id1)
+	fo.RemoveSubscriber(id1)
+	assertFanOut("FanOutIds.3", t, fo, 3)
 
-	fo.RemoveSubscriber(1)
-	assertFanOut("FanOutIdRecycle.4", t, fo, 2, 1, 2)
+	fo.RemoveAllSubscribes()
+	assertFanOut("FanOutIds.4", t, fo, 0)
+}
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.5", t, fo, 2, 0, 3)
+func TestCaseFanOutOrder(t *testing.T) {
+	fo := NewFanOut()
+	out := make(chan interface{})
+	fo.AddSubscriber(out)
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.6", t, fo, 3, 0, 4)
+	for i := 0; i < 10; i++ {
+		fo.Publish(i)
+	}
 
-	fo.RemoveSubscriber(1)
-	assertFanOut("FanOutIdRecycle.7", t, fo, 3, 1, 3)
+	for i := 0; i < 10; i++ {
+		Equals(str.Concat("FanOutOrder.", strconv.Itoa(i)), t, i, receive(t, out))
+	}
+}
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.8", t, fo, 3, 0, 4)
+func TestCaseFanOutSlowSubscriber(t *testing.T) {
+	fo := NewBufferedFanOut(2)
+	slow := make(chan interface{})
+	fo.AddSubscriber(slow)
+
+	//publishing is not blocked by subscriber not reading
+	for i := 0; i < 10; i++ {
+		fo.Publish(i)
+	}
+
+	//slow subscriber pump holds at most one event, mailbox keeps the latest ones
+	received := 0
+	for last := -1; last != 9; received++ {
+		last = receive(t, slow).(int)
+	}
+	Equals("FanOutSlowSubscriber.received", t, true, received <= 3)
+	Equals("FanOutSlowSubscriber.dropped", t, true, fo.Dropped() > 0)
+}
 
-	fo.RemoveSubscriber(2)
-	assertFanOut("FanOutIdRecycle.9", t, fo, 3, 1, 3)
+func TestCaseFanOutLossless(t *testing.T) {
+	fo := NewFanOut()
+	slow := make(chan interface{})
+	fo.AddSubscriber(slow)
+
+	//publishing is not blocked by subscriber not reading, nothing is dropped
+	for i := 0; i < FANOUT_MAILBOX*2; i++ {
+		fo.Publish(i)
+	}
+
+	for i := 0; i < FANOUT_MAILBOX*2; i++ {
+		Equals(str.Concat("FanOutLossless.", strconv.Itoa(i)), t, i, receive(t, slow))
+	}
+	Equals("FanOutLossless.dropped", t, uint64(0), fo.Dropped())
+}
 
-	fo.RemoveSubscriber(3)
-	assertFanOut("FanOutIdRecycle.10", t, fo, 3, 2, 2)
+func TestCaseFanOutStalledSubscriber(t *testing.T) {
+	fo := NewFanOut()
+	stalled := fo.AddSubscriber(make(chan interface{}))
+
+	reading := make(chan interface{})
+	id := fo.AddSubscriber(reading)
+
+	//stalled subscriber is removed once its mailbox is full, others are kept
+	for i := 0; i <= FANOUT_LOSSLESS_MAILBOX+1; i++ {
+		fo.Publish(i)
+		receive(t, reading)
+	}
+
+	select {
+	case <-fo.Removed(stalled):
+	case <-time.After(time.Second):
+		t.Fatal("stalled subscriber not removed")
+	}
+	assertFanOut("FanOutStalledSubscriber", t, fo, 1)
+
+	select {
+	case <-fo.Removed(id):
+		t.Fatal("reading subscriber removed")
+	default:
+	}
+	fo.RemoveSubscriber(id)
+}
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.11", t, fo, 3, 1, 3)
+func receive(t *testing.T, ch <-chan interface{}) interface{} {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("FanOut event not delivered")
+		return nil
+	}
+}
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.12", t, fo, 3, 0, 4)
+func assertFanOut(msg string, t *testing.T, fo *FanOut, outLen int) {
+	Equals(str.Concat(msg, " fo.Len()"), t, outLen, fo.Len())
+}
 
-	fo.AddSubscriber(make(chan interface{}))
-	assertFanOut("FanOutIdRecycle.13", t, fo, 4, 0, 5)
+func benchmarkPublish(b *testing.B, subscribers int) {
+	fo := NewFanOut()
 
-	fo.RemoveAllSubscribes()
-	assertFanOut("FanOutIdRecycle.14", t, fo, 0, 0, 0)
+	for i := 0; i < subscribers; i++ {
+		out := make(chan interface{}, FANOUT_MAILBOX)
+		fo.AddSubscriber(out)
+		go func() {
+			for range out {
+			}
+		}()
+	}
+	defer fo.RemoveAllSubscribes()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fo.Publish(i)
+	}
 }
 
-func assertFanOut(msg string, t *testing.T, fo *FanOut, id, poolLen, outLen int) {
-	Equals(str.Concat(msg, " fo.id"), t, id, fo.counter)
-	Equals(str.Concat(msg, " len(fo.pool)"), t, poolLen, len(fo.pool))
-	Equals(str.Concat(msg, " len(fo.out)"), t, outLen, len(fo.out))
+func BenchmarkFanOutPublish1(b *testing.B)    { benchmarkPublish(b, 1) }
+func BenchmarkFanOutPublish100(b *testing.B)  { benchmarkPublish(b, 100) }
+func BenchmarkFanOutPublish1000(b *testing.B) { benchmarkPublish(b, 1000) }
+
+func BenchmarkFanOutPublishParallel(b *testing.B) {
+	fo := NewFanOut()
+	for i := 0; i < 100; i++ {
+		out := make(chan interface{}, FANOUT_MAILBOX)
+		fo.AddSubscriber(out)
+		go func() {
+			for range out {
+			}
+		}()
+	}
+	defer fo.RemoveAllSubscribes()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			fo.Publish(1)
+		}
+	})
 }
@@ -0,0 +1,323 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Package cbor encodes values of JSON data model to CBOR (RFC 8949) and back. Maps are encoded with
+// sorted keys so equal values have equal encoding, times are epoch based date/time (tag 1). Values of
+// other types, e.g. structs, are encoded as their JSON representation.
+
+const (
+	major_UINT   = 0
+	major_NINT   = 1
+	major_BYTES  = 2
+	major_TEXT   = 3
+	major_ARRAY  = 4
+	major_MAP    = 5
+	major_TAG    = 6
+	major_SIMPLE = 7
+
+	tag_EPOCH = 1
+)
+
+var ErrMalformed = errors.New("Malformed CBOR")
+
+// Marshal encodes value, integers use the shortest encoding and floats the shortest precision
+// representing them exactly
+func Marshal(v interface{}) ([]byte, error) {
+	return appendValue(nil, v)
+}
+
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, major_SIMPLE<<5|22), nil
+	case bool:
+		if v {
+			return append(buf, major_SIMPLE<<5|21), nil
+		}
+		return append(buf, major_SIMPLE<<5|20), nil
+	case int:
+		return appendInt(buf, int64(v)), nil
+	case int8:
+		return appendInt(buf, int64(v)), nil
+	case int16:
+		return appendInt(buf, int64(v)), nil
+	case int32:
+		return appendInt(buf, int64(v)), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case uint:
+		return appendHead(buf, major_UINT, uint64(v)), nil
+	case uint8:
+		return appendHead(buf, major_UINT, uint64(v)), nil
+	case uint16:
+		return appendHead(buf, major_UINT, uint64(v)), nil
+	case uint32:
+		return appendHead(buf, major_UINT, uint64(v)), nil
+	case uint64:
+		return appendHead(buf, major_UINT, v), nil
+	case float32:
+		return appendFloat(buf, float64(v)), nil
+	case float64:
+		return appendFloat(buf, v), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendInt(buf, n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return appendFloat(buf, f), nil
+	case string:
+		return append(appendHead(buf, major_TEXT, uint64(len(v))), v...), nil
+	case []byte:
+		return append(appendHead(buf, major_BYTES, uint64(len(v))), v...), nil
+	case time.Time:
+		buf = appendHead(buf, major_TAG, tag_EPOCH)
+		if v.Nanosecond() == 0 {
+			return appendInt(buf, v.Unix()), nil
+		}
+		return appendFloat(buf, float64(v.UnixNano())/1e9), nil
+	case []interface{}:
+		buf = appendHead(buf, major_ARRAY, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if buf, err = appendValue(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf = appendHead(buf, major_MAP, uint64(len(v)))
+		for _, k := range keys {
+			buf = append(appendHead(buf, major_TEXT, uint64(len(k))), k...)
+
+			var err error
+			if buf, err = appendValue(buf, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+
+	//other values are encoded as their JSON representation
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err = d.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return appendValue(buf, generic)
+}
+
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	m := major << 5
+
+	switch {
+	case n < 24:
+		return append(buf, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, m|26), uint32(n))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, m|27), n)
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	if n < 0 {
+		return appendHead(buf, major_NINT, uint64(-1-n))
+	}
+
+	return appendHead(buf, major_UINT, uint64(n))
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return appendInt(buf, int64(f))
+	}
+
+	if float64(float32(f)) == f || math.IsNaN(f) {
+		return binary.BigEndian.AppendUint32(append(buf, major_SIMPLE<<5|26), math.Float32bits(float32(f)))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, major_SIMPLE<<5|27), math.Float64bits(f))
+}
+
+// Unmarshal decodes single data item. Integers are int64, floats float64, byte strings []byte, arrays
+// []interface{}, maps of text keys map[string]interface{}, other maps map[interface{}]interface{} and
+// epoch based date/time is time.Time. Other tags are ignored.
+func Unmarshal(data []byte) (interface{}, error) {
+	v, rest, err := decodeItem(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformed
+	}
+
+	return v, nil
+}
+
+func decodeHead(data []byte) (major byte, info byte, n uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, 0, 0, nil, ErrMalformed
+	}
+
+	major, info = data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return major, info, uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return major, info, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return major, info, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return major, info, binary.BigEndian.Uint64(data), data[8:], nil
+	}
+
+	return 0, 0, 0, nil, ErrMalformed
+}
+
+func decodeItem(data []byte) (interface{}, []byte, error) {
+	major, info, n, rest, err := decodeHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case major_UINT:
+		return int64(n), rest, nil
+	case major_NINT:
+		return -1 - int64(n), rest, nil
+	case major_BYTES, major_TEXT:
+		if uint64(len(rest)) < n {
+			return nil, nil, ErrMalformed
+		}
+		if major == major_TEXT {
+			return string(rest[:n]), rest[n:], nil
+		}
+		return append([]byte(nil), rest[:n]...), rest[n:], nil
+	case major_ARRAY:
+		items := make([]interface{}, 0)
+		for i := uint64(0); i < n; i++ {
+			var item interface{}
+			if item, rest, err = decodeItem(rest); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case major_MAP:
+		m := make(map[interface{}]interface{})
+		texts := true
+		for i := uint64(0); i < n; i++ {
+			var k, v interface{}
+			if k, rest, err = decodeItem(rest); err != nil {
+				return nil, nil, err
+			}
+			if v, rest, err = decodeItem(rest); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case []byte, []interface{}, map[string]interface{}, map[interface{}]interface{}:
+				return nil, nil, ErrMalformed
+			case string:
+			default:
+				texts = false
+			}
+			m[k] = v
+		}
+		if !texts {
+			return m, rest, nil
+		}
+		fields := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			fields[k.(string)] = v
+		}
+		return fields, rest, nil
+	case major_TAG:
+		v, rest, err := decodeItem(rest)
+		if err != nil || n != tag_EPOCH {
+			return v, rest, err
+		}
+		switch t := v.(type) {
+		case int64:
+			return time.Unix(t, 0).UTC(), rest, nil
+		case float64:
+			sec, frac := math.Modf(t)
+			return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), rest, nil
+		}
+		return nil, nil, ErrMalformed
+	case major_SIMPLE:
+		switch info {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		case 22, 23:
+			return nil, rest, nil
+		case 25:
+			return halfFloat(uint16(n)), rest, nil
+		case 26:
+			return float64(math.Float32frombits(uint32(n))), rest, nil
+		case 27:
+			return math.Float64frombits(n), rest, nil
+		}
+	}
+
+	return nil, nil, ErrMalformed
+}
+
+func halfFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
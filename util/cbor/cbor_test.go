@@ -0,0 +1,84 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCaseMarshal(t *testing.T) {
+	//examples of RFC 8949 appendix A
+	for _, c := range []struct {
+		value interface{}
+		hex   string
+	}{
+		{0, "00"},
+		{uint8(24), "1818"},
+		{1000000, "1a000f4240"},
+		{-1000, "3903e7"},
+		{1.5, "fa3fc00000"},
+		{1.1, "fb3ff199999999999a"},
+		{100.0, "1864"},
+		{math.Inf(-1), "faff800000"},
+		{false, "f4"},
+		{nil, "f6"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]interface{}{1, []interface{}{2, 3}}, "8201820203"},
+		{map[string]interface{}{"b": 2, "a": 1}, "a2616101616202"},
+		{time.Unix(1363896240, 0), "c11a514b67b0"},
+		{time.Unix(1363896240, 500000000), "c1fb41d452d9ec200000"},
+	} {
+		data, err := Marshal(c.value)
+		Equals("error", t, nil, err)
+		Equals(c.hex, t, c.hex, hex.EncodeToString(data))
+	}
+}
+
+func TestCaseJSONFallback(t *testing.T) {
+	type reading struct {
+		Level  string   `json:"level"`
+		Values []string `json:"values"`
+	}
+
+	data, err := Marshal(&reading{Level: "high", Values: []string{"a"}})
+	Equals("error", t, nil, err)
+
+	v, err := Unmarshal(data)
+	Equals("decoded", t, nil, err)
+	m := v.(map[string]interface{})
+	Equals("level", t, "high", m["level"])
+	Equals("values", t, "a", m["values"].([]interface{})[0])
+
+	data, _ = Marshal(map[string][]string{"n": {"1.5"}})
+	Equals("map of slices", t, "a1616e8163312e35", hex.EncodeToString(data))
+}
+
+func TestCaseUnmarshal(t *testing.T) {
+	decode := func(s string) interface{} {
+		data, _ := hex.DecodeString(s)
+		v, err := Unmarshal(data)
+		if err != nil {
+			return err
+		}
+		return v
+	}
+
+	Equals("uint", t, int64(1000000), decode("1a000f4240"))
+	Equals("nint", t, int64(-1000), decode("3903e7"))
+	Equals("half", t, 1.5, decode("f93e00"))
+	Equals("text", t, "IETF", decode("6449455446"))
+	Equals("null", t, nil, decode("f6"))
+	Equals("time", t, true, time.Unix(1363896240, 500000000).Equal(decode("c1fb41d452d9ec200000").(time.Time)))
+	Equals("integer keys", t, "a", decode("a1016161").(map[interface{}]interface{})[int64(1)])
+	Equals("truncated", t, ErrMalformed, decode("6449"))
+	Equals("trailing", t, ErrMalformed, decode("0000"))
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
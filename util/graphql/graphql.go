@@ -0,0 +1,712 @@
+// Package graphql parses GraphQL executable documents: operations with variables, fields with aliases
+// and arguments, fragments and @skip/@include directives. Schema definitions are not parsed, servers
+// resolve collected fields against their own data.
+package graphql
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+)
+
+const (
+	OPERATION_QUERY        = "query"
+	OPERATION_MUTATION     = "mutation"
+	OPERATION_SUBSCRIPTION = "subscription"
+)
+
+// Variable is reference to operation variable in argument value
+type Variable string
+
+// Enum is enum value in argument value
+type Enum string
+
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+type Operation struct {
+	Type       string
+	Name       string
+	Variables  []*VariableDefinition
+	Selections []*Selection
+}
+
+type VariableDefinition struct {
+	Name    string
+	Type    string
+	Default interface{}
+}
+
+type Fragment struct {
+	Name       string
+	On         string
+	Selections []*Selection
+}
+
+// Selection is field, fragment spread (Spread is set) or inline fragment (Inline is set, On is optional)
+type Selection struct {
+	Field      *Field
+	Spread     string
+	On         string
+	Inline     []*Selection
+	Directives []*Directive
+}
+
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]interface{}
+	Selections []*Selection
+}
+
+// Key is name of field in response, alias when set
+func (f *Field) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+
+	return f.Name
+}
+
+type Directive struct {
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// Error is GraphQL error of response "errors" list
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Operation selects operation to execute, name may be empty for document with single operation
+func (d *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) != 1 {
+			return nil, errors.New("Operation name required for document with multiple operations")
+		}
+		return d.Operations[0], nil
+	}
+
+	for _, op := range d.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+
+	return nil, errors.New(str.Concat("Unknown operation: ", name))
+}
+
+// Values coerces provided variables with defaults of operation, missing non-null variables fail
+func (op *Operation) Values(provided map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+
+	for _, def := range op.Variables {
+		if v, ok := provided[def.Name]; ok {
+			vars[def.Name] = v
+		} else if def.Default != nil {
+			vars[def.Name] = def.Default
+		} else if strings.HasSuffix(def.Type, "!") {
+			return nil, errors.New(str.Concat("Variable $", def.Name, " of type ", def.Type, " is required"))
+		}
+	}
+
+	return vars, nil
+}
+
+// Collect flattens selections of object typed typeName into fields, expanding fragments and applying
+// @skip and @include. Fields with equal response key are merged.
+func (d *Document) Collect(selections []*Selection, typeName string, vars map[string]interface{}) ([]*Field, error) {
+	fields := make([]*Field, 0, len(selections))
+	byKey := make(map[string]*Field)
+
+	var collect func(selections []*Selection, visited map[string]bool) error
+	collect = func(selections []*Selection, visited map[string]bool) error {
+		for _, s := range selections {
+			if !included(s.Directives, vars) {
+				continue
+			}
+
+			switch {
+			case s.Field != nil:
+				if f, ok := byKey[s.Field.Key()]; ok {
+					f.Selections = append(f.Selections, s.Field.Selections...)
+					continue
+				}
+				f := *s.Field
+				f.Selections = append([]*Selection(nil), s.Field.Selections...)
+				byKey[f.Key()] = &f
+				fields = append(fields, &f)
+			case s.Spread != "":
+				fragment, ok := d.Fragments[s.Spread]
+				if !ok {
+					return errors.New(str.Concat("Unknown fragment: ", s.Spread))
+				}
+				if visited[s.Spread] || fragment.On != typeName {
+					continue
+				}
+				visited[s.Spread] = true
+				if err := collect(fragment.Selections, visited); err != nil {
+					return err
+				}
+			default:
+				if s.On != "" && s.On != typeName {
+					continue
+				}
+				if err := collect(s.Inline, visited); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return fields, collect(selections, make(map[string]bool))
+}
+
+// Argument returns argument value with variables substituted
+func (f *Field) Argument(name string, vars map[string]interface{}) (interface{}, bool) {
+	v, ok := f.Arguments[name]
+	if !ok {
+		return nil, false
+	}
+
+	return Resolve(v, vars), true
+}
+
+// Resolve substitutes variables of value, enums resolve to their names
+func Resolve(v interface{}, vars map[string]interface{}) interface{} {
+	switch t := v.(type) {
+	case Variable:
+		return vars[string(t)]
+	case Enum:
+		return string(t)
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, item := range t {
+			l[i] = Resolve(item, vars)
+		}
+		return l
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			m[k] = Resolve(item, vars)
+		}
+		return m
+	}
+
+	return v
+}
+
+func included(directives []*Directive, vars map[string]interface{}) bool {
+	for _, d := range directives {
+		cond, _ := Resolve(d.Arguments["if"], vars).(bool)
+
+		switch d.Name {
+		case "skip":
+			if cond {
+				return false
+			}
+		case "include":
+			if !cond {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ----- parser
+
+const (
+	token_EOF = iota
+	token_PUNCT
+	token_NAME
+	token_INT
+	token_FLOAT
+	token_STRING
+)
+
+type token struct {
+	kind  int
+	value string
+	pos   int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+// Parse parses executable document
+func Parse(src string) (doc *Document, err error) {
+	p := &parser{src: strings.TrimPrefix(src, "\uFEFF")}
+
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+
+	p.next()
+	doc = &Document{Fragments: make(map[string]*Fragment)}
+
+	for p.tok.kind != token_EOF {
+		switch {
+		case p.peek("{"):
+			doc.Operations = append(doc.Operations, &Operation{Type: OPERATION_QUERY, Selections: p.selectionSet()})
+		case p.peekName(OPERATION_QUERY), p.peekName(OPERATION_MUTATION), p.peekName(OPERATION_SUBSCRIPTION):
+			doc.Operations = append(doc.Operations, p.operation())
+		case p.peekName("fragment"):
+			f := p.fragment()
+			doc.Fragments[f.Name] = f
+		default:
+			p.fail("Unexpected ", p.tok.value)
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		p.fail("Document has no operation")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) operation() *Operation {
+	op := &Operation{Type: p.name()}
+
+	if p.tok.kind == token_NAME {
+		op.Name = p.name()
+	}
+
+	if p.skip("(") {
+		for !p.skip(")") {
+			p.expect("$")
+			def := &VariableDefinition{Name: p.name()}
+			p.expect(":")
+			def.Type = p.typeRef()
+			if p.skip("=") {
+				def.Default = p.value(true)
+			}
+			op.Variables = append(op.Variables, def)
+		}
+	}
+
+	p.directives()
+	op.Selections = p.selectionSet()
+
+	return op
+}
+
+func (p *parser) fragment() *Fragment {
+	p.name()
+	f := &Fragment{Name: p.name()}
+
+	if !p.peekName("on") {
+		p.fail("Expected type condition of fragment ", f.Name)
+	}
+	p.name()
+	f.On = p.name()
+
+	p.directives()
+	f.Selections = p.selectionSet()
+
+	return f
+}
+
+func (p *parser) typeRef() string {
+	var t string
+
+	if p.skip("[") {
+		t = str.Concat("[", p.typeRef(), "]")
+		p.expect("]")
+	} else {
+		t = p.name()
+	}
+
+	if p.skip("!") {
+		t = str.Concat(t, "!")
+	}
+
+	return t
+}
+
+func (p *parser) selectionSet() []*Selection {
+	p.expect("{")
+	selections := make([]*Selection, 0)
+
+	for !p.skip("}") {
+		selections = append(selections, p.selection())
+	}
+
+	return selections
+}
+
+func (p *parser) selection() *Selection {
+	if p.skip("...") {
+		s := &Selection{}
+
+		if p.tok.kind == token_NAME && !p.peekName("on") {
+			s.Spread = p.name()
+			s.Directives = p.directives()
+			return s
+		}
+
+		if p.peekName("on") {
+			p.name()
+			s.On = p.name()
+		}
+		s.Directives = p.directives()
+		s.Inline = p.selectionSet()
+		return s
+	}
+
+	f := &Field{Name: p.name()}
+	if p.skip(":") {
+		f.Alias, f.Name = f.Name, p.name()
+	}
+
+	f.Arguments = p.arguments(false)
+	s := &Selection{Field: f, Directives: p.directives()}
+
+	if p.peek("{") {
+		f.Selections = p.selectionSet()
+	}
+
+	return s
+}
+
+func (p *parser) arguments(constant bool) map[string]interface{} {
+	args := make(map[string]interface{})
+
+	if !p.skip("(") {
+		return args
+	}
+
+	for !p.skip(")") {
+		name := p.name()
+		p.expect(":")
+		args[name] = p.value(constant)
+	}
+
+	return args
+}
+
+func (p *parser) directives() []*Directive {
+	var directives []*Directive
+
+	for p.skip("@") {
+		directives = append(directives, &Directive{Name: p.name(), Arguments: p.arguments(false)})
+	}
+
+	return directives
+}
+
+func (p *parser) value(constant bool) interface{} {
+	tok := p.tok
+
+	switch tok.kind {
+	case token_INT:
+		p.next()
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			p.fail("Invalid Int ", tok.value)
+		}
+		return n
+	case token_FLOAT:
+		p.next()
+		n, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			p.fail("Invalid Float ", tok.value)
+		}
+		return n
+	case token_STRING:
+		p.next()
+		return tok.value
+	case token_NAME:
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return Enum(tok.value)
+	}
+
+	switch {
+	case p.skip("$"):
+		if constant {
+			p.fail("Variable not allowed in constant value")
+		}
+		return Variable(p.name())
+	case p.skip("["):
+		l := make([]interface{}, 0)
+		for !p.skip("]") {
+			l = append(l, p.value(constant))
+		}
+		return l
+	case p.skip("{"):
+		m := make(map[string]interface{})
+		for !p.skip("}") {
+			name := p.name()
+			p.expect(":")
+			m[name] = p.value(constant)
+		}
+		return m
+	}
+
+	p.fail("Unexpected ", tok.value)
+	return nil
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != token_NAME {
+		p.fail("Expected name, found ", p.tok.value)
+	}
+
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == token_PUNCT && p.tok.value == punct
+}
+
+func (p *parser) peekName(name string) bool {
+	return p.tok.kind == token_NAME && p.tok.value == name
+}
+
+func (p *parser) skip(punct string) bool {
+	if p.peek(punct) {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(punct string) {
+	if !p.skip(punct) {
+		p.fail("Expected ", punct, ", found ", p.tok.value)
+	}
+}
+
+func (p *parser) fail(msg ...interface{}) {
+	panic(&Error{Message: str.Concat(str.Concat(msg...), " at offset ", p.tok.pos)})
+}
+
+// next reads next token, whitespace, commas and comments are ignored
+func (p *parser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		p.pos++
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: token_EOF, value: "end of document", pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: token_PUNCT, value: "...", pos: start}
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		p.pos++
+		p.tok = token{kind: token_PUNCT, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: token_NAME, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.number()
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		p.blockString()
+	case c == '"':
+		p.string()
+	default:
+		p.tok = token{kind: token_PUNCT, value: string(c), pos: start}
+		p.fail("Unexpected character ", strconv.Quote(string(c)))
+	}
+}
+
+func (p *parser) number() {
+	start := p.pos
+	kind := token_INT
+
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	digits()
+
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = token_FLOAT
+		p.pos++
+		digits()
+	}
+
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = token_FLOAT
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+
+	p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+}
+
+func (p *parser) string() {
+	start := p.pos
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.tok.pos = start
+			p.fail("Unterminated string")
+		}
+
+		c := p.src[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		if p.pos+1 >= len(p.src) {
+			p.tok.pos = start
+			p.fail("Unterminated string")
+		}
+
+		escape := p.src[p.pos+1]
+		p.pos += 2
+		switch escape {
+		case '"', '\\', '/':
+			b.WriteByte(escape)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+4 > len(p.src) {
+				p.tok.pos = start
+				p.fail("Invalid unicode escape")
+			}
+			r, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				p.tok.pos = start
+				p.fail("Invalid unicode escape")
+			}
+			b.WriteRune(rune(r))
+			p.pos += 4
+		default:
+			p.tok.pos = start
+			p.fail("Invalid escape \\", string(escape))
+		}
+	}
+
+	p.tok = token{kind: token_STRING, value: b.String(), pos: start}
+}
+
+// blockString reads """ string, common indentation and surrounding blank lines are removed
+func (p *parser) blockString() {
+	start := p.pos
+	p.pos += 3
+
+	end := strings.Index(p.src[p.pos:], `"""`)
+	for end > 0 && p.src[p.pos+end-1] == '\\' {
+		next := strings.Index(p.src[p.pos+end+3:], `"""`)
+		if next < 0 {
+			end = -1
+			break
+		}
+		end += 3 + next
+	}
+	if end < 0 {
+		p.tok.pos = start
+		p.fail("Unterminated block string")
+	}
+
+	raw := strings.ReplaceAll(p.src[p.pos:p.pos+end], `\"""`, `"""`)
+	p.pos += end + 3
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	p.tok = token{kind: token_STRING, value: strings.Join(lines, "\n"), pos: start}
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,86 @@
+package graphql
+
+import (
+	"testing"
+)
+
+func TestCaseParse(t *testing.T) {
+	doc, err := Parse(`
+		# dashboard
+		query Dashboard($verbose: Boolean = false, $limit: Int!) {
+			t: thermo { temp: temperature, ...Details @include(if: $verbose) }
+			relay(limit: $limit, mode: FAST, tags: ["a", "b"], range: {min: -1, max: 2.5e1})
+			... on Query { status @skip(if: true) }
+		}
+		fragment Details on Thermo { unit description: label(text: """
+			multi
+			  line
+		""") }
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op, err := doc.Operation("")
+	Equals("operation", t, nil, err)
+	Equals("operation type", t, OPERATION_QUERY, op.Type)
+	Equals("operation name", t, "Dashboard", op.Name)
+
+	_, err = op.Values(nil)
+	Equals("required variable", t, true, err != nil)
+
+	vars, _ := op.Values(map[string]interface{}{"limit": 5, "verbose": true})
+	fields, err := doc.Collect(op.Selections, "Query", vars)
+	Equals("collect", t, nil, err)
+	Equals("root fields", t, 2, len(fields))
+	Equals("alias", t, "t", fields[0].Key())
+
+	limit, _ := fields[1].Argument("limit", vars)
+	mode, _ := fields[1].Argument("mode", vars)
+	rng, _ := fields[1].Argument("range", vars)
+	Equals("variable", t, 5, limit)
+	Equals("enum", t, "FAST", mode)
+	Equals("object", t, 25.0, rng.(map[string]interface{})["max"])
+	Equals("negative", t, int64(-1), rng.(map[string]interface{})["min"])
+
+	thermo, _ := doc.Collect(fields[0].Selections, "Thermo", vars)
+	Equals("fragment included", t, 3, len(thermo))
+	text, _ := thermo[2].Argument("text", vars)
+	Equals("block string", t, "multi\n  line", text)
+
+	vars["verbose"] = false
+	thermo, _ = doc.Collect(fields[0].Selections, "Thermo", vars)
+	Equals("fragment excluded", t, 1, len(thermo))
+}
+
+func TestCaseParseErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`{ a `,
+		`{ a(x: ) }`,
+		`{ a(x: "unterminated) }`,
+		`query($x: Int) { a } fragment F { b }`,
+		`mutation { a(x: $y) } fragment F on T { b(y: 1 }`,
+	} {
+		_, err := Parse(src)
+		Equals(src, t, true, err != nil)
+	}
+
+	doc, _ := Parse(`query A { a } query B { b }`)
+	_, err := doc.Operation("")
+	Equals("ambiguous operation", t, true, err != nil)
+	op, _ := doc.Operation("B")
+	Equals("named operation", t, "B", op.Name)
+
+	doc, _ = Parse(`{ ...Missing }`)
+	_, err = doc.Collect(doc.Operations[0].Selections, "Query", nil)
+	Equals("unknown fragment", t, true, err != nil)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,157 @@
+// Package jose implements JWE compact serialization (RFC 7516) with direct encryption by shared
+// symmetric key, "dir" algorithm with A128GCM, A192GCM or A256GCM content encryption selected by key size,
+// and JWS with detached payload (RFC 7515) signed by ECDSA P-256, RSA or Ed25519 keys.
+package jose
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const ALG_DIR = "dir"
+
+var (
+	ErrMalformed   = errors.New("Malformed JWE")
+	ErrUnsupported = errors.New("Unsupported JWE algorithm")
+	ErrDecryption  = errors.New("JWE decryption failed")
+)
+
+// Header is JWE protected header
+type Header struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Cty string `json:"cty,omitempty"`
+}
+
+// Encrypt encrypts plaintext by 16, 24 or 32 bytes long key identified by kid
+func Encrypt(key []byte, kid string, plaintext []byte) (string, error) {
+	enc, err := encFor(key)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(&Header{Alg: ALG_DIR, Enc: enc, Kid: kid})
+	h := base64.RawURLEncoding.EncodeToString(header)
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(h))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		h,
+		"",
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt verifies and decrypts token encrypted by key
+func Decrypt(key []byte, token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 || parts[1] != "" {
+		return nil, ErrMalformed
+	}
+
+	header, err := ParseHeader(token)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := encFor(key)
+	if err != nil {
+		return nil, err
+	}
+	if header.Alg != ALG_DIR || header.Enc != enc {
+		return nil, ErrUnsupported
+	}
+
+	segments := make([][]byte, 3)
+	for i, part := range parts[2:] {
+		if segments[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+	iv, ciphertext, tag := segments[0], segments[1], segments[2]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != gcm.NonceSize() || len(tag) != gcm.Overhead() {
+		return nil, ErrMalformed
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return plaintext, nil
+}
+
+// ParseHeader decodes protected header of token without decrypting it, e.g. to select key by Kid
+func ParseHeader(token string) (*Header, error) {
+	i := strings.IndexByte(token, '.')
+	if i < 0 || strings.Count(token, ".") != 4 {
+		return nil, ErrMalformed
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token[:i])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	header := &Header{}
+	if err = json.Unmarshal(data, header); err != nil {
+		return nil, ErrMalformed
+	}
+
+	return header, nil
+}
+
+// IsCompact reports whether v is string in JWE compact serialization
+func IsCompact(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+
+	_, err := ParseHeader(s)
+	return err == nil
+}
+
+func encFor(key []byte) (string, error) {
+	switch len(key) {
+	case 16, 24, 32:
+		return "A" + strconv.Itoa(len(key)*8) + "GCM", nil
+	}
+
+	return "", errors.New("Invalid JWE key size " + strconv.Itoa(len(key)))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
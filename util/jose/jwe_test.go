@@ -0,0 +1,52 @@
+package jose
+
+import (
+	"strings"
+	"testing"
+)
+
+var key = []byte("0123456789abcdef0123456789abcdef")
+
+func TestCaseRoundTrip(t *testing.T) {
+	token, err := Encrypt(key, "k1", []byte(`{"temperature":21.5}`))
+	Equals("encrypted", t, nil, err)
+	Equals("compact", t, true, IsCompact(token))
+
+	header, err := ParseHeader(token)
+	Equals("header", t, nil, err)
+	Equals("alg", t, ALG_DIR, header.Alg)
+	Equals("enc", t, "A256GCM", header.Enc)
+	Equals("kid", t, "k1", header.Kid)
+
+	plaintext, err := Decrypt(key, token)
+	Equals("decrypted", t, nil, err)
+	Equals("plaintext", t, `{"temperature":21.5}`, string(plaintext))
+}
+
+func TestCaseTampered(t *testing.T) {
+	token, _ := Encrypt(key, "", []byte("secret"))
+	parts := strings.Split(token, ".")
+
+	//header is authenticated as additional data
+	header, _ := Encrypt(key, "other", []byte("secret"))
+	forged := strings.Split(header, ".")[0] + "." + strings.Join(parts[1:], ".")
+	_, err := Decrypt(key, forged)
+	Equals("forged header", t, ErrDecryption, err)
+
+	_, err = Decrypt([]byte("fedcba9876543210fedcba9876543210"), token)
+	Equals("wrong key", t, ErrDecryption, err)
+
+	_, err = Decrypt([]byte("0123456789abcdef"), token)
+	Equals("other enc", t, ErrUnsupported, err)
+
+	_, err = Decrypt(key, "a.b.c")
+	Equals("malformed", t, ErrMalformed, err)
+	Equals("not compact", t, false, IsCompact(21.5))
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,209 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ALG_ES256 = "ES256"
+	ALG_RS256 = "RS256"
+	ALG_EDDSA = "EdDSA"
+)
+
+var ErrSignature = errors.New("Invalid JWS signature")
+
+// SignDetached creates JWS with detached payload (RFC 7515 Appendix F), "header..signature", signed by
+// ECDSA P-256, RSA or Ed25519 private key identified by kid
+func SignDetached(key crypto.Signer, kid string, payload []byte) (string, error) {
+	alg, err := algFor(key.Public())
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(&Header{Alg: alg, Kid: kid})
+	h := base64.RawURLEncoding.EncodeToString(header)
+	input := signingInput(h, payload)
+
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, input)
+	default:
+		digest := sha256.Sum256(input)
+		if sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+			return "", err
+		}
+	}
+
+	return h + ".." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyDetached verifies JWS with detached payload by key selected by kid of its header, key must match
+// algorithm of the header. Header is returned so caller knows which key signed payload.
+func VerifyDetached(token string, payload []byte, keys func(kid string) (crypto.PublicKey, bool)) (*Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return nil, ErrMalformed
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	header := &Header{}
+	if err = json.Unmarshal(data, header); err != nil {
+		return nil, ErrMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	key, ok := keys(header.Kid)
+	if !ok {
+		return nil, ErrSignature
+	}
+
+	if alg, err := algFor(key); err != nil || alg != header.Alg {
+		return nil, ErrUnsupported
+	}
+
+	input := signingInput(parts[0], payload)
+	digest := sha256.Sum256(input)
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		ok = len(sig) == 64 && ecdsa.Verify(k, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, input, sig)
+	}
+
+	if !ok {
+		return nil, ErrSignature
+	}
+
+	return header, nil
+}
+
+// ParsePublicKey decodes PEM encoded PKIX public key
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = algFor(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// ParsePrivateKey decodes PEM encoded PKCS #8, SEC 1 EC or PKCS #1 RSA private key usable by SignDetached
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM block found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	if _, err = algFor(signer.Public()); err != nil {
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+// LoadPublicKeys reads PEM public keys from dir, key id is file name without .pem extension
+func LoadPublicKeys(dir string) (map[string]crypto.PublicKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+
+		keys[strings.TrimSuffix(filepath.Base(path), ".pem")] = key
+	}
+
+	return keys, nil
+}
+
+func algFor(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().BitSize == 256 {
+			return ALG_ES256, nil
+		}
+	case *rsa.PublicKey:
+		return ALG_RS256, nil
+	case ed25519.PublicKey:
+		return ALG_EDDSA, nil
+	}
+
+	return "", ErrUnsupported
+}
+
+func signingInput(header string, payload []byte) []byte {
+	return []byte(header + "." + base64.RawURLEncoding.EncodeToString(payload))
+}
@@ -0,0 +1,75 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestCaseDetachedSignature(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rs, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+
+	signers := map[string]crypto.Signer{"ec": ec, "rsa": rs, "ed": ed}
+	keys := func(kid string) (crypto.PublicKey, bool) {
+		signer, ok := signers[kid]
+		if !ok {
+			return nil, false
+		}
+		return signer.Public(), true
+	}
+
+	payload := []byte(`{"valve":"open"}`)
+
+	for kid, signer := range signers {
+		token, err := SignDetached(signer, kid, payload)
+		Equals(kid+" signed", t, nil, err)
+
+		header, err := VerifyDetached(token, payload, keys)
+		Equals(kid+" verified", t, nil, err)
+		if header != nil {
+			Equals(kid+" kid", t, kid, header.Kid)
+		}
+
+		_, err = VerifyDetached(token, []byte(`{"valve":"closed"}`), keys)
+		Equals(kid+" tampered payload", t, ErrSignature, err)
+	}
+
+	token, _ := SignDetached(ec, "rsa", payload)
+	_, err := VerifyDetached(token, payload, keys)
+	Equals("key of other algorithm", t, ErrUnsupported, err)
+
+	token, _ = SignDetached(ec, "unknown", payload)
+	_, err = VerifyDetached(token, payload, keys)
+	Equals("unknown key", t, ErrSignature, err)
+}
+
+func TestCaseParsePrivateKey(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(ec)
+	sec1, _ := x509.MarshalECPrivateKey(ec)
+
+	for name, block := range map[string]*pem.Block{
+		"pkcs8": {Type: "PRIVATE KEY", Bytes: der},
+		"sec1":  {Type: "EC PRIVATE KEY", Bytes: sec1},
+	} {
+		signer, err := ParsePrivateKey(pem.EncodeToMemory(block))
+		Equals(name+" parsed", t, nil, err)
+
+		token, _ := SignDetached(signer, "ec", []byte("payload"))
+		_, err = VerifyDetached(token, []byte("payload"), func(string) (crypto.PublicKey, bool) {
+			return &ec.PublicKey, true
+		})
+		Equals(name+" verified", t, nil, err)
+	}
+
+	_, err := ParsePrivateKey([]byte("not a key"))
+	Equals("invalid", t, true, err != nil)
+}
@@ -0,0 +1,547 @@
+package nats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+)
+
+// Package nats implements client of NATS core protocol: publish, subscribe and request/reply over
+// plain TCP. Lost connection is re-established and subscriptions are renewed. TLS, JetStream and
+// cluster discovery are not supported.
+
+const DEFAULT_PORT = "4222"
+
+const (
+	RECONNECT_WAIT = 2 * time.Second
+	PING_INTERVAL  = 20 * time.Second
+	// MAX_PINGS_OUT unanswered pings close connection, it is re-established
+	MAX_PINGS_OUT = 2
+	// SUB_PENDING messages are buffered per subscription, reading stops while buffer is full
+	SUB_PENDING = 1024
+)
+
+var (
+	ErrTimeout = errors.New("NATS request timed out")
+	ErrClosed  = errors.New("NATS connection closed")
+)
+
+// Msg is message delivered to subscription, Reply is subject of requester expecting response
+type Msg struct {
+	Subject string
+	Reply   string
+	Data    []byte
+}
+
+type Handler func(*Msg)
+
+// Conn is connection to NATS server shared by its subscriptions
+type Conn struct {
+	l         *sync.Mutex
+	url       *url.URL
+	name      string
+	conn      net.Conn
+	w         *bufio.Writer
+	subs      map[uint64]*Subscription
+	sid       uint64
+	connected bool
+	closed    bool
+	pingsOut  int
+}
+
+// Subscription delivers messages of Subject in order, subscriptions of the same Queue share messages
+type Subscription struct {
+	Subject string
+	Queue   string
+	sid     uint64
+	c       *Conn
+	msgs    chan *Msg
+	done    chan struct{}
+	once    *sync.Once
+}
+
+// Connect connects to server at nats://[user:password@]host[:port], user without password is sent as
+// token. Name identifies client in server monitoring.
+func Connect(rawurl, name string) (*Conn, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "nats" {
+		return nil, errors.New(str.Concat("Unsupported NATS URL scheme: ", u.Scheme))
+	}
+
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), DEFAULT_PORT)
+	}
+
+	c := &Conn{
+		l:    &sync.Mutex{},
+		url:  u,
+		name: name,
+		subs: make(map[uint64]*Subscription),
+	}
+
+	conn, r, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.attach(conn)
+	go c.read(conn, r)
+	go c.ping()
+
+	return c, nil
+}
+
+// dial connects and handshakes, server answers PING after CONNECT only when credentials are accepted
+func (c *Conn) dial() (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", c.url.Host, RECONNECT_WAIT)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(RECONNECT_WAIT))
+
+	fail := func(err error) (net.Conn, *bufio.Reader, error) {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	line, err := readLine(r)
+	if err != nil {
+		return fail(err)
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return fail(errors.New(str.Concat("Unexpected NATS greeting: ", line)))
+	}
+
+	connect, _ := json.Marshal(c.options())
+	if _, err = io.WriteString(conn, str.Concat("CONNECT ", string(connect), "\r\nPING\r\n")); err != nil {
+		return fail(err)
+	}
+
+	for {
+		line, err = readLine(r)
+		if err != nil {
+			return fail(err)
+		}
+
+		switch {
+		case line == "PONG":
+			conn.SetReadDeadline(time.Time{})
+			return conn, r, nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fail(errors.New(str.Concat("NATS connection refused: ", serverError(line))))
+		}
+	}
+}
+
+func (c *Conn) options() map[string]interface{} {
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"lang":     "go",
+		"version":  "1.0.0",
+		"name":     c.name,
+	}
+
+	if c.url.User != nil {
+		if password, ok := c.url.User.Password(); ok {
+			options["user"] = c.url.User.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = c.url.User.Username()
+		}
+	}
+
+	return options
+}
+
+// attach makes conn current connection and renews subscriptions on it
+func (c *Conn) attach(conn net.Conn) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.conn = conn
+	c.w = bufio.NewWriter(conn)
+	c.connected = true
+	c.pingsOut = 0
+
+	for _, s := range c.subs {
+		c.w.WriteString(subscribe(s))
+	}
+	c.w.Flush()
+}
+
+// read dispatches messages of conn until it fails, then reconnects unless connection was closed
+func (c *Conn) read(conn net.Conn, r *bufio.Reader) {
+	for {
+		err := c.dispatch(r)
+
+		c.l.Lock()
+		c.connected = false
+		closed := c.closed
+		c.l.Unlock()
+
+		conn.Close()
+		if closed {
+			return
+		}
+
+		log.Warn("NATS: connection to ", c.url.Host, " lost -> ", err)
+
+		for {
+			time.Sleep(RECONNECT_WAIT)
+
+			c.l.Lock()
+			closed = c.closed
+			c.l.Unlock()
+			if closed {
+				return
+			}
+
+			if conn, r, err = c.dial(); err == nil {
+				break
+			}
+		}
+
+		log.Info("NATS: reconnected to ", c.url.Host)
+		c.attach(conn)
+	}
+}
+
+func (c *Conn) dispatch(r *bufio.Reader) error {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			msg, sid, err := readMsg(line, r)
+			if err != nil {
+				return err
+			}
+			c.deliver(sid, msg)
+		case line == "PING":
+			c.write("PONG\r\n")
+		case line == "PONG":
+			c.l.Lock()
+			c.pingsOut = 0
+			c.l.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Error("NATS: ", serverError(line))
+		}
+	}
+}
+
+// readMsg reads payload of "MSG <subject> <sid> [reply] <size>"
+func readMsg(line string, r *bufio.Reader) (*Msg, uint64, error) {
+	args := strings.Fields(line)[1:]
+	if len(args) != 3 && len(args) != 4 {
+		return nil, 0, errors.New(str.Concat("Invalid NATS message: ", line))
+	}
+
+	sid, err1 := strconv.ParseUint(args[1], 10, 64)
+	size, err2 := strconv.Atoi(args[len(args)-1])
+	if err1 != nil || err2 != nil || size < 0 {
+		return nil, 0, errors.New(str.Concat("Invalid NATS message: ", line))
+	}
+
+	//payload is followed by CRLF
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, 0, err
+	}
+
+	msg := &Msg{Subject: args[0], Data: data[:size]}
+	if len(args) == 4 {
+		msg.Reply = args[2]
+	}
+
+	return msg, sid, nil
+}
+
+func (c *Conn) deliver(sid uint64, msg *Msg) {
+	c.l.Lock()
+	s, ok := c.subs[sid]
+	c.l.Unlock()
+
+	if !ok {
+		return
+	}
+
+	select {
+	case s.msgs <- msg:
+	case <-s.done:
+	}
+}
+
+// ping detects dead connections which would not fail reading otherwise
+func (c *Conn) ping() {
+	ticker := time.NewTicker(PING_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.l.Lock()
+		if c.closed {
+			c.l.Unlock()
+			return
+		}
+
+		if c.connected {
+			c.pingsOut++
+			if c.pingsOut > MAX_PINGS_OUT {
+				c.conn.Close()
+			} else {
+				c.w.WriteString("PING\r\n")
+				c.w.Flush()
+			}
+		}
+		c.l.Unlock()
+	}
+}
+
+func (c *Conn) write(s string) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.writeLocked(s)
+}
+
+func (c *Conn) writeLocked(s string) error {
+	if c.closed {
+		return ErrClosed
+	}
+	if !c.connected {
+		return errors.New(str.Concat("NATS not connected to ", c.url.Host))
+	}
+
+	c.w.WriteString(s)
+	return c.w.Flush()
+}
+
+// Publish publishes data to subject
+func (c *Conn) Publish(subject string, data []byte) error {
+	return c.PublishRequest(subject, "", data)
+}
+
+// PublishRequest publishes data to subject, responders answer to reply subject
+func (c *Conn) PublishRequest(subject, reply string, data []byte) error {
+	if !validSubject(subject) || (reply != "" && !validSubject(reply)) {
+		return errors.New(str.Concat("Invalid NATS subject: ", subject))
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	cmd := str.Concat("PUB ", subject, " ")
+	if reply != "" {
+		cmd = str.Concat(cmd, reply, " ")
+	}
+	cmd = str.Concat(cmd, strconv.Itoa(len(data)), "\r\n", string(data), "\r\n")
+
+	return c.writeLocked(cmd)
+}
+
+// Subscribe calls handler for messages of subject, "*" and ">" wildcards are supported by server
+func (c *Conn) Subscribe(subject string, handler Handler) (*Subscription, error) {
+	return c.QueueSubscribe(subject, "", handler)
+}
+
+// QueueSubscribe subscribes subject as member of queue group, message is delivered to one member only
+func (c *Conn) QueueSubscribe(subject, queue string, handler Handler) (*Subscription, error) {
+	if !validSubject(subject) {
+		return nil, errors.New(str.Concat("Invalid NATS subject: ", subject))
+	}
+
+	c.l.Lock()
+	if c.closed {
+		c.l.Unlock()
+		return nil, ErrClosed
+	}
+
+	c.sid++
+	s := &Subscription{
+		Subject: subject,
+		Queue:   queue,
+		sid:     c.sid,
+		c:       c,
+		msgs:    make(chan *Msg, SUB_PENDING),
+		done:    make(chan struct{}),
+		once:    &sync.Once{},
+	}
+	c.subs[s.sid] = s
+
+	//subscription is renewed when connection is re-established
+	if c.connected {
+		c.w.WriteString(subscribe(s))
+		c.w.Flush()
+	}
+	c.l.Unlock()
+
+	go func() {
+		for {
+			select {
+			case msg := <-s.msgs:
+				handler(msg)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// Unsubscribe stops delivery of messages, pending messages are dropped
+func (s *Subscription) Unsubscribe() error {
+	var err error
+
+	s.once.Do(func() {
+		c := s.c
+		c.l.Lock()
+		delete(c.subs, s.sid)
+		if c.connected && !c.closed {
+			err = c.writeLocked(str.Concat("UNSUB ", strconv.FormatUint(s.sid, 10), "\r\n"))
+		}
+		c.l.Unlock()
+
+		close(s.done)
+	})
+
+	return err
+}
+
+// Request publishes data to subject and waits for first response
+func (c *Conn) Request(subject string, data []byte, timeout time.Duration) (*Msg, error) {
+	id, _ := sec.UUID4()
+	inbox := str.Concat("_INBOX.", strings.Replace(id, "-", "", -1))
+
+	responses := make(chan *Msg, 1)
+	s, err := c.Subscribe(inbox, func(msg *Msg) {
+		select {
+		case responses <- msg:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer s.Unsubscribe()
+
+	if err = c.PublishRequest(subject, inbox, data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-responses:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, ErrTimeout
+	}
+}
+
+// IsConnected reports whether connection to server is established
+func (c *Conn) IsConnected() bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.connected && !c.closed
+}
+
+// Close closes connection and all its subscriptions
+func (c *Conn) Close() {
+	c.l.Lock()
+	if c.closed {
+		c.l.Unlock()
+		return
+	}
+
+	c.closed = true
+	if c.connected {
+		c.w.Flush()
+		c.conn.Close()
+	}
+
+	subs := make([]*Subscription, 0, len(c.subs))
+	for _, s := range c.subs {
+		subs = append(subs, s)
+	}
+	c.l.Unlock()
+
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
+
+func subscribe(s *Subscription) string {
+	sid := strconv.FormatUint(s.sid, 10)
+	if s.Queue != "" {
+		return str.Concat("SUB ", s.Subject, " ", s.Queue, " ", sid, "\r\n")
+	}
+
+	return str.Concat("SUB ", s.Subject, " ", sid, "\r\n")
+}
+
+// validSubject accepts non-empty dot separated tokens without whitespace
+func validSubject(subject string) bool {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return false
+	}
+
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subject converts path, e.g. context path of Thing, to subject tokens. Separators "/" become "."
+// and characters not allowed in subjects "_".
+func Subject(path string) string {
+	tokens := make([]string, 0)
+	for _, token := range strings.Split(path, "/") {
+		if token == "" {
+			continue
+		}
+		tokens = append(tokens, strings.Map(func(r rune) rune {
+			if r == '.' || r == '*' || r == '>' || r <= ' ' {
+				return '_'
+			}
+			return r
+		}, token))
+	}
+
+	return strings.Join(tokens, ".")
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func serverError(line string) string {
+	return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'")
+}
@@ -0,0 +1,162 @@
+package nats
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+)
+
+func TestCasePublishSubscribe(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	c, err := Connect(str.Concat("nats://", b.Addr().String()), "test")
+	Equals("connected", t, nil, err)
+	defer c.Close()
+
+	received := make(chan *Msg, 4)
+	s, _ := c.Subscribe("thing.event", func(m *Msg) { received <- m })
+	c.Publish("thing.event", []byte("one"))
+	c.Publish("thing.other", []byte("ignored"))
+	c.Publish("thing.event", []byte(""))
+
+	Equals("first", t, "one", string(next(received).Data))
+	Equals("empty", t, "", string(next(received).Data))
+
+	s.Unsubscribe()
+	c.Publish("thing.event", []byte("late"))
+	select {
+	case <-received:
+		t.Log("message after unsubscribe")
+		t.Fail()
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	Equals("invalid subject", t, true, c.Publish("thing..event", nil) != nil)
+}
+
+func TestCaseRequest(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	c, _ := Connect(str.Concat("nats://", b.Addr().String()), "test")
+	defer c.Close()
+
+	c.Subscribe("thing.property.level", func(m *Msg) {
+		c.Publish(m.Reply, append([]byte("level of "), m.Data...))
+	})
+
+	rs, err := c.Request("thing.property.level", []byte("tank"), time.Second)
+	Equals("request", t, nil, err)
+	Equals("response", t, "level of tank", string(rs.Data))
+
+	_, err = c.Request("thing.property.missing", nil, 20*time.Millisecond)
+	Equals("timeout", t, ErrTimeout, err)
+
+	c.Close()
+	Equals("closed", t, ErrClosed, c.Publish("thing.event", nil))
+}
+
+func TestCaseSubject(t *testing.T) {
+	Equals("path", t, "conas.dth-1", Subject("/conas/dth-1/"))
+	Equals("escaped", t, "a_b.c_", Subject("a.b/c*"))
+}
+
+func next(ch chan *Msg) *Msg {
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(time.Second):
+		return &Msg{Data: []byte("timeout")}
+	}
+}
+
+// broker is minimal NATS server of single client, messages are routed to subscriptions of exactly
+// matching subject
+type broker struct {
+	net.Listener
+	l    *sync.Mutex
+	subs map[string]map[string]net.Conn
+}
+
+func newBroker(t *testing.T) *broker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &broker{Listener: ln, l: &sync.Mutex{}, subs: make(map[string]map[string]net.Conn)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+
+	return b
+}
+
+func (b *broker) serve(conn net.Conn) {
+	defer conn.Close()
+
+	io.WriteString(conn, "INFO {}\r\n")
+	r := bufio.NewReader(conn)
+	sids := make(map[string]string)
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+
+		args := strings.Fields(line)
+		switch args[0] {
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "SUB":
+			b.l.Lock()
+			if b.subs[args[1]] == nil {
+				b.subs[args[1]] = make(map[string]net.Conn)
+			}
+			b.subs[args[1]][args[len(args)-1]] = conn
+			sids[args[len(args)-1]] = args[1]
+			b.l.Unlock()
+		case "UNSUB":
+			b.l.Lock()
+			delete(b.subs[sids[args[1]]], args[1])
+			b.l.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(args[len(args)-1])
+			data := make([]byte, size+2)
+			io.ReadFull(r, data)
+
+			reply := ""
+			if len(args) == 4 {
+				reply = str.Concat(args[2], " ")
+			}
+
+			b.l.Lock()
+			for sid, sub := range b.subs[args[1]] {
+				io.WriteString(sub, str.Concat("MSG ", args[1], " ", sid, " ", reply, args[len(args)-1], "\r\n", string(data)))
+			}
+			b.l.Unlock()
+		}
+	}
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
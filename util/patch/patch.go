@@ -0,0 +1,396 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+)
+
+// Package patch implements JSON Merge Patch (RFC 7386) and JSON Patch (RFC 6902) over generic
+// JSON values, i.e. values produced by decoding JSON into interface{}.
+
+const (
+	CONTENT_TYPE_MERGE_PATCH = "application/merge-patch+json"
+	CONTENT_TYPE_JSON_PATCH  = "application/json-patch+json"
+)
+
+var ErrTestFailed = errors.New("JSON Patch test operation failed")
+
+// Operation is one JSON Patch operation
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON emits value of add, replace and test operations even when it is null, value is omitted
+// only for operations without value
+func (op Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(&struct {
+			operation
+			Value interface{} `json:"value"`
+		}{operation(op), op.Value})
+	}
+
+	return json.Marshal(operation(op))
+}
+
+// Normalize converts v to generic JSON value, result is deep copy safe to modify
+func Normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+
+	return generic, err
+}
+
+// Merge applies merge patch to target. Target is not modified when it is normalized copy.
+func Merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = Merge(t[k], v)
+		}
+	}
+
+	return t
+}
+
+// Apply applies JSON Patch operations in order, on error no partial result is returned
+func Apply(target interface{}, ops []Operation) (interface{}, error) {
+	doc, err := Normalize(target)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if doc, err = apply(doc, op); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// Diff returns JSON Patch transforming generic JSON value a to b, see Normalize. Members are compared
+// recursively, arrays element by element, so appending to array adds only the new elements.
+func Diff(a, b interface{}) []Operation {
+	return diff("", a, b, make([]Operation, 0))
+}
+
+func diff(path string, a, b interface{}, ops []Operation) []Operation {
+	switch na := a.(type) {
+	case map[string]interface{}:
+		nb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		//members are visited in order, so equal changes produce equal patches
+		keys := make([]string, 0, len(na)+len(nb))
+		for k := range na {
+			keys = append(keys, k)
+		}
+		for k := range nb {
+			if _, ok := na[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			member := str.Concat(path, "/", escape(k))
+			va, inA := na[k]
+			vb, inB := nb[k]
+
+			switch {
+			case !inB:
+				ops = append(ops, Operation{Op: "remove", Path: member})
+			case !inA:
+				ops = append(ops, Operation{Op: "add", Path: member, Value: vb})
+			default:
+				ops = diff(member, va, vb, ops)
+			}
+		}
+		return ops
+	case []interface{}:
+		nb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		common := len(na)
+		if len(nb) < common {
+			common = len(nb)
+		}
+
+		for i := 0; i < common; i++ {
+			ops = diff(str.Concat(path, "/", strconv.Itoa(i)), na[i], nb[i], ops)
+		}
+		for i := common; i < len(nb); i++ {
+			ops = append(ops, Operation{Op: "add", Path: str.Concat(path, "/-"), Value: nb[i]})
+		}
+		//removed from the end, so indexes of remaining elements do not shift
+		for i := len(na) - 1; i >= common; i-- {
+			ops = append(ops, Operation{Op: "remove", Path: str.Concat(path, "/", strconv.Itoa(i))})
+		}
+		return ops
+	}
+
+	if !same(a, b) {
+		ops = append(ops, Operation{Op: "replace", Path: path, Value: b})
+	}
+
+	return ops
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, op.Value)
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+	case "replace":
+		doc, _, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, op.Value)
+	case "move":
+		doc, v, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, v)
+	case "copy":
+		v, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = Normalize(v); err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, v)
+	case "test":
+		v, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !same(v, op.Value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+
+	return nil, errors.New(str.Concat("Unsupported JSON Patch operation: ", op.Op))
+}
+
+// pointer splits JSON Pointer (RFC 6901) to unescaped reference tokens
+func pointer(path string) ([]string, error) {
+	if path == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, errors.New(str.Concat("Invalid JSON Pointer: ", path))
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+// escape escapes member name to JSON Pointer reference token
+func escape(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func get(doc interface{}, path string) (interface{}, error) {
+	tokens, err := pointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			v, ok := node[t]
+			if !ok {
+				return nil, missing(path)
+			}
+			doc = v
+		case []interface{}:
+			i, err := index(t, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, missing(path)
+		}
+	}
+
+	return doc, nil
+}
+
+// add sets value at path, returns updated document as root may be replaced
+func add(doc interface{}, path string, value interface{}) (interface{}, error) {
+	tokens, err := pointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parentPath := path[:strings.LastIndex(path, "/")]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		i := len(node)
+		if last != "-" {
+			if i, err = index(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+		return set(doc, parentPath, node)
+	}
+
+	return nil, missing(path)
+}
+
+// set replaces existing value at path, used to reattach arrays re-allocated by add and remove
+func set(doc interface{}, path string, value interface{}) (interface{}, error) {
+	if path == "" {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:strings.LastIndex(path, "/")])
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, _ := pointer(path)
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		i, err := index(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+	}
+
+	return doc, nil
+}
+
+// remove deletes value at path, returns updated document and removed value
+func remove(doc interface{}, path string) (interface{}, interface{}, error) {
+	tokens, err := pointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	parentPath := path[:strings.LastIndex(path, "/")]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		v, ok := node[last]
+		if !ok {
+			return nil, nil, missing(path)
+		}
+		delete(node, last)
+		return doc, v, nil
+	case []interface{}:
+		i, err := index(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := node[i]
+		node = append(node[:i:i], node[i+1:]...)
+		doc, err = set(doc, parentPath, node)
+		return doc, v, err
+	}
+
+	return nil, nil, missing(path)
+}
+
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.New(str.Concat("Invalid array index: ", token))
+	}
+
+	return i, nil
+}
+
+func missing(path string) error {
+	return errors.New(str.Concat("JSON Pointer does not exist: ", path))
+}
+
+func same(a, b interface{}) bool {
+	da, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	db, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(da) == string(db)
+}
@@ -0,0 +1,97 @@
+package patch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCaseMerge(t *testing.T) {
+	// RFC 7386 section 3 example
+	target := decode(`{"title": "Goodbye!", "author": {"givenName": "John", "familyName": "Doe"}, "tags": ["example", "sample"], "content": "This will be unchanged"}`)
+	patch := decode(`{"title": "Hello!", "phoneNumber": "+01-123-456-7890", "author": {"familyName": null}, "tags": ["example"]}`)
+	expected := `{"author":{"givenName":"John"},"content":"This will be unchanged","phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}`
+
+	Equals("Merge", t, expected, encode(Merge(target, patch)))
+}
+
+func TestCaseApply(t *testing.T) {
+	target := decode(`{"foo": ["bar", "baz"], "nested": {"list": [[1, 2], [3]]}}`)
+	ops := []Operation{}
+	json.Unmarshal([]byte(`[
+		{"op": "add", "path": "/foo/1", "value": "qux"},
+		{"op": "remove", "path": "/foo/0"},
+		{"op": "add", "path": "/nested/list/0/-", "value": 9},
+		{"op": "replace", "path": "/nested/list/1/0", "value": 4},
+		{"op": "copy", "from": "/foo", "path": "/copy"},
+		{"op": "move", "from": "/copy/1", "path": "/moved"},
+		{"op": "test", "path": "/moved", "value": "baz"}
+	]`), &ops)
+	expected := `{"copy":["qux"],"foo":["qux","baz"],"moved":"baz","nested":{"list":[[1,2,9],[4]]}}`
+
+	result, err := Apply(target, ops)
+	Equals("Apply error", t, nil, err)
+	Equals("Apply", t, expected, encode(result))
+	Equals("Apply target untouched", t, `{"foo":["bar","baz"],"nested":{"list":[[1,2],[3]]}}`, encode(target))
+}
+
+func TestCaseApplyErrors(t *testing.T) {
+	target := decode(`{"a": [1]}`)
+
+	_, err := Apply(target, []Operation{{Op: "test", Path: "/a/0", Value: 2}})
+	Equals("test failed", t, ErrTestFailed, err)
+
+	_, err = Apply(target, []Operation{{Op: "remove", Path: "/b"}})
+	Equals("missing path", t, true, err != nil)
+
+	_, err = Apply(target, []Operation{{Op: "add", Path: "/a/5", Value: 2}})
+	Equals("index out of range", t, true, err != nil)
+}
+
+func TestCaseDiff(t *testing.T) {
+	a := decode(`{"mode": "auto", "zones": [{"t": 20}, {"t": 21}], "tags": ["a", "b", "c"], "a/b": 1, "gone": true}`)
+	b := decode(`{"mode": "auto", "zones": [{"t": 20}, {"t": 22}, {"t": 19}], "tags": ["a"], "a/b": 2, "new": null}`)
+
+	ops := Diff(a, b)
+	expected := `[{"op":"replace","path":"/a~1b","value":2},{"op":"remove","path":"/gone"},{"op":"add","path":"/new","value":null},` +
+		`{"op":"remove","path":"/tags/2"},{"op":"remove","path":"/tags/1"},` +
+		`{"op":"replace","path":"/zones/1/t","value":22},{"op":"add","path":"/zones/-","value":{"t":19}}]`
+	Equals("Diff", t, expected, encode(ops))
+
+	result, err := Apply(a, ops)
+	Equals("Diff applies", t, nil, err)
+	Equals("Diff result", t, encode(b), encode(result))
+
+	Equals("no changes", t, 0, len(Diff(a, decode(encode(a)))))
+	Equals("root replaced", t, `[{"op":"replace","path":"","value":3}]`, encode(Diff(a, 3.0)))
+
+	//null is a value, it survives encoding of operations
+	c := decode(`{"mode": null, "tags": ["a"]}`)
+	ops = Diff(a, c)
+	Equals("Diff to null", t, true, strings.Contains(encode(ops), `{"op":"replace","path":"/mode","value":null}`))
+
+	var decoded []Operation
+	json.Unmarshal([]byte(encode(ops)), &decoded)
+	result, err = Apply(a, decoded)
+	Equals("Decoded diff applies", t, nil, err)
+	Equals("Decoded diff result", t, encode(c), encode(result))
+	Equals("Remove without value", t, `{"op":"remove","path":"/gone"}`, encode(Operation{Op: "remove", Path: "/gone"}))
+}
+
+func decode(s string) interface{} {
+	var v interface{}
+	json.Unmarshal([]byte(s), &v)
+	return v
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
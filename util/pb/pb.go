@@ -0,0 +1,385 @@
+// Package pb encodes dynamically typed values in Protocol Buffers wire format without generated code.
+// Untyped values are encoded as google.protobuf.Value, values of known TD type as single field message
+// `message { <type> value = 1; }` generated by tno2gen -proto.
+package pb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/conas/tno2/util/str"
+)
+
+const (
+	WIRE_VARINT = 0
+	WIRE_64BIT  = 1
+	WIRE_BYTES  = 2
+	WIRE_32BIT  = 5
+)
+
+// google.protobuf.Value, Struct and ListValue field numbers
+const (
+	value_NULL   = 1
+	value_NUMBER = 2
+	value_STRING = 3
+	value_BOOL   = 4
+	value_STRUCT = 5
+	value_LIST   = 6
+
+	struct_FIELDS = 1
+	entry_KEY     = 1
+	entry_VALUE   = 2
+	list_VALUES   = 1
+)
+
+var ErrMalformed = errors.New("Malformed protobuf message")
+
+// MarshalValue encodes v as google.protobuf.Value. Values other than JSON primitives, maps and slices
+// are converted through their JSON form first.
+func MarshalValue(v interface{}) ([]byte, error) {
+	generic, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendValue(nil, generic), nil
+}
+
+// UnmarshalValue decodes google.protobuf.Value to nil, bool, float64, string, []interface{} or map[string]interface{}
+func UnmarshalValue(data []byte) (interface{}, error) {
+	var v interface{}
+
+	err := fields(data, func(field int, wire int, varint uint64, bytes []byte) error {
+		switch field {
+		case value_NULL:
+			v = nil
+		case value_NUMBER:
+			v = math.Float64frombits(varint)
+		case value_STRING:
+			v = string(bytes)
+		case value_BOOL:
+			v = varint != 0
+		case value_STRUCT:
+			m, err := unmarshalStruct(bytes)
+			if err != nil {
+				return err
+			}
+			v = m
+		case value_LIST:
+			l, err := unmarshalList(bytes)
+			if err != nil {
+				return err
+			}
+			v = l
+		}
+		return nil
+	})
+
+	return v, err
+}
+
+// MarshalTyped encodes v as field 1 of message typed by TD value type: boolean as bool, integer as sint64,
+// number as double, string as string and anything else as google.protobuf.Value
+func MarshalTyped(valueType string, v interface{}) ([]byte, error) {
+	switch valueType {
+	case "boolean":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, typeError(valueType, v)
+		}
+		return appendVarint(appendTag(nil, 1, WIRE_VARINT), boolVarint(b)), nil
+	case "integer":
+		n, ok := number(v)
+		if !ok || n != math.Trunc(n) {
+			return nil, typeError(valueType, v)
+		}
+		return appendVarint(appendTag(nil, 1, WIRE_VARINT), zigzag(int64(n))), nil
+	case "number":
+		n, ok := number(v)
+		if !ok {
+			return nil, typeError(valueType, v)
+		}
+		return appendFixed64(appendTag(nil, 1, WIRE_64BIT), math.Float64bits(n)), nil
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, typeError(valueType, v)
+		}
+		return appendBytes(appendTag(nil, 1, WIRE_BYTES), []byte(s)), nil
+	}
+
+	value, err := MarshalValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendBytes(appendTag(nil, 1, WIRE_BYTES), value), nil
+}
+
+// UnmarshalTyped decodes message encoded by MarshalTyped, missing field decodes to zero value of the type
+func UnmarshalTyped(valueType string, data []byte) (interface{}, error) {
+	var v interface{}
+
+	switch valueType {
+	case "boolean":
+		v = false
+	case "integer":
+		v = int64(0)
+	case "number":
+		v = float64(0)
+	case "string":
+		v = ""
+	}
+
+	err := fields(data, func(field int, wire int, varint uint64, bytes []byte) error {
+		if field != 1 {
+			return nil
+		}
+
+		switch valueType {
+		case "boolean":
+			v = varint != 0
+		case "integer":
+			v = unzigzag(varint)
+		case "number":
+			v = math.Float64frombits(varint)
+		case "string":
+			v = string(bytes)
+		default:
+			value, err := UnmarshalValue(bytes)
+			if err != nil {
+				return err
+			}
+			v = value
+		}
+		return nil
+	})
+
+	return v, err
+}
+
+func typeError(valueType string, v interface{}) error {
+	return errors.New(str.Concat("Value is not ", valueType))
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, bool, string, float64:
+		return v, nil
+	}
+
+	if n, ok := number(v); ok {
+		return n, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+
+	return generic, err
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
+
+func appendValue(buf []byte, v interface{}) []byte {
+	switch t := v.(type) {
+	case nil:
+		return appendVarint(appendTag(buf, value_NULL, WIRE_VARINT), 0)
+	case bool:
+		return appendVarint(appendTag(buf, value_BOOL, WIRE_VARINT), boolVarint(t))
+	case float64:
+		return appendFixed64(appendTag(buf, value_NUMBER, WIRE_64BIT), math.Float64bits(t))
+	case string:
+		return appendBytes(appendTag(buf, value_STRING, WIRE_BYTES), []byte(t))
+	case []interface{}:
+		var list []byte
+		for _, item := range t {
+			list = appendBytes(appendTag(list, list_VALUES, WIRE_BYTES), appendValue(nil, item))
+		}
+		return appendBytes(appendTag(buf, value_LIST, WIRE_BYTES), list)
+	case map[string]interface{}:
+		//keys are sorted, so equal values have equal encoding
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var st []byte
+		for _, k := range keys {
+			entry := appendBytes(appendTag(nil, entry_KEY, WIRE_BYTES), []byte(k))
+			entry = appendBytes(appendTag(entry, entry_VALUE, WIRE_BYTES), appendValue(nil, t[k]))
+			st = appendBytes(appendTag(st, struct_FIELDS, WIRE_BYTES), entry)
+		}
+		return appendBytes(appendTag(buf, value_STRUCT, WIRE_BYTES), st)
+	}
+
+	return buf
+}
+
+func unmarshalStruct(data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+
+	err := fields(data, func(field int, wire int, varint uint64, entry []byte) error {
+		if field != struct_FIELDS {
+			return nil
+		}
+
+		var key string
+		var value interface{}
+
+		err := fields(entry, func(field int, wire int, varint uint64, bytes []byte) error {
+			switch field {
+			case entry_KEY:
+				key = string(bytes)
+			case entry_VALUE:
+				v, err := UnmarshalValue(bytes)
+				if err != nil {
+					return err
+				}
+				value = v
+			}
+			return nil
+		})
+
+		m[key] = value
+		return err
+	})
+
+	return m, err
+}
+
+func unmarshalList(data []byte) ([]interface{}, error) {
+	l := make([]interface{}, 0)
+
+	err := fields(data, func(field int, wire int, varint uint64, bytes []byte) error {
+		if field != list_VALUES {
+			return nil
+		}
+
+		v, err := UnmarshalValue(bytes)
+		l = append(l, v)
+		return err
+	})
+
+	return l, err
+}
+
+// fields calls f for every field of message, 64 and 32 bit values are passed as varint
+func fields(data []byte, f func(field int, wire int, varint uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrMalformed
+		}
+		data = data[n:]
+
+		field, wire := int(tag>>3), int(tag&7)
+		var varint uint64
+		var bytes []byte
+
+		switch wire {
+		case WIRE_VARINT:
+			varint, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ErrMalformed
+			}
+			data = data[n:]
+		case WIRE_64BIT:
+			if len(data) < 8 {
+				return ErrMalformed
+			}
+			varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case WIRE_32BIT:
+			if len(data) < 4 {
+				return ErrMalformed
+			}
+			varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case WIRE_BYTES:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return ErrMalformed
+			}
+			bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return ErrMalformed
+		}
+
+		if err := f(field, wire, varint, bytes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func appendTag(buf []byte, field int, wire int) []byte {
+	return appendVarint(buf, uint64(field)<<3|uint64(wire))
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendFixed64(buf []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, v)
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	return append(appendVarint(buf, uint64(len(b))), b...)
+}
+
+func boolVarint(b bool) uint64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func zigzag(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,75 @@
+package pb
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestCaseValueRoundTrip(t *testing.T) {
+	value := map[string]interface{}{
+		"name":    "esp",
+		"on":      true,
+		"reading": 21.5,
+		"tags":    []interface{}{"a", nil, 2.0},
+		"nested":  map[string]interface{}{"x": -1.0},
+	}
+
+	data, err := MarshalValue(value)
+	Equals("Marshal error", t, nil, err)
+
+	decoded, err := UnmarshalValue(data)
+	Equals("Unmarshal error", t, nil, err)
+	Equals("Round trip", t, encode(value), encode(decoded))
+}
+
+func TestCaseValueWire(t *testing.T) {
+	// string_value = 3 "hi"
+	data, _ := MarshalValue("hi")
+	Equals("String value", t, "1a026869", hex.EncodeToString(data))
+
+	// number_value = 2 double 1.0
+	data, _ = MarshalValue(1)
+	Equals("Number value", t, "11000000000000f03f", hex.EncodeToString(data))
+}
+
+func TestCaseTyped(t *testing.T) {
+	// sint64 value = 1 -2 is zigzag 3
+	data, err := MarshalTyped("integer", -2)
+	Equals("Integer error", t, nil, err)
+	Equals("Integer wire", t, "0803", hex.EncodeToString(data))
+
+	v, _ := UnmarshalTyped("integer", data)
+	Equals("Integer", t, int64(-2), v)
+
+	data, _ = MarshalTyped("number", 21.5)
+	v, _ = UnmarshalTyped("number", data)
+	Equals("Number", t, 21.5, v)
+
+	v, _ = UnmarshalTyped("boolean", []byte{})
+	Equals("Default", t, false, v)
+
+	data, _ = MarshalTyped("object", map[string]interface{}{"a": "b"})
+	v, _ = UnmarshalTyped("object", data)
+	Equals("Object", t, `{"a":"b"}`, encode(v))
+
+	_, err = MarshalTyped("integer", 1.5)
+	Equals("Not integer", t, true, err != nil)
+}
+
+func TestCaseMalformed(t *testing.T) {
+	_, err := UnmarshalValue([]byte{0x1a, 0x05, 'h'})
+	Equals("Truncated", t, ErrMalformed, err)
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
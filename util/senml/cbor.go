@@ -0,0 +1,195 @@
+package senml
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Minimal CBOR (RFC 8949) codec of data items used by SenML: integers, strings, arrays, maps,
+// booleans and floats. Indefinite lengths and tags are not supported.
+
+const (
+	major_UINT   = 0
+	major_NINT   = 1
+	major_BYTES  = 2
+	major_TEXT   = 3
+	major_ARRAY  = 4
+	major_MAP    = 5
+	major_TAG    = 6
+	major_SIMPLE = 7
+)
+
+var ErrMalformed = errors.New("Malformed SenML CBOR")
+
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	m := major << 5
+
+	switch {
+	case n < 24:
+		return append(buf, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, m|26), uint32(n))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, m|27), n)
+}
+
+func appendInt(buf []byte, n int) []byte {
+	if n < 0 {
+		return appendHead(buf, major_NINT, uint64(-1-n))
+	}
+
+	return appendHead(buf, major_UINT, uint64(n))
+}
+
+func appendText(buf []byte, s string) []byte {
+	return append(appendHead(buf, major_TEXT, uint64(len(s))), s...)
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	return append(appendHead(buf, major_BYTES, uint64(len(b))), b...)
+}
+
+func appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, major_SIMPLE<<5|21)
+	}
+
+	return append(buf, major_SIMPLE<<5|20)
+}
+
+// appendFloat uses the shortest of integer, single and double precision representing f exactly
+func appendFloat(buf []byte, f float64) []byte {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return appendInt(buf, int(f))
+	}
+
+	if float64(float32(f)) == f {
+		return binary.BigEndian.AppendUint32(append(buf, major_SIMPLE<<5|26), math.Float32bits(float32(f)))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, major_SIMPLE<<5|27), math.Float64bits(f))
+}
+
+func decodeHead(data []byte) (major byte, info byte, n uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, 0, 0, nil, ErrMalformed
+	}
+
+	major, info = data[0]>>5, data[0]&31
+	data = data[1:]
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return major, info, uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return major, info, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return major, info, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return major, info, binary.BigEndian.Uint64(data), data[8:], nil
+	}
+
+	return 0, 0, 0, nil, ErrMalformed
+}
+
+// decodeItem decodes one data item, integers are int64, floats float64, byte strings []byte
+// and maps map[interface{}]interface{}
+func decodeItem(data []byte) (interface{}, []byte, error) {
+	major, info, n, rest, err := decodeHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case major_UINT:
+		return int64(n), rest, nil
+	case major_NINT:
+		return -1 - int64(n), rest, nil
+	case major_BYTES, major_TEXT:
+		if uint64(len(rest)) < n {
+			return nil, nil, ErrMalformed
+		}
+		if major == major_TEXT {
+			return string(rest[:n]), rest[n:], nil
+		}
+		return append([]byte(nil), rest[:n]...), rest[n:], nil
+	case major_ARRAY:
+		items := make([]interface{}, 0)
+		for i := uint64(0); i < n; i++ {
+			var item interface{}
+			if item, rest, err = decodeItem(rest); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case major_MAP:
+		m := make(map[interface{}]interface{})
+		for i := uint64(0); i < n; i++ {
+			var k, v interface{}
+			if k, rest, err = decodeItem(rest); err != nil {
+				return nil, nil, err
+			}
+			if v, rest, err = decodeItem(rest); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := k.([]byte); ok {
+				return nil, nil, ErrMalformed
+			}
+			m[k] = v
+		}
+		return m, rest, nil
+	case major_TAG:
+		return decodeItem(rest)
+	case major_SIMPLE:
+		switch info {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		case 22, 23:
+			return nil, rest, nil
+		case 25:
+			return halfFloat(uint16(n)), rest, nil
+		case 26:
+			return float64(math.Float32frombits(uint32(n))), rest, nil
+		case 27:
+			return math.Float64frombits(n), rest, nil
+		}
+	}
+
+	return nil, nil, ErrMalformed
+}
+
+func halfFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
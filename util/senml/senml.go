@@ -0,0 +1,292 @@
+// Package senml implements Sensor Measurement Lists (RFC 8428) in JSON and CBOR representation
+package senml
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+)
+
+const (
+	CONTENT_TYPE_JSON = "application/senml+json"
+	CONTENT_TYPE_CBOR = "application/senml+cbor"
+)
+
+// Record is single SenML record, exactly one of Value, StringValue, BoolValue and DataValue is set
+// for records carrying value
+type Record struct {
+	BaseName    string   `json:"bn,omitempty"`
+	BaseTime    float64  `json:"bt,omitempty"`
+	BaseUnit    string   `json:"bu,omitempty"`
+	Name        string   `json:"n,omitempty"`
+	Unit        string   `json:"u,omitempty"`
+	Value       *float64 `json:"v,omitempty"`
+	StringValue *string  `json:"vs,omitempty"`
+	BoolValue   *bool    `json:"vb,omitempty"`
+	DataValue   string   `json:"vd,omitempty"`
+	Sum         *float64 `json:"s,omitempty"`
+	Time        float64  `json:"t,omitempty"`
+	UpdateTime  float64  `json:"ut,omitempty"`
+}
+
+// Pack is list of records, base fields of a record apply to records following it
+type Pack []Record
+
+// CBOR labels of record fields, RFC 8428 section 6
+const (
+	label_BASE_NAME   = -2
+	label_BASE_TIME   = -3
+	label_BASE_UNIT   = -4
+	label_NAME        = 0
+	label_UNIT        = 1
+	label_VALUE       = 2
+	label_STRING      = 3
+	label_BOOL        = 4
+	label_SUM         = 5
+	label_TIME        = 6
+	label_UPDATE_TIME = 7
+	label_DATA        = 8
+)
+
+var ErrNoValue = errors.New("SenML record carries no value")
+
+// Measurement creates record of value measured at t, numbers are sent as v, strings as vs, booleans
+// as vb and other values as JSON document in vs. Zero t leaves time to receiver.
+func Measurement(name, unit string, t time.Time, value interface{}) Record {
+	r := Record{
+		Name: name,
+		Unit: unit,
+	}
+
+	if !t.IsZero() {
+		r.Time = Time(t)
+	}
+
+	switch v := value.(type) {
+	case bool:
+		r.BoolValue = &v
+	case string:
+		r.StringValue = &v
+	default:
+		if n, ok := number(value); ok {
+			r.Value = &n
+			break
+		}
+
+		data, _ := json.Marshal(value)
+		s := string(data)
+		r.StringValue = &s
+	}
+
+	return r
+}
+
+// Time converts t to SenML time, seconds since Unix epoch
+func Time(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// Resolved returns records with base name, time and unit applied (RFC 8428 section 4.6)
+func (p Pack) Resolved() Pack {
+	resolved := make(Pack, 0, len(p))
+	var bn, bu string
+	var bt float64
+
+	for _, r := range p {
+		if r.BaseName != "" {
+			bn = r.BaseName
+		}
+		if r.BaseTime != 0 {
+			bt = r.BaseTime
+		}
+		if r.BaseUnit != "" {
+			bu = r.BaseUnit
+		}
+
+		r.Name = str.Concat(bn, r.Name)
+		r.Time = bt + r.Time
+		if r.Unit == "" {
+			r.Unit = bu
+		}
+		r.BaseName, r.BaseTime, r.BaseUnit = "", 0, ""
+
+		resolved = append(resolved, r)
+	}
+
+	return resolved
+}
+
+// Interface returns value carried by record
+func (r *Record) Interface() (interface{}, error) {
+	switch {
+	case r.Value != nil:
+		return *r.Value, nil
+	case r.StringValue != nil:
+		return *r.StringValue, nil
+	case r.BoolValue != nil:
+		return *r.BoolValue, nil
+	case r.DataValue != "":
+		return r.DataValue, nil
+	case r.Sum != nil:
+		return *r.Sum, nil
+	}
+
+	return nil, ErrNoValue
+}
+
+// MarshalCBOR encodes pack as CBOR array of maps with integer labels
+func MarshalCBOR(p Pack) []byte {
+	buf := appendHead(nil, major_ARRAY, uint64(len(p)))
+
+	for _, r := range p {
+		fields := make([]func([]byte) []byte, 0)
+		text := func(label int, s string) {
+			if s != "" {
+				fields = append(fields, func(b []byte) []byte { return appendText(appendInt(b, label), s) })
+			}
+		}
+		float := func(label int, f float64) {
+			if f != 0 {
+				fields = append(fields, func(b []byte) []byte { return appendFloat(appendInt(b, label), f) })
+			}
+		}
+
+		text(label_BASE_NAME, r.BaseName)
+		float(label_BASE_TIME, r.BaseTime)
+		text(label_BASE_UNIT, r.BaseUnit)
+		text(label_NAME, r.Name)
+		text(label_UNIT, r.Unit)
+		if r.Value != nil {
+			v := *r.Value
+			fields = append(fields, func(b []byte) []byte { return appendFloat(appendInt(b, label_VALUE), v) })
+		}
+		if r.StringValue != nil {
+			v := *r.StringValue
+			fields = append(fields, func(b []byte) []byte { return appendText(appendInt(b, label_STRING), v) })
+		}
+		if r.BoolValue != nil {
+			v := *r.BoolValue
+			fields = append(fields, func(b []byte) []byte { return appendBool(appendInt(b, label_BOOL), v) })
+		}
+		if r.Sum != nil {
+			v := *r.Sum
+			fields = append(fields, func(b []byte) []byte { return appendFloat(appendInt(b, label_SUM), v) })
+		}
+		float(label_TIME, r.Time)
+		float(label_UPDATE_TIME, r.UpdateTime)
+		if r.DataValue != "" {
+			v := r.DataValue
+			fields = append(fields, func(b []byte) []byte { return appendBytes(appendInt(b, label_DATA), []byte(v)) })
+		}
+
+		buf = appendHead(buf, major_MAP, uint64(len(fields)))
+		for _, f := range fields {
+			buf = f(buf)
+		}
+	}
+
+	return buf
+}
+
+// UnmarshalCBOR decodes pack encoded by MarshalCBOR or other RFC 8428 implementation
+func UnmarshalCBOR(data []byte) (Pack, error) {
+	v, rest, err := decodeItem(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformed
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, ErrMalformed
+	}
+
+	p := make(Pack, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return nil, ErrMalformed
+		}
+
+		r := Record{}
+		for k, v := range m {
+			label, ok := k.(int64)
+			if !ok {
+				continue
+			}
+
+			s, _ := v.(string)
+			f, isNumber := number(v)
+
+			switch label {
+			case label_BASE_NAME:
+				r.BaseName = s
+			case label_BASE_TIME:
+				r.BaseTime = f
+			case label_BASE_UNIT:
+				r.BaseUnit = s
+			case label_NAME:
+				r.Name = s
+			case label_UNIT:
+				r.Unit = s
+			case label_VALUE:
+				if isNumber {
+					r.Value = &f
+				}
+			case label_STRING:
+				r.StringValue = &s
+			case label_BOOL:
+				if b, ok := v.(bool); ok {
+					r.BoolValue = &b
+				}
+			case label_SUM:
+				if isNumber {
+					r.Sum = &f
+				}
+			case label_TIME:
+				r.Time = f
+			case label_UPDATE_TIME:
+				r.UpdateTime = f
+			case label_DATA:
+				if b, ok := v.([]byte); ok {
+					r.DataValue = string(b)
+				}
+			}
+		}
+
+		p = append(p, r)
+	}
+
+	return p, nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil && !math.IsNaN(f)
+	}
+
+	return 0, false
+}
@@ -0,0 +1,63 @@
+package senml
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCaseMeasurementJSON(t *testing.T) {
+	r := Measurement("temperature", "Cel", time.Unix(1276020076, 0), 23.5)
+	r.BaseName = "urn:dev:ow:10e2073a01080063/"
+
+	data, _ := json.Marshal(Pack{r})
+	Equals("JSON", t, `[{"bn":"urn:dev:ow:10e2073a01080063/","n":"temperature","u":"Cel","v":23.5,"t":1276020076}]`, string(data))
+
+	r = Measurement("state", "", time.Time{}, true)
+	data, _ = json.Marshal(Pack{r})
+	Equals("Bool", t, `[{"n":"state","vb":true}]`, string(data))
+}
+
+func TestCaseCBOR(t *testing.T) {
+	r := Measurement("temp", "Cel", time.Time{}, 23.5)
+	data := MarshalCBOR(Pack{r})
+
+	// [{0: "temp", 1: "Cel", 2: 23.5}]
+	Equals("CBOR", t, "81a3006474656d70016343656c02fa41bc0000", hex.EncodeToString(data))
+
+	p, err := UnmarshalCBOR(data)
+	Equals("Decode error", t, nil, err)
+	Equals("Decode name", t, "temp", p[0].Name)
+
+	v, _ := p[0].Interface()
+	Equals("Decode value", t, 23.5, v)
+}
+
+func TestCaseResolved(t *testing.T) {
+	v1, v2 := 1.0, 2.0
+	p := Pack{
+		{BaseName: "dev/", BaseTime: 100, BaseUnit: "V", Name: "a", Value: &v1},
+		{Name: "b", Unit: "A", Time: 5, Value: &v2},
+	}
+
+	resolved := p.Resolved()
+	Equals("Name", t, "dev/b", resolved[1].Name)
+	Equals("Time", t, 105.0, resolved[1].Time)
+	Equals("Base unit", t, "V", resolved[0].Unit)
+	Equals("Unit", t, "A", resolved[1].Unit)
+}
+
+func TestCaseHalfFloat(t *testing.T) {
+	// 0xf93e00 is half precision 1.5
+	v, _, err := decodeItem([]byte{0xf9, 0x3e, 0x00})
+	Equals("Half error", t, nil, err)
+	Equals("Half", t, 1.5, v)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,186 @@
+// Package unit converts values between units of measure identified by UCUM codes or QUDT names
+package unit
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+)
+
+// Unit is linear conversion to coherent SI unit of its dimension: si = value * Factor + Offset
+type Unit struct {
+	Code      string
+	Dimension string
+	Factor    float64
+	Offset    float64
+}
+
+const (
+	DIM_TEMPERATURE = "temperature"
+	DIM_LENGTH      = "length"
+	DIM_MASS        = "mass"
+	DIM_TIME        = "time"
+	DIM_PRESSURE    = "pressure"
+	DIM_SPEED       = "speed"
+	DIM_ENERGY      = "energy"
+	DIM_POWER       = "power"
+	DIM_VOLUME      = "volume"
+	DIM_RATIO       = "ratio"
+)
+
+// units are keyed by UCUM code, aliases map QUDT names and common spellings to codes
+var units = map[string]Unit{
+	"K":      {"K", DIM_TEMPERATURE, 1, 0},
+	"Cel":    {"Cel", DIM_TEMPERATURE, 1, 273.15},
+	"[degF]": {"[degF]", DIM_TEMPERATURE, 5.0 / 9, 273.15 - 32*5.0/9},
+
+	"m":      {"m", DIM_LENGTH, 1, 0},
+	"mm":     {"mm", DIM_LENGTH, 1e-3, 0},
+	"cm":     {"cm", DIM_LENGTH, 1e-2, 0},
+	"km":     {"km", DIM_LENGTH, 1e3, 0},
+	"[in_i]": {"[in_i]", DIM_LENGTH, 0.0254, 0},
+	"[ft_i]": {"[ft_i]", DIM_LENGTH, 0.3048, 0},
+	"[mi_i]": {"[mi_i]", DIM_LENGTH, 1609.344, 0},
+
+	"kg":      {"kg", DIM_MASS, 1, 0},
+	"g":       {"g", DIM_MASS, 1e-3, 0},
+	"[lb_av]": {"[lb_av]", DIM_MASS, 0.45359237, 0},
+
+	"s":   {"s", DIM_TIME, 1, 0},
+	"ms":  {"ms", DIM_TIME, 1e-3, 0},
+	"min": {"min", DIM_TIME, 60, 0},
+	"h":   {"h", DIM_TIME, 3600, 0},
+
+	"Pa":    {"Pa", DIM_PRESSURE, 1, 0},
+	"hPa":   {"hPa", DIM_PRESSURE, 1e2, 0},
+	"kPa":   {"kPa", DIM_PRESSURE, 1e3, 0},
+	"bar":   {"bar", DIM_PRESSURE, 1e5, 0},
+	"mbar":  {"mbar", DIM_PRESSURE, 1e2, 0},
+	"atm":   {"atm", DIM_PRESSURE, 101325, 0},
+	"[psi]": {"[psi]", DIM_PRESSURE, 6894.757293168, 0},
+
+	"m/s":      {"m/s", DIM_SPEED, 1, 0},
+	"km/h":     {"km/h", DIM_SPEED, 1 / 3.6, 0},
+	"[mi_i]/h": {"[mi_i]/h", DIM_SPEED, 0.44704, 0},
+	"[kn_i]":   {"[kn_i]", DIM_SPEED, 1852.0 / 3600, 0},
+
+	"J":    {"J", DIM_ENERGY, 1, 0},
+	"kJ":   {"kJ", DIM_ENERGY, 1e3, 0},
+	"W.h":  {"W.h", DIM_ENERGY, 3600, 0},
+	"kW.h": {"kW.h", DIM_ENERGY, 3.6e6, 0},
+
+	"W":  {"W", DIM_POWER, 1, 0},
+	"kW": {"kW", DIM_POWER, 1e3, 0},
+
+	"m3":       {"m3", DIM_VOLUME, 1, 0},
+	"L":        {"L", DIM_VOLUME, 1e-3, 0},
+	"mL":       {"mL", DIM_VOLUME, 1e-6, 0},
+	"[gal_us]": {"[gal_us]", DIM_VOLUME, 0.003785411784, 0},
+
+	"1": {"1", DIM_RATIO, 1, 0},
+	"%": {"%", DIM_RATIO, 1e-2, 0},
+}
+
+var aliases = map[string]string{
+	"kelvin":     "K",
+	"degc":       "Cel",
+	"celsius":    "Cel",
+	"deg_c":      "Cel",
+	"degf":       "[degF]",
+	"fahrenheit": "[degF]",
+	"deg_f":      "[degF]",
+	"meter":      "m",
+	"metre":      "m",
+	"millimeter": "mm",
+	"centimeter": "cm",
+	"kilometer":  "km",
+	"in":         "[in_i]",
+	"inch":       "[in_i]",
+	"ft":         "[ft_i]",
+	"foot":       "[ft_i]",
+	"mi":         "[mi_i]",
+	"mile":       "[mi_i]",
+	"kilogram":   "kg",
+	"gram":       "g",
+	"lb":         "[lb_av]",
+	"pound":      "[lb_av]",
+	"sec":        "s",
+	"second":     "s",
+	"millisec":   "ms",
+	"minute":     "min",
+	"hr":         "h",
+	"hour":       "h",
+	"pascal":     "Pa",
+	"hectopa":    "hPa",
+	"kilopa":     "kPa",
+	"millibar":   "mbar",
+	"psi":        "[psi]",
+	"m-per-sec":  "m/s",
+	"km-per-hr":  "km/h",
+	"mph":        "[mi_i]/h",
+	"mi-per-hr":  "[mi_i]/h",
+	"kn":         "[kn_i]",
+	"knot":       "[kn_i]",
+	"joule":      "J",
+	"wh":         "W.h",
+	"w-hr":       "W.h",
+	"kwh":        "kW.h",
+	"kilowatthr": "kW.h",
+	"kw-hr":      "kW.h",
+	"watt":       "W",
+	"kilowatt":   "kW",
+	"l":          "L",
+	"liter":      "L",
+	"litre":      "L",
+	"ml":         "mL",
+	"millil":     "mL",
+	"m^3":        "m3",
+	"gal":        "[gal_us]",
+	"gal_us":     "[gal_us]",
+	"percent":    "%",
+	"unitless":   "1",
+}
+
+// Lookup finds unit by UCUM code or, case insensitively, by QUDT name or common spelling
+func Lookup(name string) (Unit, bool) {
+	if u, ok := units[name]; ok {
+		return u, true
+	}
+
+	code, ok := aliases[strings.ToLower(name)]
+	if !ok {
+		for c := range units {
+			if strings.EqualFold(c, name) {
+				return units[c], true
+			}
+		}
+		return Unit{}, false
+	}
+
+	return units[code], true
+}
+
+// Convert converts value from unit to unit, both must be known and of the same dimension
+func Convert(value float64, from, to string) (float64, error) {
+	f, ok := Lookup(from)
+	if !ok {
+		return 0, errors.New(str.Concat("Unknown unit: ", from))
+	}
+
+	t, ok := Lookup(to)
+	if !ok {
+		return 0, errors.New(str.Concat("Unknown unit: ", to))
+	}
+
+	if f.Dimension != t.Dimension {
+		return 0, errors.New(str.Concat("Cannot convert ", f.Code, " (", f.Dimension, ") to ", t.Code, " (", t.Dimension, ")"))
+	}
+
+	converted := (value*f.Factor + f.Offset - t.Offset) / t.Factor
+
+	//drop floating point noise of the conversion, e.g. 76.99999999999993 degF
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(converted, 'g', 12, 64), 64)
+	return rounded, nil
+}
@@ -0,0 +1,43 @@
+package unit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCaseConvert(t *testing.T) {
+	for _, c := range []struct {
+		value    float64
+		from, to string
+		expected float64
+	}{
+		{25, "Cel", "[degF]", 77},
+		{-40, "[degF]", "Cel", -40},
+		{77, "degF", "celsius", 25},
+		{0, "Cel", "K", 273.15},
+		{1, "[mi_i]", "km", 1.609344},
+		{1013.25, "hPa", "atm", 1},
+		{100, "km/h", "m/s", 27.7778},
+		{1, "kWh", "J", 3.6e6},
+		{50, "%", "1", 0.5},
+	} {
+		v, err := Convert(c.value, c.from, c.to)
+		Equals(c.from+" to "+c.to+" error", t, nil, err)
+		Equals(c.from+" to "+c.to, t, true, math.Abs(v-c.expected) < 1e-4)
+	}
+}
+
+func TestCaseConvertErrors(t *testing.T) {
+	_, err := Convert(1, "Cel", "m")
+	Equals("Dimension mismatch", t, true, err != nil)
+
+	_, err = Convert(1, "Cel", "furlong")
+	Equals("Unknown unit", t, true, err != nil)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,772 @@
+package xmpp
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+)
+
+// Package xmpp implements client of XMPP core (RFC 6120) for Things and gateways without inbound
+// connectivity: client connects out to server, secures stream by STARTTLS, authenticates by SASL PLAIN
+// and exchanges IQ requests, messages and presence with other entities. Lost connection is
+// re-established with the same handlers. Rosters, multi-user chat and other SASL mechanisms are not
+// supported.
+
+const DEFAULT_PORT = "5222"
+
+const (
+	RECONNECT_WAIT  = 2 * time.Second
+	CONNECT_TIMEOUT = 10 * time.Second
+	// PING_INTERVAL of XEP-0199 pings to server, unanswered ping closes connection and it is re-established
+	PING_INTERVAL = 30 * time.Second
+)
+
+// namespaces of stream, its negotiation and stanzas
+const (
+	nsClient  = "jabber:client"
+	nsStream  = "http://etherx.jabber.org/streams"
+	nsTLS     = "urn:ietf:params:xml:ns:xmpp-tls"
+	nsSASL    = "urn:ietf:params:xml:ns:xmpp-sasl"
+	nsBind    = "urn:ietf:params:xml:ns:xmpp-bind"
+	nsSession = "urn:ietf:params:xml:ns:xmpp-session"
+	nsStanzas = "urn:ietf:params:xml:ns:xmpp-stanzas"
+	NS_PING   = "urn:xmpp:ping"
+)
+
+var (
+	ErrTimeout = errors.New("XMPP request timed out")
+	ErrClosed  = errors.New("XMPP connection closed")
+)
+
+// Options of connection, Server defaults to domain of JID and DEFAULT_PORT
+type Options struct {
+	// JID is user@domain[/resource], server assigns resource when it is missing
+	JID      string
+	Password string
+	Server   string
+	// TLS configures STARTTLS, server name defaults to domain of JID
+	TLS *tls.Config
+	// Insecure allows authentication over stream not secured by TLS, e.g. on local server
+	Insecure bool
+}
+
+// Element is payload of stanza, e.g. request of IQ or extension of message
+type Element struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []Element  `xml:",any"`
+}
+
+// NewElement creates element of namespace
+func NewElement(space, local, text string) *Element {
+	return &Element{XMLName: xml.Name{Space: space, Local: local}, Text: text}
+}
+
+// With sets attribute of element
+func (e *Element) With(name, value string) *Element {
+	e.Attrs = append(e.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	return e
+}
+
+// Attr returns attribute of element, missing attribute is empty
+func (e *Element) Attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// Child returns first child element of local name, nil if there is none
+func (e *Element) Child(local string) *Element {
+	for i := range e.Children {
+		if e.Children[i].XMLName.Local == local {
+			return &e.Children[i]
+		}
+	}
+
+	return nil
+}
+
+// IQ is info/query stanza, requests of type get or set are answered by result or error
+type IQ struct {
+	XMLName xml.Name     `xml:"iq"`
+	ID      string       `xml:"id,attr"`
+	Type    string       `xml:"type,attr"`
+	From    string       `xml:"from,attr,omitempty"`
+	To      string       `xml:"to,attr,omitempty"`
+	Payload *Element     `xml:",any"`
+	Error   *StanzaError `xml:"error"`
+}
+
+// Message is message stanza, Extensions are its child elements other than body and error
+type Message struct {
+	XMLName    xml.Name     `xml:"message"`
+	ID         string       `xml:"id,attr,omitempty"`
+	Type       string       `xml:"type,attr,omitempty"`
+	From       string       `xml:"from,attr,omitempty"`
+	To         string       `xml:"to,attr,omitempty"`
+	Body       string       `xml:"body,omitempty"`
+	Extensions []Element    `xml:",any"`
+	Error      *StanzaError `xml:"error"`
+}
+
+// Extension returns extension of message in namespace, nil if there is none
+func (m *Message) Extension(space string) *Element {
+	for i := range m.Extensions {
+		if m.Extensions[i].XMLName.Space == space {
+			return &m.Extensions[i]
+		}
+	}
+
+	return nil
+}
+
+// Presence is presence stanza, empty Type is available
+type Presence struct {
+	XMLName xml.Name `xml:"presence"`
+	ID      string   `xml:"id,attr,omitempty"`
+	Type    string   `xml:"type,attr,omitempty"`
+	From    string   `xml:"from,attr,omitempty"`
+	To      string   `xml:"to,attr,omitempty"`
+	Show    string   `xml:"show,omitempty"`
+	Status  string   `xml:"status,omitempty"`
+}
+
+// StanzaError is error of stanza, condition is defined by RFC 6120, e.g. item-not-found
+type StanzaError struct {
+	XMLName xml.Name  `xml:"error"`
+	Type    string    `xml:"type,attr,omitempty"`
+	Details []Element `xml:",any"`
+}
+
+// NewError creates stanza error of type (cancel, modify, auth, wait) and condition with optional text
+func NewError(typ, condition, text string) *StanzaError {
+	e := &StanzaError{Type: typ, Details: []Element{{XMLName: xml.Name{Space: nsStanzas, Local: condition}}}}
+	if text != "" {
+		e.Details = append(e.Details, Element{XMLName: xml.Name{Space: nsStanzas, Local: "text"}, Text: text})
+	}
+
+	return e
+}
+
+// Condition returns defined condition of error
+func (e *StanzaError) Condition() string {
+	for _, d := range e.Details {
+		if d.XMLName.Local != "text" {
+			return d.XMLName.Local
+		}
+	}
+
+	return "undefined-condition"
+}
+
+func (e *StanzaError) Error() string {
+	for _, d := range e.Details {
+		if d.XMLName.Local == "text" && d.Text != "" {
+			return str.Concat("XMPP error ", e.Condition(), ": ", d.Text)
+		}
+	}
+
+	return str.Concat("XMPP error ", e.Condition())
+}
+
+// IQHandler answers IQ request by result payload, returned StanzaError is sent as is, other errors as
+// internal-server-error
+type IQHandler func(iq *IQ) (*Element, error)
+
+// Conn is client connection, its handlers are kept when connection is re-established
+type Conn struct {
+	l         *sync.Mutex
+	opts      Options
+	domain    string
+	jid       string
+	conn      net.Conn
+	connected bool
+	closed    bool
+
+	pending    map[string]chan *IQ
+	handlers   map[string]IQHandler
+	onMessage  func(*Message)
+	onPresence func(*Presence)
+}
+
+// features offered by server during stream negotiation
+type features struct {
+	StartTLS   *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-tls starttls"`
+	Mechanisms []string  `xml:"urn:ietf:params:xml:ns:xmpp-sasl mechanisms>mechanism"`
+	Bind       *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-bind bind"`
+	Session    *struct {
+		Optional *struct{} `xml:"optional"`
+	} `xml:"urn:ietf:params:xml:ns:xmpp-session session"`
+}
+
+// Connect connects to server, negotiates stream and sends initial presence
+func Connect(opts Options) (*Conn, error) {
+	_, domain, _ := SplitJID(opts.JID)
+	if domain == "" {
+		return nil, errors.New(str.Concat("Invalid XMPP JID: ", opts.JID))
+	}
+
+	if opts.Server == "" {
+		opts.Server = net.JoinHostPort(domain, DEFAULT_PORT)
+	}
+
+	c := &Conn{
+		l:        &sync.Mutex{},
+		opts:     opts,
+		domain:   domain,
+		pending:  make(map[string]chan *IQ),
+		handlers: make(map[string]IQHandler),
+	}
+
+	conn, d, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.attach(conn)
+	go c.read(conn, d)
+	go c.ping()
+
+	return c, nil
+}
+
+// dial connects and negotiates stream: TLS when offered, authentication and resource binding
+func (c *Conn) dial() (net.Conn, *xml.Decoder, error) {
+	conn, err := net.DialTimeout("tcp", c.opts.Server, CONNECT_TIMEOUT)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(CONNECT_TIMEOUT))
+
+	fail := func(err error) (net.Conn, *xml.Decoder, error) {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	secure, authenticated := false, false
+	for {
+		d, f, err := c.open(conn)
+		if err != nil {
+			return fail(err)
+		}
+
+		switch {
+		case f.StartTLS != nil && !secure:
+			if _, err = io.WriteString(conn, str.Concat("<starttls xmlns='", nsTLS, "'/>")); err != nil {
+				return fail(err)
+			}
+			if err = expect(d, "proceed"); err != nil {
+				return fail(err)
+			}
+
+			tlsConn := tls.Client(conn, c.tlsConfig())
+			if err = tlsConn.Handshake(); err != nil {
+				return fail(err)
+			}
+			conn, secure = tlsConn, true
+		case !authenticated:
+			if !secure && !c.opts.Insecure {
+				return fail(errors.New(str.Concat("XMPP server ", c.opts.Server, " does not offer TLS")))
+			}
+			if err = c.authenticate(conn, d, f); err != nil {
+				return fail(err)
+			}
+			authenticated = true
+		default:
+			if err = c.bind(conn, d, f); err != nil {
+				return fail(err)
+			}
+			if _, err = io.WriteString(conn, "<presence/>"); err != nil {
+				return fail(err)
+			}
+
+			conn.SetDeadline(time.Time{})
+			return conn, d, nil
+		}
+	}
+}
+
+func (c *Conn) tlsConfig() *tls.Config {
+	if c.opts.TLS != nil {
+		return c.opts.TLS
+	}
+
+	return &tls.Config{ServerName: c.domain}
+}
+
+// open opens stream, it is opened again after TLS and authentication, and reads its features
+func (c *Conn) open(conn net.Conn) (*xml.Decoder, *features, error) {
+	header := str.Concat("<?xml version='1.0'?><stream:stream to='", c.domain, "' version='1.0' xmlns='", nsClient, "' xmlns:stream='", nsStream, "'>")
+	if _, err := io.WriteString(conn, header); err != nil {
+		return nil, nil, err
+	}
+
+	d := xml.NewDecoder(conn)
+	start, err := nextElement(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	if start.Name.Space != nsStream || start.Name.Local != "stream" {
+		return nil, nil, errors.New(str.Concat("Unexpected XMPP stream: ", start.Name.Local))
+	}
+
+	if start, err = nextElement(d); err != nil {
+		return nil, nil, err
+	}
+	if start.Name.Local != "features" {
+		return nil, nil, streamError(d, start)
+	}
+
+	f := &features{}
+	if err = d.DecodeElement(f, start); err != nil {
+		return nil, nil, err
+	}
+
+	return d, f, nil
+}
+
+func (c *Conn) authenticate(conn net.Conn, d *xml.Decoder, f *features) error {
+	plain := false
+	for _, m := range f.Mechanisms {
+		plain = plain || m == "PLAIN"
+	}
+	if !plain {
+		return errors.New("XMPP server does not offer SASL PLAIN")
+	}
+
+	user, _, _ := SplitJID(c.opts.JID)
+	credentials := base64.StdEncoding.EncodeToString([]byte(str.Concat("\x00", user, "\x00", c.opts.Password)))
+	if _, err := io.WriteString(conn, str.Concat("<auth xmlns='", nsSASL, "' mechanism='PLAIN'>", credentials, "</auth>")); err != nil {
+		return err
+	}
+
+	return expect(d, "success")
+}
+
+// bind binds resource of JID and establishes session when server requires it
+func (c *Conn) bind(conn net.Conn, d *xml.Decoder, f *features) error {
+	if f.Bind == nil {
+		return errors.New("XMPP server does not offer resource binding")
+	}
+
+	bind := NewElement(nsBind, "bind", "")
+	if _, _, resource := SplitJID(c.opts.JID); resource != "" {
+		bind.Children = []Element{{XMLName: xml.Name{Local: "resource"}, Text: resource}}
+	}
+
+	result, err := negotiate(conn, d, &IQ{ID: "bind", Type: "set", Payload: bind})
+	if err != nil {
+		return err
+	}
+
+	jid := result.Child("jid")
+	if jid == nil {
+		return errors.New("XMPP server did not bind resource")
+	}
+
+	c.l.Lock()
+	c.jid = strings.TrimSpace(jid.Text)
+	c.l.Unlock()
+
+	if f.Session != nil && f.Session.Optional == nil {
+		_, err = negotiate(conn, d, &IQ{ID: "session", Type: "set", Payload: NewElement(nsSession, "session", "")})
+	}
+
+	return err
+}
+
+// negotiate sends IQ and reads its response before stanzas are dispatched
+func negotiate(conn net.Conn, d *xml.Decoder, iq *IQ) (*Element, error) {
+	data, err := xml.Marshal(iq)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.Write(data); err != nil {
+		return nil, err
+	}
+
+	start, err := nextElement(d)
+	if err != nil {
+		return nil, err
+	}
+	if start.Name.Local != "iq" {
+		return nil, streamError(d, start)
+	}
+
+	response := &IQ{}
+	if err = d.DecodeElement(response, start); err != nil {
+		return nil, err
+	}
+	if response.Type == "error" {
+		return nil, stanzaError(response.Error)
+	}
+	if response.Payload == nil {
+		return &Element{}, nil
+	}
+
+	return response.Payload, nil
+}
+
+// expect reads element of local name, failures of SASL and STARTTLS are returned as errors
+func expect(d *xml.Decoder, local string) error {
+	start, err := nextElement(d)
+	if err != nil {
+		return err
+	}
+
+	if start.Name.Local == local {
+		return d.Skip()
+	}
+
+	return streamError(d, start)
+}
+
+// streamError reads unexpected element, e.g. stream error or SASL failure, as error
+func streamError(d *xml.Decoder, start *xml.StartElement) error {
+	failure := &Element{}
+	if err := d.DecodeElement(failure, start); err != nil {
+		return err
+	}
+
+	condition := (&StanzaError{Details: failure.Children}).Condition()
+	return errors.New(str.Concat("XMPP ", start.Name.Local, ": ", condition))
+}
+
+func nextElement(d *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch e := t.(type) {
+		case xml.StartElement:
+			return &e, nil
+		case xml.EndElement:
+			return nil, io.EOF
+		}
+	}
+}
+
+func (c *Conn) attach(conn net.Conn) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.conn = conn
+	c.connected = true
+}
+
+// read dispatches stanzas of conn until it fails, then reconnects unless connection was closed
+func (c *Conn) read(conn net.Conn, d *xml.Decoder) {
+	for {
+		err := c.dispatch(d)
+
+		c.l.Lock()
+		c.connected = false
+		closed := c.closed
+		c.l.Unlock()
+
+		conn.Close()
+		if closed {
+			return
+		}
+
+		log.Warn("XMPP: connection to ", c.opts.Server, " lost -> ", err)
+
+		for {
+			time.Sleep(RECONNECT_WAIT)
+
+			c.l.Lock()
+			closed = c.closed
+			c.l.Unlock()
+			if closed {
+				return
+			}
+
+			if conn, d, err = c.dial(); err == nil {
+				break
+			}
+		}
+
+		log.Info("XMPP: reconnected to ", c.opts.Server)
+		c.attach(conn)
+	}
+}
+
+func (c *Conn) dispatch(d *xml.Decoder) error {
+	for {
+		start, err := nextElement(d)
+		if err != nil {
+			return err
+		}
+
+		switch start.Name.Local {
+		case "iq":
+			iq := &IQ{}
+			if err = d.DecodeElement(iq, start); err != nil {
+				return err
+			}
+			c.iq(iq)
+		case "message":
+			m := &Message{}
+			if err = d.DecodeElement(m, start); err != nil {
+				return err
+			}
+			c.l.Lock()
+			handler := c.onMessage
+			c.l.Unlock()
+			if handler != nil {
+				handler(m)
+			}
+		case "presence":
+			p := &Presence{}
+			if err = d.DecodeElement(p, start); err != nil {
+				return err
+			}
+			c.l.Lock()
+			handler := c.onPresence
+			c.l.Unlock()
+			if handler != nil {
+				handler(p)
+			}
+		case "error":
+			return streamError(d, start)
+		default:
+			if err = d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// iq delivers response to waiting request or serves request by handler of its payload namespace
+func (c *Conn) iq(iq *IQ) {
+	if iq.Type == "result" || iq.Type == "error" {
+		c.l.Lock()
+		response, ok := c.pending[iq.ID]
+		c.l.Unlock()
+
+		if ok {
+			response <- iq
+		}
+		return
+	}
+
+	space := ""
+	if iq.Payload != nil {
+		space = iq.Payload.XMLName.Space
+	}
+
+	c.l.Lock()
+	handler, ok := c.handlers[space]
+	c.l.Unlock()
+
+	reply := &IQ{ID: iq.ID, Type: "result", To: iq.From}
+	switch {
+	case space == NS_PING:
+	case !ok:
+		reply.Type = "error"
+		reply.Error = NewError("cancel", "service-unavailable", "")
+	default:
+		//requests are served concurrently so long actions do not block reading
+		go func() {
+			payload, err := handler(iq)
+			if err != nil {
+				reply.Type = "error"
+				reply.Error = stanzaError(err)
+			} else {
+				reply.Payload = payload
+			}
+			if err = c.Send(reply); err != nil {
+				log.Error("XMPP: request ", iq.ID, " of ", iq.From, " not answered -> ", err)
+			}
+		}()
+		return
+	}
+
+	c.Send(reply)
+}
+
+func stanzaError(err error) *StanzaError {
+	if se, ok := err.(*StanzaError); ok && se != nil {
+		return se
+	}
+	if err == nil {
+		return NewError("cancel", "undefined-condition", "")
+	}
+
+	return NewError("wait", "internal-server-error", err.Error())
+}
+
+// ping detects dead connections which would not fail reading otherwise
+func (c *Conn) ping() {
+	ticker := time.NewTicker(PING_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.l.Lock()
+		closed, connected, conn := c.closed, c.connected, c.conn
+		c.l.Unlock()
+
+		if closed {
+			return
+		}
+
+		//any response proves connection alive, servers without ping support answer by error
+		_, err := c.Request(c.domain, "get", NewElement(NS_PING, "ping", ""), PING_INTERVAL)
+		if connected && err == ErrTimeout {
+			conn.Close()
+		}
+	}
+}
+
+// Send sends stanza, i.e. IQ, Message or Presence
+func (c *Conn) Send(stanza interface{}) error {
+	data, err := xml.Marshal(stanza)
+	if err != nil {
+		return err
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if !c.connected {
+		return errors.New(str.Concat("XMPP not connected to ", c.opts.Server))
+	}
+
+	_, err = c.conn.Write(data)
+	return err
+}
+
+// Request sends IQ of type get or set and waits for its result, error response is returned as StanzaError
+func (c *Conn) Request(to, typ string, payload *Element, timeout time.Duration) (*Element, error) {
+	id, _ := sec.UUID4()
+	response := make(chan *IQ, 1)
+
+	c.l.Lock()
+	c.pending[id] = response
+	c.l.Unlock()
+
+	defer func() {
+		c.l.Lock()
+		delete(c.pending, id)
+		c.l.Unlock()
+	}()
+
+	if err := c.Send(&IQ{ID: id, Type: typ, To: to, Payload: payload}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case iq := <-response:
+		if iq.Type == "error" {
+			return nil, stanzaError(iq.Error)
+		}
+		if iq.Payload == nil {
+			return &Element{}, nil
+		}
+		return iq.Payload, nil
+	case <-time.After(timeout):
+		return nil, ErrTimeout
+	}
+}
+
+// HandleIQ serves IQ requests of payload namespace, requests without handler are answered by
+// service-unavailable error
+func (c *Conn) HandleIQ(space string, handler IQHandler) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if handler == nil {
+		delete(c.handlers, space)
+		return
+	}
+	c.handlers[space] = handler
+}
+
+// OnMessage sets handler of received messages, it is called by reading goroutine in order of messages
+func (c *Conn) OnMessage(handler func(*Message)) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.onMessage = handler
+}
+
+// OnPresence sets handler of received presence, it is called by reading goroutine
+func (c *Conn) OnPresence(handler func(*Presence)) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.onPresence = handler
+}
+
+// JID returns full JID bound by server
+func (c *Conn) JID() string {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.jid
+}
+
+// IsConnected reports whether stream to server is established
+func (c *Conn) IsConnected() bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.connected && !c.closed
+}
+
+// Close sends unavailable presence and closes stream
+func (c *Conn) Close() {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	c.closed = true
+	if c.connected {
+		io.WriteString(c.conn, "<presence type='unavailable'/></stream:stream>")
+		c.conn.Close()
+	}
+}
+
+// SplitJID splits local@domain/resource, local part and resource are optional
+func SplitJID(jid string) (local, domain, resource string) {
+	if i := strings.Index(jid, "/"); i >= 0 {
+		jid, resource = jid[:i], jid[i+1:]
+	}
+	if i := strings.Index(jid, "@"); i >= 0 {
+		local, jid = jid[:i], jid[i+1:]
+	}
+
+	return local, jid, resource
+}
+
+// Bare returns JID without resource
+func Bare(jid string) string {
+	if i := strings.Index(jid, "/"); i >= 0 {
+		return jid[:i]
+	}
+
+	return jid
+}
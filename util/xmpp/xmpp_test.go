@@ -0,0 +1,301 @@
+package xmpp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+)
+
+func TestCaseRequest(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	gw := connect(t, s, "gw@test/gateway")
+	defer gw.Close()
+	consumer := connect(t, s, "consumer@test")
+	defer consumer.Close()
+
+	Equals("bound", t, "gw@test/gateway", gw.JID())
+	Equals("assigned", t, "consumer@test/tno2", consumer.JID())
+
+	gw.HandleIQ("urn:test", func(iq *IQ) (*Element, error) {
+		if iq.Payload.Text == "fail" {
+			return nil, errors.New("failed")
+		}
+		return NewElement("urn:test", "answer", str.Concat("of ", iq.Payload.Text, " from ", Bare(iq.From))).With("k", "v"), nil
+	})
+
+	answer, err := consumer.Request("gw@test/gateway", "get", NewElement("urn:test", "question", "temp"), time.Second)
+	Equals("answered", t, nil, err)
+	Equals("answer", t, "of temp from consumer@test", answer.Text)
+	Equals("attribute", t, "v", answer.Attr("k"))
+
+	_, err = consumer.Request("gw@test/gateway", "set", NewElement("urn:test", "question", "fail"), time.Second)
+	Equals("handler error", t, "internal-server-error", condition(err))
+
+	_, err = consumer.Request("gw@test/gateway", "get", NewElement("urn:other", "question", ""), time.Second)
+	Equals("no handler", t, "service-unavailable", condition(err))
+
+	_, err = consumer.Request("nobody@test", "get", NewElement("urn:test", "question", ""), time.Second)
+	Equals("no recipient", t, "service-unavailable", condition(err))
+
+	_, err = consumer.Request("test", "get", NewElement(NS_PING, "ping", ""), time.Second)
+	Equals("ping", t, nil, err)
+}
+
+func TestCaseMessage(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	gw := connect(t, s, "gw@test/gateway")
+	defer gw.Close()
+	consumer := connect(t, s, "consumer@test/app")
+	defer consumer.Close()
+
+	received := make(chan *Message, 1)
+	consumer.OnMessage(func(m *Message) { received <- m })
+
+	err := gw.Send(&Message{
+		To:         "consumer@test",
+		Body:       "<hi>",
+		Extensions: []Element{*NewElement("urn:test", "event", "42").With("name", "overheat")},
+	})
+	Equals("sent", t, nil, err)
+
+	select {
+	case m := <-received:
+		Equals("from", t, "gw@test/gateway", m.From)
+		Equals("body", t, "<hi>", m.Body)
+		Equals("extension", t, "42", m.Extension("urn:test").Text)
+		Equals("name", t, "overheat", m.Extension("urn:test").Attr("name"))
+		Equals("missing", t, true, m.Extension("urn:other") == nil)
+	case <-time.After(time.Second):
+		t.Log("message not received")
+		t.Fail()
+	}
+
+	gw.Close()
+	Equals("closed", t, ErrClosed, gw.Send(&Presence{}))
+}
+
+func TestCaseAuthentication(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	_, err := Connect(Options{JID: "gw@test", Password: "wrong", Server: s.Addr().String(), Insecure: true})
+	Equals("refused", t, true, err != nil && strings.Contains(err.Error(), "not-authorized"))
+
+	_, err = Connect(Options{JID: "gw@test", Password: "secret", Server: s.Addr().String()})
+	Equals("without TLS", t, true, err != nil)
+
+	_, err = Connect(Options{JID: "gw", Server: s.Addr().String()})
+	Equals("invalid JID", t, true, err != nil)
+}
+
+func TestCaseJID(t *testing.T) {
+	local, domain, resource := SplitJID("dev-1@things.example.org/sensor")
+	Equals("local", t, "dev-1", local)
+	Equals("domain", t, "things.example.org", domain)
+	Equals("resource", t, "sensor", resource)
+
+	local, domain, resource = SplitJID("example.org")
+	Equals("domain only", t, "example.org", str.Concat(local, domain, resource))
+
+	Equals("bare", t, "dev-1@example.org", Bare("dev-1@example.org/a/b"))
+}
+
+func connect(t *testing.T, s *server, jid string) *Conn {
+	c, err := Connect(Options{JID: jid, Password: "secret", Server: s.Addr().String(), Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func condition(err error) string {
+	if se, ok := err.(*StanzaError); ok {
+		return se.Condition()
+	}
+
+	return str.Concat("no stanza error: ", err)
+}
+
+// server is minimal XMPP server of domain "test" without TLS, users authenticate by password "secret".
+// Stanzas are routed to full or bare JID, server answers pings.
+type server struct {
+	net.Listener
+	l       *sync.Mutex
+	clients map[string]net.Conn
+}
+
+func newServer(t *testing.T) *server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &server{Listener: ln, l: &sync.Mutex{}, clients: make(map[string]net.Conn)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// stanza keeps routed stanza as is
+type stanza struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+func (st *stanza) attr(name string) string {
+	for _, a := range st.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func (st *stanza) bytes(from string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(str.Concat("<", st.XMLName.Local, " from='", from, "'"))
+	for _, a := range st.Attrs {
+		if a.Name.Local != "from" {
+			buf.WriteString(str.Concat(" ", a.Name.Local, "='"))
+			xml.EscapeText(&buf, []byte(a.Value))
+			buf.WriteString("'")
+		}
+	}
+	buf.WriteString(">")
+	buf.Write(st.Inner)
+	buf.WriteString(str.Concat("</", st.XMLName.Local, ">"))
+
+	return buf.Bytes()
+}
+
+func (s *server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	//stream is opened again after authentication
+	d, start := s.open(conn, str.Concat("<mechanisms xmlns='", nsSASL, "'><mechanism>PLAIN</mechanism></mechanisms>"))
+	auth := &Element{}
+	if start == nil || d.DecodeElement(auth, start) != nil {
+		return
+	}
+
+	credentials, _ := base64.StdEncoding.DecodeString(auth.Text)
+	parts := strings.Split(string(credentials), "\x00")
+	if len(parts) != 3 || parts[2] != "secret" {
+		io.WriteString(conn, str.Concat("<failure xmlns='", nsSASL, "'><not-authorized/></failure>"))
+		return
+	}
+	io.WriteString(conn, str.Concat("<success xmlns='", nsSASL, "'/>"))
+
+	d, start = s.open(conn, str.Concat("<bind xmlns='", nsBind, "'/><session xmlns='", nsSession, "'/>"))
+	bind := &IQ{}
+	if start == nil || d.DecodeElement(bind, start) != nil {
+		return
+	}
+
+	resource := "tno2"
+	if r := bind.Payload.Child("resource"); r != nil {
+		resource = r.Text
+	}
+	jid := str.Concat(parts[1], "@test/", resource)
+	io.WriteString(conn, str.Concat("<iq type='result' id='", bind.ID, "'><bind xmlns='", nsBind, "'><jid>", jid, "</jid></bind></iq>"))
+
+	s.l.Lock()
+	s.clients[jid] = conn
+	s.l.Unlock()
+
+	defer func() {
+		s.l.Lock()
+		delete(s.clients, jid)
+		s.l.Unlock()
+	}()
+
+	for {
+		start, err := nextElement(d)
+		if err != nil {
+			return
+		}
+
+		st := &stanza{}
+		if d.DecodeElement(st, start) != nil {
+			return
+		}
+
+		to, id, typ := st.attr("to"), st.attr("id"), st.attr("type")
+		request := st.XMLName.Local == "iq" && (typ == "get" || typ == "set")
+
+		switch {
+		case to == "" || to == "test":
+			//session and ping of server
+			if request {
+				io.WriteString(conn, str.Concat("<iq type='result' id='", id, "'/>"))
+			}
+		case s.route(to, st.bytes(jid)):
+		case request:
+			io.WriteString(conn, str.Concat("<iq type='error' id='", id, "' from='", to, "'><error type='cancel'><service-unavailable xmlns='", nsStanzas, "'/></error></iq>"))
+		}
+	}
+}
+
+// open reads stream header, answers it with features and returns first element of client
+func (s *server) open(conn net.Conn, features string) (*xml.Decoder, *xml.StartElement) {
+	d := xml.NewDecoder(conn)
+	if start, err := nextElement(d); err != nil || start.Name.Local != "stream" {
+		return nil, nil
+	}
+
+	io.WriteString(conn, str.Concat("<?xml version='1.0'?><stream:stream from='test' version='1.0' xmlns='", nsClient, "' xmlns:stream='", nsStream, "'>"))
+	io.WriteString(conn, str.Concat("<stream:features>", features, "</stream:features>"))
+
+	start, err := nextElement(d)
+	if err != nil {
+		return nil, nil
+	}
+
+	return d, start
+}
+
+// route writes stanza to client of full JID or to any client of bare JID
+func (s *server) route(to string, data []byte) bool {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for jid, conn := range s.clients {
+		if jid == to || Bare(jid) == to {
+			conn.Write(data)
+			return true
+		}
+	}
+
+	return false
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
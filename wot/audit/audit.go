@@ -0,0 +1,228 @@
+// Package audit records state changing interactions (property writes and action invocations) to
+// tamper-evident log. Entries are chained, hash of every entry covers hash of the previous one, so
+// modified, removed or reordered entries break the chain detected by Verify.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+)
+
+// Entry records who changed what and when, and the result. Seq, Prev and Hash are assigned by Log.
+type Entry struct {
+	Seq       uint64      `json:"seq"`
+	Time      time.Time   `json:"time"`
+	Principal string      `json:"principal,omitempty"`
+	Remote    string      `json:"remote,omitempty"`
+	Tenant    string      `json:"tenant,omitempty"`
+	Thing     string      `json:"thing"`
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Input     interface{} `json:"input,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+	Prev      string      `json:"prev"`
+	Hash      string      `json:"hash"`
+}
+
+// Sink receives every recorded entry, e.g. file, syslog or database
+type Sink interface {
+	Write(e *Entry) error
+}
+
+// Store is sink able to query recorded entries, entries are returned in order they were written
+type Store interface {
+	Sink
+	Query(q *Query) ([]*Entry, error)
+	// Last returns the last written entry, nil for empty store
+	Last() (*Entry, error)
+}
+
+// Query selects entries, empty fields match any entry. Limit keeps the latest entries, 0 is unlimited.
+type Query struct {
+	Thing     string
+	Name      string
+	Kind      string
+	Principal string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+func (q *Query) Match(e *Entry) bool {
+	return (q.Thing == "" || q.Thing == e.Thing) &&
+		(q.Name == "" || q.Name == e.Name) &&
+		(q.Kind == "" || q.Kind == e.Kind) &&
+		(q.Principal == "" || q.Principal == e.Principal) &&
+		(q.From.IsZero() || !e.Time.Before(q.From)) &&
+		(q.To.IsZero() || e.Time.Before(q.To))
+}
+
+// limit keeps the latest q.Limit entries
+func (q *Query) limit(entries []*Entry) []*Entry {
+	if q.Limit > 0 && len(entries) > q.Limit {
+		return entries[len(entries)-q.Limit:]
+	}
+
+	return entries
+}
+
+var ErrNotQueryable = errors.New("Audit log has no queryable store")
+
+// Log chains entries and writes them to store and sinks. Chain continues from the last entry of store.
+type Log struct {
+	l     *sync.Mutex
+	store Store
+	sinks []Sink
+	seq   uint64
+	prev  string
+}
+
+// NewLog creates log writing to store, which is queried by Query and Verify, and to additional sinks.
+// Store may be nil when entries are only written to sinks.
+func NewLog(store Store, sinks ...Sink) (*Log, error) {
+	l := &Log{
+		l:     &sync.Mutex{},
+		store: store,
+		sinks: sinks,
+	}
+
+	if store != nil {
+		last, err := store.Last()
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq, l.prev = last.Seq, last.Hash
+		}
+	}
+
+	return l, nil
+}
+
+// Record chains entry and writes it. Failure of store is returned, failures of other sinks are logged.
+func (l *Log) Record(e *Entry) error {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	e.Seq = l.seq + 1
+	e.Prev = l.prev
+	e.Hash = ""
+
+	//hash covers values as stored, e.g. integers decode as float64
+	if err := normalize(&e.Input); err != nil {
+		return err
+	}
+	if err := normalize(&e.Result); err != nil {
+		return err
+	}
+
+	hash, err := digest(e)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	if l.store != nil {
+		if err := l.store.Write(e); err != nil {
+			return err
+		}
+	}
+
+	l.seq, l.prev = e.Seq, e.Hash
+
+	for _, sink := range l.sinks {
+		if err := sink.Write(e); err != nil {
+			log.Error("Audit: sink write failed -> ", err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Log) Query(q *Query) ([]*Entry, error) {
+	if l.store == nil {
+		return nil, ErrNotQueryable
+	}
+
+	return l.store.Query(q)
+}
+
+// Verify checks chain of all entries in store, see Verify
+func (l *Log) Verify() (int, error) {
+	entries, err := l.Query(&Query{})
+	if err != nil {
+		return 0, err
+	}
+
+	return Verify(entries)
+}
+
+// Verify checks entries form unbroken chain from the first entry of log. Number of verified entries
+// is returned, with error describing the first entry breaking the chain.
+func Verify(entries []*Entry) (int, error) {
+	prev := ""
+
+	for i, e := range entries {
+		if e.Seq != uint64(i+1) {
+			return i, errors.New(str.Concat("Audit entry ", strconv.Itoa(i+1), " missing, found seq ", strconv.FormatUint(e.Seq, 10)))
+		}
+
+		if e.Prev != prev {
+			return i, errors.New(str.Concat("Audit entry ", strconv.FormatUint(e.Seq, 10), " does not follow previous entry"))
+		}
+
+		hash, err := digest(e)
+		if err != nil {
+			return i, err
+		}
+		if hash != e.Hash {
+			return i, errors.New(str.Concat("Audit entry ", strconv.FormatUint(e.Seq, 10), " was modified"))
+		}
+
+		prev = e.Hash
+	}
+
+	return len(entries), nil
+}
+
+// digest is SHA-256 of JSON encoded entry without its hash, Prev links it to the previous entry
+func digest(e *Entry) (string, error) {
+	c := *e
+	c.Hash = ""
+
+	data, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func normalize(v *interface{}) error {
+	if *v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(*v)
+	if err != nil {
+		return err
+	}
+
+	*v = nil
+	return json.Unmarshal(data, v)
+}
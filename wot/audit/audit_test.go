@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaseChain(t *testing.T) {
+	store := NewMemoryStore()
+	l, _ := NewLog(store)
+
+	l.Record(&Entry{Principal: "alice", Thing: "boiler", Kind: "writeproperty", Name: "setpoint", Input: 21})
+	l.Record(&Entry{Principal: "bob", Thing: "boiler", Kind: "invokeaction", Name: "purge", Result: map[string]interface{}{"ok": true}})
+	l.Record(&Entry{Principal: "alice", Thing: "valve", Kind: "writeproperty", Name: "open", Input: true})
+
+	n, err := l.Verify()
+	Equals("verified", t, 3, n)
+	Equals("valid", t, nil, err)
+
+	alice, _ := l.Query(&Query{Principal: "alice"})
+	Equals("by principal", t, 2, len(alice))
+
+	latest, _ := l.Query(&Query{Thing: "boiler", Limit: 1})
+	Equals("latest", t, "purge", latest[0].Name)
+
+	//modified entry
+	store.entries[1].Principal = "mallory"
+	n, err = l.Verify()
+	Equals("modified at", t, 1, n)
+	Equals("modified", t, true, err != nil)
+	store.entries[1].Principal = "bob"
+
+	//removed entry
+	store.entries = append(store.entries[:1], store.entries[2:]...)
+	n, err = l.Verify()
+	Equals("removed at", t, 1, n)
+	Equals("removed", t, true, err != nil)
+}
+
+func TestCaseFileStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	store, err := OpenFile(path)
+	Equals("opened", t, nil, err)
+	l, _ := NewLog(store)
+	l.Record(&Entry{Thing: "boiler", Kind: "writeproperty", Name: "setpoint", Input: 21})
+	store.Close()
+
+	//chain continues after restart
+	store, _ = OpenFile(path)
+	defer store.Close()
+	l, _ = NewLog(store)
+	l.Record(&Entry{Thing: "boiler", Kind: "writeproperty", Name: "setpoint", Input: 22.5})
+
+	entries, _ := l.Query(&Query{})
+	Equals("entries", t, 2, len(entries))
+	Equals("seq", t, uint64(2), entries[1].Seq)
+	Equals("input", t, 22.5, entries[1].Input)
+
+	n, err := Verify(entries)
+	Equals("verified", t, 2, n)
+	Equals("valid", t, nil, err)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileStore appends entries to file as JSON lines, every entry is synced to disk before it is acknowledged
+type FileStore struct {
+	l    *sync.Mutex
+	path string
+	f    *os.File
+}
+
+func OpenFile(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileStore{
+		l:    &sync.Mutex{},
+		path: path,
+		f:    f,
+	}, nil
+}
+
+func (s *FileStore) Write(e *Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if _, err = s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	return s.f.Sync()
+}
+
+func (s *FileStore) Query(q *Query) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+
+	err := s.scan(func(e *Entry) {
+		if q.Match(e) {
+			entries = append(entries, e)
+		}
+	})
+
+	return q.limit(entries), err
+}
+
+func (s *FileStore) Last() (*Entry, error) {
+	var last *Entry
+
+	err := s.scan(func(e *Entry) {
+		last = e
+	})
+
+	return last, err
+}
+
+func (s *FileStore) Close() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.f.Close()
+}
+
+func (s *FileStore) scan(f func(*Entry)) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		e := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return err
+		}
+		f(e)
+	}
+
+	return scanner.Err()
+}
+
+// MemoryStore keeps entries in memory, e.g. for tests or deployments auditing to remote sinks only
+type MemoryStore struct {
+	l       *sync.RWMutex
+	entries []*Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		l:       &sync.RWMutex{},
+		entries: make([]*Entry, 0),
+	}
+}
+
+func (s *MemoryStore) Write(e *Entry) error {
+	c := *e
+
+	s.l.Lock()
+	s.entries = append(s.entries, &c)
+	s.l.Unlock()
+
+	return nil
+}
+
+func (s *MemoryStore) Query(q *Query) ([]*Entry, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	entries := make([]*Entry, 0)
+	for _, e := range s.entries {
+		if q.Match(e) {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+
+	return q.limit(entries), nil
+}
+
+func (s *MemoryStore) Last() (*Entry, error) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.entries) == 0 {
+		return nil, nil
+	}
+
+	c := *s.entries[len(s.entries)-1]
+	return &c, nil
+}
@@ -0,0 +1,36 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink writes entries as JSON to syslog, facility AUTHPRIV keeps them apart from regular logs
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink connects to syslog daemon, empty network and raddr connect to local daemon
+func NewSyslogSink(network, raddr, tag string) (*SyslogSink, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_NOTICE|syslog.LOG_AUTHPRIV, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyslogSink{w: w}, nil
+}
+
+func (s *SyslogSink) Write(e *Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.w.Notice(string(data))
+}
+
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
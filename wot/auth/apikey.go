@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net/http"
+	"sync"
+)
+
+// APIKeys authenticates clients using "X-API-Key" header, "api_key" query parameter is accepted only
+// when enabled by AcceptQuery
+type APIKeys struct {
+	l     *sync.RWMutex
+	keys  map[string]*Principal
+	query bool
+}
+
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{
+		l:    &sync.RWMutex{},
+		keys: make(map[string]*Principal),
+	}
+}
+
+func (ak *APIKeys) Add(key, principalID string, roles ...string) *APIKeys {
+	ak.l.Lock()
+	defer ak.l.Unlock()
+
+	ak.keys[key] = &Principal{
+		ID:    principalID,
+		Roles: roles,
+	}
+
+	return ak
+}
+
+// AcceptQuery accepts key passed as "api_key" query parameter too, e.g. by browser WebSocket clients
+// which cannot set headers. Keys in URLs leak to access logs and Referer headers.
+func (ak *APIKeys) AcceptQuery() *APIKeys {
+	ak.l.Lock()
+	defer ak.l.Unlock()
+
+	ak.query = true
+
+	return ak
+}
+
+func (ak *APIKeys) Remove(key string) {
+	ak.l.Lock()
+	defer ak.l.Unlock()
+
+	delete(ak.keys, key)
+}
+
+func (ak *APIKeys) Scheme() string {
+	return SCHEME_APIKEY
+}
+
+func (ak *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	ak.l.RLock()
+	defer ak.l.RUnlock()
+
+	key := r.Header.Get("X-API-Key")
+
+	if key == "" && ak.query {
+		key = r.URL.Query().Get("api_key")
+	}
+
+	if key == "" {
+		return nil, nil
+	}
+
+	p, ok := ak.keys[key]
+
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return p, nil
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+)
+
+// Right is a permission granted on a Thing interaction
+type Right int
+
+const (
+	RIGHT_READ Right = 1 << iota
+	RIGHT_WRITE
+	RIGHT_INVOKE
+	RIGHT_SUBSCRIBE
+
+	RIGHT_ALL = RIGHT_READ | RIGHT_WRITE | RIGHT_INVOKE | RIGHT_SUBSCRIBE
+)
+
+type Status int
+
+const (
+	AUTH_OK Status = iota
+	AUTH_UNAUTHENTICATED
+	AUTH_FORBIDDEN
+)
+
+// Principal is authenticated identity of the client together with roles
+// carried by its credential (JWT claims, API key configuration)
+type Principal struct {
+	ID    string   `json:"id"`
+	Roles []string `json:"roles"`
+}
+
+// Authenticator extracts principal from the request.
+// (nil, nil) is returned when request does not carry credentials the authenticator understands,
+// so next authenticator in chain can be tried.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Security schemes of authenticators, named as OpenAPI security scheme types
+const (
+	SCHEME_APIKEY = "apiKey"
+	SCHEME_BEARER = "bearer"
+	SCHEME_MTLS   = "mutualTLS"
+)
+
+// Schemer is implemented by authenticators advertising security scheme clients authenticate with
+type Schemer interface {
+	Scheme() string
+}
+
+// Resource creates name of the interaction resource authorization rules are matched against
+func Resource(thing, interaction string) string {
+	return str.Concat(thing, "/", interaction)
+}
+
+// Guard combines authentication chain with RBAC authorization
+type Guard struct {
+	authenticators []Authenticator
+	rbac           *RBAC
+}
+
+func NewGuard(rbac *RBAC, authenticators ...Authenticator) *Guard {
+	return &Guard{
+		authenticators: authenticators,
+		rbac:           rbac,
+	}
+}
+
+func (g *Guard) Authenticate(r *http.Request) (*Principal, Status) {
+	for _, a := range g.authenticators {
+		p, err := a.Authenticate(r)
+
+		if err != nil {
+			return nil, AUTH_UNAUTHENTICATED
+		}
+
+		if p != nil {
+			return p, AUTH_OK
+		}
+	}
+
+	return nil, AUTH_UNAUTHENTICATED
+}
+
+// Schemes returns security schemes accepted by the guard in order of its authenticators
+func (g *Guard) Schemes() []string {
+	schemes := make([]string, 0, len(g.authenticators))
+
+	for _, a := range g.authenticators {
+		if s, ok := a.(Schemer); ok {
+			schemes = append(schemes, s.Scheme())
+		}
+	}
+
+	return schemes
+}
+
+func (g *Guard) Check(r *http.Request, right Right, resource string) (*Principal, Status) {
+	p, status := g.Authenticate(r)
+
+	if status != AUTH_OK {
+		return nil, status
+	}
+
+	if !g.rbac.Allowed(p, right, resource) {
+		return p, AUTH_FORBIDDEN
+	}
+
+	return p, AUTH_OK
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+
+	if len(h) > 7 && strings.EqualFold(h[0:7], "Bearer ") {
+		return strings.TrimSpace(h[7:]), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCaseRBACWildcards(t *testing.T) {
+	rbac := NewRBAC().
+		AddRole("viewer", Allow(RIGHT_READ|RIGHT_SUBSCRIBE, "*")).
+		AddRole("operator", Allow(RIGHT_WRITE|RIGHT_INVOKE, "dht-*/relay")).
+		Assign("alice", "operator")
+
+	alice := &Principal{ID: "alice", Roles: []string{"viewer"}}
+	bob := &Principal{ID: "bob", Roles: []string{"viewer"}}
+
+	Equals("RBAC.0", t, true, rbac.Allowed(alice, RIGHT_READ, "dht-1/temperature"))
+	Equals("RBAC.1", t, true, rbac.Allowed(alice, RIGHT_WRITE, "dht-1/relay"))
+	Equals("RBAC.2", t, false, rbac.Allowed(alice, RIGHT_WRITE, "dht-1/temperature"))
+	Equals("RBAC.3", t, false, rbac.Allowed(bob, RIGHT_INVOKE, "dht-1/relay"))
+	Equals("RBAC.4", t, false, rbac.Allowed(alice, RIGHT_WRITE|RIGHT_READ, "dht-1/relay"))
+}
+
+func TestCaseGuardJWTAndAPIKey(t *testing.T) {
+	jwt := NewJWT([]byte("secret"))
+	keys := NewAPIKeys().Add("k-1", "service", "admin")
+	rbac := NewRBAC().
+		AddRole("admin", Allow(RIGHT_ALL, "*")).
+		AddRole("viewer", Allow(RIGHT_READ, "*"))
+	guard := NewGuard(rbac, keys, jwt)
+
+	token, _ := jwt.Sign(map[string]interface{}{
+		"sub":   "alice",
+		"roles": []string{"viewer"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	expired, _ := jwt.Sign(map[string]interface{}{
+		"sub": "alice",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+
+	r, _ := http.NewRequest("GET", "http://localhost/thing/temperature", nil)
+	_, status := guard.Check(r, RIGHT_READ, "thing/temperature")
+	Equals("Guard.0", t, AUTH_UNAUTHENTICATED, status)
+
+	r.Header.Set("Authorization", "Bearer "+token)
+	p, status := guard.Check(r, RIGHT_READ, "thing/temperature")
+	Equals("Guard.1", t, AUTH_OK, status)
+	Equals("Guard.2", t, "alice", p.ID)
+
+	_, status = guard.Check(r, RIGHT_WRITE, "thing/temperature")
+	Equals("Guard.3", t, AUTH_FORBIDDEN, status)
+
+	r.Header.Set("Authorization", "Bearer "+expired)
+	_, status = guard.Check(r, RIGHT_READ, "thing/temperature")
+	Equals("Guard.4", t, AUTH_UNAUTHENTICATED, status)
+
+	r.Header.Del("Authorization")
+	r.Header.Set("X-API-Key", "k-1")
+	_, status = guard.Check(r, RIGHT_WRITE, "thing/temperature")
+	Equals("Guard.5", t, AUTH_OK, status)
+}
+
+func TestCaseAPIKeyQuery(t *testing.T) {
+	keys := NewAPIKeys().Add("k-1", "service", "admin")
+
+	r, _ := http.NewRequest("GET", "http://localhost/thing/temperature?api_key=k-1", nil)
+	p, err := keys.Authenticate(r)
+	Equals("Query.0", t, true, p == nil && err == nil)
+
+	keys.AcceptQuery()
+	p, err = keys.Authenticate(r)
+	Equals("Query.1", t, nil, err)
+	Equals("Query.2", t, "service", p.ID)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log("\nTest: ", assetName, "\nExpected:", expected, "\nReturned:", actual)
+		t.Fail()
+	}
+}
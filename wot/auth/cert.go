@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/x509"
+	"net/http"
+)
+
+// ClientCert authenticates clients by X.509 certificate verified during TLS handshake. Principal ID is
+// common name of certificate unless Principal maps certificate otherwise, roles are assigned by RBAC.
+// Certificates rejected by Revocation are invalid credentials.
+type ClientCert struct {
+	Principal  func(cert *x509.Certificate) string
+	Revocation RevocationChecker
+}
+
+func NewClientCert() *ClientCert {
+	return &ClientCert{
+		Principal: func(cert *x509.Certificate) string {
+			return cert.Subject.CommonName
+		},
+	}
+}
+
+func (c *ClientCert) Scheme() string {
+	return SCHEME_MTLS
+}
+
+func (c *ClientCert) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil, nil
+	}
+
+	chain := r.TLS.VerifiedChains[0]
+	cert := chain[0]
+
+	if c.Revocation != nil {
+		var issuer *x509.Certificate
+		if len(chain) > 1 {
+			issuer = chain[1]
+		}
+
+		if err := c.Revocation.Check(cert, issuer); err != nil {
+			return nil, err
+		}
+	}
+
+	id := c.Principal(cert)
+	if id == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		ID:    id,
+		Roles: make([]string, 0),
+	}, nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCredentials = errors.New("Invalid credentials.")
+
+// JWT authenticates bearer tokens signed with HS256.
+// Principal ID is taken from "sub" claim, roles from claim configured by RolesClaim (default "roles").
+type JWT struct {
+	secret     []byte
+	RolesClaim string
+	Leeway     time.Duration
+}
+
+func NewJWT(secret []byte) *JWT {
+	return &JWT{
+		secret:     secret,
+		RolesClaim: "roles",
+	}
+}
+
+func (j *JWT) Scheme() string {
+	return SCHEME_BEARER
+}
+
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, nil
+	}
+
+	claims, err := j.Verify(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	p := &Principal{
+		ID:    sub,
+		Roles: make([]string, 0),
+	}
+
+	switch roles := claims[j.RolesClaim].(type) {
+	case string:
+		p.Roles = append(p.Roles, strings.Fields(roles)...)
+	case []interface{}:
+		for _, role := range roles {
+			if s, ok := role.(string); ok {
+				p.Roles = append(p.Roles, s)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Verify checks token signature and time validity and returns its claims
+func (j *JWT) Verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, ErrInvalidCredentials
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidCredentials
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, j.sign(parts[0], parts[1])) {
+		return nil, ErrInvalidCredentials
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(j.Leeway)) {
+		return nil, ErrInvalidCredentials
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(j.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return claims, nil
+}
+
+// Sign creates HS256 token for given claims. Useful for issuing tokens and tests.
+func (j *JWT) Sign(claims map[string]interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", err
+	}
+
+	h := base64.RawURLEncoding.EncodeToString(header)
+	p := base64.RawURLEncoding.EncodeToString(payload)
+
+	return h + "." + p + "." + base64.RawURLEncoding.EncodeToString(j.sign(h, p)), nil
+}
+
+func (j *JWT) sign(header, payload string) []byte {
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write([]byte(header + "." + payload))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+
+	_ "crypto/sha1"
+)
+
+// OCSP certificate statuses (RFC 6960)
+const (
+	OCSP_GOOD = iota
+	OCSP_REVOKED
+	OCSP_UNKNOWN
+)
+
+var ErrOCSPResponse = errors.New("Invalid OCSP response.")
+
+var (
+	oidSHA1       = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasic  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	signatureOIDs = map[string]x509.SignatureAlgorithm{
+		"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+		"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+		"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+		"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+		"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+		"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+		"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+		"1.3.101.112":           x509.PureEd25519,
+	}
+)
+
+// OCSPResponse is status of single certificate reported by responder
+type OCSPResponse struct {
+	Status       int
+	SerialNumber *big.Int
+	ThisUpdate   time.Time
+	NextUpdate   time.Time
+	RevokedAt    time.Time
+}
+
+// ----- ASN.1 structures of RFC 6960
+
+type certID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest tbsRequest
+}
+
+type tbsRequest struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	RequestList []singleRequest
+}
+
+type singleRequest struct {
+	Cert certID
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type basicResponse struct {
+	TBSResponseData    responseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type responseData struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          []singleResponse
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type singleResponse struct {
+	CertID           certID
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          revokedInfo      `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// CreateOCSPRequest encodes request for status of cert, certificate is identified by SHA-1 hashes of issuer
+func CreateOCSPRequest(cert, issuer *x509.Certificate) ([]byte, error) {
+	id, err := newCertID(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(ocspRequest{
+		TBSRequest: tbsRequest{
+			RequestList: []singleRequest{{Cert: *id}},
+		},
+	})
+}
+
+// ParseOCSPResponse decodes response of responder and verifies it is signed by issuer, or by responder
+// certificate issued by issuer for OCSP signing, and it reports current status of cert
+func ParseOCSPResponse(data []byte, cert, issuer *x509.Certificate) (*OCSPResponse, error) {
+	var rs ocspResponse
+	if rest, err := asn1.Unmarshal(data, &rs); err != nil || len(rest) > 0 {
+		return nil, ErrOCSPResponse
+	}
+
+	if rs.Status != 0 || !rs.Response.ResponseType.Equal(oidOCSPBasic) {
+		return nil, ErrOCSPResponse
+	}
+
+	var basic basicResponse
+	if rest, err := asn1.Unmarshal(rs.Response.Response, &basic); err != nil || len(rest) > 0 {
+		return nil, ErrOCSPResponse
+	}
+
+	signer, err := responder(&basic, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, ok := signatureOIDs[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return nil, ErrOCSPResponse
+	}
+
+	if signer.CheckSignature(algorithm, basic.TBSResponseData.Raw, basic.Signature.RightAlign()) != nil {
+		return nil, ErrOCSPResponse
+	}
+
+	id, err := newCertID(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, single := range basic.TBSResponseData.Responses {
+		if single.CertID.SerialNumber.Cmp(id.SerialNumber) != 0 || !bytes.Equal(single.CertID.NameHash, id.NameHash) ||
+			!bytes.Equal(single.CertID.IssuerKeyHash, id.IssuerKeyHash) {
+			continue
+		}
+
+		if single.ThisUpdate.After(now.Add(time.Minute)) || (!single.NextUpdate.IsZero() && now.After(single.NextUpdate)) {
+			return nil, ErrOCSPResponse
+		}
+
+		status := &OCSPResponse{
+			Status:       OCSP_GOOD,
+			SerialNumber: single.CertID.SerialNumber,
+			ThisUpdate:   single.ThisUpdate,
+			NextUpdate:   single.NextUpdate,
+		}
+
+		switch {
+		case !single.Revoked.RevocationTime.IsZero():
+			status.Status = OCSP_REVOKED
+			status.RevokedAt = single.Revoked.RevocationTime
+		case bool(single.Unknown):
+			status.Status = OCSP_UNKNOWN
+		}
+
+		return status, nil
+	}
+
+	return nil, ErrOCSPResponse
+}
+
+// responder returns certificate signing response, delegated responder must be issued by issuer
+func responder(basic *basicResponse, issuer *x509.Certificate) (*x509.Certificate, error) {
+	if len(basic.Certificates) == 0 {
+		return issuer, nil
+	}
+
+	cert, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+	if err != nil {
+		return nil, ErrOCSPResponse
+	}
+
+	if bytes.Equal(cert.Raw, issuer.Raw) {
+		return issuer, nil
+	}
+
+	if cert.CheckSignatureFrom(issuer) != nil {
+		return nil, ErrOCSPResponse
+	}
+
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageOCSPSigning {
+			return cert, nil
+		}
+	}
+
+	return nil, ErrOCSPResponse
+}
+
+func newCertID(cert, issuer *x509.Certificate) (*certID, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+
+	nameHash := crypto.SHA1.New()
+	nameHash.Write(issuer.RawSubject)
+	keyHash := crypto.SHA1.New()
+	keyHash.Write(spki.PublicKey.RightAlign())
+
+	return &certID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+		NameHash:      nameHash.Sum(nil),
+		IssuerKeyHash: keyHash.Sum(nil),
+		SerialNumber:  cert.SerialNumber,
+	}, nil
+}
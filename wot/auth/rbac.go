@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+)
+
+// Grant gives right to all interactions matching pattern.
+// Pattern is matched against "thing/interaction" resource, '*' matches any sequence of characters.
+type Grant struct {
+	Rights  Right
+	Pattern string
+}
+
+func Allow(rights Right, pattern string) Grant {
+	return Grant{
+		Rights:  rights,
+		Pattern: pattern,
+	}
+}
+
+type Role struct {
+	Name   string
+	Grants []Grant
+}
+
+// RBAC maps principals to roles and roles to interaction rights
+type RBAC struct {
+	l           *sync.RWMutex
+	roles       map[string]*Role
+	assignments map[string][]string
+}
+
+func NewRBAC() *RBAC {
+	return &RBAC{
+		l:           &sync.RWMutex{},
+		roles:       make(map[string]*Role),
+		assignments: make(map[string][]string),
+	}
+}
+
+func (rb *RBAC) AddRole(name string, grants ...Grant) *RBAC {
+	rb.l.Lock()
+	defer rb.l.Unlock()
+
+	rb.roles[name] = &Role{
+		Name:   name,
+		Grants: grants,
+	}
+
+	return rb
+}
+
+// Assign adds roles to principal in addition to roles carried by its credential
+func (rb *RBAC) Assign(principalID string, roles ...string) *RBAC {
+	rb.l.Lock()
+	defer rb.l.Unlock()
+
+	rb.assignments[principalID] = append(rb.assignments[principalID], roles...)
+
+	return rb
+}
+
+func (rb *RBAC) Allowed(p *Principal, right Right, resource string) bool {
+	rb.l.RLock()
+	defer rb.l.RUnlock()
+
+	roles := append(append([]string{}, p.Roles...), rb.assignments[p.ID]...)
+
+	for _, roleName := range roles {
+		role, ok := rb.roles[roleName]
+
+		if !ok {
+			continue
+		}
+
+		for _, g := range role.Grants {
+			if g.Rights&right == right && match(g.Pattern, resource) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// match is simple glob where '*' matches any sequence of characters including '/'
+func match(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const OCSP_TIMEOUT = 5 * time.Second
+
+var (
+	ErrRevoked           = errors.New("Certificate revoked.")
+	ErrRevocationUnknown = errors.New("Certificate revocation status unknown.")
+)
+
+// RevocationChecker rejects revoked client certificates, issuer is nil for self signed certificates
+type RevocationChecker interface {
+	Check(cert, issuer *x509.Certificate) error
+}
+
+// Revocations checks certificate by all checkers, e.g. CRL of offline CA and OCSP of online CA
+type Revocations []RevocationChecker
+
+func (rs Revocations) Check(cert, issuer *x509.Certificate) error {
+	for _, r := range rs {
+		if err := r.Check(cert, issuer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CRL checks certificates against certificate revocation lists loaded from PEM or DER files. Lists
+// are matched to certificate issuer and their signature is verified, Reload reads files again.
+type CRL struct {
+	paths []string
+	l     *sync.RWMutex
+	lists []*x509.RevocationList
+}
+
+func NewCRL(paths ...string) (*CRL, error) {
+	c := &CRL{
+		paths: paths,
+		l:     &sync.RWMutex{},
+	}
+
+	return c, c.Reload()
+}
+
+func (c *CRL) Reload() error {
+	lists := make([]*x509.RevocationList, 0, len(c.paths))
+
+	for _, path := range c.paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+
+		list, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return errors.New(path + ": " + err.Error())
+		}
+
+		lists = append(lists, list)
+	}
+
+	c.l.Lock()
+	c.lists = lists
+	c.l.Unlock()
+
+	return nil
+}
+
+// Check rejects certificates listed by CRL of their issuer, expired list is not trusted
+func (c *CRL) Check(cert, issuer *x509.Certificate) error {
+	if issuer == nil {
+		return nil
+	}
+
+	c.l.RLock()
+	defer c.l.RUnlock()
+
+	for _, list := range c.lists {
+		if !bytes.Equal(list.RawIssuer, cert.RawIssuer) || list.CheckSignatureFrom(issuer) != nil {
+			continue
+		}
+
+		if !list.NextUpdate.IsZero() && time.Now().After(list.NextUpdate) {
+			return ErrRevocationUnknown
+		}
+
+		for _, entry := range list.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return ErrRevoked
+			}
+		}
+	}
+
+	return nil
+}
+
+// OCSP checks certificates at responders listed in their Authority Information Access extension.
+// Responses are cached until their next update. Certificates without responder pass, responder
+// failures fail the check when Strict is set.
+type OCSP struct {
+	Client *http.Client
+	Strict bool
+	l      *sync.Mutex
+	cache  map[string]*ocspStatus
+}
+
+type ocspStatus struct {
+	err        error
+	nextUpdate time.Time
+}
+
+func NewOCSP() *OCSP {
+	return &OCSP{
+		Client: &http.Client{Timeout: OCSP_TIMEOUT},
+		l:      &sync.Mutex{},
+		cache:  make(map[string]*ocspStatus),
+	}
+}
+
+func (o *OCSP) Check(cert, issuer *x509.Certificate) error {
+	if issuer == nil || len(cert.OCSPServer) == 0 {
+		return nil
+	}
+
+	key := string(issuer.RawSubject) + cert.SerialNumber.String()
+
+	o.l.Lock()
+	cached, ok := o.cache[key]
+	o.l.Unlock()
+
+	if ok && time.Now().Before(cached.nextUpdate) {
+		return cached.err
+	}
+
+	rs, err := o.query(cert, issuer)
+	if err != nil {
+		if o.Strict {
+			return ErrRevocationUnknown
+		}
+		return nil
+	}
+
+	status := &ocspStatus{nextUpdate: rs.NextUpdate}
+	switch rs.Status {
+	case OCSP_REVOKED:
+		status.err = ErrRevoked
+	case OCSP_UNKNOWN:
+		status.err = ErrRevocationUnknown
+	}
+
+	if status.nextUpdate.IsZero() {
+		status.nextUpdate = time.Now().Add(time.Minute)
+	}
+
+	o.l.Lock()
+	o.cache[key] = status
+	o.l.Unlock()
+
+	return status.err
+}
+
+func (o *OCSP) query(cert, issuer *x509.Certificate) (*OCSPResponse, error) {
+	rq, err := CreateOCSPRequest(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		rs, err := o.Client.Post(server, "application/ocsp-request", bytes.NewReader(rq))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		data, err := ioutil.ReadAll(rs.Body)
+		rs.Body.Close()
+
+		if err != nil || rs.StatusCode != http.StatusOK {
+			lastErr = errors.New("OCSP responder failed: " + server)
+			continue
+		}
+
+		return ParseOCSPResponse(data, cert, issuer)
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert, key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, cn string, ocsp ...string) *x509.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		OCSPServer:   ocsp,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+// respond creates basic OCSP response signed by CA
+func (ca *testCA) respond(t *testing.T, cert *x509.Certificate, revoked bool) []byte {
+	id, _ := newCertID(cert, ca.cert)
+	single := singleResponse{
+		CertID:     *id,
+		ThisUpdate: time.Now().Add(-time.Minute).UTC(),
+		NextUpdate: time.Now().Add(time.Hour).UTC(),
+	}
+	if revoked {
+		single.Revoked = revokedInfo{RevocationTime: time.Now().Add(-time.Minute).UTC()}
+	} else {
+		single.Good = true
+	}
+
+	tbs, err := asn1.Marshal(responseData{
+		RawResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: ca.cert.RawSubject},
+		ProducedAt:     time.Now().UTC(),
+		Responses:      []singleResponse{single},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write(tbs)
+	sig, _ := ca.key.Sign(rand.Reader, digest.Sum(nil), crypto.SHA256)
+
+	basic, _ := asn1.Marshal(basicResponse{
+		TBSResponseData:    responseData{Raw: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	})
+
+	rs, _ := asn1.Marshal(ocspResponse{
+		Response: responseBytes{ResponseType: oidOCSPBasic, Response: basic},
+	})
+
+	return rs
+}
+
+func TestCaseCRL(t *testing.T) {
+	ca := newTestCA(t)
+	good, revoked := ca.issue(t, 10, "good"), ca.issue(t, 11, "revoked")
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: revoked.SerialNumber, RevocationTime: time.Now()},
+		},
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, _ := ioutil.TempDir("", "crl")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ca.crl")
+	ioutil.WriteFile(path, der, 0600)
+
+	crl, err := NewCRL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Equals("good", t, nil, crl.Check(good, ca.cert))
+	Equals("revoked", t, ErrRevoked, crl.Check(revoked, ca.cert))
+
+	//list of other CA does not apply
+	other := newTestCA(t)
+	Equals("other issuer", t, nil, crl.Check(other.issue(t, 11, "x"), other.cert))
+}
+
+func TestCaseOCSP(t *testing.T) {
+	ca := newTestCA(t)
+	queries := 0
+	var responses map[string][]byte
+
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var rq ocspRequest
+		asn1.Unmarshal(body, &rq)
+		w.Write(responses[rq.TBSRequest.RequestList[0].Cert.SerialNumber.String()])
+	}))
+	defer responder.Close()
+
+	good, revoked := ca.issue(t, 20, "good", responder.URL), ca.issue(t, 21, "revoked", responder.URL)
+	responses = map[string][]byte{
+		"20": ca.respond(t, good, false),
+		"21": ca.respond(t, revoked, true),
+	}
+
+	o := NewOCSP()
+	Equals("good", t, nil, o.Check(good, ca.cert))
+	Equals("revoked", t, ErrRevoked, o.Check(revoked, ca.cert))
+	Equals("cached", t, nil, o.Check(good, ca.cert))
+	Equals("queries", t, 2, queries)
+
+	//response signed by other CA is rejected
+	other := newTestCA(t)
+	forged := ca.issue(t, 22, "forged", responder.URL)
+	responses["22"] = (&testCA{ca.cert, other.key}).respond(t, forged, false)
+	Equals("lenient", t, nil, o.Check(forged, ca.cert))
+
+	o.Strict = true
+	Equals("strict", t, ErrRevocationUnknown, o.Check(forged, ca.cert))
+}
+
+func TestCaseClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	cert := ca.issue(t, 30, "device-1")
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	cc := NewClientCert()
+
+	p, err := cc.Authenticate(r)
+	Equals("no tls", t, true, p == nil && err == nil)
+
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert, ca.cert}}}
+	p, _ = cc.Authenticate(r)
+	Equals("principal", t, "device-1", p.ID)
+
+	cc.Revocation = Revocations{revokeAll{}}
+	_, err = cc.Authenticate(r)
+	Equals("revoked", t, ErrRevoked, err)
+}
+
+type revokeAll struct{}
+
+func (revokeAll) Check(cert, issuer *x509.Certificate) error {
+	return ErrRevoked
+}
@@ -15,6 +15,17 @@ type Backend interface {
 	Start()
 }
 
+// StateReporter is implemented by backends able to report connection state
+type StateReporter interface {
+	State() string
+}
+
+const (
+	BE_STATE_CONNECTED    = "connected"
+	BE_STATE_DISCONNECTED = "disconnected"
+	BE_STATE_UNKNOWN      = "unknown"
+)
+
 const (
 	BE_ACTION_RQ        int8 = 0
 	BE_ACTION_RS        int8 = 1
@@ -24,6 +35,9 @@ const (
 	BE_SET_PROP_RS      int8 = 5
 	BE_EVENT            int8 = 6
 	BE_UNKNOWN_MSG_TYPE int8 = 7
+	BE_ACTION_PROGRESS  int8 = 8
+	// BE_PROP_PUSH is property value device sends without request, e.g. when it changes
+	BE_PROP_PUSH int8 = 9
 )
 
 type Encoder interface {
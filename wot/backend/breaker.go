@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	BREAKER_CLOSED    = "closed"
+	BREAKER_OPEN      = "open"
+	BREAKER_HALF_OPEN = "half-open"
+)
+
+// BREAKER_RESET is default time circuit stays open before probe, override by "breakerReset" config in seconds
+// or as duration, e.g. "1m30s"
+const BREAKER_RESET = 30 * time.Second
+
+// ErrBreakerOpen is returned by requests to device of open circuit without sending them, it matches
+// server.ErrOffline, so frontends answer it as unavailable Thing
+var ErrBreakerOpen = fmt.Errorf("device circuit open: %w", server.ErrOffline)
+
+// BreakerBackend is implemented by backends guarding requests to devices by circuit breaker
+type BreakerBackend interface {
+	// OnBreakerChange registers callback receiving ctxPath of device and new state of its circuit
+	OnBreakerChange(cb func(ctxPath, state string))
+}
+
+// breaker is circuit breaker configuration of backend, every bound device has its own circuit, so
+// dead device does not fail requests to the others
+type breaker struct {
+	l        *sync.Mutex
+	failures int
+	reset    time.Duration
+	notify   func(ctxPath, state string)
+	// now is clock of circuits, replaced by tests
+	now func() time.Time
+}
+
+// newBreaker reads "breakerFailures", consecutive failed requests opening circuit of device, and
+// "breakerReset". Breaker is disabled, nil, when failures are not configured. Numbers are accepted
+// as decoded from YAML or JSON, other values are configuration errors.
+func newBreaker(cfg map[string]interface{}) (*breaker, error) {
+	failures := 0
+	if f, ok := cfg["breakerFailures"]; ok {
+		n, ok := f.(int)
+		if v, isFloat := f.(float64); isFloat && v == math.Trunc(v) {
+			n, ok = int(v), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid breakerFailures %v: integer expected", f)
+		}
+		failures = n
+	}
+
+	if failures <= 0 {
+		return nil, nil
+	}
+
+	reset := BREAKER_RESET
+	if r, ok := cfg["breakerReset"]; ok {
+		var err error
+		if reset, err = seconds(r); err != nil {
+			return nil, fmt.Errorf("invalid breakerReset %v: %v", r, err)
+		}
+	}
+
+	return &breaker{
+		l:        &sync.Mutex{},
+		failures: failures,
+		reset:    reset,
+		now:      time.Now,
+	}, nil
+}
+
+// seconds converts config value in seconds, integer or float, or duration string, e.g. "30s"
+func seconds(v interface{}) (time.Duration, error) {
+	var d time.Duration
+
+	switch s := v.(type) {
+	case int:
+		d = time.Duration(s) * time.Second
+	case int64:
+		d = time.Duration(s) * time.Second
+	case float64:
+		d = time.Duration(s * float64(time.Second))
+	case string:
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("seconds or duration expected, got %T", v)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration")
+	}
+
+	return d, nil
+}
+
+func (b *breaker) onChange(cb func(ctxPath, state string)) {
+	if b == nil {
+		return
+	}
+
+	b.l.Lock()
+	b.notify = cb
+	b.l.Unlock()
+}
+
+// circuit returns circuit of device bound at ctxPath, nil when breaker is disabled
+func (b *breaker) circuit(ctxPath string) *circuit {
+	if b == nil {
+		return nil
+	}
+
+	return &circuit{
+		l:       &sync.Mutex{},
+		b:       b,
+		ctxPath: ctxPath,
+		state:   BREAKER_CLOSED,
+	}
+}
+
+// circuit of device is opened by consecutive failed requests, failing following requests fast. Once
+// reset elapses, single probe request is let through half-open circuit, it closes circuit when it
+// succeeds and opens it again when it fails.
+type circuit struct {
+	l        *sync.Mutex
+	b        *breaker
+	ctxPath  string
+	state    string
+	failures int
+	opened   time.Time
+}
+
+// allow reports whether request may be sent to device
+func (c *circuit) allow() bool {
+	if c == nil {
+		return true
+	}
+
+	c.l.Lock()
+	if c.state == BREAKER_CLOSED {
+		c.l.Unlock()
+		return true
+	}
+
+	//half-open circuit is waiting for its probe
+	if c.state == BREAKER_HALF_OPEN || c.b.now().Sub(c.opened) < c.b.reset {
+		c.l.Unlock()
+		return false
+	}
+
+	c.state = BREAKER_HALF_OPEN
+	c.l.Unlock()
+
+	c.changed(BREAKER_HALF_OPEN)
+	return true
+}
+
+// done records outcome of request allowed by allow
+func (c *circuit) done(failed bool) {
+	if c == nil {
+		return
+	}
+
+	c.l.Lock()
+	previous := c.state
+
+	switch {
+	case c.state == BREAKER_HALF_OPEN && failed:
+		c.state, c.opened = BREAKER_OPEN, c.b.now()
+	case c.state == BREAKER_HALF_OPEN:
+		c.state, c.failures = BREAKER_CLOSED, 0
+	case c.state == BREAKER_CLOSED && failed:
+		c.failures++
+		if c.failures >= c.b.failures {
+			c.state, c.opened = BREAKER_OPEN, c.b.now()
+		}
+	case c.state == BREAKER_CLOSED:
+		c.failures = 0
+	}
+
+	state := c.state
+	c.l.Unlock()
+
+	if state != previous {
+		c.changed(state)
+	}
+}
+
+func (c *circuit) changed(state string) {
+	c.b.l.Lock()
+	notify := c.b.notify
+	c.b.l.Unlock()
+
+	if notify != nil {
+		notify(c.ctxPath, state)
+	}
+}
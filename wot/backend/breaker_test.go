@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCaseBreakerConfig(t *testing.T) {
+	cases := []struct {
+		cfg      map[string]interface{}
+		failures int
+		reset    time.Duration
+		invalid  bool
+	}{
+		{map[string]interface{}{}, 0, 0, false},
+		{map[string]interface{}{"breakerFailures": 3}, 3, BREAKER_RESET, false},
+		{map[string]interface{}{"breakerFailures": 3.0, "breakerReset": 5}, 3, 5 * time.Second, false},
+		{map[string]interface{}{"breakerFailures": 3, "breakerReset": 2.5}, 3, 2500 * time.Millisecond, false},
+		{map[string]interface{}{"breakerFailures": 3, "breakerReset": "1m30s"}, 3, 90 * time.Second, false},
+		{map[string]interface{}{"breakerFailures": 3, "breakerReset": "soon"}, 0, 0, true},
+		{map[string]interface{}{"breakerFailures": 3, "breakerReset": -1}, 0, 0, true},
+		{map[string]interface{}{"breakerFailures": 3, "breakerReset": []int{5}}, 0, 0, true},
+		{map[string]interface{}{"breakerFailures": "3"}, 0, 0, true},
+		{map[string]interface{}{"breakerFailures": 2.5}, 0, 0, true},
+	}
+
+	for _, c := range cases {
+		name := fmt.Sprint(c.cfg)
+		b, err := newBreaker(c.cfg)
+		Equals(name+" invalid", t, c.invalid, err != nil)
+
+		if b == nil {
+			Equals(name+" disabled", t, 0, c.failures)
+			continue
+		}
+		Equals(name+" failures", t, c.failures, b.failures)
+		Equals(name+" reset", t, c.reset, b.reset)
+	}
+}
+
+func TestCaseCircuitStates(t *testing.T) {
+	b, _ := newBreaker(map[string]interface{}{"breakerFailures": 2, "breakerReset": 10})
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return clock }
+
+	var changes []string
+	b.onChange(func(ctxPath, state string) {
+		changes = append(changes, state)
+	})
+
+	//request is sent after advance when circuit allows it, failed is its outcome
+	steps := []struct {
+		advance time.Duration
+		failed  bool
+		allowed bool
+		state   string
+	}{
+		{0, true, true, BREAKER_CLOSED},
+		{0, false, true, BREAKER_CLOSED},
+		{0, true, true, BREAKER_CLOSED},
+		{0, true, true, BREAKER_OPEN},
+		{5 * time.Second, false, false, BREAKER_OPEN},
+		{5 * time.Second, true, true, BREAKER_OPEN},
+		{9 * time.Second, false, false, BREAKER_OPEN},
+		{time.Second, false, true, BREAKER_CLOSED},
+		{0, true, true, BREAKER_CLOSED},
+	}
+
+	c := b.circuit("/lamp")
+	for i, s := range steps {
+		clock = clock.Add(s.advance)
+
+		allowed := c.allow()
+		Equals(fmt.Sprint("step ", i, " allowed"), t, s.allowed, allowed)
+		if allowed {
+			c.done(s.failed)
+		}
+		Equals(fmt.Sprint("step ", i, " state"), t, s.state, c.state)
+	}
+
+	Equals("Changes", t, fmt.Sprint([]string{BREAKER_OPEN, BREAKER_HALF_OPEN, BREAKER_OPEN, BREAKER_HALF_OPEN, BREAKER_CLOSED}), fmt.Sprint(changes))
+
+	//second failure in a row opens circuit again, once reset elapses single probe is let through
+	c.done(true)
+	clock = clock.Add(10 * time.Second)
+	Equals("Probe", t, true, c.allow())
+	Equals("Waiting for probe", t, false, c.allow())
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/col"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+// Conversation based backends send requests to device and match its responses by conversation ID.
+// Transport of messages is provided by backend, conversations and device messages are handled here.
+
+// converse sends encoded message by send, requests expecting response wait for it at most timeout.
+// Requests to device of open circuit c fail with ErrBreakerOpen without being sent.
+func converse(
+	conversations *col.Map,
+	c *circuit,
+	timeout time.Duration,
+	encoder Encoder,
+	msgType int8,
+	msgName string,
+	data interface{},
+	requestID string,
+	ph async.ProgressHandler,
+	send func([]byte) error) interface{} {
+
+	expectsResponse := msgType == BE_ACTION_RQ || msgType == BE_GET_PROP_RQ
+	if expectsResponse && !c.allow() {
+		return ErrBreakerOpen
+	}
+
+	conversationID := conversation(requestID)
+	msg := encoder.Encode(msgType, conversationID, msgName, data)
+
+	var promise *async.Promise
+	if expectsResponse {
+		promise = async.NewPromise()
+		conversations.Add(conversationID, &pending{promise: promise, ph: ph})
+		defer conversations.Del(conversationID)
+	}
+
+	if err := send(msg); err != nil {
+		log.Error("Backend: ", msgName, " not sent -> ", err)
+		if expectsResponse {
+			c.done(true)
+			return err
+		}
+		return nil
+	}
+
+	if !expectsResponse {
+		return nil
+	}
+
+	// wait to receive response from device to fulfill the promise
+	response, err := promise.WaitTimeout(timeout)
+	c.done(err != nil)
+	if err != nil {
+		log.Error("Backend: no response for ", msgName, " -> ", err)
+		return err
+	}
+
+	return response
+}
+
+// conversation returns unique conversation ID, ID of request the message is sent for is its prefix,
+// so device side logs can be correlated with the request
+func conversation(requestID string) string {
+	id, _ := sec.UUID4()
+	if requestID == "" {
+		return id
+	}
+
+	return str.Concat(requestID, "-", id[:8])
+}
+
+// pending is conversation waiting for device response, ph receives progress of action conversations
+type pending struct {
+	promise *async.Promise
+	ph      async.ProgressHandler
+}
+
+// deviceMessage handles message received from device, every message counts as heartbeat
+func deviceMessage(wos *server.WotServer, encoder Encoder, conversations *col.Map, payload []byte) {
+	msgType, conversationID, msgName, msgData := encoder.Decode(payload)
+	wos.Heartbeat()
+
+	switch msgType {
+	case BE_ACTION_RS, BE_GET_PROP_RS:
+		//late responses of timed out conversations are dropped
+		if conv, ok := conversations.Get(conversationID); ok {
+			conv.(*pending).promise.Set(msgData)
+		}
+	case BE_ACTION_PROGRESS:
+		if conv, ok := conversations.Get(conversationID); ok && conv.(*pending).ph != nil {
+			server.ReportProgress(conv.(*pending).ph, progressOf(msgData))
+		}
+	case BE_EVENT:
+		wos.EmitEvent(msgName, msgData)
+	case BE_PROP_PUSH:
+		wos.PushProperty(msgName, msgData)
+	}
+}
+
+// progressOf reads progress reported by device, percent, stage and message fields are recognized
+func progressOf(data interface{}) server.Progress {
+	field := func(name string) string {
+		switch d := data.(type) {
+		case map[string][]string:
+			if len(d[name]) > 0 {
+				return d[name][0]
+			}
+		case map[string]interface{}:
+			if v, ok := d[name]; ok {
+				return fmt.Sprint(v)
+			}
+		}
+		return ""
+	}
+
+	percent, _ := strconv.ParseFloat(field("percent"), 64)
+
+	return server.Progress{
+		Percent: percent,
+		Stage:   field("stage"),
+		Message: field("message"),
+	}
+}
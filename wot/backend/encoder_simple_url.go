@@ -30,10 +30,14 @@ func (sc *SimpleUrlEncoder) Decode(buf []byte) (int8, string, string, interface{
 	switch int8(msgTypeCode) {
 	case BE_ACTION_RS:
 		return BE_ACTION_RS, conversationID, msgType, msgData
+	case BE_ACTION_PROGRESS:
+		return BE_ACTION_PROGRESS, conversationID, msgType, msgData
 	case BE_GET_PROP_RS:
 		return BE_GET_PROP_RS, conversationID, msgType, msgData
 	case BE_EVENT:
 		return BE_EVENT, "", msgType, msgData
+	case BE_PROP_PUSH:
+		return BE_PROP_PUSH, "", msgType, msgData
 	default:
 		return BE_UNKNOWN_MSG_TYPE, "", msgType, nil
 	}
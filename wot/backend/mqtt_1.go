@@ -49,11 +49,15 @@ func (mb *MQTT_1) Bind(wos *server.WotServer, ctxPath string, encoder Encoder) {
 
 func (mb *MQTT_1) Start() {}
 
+func (mb *MQTT_1) State() string {
+	return mqttState(mb.client)
+}
+
 func (mb *MQTT_1) setup(ctxPath string, wos *server.WotServer) {
 	deviceTopic := str.Concat(ctxPath, "/#")
 	token2 := mb.client.Subscribe(deviceTopic, 0, mb.eventHandler(ctxPath, wos))
 	if token2.Wait() && token2.Error() != nil {
-		log.Fatal(token2.Error)
+		log.Fatal(token2.Error())
 		os.Exit(1)
 	}
 	log.Info("MQTT_1 Backend: subscribed to device topic -> ", deviceTopic)
@@ -78,21 +82,17 @@ func (mb *MQTT_1) publish(propPath string, data interface{}) mqtt.Token {
 	return token
 }
 
-type PropertyChange struct {
-	Name  string      `json:"name"`
-	Value interface{} `json:"value"`
-}
+// PropertyChange is property-change event payload, see server.PropertyChange
+type PropertyChange = server.PropertyChange
 
 func (mb *MQTT_1) eventHandler(ctxPath string, wos *server.WotServer) func(mqtt.Client, mqtt.Message) {
 	return func(client mqtt.Client, m mqtt.Message) {
 		topic := m.Topic()
 
-		p := PropertyChange{
-			Name:  topic[len(ctxPath)+1 : len(topic)],
-			Value: string(m.Payload()),
-		}
+		name := topic[len(ctxPath)+1 : len(topic)]
+		value := string(m.Payload())
 
-		mb.values[topic] = p.Value
-		wos.EmitEvent("property-change", p)
+		mb.values[topic] = value
+		wos.EmitPropertyChange(name, value)
 	}
 }
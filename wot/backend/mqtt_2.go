@@ -14,11 +14,24 @@ import (
 )
 
 type MQTT_2 struct {
-	client   mqtt.Client
-	bindings map[string]*col.Map
+	client    mqtt.Client
+	bindings  map[string]*col.Map
+	circuits  map[string]*circuit
+	timeout   time.Duration
+	heartbeat time.Duration
+	breaker   *breaker
 }
 
+// MQTT_2_TIMEOUT is default time to wait for device response, override by "timeout" config in seconds
+const MQTT_2_TIMEOUT = 10 * time.Second
+
 func NewMQTT_2(cfg map[string]interface{}) Backend {
+	//invalid breaker configuration fails before connecting
+	cb, err := newBreaker(cfg)
+	if err != nil {
+		panic(err)
+	}
+
 	url := cfg["url"].(string)
 	opts := mqtt.NewClientOptions().AddBroker(url).SetClientID("ClientID")
 	opts.SetKeepAlive(20 * time.Second)
@@ -29,22 +42,60 @@ func NewMQTT_2(cfg map[string]interface{}) Backend {
 		panic(token.Error())
 	}
 
+	timeout := MQTT_2_TIMEOUT
+	if t, ok := cfg["timeout"]; ok {
+		timeout = time.Duration(t.(int)) * time.Second
+	}
+
+	//devices are expected to publish at least every "heartbeat" seconds, any message counts
+	var heartbeat time.Duration
+	if h, ok := cfg["heartbeat"]; ok {
+		heartbeat = time.Duration(h.(int)) * time.Second
+	}
+
+	//requests to device failing "breakerFailures" times in a row fail fast for "breakerReset" seconds
 	return &MQTT_2{
-		client:   c,
-		bindings: make(map[string]*col.Map),
+		client:    c,
+		bindings:  make(map[string]*col.Map),
+		circuits:  make(map[string]*circuit),
+		timeout:   timeout,
+		heartbeat: heartbeat,
+		breaker:   cb,
 	}
 }
 
 func (mb *MQTT_2) Bind(wos *server.WotServer, baseTopic string, encoder Encoder) {
 	bindingID, _ := sec.UUID4()
 	mb.bindings[bindingID] = col.NewConcurentMap()
+	mb.circuits[bindingID] = mb.breaker.circuit(baseTopic)
 
 	mb.setupDeviceInTopic(bindingID, baseTopic, wos, encoder)
 	mb.setupDeviceOutTopic(bindingID, baseTopic, wos, encoder)
+
+	//tracked after device handlers are set, availability is answered by gateway
+	if mb.heartbeat > 0 {
+		wos.TrackAvailability(mb.heartbeat)
+	}
 }
 
 func (mb *MQTT_2) Start() {}
 
+func (mb *MQTT_2) OnBreakerChange(cb func(ctxPath, state string)) {
+	mb.breaker.onChange(cb)
+}
+
+func (mb *MQTT_2) State() string {
+	return mqttState(mb.client)
+}
+
+func mqttState(c mqtt.Client) string {
+	if c.IsConnected() {
+		return BE_STATE_CONNECTED
+	}
+
+	return BE_STATE_DISCONNECTED
+}
+
 func (mb *MQTT_2) setupDeviceInTopic(bindingID string, baseTopic string, wos *server.WotServer, encoder Encoder) {
 	deviceInTopic := str.Concat(baseTopic, "/i")
 	log.Info("MQTTBackend: device in topic -> ", deviceInTopic)
@@ -52,18 +103,18 @@ func (mb *MQTT_2) setupDeviceInTopic(bindingID string, baseTopic string, wos *se
 	for _, a := range wos.GetDescription().Actions {
 		wos.OnInvokeAction(a.Name, func(payload interface{}, ph async.ProgressHandler) interface{} {
 			log.Info("Action invoked ", a.Name, payload)
-			return mb.publish(bindingID, encoder, deviceInTopic, BE_ACTION_RQ, a.Name, payload)
+			return mb.publish(bindingID, encoder, deviceInTopic, BE_ACTION_RQ, a.Name, payload, server.ActionRequestID(ph), ph)
 		})
 	}
 
 	for _, p := range wos.GetDescription().Properties {
 		wos.OnGetProperty(p.Name, func() interface{} {
-			return mb.publish(bindingID, encoder, deviceInTopic, BE_GET_PROP_RQ, p.Name, nil)
+			return mb.publish(bindingID, encoder, deviceInTopic, BE_GET_PROP_RQ, p.Name, nil, wos.RequestID(), nil)
 		})
 
 		if p.Writable {
 			wos.OnUpdateProperty(p.Name, func(payload interface{}) {
-				mb.publish(bindingID, encoder, deviceInTopic, BE_SET_PROP_RQ, p.Name, payload)
+				mb.publish(bindingID, encoder, deviceInTopic, BE_SET_PROP_RQ, p.Name, payload, wos.RequestID(), nil)
 			})
 		}
 	}
@@ -75,28 +126,15 @@ func (mb *MQTT_2) publish(
 	deviceInTopic string,
 	msgType int8,
 	msgName string,
-	data interface{}) interface{} {
-
-	conversationID, _ := sec.UUID4()
-	urlQ := encoder.Encode(msgType, conversationID, msgName, data)
-
-	var response interface{}
-	var promise *async.Promise
-	if msgType == BE_ACTION_RQ || msgType == BE_GET_PROP_RQ {
-		promise = async.NewPromise()
-		mb.bindings[bindingID].Add(conversationID, promise)
-	}
-
-	log.Info("Will publish ", deviceInTopic, " : ", string(urlQ))
-	mb.client.Publish(deviceInTopic, 0, false, urlQ)
-	// wait to receive response on deviceOutTopic to fulfuill the promise
-	// Q: should we timeout?
-	if msgType == BE_ACTION_RQ || msgType == BE_GET_PROP_RQ {
-		response = promise.Get()
-		mb.bindings[bindingID].Del(conversationID)
-	}
-
-	return response
+	data interface{},
+	requestID string,
+	ph async.ProgressHandler) interface{} {
+
+	return converse(mb.bindings[bindingID], mb.circuits[bindingID], mb.timeout, encoder, msgType, msgName, data, requestID, ph, func(msg []byte) error {
+		log.Info("Will publish ", deviceInTopic, " : ", string(msg))
+		mb.client.Publish(deviceInTopic, 0, false, msg)
+		return nil
+	})
 }
 
 func (mb *MQTT_2) setupDeviceOutTopic(bindingID string, baseTopic string, wos *server.WotServer, encoder Encoder) {
@@ -110,19 +148,7 @@ func (mb *MQTT_2) setupDeviceOutTopic(bindingID string, baseTopic string, wos *s
 
 func outSubHandler(wos *server.WotServer, encoder Encoder, conversations *col.Map) func(mqtt.Client, mqtt.Message) {
 	return func(client mqtt.Client, m mqtt.Message) {
-		msgType, conversationID, msgName, msgData := encoder.Decode(m.Payload())
-
 		log.Info("MQTT message receive ", string(m.Payload()))
-
-		switch msgType {
-		case BE_ACTION_RS:
-			conv, _ := conversations.Get(conversationID)
-			conv.(*async.Promise).Set(msgData)
-		case BE_GET_PROP_RS:
-			conv, _ := conversations.Get(conversationID)
-			conv.(*async.Promise).Set(msgData)
-		case BE_EVENT:
-			wos.EmitEvent(msgName, msgData)
-		}
+		deviceMessage(wos, encoder, conversations, m.Payload())
 	}
 }
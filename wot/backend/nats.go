@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/col"
+	"github.com/conas/tno2/util/nats"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+// NATS backend attaches devices over NATS with conversations of MQTT_2. Device bound at /conas/dev-1
+// receives requests at subject conas.dev-1.i and publishes responses and events to conas.dev-1.o.
+type NATS struct {
+	conn      *nats.Conn
+	timeout   time.Duration
+	heartbeat time.Duration
+	breaker   *breaker
+}
+
+// NATS_TIMEOUT is default time to wait for device response, override by "timeout" config in seconds
+const NATS_TIMEOUT = 10 * time.Second
+
+// NewNATS connects to NATS server at "url", "timeout", "heartbeat" and breaker are configured as of MQTT_2
+func NewNATS(cfg map[string]interface{}) Backend {
+	//invalid breaker configuration fails before connecting
+	cb, err := newBreaker(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	conn, err := nats.Connect(cfg["url"].(string), "tno2 backend")
+	if err != nil {
+		panic(err)
+	}
+
+	timeout := NATS_TIMEOUT
+	if t, ok := cfg["timeout"]; ok {
+		timeout = time.Duration(t.(int)) * time.Second
+	}
+
+	var heartbeat time.Duration
+	if h, ok := cfg["heartbeat"]; ok {
+		heartbeat = time.Duration(h.(int)) * time.Second
+	}
+
+	return &NATS{
+		conn:      conn,
+		timeout:   timeout,
+		heartbeat: heartbeat,
+		breaker:   cb,
+	}
+}
+
+func (nb *NATS) Bind(wos *server.WotServer, ctxPath string, encoder Encoder) {
+	subject := nats.Subject(ctxPath)
+	deviceIn := str.Concat(subject, ".i")
+	deviceOut := str.Concat(subject, ".o")
+	conversations := col.NewConcurentMap()
+	c := nb.breaker.circuit(ctxPath)
+
+	send := func(msg []byte) error {
+		return nb.conn.Publish(deviceIn, msg)
+	}
+
+	for _, a := range wos.GetDescription().Actions {
+		wos.OnInvokeAction(a.Name, func(payload interface{}, ph async.ProgressHandler) interface{} {
+			return converse(conversations, c, nb.timeout, encoder, BE_ACTION_RQ, a.Name, payload, server.ActionRequestID(ph), ph, send)
+		})
+	}
+
+	for _, p := range wos.GetDescription().Properties {
+		wos.OnGetProperty(p.Name, func() interface{} {
+			return converse(conversations, c, nb.timeout, encoder, BE_GET_PROP_RQ, p.Name, nil, wos.RequestID(), nil, send)
+		})
+
+		if p.Writable {
+			wos.OnUpdateProperty(p.Name, func(payload interface{}) {
+				converse(conversations, c, nb.timeout, encoder, BE_SET_PROP_RQ, p.Name, payload, wos.RequestID(), nil, send)
+			})
+		}
+	}
+
+	_, err := nb.conn.Subscribe(deviceOut, func(m *nats.Msg) {
+		deviceMessage(wos, encoder, conversations, m.Data)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	log.Info("NATSBackend: device subjects -> ", deviceIn, ", ", deviceOut)
+
+	//tracked after device handlers are set, availability is answered by gateway
+	if nb.heartbeat > 0 {
+		wos.TrackAvailability(nb.heartbeat)
+	}
+}
+
+func (nb *NATS) Start() {}
+
+func (nb *NATS) OnBreakerChange(cb func(ctxPath, state string)) {
+	nb.breaker.onChange(cb)
+}
+
+func (nb *NATS) State() string {
+	if nb.conn.IsConnected() {
+		return BE_STATE_CONNECTED
+	}
+
+	return BE_STATE_DISCONNECTED
+}
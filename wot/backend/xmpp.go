@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/col"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/util/xmpp"
+	"github.com/conas/tno2/wot/server"
+)
+
+// XMPP_DEVICE_NS is namespace of device element carrying base64 of encoded message
+const XMPP_DEVICE_NS = "urn:tno2:wot:device"
+
+// XMPP_TIMEOUT is default time to wait for device response, override by "timeout" config in seconds
+const XMPP_TIMEOUT = 10 * time.Second
+
+// XMPP backend attaches devices behind firewalls over XMPP server with conversations of MQTT_2. Device
+// bound at /conas/dev-1 is entity conas.dev-1@<domain>, requests and responses are exchanged as messages
+// of device element. Available presence of device counts as heartbeat.
+type XMPP struct {
+	conn      *xmpp.Conn
+	domain    string
+	timeout   time.Duration
+	heartbeat time.Duration
+	breaker   *breaker
+	l         *sync.Mutex
+	devices   map[string]func(payload []byte)
+}
+
+// NewXMPP connects to server of "jid" by "password", "server" overrides host:port of server and "insecure"
+// allows servers without TLS. Devices are entities of "domain", domain of gateway JID by default, "timeout",
+// "heartbeat" and breaker are configured as of MQTT_2.
+func NewXMPP(cfg map[string]interface{}) Backend {
+	//invalid breaker configuration fails before connecting
+	cb, err := newBreaker(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	opts := xmpp.Options{JID: cfg["jid"].(string)}
+	opts.Password, _ = cfg["password"].(string)
+	opts.Server, _ = cfg["server"].(string)
+	opts.Insecure, _ = cfg["insecure"].(bool)
+
+	conn, err := xmpp.Connect(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	_, domain, _ := xmpp.SplitJID(opts.JID)
+	if d, ok := cfg["domain"].(string); ok {
+		domain = d
+	}
+
+	timeout := XMPP_TIMEOUT
+	if t, ok := cfg["timeout"]; ok {
+		timeout = time.Duration(t.(int)) * time.Second
+	}
+
+	var heartbeat time.Duration
+	if h, ok := cfg["heartbeat"]; ok {
+		heartbeat = time.Duration(h.(int)) * time.Second
+	}
+
+	xb := &XMPP{
+		conn:      conn,
+		domain:    domain,
+		timeout:   timeout,
+		heartbeat: heartbeat,
+		breaker:   cb,
+		l:         &sync.Mutex{},
+		devices:   make(map[string]func([]byte)),
+	}
+
+	conn.OnMessage(xb.deviceMessage)
+	conn.OnPresence(xb.devicePresence)
+
+	return xb
+}
+
+func (xb *XMPP) Bind(wos *server.WotServer, ctxPath string, encoder Encoder) {
+	device := str.Concat(strings.Replace(strings.Trim(ctxPath, "/"), "/", ".", -1), "@", xb.domain)
+	conversations := col.NewConcurentMap()
+	c := xb.breaker.circuit(ctxPath)
+
+	send := func(msg []byte) error {
+		payload := xmpp.NewElement(XMPP_DEVICE_NS, "device", base64.StdEncoding.EncodeToString(msg))
+		return xb.conn.Send(&xmpp.Message{To: device, Extensions: []xmpp.Element{*payload}})
+	}
+
+	for _, a := range wos.GetDescription().Actions {
+		wos.OnInvokeAction(a.Name, func(payload interface{}, ph async.ProgressHandler) interface{} {
+			return converse(conversations, c, xb.timeout, encoder, BE_ACTION_RQ, a.Name, payload, server.ActionRequestID(ph), ph, send)
+		})
+	}
+
+	for _, p := range wos.GetDescription().Properties {
+		wos.OnGetProperty(p.Name, func() interface{} {
+			return converse(conversations, c, xb.timeout, encoder, BE_GET_PROP_RQ, p.Name, nil, wos.RequestID(), nil, send)
+		})
+
+		if p.Writable {
+			wos.OnUpdateProperty(p.Name, func(payload interface{}) {
+				converse(conversations, c, xb.timeout, encoder, BE_SET_PROP_RQ, p.Name, payload, wos.RequestID(), nil, send)
+			})
+		}
+	}
+
+	xb.l.Lock()
+	xb.devices[device] = func(payload []byte) {
+		if payload == nil {
+			wos.Heartbeat()
+			return
+		}
+		deviceMessage(wos, encoder, conversations, payload)
+	}
+	xb.l.Unlock()
+
+	log.Info("XMPPBackend: device -> ", device)
+
+	//tracked after device handlers are set, availability is answered by gateway
+	if xb.heartbeat > 0 {
+		wos.TrackAvailability(xb.heartbeat)
+	}
+}
+
+// deviceMessage passes payload of device element to device of sender
+func (xb *XMPP) deviceMessage(m *xmpp.Message) {
+	payload := m.Extension(XMPP_DEVICE_NS)
+	if payload == nil || m.Type == "error" {
+		return
+	}
+
+	msg, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload.Text))
+	if err != nil {
+		log.Error("XMPPBackend: invalid message of ", m.From, " -> ", err)
+		return
+	}
+
+	if device := xb.device(m.From); device != nil {
+		device(msg)
+	}
+}
+
+// devicePresence counts available presence of device as heartbeat
+func (xb *XMPP) devicePresence(p *xmpp.Presence) {
+	if p.Type != "" {
+		return
+	}
+
+	if device := xb.device(p.From); device != nil {
+		device(nil)
+	}
+}
+
+func (xb *XMPP) device(jid string) func([]byte) {
+	xb.l.Lock()
+	defer xb.l.Unlock()
+
+	return xb.devices[xmpp.Bare(jid)]
+}
+
+func (xb *XMPP) Start() {}
+
+func (xb *XMPP) OnBreakerChange(cb func(ctxPath, state string)) {
+	xb.breaker.onChange(cb)
+}
+
+func (xb *XMPP) State() string {
+	if xb.conn.IsConnected() {
+		return BE_STATE_CONNECTED
+	}
+
+	return BE_STATE_DISCONNECTED
+}
@@ -0,0 +1,71 @@
+package frontend
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// BinaryEncoder writes binary values as they are, e.g. image/jpeg snapshot of camera, instead of JSON
+// string of their base64 encoding. It encodes values of interactions declaring binary contentType
+// without registered encoder, see isBinary.
+type BinaryEncoder struct{}
+
+func NewBinaryEncoder() *BinaryEncoder {
+	return &BinaryEncoder{}
+}
+
+func (c *BinaryEncoder) Info() string {
+	return "BINARY"
+}
+
+// Encode writes []byte or base64 encoded string
+func (c *BinaryEncoder) Encode(w io.Writer, v interface{}) error {
+	data, ok := model.Bytes(v)
+
+	if !ok {
+		return errors.New(str.Concat("Binary encoder can't encode ", v))
+	}
+
+	_, err := w.Write(data)
+	return err
+}
+
+// Decode reads body into *interface{} as []byte
+func (c *BinaryEncoder) Decode(r io.Reader, t interface{}) error {
+	target, ok := t.(*interface{})
+
+	if !ok {
+		return errors.New("Binary encoder decodes into interface{} only")
+	}
+
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	*target = data
+	return nil
+}
+
+// isBinary reports whether values of content type are opaque bytes
+func isBinary(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return false
+	}
+
+	switch strings.SplitN(mt, "/", 2)[0] {
+	case "image", "audio", "video":
+		return true
+	}
+
+	return mt == CONTENT_TYPE_OCTET_STREAM
+}
@@ -23,6 +23,10 @@ func (c *JsonEncoder) Info() string {
 	return ENCODING_JSON
 }
 
+func (c *JsonEncoder) ContentType() string {
+	return CONTENT_TYPE_JSON
+}
+
 func (c *JsonEncoder) Encode(w io.Writer, v interface{}) error {
 	return json.NewEncoder(w).Encode(v)
 }
@@ -0,0 +1,74 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/conas/tno2/util/pb"
+	"github.com/conas/tno2/util/str"
+)
+
+const (
+	ENCODING_PROTOBUF string = "PROTOBUF"
+)
+
+func init() {
+	Encoders.Register(&ProtobufEncoder{})
+}
+
+// ProtobufEncoder encodes values as google.protobuf.Value, it needs no schema and is used where value type
+// is not known. Property values are sent as typed messages generated by tno2gen -proto (see util/pb).
+type ProtobufEncoder struct{}
+
+func NewProtobufEncoder() *ProtobufEncoder {
+	return &ProtobufEncoder{}
+}
+
+func (c *ProtobufEncoder) Info() string {
+	return ENCODING_PROTOBUF
+}
+
+func (c *ProtobufEncoder) ContentType() string {
+	return CONTENT_TYPE_PROTOBUF
+}
+
+func (c *ProtobufEncoder) Encode(w io.Writer, v interface{}) error {
+	data, err := pb.MarshalValue(v)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func (c *ProtobufEncoder) Decode(r io.Reader, t interface{}) error {
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	v, err := pb.UnmarshalValue(data)
+
+	if err != nil {
+		return errors.New(str.Concat("Error unmarshaling input using ", c.Info(), " codec."))
+	}
+
+	if generic, ok := t.(*interface{}); ok {
+		*generic = v
+		return nil
+	}
+
+	//typed targets are filled through JSON form of the value
+	js, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, t)
+}
@@ -0,0 +1,116 @@
+package frontend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/conas/tno2/util/senml"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	ENCODING_SENML_JSON string = "SENML+JSON"
+	ENCODING_SENML_CBOR string = "SENML+CBOR"
+)
+
+func init() {
+	Encoders.Register(&SenmlEncoder{})
+	Encoders.Register(&SenmlEncoder{cbor: true})
+}
+
+// SenmlEncoder encodes values as SenML pack of single record. Events are named by event and timed
+// by event timestamp, decoding takes value of the first record.
+type SenmlEncoder struct {
+	cbor bool
+}
+
+func NewSenmlJsonEncoder() *SenmlEncoder {
+	return &SenmlEncoder{}
+}
+
+func NewSenmlCborEncoder() *SenmlEncoder {
+	return &SenmlEncoder{cbor: true}
+}
+
+func (c *SenmlEncoder) Info() string {
+	if c.cbor {
+		return ENCODING_SENML_CBOR
+	}
+
+	return ENCODING_SENML_JSON
+}
+
+func (c *SenmlEncoder) ContentType() string {
+	if c.cbor {
+		return senml.CONTENT_TYPE_CBOR
+	}
+
+	return senml.CONTENT_TYPE_JSON
+}
+
+func (c *SenmlEncoder) Encode(w io.Writer, v interface{}) error {
+	var record senml.Record
+
+	if e, ok := v.(*server.Event); ok {
+		record = senml.Measurement(e.Event, "", e.Timestamp, e.Data)
+	} else {
+		record = senml.Measurement("", "", time.Time{}, v)
+	}
+
+	return c.EncodePack(w, senml.Pack{record})
+}
+
+func (c *SenmlEncoder) EncodePack(w io.Writer, p senml.Pack) error {
+	if c.cbor {
+		_, err := w.Write(senml.MarshalCBOR(p))
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(p)
+}
+
+func (c *SenmlEncoder) Decode(r io.Reader, t interface{}) error {
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	var p senml.Pack
+	if c.cbor {
+		p, err = senml.UnmarshalCBOR(data)
+	} else {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = d.Decode(&p)
+	}
+
+	if err != nil || len(p) == 0 {
+		return errors.New(str.Concat("Error unmarshaling input using ", c.Info(), " codec."))
+	}
+
+	record := p.Resolved()[0]
+	v, err := record.Interface()
+
+	if err != nil {
+		return err
+	}
+
+	if generic, ok := t.(*interface{}); ok {
+		*generic = v
+		return nil
+	}
+
+	js, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, t)
+}
@@ -3,9 +3,13 @@ package frontend
 import (
 	"errors"
 	"io"
+	"mime"
+	"net"
 
 	"github.com/conas/tno2/util/col"
 	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
 	"github.com/conas/tno2/wot/server"
 )
 
@@ -16,10 +20,30 @@ type Frontend interface {
 	Start()
 }
 
+// Unbinder is implemented by frontends able to remove bound Thing
+type Unbinder interface {
+	Unbind(ctxPath string) bool
+}
+
+// ListenerFrontend is implemented by frontends able to serve on provided listener instead of own port
+type ListenerFrontend interface {
+	Frontend
+	Serve(l net.Listener) error
+}
+
+// TenantFrontend is implemented by frontends able to host Things of multiple tenants in isolation
+type TenantFrontend interface {
+	Frontend
+	AddTenant(tenant string, guard *auth.Guard)
+	BindTenant(tenant, ctxPath string, s *server.WotServer)
+}
+
 // ----- CODEC TYPES
 
 const (
 	ENCODING_JSON string = "JSON"
+	// CONTENT_TYPE_JSON is media type of values of interactions declaring no content type
+	CONTENT_TYPE_JSON = "application/json"
 )
 
 type Encoder interface {
@@ -28,6 +52,12 @@ type Encoder interface {
 	Decode(io.Reader, interface{}) error
 }
 
+// ContentTyped is implemented by encoders of values of media type, interactions declaring the media type
+// as their contentType are encoded by them
+type ContentTyped interface {
+	ContentType() string
+}
+
 type EncoderRegistry struct {
 	reg *col.Map
 }
@@ -57,3 +87,54 @@ func (es *EncoderRegistry) Get(code string) (Encoder, error) {
 func (es *EncoderRegistry) Registered() []string {
 	return es.reg.Keys()
 }
+
+// ForContentType returns registered encoder of media type, parameters of content type are ignored
+func (es *EncoderRegistry) ForContentType(contentType string) (Encoder, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, code := range es.reg.Keys() {
+		e, _ := es.Get(code)
+		if ct, ok := e.(ContentTyped); ok && ct.ContentType() == mt {
+			return e, true
+		}
+	}
+
+	return nil, false
+}
+
+// PropertyEncoder returns encoder of property values and their content type, selected by contentType
+// declared by property. Values of binary content types, e.g. image/jpeg, are sent as raw bytes. Values
+// of properties declaring no content type, or other content type without registered encoder, are
+// encoded as JSON.
+func PropertyEncoder(prop model.Property) (Encoder, string) {
+	return contentEncoder(prop.ContentType)
+}
+
+// PropertyDecoder returns decoder of values written to property, see PropertyEncoder
+func PropertyDecoder(prop model.Property) Encoder {
+	decoder, _ := contentEncoder(prop.ContentType)
+	return decoder
+}
+
+// InputDecoder returns decoder of action input selected by contentType declared by input data, JSON by default
+func InputDecoder(action model.Action) Encoder {
+	decoder, _ := contentEncoder(action.InputData.ContentType)
+	return decoder
+}
+
+func contentEncoder(contentType string) (Encoder, string) {
+	if contentType != "" {
+		if e, ok := Encoders.ForContentType(contentType); ok {
+			return e, contentType
+		}
+		if isBinary(contentType) {
+			return NewBinaryEncoder(), contentType
+		}
+	}
+
+	e, _ := Encoders.Get(ENCODING_JSON)
+	return e, CONTENT_TYPE_JSON
+}
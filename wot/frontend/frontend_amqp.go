@@ -0,0 +1,261 @@
+package frontend
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/amqp"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+// AMQP_PREFIX is first segment of addresses of bound Things, override by "prefix" config
+const AMQP_PREFIX = "wot"
+
+// AMQP_TIMEOUT is default time to wait for action result and broker outcome, override by "timeout" config in seconds
+const AMQP_TIMEOUT = 10 * time.Second
+
+// AMQP_RECONNECT is interval of attempts to connect again to broker when connection is lost
+const AMQP_RECONNECT = 5 * time.Second
+
+// AMQP frontend exposes bound Things via AMQP 1.0 broker. Thing bound at /dev-1 receives requests at
+// address wot/dev-1, subject of request message selects interaction
+//
+//	td                    Thing Description
+//	property/<name>       property read
+//	property/<name>/set   property write, body carries value
+//	action/<name>         action invocation, answered with result when action completes
+//
+// Replies are sent to reply-to address of request with correlation-id of request message-id, body is
+// JSON of MessageReply and application property "status" repeats its status. Events are sent to address
+// wot/dev-1/event/<name>, broker decides whether it is topic or queue. Access to addresses is controlled
+// by broker. Thing address is advertised as URI of Thing Description and event addresses as event hrefs.
+type AMQP struct {
+	url       string
+	binding   string
+	container string
+	prefix    string
+	timeout   time.Duration
+	l         *sync.Mutex
+	conn      *amqp.Conn
+	senders   map[string]*amqp.Sender
+	things    map[string]*amqpThing
+}
+
+// amqpThing keeps receiver and event listeners of bound Thing so it can be unbound
+type amqpThing struct {
+	s         *server.WotServer
+	address   string
+	receiver  *amqp.Receiver
+	listeners map[string]*server.EventListener
+}
+
+// NewAMQP connects to broker at "url", amqps:// urls use TLS and user of url is authenticated by SASL PLAIN
+func NewAMQP(cfg map[string]interface{}) Frontend {
+	name, _ := cfg["hostname"].(string)
+	container := str.Concat("tno2 ", name)
+	conn, err := amqp.Dial(cfg["url"].(string), container)
+	if err != nil {
+		panic(err)
+	}
+
+	prefix := AMQP_PREFIX
+	if p, ok := cfg["prefix"].(string); ok {
+		prefix = p
+	}
+
+	timeout := AMQP_TIMEOUT
+	if t, ok := cfg["timeout"].(int); ok {
+		timeout = time.Duration(t) * time.Second
+	}
+
+	binding, _ := sec.UUID4()
+
+	a := &AMQP{
+		url:       cfg["url"].(string),
+		binding:   str.Concat("AMQP ", binding),
+		container: container,
+		prefix:    prefix,
+		timeout:   timeout,
+		l:         &sync.Mutex{},
+		conn:      conn,
+		senders:   make(map[string]*amqp.Sender),
+		things:    make(map[string]*amqpThing),
+	}
+	go a.reconnect(conn)
+
+	return a
+}
+
+func (a *AMQP) Bind(ctxPath string, s *server.WotServer) {
+	a.Unbind(ctxPath)
+
+	thing := &amqpThing{
+		s:         s,
+		address:   str.Concat(a.prefix, "/", strings.Trim(ctxPath, "/")),
+		listeners: make(map[string]*server.EventListener),
+	}
+
+	forms := &server.Forms{
+		URIs:   []string{brokerHref(a.url, thing.address)},
+		Events: make(map[string][]string),
+	}
+
+	for _, e := range s.GetDescription().Events {
+		address := str.Concat(thing.address, "/event/", e.Name)
+		forms.Events[e.Name] = []string{brokerHref(a.url, address)}
+		id, _ := sec.UUID4()
+
+		listener := &server.EventListener{
+			ID: id,
+			CB: func(event interface{}) {
+				data, err := json.Marshal(event)
+				if err == nil {
+					err = a.send(address, &amqp.Message{To: address, Subject: e.Name, ContentType: "application/json", Data: data})
+				}
+				if err != nil {
+					log.Error("AMQP: event ", e.Name, " not sent -> ", err)
+				}
+			},
+		}
+		thing.listeners[e.Name] = listener
+		s.AddListener(e.Name, listener)
+	}
+
+	a.l.Lock()
+	a.things[ctxPath] = thing
+	a.attach(thing)
+	a.l.Unlock()
+
+	s.AddForms(a.binding, forms)
+
+	log.Info("AMQP: bound thing -> ", thing.address)
+}
+
+// attach attaches receiver of Thing requests, it is called with lock held
+func (a *AMQP) attach(thing *amqpThing) {
+	receiver, err := a.conn.NewReceiver(thing.address, func(m *amqp.Message) {
+		//handler is called by reading goroutine of connection, long actions must not block it
+		go a.serve(thing, m)
+	})
+
+	if err != nil {
+		log.Error("AMQP: receiver of ", thing.address, " not attached -> ", err)
+		return
+	}
+
+	thing.receiver = receiver
+}
+
+// serve answers request to its reply-to address, requests without reply address are ignored
+func (a *AMQP) serve(thing *amqpThing, m *amqp.Message) {
+	if m.ReplyTo == "" {
+		return
+	}
+
+	reply := interact(thing.s, m.Subject, m.Data, a.timeout)
+	data, err := json.Marshal(reply)
+	if err == nil {
+		err = a.send(m.ReplyTo, &amqp.Message{
+			To:            m.ReplyTo,
+			Subject:       m.Subject,
+			CorrelationID: m.MessageID,
+			ContentType:   "application/json",
+			Properties:    map[string]interface{}{"status": int32(reply.Status)},
+			Data:          data,
+		})
+	}
+
+	if err != nil {
+		log.Error("AMQP: request ", m.Subject, " of ", thing.address, " not answered -> ", err)
+	}
+}
+
+// send sends message by sender link of address, links are kept for next messages of the same address
+func (a *AMQP) send(address string, m *amqp.Message) error {
+	a.l.Lock()
+	sender, ok := a.senders[address]
+	if !ok {
+		var err error
+		if sender, err = a.conn.NewSender(address); err != nil {
+			a.l.Unlock()
+			return err
+		}
+		a.senders[address] = sender
+	}
+	a.l.Unlock()
+
+	err := sender.Send(m, a.timeout)
+	if err != nil && err != amqp.ErrReleased {
+		//link may be detached by broker, next message attaches new one
+		a.l.Lock()
+		if a.senders[address] == sender {
+			delete(a.senders, address)
+		}
+		a.l.Unlock()
+		sender.Close()
+	}
+
+	return err
+}
+
+// reconnect connects to broker again when connection is lost and attaches receivers of bound Things
+func (a *AMQP) reconnect(conn *amqp.Conn) {
+	for {
+		<-conn.Done()
+		log.Error("AMQP: connection lost -> ", conn.Err())
+
+		for {
+			time.Sleep(AMQP_RECONNECT)
+
+			var err error
+			if conn, err = amqp.Dial(a.url, a.container); err == nil {
+				break
+			}
+			log.Error("AMQP: connect failed -> ", err)
+		}
+
+		a.l.Lock()
+		a.conn = conn
+		a.senders = make(map[string]*amqp.Sender)
+		for _, thing := range a.things {
+			thing.receiver = nil
+			a.attach(thing)
+		}
+		a.l.Unlock()
+
+		log.Info("AMQP: connected again -> ", a.url)
+	}
+}
+
+// Unbind detaches receiver of Thing and stops sending its events
+func (a *AMQP) Unbind(ctxPath string) bool {
+	a.l.Lock()
+	thing, ok := a.things[ctxPath]
+	delete(a.things, ctxPath)
+	var receiver *amqp.Receiver
+	if ok {
+		receiver = thing.receiver
+	}
+	a.l.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	if receiver != nil {
+		receiver.Close()
+	}
+	for name, listener := range thing.listeners {
+		thing.s.RemoveListener(name, listener)
+	}
+	thing.s.RemoveForms(a.binding)
+
+	return true
+}
+
+func (a *AMQP) Start() {}
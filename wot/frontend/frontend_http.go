@@ -1,14 +1,26 @@
 package frontend
 
 import (
+	"errors"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/conas/tno2/util/async"
 	"github.com/conas/tno2/util/sec"
 	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/audit"
+	"github.com/conas/tno2/wot/auth"
 	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/schedule"
 	"github.com/conas/tno2/wot/server"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -18,95 +30,411 @@ import (
 // There is some issue about enabling cors using gorilla handlers. workaround is done manually
 
 type Http struct {
-	hostname      string
-	port          int
-	router        *mux.Router
-	hrefs         []string
-	wotServers    map[string]*server.WotServer
-	subscribers   *server.Subscribers
-	actionResults *server.ActionResults
+	hostname        string
+	port            int
+	router          *router
+	hrefs           []string
+	l               *sync.RWMutex
+	wotServers      map[string]*server.WotServer
+	thingRouters    map[string]*router
+	defaultTenant   *tenant
+	tenants         map[string]*tenant
+	admin           *admin
+	lifecycle       *async.FanOut
+	tlsCert         string
+	tlsKey          string
+	h2c             bool
+	push            bool
+	socket          string
+	listeners       []*listener
+	routes          RouteStrategy
+	collections     bool
+	graphql         bool
+	scheduler       *schedule.Scheduler
+	scheduleResults *async.FanOut
+	signatures      *signatures
+	audit           *audit.Log
+	clientTLS       *clientTLS
+	ws              *wsConfig
+	limits          *limits
+	groups          *groups
+	labels          *labels
+	callbacks       *callbacks
+	multicast       *multicast
+	socketIO        *socketIO
+	middlewares     *middlewares
+	mount           *mount
+	proxies         *proxies
+	// binding identifies forms of binding in descriptions of bound WotServers
+	binding string
 }
 
 // ----- Server API methods
 
 func NewHTTP(cfg map[string]interface{}) Frontend {
 	http := &Http{
-		hostname:      cfg["hostname"].(string),
-		port:          cfg["port"].(int),
-		router:        mux.NewRouter().StrictSlash(true),
-		hrefs:         make([]string, 0),
-		wotServers:    make(map[string]*server.WotServer),
-		subscribers:   server.NewSubscribers(),
-		actionResults: server.NewActionResults(),
+		hostname:        cfg["hostname"].(string),
+		port:            cfg["port"].(int),
+		router:          newRouter(),
+		hrefs:           make([]string, 0),
+		l:               &sync.RWMutex{},
+		wotServers:      make(map[string]*server.WotServer),
+		thingRouters:    make(map[string]*router),
+		defaultTenant:   newTenant("", nil),
+		tenants:         make(map[string]*tenant),
+		lifecycle:       async.NewFanOut(),
+		scheduleResults: async.NewFanOut(),
+	}
+
+	binding, _ := sec.UUID4()
+	http.binding = str.Concat("HTTP ", binding)
+
+	if guard, ok := cfg["auth"]; ok {
+		http.defaultTenant.guard = guard.(*auth.Guard)
 	}
 
 	http.registerRoot()
-	http.registerPreflight()
+	http.registerAggregation(http.defaultTenant, "")
+	http.registerLifecycle(http.defaultTenant)
+
+	if ui, ok := cfg["ui"]; ok && ui.(bool) {
+		http.registerUI()
+	}
+
+	//HTTP/2 is negotiated on TLS, h2c enables HTTP/2 over cleartext for internal deployments
+	if cert, ok := cfg["tlsCert"]; ok {
+		http.tlsCert = cert.(string)
+		http.tlsKey = cfg["tlsKey"].(string)
+	}
+
+	if h2c, ok := cfg["h2c"]; ok {
+		http.h2c = h2c.(bool)
+	}
+
+	if push, ok := cfg["push"]; ok {
+		http.push = push.(bool)
+	}
+
+	//Unix domain socket replaces TCP port, e.g. for sidecar consumers or proxy owning TLS
+	if socket, ok := cfg["socket"]; ok {
+		http.socket = socket.(string)
+	}
+
+	//deferred action invocations are persisted to schedules file, if configured
+	schedules, _ := cfg["schedules"].(string)
+	http.scheduler = schedule.NewScheduler(schedules, http.fireSchedule)
+
+	http.configureSignatures(cfg)
+	http.configureClientTLS(cfg)
+	http.configureWebSockets(cfg)
+	http.configureLimits(cfg)
+	http.configureListeners(cfg)
+	http.configureMount(cfg)
+	http.configureProxies(cfg)
+	http.configureRoutes(cfg)
+	http.configureGroups(cfg)
+	http.configureNotFound(cfg)
+	http.configureLabels(cfg)
+	http.configureCallbacks(cfg)
+	http.configureMulticast(cfg)
+	http.configureSocketIO(cfg)
+	http.configureMiddlewares(cfg)
+
+	//GraphQL endpoint over Things of default tenant, tenants get their own when added
+	if enabled, ok := cfg["graphql"].(bool); ok && enabled {
+		http.graphql = true
+		http.registerGraphQL(http.defaultTenant, "")
+	}
 
 	return http
 }
 
 func (p *Http) Bind(ctxPath string, s *server.WotServer) {
-	td := s.GetDescription()
+	p.bind(p.defaultTenant, ctxPath, s)
+}
+
+func (p *Http) bind(t *tenant, ctxPath string, s *server.WotServer) {
+	rt := newRouter()
+	p.createRoutes(rt, t, ctxPath, s)
+
+	p.l.Lock()
+	replaced, rebound := p.wotServers[ctxPath]
 	p.wotServers[ctxPath] = s
-	p.createRoutes(ctxPath, td)
-	p.updateThingDescription(ctxPath, td)
+	p.thingRouters[ctxPath] = rt
+	t.things[ctxPath] = s
+	p.l.Unlock()
+
+	eventType := server.THING_CREATED
+	if rebound {
+		//subscriptions and tasks belong to replaced WotServer
+		t.subscribers.CancelThing(ctxPath)
+		t.actionResults.RemoveThing(ctxPath)
+		eventType = server.THING_UPDATED
+
+		if replaced != s {
+			replaced.RemoveForms(p.binding)
+		}
+	}
+
+	p.multicast.bind(ctxPath, s)
+	p.socketIO.rebind(ctxPath, s, p.limits)
+	p.publishLifecycle(eventType, t, ctxPath, s.Name())
+}
+
+// Unbind removes Thing routes and cancels all its subscriptions and action tasks
+func (p *Http) Unbind(ctxPath string) bool {
+	p.l.Lock()
+	s, ok := p.wotServers[ctxPath]
+	delete(p.wotServers, ctxPath)
+	delete(p.thingRouters, ctxPath)
+	t := p.tenantOf(ctxPath)
+	delete(t.things, ctxPath)
+	p.l.Unlock()
+
+	if ok {
+		t.subscribers.CancelThing(ctxPath)
+		t.actionResults.RemoveThing(ctxPath)
+		p.scheduler.CancelThing(ctxPath)
+		p.multicast.unbind(ctxPath)
+		p.socketIO.rebind(ctxPath, nil, p.limits)
+		s.RemoveForms(p.binding)
+		log.Info("HTTP: unbound thing -> ", ctxPath)
+		p.publishLifecycle(server.THING_REMOVED, t, ctxPath, "")
+	}
+
+	return ok
+}
+
+func (p *Http) tenantOf(ctxPath string) *tenant {
+	for _, t := range p.tenants {
+		if _, ok := t.things[ctxPath]; ok {
+			return t
+		}
+	}
+
+	return p.defaultTenant
 }
 
 func (p *Http) Start() {
+	p.scheduler.Start()
+
+	if p.socket != "" {
+		l, err := listenUnix(p.socket)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Fatal(p.serve(l))
+	}
+
+	ls, err := p.listen()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	port := str.Concat(":", strconv.Itoa(p.port))
-	log.Fatal(http.ListenAndServe(port, p.router))
+	errs := make(chan error, len(ls))
+	for _, l := range ls {
+		go func() {
+			errs <- p.serve(l)
+		}()
+	}
+
+	log.Fatal(<-errs)
 	// log.Fatal(http.ListenAndServe(port,
 	// 	handlers.CORS(
 	// 		handlers.AllowedOrigins([]string{"*"}),
 	// 		handlers.AllowedMethods([]string{"GET", "PUT", "POST", "OPTIONS"}))(p.router)))
 }
 
-func (p *Http) updateThingDescription(ctxPath string, td *model.ThingDescription) {
-	td.Uris = append(td.Uris, str.Concat("http://", p.hostname, ":", p.port, ctxPath))
-	td.Encodings = Encoders.Registered()
+// Serve accepts connections on l, TLS is used when configured
+func (p *Http) Serve(l net.Listener) error {
+	p.scheduler.Start()
+	return p.serve(l)
+}
+
+func (p *Http) serve(l net.Listener) error {
+	srv := p.server(l.Addr().String())
+
+	if p.tlsCert != "" {
+		return srv.ServeTLS(l, p.tlsCert, p.tlsKey)
+	}
+
+	return srv.Serve(l)
+}
+
+// listenUnix listens on Unix socket, stale socket file left by previous run is removed
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	return net.Listen("unix", path)
 }
 
-func (p *Http) registerPreflight() {
-	p.addPreflight(&route{handlerFunc: func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "X-PINGOTHER, Content-Type")
-		w.Header().Set("Access-Control-Max-Age", "86400")
-		w.WriteHeader(http.StatusOK)
-	}})
+func (p *Http) server(addr string) *http.Server {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(p.tlsCert != "")
+	protocols.SetUnencryptedHTTP2(p.h2c)
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           p,
+		Protocols:         protocols,
+		TLSConfig:         p.tlsConfig(),
+		ReadTimeout:       p.limits.readTimeout,
+		ReadHeaderTimeout: p.limits.readHeaderTimeout,
+		WriteTimeout:      p.limits.writeTimeout,
+		IdleTimeout:       p.limits.idleTimeout,
+	}
+}
+
+func (p *Http) scheme() string {
+	if p.tlsCert != "" {
+		return "https"
+	}
+
+	return "http"
 }
 
 func (p *Http) registerRoot() {
-	p.addRoute(&route{
+	p.addRoute(p.router, &route{
 		method:  "GET",
 		pattern: "/",
 		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, p.defaultTenant) {
+				return
+			}
+
 			ls := links()
 
-			for path := range p.wotServers {
+			p.l.RLock()
+			for path := range p.defaultTenant.things {
 				ls.Links = append(ls.Links, httpSubURL(r, path))
 			}
+			p.l.RUnlock()
 
 			sendOK(w, r, ls)
 		},
 	})
+
+	//WoT Discovery introduction, crawlers find Things without knowing their context paths
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: "/.well-known/wot",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, p.defaultTenant) {
+				return
+			}
+
+			p.l.RLock()
+			paths := make([]string, 0, len(p.defaultTenant.things))
+			for path := range p.defaultTenant.things {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			languages := acceptedLanguages(r)
+			tds := make([]*model.ThingDescription, 0, len(paths))
+			for _, path := range paths {
+				td, _ := p.defaultTenant.things[path].Description().Localized(languages)
+				tds = append(tds, td)
+			}
+			p.l.RUnlock()
+
+			w.Header().Add("Vary", "Accept-Language")
+			sendTagged(w, r, tds)
+		},
+	})
+}
+
+// ServeHTTP passes request to middlewares and dispatches it to router of the bound Thing with the
+// longest matching context path. Requests of paths not routed by any Thing are served by frontend
+// level routes.
+func (p *Http) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withRequestID(w, r)
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	w = sw
+	defer p.recovery(sw, r)
+
+	r, ok := p.mount.strip(r)
+	if !ok {
+		sendCode(w, r, http.StatusNotFound, "Not found.")
+		return
+	}
+	r = p.proxies.forwarded(r)
+
+	p.middlewares.wrap(http.HandlerFunc(p.dispatch)).ServeHTTP(w, r)
+}
+
+func (p *Http) dispatch(w http.ResponseWriter, r *http.Request) {
+	rt, ctxPath, ok := p.thingRouter(r.URL.Path)
+	if !ok || !rt.serves(r) {
+		rt, ctxPath = p.router, ""
+	} else {
+		r = p.withThing(r, ctxPath)
+	}
+
+	release, ok := p.admitRequest(w, r, ctxPath)
+	if !ok {
+		return
+	}
+	defer release()
+
+	rt.ServeHTTP(w, r)
+}
+
+func (p *Http) thingRouter(path string) (*router, string, bool) {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	var match *router
+	var matchPath string
+	matchLen := -1
+
+	for ctxPath, rt := range p.thingRouters {
+		if len(ctxPath) > matchLen && (path == ctxPath || strings.HasPrefix(path, str.Concat(ctxPath, "/"))) {
+			match, matchPath, matchLen = rt, ctxPath, len(ctxPath)
+		}
+	}
+
+	return match, matchPath, match != nil
 }
 
 // ----- ThingDescription parser methods
 
-func (p *Http) createRoutes(ctxPath string, td *model.ThingDescription) {
-	p.enablePreflight(ctxPath)
-	p.registerDeviceRoot(ctxPath)
-	p.registerDeviceDescriptor(ctxPath, td)
-	p.registerProperties(ctxPath, td.Properties)
-	p.registerActions(ctxPath, td.Actions)
-	p.registerEvents(ctxPath, td.Events)
+// createRoutes registers routes of Thing and advertises them as forms of binding in its description.
+// Routes are derived from declared hrefs, so Thing bound to several bindings is served by all of them.
+func (p *Http) createRoutes(rt *router, t *tenant, ctxPath string, s *server.WotServer) {
+	td := s.Description()
+	forms := &server.Forms{
+		Encodings:  Encoders.Registered(),
+		Properties: make(map[string][]string),
+		Actions:    make(map[string][]string),
+		Events:     make(map[string][]string),
+	}
+	for _, base := range p.baseURLs() {
+		forms.URIs = append(forms.URIs, str.Concat(base, ctxPath))
+	}
+
+	p.enablePreflight(rt, ctxPath)
+	p.registerDeviceRoot(rt, t, ctxPath)
+	p.registerDeviceDescriptor(rt, t, ctxPath, s)
+	p.registerProperties(rt, t, ctxPath, s, td.Properties, forms)
+	p.registerActions(rt, t, ctxPath, s, td.Actions, forms)
+	p.registerEvents(rt, t, ctxPath, s, td.Events, forms)
+	p.registerSchedules(rt, t, ctxPath, s)
+	p.registerSnapshot(rt, t, ctxPath, s)
+	p.registerAPIDocs(rt, t, ctxPath, s)
+
+	if p.collections {
+		p.registerCollections(rt, t, ctxPath, s)
+	}
+
+	s.AddForms(p.binding, forms)
 }
 
-func (p *Http) enablePreflight(ctxPath string) {
-	p.addRoute(&route{
+func (p *Http) enablePreflight(rt *router, ctxPath string) {
+	p.addRoute(rt, &route{
 		method:  "OPTIONS",
 		pattern: contextPath(ctxPath, ""),
 		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
@@ -117,124 +445,304 @@ func (p *Http) enablePreflight(ctxPath string) {
 	})
 }
 
-func (p *Http) registerDeviceRoot(ctxPath string) {
-	p.addRoute(&route{
+func (p *Http) registerDeviceRoot(rt *router, t *tenant, ctxPath string) {
+	p.addRoute(rt, &route{
 		method:  "GET",
 		pattern: contextPath(ctxPath, ""),
 		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
 			hrefs := links(httpSubURL(r, "description"))
 
+			if p.push {
+				pushDescription(w, r, ctxPath)
+			}
+
 			sendOK(w, r, hrefs)
 		},
 	})
 }
 
-func (p *Http) registerDeviceDescriptor(ctxPath string, td *model.ThingDescription) {
-	p.addRoute(&route{
+// pushDescription pushes TD to HTTP/2 clients fetching Thing root, client credentials are passed
+// along so the pushed request passes authentication
+func pushDescription(w http.ResponseWriter, r *http.Request, ctxPath string) {
+	pusher, ok := w.(http.Pusher)
+	if !ok {
+		return
+	}
+
+	header := http.Header{}
+	for _, h := range []string{"Authorization", "X-API-Key", "Accept"} {
+		if v := r.Header.Get(h); v != "" {
+			header.Set(h, v)
+		}
+	}
+
+	if err := pusher.Push(contextPath(ctxPath, "description"), &http.PushOptions{Header: header}); err != nil && err != http.ErrNotSupported {
+		log.Info("HTTP: description push failed -> ", err)
+	}
+}
+
+func (p *Http) registerDeviceDescriptor(rt *router, t *tenant, ctxPath string, s *server.WotServer) {
+	p.addRoute(rt, &route{
 		method:  "GET",
 		pattern: contextPath(ctxPath, "description"),
 		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
-			sendOK(w, r, td)
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			td := s.Description()
+			if version := r.URL.Query().Get("version"); version != "" {
+				number, err := strconv.Atoi(version)
+				if err != nil {
+					sendCode(w, r, http.StatusBadRequest, "Invalid version.")
+					return
+				}
+
+				var ok bool
+				if td, ok = s.DescriptionVersion(number); !ok {
+					sendCode(w, r, http.StatusNotFound, "Version not kept.")
+					return
+				}
+			}
+
+			//?format=tm exports description as Thing Model of similar Things
+			if r.URL.Query().Get("format") == "tm" {
+				td = td.ThingModel()
+			}
+
+			sendDescription(w, r, td)
 		},
 	})
 }
 
-func (p *Http) registerProperties(ctxPath string, properties []model.Property) {
+func (p *Http) registerProperties(rt *router, t *tenant, ctxPath string, s *server.WotServer, properties []model.Property, forms *server.Forms) {
 	for _, prop := range properties {
-		p.addRoute(&route{
-			method:      "GET",
-			pattern:     contextPath(ctxPath, prop.Hrefs[0]),
-			handlerFunc: p.propertyGetHandler(ctxPath, prop),
-		})
+		paths := p.routes.Paths(AFFORDANCE_PROPERTY, prop.Name, s.DeclaredHrefs(prop.Name))
+
+		for _, path := range paths {
+			pattern := routePattern(path)
+
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, pattern),
+				handlerFunc: p.propertyGetHandler(t, s, prop),
+			})
+
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/history")),
+				handlerFunc: p.propertyHistoryHandler(t, s, prop),
+			})
 
-		if prop.Writable {
-			p.addRoute(&route{
-				method:      "PUT",
-				pattern:     contextPath(ctxPath, prop.Hrefs[0]),
-				handlerFunc: p.propertySetHandler(ctxPath, prop),
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/sync")),
+				handlerFunc: p.propertySyncHandler(t, ctxPath, s, prop),
 			})
+
+			if prop.Writable {
+				p.addRoute(rt, &route{
+					method:      "PUT",
+					pattern:     contextPath(ctxPath, pattern),
+					handlerFunc: p.propertySetHandler(t, s, prop),
+				})
+
+				p.addRoute(rt, &route{
+					method:      "PATCH",
+					pattern:     contextPath(ctxPath, pattern),
+					handlerFunc: p.propertyPatchHandler(t, s, prop),
+				})
+
+				p.addRoute(rt, &route{
+					method:      "PUT",
+					pattern:     contextPath(ctxPath, str.Concat(pattern, "/cas")),
+					handlerFunc: p.propertyCASHandler(t, s, prop),
+				})
+			}
 		}
 
-		prop.Hrefs[0] = str.Concat("http://", p.hostname, ":", p.port, ctxPath, "/", prop.Hrefs[0])
+		forms.Properties[prop.Name] = p.advertise(ctxPath, paths)
 	}
 }
 
-func (p *Http) registerActions(ctxPath string, actions []model.Action) {
+func (p *Http) registerActions(rt *router, t *tenant, ctxPath string, s *server.WotServer, actions []model.Action, forms *server.Forms) {
 	for _, action := range actions {
-		p.addRoute(&route{
-			method:      "POST",
-			pattern:     contextPath(ctxPath, action.Hrefs[0]),
-			handlerFunc: p.actionStartHandler(p.wotServers[ctxPath], action.Name),
-		})
+		paths := p.routes.Paths(AFFORDANCE_ACTION, action.Name, s.DeclaredHrefs(action.Name))
 
-		p.addRoute(&route{
-			method:      "GET",
-			pattern:     contextPath(ctxPath, str.Concat(action.Hrefs[0], "/{taskid}")),
-			handlerFunc: p.actionTaskHandler(p.wotServers[ctxPath]),
-		})
+		for _, path := range paths {
+			pattern := routePattern(path)
 
-		p.addRoute(&route{
-			method:      "GET",
-			pattern:     contextPath(ctxPath, str.Concat(action.Hrefs[0], "/ws/{taskid}")),
-			handlerFunc: p.actionWSTaskHandler(p.wotServers[ctxPath]),
-		})
+			p.addRoute(rt, &route{
+				method:      "POST",
+				pattern:     contextPath(ctxPath, pattern),
+				handlerFunc: p.actionStartHandler(t, ctxPath, s, action.Name),
+			})
+
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/{taskid}")),
+				handlerFunc: p.actionTaskHandler(t, ctxPath, s, action.Name),
+			})
+
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/ws/{taskid}")),
+				handlerFunc: p.actionWSTaskHandler(t, ctxPath, s, action.Name),
+			})
+
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/{taskid}/result")),
+				handlerFunc: p.actionResultHandler(t, ctxPath, s, action.Name),
+			})
+		}
 
-		action.Hrefs[0] = str.Concat("http://", p.hostname, ":", p.port, ctxPath, "/", action.Hrefs[0])
+		forms.Actions[action.Name] = p.advertise(ctxPath, paths)
 	}
 }
 
-func (p *Http) registerEvents(ctxPath string, events []model.Event) {
+func (p *Http) registerEvents(rt *router, t *tenant, ctxPath string, s *server.WotServer, events []model.Event, forms *server.Forms) {
 	for _, event := range events {
-		p.addRoute(&route{
-			method:      "POST",
-			pattern:     contextPath(ctxPath, event.Hrefs[0]),
-			handlerFunc: p.eventSubscribeHandler(p.wotServers[ctxPath], event.Name),
-		})
+		paths := p.routes.Paths(AFFORDANCE_EVENT, event.Name, s.DeclaredHrefs(event.Name))
 
-		p.addRoute(&route{
-			method:      "GET",
-			pattern:     contextPath(ctxPath, str.Concat(event.Hrefs[0], "/ws/{subscriptionID}")),
-			handlerFunc: p.eventWSClientHandler(p.wotServers[ctxPath]),
-		})
+		for _, path := range paths {
+			pattern := routePattern(path)
+
+			p.addRoute(rt, &route{
+				method:      "POST",
+				pattern:     contextPath(ctxPath, pattern),
+				handlerFunc: p.eventSubscribeHandler(t, ctxPath, s, event.Name),
+			})
 
-		event.Hrefs[0] = str.Concat("http://", p.hostname, ":", p.port, ctxPath, "/", event.Hrefs[0])
+			p.addRoute(rt, &route{
+				method:      "GET",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/ws/{subscriptionID}")),
+				handlerFunc: p.eventWSClientHandler(t, ctxPath, s, event.Name),
+			})
+
+			p.addRoute(rt, &route{
+				method:      "DELETE",
+				pattern:     contextPath(ctxPath, str.Concat(pattern, "/ws/{subscriptionID}")),
+				handlerFunc: p.eventUnsubscribeHandler(t, ctxPath, s, event.Name),
+			})
+		}
+
+		forms.Events[event.Name] = p.multicast.advertise(event.Name, p.advertise(ctxPath, paths))
 	}
 }
 
-func (p *Http) propertyGetHandler(ctxPath string, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
+func (p *Http) propertyGetHandler(t *tenant, wotServer *server.WotServer, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		value := p.wotServers[ctxPath].GetProperty(prop.Name)
+		if !p.authorized(w, r, t, auth.RIGHT_READ, wotServer, prop.Name) {
+			return
+		}
+
+		value := requested(r, wotServer).GetProperty(prop.Name)
 		data := value.Get()
 
 		switch data.(type) {
 		case server.Status:
-			if data.(server.Status) != server.WOT_OK {
+			if data.(server.Status) != server.WOT_OK && !sendLastKnown(w, r, wotServer, prop, data) {
 				sendERR(w, r, data)
 			}
 		case error:
-			sendERR(w, r, data)
+			if !sendLastKnown(w, r, wotServer, prop, data) {
+				sendERR(w, r, data)
+			}
 		default:
-			sendOK(w, r, data)
+			if stream, ok := asStream(data); ok {
+				if c, ok := stream.Reader.(io.Closer); ok {
+					defer c.Close()
+				}
+				sendStream(w, r, prop.Name, stream)
+				return
+			}
+
+			//ciphertext is forwarded as is, conversions and other encodings need plaintext
+			if prop.Encryption != nil {
+				sendTagged(w, r, data)
+				return
+			}
+
+			if to := r.URL.Query().Get("unit"); to != "" {
+				converted, err := convertUnit(prop, data, to)
+				if err != nil {
+					sendPlainERR(w, err)
+					return
+				}
+				sendTagged(w, r, converted)
+				return
+			}
+
+			if fields := r.URL.Query().Get("fields"); fields != "" {
+				projection, err := project(data, fields)
+				if err != nil {
+					sendERR(w, r, err)
+					return
+				}
+				data = projection
+			}
+
+			if acceptsProtobuf(r) {
+				sendProtobuf(w, r, prop, data)
+				return
+			}
+
+			if encoder, ok := acceptsSenML(r); ok {
+				sendSenML(w, r, encoder, wotServer.Name(), prop, data)
+				return
+			}
+
+			encoder, contentType := PropertyEncoder(prop)
+			sendTaggedAs(w, r, encoder, contentType, data)
 		}
 	}
 }
 
-func (p *Http) propertySetHandler(ctxPath string, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
+func (p *Http) propertySetHandler(t *tenant, wotServer *server.WotServer, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_WRITE, wotServer, prop.Name) {
+			return
+		}
+
+		limitBody(w, r, p.limits.propertyBody)
+
 		var wo interface{}
-		err := readBody(r, &wo)
+		var err error
+
+		if isProtobuf(r) {
+			wo, err = readProtobuf(r, prop)
+		} else if encoder, ok := senmlBody(r); ok {
+			err = encoder.Decode(r.Body, &wo)
+		} else {
+			err = decodeBody(r, bodyDecoder(r, PropertyDecoder(prop)), &wo)
+		}
 
 		if err != nil {
 			sendPlainERR(w, err)
 			return
 		}
 
-		value := p.wotServers[ctxPath].SetProperty(prop.Name, wo)
+		var value *async.Promise
+		if condition := r.Header.Get("If-Match"); condition != "" {
+			value = requested(r, wotServer).SetPropertyIf(prop.Name, ifMatch(prop, condition), wo)
+		} else {
+			value = requested(r, wotServer).SetProperty(prop.Name, wo)
+		}
 		data := value.Get()
+		p.audited(p.auditEntry(r, t, wotServer, server.INTERACTION_WRITE, prop.Name, wo), data)
 
 		switch data.(type) {
 		case server.Status:
-			if data.(server.Status) != server.WOT_OK {
+			if data.(server.Status) == server.WOT_PROPERTY_CONFLICT {
+				sendCode(w, r, http.StatusPreconditionFailed, "Property changed since it was read")
+			} else if data.(server.Status) == server.WOT_WRITE_QUEUED {
+				sendCode(w, r, http.StatusAccepted, "Write queued until Thing is online")
+			} else if data.(server.Status) != server.WOT_OK {
 				sendERR(w, r, data)
 			}
 		case error:
@@ -243,91 +751,376 @@ func (p *Http) propertySetHandler(ctxPath string, prop model.Property) func(w ht
 	}
 }
 
-func (p *Http) actionStartHandler(wotServer *server.WotServer, actionName string) func(w http.ResponseWriter, r *http.Request) {
+func (p *Http) actionStartHandler(t *tenant, ctxPath string, wotServer *server.WotServer, actionName string) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var wo interface{}
-		err := readBody(r, &wo)
+		if !p.authorized(w, r, t, auth.RIGHT_INVOKE, wotServer, actionName) {
+			return
+		}
+
+		p.limitActionBody(w, r)
+
+		if !p.verifySignature(w, r, wotServer, actionName) {
+			return
+		}
+
+		var decoder Encoder
+		if action, ok := findAction(wotServer.GetDescription(), actionName); ok {
+			decoder = InputDecoder(*action)
+		}
+
+		wo, consumed, err := readActionInput(r, decoder, p.limits.actionBody)
+
+		if err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		at, scheduled, err := scheduledAt(r)
+
+		if err == nil && scheduled && consumed != nil {
+			err = errStreamScheduled
+		}
+
+		//?callback=<URL> is posted final task status once action finishes
+		var callbackURL string
+		if err == nil {
+			callbackURL, err = p.callbackURL(r)
+		}
+
+		if err == nil && scheduled && callbackURL != "" {
+			err = errCallbackScheduled
+		}
 
 		if err != nil {
 			sendPlainERR(w, err)
 			return
 		}
 
-		actionID, slot := p.actionResults.CreateSlot()
+		actionID, slot := t.actionResults.CreateSlot(ctxPath, actionName)
 		clients := async.NewFanOut()
-		p.subscribers.CreateSubscription(actionID, clients)
-		ph := server.NewWotProgressHandler(actionName, slot, clients)
-		wotServer.InvokeAction(actionName, wo, ph)
+		t.subscribers.CreateSubscription(&server.Subscription{
+			ID:      actionID,
+			Thing:   ctxPath,
+			Name:    actionName,
+			Clients: clients,
+		})
+		ph := server.NewTaskProgressHandler(actionID, actionName, slot, clients)
+
+		entry := p.auditEntry(r, t, wotServer, server.INTERACTION_INVOKE, actionName, wo)
+
+		if scheduled {
+			p.audited(entry, map[string]interface{}{"scheduledAt": at})
+			p.scheduleAction(w, r, ctxPath, actionName, actionID, wo, at, ph)
+			return
+		}
+
+		invocation := requested(r, wotServer).InvokeAction(actionName, wo, ph)
+		if entry != nil {
+			go p.auditedTask(entry, invocation, slot)
+		}
+
+		if rejected(invocation) {
+			t.subscribers.CancelSubscription(actionID)
+			t.actionResults.RemoveSlot(actionID)
+			sendCode(w, r, http.StatusConflict, "Action busy")
+			return
+		}
+
+		if offline(invocation) {
+			t.subscribers.CancelSubscription(actionID)
+			t.actionResults.RemoveSlot(actionID)
+			sendUnavailable(w, r)
+			return
+		}
+
+		if shed(invocation) {
+			t.subscribers.CancelSubscription(actionID)
+			t.actionResults.RemoveSlot(actionID)
+			sendBusy(w, r)
+			return
+		}
+
+		if refused(invocation) {
+			t.subscribers.CancelSubscription(actionID)
+			t.actionResults.RemoveSlot(actionID)
+			sendERR(w, r, invocation.Get())
+			return
+		}
+
+		if callbackURL != "" {
+			cb := &Callback{Task: actionID, Thing: ctxPath, Action: actionName, RequestID: requestID(r)}
+			go p.callback(callbackURL, cb, invocation, slot)
+		}
+
+		//streamed input is readable only while request is being handled
+		if consumed != nil {
+			select {
+			case <-consumed:
+			case <-invocation.Done():
+			}
+		}
 
 		hrefs := links(websocketSubURL(r, actionID), httpSubURL(r, actionID))
+
+		//fast actions are answered by result, by description or Prefer: return=representation
+		if action, _ := findAction(wotServer.GetDescription(), actionName); synchronous(r, action) {
+			p.sendResult(w, r, t, actionID, slot, hrefs)
+			return
+		}
+
 		sendOK(w, r, hrefs)
 	}
 }
 
-func (p *Http) actionTaskHandler(wotServer *server.WotServer) func(http.ResponseWriter, *http.Request) {
+// invokeTask starts action outside of REST handler as task observable at returned href, slot and
+// subscription of the task are dropped again when action rejects invocation
+func (p *Http) invokeTask(r *http.Request, t *tenant, ctxPath string, s *server.WotServer, action model.Action, input interface{}) (string, string, error) {
+	actionID, slot := t.actionResults.CreateSlot(ctxPath, action.Name)
+	clients := async.NewFanOut()
+	t.subscribers.CreateSubscription(&server.Subscription{
+		ID:      actionID,
+		Thing:   ctxPath,
+		Name:    action.Name,
+		Clients: clients,
+	})
+	ph := server.NewTaskProgressHandler(actionID, action.Name, slot, clients)
+
+	entry := p.auditEntry(r, t, s, server.INTERACTION_INVOKE, action.Name, input)
+	invocation := requested(r, s).InvokeAction(action.Name, input, ph)
+	if entry != nil {
+		go p.auditedTask(entry, invocation, slot)
+	}
+
+	if rejected(invocation) {
+		t.subscribers.CancelSubscription(actionID)
+		t.actionResults.RemoveSlot(actionID)
+		return "", "", errors.New("Action busy")
+	}
+
+	if offline(invocation) {
+		t.subscribers.CancelSubscription(actionID)
+		t.actionResults.RemoveSlot(actionID)
+		return "", "", &server.StatusError{Status: server.WOT_THING_OFFLINE}
+	}
+
+	if shed(invocation) {
+		t.subscribers.CancelSubscription(actionID)
+		t.actionResults.RemoveSlot(actionID)
+		return "", "", &server.StatusError{Status: server.WOT_THING_BUSY}
+	}
+
+	return actionID, str.Concat(p.actionHref(s, action), "/", actionID), nil
+}
+
+// actionHref returns href of action advertised by binding, the first one of other bindings otherwise
+func (p *Http) actionHref(s *server.WotServer, action model.Action) string {
+	if forms := s.Forms(p.binding); forms != nil && len(forms.Actions[action.Name]) > 0 {
+		return forms.Actions[action.Name][0]
+	}
+
+	return action.Hrefs[0]
+}
+
+// rejected reports invocation refused by action concurrency policy, such invocation is resolved immediately
+func rejected(invocation *async.Promise) bool {
+	select {
+	case <-invocation.Done():
+		return invocation.Get() == server.WOT_ACTION_BUSY
+	default:
+		return false
+	}
+}
+
+func (p *Http) actionTaskHandler(t *tenant, ctxPath string, wotServer *server.WotServer, actionName string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_INVOKE, wotServer, actionName) {
+			return
+		}
+
 		vars := mux.Vars(r)
 		taskid := vars["taskid"]
-		slot, rc := p.actionResults.GetSlot(taskid)
+		slot, ok := t.actionResults.GetActionSlot(taskid, ctxPath, actionName)
 
-		if rc {
-			sendOK(w, r, slot.Load())
-		} else {
-			sendERR(w, r, rc)
+		if !ok {
+			sendCode(w, r, http.StatusNotFound, "Unknown task")
+			return
+		}
+
+		//long-poll, ?wait=30s blocks until task completes or wait elapses
+		if param := r.URL.Query().Get("wait"); param != "" {
+			wait, err := time.ParseDuration(param)
+			if err != nil || wait < 0 {
+				sendPlainERR(w, errors.New(str.Concat("Invalid wait: ", param)))
+				return
+			}
+
+			sendOK(w, r, p.awaitTask(r, t, taskid, slot, wait))
+			return
 		}
+
+		sendOK(w, r, slot.Load())
 	}
 }
 
-func (p *Http) actionWSTaskHandler(wotServer *server.WotServer) func(w http.ResponseWriter, r *http.Request) {
+func (p *Http) actionWSTaskHandler(t *tenant, ctxPath string, wotServer *server.WotServer, actionName string) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_INVOKE, wotServer, actionName) {
+			return
+		}
+
 		vars := mux.Vars(r)
 		taskid := vars["taskid"]
-		slot, _ := p.actionResults.GetSlot(taskid)
-		p.wsHandler(wotServer, taskid, slot.Load(), w, r)
+		slot, ok := t.actionResults.GetActionSlot(taskid, ctxPath, actionName)
+
+		if !ok {
+			sendCode(w, r, http.StatusNotFound, "Unknown task")
+			return
+		}
+
+		p.wsHandler(t, ctxPath, wotServer, taskid, actionName, slot.Load(), w, r)
 	}
 }
 
-func (p *Http) wsHandler(wotServer *server.WotServer, handlerId string, welcomeValue interface{}, w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+// wsHandler connects client to subscription handlerId of interaction name of Thing at ctxPath
+func (p *Http) wsHandler(t *tenant, ctxPath string, wotServer *server.WotServer, handlerId, name string, welcomeValue interface{}, w http.ResponseWriter, r *http.Request) {
+	//subscription of other interaction is not reachable by rights granted on this one
+	sub, ok := t.subscribers.Subscription(handlerId)
+	if !ok || sub.Thing != ctxPath || sub.Name != name {
+		sendCode(w, r, http.StatusNotFound, "Subscription not found.")
+		return
+	}
 
-	if err != nil {
-		log.Println("Error creating WebSocket at: ", err)
+	//client reconnecting to subscription lost e.g. by restart needs to subscribe again
+	done := t.subscribers.Done(handlerId)
+	if done == nil {
+		sendCode(w, r, http.StatusNotFound, "Subscription not found.")
+		return
+	}
+
+	if encoding, _ := wsEncoding(r); encoding != ENCODING_JSON {
+		if _, err := Encoders.Get(encoding); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+	}
+
+	//client is added before upgrade, so connections over limit are rejected with HTTP status
+	if !p.limits.wsClients.acquire(ctxPath) {
+		sendCode(w, r, http.StatusTooManyRequests, "Too many WebSocket clients.")
 		return
 	}
+	defer p.limits.wsClients.release(ctxPath)
 
 	clientCh := make(chan interface{})
-	clientID := p.subscribers.AddClient(handlerId, clientCh)
+	clientID, ok := t.subscribers.AddClientLimit(handlerId, clientCh, p.ws.maxClients)
+
+	if !ok {
+		sendCode(w, r, http.StatusTooManyRequests, "Too many clients of subscription.")
+		return
+	}
+	removed := t.subscribers.ClientRemoved(handlerId, clientID)
+
+	conn, err := p.upgradeWS(w, r)
+
+	if err != nil {
+		t.subscribers.RemoveClient(handlerId, clientID)
+		log.Println("Error creating WebSocket at: ", err)
+		return
+	}
 
 	log.Println("Created internal subscriber handlerId: ", handlerId, " clientID: ", clientID)
 
 	//Do not let client wait for the first value a provide with data on connection opened
 	if welcomeValue != nil {
+		p.writeDeadline(conn)
 		writeData(conn, r, welcomeValue)
 	}
 
+	//reconnecting client of acknowledged subscription gets events it has not acknowledged,
+	//?ack=N acknowledges events received before connection was lost
+	replay, _ := t.subscribers.Replay(handlerId)
+	var lastSeq uint64
+
+	if replay != nil {
+		if ack, err := strconv.ParseUint(r.URL.Query().Get("ack"), 10, 64); err == nil {
+			replay.Ack(ack)
+		}
+
+		for _, s := range replay.Unacked() {
+			p.writeDeadline(conn)
+			if err = writeSequenced(conn, s); err != nil {
+				break
+			}
+			lastSeq = s.Seq
+		}
+	}
+
+	closed := make(chan struct{})
+	p.keepAlive(conn, closed)
+	go readClient(conn, replay, closed)
+
 	wsOpened := true
-	for event := range clientCh {
-		//FIXME: We need to handle 2 situations
-		// 1. websocket closed
-		// 2. no more data on channel
-		if err = writeData(conn, r, event); err != nil && wsOpened {
-			p.subscribers.RemoveClient(handlerId, clientID)
-			log.Println("Removed internal subscriber handlerId: ", handlerId, " clientID: ", clientID)
-			wsOpened = false
+	for {
+		select {
+		case event := <-clientCh:
+			p.writeDeadline(conn)
+			if s, ok := event.(*server.Sequenced); ok {
+				//already redelivered from replay buffer
+				if s.Seq <= lastSeq {
+					continue
+				}
+				err = writeSequenced(conn, s)
+			} else {
+				err = writeData(conn, r, event)
+			}
+
+			//FIXME: We need to handle 2 situations
+			// 1. websocket closed
+			// 2. no more data on channel
+			if err != nil && wsOpened {
+				t.subscribers.RemoveClient(handlerId, clientID)
+				log.Println("Removed internal subscriber handlerId: ", handlerId, " clientID: ", clientID)
+				wsOpened = false
+				//e.g. write deadline exceeded, closing connection stops reading client
+				conn.Close()
+			}
+		case <-closed:
+			t.subscribers.RemoveClient(handlerId, clientID)
+			log.Println("Client disconnected handlerId: ", handlerId, " clientID: ", clientID)
+			conn.Close()
+			return
+		case <-done:
+			log.Println("Subscription cancelled handlerId: ", handlerId, " clientID: ", clientID)
+			conn.Close()
+			return
+		case <-removed:
+			log.Println("Removed stalled client handlerId: ", handlerId, " clientID: ", clientID)
+			conn.Close()
+			return
 		}
 	}
 }
 
 // CREDIT TO Gorilla websocket library
 func writeData(wsc *websocket.Conn, r *http.Request, v interface{}) error {
-	w, err := wsc.NextWriter(websocket.TextMessage)
+	encoding, messageType := wsEncoding(r)
+
+	if pm, ok := v.(*preparedMessage); ok {
+		data, err := pm.Encoded(encoding)
+		if err != nil {
+			return err
+		}
+		return wsc.WriteMessage(messageType, data)
+	}
+
+	w, err := wsc.NextWriter(messageType)
 	if err != nil {
 		return err
 	}
 
-	encoder, err := Encoders.Get("JSON")
+	encoder, err := Encoders.Get(encoding)
 	if err != nil {
-		w.Write([]byte("Unsupported Encoding: JSON"))
+		w.Write([]byte(str.Concat("Unsupported Encoding: ", encoding)))
 		return err
 	}
 
@@ -339,38 +1132,130 @@ func writeData(wsc *websocket.Conn, r *http.Request, v interface{}) error {
 	return err2
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin:     func(r *http.Request) bool { return true },
+// wsEncoding is encoding of WebSocket messages selected by ?encoding= when connecting, e.g. senml+cbor.
+// Messages of encodings other than JSON are sent as binary frames.
+func wsEncoding(r *http.Request) (string, int) {
+	encoding := strings.ToUpper(r.URL.Query().Get("encoding"))
+
+	switch encoding {
+	case "", ENCODING_JSON:
+		return ENCODING_JSON, websocket.TextMessage
+	case ENCODING_SENML_JSON:
+		return encoding, websocket.TextMessage
+	}
+
+	return encoding, websocket.BinaryMessage
 }
 
-func (p *Http) eventSubscribeHandler(wotServer *server.WotServer, eventName string) func(http.ResponseWriter, *http.Request) {
+func (p *Http) eventSubscribeHandler(t *tenant, ctxPath string, wotServer *server.WotServer, eventName string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_SUBSCRIBE, wotServer, eventName) {
+			return
+		}
+
+		batch, err := readBatchPolicy(r)
+		if err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		replay, err := readReplayBuffer(r)
+		if err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		if !p.limits.subscriptions.acquire(ctxPath) {
+			sendCode(w, r, http.StatusTooManyRequests, "Too many subscriptions.")
+			return
+		}
+
 		subscriptionID, _ := sec.UUID4()
-		clients := async.NewFanOut()
+		//slow clients of high-rate event streams get the latest events, acknowledged ones replay the gaps
+		clients := async.NewBufferedFanOut(async.FANOUT_MAILBOX)
+
+		//acknowledged subscription records events even when no client is connected
+		publish := clients.Publish
+		if replay != nil {
+			publish = func(msg interface{}) {
+				clients.Publish(replay.Record(msg))
+			}
+		}
+
+		listener := p.eventHandler(subscriptionID, publish)
+		onCancel := func() {
+			wotServer.RemoveListener(eventName, listener)
+		}
+
+		//batched subscription publishes collected events instead of each event
+		if batch != nil {
+			b := newBatcher(batch, eventName, publish)
+			listener.CB = b.add
+			onCancel = func() {
+				wotServer.RemoveListener(eventName, listener)
+				b.stop()
+			}
+		}
+
+		//subscription slot is released when subscription is cancelled
+		cancel := onCancel
+		onCancel = func() {
+			cancel()
+			p.limits.subscriptions.release(ctxPath)
+		}
 
-		p.subscribers.CreateSubscription(subscriptionID, clients)
-		wotServer.AddListener(eventName, p.eventHandler(subscriptionID, clients))
+		t.subscribers.CreateSubscription(&server.Subscription{
+			ID:       subscriptionID,
+			Thing:    ctxPath,
+			Name:     eventName,
+			Clients:  clients,
+			OnCancel: onCancel,
+			Replay:   replay,
+		})
+		wotServer.AddListener(eventName, listener)
 
 		hrefs := links(websocketSubURL(r, subscriptionID))
 		sendOK(w, r, hrefs)
 	}
 }
 
-func (p *Http) eventWSClientHandler(wotServer *server.WotServer) func(w http.ResponseWriter, r *http.Request) {
+func (p *Http) eventWSClientHandler(t *tenant, ctxPath string, wotServer *server.WotServer, eventName string) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_SUBSCRIBE, wotServer, eventName) {
+			return
+		}
+
 		vars := mux.Vars(r)
 		subscriptionID := vars["subscriptionID"]
-		p.wsHandler(wotServer, subscriptionID, nil, w, r)
+		p.wsHandler(t, ctxPath, wotServer, subscriptionID, eventName, nil, w, r)
+	}
+}
+
+// eventUnsubscribeHandler cancels subscription and disconnects its clients, releasing its subscription slot
+func (p *Http) eventUnsubscribeHandler(t *tenant, ctxPath string, wotServer *server.WotServer, eventName string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_SUBSCRIBE, wotServer, eventName) {
+			return
+		}
+
+		subscriptionID := mux.Vars(r)["subscriptionID"]
+		sub, ok := t.subscribers.Subscription(subscriptionID)
+
+		if !ok || sub.Thing != ctxPath || sub.Name != eventName || !t.subscribers.CancelSubscription(subscriptionID) {
+			sendCode(w, r, http.StatusNotFound, "Subscription not found.")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func (p *Http) eventHandler(uuid string, clients *async.FanOut) *server.EventListener {
+func (p *Http) eventHandler(uuid string, publish func(interface{})) *server.EventListener {
 	el := &server.EventListener{
 		ID: uuid,
 		CB: func(event interface{}) {
-			clients.Publish(event)
+			//event is serialized once for all subscribed clients
+			publish(prepareEvent(event))
 		},
 	}
 
@@ -390,7 +1275,7 @@ func links(links ...Link) *Links {
 }
 
 func httpSubURL(r *http.Request, subresource string) Link {
-	uri := removeTTslash(r.URL.RequestURI())
+	uri := removeTTslash(r.URL.EscapedPath())
 
 	if len(uri) == 0 {
 		uri = str.Concat("/", removeTTslash(subresource))
@@ -398,7 +1283,12 @@ func httpSubURL(r *http.Request, subresource string) Link {
 		uri = str.Concat("/", uri, "/", removeTTslash(subresource))
 	}
 
-	linkString := str.Concat("http://", r.Host, uri)
+	scheme := "http://"
+	if secure(r) {
+		scheme = "https://"
+	}
+
+	linkString := str.Concat(scheme, r.Host, mountPrefix(r), uri)
 
 	return Link{
 		Rel:  "rest",
@@ -407,7 +1297,7 @@ func httpSubURL(r *http.Request, subresource string) Link {
 }
 
 func websocketSubURL(r *http.Request, subresource string) Link {
-	uri := removeTTslash(r.URL.RequestURI())
+	uri := removeTTslash(r.URL.EscapedPath())
 
 	if len(uri) == 0 {
 		uri = str.Concat("/ws/", removeTTslash(subresource))
@@ -415,7 +1305,12 @@ func websocketSubURL(r *http.Request, subresource string) Link {
 		uri = str.Concat("/", uri, "/ws/", removeTTslash(subresource))
 	}
 
-	linkString := str.Concat("ws://", r.Host, uri)
+	scheme := "ws://"
+	if secure(r) {
+		scheme = "wss://"
+	}
+
+	linkString := str.Concat(scheme, r.Host, mountPrefix(r), uri)
 
 	return Link{
 		Rel:  "websocket",
@@ -456,7 +1351,23 @@ func readBody(r *http.Request, t interface{}) error {
 		return err
 	}
 
-	err = encoder.Decode(r.Body, t)
+	return decodeBody(r, encoder, t)
+}
+
+// bodyDecoder returns JSON decoder for JSON bodies, e.g. base64 string written to binary property,
+// decoder of interaction otherwise
+func bodyDecoder(r *http.Request, decoder Encoder) Encoder {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mt == CONTENT_TYPE_JSON {
+		json, _ := Encoders.Get(ENCODING_JSON)
+		return json
+	}
+
+	return decoder
+}
+
+// decodeBody decodes request body by decoder, see PropertyDecoder. Body size is limited by handler, see limitBody.
+func decodeBody(r *http.Request, decoder Encoder, t interface{}) error {
+	err := decoder.Decode(r.Body, t)
 
 	if err != nil {
 		return err
@@ -480,6 +1391,33 @@ func sendOK(w http.ResponseWriter, r *http.Request, payload interface{}) {
 }
 
 func sendERR(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	if overloaded(payload) {
+		sendBusy(w, r)
+		return
+	}
+
+	if unavailable(payload) {
+		sendUnavailable(w, r)
+		return
+	}
+
+	if invalid(payload) {
+		sendCode(w, r, http.StatusUnprocessableEntity, payload)
+		return
+	}
+
+	if notFound(payload) {
+		if status, ok := payload.(server.Status); ok {
+			payload = &server.StatusError{Status: status}
+		}
+		sendCode(w, r, http.StatusNotFound, payload)
+		return
+	}
+
+	sendCode(w, r, http.StatusBadRequest, payload)
+}
+
+func sendCode(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
 	encoder, err := Encoders.Get("JSON")
 
 	if err != nil {
@@ -489,7 +1427,7 @@ func sendERR(w http.ResponseWriter, r *http.Request, payload interface{}) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(http.StatusBadRequest)
+	w.WriteHeader(code)
 
 	switch payload.(type) {
 	default:
@@ -502,11 +1440,56 @@ func sendERR(w http.ResponseWriter, r *http.Request, payload interface{}) {
 func sendPlainERR(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "text/plain")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(http.StatusBadRequest)
+
+	if tooLarge(err) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
 
 	w.Write([]byte(err.Error()))
 }
 
+// ----- Authorization
+
+func (p *Http) authenticated(w http.ResponseWriter, r *http.Request, t *tenant) bool {
+	if t.guard == nil {
+		return true
+	}
+
+	_, status := t.guard.Authenticate(r)
+
+	return p.checkAuthStatus(w, r, status)
+}
+
+func (p *Http) authorized(w http.ResponseWriter, r *http.Request, t *tenant, right auth.Right, wotServer *server.WotServer, interaction string) bool {
+	if !p.thingCertAllowed(w, r, wotServer) {
+		return false
+	}
+
+	if t.guard == nil {
+		return true
+	}
+
+	_, status := t.guard.Check(r, right, auth.Resource(wotServer.Name(), interaction))
+
+	return p.checkAuthStatus(w, r, status)
+}
+
+func (p *Http) checkAuthStatus(w http.ResponseWriter, r *http.Request, status auth.Status) bool {
+	switch status {
+	case auth.AUTH_UNAUTHENTICATED:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		sendCode(w, r, http.StatusUnauthorized, "Authentication required.")
+		return false
+	case auth.AUTH_FORBIDDEN:
+		sendCode(w, r, http.StatusForbidden, "Access to interaction denied.")
+		return false
+	}
+
+	return true
+}
+
 type Links struct {
 	Links []Link `json:"links"`
 }
@@ -522,16 +1505,6 @@ type route struct {
 	handlerFunc http.HandlerFunc
 }
 
-func (p *Http) addRoute(route *route) {
-	p.router.
-		Methods(route.method).
-		Path(route.pattern).
-		Name(route.pattern).
-		Handler(route.handlerFunc)
-}
-
-func (p *Http) addPreflight(route *route) {
-	p.router.
-		Methods("OPTIONS").
-		Handler(route.handlerFunc)
+func (p *Http) addRoute(rt *router, route *route) {
+	rt.handle(route.method, route.pattern, route.handlerFunc)
 }
@@ -0,0 +1,94 @@
+package frontend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+// AckMessage is sent by client of acknowledged subscription, it acknowledges all events up to Ack sequence
+type AckMessage struct {
+	Ack uint64 `json:"ack"`
+}
+
+// readReplayBuffer creates replay buffer for subscription requested with ?ack=true, optionally sized by ?buffer=N.
+// Events of such subscription carry "seq" number, unacknowledged events are redelivered when client reconnects.
+func readReplayBuffer(r *http.Request) (*server.ReplayBuffer, error) {
+	q := r.URL.Query()
+
+	if ack := q.Get("ack"); ack == "" || ack == "false" {
+		return nil, nil
+	}
+
+	size := server.REPLAY_BUFFER
+	if buffer := q.Get("buffer"); buffer != "" {
+		n, err := strconv.Atoi(buffer)
+		if err != nil || n <= 0 {
+			return nil, errors.New(str.Concat("Invalid replay buffer size: ", buffer))
+		}
+		size = n
+	}
+
+	return server.NewReplayBuffer(size), nil
+}
+
+// writeSequenced writes event with its sequence number added as "seq" member, so acknowledged
+// events keep the shape of plain events
+func writeSequenced(wsc *websocket.Conn, s *server.Sequenced) error {
+	var data []byte
+	var err error
+
+	if pm, ok := s.Message.(*preparedMessage); ok {
+		data, err = pm.Encoded(ENCODING_JSON)
+	} else {
+		data, err = prepare(s.Message).Encoded(ENCODING_JSON)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	data = bytes.TrimSpace(data)
+	seq := str.Concat(`{"seq":`, strconv.FormatUint(s.Seq, 10))
+
+	var buf bytes.Buffer
+	buf.WriteString(seq)
+
+	switch {
+	case bytes.Equal(data, []byte("{}")):
+		buf.WriteString("}")
+	case len(data) > 0 && data[0] == '{':
+		buf.WriteString(",")
+		buf.Write(data[1:])
+	default:
+		buf.WriteString(`,"data":`)
+		buf.Write(data)
+		buf.WriteString("}")
+	}
+
+	return wsc.WriteMessage(websocket.TextMessage, buf.Bytes())
+}
+
+// readClient reads client messages until connection is closed, acknowledgements are applied to replay buffer
+func readClient(wsc *websocket.Conn, replay *server.ReplayBuffer, closed chan<- struct{}) {
+	defer close(closed)
+
+	for {
+		_, data, err := wsc.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		//messages other than acknowledgement are ignored
+		var msg AckMessage
+		if json.Unmarshal(data, &msg) == nil && replay != nil && msg.Ack > 0 {
+			replay.Ack(msg.Ack)
+		}
+	}
+}
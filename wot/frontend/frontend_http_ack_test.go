@@ -0,0 +1,61 @@
+package frontend
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// nextSeq reads sequence number of next event delivered to acknowledged subscription
+func nextSeq(t *testing.T, conn *websocket.Conn) uint64 {
+	var e struct {
+		Seq uint64 `json:"seq"`
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&e); err != nil {
+		t.Fatal(err)
+	}
+
+	return e.Seq
+}
+
+func TestCaseAcknowledgedEvents(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	ws := subscribe(t, p, "/lamp/event/property-change?ack=true&buffer=8")
+
+	//events are recorded while no client is connected
+	s.EmitPropertyChange("on", true)
+	s.EmitPropertyChange("on", false)
+
+	conn, closer := dial(t, p, ws, nil)
+	Equals("First", t, uint64(1), nextSeq(t, conn))
+	Equals("Second", t, uint64(2), nextSeq(t, conn))
+	closer()
+
+	//reconnecting client acknowledges events received before connection was lost
+	conn, closer = dial(t, p, ws+"?ack=1", nil)
+	Equals("Unacknowledged", t, uint64(2), nextSeq(t, conn))
+
+	conn.WriteJSON(&AckMessage{Ack: 2})
+	s.EmitPropertyChange("on", true)
+	Equals("Live", t, uint64(3), nextSeq(t, conn))
+	closer()
+
+	conn, closer = dial(t, p, ws, nil)
+	defer closer()
+	Equals("Redelivered", t, uint64(3), nextSeq(t, conn))
+}
+
+func TestCaseInvalidReplayBuffer(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "POST", "/lamp/event/property-change?ack=true&buffer=0", "")
+	Equals("Invalid buffer", t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,159 @@
+package frontend
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/mux"
+)
+
+// BackendStates reports connection state of backends keyed by backend ID
+type BackendStates func() map[string]string
+
+// AdminFrontend is implemented by frontends exposing management API
+type AdminFrontend interface {
+	Frontend
+	Unbinder
+	EnableAdmin(guard *auth.Guard, backends BackendStates)
+}
+
+type admin struct {
+	guard    *auth.Guard
+	backends BackendStates
+}
+
+type ThingInfo struct {
+	CtxPath string            `json:"ctxPath"`
+	Tenant  string            `json:"tenant,omitempty"`
+	Name    string            `json:"name"`
+	Types   []string          `json:"types,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// EnableAdmin exposes management API under /admin. Admin resources are authorized as "admin/{resource}",
+// RIGHT_READ is required for inspection, RIGHT_WRITE for subscription termination, Thing unbind and
+// group changes.
+func (p *Http) EnableAdmin(guard *auth.Guard, backends BackendStates) {
+	if guard == nil {
+		panic("Admin API requires authentication guard.")
+	}
+
+	p.admin = &admin{
+		guard:    guard,
+		backends: backends,
+	}
+
+	p.adminRoute("GET", "/admin/things", auth.RIGHT_READ, "things", func(w http.ResponseWriter, r *http.Request) {
+		sendOK(w, r, p.thingInfos())
+	})
+
+	p.adminRoute("DELETE", "/admin/things/{ctxPath:.+}", auth.RIGHT_WRITE, "things", func(w http.ResponseWriter, r *http.Request) {
+		ctxPath := "/" + mux.Vars(r)["ctxPath"]
+
+		if !p.Unbind(ctxPath) {
+			sendCode(w, r, http.StatusNotFound, "Thing not bound.")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	p.adminRoute("GET", "/admin/subscriptions", auth.RIGHT_READ, "subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		subs := make([]*server.SubscriptionInfo, 0)
+		for _, t := range p.allTenants() {
+			subs = append(subs, t.subscribers.List()...)
+		}
+
+		sendOK(w, r, subs)
+	})
+
+	p.adminRoute("DELETE", "/admin/subscriptions/{subscriptionID}", auth.RIGHT_WRITE, "subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		subscriptionID := mux.Vars(r)["subscriptionID"]
+
+		for _, t := range p.allTenants() {
+			if t.subscribers.CancelSubscription(subscriptionID) {
+				log.Info("HTTP admin: subscription terminated -> ", subscriptionID)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		sendCode(w, r, http.StatusNotFound, "Subscription not found.")
+	})
+
+	p.adminRoute("GET", "/admin/tasks", auth.RIGHT_READ, "tasks", func(w http.ResponseWriter, r *http.Request) {
+		tasks := make([]*server.TaskInfo, 0)
+		for _, t := range p.allTenants() {
+			tasks = append(tasks, t.actionResults.Pending()...)
+		}
+
+		sendOK(w, r, tasks)
+	})
+
+	p.adminRoute("GET", "/admin/backends", auth.RIGHT_READ, "backends", func(w http.ResponseWriter, r *http.Request) {
+		states := make(map[string]string)
+		if p.admin.backends != nil {
+			states = p.admin.backends()
+		}
+
+		sendOK(w, r, states)
+	})
+
+	p.registerScheduleAdmin()
+	p.registerAuditAdmin()
+	p.registerGroupAdmin()
+	p.registerLabelAdmin()
+}
+
+func (p *Http) adminRoute(method, pattern string, right auth.Right, resource string, handler http.HandlerFunc) {
+	p.addRoute(p.router, &route{
+		method:  method,
+		pattern: pattern,
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			_, status := p.admin.guard.Check(r, right, auth.Resource("admin", resource))
+
+			if p.checkAuthStatus(w, r, status) {
+				limitBody(w, r, MAX_BODY)
+				handler(w, r)
+			}
+		},
+	})
+}
+
+func (p *Http) thingInfos() []*ThingInfo {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	infos := make([]*ThingInfo, 0, len(p.wotServers))
+	for _, t := range append(p.tenantList(), p.defaultTenant) {
+		for ctxPath, s := range t.things {
+			infos = append(infos, &ThingInfo{
+				CtxPath: ctxPath,
+				Tenant:  t.name,
+				Name:    s.Name(),
+				Types:   []string(s.GetDescription().AT_Type),
+				Labels:  p.thingLabels(ctxPath),
+			})
+		}
+	}
+
+	return infos
+}
+
+func (p *Http) allTenants() []*tenant {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	return append(p.tenantList(), p.defaultTenant)
+}
+
+func (p *Http) tenantList() []*tenant {
+	ts := make([]*tenant, 0, len(p.tenants))
+	for _, t := range p.tenants {
+		ts = append(ts, t)
+	}
+
+	return ts
+}
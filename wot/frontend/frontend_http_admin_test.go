@@ -0,0 +1,75 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/auth"
+)
+
+func adminHTTP() *Http {
+	rbac := auth.NewRBAC().
+		AddRole("viewer", auth.Allow(auth.RIGHT_READ, "admin/*")).
+		AddRole("admin", auth.Allow(auth.RIGHT_ALL, "admin/*"))
+	keys := auth.NewAPIKeys().
+		Add("k-viewer", "viewer", "viewer").
+		Add("k-admin", "admin", "admin")
+
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+	p.EnableAdmin(auth.NewGuard(rbac, keys), func() map[string]string {
+		return map[string]string{"mqtt-1": "connected"}
+	})
+
+	return p
+}
+
+func TestCaseAdminThings(t *testing.T) {
+	p := adminHTTP()
+
+	w := serve(p, "GET", "/admin/things", "")
+	Equals("Unauthenticated", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "GET", "/admin/things", "", "X-API-Key", "k-viewer")
+	var things []ThingInfo
+	json.Unmarshal(w.Body.Bytes(), &things)
+	Equals("Things", t, 1, len(things))
+	Equals("Thing", t, "/lamp", things[0].CtxPath)
+
+	w = serve(p, "DELETE", "/admin/things/lamp", "", "X-API-Key", "k-viewer")
+	Equals("Unbind by viewer", t, http.StatusForbidden, w.Code)
+
+	w = serve(p, "DELETE", "/admin/things/lamp", "", "X-API-Key", "k-admin")
+	Equals("Unbind", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Unbound Thing", t, http.StatusNotFound, w.Code)
+
+	w = serve(p, "DELETE", "/admin/things/lamp", "", "X-API-Key", "k-admin")
+	Equals("Unbind unbound", t, http.StatusNotFound, w.Code)
+
+	w = serve(p, "GET", "/admin/backends", "", "X-API-Key", "k-viewer")
+	Equals("Backends", t, `{"mqtt-1":"connected"}`, strings.TrimSpace(w.Body.String()))
+}
+
+func TestCaseAdminSubscriptions(t *testing.T) {
+	p := adminHTTP()
+
+	w := serve(p, "POST", "/lamp/event/property-change", "")
+	var ls Links
+	json.Unmarshal(w.Body.Bytes(), &ls)
+	Equals("Subscribed", t, 1, len(ls.Links))
+	subscriptionID := path.Base(ls.Links[0].Href)
+
+	w = serve(p, "GET", "/admin/subscriptions", "", "X-API-Key", "k-viewer")
+	Equals("Listed", t, true, strings.Contains(w.Body.String(), subscriptionID))
+
+	w = serve(p, "DELETE", "/admin/subscriptions/"+subscriptionID, "", "X-API-Key", "k-admin")
+	Equals("Terminated", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "DELETE", "/admin/subscriptions/"+subscriptionID, "", "X-API-Key", "k-admin")
+	Equals("Terminated twice", t, http.StatusNotFound, w.Code)
+}
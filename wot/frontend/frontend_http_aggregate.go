@@ -0,0 +1,112 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+)
+
+// AGGREGATE_TIMEOUT limits read of property from one Thing of aggregated read
+const AGGREGATE_TIMEOUT = 5 * time.Second
+
+// ThingValue is property value of one Thing in aggregated read, Error is set when Thing failed
+type ThingValue struct {
+	Thing string      `json:"thing"`
+	Value interface{} `json:"value,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// registerAggregation exposes {base}/things/properties?name={property} reading property from all tenant
+// Things declaring it concurrently. Failure or denial of one Thing is reported in its entry, "timeout"
+// (e.g. "2s") limits reads of slow Things.
+func (p *Http) registerAggregation(t *tenant, base string) {
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: str.Concat(base, "/things/properties"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			name := r.URL.Query().Get("name")
+			if name == "" {
+				sendPlainERR(w, errors.New("Property name required"))
+				return
+			}
+
+			timeout := AGGREGATE_TIMEOUT
+			if param := r.URL.Query().Get("timeout"); param != "" {
+				d, err := time.ParseDuration(param)
+				if err != nil || d <= 0 {
+					sendPlainERR(w, errors.New(str.Concat("Invalid timeout: ", param)))
+					return
+				}
+				timeout = d
+			}
+
+			sendOK(w, r, p.aggregate(r, t, base, name, timeout))
+		},
+	})
+}
+
+func (p *Http) aggregate(r *http.Request, t *tenant, base, name string, timeout time.Duration) []*ThingValue {
+	p.l.RLock()
+	things := make(map[string]*server.WotServer)
+	for ctxPath, s := range t.things {
+		if declaresProperty(s, name) {
+			things[ctxPath] = s
+		}
+	}
+	p.l.RUnlock()
+
+	values := make([]*ThingValue, 0, len(things))
+	l := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	for ctxPath, s := range things {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tv := &ThingValue{Thing: strings.TrimPrefix(ctxPath, base)}
+			if err := p.permitted(r, t, auth.RIGHT_READ, s, name); err != nil {
+				tv.Error = err.Error()
+			} else if value, err := requested(r, s).GetProperty(name).WaitTimeout(timeout); err != nil {
+				tv.Error = err.Error()
+			} else if value, err = callResult(value); err != nil {
+				tv.Error = err.Error()
+			} else {
+				tv.Value = value
+			}
+
+			l.Lock()
+			values = append(values, tv)
+			l.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Thing < values[j].Thing
+	})
+
+	return values
+}
+
+func declaresProperty(s *server.WotServer, name string) bool {
+	for _, prop := range s.GetDescription().Properties {
+		if prop.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,38 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCaseAggregatedRead(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp-1", lamp())
+	p.Bind("/thermostat", thermostat())
+
+	slow := lamp()
+	release := make(chan bool)
+	defer close(release)
+	slow.OnGetProperty("power", func() interface{} {
+		<-release
+		return 0.0
+	})
+	p.Bind("/lamp-2", slow)
+
+	w := serve(p, "GET", "/things/properties?name=power&timeout=50ms", "")
+	var values []*ThingValue
+	json.Unmarshal(w.Body.Bytes(), &values)
+
+	Equals("Things declaring property", t, 2, len(values))
+	Equals("Thing", t, "/lamp-1", values[0].Thing)
+	Equals("Value", t, 7.5, values[0].Value)
+	Equals("Slow Thing", t, "/lamp-2", values[1].Thing)
+	Equals("Slow Thing failed", t, true, values[1].Error != "" && values[1].Value == nil)
+
+	w = serve(p, "GET", "/things/properties", "")
+	Equals("Without name", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "GET", "/things/properties?name=power&timeout=soon", "")
+	Equals("Invalid timeout", t, http.StatusBadRequest, w.Code)
+}
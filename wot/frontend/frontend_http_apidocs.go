@@ -0,0 +1,37 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/conas/tno2/wot/gen"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// registerAPIDocs exposes {ctx}/openapi.json describing Thing routes and {ctx}/asyncapi.json describing
+// its event channels, both with security schemes of Thing tenant
+func (p *Http) registerAPIDocs(rt *router, t *tenant, ctxPath string, s *server.WotServer) {
+	docs := map[string]func(*model.ThingDescription, []string) map[string]interface{}{
+		"openapi.json":  gen.OpenAPI,
+		"asyncapi.json": gen.AsyncAPI,
+	}
+
+	for name, generate := range docs {
+		p.addRoute(rt, &route{
+			method:  "GET",
+			pattern: contextPath(ctxPath, name),
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				if !p.authenticated(w, r, t) {
+					return
+				}
+
+				var schemes []string
+				if t.guard != nil {
+					schemes = t.guard.Schemes()
+				}
+
+				sendTagged(w, r, generate(s.Description(), schemes))
+			},
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCaseOpenAPI(t *testing.T) {
+	p := testHTTP(nil)
+	p.AddTenant("acme", tenantGuard("k-acme"))
+	p.BindTenant("acme", "/lamp", lamp())
+
+	w := serve(p, "GET", "/t/acme/lamp/openapi.json", "")
+	Equals("Unauthenticated", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "GET", "/t/acme/lamp/openapi.json", "", "X-API-Key", "k-acme")
+	var doc struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	Equals("Document", t, nil, json.Unmarshal(w.Body.Bytes(), &doc))
+	Equals("Version", t, true, doc.OpenAPI != "")
+	Equals("Writable property", t, true, doc.Paths["/property/on"]["put"] != nil)
+	Equals("Read-only property", t, true, doc.Paths["/property/power"]["put"] == nil)
+	Equals("Action", t, true, doc.Paths["/action/toggle"]["post"] != nil)
+	Equals("Security schemes", t, 1, len(doc.Components.SecuritySchemes))
+}
+
+func TestCaseAsyncAPI(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/asyncapi.json", "")
+	var doc struct {
+		AsyncAPI string                            `json:"asyncapi"`
+		Servers  map[string]map[string]interface{} `json:"servers"`
+		Channels map[string]map[string]interface{} `json:"channels"`
+	}
+	Equals("Document", t, nil, json.Unmarshal(w.Body.Bytes(), &doc))
+	Equals("Version", t, true, doc.AsyncAPI != "")
+	Equals("WebSocket server", t, "ws://localhost:8080/lamp", doc.Servers["ws0"]["url"])
+	Equals("Event channel", t, true, doc.Channels["/event/property-change/ws/{subscriptionID}"]["subscribe"] != nil)
+}
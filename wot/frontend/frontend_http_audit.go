@@ -0,0 +1,175 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/audit"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+)
+
+// AuditFrontend is implemented by frontends recording state changing interactions to audit log
+type AuditFrontend interface {
+	EnableAudit(l *audit.Log)
+}
+
+// EnableAudit records property writes and action invocations with principal performing them and their
+// results. Admin API serves the log at /admin/audit and verifies its chain at /admin/audit/verify.
+func (p *Http) EnableAudit(l *audit.Log) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.audit = l
+}
+
+func (p *Http) auditLog() *audit.Log {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	return p.audit
+}
+
+// auditEntry starts entry of interaction requested by r, nil when audit is disabled. Entry is recorded
+// by audited once interaction result is known.
+func (p *Http) auditEntry(r *http.Request, t *tenant, wotServer *server.WotServer, kind, name string, input interface{}) *audit.Entry {
+	if p.auditLog() == nil {
+		return nil
+	}
+
+	e := &audit.Entry{
+		Time:      time.Now(),
+		Remote:    r.RemoteAddr,
+		Tenant:    t.name,
+		Thing:     wotServer.Name(),
+		Kind:      kind,
+		Name:      name,
+		Input:     input,
+		RequestID: requestID(r),
+	}
+
+	if t.guard != nil {
+		if principal, status := t.guard.Authenticate(r); status == auth.AUTH_OK && principal != nil {
+			e.Principal = principal.ID
+		}
+	}
+
+	return e
+}
+
+func (p *Http) audited(e *audit.Entry, result interface{}) {
+	l := p.auditLog()
+	if e == nil || l == nil {
+		return
+	}
+
+	switch v := result.(type) {
+	case server.Status:
+		if v != server.WOT_OK {
+			e.Error = (&server.StatusError{Status: v}).Error()
+		}
+	case error:
+		e.Error = v.Error()
+	default:
+		e.Result = result
+	}
+
+	if err := l.Record(e); err != nil {
+		log.Error("HTTP: audit entry of ", e.Thing, "/", e.Name, " not recorded -> ", err)
+	}
+}
+
+// auditedTask records action result once invocation completes, result is taken from task slot
+func (p *Http) auditedTask(e *audit.Entry, invocation *async.Promise, slot *atomic.Value) {
+	result := invocation.Get()
+
+	if status, ok := slot.Load().(*server.TaskStatus); ok && result == server.WOT_OK {
+		switch status.Status {
+		case server.TASK_DONE:
+			result = status.Data
+		case server.TASK_FAILED:
+			result = errors.New(fmt.Sprint(status.Data))
+		}
+	}
+
+	p.audited(e, result)
+}
+
+func (p *Http) registerAuditAdmin() {
+	p.adminRoute("GET", "/admin/audit", auth.RIGHT_READ, "audit", func(w http.ResponseWriter, r *http.Request) {
+		l := p.auditLog()
+		if l == nil {
+			sendCode(w, r, http.StatusNotFound, "Audit not enabled.")
+			return
+		}
+
+		q, err := auditQuery(r)
+		if err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		entries, err := l.Query(q)
+		if err != nil {
+			sendERR(w, r, err)
+			return
+		}
+
+		sendOK(w, r, entries)
+	})
+
+	p.adminRoute("GET", "/admin/audit/verify", auth.RIGHT_READ, "audit", func(w http.ResponseWriter, r *http.Request) {
+		l := p.auditLog()
+		if l == nil {
+			sendCode(w, r, http.StatusNotFound, "Audit not enabled.")
+			return
+		}
+
+		verified, err := l.Verify()
+		result := map[string]interface{}{
+			"valid":    err == nil,
+			"verified": verified,
+		}
+		if err != nil {
+			result["error"] = err.Error()
+		}
+
+		sendOK(w, r, result)
+	})
+}
+
+// auditQuery reads query parameters thing, name, kind, principal, from and to (RFC3339) and limit
+func auditQuery(r *http.Request) (*audit.Query, error) {
+	params := r.URL.Query()
+	q := &audit.Query{
+		Thing:     params.Get("thing"),
+		Name:      params.Get("name"),
+		Kind:      params.Get("kind"),
+		Principal: params.Get("principal"),
+	}
+
+	var err error
+	if from := params.Get("from"); from != "" {
+		if q.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, err
+		}
+	}
+	if to := params.Get("to"); to != "" {
+		if q.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, err
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
@@ -0,0 +1,60 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/audit"
+	"github.com/conas/tno2/wot/server"
+)
+
+// auditedEntries queries audit log until it has n entries matching query
+func auditedEntries(t *testing.T, h http.Handler, query string, n int) []*audit.Entry {
+	var entries []*audit.Entry
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		entries = nil
+		json.Unmarshal(serve(h, "GET", "/admin/audit"+query, "", "X-API-Key", "k-viewer").Body.Bytes(), &entries)
+		if len(entries) >= n {
+			break
+		}
+	}
+
+	return entries
+}
+
+func TestCaseAudit(t *testing.T) {
+	p := adminHTTP()
+
+	w := serve(p, "GET", "/admin/audit", "", "X-API-Key", "k-viewer")
+	Equals("Not enabled", t, http.StatusNotFound, w.Code)
+
+	l, _ := audit.NewLog(audit.NewMemoryStore())
+	p.EnableAudit(l)
+
+	serve(p, "PUT", "/lamp/property/on", "true")
+	serve(p, "POST", "/lamp/action/toggle", "null")
+
+	entries := auditedEntries(t, p, "", 2)
+	Equals("Entries", t, 2, len(entries))
+
+	writes := auditedEntries(t, p, "?kind="+url.QueryEscape(server.INTERACTION_WRITE), 1)
+	Equals("Writes", t, 1, len(writes))
+	Equals("Written property", t, "on", writes[0].Name)
+	Equals("Written value", t, true, writes[0].Input)
+
+	invocations := auditedEntries(t, p, "?name=toggle", 1)
+	Equals("Invocations", t, 1, len(invocations))
+	Equals("Action result", t, false, invocations[0].Result)
+
+	var verified map[string]interface{}
+	json.Unmarshal(serve(p, "GET", "/admin/audit/verify", "", "X-API-Key", "k-viewer").Body.Bytes(), &verified)
+	Equals("Valid", t, true, verified["valid"])
+	Equals("Verified", t, 2.0, verified["verified"])
+
+	w = serve(p, "GET", "/admin/audit?limit=x", "", "X-API-Key", "k-viewer")
+	Equals("Invalid query", t, http.StatusBadRequest, w.Code)
+}
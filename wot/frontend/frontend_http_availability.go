@@ -0,0 +1,90 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/server"
+)
+
+type thingKey struct{}
+
+// withThing attaches Thing served by request to its context, so refused interactions of offline Thing
+// can be answered with Retry-After of the Thing
+func (p *Http) withThing(r *http.Request, ctxPath string) *http.Request {
+	p.l.RLock()
+	s, ok := p.wotServers[ctxPath]
+	p.l.RUnlock()
+
+	if !ok {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), thingKey{}, s))
+}
+
+// unavailable reports whether WotServer call result is status or error of offline Thing
+func unavailable(result interface{}) bool {
+	switch v := result.(type) {
+	case server.Status:
+		return v == server.WOT_THING_OFFLINE
+	case error:
+		return errors.Is(v, server.ErrOffline)
+	}
+
+	return false
+}
+
+// offline reports invocation refused by offline Thing, such invocation is resolved immediately
+func offline(invocation *async.Promise) bool {
+	select {
+	case <-invocation.Done():
+		return invocation.Get() == server.WOT_THING_OFFLINE
+	default:
+		return false
+	}
+}
+
+// overloaded reports WotServer call result refused by bulkhead of Thing, see server.Bulkhead
+func overloaded(result interface{}) bool {
+	switch v := result.(type) {
+	case server.Status:
+		return v == server.WOT_THING_BUSY
+	case error:
+		return errors.Is(v, server.ErrBusy)
+	}
+
+	return false
+}
+
+// shed reports invocation refused by bulkhead of Thing, such invocation is resolved immediately
+func shed(invocation *async.Promise) bool {
+	select {
+	case <-invocation.Done():
+		return overloaded(invocation.Get())
+	default:
+		return false
+	}
+}
+
+// sendBusy answers 503 to interaction refused by bulkhead of Thing, it may be retried as soon as
+// interactions in flight settle
+func sendBusy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	sendCode(w, r, http.StatusServiceUnavailable, "Thing busy")
+}
+
+// sendUnavailable answers 503, Retry-After is heartbeat interval of Thing served by request
+func sendUnavailable(w http.ResponseWriter, r *http.Request) {
+	if s, ok := r.Context().Value(thingKey{}).(*server.WotServer); ok {
+		if retry := s.Availability().RetryAfter; retry > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+		}
+	}
+
+	sendCode(w, r, http.StatusServiceUnavailable, "Thing offline")
+}
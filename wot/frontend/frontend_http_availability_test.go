@@ -0,0 +1,108 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// awaitOffline waits until Thing tracking availability misses heartbeats and is offline
+func awaitOffline(t *testing.T, s *server.WotServer) {
+	for deadline := time.Now().Add(5 * time.Second); s.Availability().State != server.AVAILABILITY_OFFLINE; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Thing not offline")
+		}
+	}
+}
+
+func TestCaseOfflineThing(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp().TrackAvailability(20 * time.Millisecond)
+	p.Bind("/lamp", s)
+	awaitOffline(t, s)
+
+	w := serve(p, "GET", "/lamp/property/power", "")
+	Equals("Read refused", t, http.StatusServiceUnavailable, w.Code)
+	Equals("Retry after heartbeat interval", t, "1", w.Header().Get("Retry-After"))
+
+	w = serve(p, "POST", "/lamp/action/toggle", "null")
+	Equals("Invocation refused", t, http.StatusServiceUnavailable, w.Code)
+
+	w = serve(p, "GET", "/lamp/availability", "")
+	a := &server.Availability{}
+	json.Unmarshal(w.Body.Bytes(), a)
+	Equals("Availability readable", t, http.StatusOK, w.Code)
+	Equals("State", t, server.AVAILABILITY_OFFLINE, a.State)
+
+	s.Heartbeat()
+
+	w = serve(p, "GET", "/lamp/property/power", "")
+	Equals("Online again", t, http.StatusOK, w.Code)
+}
+
+func TestCaseQueuedWrite(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"graphql": true})
+	s := lamp().TrackAvailability(20 * time.Millisecond)
+	Equals("Queue", t, nil, s.QueueWrites("", server.CONFLICT_LAST_WRITE_WINS))
+	p.Bind("/lamp", s)
+	awaitOffline(t, s)
+
+	w := serve(p, "PUT", "/lamp/property/on", "true")
+	Equals("Write queued", t, http.StatusAccepted, w.Code)
+
+	w = serve(p, "POST", "/graphql", `{"query": "mutation { lamp { set_on(value: false) } }"}`)
+	Equals("Mutation queued", t, `{"data":{"lamp":{"set_on":true}}}`, strings.TrimSpace(w.Body.String()))
+	Equals("Queued writes", t, 2, len(s.QueuedWrites()))
+
+	s.Heartbeat()
+
+	for deadline := time.Now().Add(5 * time.Second); len(s.QueuedWrites()) > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("writes not flushed")
+		}
+	}
+
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Flushed in order", t, "false", strings.TrimSpace(w.Body.String()))
+}
+
+func TestCaseBulkhead(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:       "gate",
+		Properties: []model.Property{{Name: "state", ValueType: model.ValueType{Type: "string"}, Hrefs: []string{"property/state"}}},
+		Actions:    []model.Action{{Name: "open", Hrefs: []string{"action/open"}}},
+	})
+	release := make(chan bool)
+	s.OnGetProperty("state", func() interface{} { return "closed" })
+	s.OnInvokeAction("open", func(interface{}, async.ProgressHandler) interface{} {
+		<-release
+		return true
+	})
+	p.Bind("/gate", s.Bulkhead(1))
+
+	task := taskPath(t, serve(p, "POST", "/gate/action/open", "null"))
+
+	w := serve(p, "POST", "/gate/action/open", "null")
+	Equals("Invocation refused", t, http.StatusServiceUnavailable, w.Code)
+	Equals("Retry soon", t, "1", w.Header().Get("Retry-After"))
+
+	w = serve(p, "GET", "/gate/property/state", "")
+	Equals("Read refused", t, http.StatusServiceUnavailable, w.Code)
+
+	release <- true
+	finished(t, p, task)
+
+	//slot is returned asynchronously once invocation settles
+	for deadline := time.Now().Add(5 * time.Second); w.Code != http.StatusOK && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		w = serve(p, "GET", "/gate/property/state", "")
+	}
+	Equals("Admitted again", t, http.StatusOK, w.Code)
+}
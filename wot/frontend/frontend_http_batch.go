@@ -0,0 +1,198 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	AGGREGATE_BATCH = "batch"
+	AGGREGATE_COUNT = "count"
+	AGGREGATE_AVG   = "avg"
+)
+
+// batchPolicy is requested by subscriber with ?window=<ms>&max=<events>&aggregate=batch|count|avg.
+// Events are delivered once per window, or when max events are collected, as one message.
+type batchPolicy struct {
+	window    time.Duration
+	max       int
+	aggregate string
+}
+
+// Aggregate is data of aggregated event, Avg, Min and Max are computed over numeric event data
+type Aggregate struct {
+	Count int      `json:"count"`
+	Avg   *float64 `json:"avg,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// readBatchPolicy returns nil policy when subscriber requested events one by one
+func readBatchPolicy(r *http.Request) (*batchPolicy, error) {
+	q := r.URL.Query()
+
+	if q.Get("window") == "" && q.Get("max") == "" && q.Get("aggregate") == "" {
+		return nil, nil
+	}
+
+	bp := &batchPolicy{aggregate: AGGREGATE_BATCH}
+
+	if window := q.Get("window"); window != "" {
+		ms, err := strconv.Atoi(window)
+		if err != nil || ms <= 0 {
+			return nil, errors.New(str.Concat("Invalid batch window: ", window))
+		}
+		bp.window = time.Duration(ms) * time.Millisecond
+	}
+
+	if max := q.Get("max"); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil || n <= 0 {
+			return nil, errors.New(str.Concat("Invalid batch max: ", max))
+		}
+		bp.max = n
+	}
+
+	switch aggregate := q.Get("aggregate"); aggregate {
+	case "":
+	case AGGREGATE_BATCH, AGGREGATE_COUNT, AGGREGATE_AVG:
+		bp.aggregate = aggregate
+	default:
+		return nil, errors.New(str.Concat("Unknown aggregation: ", aggregate))
+	}
+
+	if bp.window == 0 && bp.max == 0 {
+		return nil, errors.New("Batch window or max required")
+	}
+
+	return bp, nil
+}
+
+// batcher collects events of one subscription and publishes them to clients as one message
+type batcher struct {
+	policy    *batchPolicy
+	eventName string
+	l         *sync.Mutex
+	events    []*server.Event
+	publish   func(interface{})
+	quit      chan struct{}
+	once      *sync.Once
+}
+
+func newBatcher(policy *batchPolicy, eventName string, publish func(interface{})) *batcher {
+	b := &batcher{
+		policy:    policy,
+		eventName: eventName,
+		l:         &sync.Mutex{},
+		events:    make([]*server.Event, 0),
+		publish:   publish,
+		quit:      make(chan struct{}),
+		once:      &sync.Once{},
+	}
+
+	if policy.window > 0 {
+		go b.run()
+	}
+
+	return b
+}
+
+func (b *batcher) add(event interface{}) {
+	e, ok := event.(*server.Event)
+	if !ok {
+		return
+	}
+
+	b.l.Lock()
+	b.events = append(b.events, e)
+	full := b.policy.max > 0 && len(b.events) >= b.policy.max
+	b.l.Unlock()
+
+	if full {
+		b.flush()
+	}
+}
+
+func (b *batcher) run() {
+	ticker := time.NewTicker(b.policy.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+func (b *batcher) stop() {
+	b.once.Do(func() {
+		close(b.quit)
+	})
+}
+
+// flush publishes collected events, empty windows are not published
+func (b *batcher) flush() {
+	b.l.Lock()
+	events := b.events
+	b.events = make([]*server.Event, 0, len(events))
+	b.l.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+
+	var data interface{} = events
+	if b.policy.aggregate != AGGREGATE_BATCH {
+		data = aggregate(events, b.policy.aggregate == AGGREGATE_AVG)
+	}
+
+	b.publish(prepare(&server.Event{
+		Event:     b.eventName,
+		Timestamp: time.Now(),
+		Data:      data,
+	}))
+}
+
+func aggregate(events []*server.Event, stats bool) *Aggregate {
+	a := &Aggregate{Count: len(events)}
+
+	if !stats {
+		return a
+	}
+
+	var sum, min, max float64
+	n := 0
+
+	for _, e := range events {
+		v, ok := model.Number(e.Data)
+		if !ok {
+			continue
+		}
+
+		if n == 0 || v < min {
+			min = v
+		}
+		if n == 0 || v > max {
+			max = v
+		}
+		sum += v
+		n++
+	}
+
+	if n > 0 {
+		avg := sum / float64(n)
+		a.Avg, a.Min, a.Max = &avg, &min, &max
+	}
+
+	return a
+}
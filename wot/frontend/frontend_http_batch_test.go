@@ -0,0 +1,68 @@
+package frontend
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCaseBatchedEvents(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	conn, closer := dial(t, p, subscribe(t, p, "/lamp/event/property-change?max=2"), nil)
+	defer closer()
+
+	s.EmitEvent("property-change", 1)
+	s.EmitEvent("property-change", 2)
+
+	var batch struct {
+		Event string        `json:"event"`
+		Data  []interface{} `json:"data"`
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	Equals("Read", t, nil, conn.ReadJSON(&batch))
+	Equals("Batch event", t, "property-change", batch.Event)
+	Equals("Batch size", t, 2, len(batch.Data))
+}
+
+func TestCaseAggregatedEvents(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	conn, closer := dial(t, p, subscribe(t, p, "/lamp/event/property-change?window=50&aggregate=avg"), nil)
+	defer closer()
+
+	for _, v := range []float64{1, 2, 6} {
+		s.EmitEvent("property-change", v)
+	}
+
+	//window may close between emitted events, windows are summed until all events are counted
+	count, sum := 0, 0.0
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for count < 3 {
+		var aggregated struct {
+			Data Aggregate `json:"data"`
+		}
+		if err := conn.ReadJSON(&aggregated); err != nil {
+			t.Fatal(err)
+		}
+		count += aggregated.Data.Count
+		sum += *aggregated.Data.Avg * float64(aggregated.Data.Count)
+	}
+
+	Equals("Count", t, 3, count)
+	Equals("Avg", t, 3.0, sum/float64(count))
+}
+
+func TestCaseInvalidBatchPolicy(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	for _, query := range []string{"window=0", "max=x", "aggregate=median&max=2", "aggregate=count"} {
+		w := serve(p, "POST", "/lamp/event/property-change?"+query, "")
+		Equals(query, t, http.StatusBadRequest, w.Code)
+	}
+}
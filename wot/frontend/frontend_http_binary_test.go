@@ -0,0 +1,73 @@
+package frontend
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+var jpeg = []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F'}
+
+func TestCaseBinaryProperty(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "camera",
+		Properties: []model.Property{{
+			Name:        "snapshot",
+			ValueType:   model.ValueType{Type: "string", ContentEncoding: model.CONTENT_ENCODING_BASE64},
+			Writable:    true,
+			Hrefs:       []string{"property/snapshot"},
+			ContentType: "image/jpeg",
+		}},
+	})
+	snapshot := interface{}(jpeg)
+	s.OnGetProperty("snapshot", func() interface{} { return snapshot })
+	s.OnUpdateProperty("snapshot", func(v interface{}) { snapshot = v })
+	p.Bind("/camera", s)
+
+	w := serve(p, "GET", "/camera/property/snapshot", "")
+	Equals("Media type", t, "image/jpeg", w.Header().Get("Content-Type"))
+	Equals("Raw bytes", t, string(jpeg), w.Body.String())
+
+	w = serve(p, "PUT", "/camera/property/snapshot", "\xff\xd8raw", "Content-Type", "image/jpeg")
+	Equals("Raw written", t, true, w.Code/100 == 2)
+	Equals("Raw value", t, "\xff\xd8raw", serve(p, "GET", "/camera/property/snapshot", "").Body.String())
+
+	encoded := `"` + base64.StdEncoding.EncodeToString(jpeg) + `"`
+	w = serve(p, "PUT", "/camera/property/snapshot", encoded, "Content-Type", CONTENT_TYPE_JSON)
+	Equals("Base64 written", t, true, w.Code/100 == 2)
+	Equals("Served raw", t, string(jpeg), serve(p, "GET", "/camera/property/snapshot", "").Body.String())
+}
+
+func TestCaseBinaryPropertyConditionalWrite(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "camera",
+		Properties: []model.Property{{
+			Name:        "snapshot",
+			ValueType:   model.ValueType{Type: "string", ContentEncoding: model.CONTENT_ENCODING_BASE64},
+			Writable:    true,
+			Hrefs:       []string{"property/snapshot"},
+			ContentType: "image/jpeg",
+		}},
+	})
+	snapshot := interface{}(jpeg)
+	s.OnGetProperty("snapshot", func() interface{} { return snapshot })
+	s.OnUpdateProperty("snapshot", func(v interface{}) { snapshot = v })
+	p.Bind("/camera", s)
+
+	tag := serve(p, "GET", "/camera/property/snapshot", "").Header().Get("ETag")
+
+	w := serve(p, "PUT", "/camera/property/snapshot", "\xff\xd8new", "Content-Type", "image/jpeg", "If-Match", tag)
+	Equals("Matching write", t, true, w.Code/100 == 2)
+	Equals("Written", t, "\xff\xd8new", serve(p, "GET", "/camera/property/snapshot", "").Body.String())
+
+	w = serve(p, "PUT", "/camera/property/snapshot", "\xff\xd8old", "Content-Type", "image/jpeg", "If-Match", tag)
+	Equals("Stale write", t, http.StatusPreconditionFailed, w.Code)
+	Equals("Kept", t, "\xff\xd8new", serve(p, "GET", "/camera/property/snapshot", "").Body.String())
+}
@@ -0,0 +1,184 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	// CALLBACK_ATTEMPTS is number of deliveries of callback before it is dropped
+	CALLBACK_ATTEMPTS = 3
+	CALLBACK_TIMEOUT  = 10 * time.Second
+	// CALLBACK_KEY_ID identifies generated key signing callbacks, when none is configured
+	CALLBACK_KEY_ID = "callback"
+)
+
+var errCallbackScheduled = errors.New("Callback of scheduled action is not supported")
+
+// Callback is payload posted to callback URL of action invocation when its task finishes. Body is signed
+// by JWS with detached payload in HEADER_JWS_SIGNATURE header, public key is published at /callbacks/key.
+type Callback struct {
+	Task      string             `json:"task"`
+	Thing     string             `json:"thing"`
+	Action    string             `json:"action"`
+	RequestID string             `json:"requestId,omitempty"`
+	Status    *server.TaskStatus `json:"status"`
+}
+
+// CallbackKey is public key verifying signatures of callbacks
+type CallbackKey struct {
+	Kid string `json:"kid"`
+	Key string `json:"key"`
+}
+
+type callbacks struct {
+	key    crypto.Signer
+	kid    string
+	hosts  map[string]bool
+	client *http.Client
+}
+
+// configureCallbacks loads key signing callbacks from cfg "callbackKey" PEM file, key id is file name
+// without extension. Key is generated on start when not configured. Callback URLs may be restricted to
+// hosts listed in "callbackHosts".
+func (p *Http) configureCallbacks(cfg map[string]interface{}) {
+	p.callbacks = &callbacks{
+		kid:    CALLBACK_KEY_ID,
+		client: &http.Client{Timeout: CALLBACK_TIMEOUT},
+	}
+
+	if path, ok := cfg["callbackKey"].(string); ok {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			panic(str.Concat("Invalid callbackKey: ", err.Error()))
+		}
+
+		if p.callbacks.key, err = jose.ParsePrivateKey(data); err != nil {
+			panic(str.Concat("Invalid callbackKey: ", err.Error()))
+		}
+		p.callbacks.kid = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	} else {
+		p.callbacks.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+
+	if hosts := stringList("callbackHosts", cfg["callbackHosts"]); hosts != nil {
+		p.callbacks.hosts = make(map[string]bool)
+		for _, host := range hosts {
+			p.callbacks.hosts[host] = true
+		}
+	}
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: "/callbacks/key",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			der, err := x509.MarshalPKIXPublicKey(p.callbacks.key.Public())
+			if err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+
+			sendOK(w, r, &CallbackKey{
+				Kid: p.callbacks.kid,
+				Key: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			})
+		},
+	})
+}
+
+// callbackURL parses ?callback=<URL> of action invocation, empty URL is returned when callback is not requested
+func (p *Http) callbackURL(r *http.Request) (string, error) {
+	param := r.URL.Query().Get("callback")
+	if param == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(param)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New(str.Concat("Invalid callback: ", param))
+	}
+
+	if p.callbacks.hosts != nil && !p.callbacks.hosts[u.Hostname()] {
+		return "", errors.New(str.Concat("Callback host not allowed: ", u.Hostname()))
+	}
+
+	return u.String(), nil
+}
+
+// callback posts final status of task to callbackURL once invocation finishes. Failed deliveries are
+// retried with growing delay and dropped after CALLBACK_ATTEMPTS.
+func (p *Http) callback(callbackURL string, cb *Callback, invocation *async.Promise, slot *atomic.Value) {
+	invocation.Get()
+	cb.Status, _ = slot.Load().(*server.TaskStatus)
+
+	body, err := json.Marshal(cb)
+	if err != nil {
+		log.Error("HTTP: callback of task ", cb.Task, " not encoded -> ", err)
+		return
+	}
+
+	signature, err := jose.SignDetached(p.callbacks.key, p.callbacks.kid, body)
+	if err != nil {
+		log.Error("HTTP: callback of task ", cb.Task, " not signed -> ", err)
+		return
+	}
+
+	for attempt := 1; attempt <= CALLBACK_ATTEMPTS; attempt++ {
+		if err = p.callbacks.deliver(callbackURL, cb.RequestID, body, signature); err == nil {
+			return
+		}
+
+		log.Warn("HTTP: callback of task ", cb.Task, " to ", callbackURL, " failed, attempt ", attempt, " -> ", err)
+		if attempt < CALLBACK_ATTEMPTS {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+
+	log.Error("HTTP: callback of task ", cb.Task, " to ", callbackURL, " dropped")
+}
+
+func (c *callbacks) deliver(callbackURL, requestID string, body []byte, signature string) error {
+	rq, err := http.NewRequest("POST", callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	rq.Header.Set("Content-Type", "application/json")
+	rq.Header.Set(HEADER_JWS_SIGNATURE, signature)
+	if requestID != "" {
+		rq.Header.Set(HEADER_REQUEST_ID, requestID)
+	}
+
+	rs, err := c.client.Do(rq)
+	if err != nil {
+		return err
+	}
+	rs.Body.Close()
+
+	if rs.StatusCode/100 != 2 {
+		return errors.New(str.Concat("Callback responded ", strconv.Itoa(rs.StatusCode)))
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"crypto"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/wot/server"
+)
+
+type delivery struct {
+	body      []byte
+	signature string
+	requestID string
+}
+
+func TestCaseActionCallback(t *testing.T) {
+	deliveries := make(chan *delivery, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		deliveries <- &delivery{body, r.Header.Get(HEADER_JWS_SIGNATURE), r.Header.Get(HEADER_REQUEST_ID)}
+	}))
+	defer receiver.Close()
+
+	p := testHTTP(map[string]interface{}{"callbackHosts": []interface{}{"127.0.0.1"}})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "POST", "/lamp/action/toggle?callback="+url.QueryEscape(receiver.URL+"/done"), "null", HEADER_REQUEST_ID, "req-7")
+	Equals("Invoked", t, true, w.Code/100 == 2)
+
+	var d *delivery
+	select {
+	case d = <-deliveries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not delivered")
+	}
+
+	cb := &Callback{}
+	json.Unmarshal(d.body, cb)
+	Equals("Thing", t, "/lamp", cb.Thing)
+	Equals("Action", t, "toggle", cb.Action)
+	Equals("Request", t, "req-7", cb.RequestID)
+	Equals("Request header", t, "req-7", d.requestID)
+	Equals("Final status", t, server.TASK_DONE, cb.Status.Status)
+
+	key := &CallbackKey{}
+	json.Unmarshal(serve(p, "GET", "/callbacks/key", "").Body.Bytes(), key)
+	public, err := jose.ParsePublicKey([]byte(key.Key))
+	Equals("Public key", t, nil, err)
+
+	_, err = jose.VerifyDetached(d.signature, d.body, func(kid string) (crypto.PublicKey, bool) {
+		return public, kid == key.Kid
+	})
+	Equals("Signed", t, nil, err)
+}
+
+func TestCaseInvalidCallback(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"callbackHosts": []interface{}{"127.0.0.1"}})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "POST", "/lamp/action/toggle?callback="+url.QueryEscape("ftp://127.0.0.1/done"), "null")
+	Equals("Invalid scheme", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "POST", "/lamp/action/toggle?callback="+url.QueryEscape("http://example.com/done"), "null")
+	Equals("Host not allowed", t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,66 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// CASRequest is body of compare-and-set property write. Write succeeds only if current property
+// value equals Expected, or has ETag equal to Version when Version is given.
+type CASRequest struct {
+	Expected interface{} `json:"expected"`
+	Version  string      `json:"version,omitempty"`
+	Value    interface{} `json:"value"`
+}
+
+// CASConflict is returned with 409 when property changed, so client can retry with current value
+type CASConflict struct {
+	Current interface{} `json:"current"`
+	Version string      `json:"version"`
+}
+
+func (p *Http) propertyCASHandler(t *tenant, wotServer *server.WotServer, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_WRITE, wotServer, prop.Name) {
+			return
+		}
+
+		limitBody(w, r, p.limits.propertyBody)
+
+		cas := &CASRequest{}
+		if err := readBody(r, cas); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		//condition is evaluated inside WotServer call, current value is safe to read once call is done
+		var current interface{}
+		cond := func(value interface{}) bool {
+			current = value
+
+			if cas.Version != "" {
+				return ifMatch(prop, cas.Version)(value)
+			}
+
+			return server.SameValue(value, cas.Expected)
+		}
+
+		data := requested(r, wotServer).SetPropertyIf(prop.Name, cond, cas.Value).Get()
+		p.audited(p.auditEntry(r, t, wotServer, server.INTERACTION_WRITE, prop.Name, cas.Value), data)
+
+		switch data.(type) {
+		case server.Status:
+			if data.(server.Status) == server.WOT_PROPERTY_CONFLICT {
+				version, _ := valueETag(prop, current)
+				sendCode(w, r, http.StatusConflict, &CASConflict{Current: current, Version: version})
+			} else if data.(server.Status) != server.WOT_OK {
+				sendERR(w, r, data)
+			}
+		case error:
+			sendERR(w, r, data)
+		}
+	}
+}
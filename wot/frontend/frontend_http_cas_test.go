@@ -0,0 +1,32 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCasePropertyCAS(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	w := serve(p, "PUT", "/lamp/property/on/cas", `{"expected": true, "value": false}`, "Content-Type", "application/json")
+	Equals("Conflict", t, http.StatusConflict, w.Code)
+
+	var conflict CASConflict
+	json.Unmarshal(w.Body.Bytes(), &conflict)
+	Equals("Current", t, false, conflict.Current)
+	Equals("Version", t, true, conflict.Version != "")
+
+	w = serve(p, "PUT", "/lamp/property/on/cas", `{"expected": false, "value": true}`, "Content-Type", "application/json")
+	Equals("Swapped", t, http.StatusOK, w.Code)
+	Equals("Swapped value", t, true, s.GetProperty("on").Get())
+
+	body, _ := json.Marshal(&CASRequest{Version: conflict.Version, Value: false})
+	w = serve(p, "PUT", "/lamp/property/on/cas", string(body), "Content-Type", "application/json")
+	Equals("Stale version", t, http.StatusConflict, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/power/cas", `{"expected": 7.5, "value": 1}`, "Content-Type", "application/json")
+	Equals("Read-only", t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,42 @@
+package frontend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseActionBusy(t *testing.T) {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "valve",
+		Actions: []model.Action{{
+			Name:        "open",
+			Hrefs:       []string{"action/open"},
+			Concurrency: &model.Concurrency{Policy: model.CONCURRENCY_REJECT},
+		}},
+	})
+
+	release := make(chan bool)
+	s.OnInvokeAction("open", func(input interface{}, ph async.ProgressHandler) interface{} {
+		<-release
+		return true
+	})
+
+	p := testHTTP(nil)
+	p.Bind("/valve", s)
+
+	first := taskPath(t, serve(p, "POST", "/valve/action/open", "true"))
+
+	w := serve(p, "POST", "/valve/action/open", "true")
+	Equals("Busy", t, http.StatusConflict, w.Code)
+
+	release <- true
+	Equals("First", t, server.TASK_DONE, finished(t, p, first).Status)
+
+	go func() { release <- true }()
+	next := taskPath(t, serve(p, "POST", "/valve/action/open", "true"))
+	Equals("Next", t, server.TASK_DONE, finished(t, p, next).Status)
+}
@@ -0,0 +1,60 @@
+package frontend
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/senml"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// meter is Thing exchanging values in declared content types, "unknown" declares type without encoder
+func meter() *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "meter",
+		Properties: []model.Property{
+			{Name: "limit", ValueType: model.ValueType{Type: "number"}, Writable: true, Hrefs: []string{"property/limit"}, ContentType: senml.CONTENT_TYPE_JSON},
+			{Name: "unknown", ValueType: model.ValueType{Type: "number"}, Hrefs: []string{"property/unknown"}, ContentType: "application/x-unknown"},
+		},
+		Actions: []model.Action{
+			{Name: "calibrate", Hrefs: []string{"action/calibrate"}, Synchronous: true,
+				InputData: model.InputData{ValueType: model.ValueType{Type: "number"}, ContentType: senml.CONTENT_TYPE_JSON}},
+		},
+	})
+
+	limit := interface{}(16.0)
+	s.OnGetProperty("limit", func() interface{} { return limit })
+	s.OnUpdateProperty("limit", func(v interface{}) { limit = v })
+	s.OnGetProperty("unknown", func() interface{} { return 1.0 })
+	s.OnInvokeAction("calibrate", func(input interface{}, ph async.ProgressHandler) interface{} {
+		return input
+	})
+
+	return s
+}
+
+func TestCaseDeclaredContentType(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/meter", meter())
+
+	w := serve(p, "GET", "/meter/property/limit", "")
+	Equals("Declared type", t, senml.CONTENT_TYPE_JSON, w.Header().Get("Content-Type"))
+	var pack senml.Pack
+	json.Unmarshal(w.Body.Bytes(), &pack)
+	Equals("Value", t, 16.0, *pack[0].Value)
+
+	serve(p, "PUT", "/meter/property/limit", `[{"n": "limit", "v": 20}]`)
+	w = serve(p, "GET", "/meter/property/limit", "")
+	pack = nil
+	json.Unmarshal(w.Body.Bytes(), &pack)
+	Equals("Decoded by declared type", t, 20.0, *pack[0].Value)
+
+	w = serve(p, "GET", "/meter/property/unknown", "")
+	Equals("JSON fallback", t, CONTENT_TYPE_JSON, w.Header().Get("Content-Type"))
+
+	w = serve(p, "POST", "/meter/action/calibrate", `[{"n": "offset", "v": 0.5}]`)
+	Equals("Input decoded by declared type", t, "0.5", strings.TrimSpace(w.Body.String()))
+}
@@ -0,0 +1,229 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/patch"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+// WS_SUBPROTOCOL_SYNC is WebSocket subprotocol of property synchronization, messages are SyncMessage
+const WS_SUBPROTOCOL_SYNC = "wot.sync.v1"
+
+const (
+	SYNC_STATE = "state"
+	SYNC_PATCH = "patch"
+)
+
+// SyncMessage is sent to client synchronizing property. First message carries full Value of property,
+// following ones JSON Patch of changes since previous message. Seq increments by one with every message,
+// client detecting a gap sends ResyncMessage and gets full Value again.
+type SyncMessage struct {
+	Seq   uint64            `json:"seq"`
+	Type  string            `json:"type"`
+	Value interface{}       `json:"value,omitempty"`
+	Patch []patch.Operation `json:"patch,omitempty"`
+}
+
+// ResyncMessage is sent by client which lost track of property state
+type ResyncMessage struct {
+	Resync bool `json:"resync"`
+}
+
+// syncState keeps latest change not yet sent to client, changes arriving faster than client
+// reads are coalesced into one patch
+type syncState struct {
+	l       *sync.Mutex
+	pending interface{}
+	changed bool
+	notify  chan struct{}
+}
+
+func (ss *syncState) offer(value interface{}) {
+	ss.l.Lock()
+	ss.pending = value
+	ss.changed = true
+	ss.l.Unlock()
+
+	select {
+	case ss.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (ss *syncState) take() (interface{}, bool) {
+	ss.l.Lock()
+	defer ss.l.Unlock()
+
+	value, changed := ss.pending, ss.changed
+	ss.pending, ss.changed = nil, false
+
+	return value, changed
+}
+
+// propertySyncHandler synchronizes property over WebSocket. Client gets full state when connected and
+// JSON Patch deltas of changes published by PROPERTY_CHANGE_EVENT, so large object properties changing
+// often cost only the changed members. Patch larger than the state is replaced by the state.
+func (p *Http) propertySyncHandler(t *tenant, ctxPath string, wotServer *server.WotServer, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_READ, wotServer, prop.Name) {
+			return
+		}
+
+		if !declaresEvent(wotServer, server.PROPERTY_CHANGE_EVENT) {
+			sendCode(w, r, http.StatusNotFound, "Thing does not publish property changes.")
+			return
+		}
+
+		//value is read before upgrade, so failed read is answered with HTTP status
+		data := requested(r, wotServer).GetProperty(prop.Name).Get()
+		if status, ok := data.(server.Status); ok && status != server.WOT_OK {
+			sendERR(w, r, data)
+			return
+		}
+		if _, ok := data.(error); ok {
+			sendERR(w, r, data)
+			return
+		}
+
+		state, err := patch.Normalize(data)
+		if err != nil {
+			sendERR(w, r, err)
+			return
+		}
+
+		if !p.limits.wsClients.acquire(ctxPath) {
+			sendCode(w, r, http.StatusTooManyRequests, "Too many WebSocket clients.")
+			return
+		}
+		defer p.limits.wsClients.release(ctxPath)
+
+		ss := &syncState{
+			l:      &sync.Mutex{},
+			notify: make(chan struct{}, 1),
+		}
+
+		listenerID, _ := sec.UUID4()
+		listener := &server.EventListener{
+			ID: listenerID,
+			CB: func(event interface{}) {
+				e, ok := event.(*server.Event)
+				if !ok {
+					return
+				}
+				if change, ok := e.Data.(*server.PropertyChange); ok && change.Name == prop.Name {
+					ss.offer(change.Value)
+				}
+			},
+		}
+
+		//subscribed before upgrade, so change during handshake is not missed
+		wotServer.AddListener(server.PROPERTY_CHANGE_EVENT, listener)
+		defer wotServer.RemoveListener(server.PROPERTY_CHANGE_EVENT, listener)
+
+		conn, err := p.upgradeWS(w, r)
+		if err != nil {
+			log.Println("Error creating WebSocket at: ", err)
+			return
+		}
+		defer conn.Close()
+
+		resync := make(chan struct{}, 1)
+		closed := make(chan struct{})
+		p.keepAlive(conn, closed)
+		go readResync(conn, resync, closed)
+
+		var seq uint64
+		send := func(msg *SyncMessage) error {
+			seq++
+			msg.Seq = seq
+			p.writeDeadline(conn)
+			return conn.WriteJSON(msg)
+		}
+
+		if err = send(&SyncMessage{Type: SYNC_STATE, Value: state}); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case <-ss.notify:
+				value, changed := ss.take()
+				if !changed {
+					continue
+				}
+
+				next, err := patch.Normalize(value)
+				if err != nil {
+					log.Error("Property ", prop.Name, " change not synchronized -> ", err)
+					continue
+				}
+
+				ops := patch.Diff(state, next)
+				state = next
+				if len(ops) == 0 {
+					continue
+				}
+
+				if err = send(delta(state, ops)); err != nil {
+					return
+				}
+			case <-resync:
+				if err = send(&SyncMessage{Type: SYNC_STATE, Value: state}); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}
+}
+
+// delta is patch message, or state message when patch would not be smaller
+func delta(state interface{}, ops []patch.Operation) *SyncMessage {
+	encodedOps, err1 := json.Marshal(ops)
+	encodedState, err2 := json.Marshal(state)
+
+	if err1 == nil && err2 == nil && len(encodedOps) >= len(encodedState) {
+		return &SyncMessage{Type: SYNC_STATE, Value: state}
+	}
+
+	return &SyncMessage{Type: SYNC_PATCH, Patch: ops}
+}
+
+// readResync reads client messages until connection is closed, resync requests are signalled to writer
+func readResync(wsc *websocket.Conn, resync chan<- struct{}, closed chan<- struct{}) {
+	defer close(closed)
+
+	for {
+		_, data, err := wsc.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg ResyncMessage
+		if json.Unmarshal(data, &msg) == nil && msg.Resync {
+			select {
+			case resync <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func declaresEvent(wotServer *server.WotServer, eventName string) bool {
+	for _, e := range wotServer.GetDescription().Events {
+		if e.Name == eventName {
+			return true
+		}
+	}
+
+	return false
+}
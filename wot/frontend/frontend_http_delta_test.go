@@ -0,0 +1,63 @@
+package frontend
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/patch"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+func TestCasePropertySync(t *testing.T) {
+	p := testHTTP(nil)
+	s := thermostat()
+	p.Bind("/thermostat", s)
+
+	dialer := &websocket.Dialer{Subprotocols: []string{WS_SUBPROTOCOL_SYNC}}
+	conn, closer := dialWith(t, dialer, p, "/thermostat/property/config/sync", nil)
+	defer closer()
+	Equals("Negotiated", t, WS_SUBPROTOCOL_SYNC, conn.Subprotocol())
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	msg := &SyncMessage{}
+	Equals("Read state", t, nil, conn.ReadJSON(msg))
+	Equals("State", t, SYNC_STATE, msg.Type)
+	Equals("Seq", t, uint64(1), msg.Seq)
+	Equals("Mode", t, "heat", msg.Value.(map[string]interface{})["mode"])
+
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "config", Value: map[string]interface{}{
+		"mode": "cool", "target": 21.0, "display": map[string]interface{}{"unit": "C"},
+	}})
+
+	msg = &SyncMessage{}
+	Equals("Read patch", t, nil, conn.ReadJSON(msg))
+	Equals("Patch", t, SYNC_PATCH, msg.Type)
+	Equals("Seq", t, uint64(2), msg.Seq)
+	Equals("Delta", t, 1, len(msg.Patch))
+	Equals("Changed member", t, patch.Operation{Op: "replace", Path: "/mode", Value: "cool"}, msg.Patch[0])
+
+	Equals("Resync", t, nil, conn.WriteJSON(&ResyncMessage{Resync: true}))
+
+	msg = &SyncMessage{}
+	Equals("Read state", t, nil, conn.ReadJSON(msg))
+	Equals("Full state again", t, SYNC_STATE, msg.Type)
+	Equals("Seq", t, uint64(3), msg.Seq)
+	Equals("Current mode", t, "cool", msg.Value.(map[string]interface{})["mode"])
+}
+
+func TestCasePropertySyncWithoutChanges(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:       "gauge",
+		Properties: []model.Property{{Name: "level", ValueType: model.ValueType{Type: "number"}, Hrefs: []string{"property/level"}}},
+	})
+	s.OnGetProperty("level", func() interface{} { return 3.0 })
+	p.Bind("/gauge", s)
+
+	w := serve(p, "GET", "/gauge/property/level/sync", "")
+	Equals("No change event", t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,25 @@
+package frontend
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseWellKnownWoT(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/thermostat", thermostat())
+	p.Bind("/lamp", lamp())
+	p.AddTenant("acme", tenantGuard("k-acme"))
+	p.BindTenant("acme", "/hidden", lamp())
+
+	w := serve(p, "GET", "/.well-known/wot", "")
+	var tds []*model.ThingDescription
+	json.Unmarshal(w.Body.Bytes(), &tds)
+
+	Equals("Things of default tenant", t, 2, len(tds))
+	Equals("Sorted by context path", t, "lamp", tds[0].Name)
+	Equals("Second", t, "thermostat", tds[1].Name)
+	Equals("Vary", t, "Accept-Language", w.Header().Get("Vary"))
+}
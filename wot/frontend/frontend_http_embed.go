@@ -0,0 +1,90 @@
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+)
+
+// HandlerFrontend is implemented by frontends embeddable into HTTP server of application
+type HandlerFrontend interface {
+	Frontend
+	Handler() http.Handler
+}
+
+// mount is path prefix binding is served under and roots advertised instead of listener URLs, when
+// binding is embedded into server of application
+type mount struct {
+	prefix   string
+	baseURLs []string
+}
+
+type mountKey struct{}
+
+// configureMount reads "prefix", path binding is mounted under, e.g. "/wot", and "baseURL", list of
+// roots of application server advertised in Thing Descriptions, e.g. "https://example.org". Prefix is
+// appended to advertised roots.
+func (p *Http) configureMount(cfg map[string]interface{}) {
+	p.mount = &mount{}
+
+	if prefix, ok := cfg["prefix"].(string); ok {
+		if !strings.HasPrefix(prefix, "/") {
+			panic(str.Concat("Invalid prefix: absolute path expected, got ", prefix))
+		}
+		p.mount.prefix = strings.TrimSuffix(prefix, "/")
+	}
+
+	for _, base := range stringList("baseURL", cfg["baseURL"]) {
+		u, err := url.Parse(base)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			panic(str.Concat("Invalid baseURL: absolute http(s) URL expected, got ", base))
+		}
+		p.mount.baseURLs = append(p.mount.baseURLs, strings.TrimSuffix(base, "/"))
+	}
+}
+
+// Handler returns handler of binding for HTTP server of application, binding then does not need to
+// own listener. Application routes paths of prefix to it, e.g.
+//
+//	mux.Handle("/wot/", fe.Handler())
+//
+// for binding configured by "prefix" "/wot". Pending scheduled invocations are armed as by Start.
+func (p *Http) Handler() http.Handler {
+	p.scheduler.Start()
+
+	return p
+}
+
+// strip removes prefix of mounted binding from request path, requests outside of prefix are not
+// served by binding
+func (m *mount) strip(r *http.Request) (*http.Request, bool) {
+	if m.prefix == "" {
+		return r, true
+	}
+
+	path := r.URL.Path
+	if path != m.prefix && !strings.HasPrefix(path, str.Concat(m.prefix, "/")) {
+		return r, false
+	}
+
+	u := *r.URL
+	u.Path = strings.TrimPrefix(path, m.prefix)
+	u.RawPath = strings.TrimPrefix(r.URL.RawPath, m.prefix)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	mounted := r.WithContext(context.WithValue(r.Context(), mountKey{}, m.prefix))
+	mounted.URL = &u
+
+	return mounted, true
+}
+
+// mountPrefix returns prefix request path was received under, links built from request path include it
+func mountPrefix(r *http.Request) string {
+	prefix, _ := r.Context().Value(mountKey{}).(string)
+	return prefix
+}
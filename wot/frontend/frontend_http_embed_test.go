@@ -0,0 +1,34 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseEmbeddedHandler(t *testing.T) {
+	p := testHTTP(map[string]interface{}{
+		"prefix":  "/wot/",
+		"baseURL": []interface{}{"https://example.org"},
+	})
+	p.Bind("/lamp", lamp())
+
+	app := http.NewServeMux()
+	app.Handle("/wot/", p.Handler())
+
+	w := serve(app, "GET", "/wot/lamp/property/power", "")
+	Equals("Mounted", t, "7.5", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/lamp/property/power", "")
+	Equals("Outside prefix", t, http.StatusNotFound, w.Code)
+
+	td := &model.ThingDescription{}
+	json.Unmarshal(serve(app, "GET", "/wot/lamp/description", "").Body.Bytes(), td)
+	Equals("Advertised root", t, "https://example.org/wot/lamp", td.Uris[0])
+
+	w = serve(app, "POST", "/wot/lamp/action/toggle", "null", "Prefer", PREFER_ASYNC)
+	Equals("Task under prefix", t, true, strings.HasPrefix(taskPath(t, w), "/wot/lamp/action/toggle/"))
+}
@@ -0,0 +1,52 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseEncryptedProperty(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	token, _ := jose.Encrypt(key, "meter-1", []byte("42.5"))
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "meter",
+		Properties: []model.Property{{
+			Name:       "reading",
+			Unit:       "kW",
+			Writable:   true,
+			ValueType:  model.ValueType{Type: "number"},
+			Hrefs:      []string{"property/reading"},
+			Encryption: &model.Encryption{Scheme: model.ENCRYPTION_JWE, KeyID: "meter-1"},
+		}},
+	})
+	var reading interface{} = token
+	s.OnGetProperty("reading", func() interface{} { return reading })
+	s.OnUpdateProperty("reading", func(v interface{}) { reading = v })
+
+	p := testHTTP(nil)
+	p.Bind("/meter", s)
+
+	//ciphertext is forwarded as is, even when conversion is asked for
+	w := serve(p, "GET", "/meter/property/reading?unit=W", "")
+	var forwarded string
+	json.Unmarshal(w.Body.Bytes(), &forwarded)
+	Equals("Ciphertext", t, token, forwarded)
+
+	plaintext, _ := jose.Decrypt(key, forwarded)
+	Equals("Plaintext", t, "42.5", string(plaintext))
+
+	w = serve(p, "PUT", "/meter/property/reading", "17")
+	Equals("Plaintext written", t, http.StatusBadRequest, w.Code)
+	Equals("Kept", t, token, reading)
+
+	written, _ := jose.Encrypt(key, "meter-1", []byte("17"))
+	w = serve(p, "PUT", "/meter/property/reading", `"`+written+`"`)
+	Equals("Ciphertext written", t, http.StatusOK, w.Code)
+	Equals("Forwarded", t, written, reading)
+}
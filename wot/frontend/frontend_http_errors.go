@@ -0,0 +1,105 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/server"
+)
+
+// Problem is RFC 7807 problem details of request failed by internal error, RequestID identifies
+// the request in logs, see HEADER_REQUEST_ID
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// configureNotFound sets handler of requests not routed by frontend from cfg "notFound", by default
+// they are answered 404 with JSON message
+func (p *Http) configureNotFound(cfg map[string]interface{}) {
+	p.router.notFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sendCode(w, r, http.StatusNotFound, "Not found.")
+	})
+
+	if handler, ok := cfg["notFound"]; ok {
+		h, ok := handler.(http.Handler)
+		if !ok {
+			panic("Invalid notFound: http.Handler expected")
+		}
+		p.router.notFound = h
+	}
+}
+
+// recovery converts panic of request handler to 500 problem response, it has to be deferred. Response
+// already started by the handler is left as it is, the client gets it truncated.
+func (p *Http) recovery(w *statusWriter, r *http.Request) {
+	err := recover()
+	if err == nil {
+		return
+	}
+
+	//aborted handler is not a failure, it is passed to http server to drop the connection
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+
+	log.Error("HTTP: request ", requestID(r), " ", r.Method, " ", r.URL.Path, " failed -> ", err, "\n", string(debug.Stack()))
+
+	if w.started {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	if encoder, encErr := Encoders.Get("JSON"); encErr == nil {
+		encoder.Encode(w, &Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(http.StatusInternalServerError),
+			Status:    http.StatusInternalServerError,
+			Detail:    "Request failed by internal error.",
+			RequestID: requestID(r),
+		})
+	}
+}
+
+// notFound reports whether WotServer call result is status or error of interaction unknown to Thing
+func notFound(result interface{}) bool {
+	switch v := result.(type) {
+	case server.Status:
+		return v.NotFound()
+	case error:
+		return errors.Is(v, server.ErrNotFound)
+	}
+
+	return false
+}
+
+// invalid reports whether WotServer call result is error of value not conforming to value type of interaction
+func invalid(result interface{}) bool {
+	switch v := result.(type) {
+	case server.Status:
+		return v == server.WOT_INVALID_VALUE
+	case error:
+		return errors.Is(v, server.ErrInvalid)
+	}
+
+	return false
+}
+
+// refused reports invocation with invalid input, such invocation is resolved immediately
+func refused(invocation *async.Promise) bool {
+	select {
+	case <-invocation.Done():
+		return invalid(invocation.Get())
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseRecovery(t *testing.T) {
+	p := testHTTP(map[string]interface{}{
+		"notFound": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/started" {
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte("partial"))
+			}
+			panic("handler failed")
+		}),
+	})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/failed", nil))
+	Equals("Failed status", t, http.StatusInternalServerError, rec.Code)
+	Equals("Failed content type", t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/started", nil))
+	Equals("Started status", t, http.StatusAccepted, rec.Code)
+	Equals("Started body", t, "partial", strings.TrimSpace(rec.Body.String()))
+}
+
+func TestCaseUnknownTask(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/action/toggle/nope", "")
+	Equals("Task", t, http.StatusNotFound, w.Code)
+
+	w = serve(p, "GET", "/lamp/action/toggle/ws/nope", "")
+	Equals("Task WebSocket", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseInvalidValues(t *testing.T) {
+	dimmer := server.CreateFromDescription(&model.ThingDescription{
+		Name: "dimmer",
+		Actions: []model.Action{{
+			Name:      "dim",
+			Hrefs:     []string{"action/dim"},
+			InputData: model.InputData{ValueType: model.ValueType{Type: "integer", Minimum: 0, Maximum: 100}},
+		}},
+	})
+	invoked := 0
+	dimmer.OnInvokeAction("dim", func(interface{}, async.ProgressHandler) interface{} {
+		invoked++
+		return nil
+	})
+
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+	p.Bind("/dimmer", dimmer)
+
+	w := serve(p, "PUT", "/lamp/property/on", `"yes"`)
+	Equals("Invalid property value", t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/on", `"yes"`, "If-Match", "*")
+	Equals("Invalid conditional write", t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Kept", t, "false", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "POST", "/dimmer/action/dim", `"half"`)
+	Equals("Invalid action input", t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(p, "POST", "/dimmer/action/dim", `150`)
+	Equals("Action input out of range", t, http.StatusUnprocessableEntity, w.Code)
+	Equals("Not invoked", t, 0, invoked)
+
+	w = serve(p, "POST", "/dimmer/action/dim", `50`)
+	finished(t, p, taskPath(t, w))
+	Equals("Invoked", t, 1, invoked)
+}
@@ -0,0 +1,111 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// sendTagged sends payload with strong ETag derived from its encoding. Clients presenting matching
+// If-None-Match get 304 without the body.
+func sendTagged(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	encoder, err := Encoders.Get(ENCODING_JSON)
+
+	if err != nil {
+		sendPlainERR(w, err)
+		return
+	}
+
+	sendTaggedAs(w, r, encoder, CONTENT_TYPE_JSON, payload)
+}
+
+// sendTaggedAs sends payload tagged like sendTagged, encoded by encoder as contentType. Binary payloads,
+// e.g. camera snapshots, are cached by clients only with revalidation, as the same URL serves newer data.
+func sendTaggedAs(w http.ResponseWriter, r *http.Request, encoder Encoder, contentType string, payload interface{}) {
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, payload); err != nil {
+		sendPlainERR(w, err)
+		return
+	}
+
+	tag := etagOf(buf.Bytes())
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Access-Control-Expose-Headers", "ETag")
+	if isBinary(contentType) {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if etagMatch(r.Header.Get("If-None-Match"), tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func etagOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return str.Concat("\"", hex.EncodeToString(sum[:12]), "\"")
+}
+
+// valueETag returns ETag of property value, encoded as it is sent on property read, see PropertyEncoder
+func valueETag(prop model.Property, v interface{}) (string, error) {
+	encoder, _ := PropertyEncoder(prop)
+
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, v); err != nil {
+		return "", err
+	}
+
+	return etagOf(buf.Bytes()), nil
+}
+
+// etagMatch reports whether If-None-Match header matches tag by weak comparison, weak validators
+// compare equal to strong ones with the same value
+func etagMatch(header, tag string) bool {
+	return conditionMatch(header, tag, true)
+}
+
+// strongETagMatch reports whether If-Match header matches tag by strong comparison, weak validators
+// never match
+func strongETagMatch(header, tag string) bool {
+	return conditionMatch(header, tag, false)
+}
+
+func conditionMatch(header, tag string, weak bool) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch returns write condition holding when current property value matches If-Match condition
+func ifMatch(prop model.Property, condition string) func(current interface{}) bool {
+	return func(current interface{}) bool {
+		tag, err := valueETag(prop, current)
+		return err == nil && strongETagMatch(condition, tag)
+	}
+}
@@ -0,0 +1,46 @@
+package frontend
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCasePropertyETag(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/property/on", "")
+	tag := w.Header().Get("ETag")
+	Equals("Tagged", t, true, tag != "")
+
+	w = serve(p, "GET", "/lamp/property/on", "", "If-None-Match", tag)
+	Equals("Not modified", t, http.StatusNotModified, w.Code)
+	Equals("No body", t, 0, w.Body.Len())
+
+	w = serve(p, "GET", "/lamp/property/on", "", "If-None-Match", "W/"+tag)
+	Equals("Weak validator", t, http.StatusNotModified, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/on", "true", "If-Match", "W/"+tag, "Content-Type", "application/json")
+	Equals("Weak write condition", t, http.StatusPreconditionFailed, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/on", "true", "If-Match", `"stale"`, "Content-Type", "application/json")
+	Equals("Stale write", t, http.StatusPreconditionFailed, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/on", "true", "If-Match", tag, "Content-Type", "application/json")
+	Equals("Conditional write", t, http.StatusOK, w.Code)
+
+	w = serve(p, "GET", "/lamp/property/on", "", "If-None-Match", tag)
+	Equals("Modified", t, http.StatusOK, w.Code)
+	Equals("New tag", t, true, w.Header().Get("ETag") != tag)
+}
+
+func TestCaseDescriptionETag(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/description", "")
+	Equals("Description", t, http.StatusOK, w.Code)
+
+	w = serve(p, "GET", "/lamp/description", "", "If-None-Match", w.Header().Get("ETag"))
+	Equals("Not modified", t, http.StatusNotModified, w.Code)
+}
@@ -0,0 +1,97 @@
+package frontend
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/conas/tno2/util/patch"
+)
+
+var errNotObject = errors.New("Field projection requires object value")
+
+// project returns projection of object value v to fields given as comma separated dotted
+// paths, e.g. "a,b.c". Paths crossing arrays are projected on every array element, missing
+// fields are left out.
+func project(v interface{}, fields string) (interface{}, error) {
+	generic, err := patch.Normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := generic.(map[string]interface{}); !ok {
+		return nil, errNotObject
+	}
+
+	var result interface{}
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			result = merge(result, pick(generic, strings.Split(field, ".")))
+		}
+	}
+
+	if result == nil {
+		result = make(map[string]interface{})
+	}
+
+	return result, nil
+}
+
+// pick returns v reduced to path, nil when path does not exist
+func pick(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return v
+	}
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil
+		}
+		if picked := pick(child, path[1:]); picked != nil || child == nil {
+			return map[string]interface{}{path[0]: picked}
+		}
+	case []interface{}:
+		items := make([]interface{}, len(node))
+		for i := range node {
+			items[i] = pick(node[i], path)
+			//keep array positions, objects without the field are projected to empty object
+			if _, ok := node[i].(map[string]interface{}); ok && items[i] == nil {
+				items[i] = make(map[string]interface{})
+			}
+		}
+		return items
+	}
+
+	return nil
+}
+
+// merge combines two projections of the same value
+func merge(a, b interface{}) interface{} {
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	switch na := a.(type) {
+	case map[string]interface{}:
+		if nb, ok := b.(map[string]interface{}); ok {
+			for k, v := range nb {
+				na[k] = merge(na[k], v)
+			}
+			return na
+		}
+	case []interface{}:
+		if nb, ok := b.([]interface{}); ok && len(na) == len(nb) {
+			for i := range na {
+				na[i] = merge(na[i], nb[i])
+			}
+			return na
+		}
+	}
+
+	return b
+}
@@ -0,0 +1,32 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaseFieldsProjection(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/thermostat", thermostat())
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/thermostat/property/config?fields=mode,display.unit,missing", "")
+	Equals("Projected", t, http.StatusOK, w.Code)
+	Equals("Projection", t, `{"display":{"unit":"C"},"mode":"heat"}`, strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/lamp/property/on?fields=value", "")
+	Equals("Not object", t, http.StatusBadRequest, w.Code)
+}
+
+func TestCaseProjectArrays(t *testing.T) {
+	var v interface{}
+	json.Unmarshal([]byte(`{"rooms": [{"name": "hall", "t": 20}, {"t": 21}], "id": 1}`), &v)
+
+	projected, err := project(v, "rooms.name, id")
+	data, _ := json.Marshal(projected)
+
+	Equals("Error", t, nil, err)
+	Equals("Projection", t, `{"id":1,"rooms":[{"name":"hall"},{}]}`, string(data))
+}
@@ -0,0 +1,81 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func description(h http.Handler, ctxPath string) *model.ThingDescription {
+	td := &model.ThingDescription{}
+	json.Unmarshal(serve(h, "GET", ctxPath+"/description", "").Body.Bytes(), td)
+	return td
+}
+
+func TestCaseFormsOfBindings(t *testing.T) {
+	a := testHTTP(nil)
+	b := testHTTP(map[string]interface{}{"port": 8081})
+
+	s := lamp()
+	a.Bind("/lamp", s)
+	b.Bind("/lamp", s)
+	a.Bind("/lamp", s)
+
+	td := description(a, "/lamp")
+	Equals("URIs of both bindings", t, "http://localhost:8080/lamp http://localhost:8081/lamp", strings.Join(td.Uris, " "))
+	Equals("Same description by other binding", t, strings.Join(td.Uris, " "), strings.Join(description(b, "/lamp").Uris, " "))
+
+	w := serve(b, "GET", "/lamp/property/power", "")
+	Equals("Served by both", t, http.StatusOK, w.Code)
+
+	b.Unbind("/lamp")
+	Equals("Forms withdrawn", t, "http://localhost:8080/lamp", strings.Join(description(a, "/lamp").Uris, " "))
+}
+
+func TestCaseDescriptionVersion(t *testing.T) {
+	a := testHTTP(nil)
+	b := testHTTP(map[string]interface{}{"port": 8081})
+
+	s := lamp()
+	a.Bind("/lamp", s)
+	first := s.Version()
+	b.Bind("/lamp", s)
+
+	td := description(a, "/lamp")
+	Equals("Revised", t, first+1, td.Version.Number)
+
+	w := serve(a, "GET", "/lamp/description?version="+strconv.Itoa(first), "")
+	previous := &model.ThingDescription{}
+	json.Unmarshal(w.Body.Bytes(), previous)
+	Equals("Kept version", t, first, previous.Version.Number)
+	Equals("Forms of version", t, "http://localhost:8080/lamp", strings.Join(previous.Uris, " "))
+
+	w = serve(a, "GET", "/lamp/description?version=latest", "")
+	Equals("Invalid version", t, http.StatusBadRequest, w.Code)
+
+	w = serve(a, "GET", "/lamp/description?version=999", "")
+	Equals("Unknown version", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseThingModelExport(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	tm := &model.ThingDescription{}
+	json.Unmarshal(serve(p, "GET", "/lamp/description?format=tm", "").Body.Bytes(), tm)
+
+	Equals("Thing Model", t, true, tm.AT_Type.Has(model.TM_TYPE))
+	Equals("No instance URIs", t, 0, len(tm.Uris))
+	Equals("No version number", t, true, tm.Version == nil)
+	for _, href := range tm.Properties[0].Hrefs {
+		Equals("Relative href", t, false, strings.Contains(href, "://"))
+	}
+
+	td := description(p, "/lamp")
+	Equals("Description intact", t, false, td.AT_Type.Has(model.TM_TYPE))
+	Equals("Instance URI", t, 1, len(td.Uris))
+}
@@ -0,0 +1,669 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/graphql"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/gen"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+// WS_SUBPROTOCOL_GRAPHQL carries GraphQL operations and subscription results over WebSocket
+const WS_SUBPROTOCOL_GRAPHQL = "graphql-transport-ws"
+
+// graphql-transport-ws message types
+const (
+	gql_CONNECTION_INIT = "connection_init"
+	gql_CONNECTION_ACK  = "connection_ack"
+	gql_PING            = "ping"
+	gql_PONG            = "pong"
+	gql_SUBSCRIBE       = "subscribe"
+	gql_NEXT            = "next"
+	gql_ERROR           = "error"
+	gql_COMPLETE        = "complete"
+)
+
+// GraphQLRequest is GraphQL over HTTP request body, or query parameters of GET request
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphQLResponse struct {
+	Data   interface{}      `json:"data,omitempty"`
+	Errors []*graphql.Error `json:"errors,omitempty"`
+}
+
+type gqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// gqlTask is Task object returned by action mutation
+type gqlTask map[string]interface{}
+
+// gqlThing is Thing exposed as field of GraphQL root types
+type gqlThing struct {
+	field   string
+	ctxPath string
+	s       *server.WotServer
+}
+
+// registerGraphQL exposes things of tenant at {base}/graphql. Every Thing is field of Query with its
+// properties, of Mutation with its actions and set_{property} writes and of Subscription with its events.
+// GET {base}/graphql/schema returns schema in SDL.
+func (p *Http) registerGraphQL(t *tenant, base string) {
+	endpoint := str.Concat(base, "/graphql")
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if !p.authenticated(w, r, t) {
+			return
+		}
+
+		if websocket.IsWebSocketUpgrade(r) {
+			p.graphqlWS(w, r, t, base)
+			return
+		}
+
+		limitBody(w, r, MAX_BODY)
+
+		rq := &GraphQLRequest{}
+		if r.Method == "GET" {
+			rq.Query = r.URL.Query().Get("query")
+			rq.OperationName = r.URL.Query().Get("operationName")
+			if vars := r.URL.Query().Get("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &rq.Variables); err != nil {
+					sendCode(w, r, http.StatusBadRequest, gqlErrors(err))
+					return
+				}
+			}
+		} else if err := readBody(r, rq); err != nil {
+			sendCode(w, r, http.StatusBadRequest, gqlErrors(err))
+			return
+		}
+
+		doc, op, vars, err := parseGraphQL(rq)
+		if err == nil && op.Type == graphql.OPERATION_SUBSCRIPTION {
+			err = errors.New("Subscriptions require WebSocket")
+		}
+		if err == nil && op.Type == graphql.OPERATION_MUTATION && r.Method == "GET" {
+			err = errors.New("Mutations require POST")
+		}
+		if err != nil {
+			sendCode(w, r, http.StatusBadRequest, gqlErrors(err))
+			return
+		}
+
+		sendOK(w, r, p.executeGraphQL(r, t, base, doc, op, vars))
+	}
+
+	p.addRoute(p.router, &route{method: "GET", pattern: endpoint, handlerFunc: handler})
+	p.addRoute(p.router, &route{method: "POST", pattern: endpoint, handlerFunc: handler})
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: str.Concat(endpoint, "/schema"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/graphql")
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Write([]byte(graphqlSchema(p.graphqlThings(t, base))))
+		},
+	})
+}
+
+func parseGraphQL(rq *GraphQLRequest) (*graphql.Document, *graphql.Operation, map[string]interface{}, error) {
+	doc, err := graphql.Parse(rq.Query)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	op, err := doc.Operation(rq.OperationName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	vars, err := op.Values(rq.Variables)
+	return doc, op, vars, err
+}
+
+func gqlErrors(err error) *GraphQLResponse {
+	return &GraphQLResponse{Errors: []*graphql.Error{{Message: err.Error()}}}
+}
+
+// graphqlThings returns things of tenant sorted by context path, field names are unique
+func (p *Http) graphqlThings(t *tenant, base string) []*gqlThing {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	paths := make([]string, 0, len(t.things))
+	for path := range t.things {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	things := make([]*gqlThing, 0, len(paths))
+	fields := make(map[string]bool)
+
+	for _, path := range paths {
+		field := gqlName(strings.TrimPrefix(path, base))
+		if fields[field] {
+			continue
+		}
+
+		fields[field] = true
+		things = append(things, &gqlThing{field: field, ctxPath: path, s: t.things[path]})
+	}
+
+	return things
+}
+
+// executeGraphQL executes query or mutation, fields of mutation are executed in order
+func (p *Http) executeGraphQL(r *http.Request, t *tenant, base string, doc *graphql.Document, op *graphql.Operation, vars map[string]interface{}) *GraphQLResponse {
+	rs := &GraphQLResponse{}
+	root := gqlRootType(op.Type)
+
+	fields, err := doc.Collect(op.Selections, root, vars)
+	if err != nil {
+		return gqlErrors(err)
+	}
+
+	things := make(map[string]*gqlThing)
+	for _, thing := range p.graphqlThings(t, base) {
+		things[thing.field] = thing
+	}
+
+	data := make(map[string]interface{})
+	for _, f := range fields {
+		if f.Name == "__typename" {
+			data[f.Key()] = root
+			continue
+		}
+
+		thing, ok := things[f.Name]
+		if !ok {
+			rs.Errors = append(rs.Errors, &graphql.Error{Message: str.Concat("Unknown field ", f.Name, " of ", root), Path: []interface{}{f.Key()}})
+			data[f.Key()] = nil
+			continue
+		}
+
+		var typeName string
+		var resolve func(*graphql.Field) (interface{}, error)
+
+		if op.Type == graphql.OPERATION_MUTATION {
+			typeName = gqlTypeName(thing.field, "Mutation")
+			resolve = func(f *graphql.Field) (interface{}, error) {
+				return p.mutateThing(r, t, thing, f, vars)
+			}
+		} else {
+			typeName = gqlTypeName(thing.field, "")
+			resolve = func(f *graphql.Field) (interface{}, error) {
+				return p.queryThing(r, t, thing, f)
+			}
+		}
+
+		data[f.Key()] = p.resolveObject(rs, doc, []interface{}{f.Key()}, f.Selections, typeName, vars, resolve)
+	}
+
+	rs.Data = data
+	return rs
+}
+
+// resolveObject resolves selected fields of object one by one, errors are collected in response
+func (p *Http) resolveObject(rs *GraphQLResponse, doc *graphql.Document, path []interface{}, selections []*graphql.Selection,
+	typeName string, vars map[string]interface{}, resolve func(*graphql.Field) (interface{}, error)) map[string]interface{} {
+
+	fields, err := doc.Collect(selections, typeName, vars)
+	if err != nil {
+		rs.Errors = append(rs.Errors, &graphql.Error{Message: err.Error(), Path: path})
+		return nil
+	}
+
+	object := make(map[string]interface{})
+	for _, f := range fields {
+		if f.Name == "__typename" {
+			object[f.Key()] = typeName
+			continue
+		}
+
+		v, err := resolve(f)
+		if err != nil {
+			rs.Errors = append(rs.Errors, &graphql.Error{Message: err.Error(), Path: append(append([]interface{}{}, path...), f.Key())})
+		}
+
+		if task, ok := v.(gqlTask); ok {
+			v = p.resolveObject(rs, doc, append(append([]interface{}{}, path...), f.Key()), f.Selections, "Task", vars,
+				func(f *graphql.Field) (interface{}, error) {
+					v, ok := task[f.Name]
+					if !ok {
+						return nil, errors.New(str.Concat("Unknown field ", f.Name, " of Task"))
+					}
+					return v, nil
+				})
+		}
+
+		object[f.Key()] = v
+	}
+
+	return object
+}
+
+func (p *Http) queryThing(r *http.Request, t *tenant, thing *gqlThing, f *graphql.Field) (interface{}, error) {
+	prop, ok := gqlProperty(thing.s.GetDescription(), f.Name)
+	if !ok {
+		return nil, errors.New(str.Concat("Unknown property ", f.Name))
+	}
+
+	if err := p.permitted(r, t, auth.RIGHT_READ, thing.s, prop.Name); err != nil {
+		return nil, err
+	}
+
+	return callResult(requested(r, thing.s).GetProperty(prop.Name).Get())
+}
+
+func (p *Http) mutateThing(r *http.Request, t *tenant, thing *gqlThing, f *graphql.Field, vars map[string]interface{}) (interface{}, error) {
+	td := thing.s.GetDescription()
+
+	if prop, ok := gqlProperty(td, strings.TrimPrefix(f.Name, "set_")); ok && prop.Writable && strings.HasPrefix(f.Name, "set_") {
+		if err := p.permitted(r, t, auth.RIGHT_WRITE, thing.s, prop.Name); err != nil {
+			return nil, err
+		}
+
+		value, _ := f.Argument("value", vars)
+		data := requested(r, thing.s).SetProperty(prop.Name, value).Get()
+		p.audited(p.auditEntry(r, t, thing.s, server.INTERACTION_WRITE, prop.Name, value), data)
+
+		if _, err := callResult(data); err != nil {
+			return nil, err
+		}
+		return true, nil
+	}
+
+	for _, action := range td.Actions {
+		if gqlName(action.Name) != f.Name {
+			continue
+		}
+
+		if err := p.permitted(r, t, auth.RIGHT_INVOKE, thing.s, action.Name); err != nil {
+			return nil, err
+		}
+
+		if action.Signed {
+			return nil, errors.New(str.Concat("Action ", action.Name, " requires signed invocation"))
+		}
+
+		input, _ := f.Argument("input", vars)
+		actionID, href, err := p.invokeTask(r, t, thing.ctxPath, thing.s, action, input)
+		if err != nil {
+			return nil, err
+		}
+
+		return gqlTask{
+			"id":   actionID,
+			"href": href,
+		}, nil
+	}
+
+	return nil, errors.New(str.Concat("Unknown mutation ", f.Name))
+}
+
+// permitted checks right like authorized, denial is returned as error instead of response
+func (p *Http) permitted(r *http.Request, t *tenant, right auth.Right, s *server.WotServer, interaction string) error {
+	rec := httptest.NewRecorder()
+
+	if !p.authorized(rec, r, t, right, s, interaction) {
+		var msg string
+		json.Unmarshal(rec.Body.Bytes(), &msg)
+		if msg == "" {
+			msg = http.StatusText(rec.Code)
+		}
+		return errors.New(msg)
+	}
+
+	return nil
+}
+
+// graphqlWS serves graphql-transport-ws connection, subscriptions stream data of events
+func (p *Http) graphqlWS(w http.ResponseWriter, r *http.Request, t *tenant, base string) {
+	upgrader := *p.ws.upgrader
+	upgrader.Subprotocols = []string{WS_SUBPROTOCOL_GRAPHQL}
+
+	if requested := websocket.Subprotocols(r); len(requested) > 0 && !supports(requested, WS_SUBPROTOCOL_GRAPHQL) {
+		sendPlainERR(w, errors.New(str.Concat("Unsupported WebSocket subprotocols: ", strings.Join(requested, ", "))))
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(p.ws.maxMessage)
+
+	wl := &sync.Mutex{}
+	send := func(id, kind string, payload interface{}) error {
+		msg := &gqlMessage{ID: id, Type: kind}
+		if payload != nil {
+			msg.Payload, _ = json.Marshal(payload)
+		}
+
+		wl.Lock()
+		defer wl.Unlock()
+		p.writeDeadline(conn)
+		return conn.WriteJSON(msg)
+	}
+
+	closed := make(chan struct{})
+	go p.keepAlive(conn, closed)
+
+	sl := &sync.Mutex{}
+	stops := make(map[string]func())
+
+	defer func() {
+		close(closed)
+		conn.Close()
+
+		sl.Lock()
+		for _, stop := range stops {
+			stop()
+		}
+		sl.Unlock()
+	}()
+
+	for {
+		msg := &gqlMessage{}
+		if err := conn.ReadJSON(msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case gql_CONNECTION_INIT:
+			send("", gql_CONNECTION_ACK, nil)
+		case gql_PING:
+			send("", gql_PONG, nil)
+		case gql_COMPLETE:
+			sl.Lock()
+			if stop, ok := stops[msg.ID]; ok {
+				stop()
+				delete(stops, msg.ID)
+			}
+			sl.Unlock()
+		case gql_SUBSCRIBE:
+			rq := &GraphQLRequest{}
+			if err := json.Unmarshal(msg.Payload, rq); err != nil {
+				send(msg.ID, gql_ERROR, gqlErrors(err).Errors)
+				continue
+			}
+
+			doc, op, vars, err := parseGraphQL(rq)
+			if err != nil {
+				send(msg.ID, gql_ERROR, gqlErrors(err).Errors)
+				continue
+			}
+
+			if op.Type != graphql.OPERATION_SUBSCRIPTION {
+				send(msg.ID, gql_NEXT, p.executeGraphQL(r, t, base, doc, op, vars))
+				send(msg.ID, gql_COMPLETE, nil)
+				continue
+			}
+
+			stop, err := p.subscribeGraphQL(r, t, base, doc, op, vars, func(rs *GraphQLResponse) {
+				send(msg.ID, gql_NEXT, rs)
+			})
+			if err != nil {
+				send(msg.ID, gql_ERROR, gqlErrors(err).Errors)
+				continue
+			}
+
+			sl.Lock()
+			if previous, ok := stops[msg.ID]; ok {
+				previous()
+			}
+			stops[msg.ID] = stop
+			sl.Unlock()
+		default:
+			log.Warn("GraphQL: unknown message type ", msg.Type)
+		}
+	}
+}
+
+// subscribeGraphQL listens to event selected by single root field with single event field
+func (p *Http) subscribeGraphQL(r *http.Request, t *tenant, base string, doc *graphql.Document, op *graphql.Operation,
+	vars map[string]interface{}, next func(*GraphQLResponse)) (func(), error) {
+
+	root := gqlRootType(op.Type)
+	fields, err := doc.Collect(op.Selections, root, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) != 1 {
+		return nil, errors.New("Subscription must select exactly one Thing")
+	}
+
+	var thing *gqlThing
+	for _, candidate := range p.graphqlThings(t, base) {
+		if candidate.field == fields[0].Name {
+			thing = candidate
+		}
+	}
+	if thing == nil {
+		return nil, errors.New(str.Concat("Unknown field ", fields[0].Name, " of ", root))
+	}
+
+	events, err := doc.Collect(fields[0].Selections, gqlTypeName(thing.field, "Subscription"), vars)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) != 1 {
+		return nil, errors.New("Subscription must select exactly one event")
+	}
+
+	var eventName string
+	for _, e := range thing.s.GetDescription().Events {
+		if gqlName(e.Name) == events[0].Name {
+			eventName = e.Name
+		}
+	}
+	if eventName == "" {
+		return nil, errors.New(str.Concat("Unknown event ", events[0].Name))
+	}
+
+	if err := p.permitted(r, t, auth.RIGHT_SUBSCRIBE, thing.s, eventName); err != nil {
+		return nil, err
+	}
+
+	if !p.limits.subscriptions.acquire(thing.ctxPath) {
+		return nil, errors.New("Too many subscriptions.")
+	}
+
+	id, _ := sec.UUID4()
+	listener := &server.EventListener{
+		ID: id,
+		CB: func(event interface{}) {
+			data := event
+			if e, ok := event.(*server.Event); ok {
+				data = e.Data
+			}
+
+			next(&GraphQLResponse{Data: map[string]interface{}{
+				fields[0].Key(): map[string]interface{}{events[0].Key(): data},
+			}})
+		},
+	}
+	thing.s.AddListener(eventName, listener)
+
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			thing.s.RemoveListener(eventName, listener)
+			p.limits.subscriptions.release(thing.ctxPath)
+		})
+	}, nil
+}
+
+// graphqlSchema describes things in SDL, values without scalar TD type are JSON
+func graphqlSchema(things []*gqlThing) string {
+	var b strings.Builder
+	var query, mutation, subscription []string
+
+	b.WriteString("scalar JSON\n\ntype Task {\n  id: ID!\n  href: String!\n}\n")
+
+	for _, thing := range things {
+		td := thing.s.GetDescription()
+
+		var fields []string
+		for _, prop := range td.Properties {
+			fields = append(fields, str.Concat(gqlName(prop.Name), ": ", gqlType(prop.ValueType)))
+		}
+		query = append(query, gqlObject(&b, gqlTypeName(thing.field, ""), thing.field, fields)...)
+
+		fields = nil
+		for _, prop := range td.Properties {
+			if prop.Writable {
+				fields = append(fields, str.Concat("set_", gqlName(prop.Name), "(value: ", gqlType(prop.ValueType), "): Boolean"))
+			}
+		}
+		for _, action := range td.Actions {
+			if action.InputData.ValueType.Type != "" {
+				fields = append(fields, str.Concat(gqlName(action.Name), "(input: ", gqlType(action.InputData.ValueType), "): Task"))
+			} else {
+				fields = append(fields, str.Concat(gqlName(action.Name), ": Task"))
+			}
+		}
+		mutation = append(mutation, gqlObject(&b, gqlTypeName(thing.field, "Mutation"), thing.field, fields)...)
+
+		fields = nil
+		for _, event := range td.Events {
+			fields = append(fields, str.Concat(gqlName(event.Name), ": ", gqlType(event.ValueType)))
+		}
+		subscription = append(subscription, gqlObject(&b, gqlTypeName(thing.field, "Subscription"), thing.field, fields)...)
+	}
+
+	if len(query) == 0 {
+		query = append(query, "_empty: Boolean")
+	}
+	gqlObject(&b, "Query", "", query)
+	gqlObject(&b, "Mutation", "", mutation)
+	gqlObject(&b, "Subscription", "", subscription)
+
+	return b.String()
+}
+
+// gqlObject writes type with fields, it returns field of root type referencing it, types without fields are omitted
+func gqlObject(b *strings.Builder, typeName, field string, fields []string) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	b.WriteString(str.Concat("\ntype ", typeName, " {\n"))
+	for _, f := range fields {
+		b.WriteString(str.Concat("  ", f, "\n"))
+	}
+	b.WriteString("}\n")
+
+	return []string{str.Concat(field, ": ", typeName)}
+}
+
+func gqlRootType(operation string) string {
+	switch operation {
+	case graphql.OPERATION_MUTATION:
+		return "Mutation"
+	case graphql.OPERATION_SUBSCRIPTION:
+		return "Subscription"
+	}
+
+	return "Query"
+}
+
+func gqlTypeName(field, suffix string) string {
+	return gqlName(str.Concat(gen.Ident(field), "Thing", suffix))
+}
+
+func gqlType(vt model.ValueType) string {
+	switch vt.Type {
+	case "boolean":
+		return "Boolean"
+	case "integer":
+		return "Int"
+	case "number":
+		return "Float"
+	case "string":
+		return "String"
+	}
+
+	return "JSON"
+}
+
+// gqlName maps name to GraphQL name, characters other than letters, digits and underscore are replaced
+func gqlName(name string) string {
+	var b strings.Builder
+
+	for i, c := range []byte(strings.Trim(name, "/")) {
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_':
+			b.WriteByte(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+func gqlProperty(td *model.ThingDescription, field string) (model.Property, bool) {
+	for _, prop := range td.Properties {
+		if gqlName(prop.Name) == field {
+			return prop, true
+		}
+	}
+
+	return model.Property{}, false
+}
+
+// callResult maps result of WotServer call to value or error
+func callResult(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case server.Status:
+		//write queued for offline Thing is accepted
+		if v != server.WOT_OK && v != server.WOT_WRITE_QUEUED {
+			return nil, &server.StatusError{Status: v}
+		}
+		return nil, nil
+	case error:
+		return nil, v
+	}
+
+	return data, nil
+}
+
+func supports(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCaseGraphQLQuery(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"graphql": true})
+	p.Bind("/lamp", lamp())
+	p.Bind("/thermostat", thermostat())
+
+	w := serve(p, "POST", "/graphql", `{"query": "{ lamp { on power } thermostat { config } }"}`)
+	Equals("Query", t, `{"data":{"lamp":{"on":false,"power":7.5},"thermostat":{"config":{"display":{"unit":"C"},"mode":"heat","target":21}}}}`, strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/graphql?query="+`%7B%20lamp%20%7B%20power%20%7D%20%7D`, "")
+	Equals("GET query", t, `{"data":{"lamp":{"power":7.5}}}`, strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "POST", "/graphql", `{"query": "{ lamp { brightness } }"}`)
+	var rs GraphQLResponse
+	json.Unmarshal(w.Body.Bytes(), &rs)
+	Equals("Unknown field", t, 1, len(rs.Errors))
+
+	w = serve(p, "POST", "/graphql", `{"query": "{ lamp { "}`)
+	Equals("Syntax error", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "GET", "/graphql/schema", "")
+	Equals("Schema", t, true, strings.Contains(w.Body.String(), "set_on(value: Boolean): Boolean"))
+}
+
+func TestCaseGraphQLMutation(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"graphql": true})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/graphql?query=mutation%20%7B%20lamp%20%7B%20set_on(value%3A%20true)%20%7D%20%7D", "")
+	Equals("Mutation by GET", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "POST", "/graphql", `{"query": "mutation($on: Boolean) { lamp { set_on(value: $on) } }", "variables": {"on": true}}`)
+	Equals("Written", t, `{"data":{"lamp":{"set_on":true}}}`, strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Property", t, "true", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "POST", "/graphql", `{"query": "mutation { lamp { toggle { id href } } }"}`)
+	var rs struct {
+		Data struct {
+			Lamp struct {
+				Toggle gqlTask `json:"toggle"`
+			} `json:"lamp"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &rs)
+	Equals("Task", t, true, strings.Contains(rs.Data.Lamp.Toggle["href"].(string), "/lamp/action/toggle/"))
+}
+
+func TestCaseGraphQLTenant(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"graphql": true})
+	p.AddTenant("acme", tenantGuard("k-acme"))
+	p.BindTenant("acme", "/lamp", lamp())
+
+	w := serve(p, "POST", "/t/acme/graphql", `{"query": "{ lamp { power } }"}`)
+	Equals("Unauthenticated", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "POST", "/t/acme/graphql", `{"query": "{ lamp { power } }"}`, "X-API-Key", "k-acme")
+	Equals("Tenant Thing", t, `{"data":{"lamp":{"power":7.5}}}`, strings.TrimSpace(w.Body.String()))
+}
+
+func TestCaseGraphQLSubscription(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"graphql": true})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	dialer := &websocket.Dialer{Subprotocols: []string{WS_SUBPROTOCOL_GRAPHQL}}
+	conn, closer := dialWith(t, dialer, p, "/graphql", nil)
+	defer closer()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn.WriteJSON(&gqlMessage{Type: gql_CONNECTION_INIT})
+	ack := &gqlMessage{}
+	conn.ReadJSON(ack)
+	Equals("Acknowledged", t, gql_CONNECTION_ACK, ack.Type)
+
+	conn.WriteJSON(&gqlMessage{ID: "1", Type: gql_SUBSCRIBE, Payload: json.RawMessage(`{"query": "subscription { lamp { property_change } }"}`)})
+
+	//listener is added after subscribe message is read, changes are emitted until one is received
+	received := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-received:
+				return
+			case <-time.After(20 * time.Millisecond):
+				s.EmitPropertyChange("on", true)
+			}
+		}
+	}()
+
+	next := &gqlMessage{}
+	err := conn.ReadJSON(next)
+	close(received)
+	Equals("Read", t, nil, err)
+	Equals("Next", t, gql_NEXT, next.Type)
+	Equals("Subscription", t, "1", next.ID)
+	Equals("Change", t, true, strings.Contains(string(next.Payload), `"property_change"`))
+}
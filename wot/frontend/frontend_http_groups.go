@@ -0,0 +1,451 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/mux"
+)
+
+// Outcomes of group operation on one member Thing
+const (
+	GROUP_OK      = "ok"
+	GROUP_FAILED  = "failed"
+	GROUP_SKIPPED = "skipped"
+)
+
+// Group is named set of Things, explicit Members are context paths, Things tagged by any of Tags belong
+// to the group too. Things lists context paths of members bound when group is read.
+type Group struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Things  []string `json:"things,omitempty"`
+}
+
+// GroupResult is outcome of group operation on one Thing, Task is href of started action task
+type GroupResult struct {
+	Thing  string `json:"thing"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Task   string `json:"task,omitempty"`
+}
+
+// GroupReport consolidates results of group operation, members not declaring the interaction are skipped
+type GroupReport struct {
+	Group     string         `json:"group"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	Results   []*GroupResult `json:"results"`
+}
+
+type groups struct {
+	l      *sync.RWMutex
+	tags   map[string][]string
+	groups map[string]*Group
+}
+
+// configureGroups reads Thing tags from cfg "tags" (context path -> list of tags) and groups from cfg
+// "groups" (name -> {"members": [...], "tags": [...]}), both can be changed later by admin API.
+func (p *Http) configureGroups(cfg map[string]interface{}) {
+	p.groups = &groups{
+		l:      &sync.RWMutex{},
+		tags:   make(map[string][]string),
+		groups: make(map[string]*Group),
+	}
+
+	if tags, ok := cfg["tags"].(map[string]interface{}); ok {
+		for ctxPath, list := range tags {
+			p.groups.tags[ctxPath] = stringList("tags", list)
+		}
+	}
+
+	if defs, ok := cfg["groups"].(map[string]interface{}); ok {
+		for name, def := range defs {
+			m, ok := def.(map[string]interface{})
+			if !ok {
+				panic(str.Concat("Invalid group: ", name))
+			}
+
+			p.groups.groups[name] = &Group{
+				Name:    name,
+				Members: stringList("members", m["members"]),
+				Tags:    stringList("tags", m["tags"]),
+			}
+		}
+	}
+
+	p.registerGroups()
+}
+
+func stringList(key string, v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		panic(str.Concat("Invalid ", key, ": list of strings expected"))
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			panic(str.Concat("Invalid ", key, ": list of strings expected"))
+		}
+		values = append(values, s)
+	}
+
+	return values
+}
+
+// registerGroups exposes groups at /groups. Group operations write property or invoke action on all
+// members concurrently, every member is authorized by guard of its tenant. Response is 200 when all
+// members succeeded, 207 with per Thing results otherwise.
+func (p *Http) registerGroups() {
+	t := p.defaultTenant
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: "/groups",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			sendOK(w, r, p.groupList())
+		},
+	})
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: "/groups/{group}",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			if g, ok := p.group(mux.Vars(r)["group"]); ok {
+				sendOK(w, r, g)
+			} else {
+				sendCode(w, r, http.StatusNotFound, "Group not found.")
+			}
+		},
+	})
+
+	p.addRoute(p.router, &route{
+		method:  "PUT",
+		pattern: "/groups/{group}/properties/{property}",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			g, ok := p.group(mux.Vars(r)["group"])
+			if !ok {
+				sendCode(w, r, http.StatusNotFound, "Group not found.")
+				return
+			}
+
+			limitBody(w, r, p.limits.propertyBody)
+
+			var value interface{}
+			if err := readBody(r, &value); err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+
+			name := mux.Vars(r)["property"]
+			sendReport(w, r, p.groupOperation(g, func(ctxPath string, s *server.WotServer, result *GroupResult) {
+				p.groupWrite(r, ctxPath, s, name, value, result)
+			}))
+		},
+	})
+
+	p.addRoute(p.router, &route{
+		method:  "POST",
+		pattern: "/groups/{group}/actions/{action}",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			g, ok := p.group(mux.Vars(r)["group"])
+			if !ok {
+				sendCode(w, r, http.StatusNotFound, "Group not found.")
+				return
+			}
+
+			limitBody(w, r, p.limits.actionBody)
+
+			var input interface{}
+			if r.ContentLength != 0 {
+				if err := readBody(r, &input); err != nil {
+					sendPlainERR(w, err)
+					return
+				}
+			}
+
+			name := mux.Vars(r)["action"]
+			sendReport(w, r, p.groupOperation(g, func(ctxPath string, s *server.WotServer, result *GroupResult) {
+				p.groupInvoke(r, ctxPath, s, name, input, result)
+			}))
+		},
+	})
+}
+
+// registerGroupAdmin exposes group definitions and Thing tags in admin API as "admin/groups"
+func (p *Http) registerGroupAdmin() {
+	p.adminRoute("GET", "/admin/groups", auth.RIGHT_READ, "groups", func(w http.ResponseWriter, r *http.Request) {
+		sendOK(w, r, p.groupList())
+	})
+
+	p.adminRoute("PUT", "/admin/groups/{group}", auth.RIGHT_WRITE, "groups", func(w http.ResponseWriter, r *http.Request) {
+		g := &Group{}
+		if err := readBody(r, g); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		g.Name = mux.Vars(r)["group"]
+		g.Things = nil
+
+		p.groups.l.Lock()
+		p.groups.groups[g.Name] = g
+		p.groups.l.Unlock()
+
+		log.Info("HTTP admin: group defined -> ", g.Name)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	p.adminRoute("DELETE", "/admin/groups/{group}", auth.RIGHT_WRITE, "groups", func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["group"]
+
+		p.groups.l.Lock()
+		_, ok := p.groups.groups[name]
+		delete(p.groups.groups, name)
+		p.groups.l.Unlock()
+
+		if !ok {
+			sendCode(w, r, http.StatusNotFound, "Group not found.")
+			return
+		}
+
+		log.Info("HTTP admin: group removed -> ", name)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	p.adminRoute("PUT", "/admin/tags/{ctxPath:.+}", auth.RIGHT_WRITE, "groups", func(w http.ResponseWriter, r *http.Request) {
+		var tags []string
+		if err := readBody(r, &tags); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		ctxPath := "/" + mux.Vars(r)["ctxPath"]
+
+		p.groups.l.Lock()
+		if len(tags) == 0 {
+			delete(p.groups.tags, ctxPath)
+		} else {
+			p.groups.tags[ctxPath] = tags
+		}
+		p.groups.l.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (p *Http) groupList() []*Group {
+	p.groups.l.RLock()
+	names := make([]string, 0, len(p.groups.groups))
+	for name := range p.groups.groups {
+		names = append(names, name)
+	}
+	p.groups.l.RUnlock()
+
+	sort.Strings(names)
+
+	list := make([]*Group, 0, len(names))
+	for _, name := range names {
+		if g, ok := p.group(name); ok {
+			list = append(list, g)
+		}
+	}
+
+	return list
+}
+
+// group returns copy of group with its bound members resolved
+func (p *Http) group(name string) (*Group, bool) {
+	p.groups.l.RLock()
+	defer p.groups.l.RUnlock()
+
+	def, ok := p.groups.groups[name]
+	if !ok {
+		return nil, false
+	}
+
+	g := *def
+	g.Things = make([]string, 0)
+
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	for ctxPath := range p.wotServers {
+		if p.isMember(def, ctxPath) {
+			g.Things = append(g.Things, ctxPath)
+		}
+	}
+	sort.Strings(g.Things)
+
+	return &g, true
+}
+
+func (p *Http) isMember(g *Group, ctxPath string) bool {
+	for _, member := range g.Members {
+		if member == ctxPath {
+			return true
+		}
+	}
+
+	for _, tag := range p.groups.tags[ctxPath] {
+		for _, groupTag := range g.Tags {
+			if tag == groupTag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// groupOperation runs op on every member of resolved group g concurrently and consolidates results
+func (p *Http) groupOperation(g *Group, op func(ctxPath string, s *server.WotServer, result *GroupResult)) *GroupReport {
+	p.l.RLock()
+	members := make(map[string]*server.WotServer, len(g.Things))
+	for _, ctxPath := range g.Things {
+		if s, ok := p.wotServers[ctxPath]; ok {
+			members[ctxPath] = s
+		}
+	}
+	p.l.RUnlock()
+
+	report := &GroupReport{
+		Group:   g.Name,
+		Results: make([]*GroupResult, 0, len(members)),
+	}
+	l := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	for ctxPath, s := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := &GroupResult{Thing: ctxPath, Status: GROUP_OK}
+			op(ctxPath, s, result)
+
+			l.Lock()
+			defer l.Unlock()
+
+			switch result.Status {
+			case GROUP_OK:
+				report.Succeeded++
+			case GROUP_FAILED:
+				report.Failed++
+			default:
+				report.Skipped++
+			}
+			report.Results = append(report.Results, result)
+		}()
+	}
+
+	wg.Wait()
+
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].Thing < report.Results[j].Thing
+	})
+
+	return report
+}
+
+func (p *Http) groupWrite(r *http.Request, ctxPath string, s *server.WotServer, name string, value interface{}, result *GroupResult) {
+	prop, ok := findProperty(s.GetDescription(), name)
+	if !ok {
+		result.Status = GROUP_SKIPPED
+		return
+	}
+
+	t := p.memberTenant(ctxPath)
+
+	var err error
+	if !prop.Writable {
+		err = errors.New(str.Concat("Property ", name, " not writable"))
+	} else if err = p.permitted(r, t, auth.RIGHT_WRITE, s, name); err == nil {
+		data := requested(r, s).SetProperty(name, value).Get()
+		p.audited(p.auditEntry(r, t, s, server.INTERACTION_WRITE, name, value), data)
+		_, err = callResult(data)
+	}
+
+	if err != nil {
+		result.Status = GROUP_FAILED
+		result.Error = err.Error()
+	}
+}
+
+func (p *Http) groupInvoke(r *http.Request, ctxPath string, s *server.WotServer, name string, input interface{}, result *GroupResult) {
+	action, ok := findAction(s.GetDescription(), name)
+	if !ok {
+		result.Status = GROUP_SKIPPED
+		return
+	}
+
+	t := p.memberTenant(ctxPath)
+
+	var err error
+	if action.Signed {
+		err = errors.New(str.Concat("Action ", name, " requires signed invocation"))
+	} else if err = p.permitted(r, t, auth.RIGHT_INVOKE, s, name); err == nil {
+		_, result.Task, err = p.invokeTask(r, t, ctxPath, s, *action, input)
+	}
+
+	if err != nil {
+		result.Status = GROUP_FAILED
+		result.Error = err.Error()
+	}
+}
+
+func (p *Http) memberTenant(ctxPath string) *tenant {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	return p.tenantOf(ctxPath)
+}
+
+func sendReport(w http.ResponseWriter, r *http.Request, report *GroupReport) {
+	if report.Failed > 0 {
+		sendCode(w, r, http.StatusMultiStatus, report)
+	} else {
+		sendOK(w, r, report)
+	}
+}
+
+func findProperty(td *model.ThingDescription, name string) (model.Property, bool) {
+	for _, prop := range td.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+
+	return model.Property{}, false
+}
@@ -0,0 +1,104 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func groupsHTTP() *Http {
+	p := testHTTP(map[string]interface{}{
+		"tags": map[string]interface{}{
+			"/lamp-2": []interface{}{"hall"},
+		},
+		"groups": map[string]interface{}{
+			"lights": map[string]interface{}{
+				"members": []interface{}{"/lamp-1", "/thermostat"},
+				"tags":    []interface{}{"hall"},
+			},
+		},
+	})
+	p.Bind("/lamp-1", lamp())
+	p.Bind("/lamp-2", lamp())
+	p.Bind("/thermostat", thermostat())
+
+	return p
+}
+
+func TestCaseGroupRead(t *testing.T) {
+	p := groupsHTTP()
+
+	w := serve(p, "GET", "/groups/lights", "")
+	g := &Group{}
+	json.Unmarshal(w.Body.Bytes(), g)
+	Equals("Resolved members", t, "/lamp-1 /lamp-2 /thermostat", strings.Join(g.Things, " "))
+
+	w = serve(p, "GET", "/groups", "")
+	var list []*Group
+	json.Unmarshal(w.Body.Bytes(), &list)
+	Equals("Groups", t, 1, len(list))
+
+	w = serve(p, "GET", "/groups/unknown", "")
+	Equals("Unknown group", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseGroupWrite(t *testing.T) {
+	p := groupsHTTP()
+
+	w := serve(p, "PUT", "/groups/lights/properties/on", "true")
+	Equals("All written", t, http.StatusOK, w.Code)
+
+	report := &GroupReport{}
+	json.Unmarshal(w.Body.Bytes(), report)
+	Equals("Succeeded", t, 2, report.Succeeded)
+	Equals("Skipped", t, 1, report.Skipped)
+	Equals("Skipped Thing", t, GroupResult{Thing: "/thermostat", Status: GROUP_SKIPPED}, *report.Results[2])
+
+	w = serve(p, "GET", "/lamp-2/property/on", "")
+	Equals("Member written", t, "true", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "PUT", "/groups/lights/properties/power", "1")
+	Equals("Read-only property", t, http.StatusMultiStatus, w.Code)
+
+	report = &GroupReport{}
+	json.Unmarshal(w.Body.Bytes(), report)
+	Equals("Failed", t, 2, report.Failed)
+	Equals("Failure reported", t, GROUP_FAILED, report.Results[0].Status)
+}
+
+func TestCaseGroupInvoke(t *testing.T) {
+	p := groupsHTTP()
+
+	w := serve(p, "POST", "/groups/lights/actions/toggle", "")
+	report := &GroupReport{}
+	json.Unmarshal(w.Body.Bytes(), report)
+
+	Equals("Invoked", t, http.StatusOK, w.Code)
+	Equals("Succeeded", t, 2, report.Succeeded)
+	Equals("Task started", t, true, report.Results[0].Task != "")
+}
+
+func TestCaseGroupAdmin(t *testing.T) {
+	p := adminHTTP()
+
+	w := serve(p, "PUT", "/admin/groups/all", `{"tags": ["any"]}`, "X-API-Key", "k-viewer")
+	Equals("Viewer forbidden", t, http.StatusForbidden, w.Code)
+
+	w = serve(p, "PUT", "/admin/groups/all", `{"tags": ["any"]}`, "X-API-Key", "k-admin")
+	Equals("Group defined", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "PUT", "/admin/tags/lamp", `["any"]`, "X-API-Key", "k-admin")
+	Equals("Thing tagged", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "GET", "/groups/all", "")
+	g := &Group{}
+	json.Unmarshal(w.Body.Bytes(), g)
+	Equals("Tagged member", t, "/lamp", strings.Join(g.Things, " "))
+
+	w = serve(p, "DELETE", "/admin/groups/all", "", "X-API-Key", "k-admin")
+	Equals("Group removed", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "DELETE", "/admin/groups/all", "", "X-API-Key", "k-admin")
+	Equals("Removed group", t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,85 @@
+package frontend
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCaseH2C(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"h2c": true})
+	p.Bind("/lamp", lamp())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := p.server("")
+	go srv.Serve(l)
+	defer srv.Close()
+
+	protocols := &http.Protocols{}
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	resp, err := client.Get("http://" + l.Addr().String() + "/lamp/property/on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	Equals("Protocol", t, 2, resp.ProtoMajor)
+	Equals("Value", t, "false", strings.TrimSpace(string(body)))
+}
+
+// pushRecorder is response writer of HTTP/2 connection recording pushed targets and their headers
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed  []string
+	headers []http.Header
+}
+
+func (pr *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	pr.pushed = append(pr.pushed, target)
+	pr.headers = append(pr.headers, opts.Header)
+	return nil
+}
+
+func TestCasePushThroughMiddlewares(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"push": true, "middleware": []interface{}{"compress", "log"}})
+	p.Bind("/lamp", lamp())
+
+	rec := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rq := httptest.NewRequest("GET", "/lamp/", nil)
+	rq.Header.Set("Accept-Encoding", "gzip")
+	p.ServeHTTP(rec, rq)
+
+	Equals("Pushed through wrapped writers", t, "/lamp/description", strings.Join(rec.pushed, " "))
+}
+
+func TestCasePushDescription(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"push": true})
+	p.Bind("/lamp", lamp())
+
+	rec := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rq := httptest.NewRequest("GET", "/lamp/", nil)
+	rq.Header.Set("X-API-Key", "k-1")
+	rq.Header.Set("Cookie", "session=1")
+	p.ServeHTTP(rec, rq)
+
+	Equals("Root answered", t, http.StatusOK, rec.Code)
+	Equals("Description pushed", t, "/lamp/description", strings.Join(rec.pushed, " "))
+	Equals("Credentials passed", t, "k-1", rec.headers[0].Get("X-API-Key"))
+	Equals("Other headers left out", t, "", rec.headers[0].Get("Cookie"))
+
+	p = testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	rec = &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/lamp/", nil))
+	Equals("Push disabled", t, 0, len(rec.pushed))
+}
@@ -0,0 +1,103 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	// HISTORY_PERIOD is queried when from is not given
+	HISTORY_PERIOD = 24 * time.Hour
+	// HISTORY_BUCKETS is number of buckets period is split to when resolution is not given
+	HISTORY_BUCKETS = 100
+)
+
+// History is downsampled time series of property
+type History struct {
+	Property   string          `json:"property"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Resolution string          `json:"resolution"`
+	Buckets    []server.Bucket `json:"buckets"`
+}
+
+// propertyHistoryHandler serves ?from=&to=&resolution= query of property history. Times are RFC 3339
+// or durations relative to now such as -24h, resolution is duration such as 15m.
+func (p *Http) propertyHistoryHandler(t *tenant, wotServer *server.WotServer, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_READ, wotServer, prop.Name) {
+			return
+		}
+
+		store := wotServer.History()
+		if store == nil {
+			sendCode(w, r, http.StatusNotFound, "History is not recorded.")
+			return
+		}
+
+		now := time.Now()
+		q := r.URL.Query()
+
+		to, err := historyTime(q.Get("to"), now, now)
+		if err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		from, err := historyTime(q.Get("from"), to.Add(-HISTORY_PERIOD), now)
+		if err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		if !from.Before(to) {
+			sendPlainERR(w, errors.New("History from must precede to"))
+			return
+		}
+
+		resolution := to.Sub(from) / HISTORY_BUCKETS
+		if res := q.Get("resolution"); res != "" {
+			if resolution, err = time.ParseDuration(res); err != nil || resolution <= 0 {
+				sendPlainERR(w, errors.New(str.Concat("Invalid resolution: ", res)))
+				return
+			}
+		}
+
+		samples, err := store.Query(prop.Name, from, to)
+		if err != nil {
+			sendCode(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		sendOK(w, r, &History{
+			Property:   prop.Name,
+			From:       from,
+			To:         to,
+			Resolution: resolution.String(),
+			Buckets:    server.Downsample(samples, from, resolution),
+		})
+	}
+}
+
+func historyTime(v string, dflt, now time.Time) (time.Time, error) {
+	if v == "" {
+		return dflt, nil
+	}
+
+	if strings.HasPrefix(v, "-") {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCasePropertyHistory(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/property/power/history", "")
+	Equals("Not recorded", t, http.StatusNotFound, w.Code)
+
+	store := server.NewMemoryHistory(server.HISTORY_CAPACITY)
+	p.Bind("/lamp", lamp().RecordHistory(store))
+
+	from := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, s := range []server.Sample{
+		{Time: from.Add(time.Minute), Value: 1},
+		{Time: from.Add(2 * time.Minute), Value: 3},
+		{Time: from.Add(40 * time.Minute), Value: 5},
+		{Time: from.Add(2 * time.Hour), Value: 100},
+	} {
+		store.Append("power", s)
+	}
+
+	query := "?from=2026-01-01T12:00:00Z&to=2026-01-01T13:00:00Z"
+	w = serve(p, "GET", "/lamp/property/power/history"+query+"&resolution=30m", "")
+	history := &History{}
+	json.Unmarshal(w.Body.Bytes(), history)
+	Equals("Resolution", t, "30m0s", history.Resolution)
+	Equals("Buckets", t, 2, len(history.Buckets))
+	Equals("Count", t, 2, history.Buckets[0].Count)
+	Equals("Avg", t, 2.0, history.Buckets[0].Avg)
+	Equals("Min", t, 1.0, history.Buckets[0].Min)
+	Equals("Max", t, 3.0, history.Buckets[0].Max)
+	Equals("Second bucket", t, true, history.Buckets[1].Start.Equal(from.Add(30*time.Minute)))
+
+	w = serve(p, "GET", "/lamp/property/power/history"+query+"&resolution=-5m", "")
+	Equals("Invalid resolution", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "GET", "/lamp/property/power/history?from=2026-01-01T13:00:00Z&to=2026-01-01T12:00:00Z", "")
+	Equals("Reversed period", t, http.StatusBadRequest, w.Code)
+}
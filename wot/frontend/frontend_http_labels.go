@@ -0,0 +1,130 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/gorilla/mux"
+)
+
+type labels struct {
+	l      *sync.RWMutex
+	things map[string]map[string]string
+}
+
+// labelFilter matches Things by label key and value, empty value matches any Thing having the key
+type labelFilter struct {
+	key   string
+	value string
+	any   bool
+}
+
+// configureLabels reads key/value labels of Things from cfg "labels" (context path -> {key: value}),
+// labels can be replaced later by admin API
+func (p *Http) configureLabels(cfg map[string]interface{}) {
+	p.labels = &labels{
+		l:      &sync.RWMutex{},
+		things: make(map[string]map[string]string),
+	}
+
+	defs, ok := cfg["labels"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for ctxPath, def := range defs {
+		m, ok := def.(map[string]interface{})
+		if !ok {
+			panic(str.Concat("Invalid labels of ", ctxPath, ": map of strings expected"))
+		}
+
+		values := make(map[string]string, len(m))
+		for key, value := range m {
+			s, ok := value.(string)
+			if !ok {
+				panic(str.Concat("Invalid label ", key, " of ", ctxPath, ": string expected"))
+			}
+			values[key] = s
+		}
+
+		p.labels.things[ctxPath] = values
+	}
+}
+
+// registerLabelAdmin exposes labels of Things in admin API as "admin/labels", PUT replaces all labels of Thing
+func (p *Http) registerLabelAdmin() {
+	p.adminRoute("GET", "/admin/labels/{ctxPath:.+}", auth.RIGHT_READ, "labels", func(w http.ResponseWriter, r *http.Request) {
+		sendOK(w, r, p.thingLabels("/"+mux.Vars(r)["ctxPath"]))
+	})
+
+	p.adminRoute("PUT", "/admin/labels/{ctxPath:.+}", auth.RIGHT_WRITE, "labels", func(w http.ResponseWriter, r *http.Request) {
+		var values map[string]string
+		if err := readBody(r, &values); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		ctxPath := "/" + mux.Vars(r)["ctxPath"]
+
+		p.labels.l.Lock()
+		if len(values) == 0 {
+			delete(p.labels.things, ctxPath)
+		} else {
+			p.labels.things[ctxPath] = values
+		}
+		p.labels.l.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// thingLabels returns copy of labels of Thing, nil when Thing has none
+func (p *Http) thingLabels(ctxPath string) map[string]string {
+	p.labels.l.RLock()
+	defer p.labels.l.RUnlock()
+
+	values, ok := p.labels.things[ctxPath]
+	if !ok {
+		return nil
+	}
+
+	c := make(map[string]string, len(values))
+	for key, value := range values {
+		c[key] = value
+	}
+
+	return c
+}
+
+// labelFilters parses "label" query parameters, "key=value" matches label value, "key" its presence
+func labelFilters(r *http.Request) ([]labelFilter, error) {
+	params := r.URL.Query()["label"]
+	filters := make([]labelFilter, 0, len(params))
+
+	for _, param := range params {
+		key, value, found := strings.Cut(param, "=")
+		if key == "" {
+			return nil, errors.New(str.Concat("Invalid label filter: ", param))
+		}
+
+		filters = append(filters, labelFilter{key: key, value: value, any: !found})
+	}
+
+	return filters, nil
+}
+
+// matchLabels reports whether labels satisfy all filters
+func matchLabels(values map[string]string, filters []labelFilter) bool {
+	for _, f := range filters {
+		value, ok := values[f.key]
+		if !ok || (!f.any && value != f.value) {
+			return false
+		}
+	}
+
+	return true
+}
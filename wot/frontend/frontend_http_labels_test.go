@@ -0,0 +1,60 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func labeled(t *testing.T, p *Http, target string) []string {
+	w := serve(p, "GET", target, "")
+	Equals(target, t, http.StatusOK, w.Code)
+
+	var things []*ThingInfo
+	json.Unmarshal(w.Body.Bytes(), &things)
+
+	paths := make([]string, 0, len(things))
+	for _, info := range things {
+		paths = append(paths, info.CtxPath)
+	}
+
+	return paths
+}
+
+func TestCaseLabelFilter(t *testing.T) {
+	p := testHTTP(map[string]interface{}{
+		"labels": map[string]interface{}{
+			"/lamp-1": map[string]interface{}{"site": "plant42", "floor": "1"},
+			"/lamp-2": map[string]interface{}{"site": "plant7"},
+		},
+	})
+	p.Bind("/lamp-1", lamp())
+	p.Bind("/lamp-2", lamp())
+	p.Bind("/lamp-3", lamp())
+
+	Equals("Unfiltered", t, 3, len(labeled(t, p, "/things")))
+	Equals("Value", t, "/lamp-1", strings.Join(labeled(t, p, "/things?label=site=plant42"), " "))
+	Equals("Presence", t, "/lamp-1 /lamp-2", strings.Join(labeled(t, p, "/things?label=site"), " "))
+	Equals("All filters", t, "", strings.Join(labeled(t, p, "/things?label=site&label=floor=2"), " "))
+
+	w := serve(p, "GET", "/things?label==plant42", "")
+	Equals("Invalid filter", t, http.StatusBadRequest, w.Code)
+}
+
+func TestCaseLabelAdmin(t *testing.T) {
+	p := adminHTTP()
+
+	w := serve(p, "PUT", "/admin/labels/lamp", `{"site": "plant42"}`, "X-API-Key", "k-viewer")
+	Equals("Viewer forbidden", t, http.StatusForbidden, w.Code)
+
+	w = serve(p, "PUT", "/admin/labels/lamp", `{"site": "plant42"}`, "X-API-Key", "k-admin")
+	Equals("Labels replaced", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "GET", "/admin/labels/lamp", "", "X-API-Key", "k-viewer")
+	var values map[string]string
+	json.Unmarshal(w.Body.Bytes(), &values)
+	Equals("Labels", t, "plant42", values["site"])
+
+	Equals("Filtered", t, "/lamp", strings.Join(labeled(t, p, "/things?label=site=plant42"), " "))
+}
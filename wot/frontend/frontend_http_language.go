@@ -0,0 +1,64 @@
+package frontend
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+// acceptedLanguages returns language tags of Accept-Language header by descending quality, refused
+// languages (q=0) and wildcard are left out
+func acceptedLanguages(r *http.Request) []string {
+	type accepted struct {
+		tag     string
+		quality float64
+	}
+
+	languages := make([]accepted, 0)
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		params := strings.Split(strings.Replace(part, " ", "", -1), ";")
+		if params[0] == "" || params[0] == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			languages = append(languages, accepted{params[0], quality})
+		}
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	tags := make([]string, 0, len(languages))
+	for _, language := range languages {
+		tags = append(tags, language.tag)
+	}
+
+	return tags
+}
+
+// sendDescription sends description localized to language negotiated by Accept-Language, language
+// is announced by Content-Language
+func sendDescription(w http.ResponseWriter, r *http.Request, td *model.ThingDescription) {
+	localized, language := td.Localized(acceptedLanguages(r))
+
+	w.Header().Add("Vary", "Accept-Language")
+	if language != "" {
+		w.Header().Set("Content-Language", language)
+	}
+
+	sendTagged(w, r, localized)
+}
@@ -0,0 +1,39 @@
+package frontend
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseDescriptionLanguage(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := lamp()
+	td := s.GetDescription()
+	td.Title = "Lamp"
+	td.Titles = map[string]string{"de": "Lampe", "cs": "Lampa"}
+	td.Properties[0].Titles = map[string]string{"de": "Eingeschaltet"}
+	p.Bind("/lamp", s)
+
+	w := serve(p, "GET", "/lamp/description", "", "Accept-Language", "fr;q=1, de-AT;q=0.8, cs;q=0.5")
+	localized := &model.ThingDescription{}
+	json.Unmarshal(w.Body.Bytes(), localized)
+	Equals("Negotiated", t, "de", w.Header().Get("Content-Language"))
+	Equals("Title", t, "Lampe", localized.Title)
+	Equals("Interaction title", t, "Eingeschaltet", localized.Properties[0].Title)
+	Equals("Translations kept", t, "Lampa", localized.Titles["cs"])
+	Equals("Vary", t, "Accept-Language", w.Header().Get("Vary"))
+
+	w = serve(p, "GET", "/lamp/description", "", "Accept-Language", "de;q=0, fr")
+	localized = &model.ThingDescription{}
+	json.Unmarshal(w.Body.Bytes(), localized)
+	Equals("No translation", t, "", w.Header().Get("Content-Language"))
+	Equals("Default title", t, "Lamp", localized.Title)
+
+	w = serve(p, "GET", "/.well-known/wot", "", "Accept-Language", "cs")
+	var tds []*model.ThingDescription
+	json.Unmarshal(w.Body.Bytes(), &tds)
+	Equals("Discovery localized", t, "Lampa", tds[0].Title)
+}
@@ -0,0 +1,39 @@
+package frontend
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// HEADER_STALE_WARNING flags response answered by last known value (RFC 7234 warn-code 110)
+const HEADER_STALE_WARNING = `110 - "Response is Stale"`
+
+// sendLastKnown answers read of property declaring LastKnown, failed by offline Thing or backend error,
+// by its last known value. Response carries Warning, Age and Last-Modified of the value. False is
+// returned when fallback does not apply and failure has to be sent.
+func sendLastKnown(w http.ResponseWriter, r *http.Request, s *server.WotServer, prop model.Property, failure interface{}) bool {
+	if !prop.LastKnown {
+		return false
+	}
+
+	if _, backendErr := failure.(error); !backendErr && !unavailable(failure) {
+		return false
+	}
+
+	known, ok := s.LastKnown(prop.Name)
+	if !ok {
+		return false
+	}
+
+	w.Header().Set("Warning", HEADER_STALE_WARNING)
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(known.Time).Seconds())))
+	w.Header().Set("Last-Modified", known.Time.UTC().Format(http.TimeFormat))
+	w.Header().Add("Access-Control-Expose-Headers", "Warning, Age")
+	sendTagged(w, r, known.Value)
+
+	return true
+}
@@ -0,0 +1,51 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseLastKnownValue(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "meter",
+		Properties: []model.Property{
+			{Name: "energy", ValueType: model.ValueType{Type: "number"}, Hrefs: []string{"property/energy"}, LastKnown: true},
+			{Name: "voltage", ValueType: model.ValueType{Type: "number"}, Hrefs: []string{"property/voltage"}},
+		},
+	})
+	failing := &atomic.Bool{}
+	read := func(value float64) func() interface{} {
+		return func() interface{} {
+			if failing.Load() {
+				return errors.New("backend disconnected")
+			}
+			return value
+		}
+	}
+	s.OnGetProperty("energy", read(42.5))
+	s.OnGetProperty("voltage", read(230.0))
+	p.Bind("/meter", s)
+
+	w := serve(p, "GET", "/meter/property/energy", "")
+	Equals("Fresh", t, "", w.Header().Get("Warning"))
+
+	failing.Store(true)
+
+	w = serve(p, "GET", "/meter/property/energy", "")
+	Equals("Stale answered", t, http.StatusOK, w.Code)
+	Equals("Stale value", t, "42.5", strings.TrimSpace(w.Body.String()))
+	Equals("Flagged", t, HEADER_STALE_WARNING, w.Header().Get("Warning"))
+	Equals("Age", t, "0", w.Header().Get("Age"))
+
+	w = serve(p, "GET", "/meter/property/voltage", "")
+	Equals("No fallback", t, http.StatusBadRequest, w.Code)
+	Equals("Not flagged", t, "", w.Header().Get("Warning"))
+}
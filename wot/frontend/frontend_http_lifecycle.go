@@ -0,0 +1,158 @@
+package frontend
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// ThingEvent is lifecycle meta-event published when Thing is bound (created), rebound (updated)
+// or unbound (removed) on frontend
+type ThingEvent struct {
+	Type    string    `json:"type"`
+	CtxPath string    `json:"ctxPath"`
+	Name    string    `json:"name,omitempty"`
+	Time    time.Time `json:"time"`
+	tenant  *tenant
+}
+
+func (p *Http) publishLifecycle(eventType string, t *tenant, ctxPath, name string) {
+	p.lifecycle.Publish(&ThingEvent{
+		Type:    eventType,
+		CtxPath: ctxPath,
+		Name:    name,
+		Time:    time.Now(),
+		tenant:  t,
+	})
+}
+
+// registerLifecycle exposes tenant Things at {tenant}/things and stream of their lifecycle events
+// at {tenant}/things/ws, so consumers can stay in sync without polling. Listing is filtered by
+// "label" parameters, e.g. ?label=site=plant42, and semantic "type" parameters, e.g.
+// ?type=iot:TemperatureSensor, all of them have to match.
+func (p *Http) registerLifecycle(t *tenant) {
+	root := "/things"
+	if t != p.defaultTenant {
+		root = tenantPath(t.name, root)
+	}
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: root,
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			filters, err := labelFilters(r)
+			if err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+			types := r.URL.Query()["type"]
+
+			things := make([]*ThingInfo, 0)
+
+			p.l.RLock()
+			for ctxPath, s := range t.things {
+				td := s.GetDescription()
+				if labels := p.thingLabels(ctxPath); matchLabels(labels, filters) && matchTypes(td, types) {
+					things = append(things, &ThingInfo{CtxPath: ctxPath, Tenant: t.name, Name: s.Name(), Types: []string(td.AT_Type), Labels: labels})
+				}
+			}
+			p.l.RUnlock()
+
+			sort.Slice(things, func(i, j int) bool {
+				return things[i].CtxPath < things[j].CtxPath
+			})
+
+			sendOK(w, r, things)
+		},
+	})
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: str.Concat(root, "/ws"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			p.lifecycleWSHandler(t, w, r)
+		},
+	})
+}
+
+func (p *Http) lifecycleWSHandler(t *tenant, w http.ResponseWriter, r *http.Request) {
+	p.fanOutWS(w, r, p.lifecycle, func(e interface{}) bool {
+		return e.(*ThingEvent).tenant == t
+	})
+}
+
+// fanOutWS streams events published to fo and accepted by filter to WebSocket client, client not keeping
+// up with lossless fo is disconnected once it is removed from fo
+func (p *Http) fanOutWS(w http.ResponseWriter, r *http.Request, fo *async.FanOut, accept func(interface{}) bool) {
+	if !p.limits.wsClients.acquire("") {
+		sendCode(w, r, http.StatusTooManyRequests, "Too many WebSocket clients.")
+		return
+	}
+	defer p.limits.wsClients.release("")
+
+	conn, err := p.upgradeWS(w, r)
+
+	if err != nil {
+		log.Println("Error creating WebSocket at: ", err)
+		return
+	}
+	defer conn.Close()
+
+	events := make(chan interface{})
+	id := fo.AddSubscriber(events)
+	defer fo.RemoveSubscriber(id)
+	removed := fo.Removed(id)
+
+	//event stream is write only, reading detects closed connection
+	closed := make(chan struct{})
+	p.keepAlive(conn, closed)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case e := <-events:
+			if accept(e) {
+				p.writeDeadline(conn)
+				if err = writeData(conn, r, e); err != nil {
+					return
+				}
+			}
+		case <-closed:
+			return
+		case <-removed:
+			log.Println("Slow WebSocket client disconnected: ", r.URL.Path)
+			return
+		}
+	}
+}
+
+// matchTypes reports whether TD declares all semantic types
+func matchTypes(td *model.ThingDescription, types []string) bool {
+	for _, t := range types {
+		if !td.HasType(t) {
+			return false
+		}
+	}
+
+	return true
+}
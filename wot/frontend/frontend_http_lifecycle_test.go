@@ -0,0 +1,62 @@
+package frontend
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseLifecycle(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp-1", lamp())
+
+	w := serve(p, "GET", "/things", "")
+	var things []ThingInfo
+	json.Unmarshal(w.Body.Bytes(), &things)
+	Equals("Things", t, 1, len(things))
+	Equals("Thing", t, "/lamp-1", things[0].CtxPath)
+
+	conn, closeWS := dial(t, p, "/things/ws", nil)
+	defer closeWS()
+
+	//subscriber is registered after upgrade, events are published until it receives one
+	bound := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-bound:
+				return
+			case <-time.After(20 * time.Millisecond):
+				p.Bind("/lamp-2", lamp())
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var e ThingEvent
+	err := conn.ReadJSON(&e)
+	close(bound)
+	Equals("Read", t, nil, err)
+	Equals("Event type", t, true, e.Type == server.THING_CREATED || e.Type == server.THING_UPDATED)
+	Equals("Event Thing", t, "/lamp-2", e.CtxPath)
+}
+
+func TestCaseTypeSearch(t *testing.T) {
+	p := testHTTP(nil)
+
+	sensor := lamp()
+	sensor.GetDescription().AT_Context = model.Context{"http://w3c.github.io/wot/w3c-wot-td-context.jsonld",
+		map[string]interface{}{"iot": "http://iotschema.org/"}}
+	sensor.GetDescription().AT_Type = model.Types{"Thing", "iot:TemperatureSensor"}
+	p.Bind("/sensor", sensor)
+	p.Bind("/lamp", lamp())
+
+	Equals("Compact IRI", t, "/sensor", strings.Join(labeled(t, p, "/things?type=iot:TemperatureSensor"), " "))
+	Equals("Expanded IRI", t, "/sensor", strings.Join(labeled(t, p, "/things?type=http://iotschema.org/TemperatureSensor"), " "))
+	Equals("All types", t, "", strings.Join(labeled(t, p, "/things?type=Thing&type=iot:Light"), " "))
+	Equals("Labels and types", t, "", strings.Join(labeled(t, p, "/things?type=Thing&label=site"), " "))
+}
@@ -0,0 +1,164 @@
+package frontend
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MAX_BODY limits bodies decoded in memory, e.g. property values and action input
+	MAX_BODY = 1 << 20
+	// MAX_STREAM_BODY limits streamed action input, e.g. firmware image upload
+	MAX_STREAM_BODY = 256 << 20
+	// READ_TIMEOUT disconnects clients sending request slowly, streamed uploads included
+	READ_TIMEOUT = 10 * time.Minute
+	// READ_HEADER_TIMEOUT disconnects clients sending request headers slowly
+	READ_HEADER_TIMEOUT = 10 * time.Second
+	IDLE_TIMEOUT        = 2 * time.Minute
+)
+
+// limits caps concurrent HTTP requests, WebSocket clients and event subscriptions of binding,
+// in total and per Thing, so small devices are not overwhelmed. Zero cap is unlimited.
+type limits struct {
+	requests      *gauge
+	wsClients     *gauge
+	subscriptions *gauge
+	// body sizes of property writes, decoded action input and streamed action input, zero is unlimited
+	propertyBody int64
+	actionBody   int64
+	streamBody   int64
+	// server timeouts, streamed uploads need read timeout long enough for the whole body
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+}
+
+// gauge counts concurrent uses of resource in total and per Thing context path
+type gauge struct {
+	max      int
+	thingMax int
+	l        *sync.Mutex
+	total    int
+	things   map[string]int
+}
+
+func newGauge(max, thingMax int) *gauge {
+	return &gauge{
+		max:      max,
+		thingMax: thingMax,
+		l:        &sync.Mutex{},
+		things:   make(map[string]int),
+	}
+}
+
+// acquire takes one use of Thing resource, empty ctxPath counts to total only
+func (g *gauge) acquire(ctxPath string) bool {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	if g.max > 0 && g.total >= g.max {
+		return false
+	}
+
+	if ctxPath != "" && g.thingMax > 0 && g.things[ctxPath] >= g.thingMax {
+		return false
+	}
+
+	g.total++
+	if ctxPath != "" {
+		g.things[ctxPath]++
+	}
+
+	return true
+}
+
+func (g *gauge) release(ctxPath string) {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	g.total--
+	if ctxPath == "" {
+		return
+	}
+
+	if g.things[ctxPath]--; g.things[ctxPath] <= 0 {
+		delete(g.things, ctxPath)
+	}
+}
+
+// configureLimits reads "limits" with caps "requests", "wsClients" and "subscriptions" and their per Thing
+// variants "thingRequests", "thingWSClients" and "thingSubscriptions", body sizes "propertyBody", "actionBody"
+// and "streamBody" in bytes and server timeouts "readTimeout", "readHeaderTimeout", "writeTimeout" and
+// "idleTimeout" (e.g. "30s")
+func (p *Http) configureLimits(cfg map[string]interface{}) {
+	caps, _ := cfg["limits"].(map[string]interface{})
+	limit := func(key string, def int) int {
+		if n, ok := caps[key].(int); ok {
+			return n
+		}
+		return def
+	}
+	timeout := func(key string, def time.Duration) time.Duration {
+		if _, ok := caps[key]; ok {
+			return durationParam(caps, key)
+		}
+		return def
+	}
+
+	p.limits = &limits{
+		requests:          newGauge(limit("requests", 0), limit("thingRequests", 0)),
+		wsClients:         newGauge(limit("wsClients", 0), limit("thingWSClients", 0)),
+		subscriptions:     newGauge(limit("subscriptions", 0), limit("thingSubscriptions", 0)),
+		propertyBody:      int64(limit("propertyBody", MAX_BODY)),
+		actionBody:        int64(limit("actionBody", MAX_BODY)),
+		streamBody:        int64(limit("streamBody", MAX_STREAM_BODY)),
+		readTimeout:       timeout("readTimeout", READ_TIMEOUT),
+		readHeaderTimeout: timeout("readHeaderTimeout", READ_HEADER_TIMEOUT),
+		writeTimeout:      timeout("writeTimeout", 0),
+		idleTimeout:       timeout("idleTimeout", IDLE_TIMEOUT),
+	}
+}
+
+// limitBody makes reading body longer than max fail, body is then rejected with 413
+func limitBody(w http.ResponseWriter, r *http.Request, max int64) {
+	if max > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+	}
+}
+
+// limitActionBody limits streamed action input by stream limit and decoded input by action limit
+func (p *Http) limitActionBody(w http.ResponseWriter, r *http.Request) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if contentType == "multipart/form-data" || contentType == CONTENT_TYPE_OCTET_STREAM {
+		limitBody(w, r, p.limits.streamBody)
+		return
+	}
+
+	limitBody(w, r, p.limits.actionBody)
+}
+
+func tooLarge(err error) bool {
+	var maxBytes *http.MaxBytesError
+	return errors.As(err, &maxBytes)
+}
+
+// admitRequest takes request slot, WebSocket upgrades are limited as WebSocket clients instead
+func (p *Http) admitRequest(w http.ResponseWriter, r *http.Request, ctxPath string) (func(), bool) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return func() {}, true
+	}
+
+	if !p.limits.requests.acquire(ctxPath) {
+		w.Header().Set("Retry-After", "1")
+		sendCode(w, r, http.StatusServiceUnavailable, "Too many concurrent requests.")
+		return nil, false
+	}
+
+	return func() { p.limits.requests.release(ctxPath) }, true
+}
@@ -0,0 +1,136 @@
+package frontend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseSubscriptionLimit(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"limits": map[string]interface{}{"thingSubscriptions": 1}})
+	p.Bind("/lamp", lamp())
+	p.Bind("/thermostat", thermostat())
+
+	ws := subscribe(t, p, "/lamp/event/property-change")
+
+	w := serve(p, "POST", "/lamp/event/property-change", "")
+	Equals("Over Thing limit", t, http.StatusTooManyRequests, w.Code)
+
+	subscribe(t, p, "/thermostat/event/property-change")
+
+	w = serve(p, "DELETE", ws, "")
+	Equals("Unsubscribed", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "DELETE", ws, "")
+	Equals("Unsubscribed twice", t, http.StatusNotFound, w.Code)
+
+	subscribe(t, p, "/lamp/event/property-change")
+}
+
+func TestCaseRequestLimit(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"limits": map[string]interface{}{"thingRequests": 1}})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	started, release := make(chan bool), make(chan bool)
+	s.OnGetProperty("power", func() interface{} {
+		started <- true
+		<-release
+		return 7.5
+	})
+
+	done := make(chan int)
+	go func() { done <- serve(p, "GET", "/lamp/property/power", "").Code }()
+	<-started
+
+	w := serve(p, "GET", "/lamp/property/on", "")
+	Equals("Over Thing limit", t, http.StatusServiceUnavailable, w.Code)
+	Equals("Retry", t, "1", w.Header().Get("Retry-After"))
+
+	release <- true
+	Equals("Admitted", t, http.StatusOK, <-done)
+
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Released", t, http.StatusOK, w.Code)
+}
+
+func TestCaseBodyLimits(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"limits": map[string]interface{}{
+		"propertyBody": 16, "actionBody": 16, "streamBody": 32, "readTimeout": "30s",
+	}})
+	p.Bind("/lamp", lamp())
+
+	//streamed input over limit fails reading by action
+	sink := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "sink",
+		Actions: []model.Action{{Name: "store", Hrefs: []string{"action/store"}}},
+	})
+	readErr := make(chan error, 1)
+	sink.OnInvokeAction("store", func(input interface{}, ph async.ProgressHandler) interface{} {
+		_, err := ioutil.ReadAll(input.(*server.Stream).Reader)
+		readErr <- err
+		return nil
+	})
+	p.Bind("/sink", sink)
+
+	padded := `true` + strings.Repeat(" ", 32)
+	w := serve(p, "PUT", "/lamp/property/on", padded)
+	Equals("Property body", t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/on", "true")
+	Equals("Property within limit", t, http.StatusOK, w.Code)
+
+	w = serve(p, "PUT", "/lamp/property/on/cas", `{"expected": false, "value": true}`)
+	Equals("CAS body", t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = serve(p, "POST", "/lamp/action/toggle", `"`+strings.Repeat("x", 32)+`"`)
+	Equals("Action body", t, http.StatusRequestEntityTooLarge, w.Code)
+
+	serve(p, "POST", "/sink/action/store", strings.Repeat("x", 64), "Content-Type", CONTENT_TYPE_OCTET_STREAM)
+	Equals("Streamed input", t, true, tooLarge(<-readErr))
+
+	//form fields buffered in memory are limited by action body
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, field := range []string{"a", "b", "c"} {
+		mw.WriteField(field, "12345678")
+	}
+	mw.Close()
+	w = serve(p, "POST", "/sink/action/store", body.String(), "Content-Type", mw.FormDataContentType())
+	Equals("Form fields", t, http.StatusRequestEntityTooLarge, w.Code)
+
+	srv := p.server("")
+	Equals("Read timeout", t, 30*time.Second, srv.ReadTimeout)
+	Equals("Read header timeout", t, READ_HEADER_TIMEOUT, srv.ReadHeaderTimeout)
+}
+
+func TestCaseRaisedBodyLimits(t *testing.T) {
+	padded := `true` + strings.Repeat(" ", 2*MAX_BODY)
+
+	p := testHTTP(map[string]interface{}{"limits": map[string]interface{}{
+		"propertyBody": 4 * MAX_BODY, "actionBody": 0,
+	}})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "PUT", "/lamp/property/on", padded)
+	Equals("Raised property limit", t, http.StatusOK, w.Code)
+
+	w = serve(p, "POST", "/lamp/action/toggle", padded)
+	Equals("Unlimited action body", t, http.StatusOK, w.Code)
+
+	p = testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w = serve(p, "PUT", "/lamp/property/on", padded)
+	Equals("Default property limit", t, http.StatusRequestEntityTooLarge, w.Code)
+	Equals("Default stream limit", t, int64(MAX_STREAM_BODY), p.limits.streamBody)
+	Equals("Default read timeout", t, READ_TIMEOUT, p.server("").ReadTimeout)
+}
@@ -0,0 +1,121 @@
+package frontend
+
+import (
+	"net"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+)
+
+// listener is address HTTP binding listens on, e.g. "[::1]:8080" or "192.168.1.10:8080", and host
+// advertised in Thing Description URIs of Things reachable through it
+type listener struct {
+	addr     string
+	hostname string
+}
+
+// configureListeners reads "listen" with addresses as strings, or objects with "address" and optional
+// "hostname" advertised instead of address host. Without "listen" binding listens on all interfaces
+// on "port" and advertises "hostname".
+func (p *Http) configureListeners(cfg map[string]interface{}) {
+	p.listeners = []*listener{{
+		addr:     str.Concat(":", strconv.Itoa(p.port)),
+		hostname: p.hostname,
+	}}
+
+	entries, ok := cfg["listen"].([]interface{})
+	if !ok {
+		return
+	}
+
+	p.listeners = make([]*listener, 0, len(entries))
+
+	for _, entry := range entries {
+		var l *listener
+
+		switch e := entry.(type) {
+		case string:
+			l = &listener{addr: e}
+		case map[string]interface{}:
+			addr, _ := e["address"].(string)
+			hostname, _ := e["hostname"].(string)
+			l = &listener{addr: addr, hostname: hostname}
+		default:
+			panic("HTTP: listen entry must be address or object with address")
+		}
+
+		host, _, err := net.SplitHostPort(l.addr)
+		if err != nil {
+			panic(str.Concat("HTTP: invalid listen address ", l.addr, " -> ", err))
+		}
+
+		if l.hostname == "" {
+			l.hostname = host
+		}
+
+		//wildcard address is reachable by configured hostname
+		if ip := net.ParseIP(l.hostname); l.hostname == "" || ip != nil && ip.IsUnspecified() {
+			l.hostname = p.hostname
+		}
+
+		p.listeners = append(p.listeners, l)
+	}
+
+	if len(p.listeners) == 0 {
+		panic("HTTP: listen requires at least one address")
+	}
+}
+
+// listen opens all configured listeners before serving, so misconfigured address fails the start
+func (p *Http) listen() ([]net.Listener, error) {
+	ls := make([]net.Listener, 0, len(p.listeners))
+
+	for _, l := range p.listeners {
+		nl, err := net.Listen("tcp", l.addr)
+		if err != nil {
+			for _, opened := range ls {
+				opened.Close()
+			}
+			return nil, err
+		}
+
+		log.Info("HTTP: listening on ", nl.Addr())
+		ls = append(ls, nl)
+	}
+
+	return ls, nil
+}
+
+// baseURLs returns root URL advertised for every listener, IPv6 hosts are enclosed in brackets. Roots
+// of mounted binding replace them.
+func (p *Http) baseURLs() []string {
+	urls := make([]string, 0, len(p.listeners))
+
+	if len(p.mount.baseURLs) > 0 {
+		for _, base := range p.mount.baseURLs {
+			urls = append(urls, str.Concat(base, p.mount.prefix))
+		}
+		return urls
+	}
+
+	for _, l := range p.listeners {
+		_, port, _ := net.SplitHostPort(l.addr)
+		urls = append(urls, str.Concat(p.scheme(), "://", net.JoinHostPort(l.hostname, port), p.mount.prefix))
+	}
+
+	return urls
+}
+
+// advertise returns absolute hrefs of interaction paths for every listener
+func (p *Http) advertise(ctxPath string, paths []string) []string {
+	abs := make([]string, 0, len(p.listeners)*len(paths))
+
+	for _, path := range paths {
+		for _, base := range p.baseURLs() {
+			abs = append(abs, str.Concat(base, ctxPath, "/", escapePath(path)))
+		}
+	}
+
+	return abs
+}
@@ -0,0 +1,37 @@
+package frontend
+
+import "testing"
+
+func TestCaseAdvertisedListeners(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"listen": []interface{}{
+		"[::1]:8081",
+		map[string]interface{}{"address": "0.0.0.0:8082"},
+		map[string]interface{}{"address": "127.0.0.1:8083", "hostname": "gw.local"},
+	}})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	forms := s.Forms(p.binding)
+	Equals("URIs", t, 3, len(forms.URIs))
+	Equals("IPv6", t, "http://[::1]:8081/lamp", forms.URIs[0])
+	Equals("Wildcard", t, "http://localhost:8082/lamp", forms.URIs[1])
+	Equals("Hostname", t, "http://gw.local:8083/lamp", forms.URIs[2])
+
+	Equals("Property hrefs", t, 3, len(forms.Properties["power"]))
+	Equals("Property href", t, "http://gw.local:8083/lamp/property/power", forms.Properties["power"][2])
+}
+
+func TestCaseListen(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"listen": []interface{}{"127.0.0.1:0", "127.0.0.1:0"}})
+
+	ls, err := p.listen()
+	Equals("Listening", t, nil, err)
+	Equals("Listeners", t, 2, len(ls))
+	for _, l := range ls {
+		l.Close()
+	}
+
+	p = testHTTP(map[string]interface{}{"listen": []interface{}{"127.0.0.1:0", "192.0.2.1:8080"}})
+	_, err = p.listen()
+	Equals("Unavailable address", t, true, err != nil)
+}
@@ -0,0 +1,70 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseUnknownSubscriptionWS(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/event/property-change/ws/gone", "")
+	Equals("Lost subscription", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseListenerResubscribe(t *testing.T) {
+	p := adminHTTP()
+	s := lamp()
+	p.Bind("/lamp-2", s)
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	states := make(chan proxy.ConnState, 16)
+	c, _ := proxy.NewHttpClient(srv.URL + "/lamp-2")
+	c.WithReconnectBackoff(10*time.Millisecond, 50*time.Millisecond).
+		OnConnectionState(func(eventName string, state proxy.ConnState, err error) {
+			states <- state
+		})
+
+	events := make(chan *server.Event, 16)
+	sub, err := c.AddListener("property-change", func(e *server.Event) {
+		events <- e
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	Equals("Connected", t, proxy.CONN_CONNECTED, <-states)
+
+	//subscription terminated by server is created again
+	var subs []*server.SubscriptionInfo
+	json.Unmarshal(serve(p, "GET", "/admin/subscriptions", "", "X-API-Key", "k-viewer").Body.Bytes(), &subs)
+	Equals("Subscriptions", t, 1, len(subs))
+	serve(p, "DELETE", "/admin/subscriptions/"+subs[0].ID, "", "X-API-Key", "k-admin")
+
+	for reconnected := false; !reconnected; {
+		select {
+		case state := <-states:
+			reconnected = state == proxy.CONN_CONNECTED
+		case <-time.After(5 * time.Second):
+			t.Fatal("listener not reconnected")
+		}
+	}
+
+	s.EmitPropertyChange("on", true)
+
+	select {
+	case e := <-events:
+		Equals("Event", t, "property-change", e.Event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not received after resubscribe")
+	}
+}
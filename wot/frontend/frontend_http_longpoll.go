@@ -0,0 +1,56 @@
+package frontend
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/conas/tno2/wot/server"
+)
+
+// MAX_TASK_WAIT limits long-poll of action task, longer waits are shortened
+const MAX_TASK_WAIT = 60 * time.Second
+
+// awaitTask waits until task taskid completes, wait elapses, client goes away or Thing is unbound, and
+// returns the task state at that time. Wait is shortened to fit into server write timeout.
+func (p *Http) awaitTask(r *http.Request, t *tenant, taskid string, slot *atomic.Value, wait time.Duration) interface{} {
+	if wait > MAX_TASK_WAIT {
+		wait = MAX_TASK_WAIT
+	}
+	if p.limits.writeTimeout > 0 && wait > p.limits.writeTimeout-time.Second {
+		wait = p.limits.writeTimeout - time.Second
+	}
+
+	clients, ok := t.subscribers.Clients(taskid)
+	if !ok || wait <= 0 {
+		return slot.Load()
+	}
+
+	//subscribed before state is checked, so completion in between is not missed
+	updates := make(chan interface{})
+	id := clients.AddSubscriber(updates)
+	defer clients.RemoveSubscriber(id)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	done := t.subscribers.Done(taskid)
+
+	for !completed(slot.Load()) {
+		select {
+		case <-updates:
+		case <-done:
+			return slot.Load()
+		case <-timer.C:
+			return slot.Load()
+		case <-r.Context().Done():
+			return slot.Load()
+		}
+	}
+
+	return slot.Load()
+}
+
+func completed(state interface{}) bool {
+	status, ok := state.(*server.TaskStatus)
+	return ok && (status.Status == server.TASK_DONE || status.Status == server.TASK_FAILED)
+}
@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseTaskLongPoll(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "gate",
+		Actions: []model.Action{{Name: "open", Hrefs: []string{"action/open"}}},
+	})
+	release := make(chan bool)
+	s.OnInvokeAction("open", func(input interface{}, ph async.ProgressHandler) interface{} {
+		<-release
+		return "opened"
+	})
+	p.Bind("/gate", s)
+
+	task := taskPath(t, serve(p, "POST", "/gate/action/open", "null"))
+
+	started := time.Now()
+	status := &server.TaskStatus{}
+	json.Unmarshal(serve(p, "GET", task+"?wait=50ms", "").Body.Bytes(), status)
+	Equals("Wait elapsed", t, true, time.Since(started) >= 50*time.Millisecond)
+	Equals("Pending", t, false, completed(status))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		release <- true
+	}()
+
+	w := serve(p, "GET", task+"?wait=5s", "")
+	status = &server.TaskStatus{}
+	json.Unmarshal(w.Body.Bytes(), status)
+	Equals("Completed before wait elapsed", t, true, time.Since(started) < 5*time.Second)
+	Equals("Done", t, server.TASK_DONE, status.Status)
+
+	w = serve(p, "GET", task+"?wait=soon", "")
+	Equals("Invalid wait", t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,270 @@
+package frontend
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+)
+
+// Middleware wraps handler of HTTP binding, it is signature of negroni/chi style middlewares so they
+// plug in as they are
+type Middleware func(http.Handler) http.Handler
+
+// MiddlewareFrontend is implemented by frontends accepting HTTP middlewares
+type MiddlewareFrontend interface {
+	UseHTTP(mw ...Middleware)
+}
+
+// middlewares is ordered chain of middlewares, the first one is the outermost. Built handler is cached
+// until chain or global chain changes.
+type middlewares struct {
+	l          *sync.Mutex
+	chain      []Middleware
+	handler    http.Handler
+	generation uint64
+}
+
+var globalMiddlewares = &middlewares{l: &sync.Mutex{}}
+
+// globalGeneration changes with every global middleware, so bindings rebuild their handlers
+var globalGeneration uint64 = 1
+
+// UseHTTP appends middlewares applied by all HTTP bindings, they wrap middlewares of binding
+func UseHTTP(mw ...Middleware) {
+	globalMiddlewares.l.Lock()
+	globalMiddlewares.chain = append(globalMiddlewares.chain, mw...)
+	globalMiddlewares.l.Unlock()
+
+	atomic.AddUint64(&globalGeneration, 1)
+}
+
+// UseHTTP appends middlewares of binding. Middlewares see every request of binding with request ID
+// assigned, before it is routed to Thing, and panics of them are recovered as of handlers.
+func (p *Http) UseHTTP(mw ...Middleware) {
+	p.middlewares.l.Lock()
+	p.middlewares.chain = append(p.middlewares.chain, mw...)
+	p.middlewares.handler = nil
+	p.middlewares.l.Unlock()
+}
+
+// configureMiddlewares reads "middleware", list of built-in middlewares "compress" and "log" or
+// Middleware values
+func (p *Http) configureMiddlewares(cfg map[string]interface{}) {
+	p.middlewares = &middlewares{l: &sync.Mutex{}}
+
+	mws, ok := cfg["middleware"].([]interface{})
+	if !ok {
+		if _, set := cfg["middleware"]; set {
+			panic("Invalid middleware: list expected")
+		}
+		return
+	}
+
+	for _, mw := range mws {
+		switch mw := mw.(type) {
+		case Middleware:
+			p.UseHTTP(mw)
+		case func(http.Handler) http.Handler:
+			p.UseHTTP(mw)
+		case string:
+			switch mw {
+			case "compress":
+				p.UseHTTP(CompressHTTP)
+			case "log":
+				p.UseHTTP(LogHTTP)
+			default:
+				panic(str.Concat("Invalid middleware: ", mw))
+			}
+		default:
+			panic(str.Concat("Invalid middleware: ", mw))
+		}
+	}
+}
+
+// wrap returns dispatch of binding wrapped by global middlewares and middlewares of binding
+func (m *middlewares) wrap(dispatch http.Handler) http.Handler {
+	generation := atomic.LoadUint64(&globalGeneration)
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.handler != nil && m.generation == generation {
+		return m.handler
+	}
+
+	globalMiddlewares.l.Lock()
+	chain := append(append([]Middleware{}, globalMiddlewares.chain...), m.chain...)
+	globalMiddlewares.l.Unlock()
+
+	h := dispatch
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+	m.handler, m.generation = h, generation
+
+	return h
+}
+
+// ----- Built-in middlewares
+
+// CompressHTTP gzips responses of clients accepting gzip. WebSocket upgrades and event streams are passed
+// through, so events are not delayed by compression buffer.
+func CompressHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if r.Method == "HEAD" || !acceptsGzip(r) || r.Header.Get("Upgrade") != "" || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipWriter{ResponseWriter: w}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		//"gzip;q=0" refuses gzip
+		params := strings.Split(strings.Replace(coding, " ", "", -1), ";")
+		if params[0] == "gzip" && (len(params) == 1 || params[1] != "q=0") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gzipWriter compresses body unless handler encoded it already or response has no body
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (gw *gzipWriter) WriteHeader(code int) {
+	if gw.wroteHeader {
+		return
+	}
+	gw.wroteHeader = true
+
+	h := gw.Header()
+	if h.Get("Content-Encoding") == "" && h.Get("Content-Range") == "" && code != http.StatusNoContent && code != http.StatusNotModified {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
+	}
+
+	gw.ResponseWriter.WriteHeader(code)
+}
+
+func (gw *gzipWriter) Write(p []byte) (int, error) {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+
+	if gw.gz == nil {
+		return gw.ResponseWriter.Write(p)
+	}
+
+	return gw.gz.Write(p)
+}
+
+// Flush passes compressed data written so far to client, streamed properties stay streamed
+func (gw *gzipWriter) Flush() {
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (gw *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := gw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+
+	return nil, nil, http.ErrNotSupported
+}
+
+// Push passes server push to HTTP/2 connection, pushed responses are compressed by their own requests
+func (gw *gzipWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := gw.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+func (gw *gzipWriter) close() {
+	if gw.gz != nil {
+		gw.gz.Close()
+	}
+}
+
+// LogHTTP logs method, path, status, size and duration of every request with its request ID
+func LogHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sw, r)
+
+		log.Info("HTTP: ", requestID(r), " ", r.Method, " ", r.URL.RequestURI(), " ", sw.status, " ", sw.size, "B ", time.Since(start))
+	})
+}
+
+// statusWriter records status and size of response and whether it was started
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	size    int
+	started bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.status, sw.started = code, true
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	sw.started = true
+	n, err := sw.ResponseWriter.Write(p)
+	sw.size += n
+	return n, err
+}
+
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := sw.ResponseWriter.(http.Hijacker); ok {
+		sw.status, sw.started = http.StatusSwitchingProtocols, true
+		return h.Hijack()
+	}
+
+	return nil, nil, http.ErrNotSupported
+}
+
+func (sw *statusWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := sw.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
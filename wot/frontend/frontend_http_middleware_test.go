@@ -0,0 +1,68 @@
+package frontend
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// tagging appends name to X-Chain header of response, so order of middlewares is observable
+func tagging(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestCaseMiddlewareChain(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	var seen string
+	p.UseHTTP(tagging("outer"), tagging("inner"))
+	p.UseHTTP(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = requestID(r)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	w := serve(p, "GET", "/lamp/property/power", "", HEADER_REQUEST_ID, "req-9")
+	Equals("Dispatched", t, http.StatusOK, w.Code)
+	Equals("Order", t, "outer inner", strings.Join(w.Header()["X-Chain"], " "))
+	Equals("Request ID assigned", t, "req-9", seen)
+}
+
+func TestCaseMiddlewarePanic(t *testing.T) {
+	p := testHTTP(nil)
+	p.UseHTTP(func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("middleware failed")
+		})
+	})
+
+	w := serve(p, "GET", "/things", "")
+	Equals("Recovered", t, http.StatusInternalServerError, w.Code)
+	Equals("Problem", t, "application/problem+json", w.Header().Get("Content-Type"))
+}
+
+func TestCaseCompressMiddleware(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"middleware": []interface{}{"compress"}})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/property/power", "", "Accept-Encoding", "gzip")
+	Equals("Compressed", t, "gzip", w.Header().Get("Content-Encoding"))
+
+	zr, err := gzip.NewReader(w.Body)
+	Equals("Gzip", t, nil, err)
+	body, _ := ioutil.ReadAll(zr)
+	Equals("Value", t, "7.5", strings.TrimSpace(string(body)))
+
+	w = serve(p, "GET", "/lamp/property/power", "")
+	Equals("Identity", t, "", w.Header().Get("Content-Encoding"))
+	Equals("Vary", t, "Accept-Encoding", w.Header().Get("Vary"))
+}
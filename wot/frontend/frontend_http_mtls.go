@@ -0,0 +1,128 @@
+package frontend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	CLIENT_AUTH_OPTIONAL = "optional"
+	CLIENT_AUTH_REQUIRE  = "require"
+)
+
+// clientTLS verifies client certificates of HTTPS listener. Certificates issued by any configured CA
+// pass TLS handshake, Things with own CA accept only certificates chaining to it.
+type clientTLS struct {
+	auth   tls.ClientAuthType
+	pool   *x509.CertPool
+	things map[string]*x509.CertPool
+}
+
+// configureClientTLS reads "clientCA" PEM bundle trusted for client certificates, "clientAuth" (optional
+// or require) and "clientCAs" mapping Thing names to their own CA bundles. CA which can not be loaded
+// is a configuration error, listener would otherwise accept clients it should not.
+func (p *Http) configureClientTLS(cfg map[string]interface{}) {
+	caPath, _ := cfg["clientCA"].(string)
+	thingCAs, _ := cfg["clientCAs"].(map[string]interface{})
+
+	if caPath == "" && len(thingCAs) == 0 {
+		return
+	}
+
+	if p.tlsCert == "" {
+		panic("Client certificates require TLS enabled.")
+	}
+
+	ct := &clientTLS{
+		auth:   tls.VerifyClientCertIfGiven,
+		pool:   x509.NewCertPool(),
+		things: make(map[string]*x509.CertPool),
+	}
+
+	if caPath != "" {
+		loadCertPool(ct.pool, caPath)
+	}
+
+	for name, path := range thingCAs {
+		pool := x509.NewCertPool()
+		loadCertPool(pool, path.(string))
+		loadCertPool(ct.pool, path.(string))
+		ct.things[name] = pool
+	}
+
+	if mode, ok := cfg["clientAuth"].(string); ok {
+		switch mode {
+		case CLIENT_AUTH_REQUIRE:
+			ct.auth = tls.RequireAndVerifyClientCert
+		case CLIENT_AUTH_OPTIONAL:
+		default:
+			panic(str.Concat("Unknown client auth mode: ", mode))
+		}
+	}
+
+	p.clientTLS = ct
+	log.Info("HTTP: client certificates verified, mode -> ", ct.auth)
+}
+
+func loadCertPool(pool *x509.CertPool, path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(str.Concat("Client CA not loaded: ", err.Error()))
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		panic(str.Concat("Client CA has no certificates: ", path))
+	}
+}
+
+func (p *Http) tlsConfig() *tls.Config {
+	if p.clientTLS == nil {
+		return nil
+	}
+
+	return &tls.Config{
+		ClientAuth: p.clientTLS.auth,
+		ClientCAs:  p.clientTLS.pool,
+	}
+}
+
+// thingCertAllowed checks client certificate chains to CA of Thing, Things without own CA allow any client
+func (p *Http) thingCertAllowed(w http.ResponseWriter, r *http.Request, wotServer *server.WotServer) bool {
+	if p.clientTLS == nil {
+		return true
+	}
+
+	pool, ok := p.clientTLS.things[wotServer.Name()]
+	if !ok {
+		return true
+	}
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		sendCode(w, r, http.StatusUnauthorized, "Client certificate required.")
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := r.TLS.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	if err != nil {
+		sendCode(w, r, http.StatusForbidden, "Client certificate not trusted by thing.")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,96 @@
+package frontend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// certificate creates certificate of cn signed by issuer and its key, self-signed CA when issuer is nil
+func certificate(t *testing.T, cn string, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if issuer == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		issuer, issuerKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func writeCA(t *testing.T, dir, name string, cert *x509.Certificate) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// serveTLS sends GET request to handler as if client presented certificate over TLS
+func serveTLS(h http.Handler, target string, cert *x509.Certificate) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", target, nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestCaseThingClientCA(t *testing.T) {
+	dir := t.TempDir()
+	lampCA, lampKey := certificate(t, "lamp-ca", nil, nil)
+	siteCA, siteKey := certificate(t, "site-ca", nil, nil)
+
+	p := testHTTP(map[string]interface{}{
+		"tlsCert":   "server.pem",
+		"tlsKey":    "server.key",
+		"clientCA":  writeCA(t, dir, "site.pem", siteCA),
+		"clientCAs": map[string]interface{}{"lamp": writeCA(t, dir, "lamp.pem", lampCA)},
+	})
+	p.Bind("/lamp", lamp())
+	p.Bind("/thermostat", thermostat())
+
+	config := p.tlsConfig()
+	Equals("Optional client certificate", t, tls.VerifyClientCertIfGiven, config.ClientAuth)
+
+	w := serve(p, "GET", "/lamp/property/power", "")
+	Equals("Without certificate", t, http.StatusUnauthorized, w.Code)
+
+	operator, _ := certificate(t, "operator", siteCA, siteKey)
+	w = serveTLS(p, "/lamp/property/power", operator)
+	Equals("Other CA", t, http.StatusForbidden, w.Code)
+
+	device, _ := certificate(t, "lamp-service", lampCA, lampKey)
+	w = serveTLS(p, "/lamp/property/power", device)
+	Equals("Thing CA", t, http.StatusOK, w.Code)
+
+	w = serve(p, "GET", "/thermostat/property/config", "")
+	Equals("Thing without CA", t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,166 @@
+package frontend
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/cbor"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+// MULTICAST_MAX_DATAGRAM is largest datagram sent, larger events are not broadcast
+const MULTICAST_MAX_DATAGRAM = 65507
+
+type multicast struct {
+	conn   *net.UDPConn
+	group  string
+	events map[string]bool
+	seq    uint64
+	sender string
+	l      *sync.Mutex
+	things map[string]*multicastThing
+}
+
+// multicastThing keeps event listeners of bound Thing so they can be removed when it is unbound
+type multicastThing struct {
+	s         *server.WotServer
+	listeners map[string]*server.EventListener
+}
+
+// configureMulticast broadcasts events listed in "multicastEvents" ("*" for all) to UDP "multicast" group,
+// e.g. "239.255.42.1:5690", in addition to WebSocket delivery. Datagrams keep default multicast TTL 1 so
+// they do not leave local segment.
+func (p *Http) configureMulticast(cfg map[string]interface{}) {
+	group, ok := cfg["multicast"].(string)
+	if !ok {
+		return
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil || !addr.IP.IsMulticast() {
+		panic(str.Concat("Invalid multicast: multicast group address expected, got ", group))
+	}
+
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		panic(str.Concat("Invalid multicast: ", err.Error()))
+	}
+
+	events := make(map[string]bool)
+	for _, name := range stringList("multicastEvents", cfg["multicastEvents"]) {
+		events[name] = true
+	}
+
+	//sender is new on every start, sequence numbers start from 1
+	sender, _ := sec.UUID4()
+
+	p.multicast = &multicast{
+		conn:   conn,
+		group:  addr.String(),
+		events: events,
+		sender: sender,
+		l:      &sync.Mutex{},
+		things: make(map[string]*multicastThing),
+	}
+
+	log.Info("HTTP: broadcasting events to multicast group -> ", p.multicast.group)
+}
+
+func (m *multicast) selected(event string) bool {
+	return m.events["*"] || m.events[event]
+}
+
+// advertise adds href of multicast group to hrefs of broadcast event
+func (m *multicast) advertise(event string, hrefs []string) []string {
+	if m == nil || !m.selected(event) {
+		return hrefs
+	}
+
+	return append(hrefs, str.Concat("udp://", m.group))
+}
+
+// bind broadcasts selected events of Thing, listeners of replaced WotServer are removed
+func (m *multicast) bind(ctxPath string, s *server.WotServer) {
+	if m == nil {
+		return
+	}
+
+	m.unbind(ctxPath)
+
+	thing := &multicastThing{s: s, listeners: make(map[string]*server.EventListener)}
+	for _, e := range s.GetDescription().Events {
+		if !m.selected(e.Name) {
+			continue
+		}
+
+		id, _ := sec.UUID4()
+		listener := &server.EventListener{
+			ID: id,
+			CB: func(event interface{}) {
+				m.broadcast(ctxPath, e.Name, event)
+			},
+		}
+		thing.listeners[e.Name] = listener
+		s.AddListener(e.Name, listener)
+	}
+
+	m.l.Lock()
+	m.things[ctxPath] = thing
+	m.l.Unlock()
+}
+
+func (m *multicast) unbind(ctxPath string) {
+	if m == nil {
+		return
+	}
+
+	m.l.Lock()
+	thing, ok := m.things[ctxPath]
+	delete(m.things, ctxPath)
+	m.l.Unlock()
+
+	if !ok {
+		return
+	}
+
+	for name, listener := range thing.listeners {
+		thing.s.RemoveListener(name, listener)
+	}
+}
+
+// broadcast sends event as CBOR map of "seq", "sender", "thing", "event", "timestamp" and "data". Seq
+// increases by one with every datagram of sender, so consumers detect lost datagrams by gaps and restarted
+// gateway by new sender.
+func (m *multicast) broadcast(ctxPath, name string, event interface{}) {
+	data, timestamp := event, time.Now()
+	if e, ok := event.(*server.Event); ok {
+		data, timestamp = e.Data, e.Timestamp
+	}
+
+	datagram, err := cbor.Marshal(map[string]interface{}{
+		"seq":       atomic.AddUint64(&m.seq, 1),
+		"sender":    m.sender,
+		"thing":     ctxPath,
+		"event":     name,
+		"timestamp": timestamp,
+		"data":      data,
+	})
+
+	switch {
+	case err != nil:
+	case len(datagram) > MULTICAST_MAX_DATAGRAM:
+		err = errors.New(str.Concat("datagram of ", len(datagram), " bytes too large"))
+	default:
+		_, err = m.conn.Write(datagram)
+	}
+
+	if err != nil {
+		log.Error("HTTP: event ", name, " of ", ctxPath, " not broadcast -> ", err)
+	}
+}
@@ -0,0 +1,60 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/cbor"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseMulticastEvents(t *testing.T) {
+	p := testHTTP(map[string]interface{}{
+		"multicast":       "239.255.42.1:5690",
+		"multicastEvents": []interface{}{server.PROPERTY_CHANGE_EVENT},
+	})
+
+	//datagrams are redirected to loopback listener, test host need not route multicast
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	Equals("Listen", t, nil, err)
+	defer listener.Close()
+	p.multicast.conn, err = net.DialUDP("udp", nil, listener.LocalAddr().(*net.UDPAddr))
+	Equals("Dial", t, nil, err)
+
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	td := &model.ThingDescription{}
+	json.Unmarshal(serve(p, "GET", "/lamp/description", "").Body.Bytes(), td)
+	hrefs := td.Events[0].Hrefs
+	Equals("Advertised", t, "udp://239.255.42.1:5690", hrefs[len(hrefs)-1])
+
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "on", Value: true})
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "on", Value: false})
+
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for seq := int64(1); seq <= 2; seq++ {
+		buf := make([]byte, MULTICAST_MAX_DATAGRAM)
+		n, err := listener.Read(buf)
+		Equals("Received", t, nil, err)
+
+		v, err := cbor.Unmarshal(buf[:n])
+		Equals("CBOR", t, nil, err)
+
+		datagram := v.(map[string]interface{})
+		Equals("Seq", t, seq, datagram["seq"])
+		Equals("Thing", t, "/lamp", datagram["thing"])
+		Equals("Event", t, server.PROPERTY_CHANGE_EVENT, datagram["event"])
+		Equals("Sender", t, p.multicast.sender, datagram["sender"])
+	}
+
+	p.Unbind("/lamp")
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "on", Value: true})
+
+	listener.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, err = listener.Read(make([]byte, MULTICAST_MAX_DATAGRAM))
+	Equals("Not broadcast after unbind", t, true, err != nil)
+}
@@ -0,0 +1,93 @@
+package frontend
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/conas/tno2/util/patch"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+var errPreconditionFailed = errors.New("Property changed since it was read")
+
+// propertyPatchHandler applies JSON Merge Patch or JSON Patch, selected by Content-Type, to current
+// property value. Patch is applied inside WotServer call, so concurrent writes can not be lost.
+func (p *Http) propertyPatchHandler(t *tenant, wotServer *server.WotServer, prop model.Property) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_WRITE, wotServer, prop.Name) {
+			return
+		}
+
+		limitBody(w, r, p.limits.propertyBody)
+
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		var update func(current interface{}) (interface{}, error)
+		var input interface{}
+
+		switch contentType {
+		case patch.CONTENT_TYPE_MERGE_PATCH:
+			var mergePatch interface{}
+			if err := readBody(r, &mergePatch); err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+			input = mergePatch
+			update = func(current interface{}) (interface{}, error) {
+				target, err := patch.Normalize(current)
+				if err != nil {
+					return nil, err
+				}
+				return patch.Merge(target, mergePatch), nil
+			}
+		case patch.CONTENT_TYPE_JSON_PATCH:
+			ops := make([]patch.Operation, 0)
+			if err := readBody(r, &ops); err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+			input = ops
+			update = func(current interface{}) (interface{}, error) {
+				return patch.Apply(current, ops)
+			}
+		default:
+			w.Header().Set("Accept-Patch", str.Concat(patch.CONTENT_TYPE_MERGE_PATCH, ", ", patch.CONTENT_TYPE_JSON_PATCH))
+			sendCode(w, r, http.StatusUnsupportedMediaType, str.Concat("Unsupported patch type: ", contentType))
+			return
+		}
+
+		condition := r.Header.Get("If-Match")
+		data := requested(r, wotServer).UpdateProperty(prop.Name, func(current interface{}) (interface{}, error) {
+			if condition != "" && !ifMatch(prop, condition)(current) {
+				return nil, errPreconditionFailed
+			}
+
+			value, err := update(current)
+			if err != nil {
+				return nil, err
+			}
+
+			return value, prop.ValueType.Validate(value)
+		}).Get()
+		p.audited(p.auditEntry(r, t, wotServer, server.INTERACTION_WRITE, prop.Name, input), data)
+
+		switch data.(type) {
+		case server.Status:
+			sendERR(w, r, data)
+		case error:
+			switch data {
+			case errPreconditionFailed:
+				sendCode(w, r, http.StatusPreconditionFailed, data)
+			case patch.ErrTestFailed:
+				sendCode(w, r, http.StatusConflict, data)
+			default:
+				sendCode(w, r, http.StatusUnprocessableEntity, data)
+			}
+		default:
+			sendTagged(w, r, data)
+		}
+	}
+}
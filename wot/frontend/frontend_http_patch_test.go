@@ -0,0 +1,40 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/util/patch"
+)
+
+func TestCasePropertyMergePatch(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/thermostat", thermostat())
+
+	w := serve(p, "PATCH", "/thermostat/property/config", `{"target": 23, "display": {"unit": "F"}}`, "Content-Type", patch.CONTENT_TYPE_MERGE_PATCH)
+	Equals("Patched", t, http.StatusOK, w.Code)
+	Equals("Patched value", t, `{"display":{"unit":"F"},"mode":"heat","target":23}`, strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "PATCH", "/thermostat/property/config", `{"target": "hot"}`, "Content-Type", patch.CONTENT_TYPE_MERGE_PATCH)
+	Equals("Invalid value", t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(p, "PATCH", "/thermostat/property/config", `{"target": 20}`, "Content-Type", patch.CONTENT_TYPE_MERGE_PATCH, "If-Match", `"stale"`)
+	Equals("Stale", t, http.StatusPreconditionFailed, w.Code)
+
+	w = serve(p, "PATCH", "/thermostat/property/config", `{"target": 20}`, "Content-Type", "application/json")
+	Equals("Unsupported", t, http.StatusUnsupportedMediaType, w.Code)
+	Equals("Accept-Patch", t, true, strings.Contains(w.Header().Get("Accept-Patch"), patch.CONTENT_TYPE_JSON_PATCH))
+}
+
+func TestCasePropertyJSONPatch(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/thermostat", thermostat())
+
+	w := serve(p, "PATCH", "/thermostat/property/config", `[{"op": "test", "path": "/mode", "value": "cool"}, {"op": "replace", "path": "/mode", "value": "off"}]`, "Content-Type", patch.CONTENT_TYPE_JSON_PATCH)
+	Equals("Test failed", t, http.StatusConflict, w.Code)
+
+	w = serve(p, "PATCH", "/thermostat/property/config", `[{"op": "test", "path": "/mode", "value": "heat"}, {"op": "replace", "path": "/mode", "value": "off"}]`, "Content-Type", patch.CONTENT_TYPE_JSON_PATCH)
+	Equals("Patched", t, http.StatusOK, w.Code)
+	Equals("Patched value", t, true, strings.Contains(w.Body.String(), `"mode":"off"`))
+}
@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/conas/tno2/wot/server"
+)
+
+// preparedMessage is payload shared by all WebSocket clients of one subscription. Payload is
+// serialized once per encoding on first write, following writes reuse the encoded bytes.
+type preparedMessage struct {
+	v       interface{}
+	l       *sync.Mutex
+	encoded map[string][]byte
+}
+
+// prepareEvent returns prepared message shared by all listeners of the event
+func prepareEvent(event interface{}) *preparedMessage {
+	if e, ok := event.(*server.Event); ok {
+		return e.Memo("frontend.prepared", func() interface{} {
+			return prepare(e)
+		}).(*preparedMessage)
+	}
+
+	return prepare(event)
+}
+
+func prepare(v interface{}) *preparedMessage {
+	return &preparedMessage{
+		v:       v,
+		l:       &sync.Mutex{},
+		encoded: make(map[string][]byte),
+	}
+}
+
+func (pm *preparedMessage) Encoded(encoding string) ([]byte, error) {
+	pm.l.Lock()
+	defer pm.l.Unlock()
+
+	if data, ok := pm.encoded[encoding]; ok {
+		return data, nil
+	}
+
+	encoder, err := Encoders.Get(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = encoder.Encode(&buf, pm.v); err != nil {
+		return nil, err
+	}
+
+	pm.encoded[encoding] = buf.Bytes()
+	return pm.encoded[encoding], nil
+}
@@ -0,0 +1,31 @@
+package frontend
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCasePreparedEvent(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	ws := subscribe(t, p, "/lamp/event/property-change")
+	conn1, close1 := dial(t, p, ws, nil)
+	defer close1()
+	conn2, close2 := dial(t, p, ws, nil)
+	defer close2()
+
+	s.EmitPropertyChange("on", true)
+
+	conn1.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn2.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, m1, err1 := conn1.ReadMessage()
+	_, m2, err2 := conn2.ReadMessage()
+
+	Equals("Read 1", t, nil, err1)
+	Equals("Read 2", t, nil, err2)
+	Equals("Shared payload", t, string(m1), string(m2))
+	Equals("Change", t, true, strings.Contains(string(m1), `"name":"on","value":true`))
+}
@@ -0,0 +1,71 @@
+package frontend
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// copier is Thing with action "copy" reporting progress, invocation with input "hold" waits for release
+func copier(release chan bool) *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "copier",
+		Actions: []model.Action{{Name: "copy", Hrefs: []string{"action/copy"}}},
+		Events:  []model.Event{{Name: server.ACTION_STATUS_EVENT, Hrefs: []string{"event/actionstatus"}}},
+	})
+
+	s.OnInvokeAction("copy", func(input interface{}, ph async.ProgressHandler) interface{} {
+		server.ReportProgress(ph, server.Progress{Percent: 40, Stage: "copying"})
+		if input == "hold" {
+			<-release
+		}
+		return "copied"
+	})
+
+	return s
+}
+
+func TestCaseTaskProgress(t *testing.T) {
+	p := testHTTP(nil)
+	release := make(chan bool)
+	p.Bind("/copier", copier(release))
+
+	task := taskPath(t, serve(p, "POST", "/copier/action/copy", `"hold"`))
+
+	status := &server.TaskStatus{}
+	for deadline := time.Now().Add(5 * time.Second); status.Progress == nil && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		json.Unmarshal(serve(p, "GET", task, "").Body.Bytes(), status)
+	}
+
+	Equals("Running", t, server.TASK_RUNNING, status.Status)
+	Equals("Progress", t, server.Progress{Percent: 40, Stage: "copying"}, *status.Progress)
+
+	release <- true
+	status = finished(t, p, task)
+	Equals("Done", t, server.TASK_DONE, status.Status)
+	Equals("Progress cleared", t, true, status.Progress == nil)
+}
+
+func TestCaseActionStatusEvent(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/copier", copier(nil))
+
+	conn, closer := dial(t, p, subscribe(t, p, "/copier/event/actionstatus"), nil)
+	defer closer()
+
+	var e struct {
+		Data server.ActionStatus `json:"data"`
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	serve(p, "POST", "/copier/action/copy", "null", HEADER_REQUEST_ID, "req-1")
+
+	Equals("Read", t, nil, conn.ReadJSON(&e))
+	Equals("Request", t, "req-1", e.Data.RequestID)
+	Equals("Action", t, "copy", e.Data.Name)
+	Equals("Task", t, true, e.Data.Task != "")
+}
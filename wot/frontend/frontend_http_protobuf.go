@@ -0,0 +1,66 @@
+package frontend
+
+import (
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/conas/tno2/util/pb"
+	"github.com/conas/tno2/wot/model"
+)
+
+// CONTENT_TYPE_PROTOBUF selects Protocol Buffers encoding of property values in Accept and Content-Type
+// headers. Values are messages generated by tno2gen -proto for the property, e.g. TemperatureProperty.
+const CONTENT_TYPE_PROTOBUF = "application/x-protobuf"
+
+// acceptsProtobuf reports whether client asked for protobuf response
+func acceptsProtobuf(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mt == CONTENT_TYPE_PROTOBUF {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isProtobuf reports whether request body is protobuf encoded
+func isProtobuf(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mt == CONTENT_TYPE_PROTOBUF
+}
+
+func sendProtobuf(w http.ResponseWriter, r *http.Request, prop model.Property, payload interface{}) {
+	data, err := pb.MarshalTyped(prop.ValueType.Type, payload)
+
+	if err != nil {
+		sendPlainERR(w, err)
+		return
+	}
+
+	tag := etagOf(data)
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "ETag")
+	w.Header().Add("Vary", "Accept")
+
+	if etagMatch(r.Header.Get("If-None-Match"), tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", CONTENT_TYPE_PROTOBUF)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func readProtobuf(r *http.Request, prop model.Property) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.UnmarshalTyped(prop.ValueType.Type, data)
+}
@@ -0,0 +1,31 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/util/pb"
+)
+
+func TestCasePropertyProtobuf(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/property/power", "", "Accept", CONTENT_TYPE_PROTOBUF)
+	expected, _ := pb.MarshalTyped("number", 7.5)
+	Equals("Content type", t, CONTENT_TYPE_PROTOBUF, w.Header().Get("Content-Type"))
+	Equals("Typed message", t, string(expected), w.Body.String())
+
+	w = serve(p, "GET", "/lamp/property/power", "", "Accept", CONTENT_TYPE_PROTOBUF, "If-None-Match", w.Header().Get("ETag"))
+	Equals("Not modified", t, http.StatusNotModified, w.Code)
+
+	on, _ := pb.MarshalTyped("boolean", true)
+	serve(p, "PUT", "/lamp/property/on", string(on), "Content-Type", CONTENT_TYPE_PROTOBUF)
+
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Written", t, "true", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "PUT", "/lamp/property/on", "\xff\xff", "Content-Type", CONTENT_TYPE_PROTOBUF)
+	Equals("Malformed", t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,153 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/gorilla/mux"
+)
+
+const (
+	PROVISIONING_PENDING  = "pending"
+	PROVISIONING_APPROVED = "approved"
+	PROVISIONING_REJECTED = "rejected"
+
+	// HEADER_BOOTSTRAP_TOKEN carries bootstrap credential of device being provisioned
+	HEADER_BOOTSTRAP_TOKEN = "X-Bootstrap-Token"
+)
+
+var (
+	ErrBootstrapToken  = errors.New("Invalid bootstrap token")
+	ErrUnknownRequest  = errors.New("Unknown provisioning request")
+	ErrRequestNotReady = errors.New("Provisioning request is not pending")
+)
+
+// ProvisioningRequest is device asking to be onboarded with its ThingDescription. CSR is optional
+// PEM certificate signing request, Identity is issued when request is approved.
+type ProvisioningRequest struct {
+	ID          string                  `json:"id"`
+	Device      string                  `json:"device"`
+	Description *model.ThingDescription `json:"description"`
+	CSR         string                  `json:"csr,omitempty"`
+	State       string                  `json:"state"`
+	Reason      string                  `json:"reason,omitempty"`
+	Created     time.Time               `json:"created"`
+	Identity    *Identity               `json:"identity,omitempty"`
+}
+
+// Identity is credential issued to provisioned device, API key and certificate signed by platform CA
+// when device sent CSR. CtxPath locates its Thing.
+type Identity struct {
+	APIKey      string `json:"apiKey,omitempty"`
+	Certificate string `json:"certificate,omitempty"`
+	CtxPath     string `json:"ctxPath"`
+}
+
+// Provisioner is implemented by platform onboarding devices, requests are authenticated by bootstrap token
+type Provisioner interface {
+	Request(bootstrap string, rq *ProvisioningRequest) (*ProvisioningRequest, error)
+	Status(bootstrap, requestID string) (*ProvisioningRequest, error)
+	Requests(state string) []*ProvisioningRequest
+	Approve(requestID string) (*ProvisioningRequest, error)
+	Reject(requestID, reason string) (*ProvisioningRequest, error)
+}
+
+// ProvisioningFrontend is implemented by admin frontends exposing provisioning
+type ProvisioningFrontend interface {
+	AdminFrontend
+	EnableProvisioning(provisioner Provisioner)
+}
+
+// EnableProvisioning exposes device API POST /provisioning/requests and GET /provisioning/requests/{id}
+// authenticated by bootstrap token, and approval queue /admin/provisioning. Requires admin API.
+func (p *Http) EnableProvisioning(provisioner Provisioner) {
+	if p.admin == nil {
+		panic("Provisioning API requires admin API enabled.")
+	}
+
+	p.addRoute(p.router, &route{
+		method:  "POST",
+		pattern: "/provisioning/requests",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			limitBody(w, r, MAX_BODY)
+
+			rq := &ProvisioningRequest{}
+			if err := readBody(r, rq); err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+
+			created, err := provisioner.Request(r.Header.Get(HEADER_BOOTSTRAP_TOKEN), rq)
+			if err != nil {
+				sendProvisioningERR(w, r, err)
+				return
+			}
+
+			sendCode(w, r, http.StatusAccepted, created)
+		},
+	})
+
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: "/provisioning/requests/{requestID}",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			rq, err := provisioner.Status(r.Header.Get(HEADER_BOOTSTRAP_TOKEN), mux.Vars(r)["requestID"])
+			if err != nil {
+				sendProvisioningERR(w, r, err)
+				return
+			}
+
+			sendOK(w, r, rq)
+		},
+	})
+
+	p.adminRoute("GET", "/admin/provisioning", auth.RIGHT_READ, "provisioning", func(w http.ResponseWriter, r *http.Request) {
+		sendOK(w, r, provisioner.Requests(r.URL.Query().Get("state")))
+	})
+
+	p.adminRoute("POST", "/admin/provisioning/{requestID}/approve", auth.RIGHT_WRITE, "provisioning", func(w http.ResponseWriter, r *http.Request) {
+		rq, err := provisioner.Approve(mux.Vars(r)["requestID"])
+		if err != nil {
+			sendProvisioningERR(w, r, err)
+			return
+		}
+
+		sendOK(w, r, rq)
+	})
+
+	p.adminRoute("POST", "/admin/provisioning/{requestID}/reject", auth.RIGHT_WRITE, "provisioning", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if r.ContentLength != 0 {
+			if err := readBody(r, &body); err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+		}
+
+		rq, err := provisioner.Reject(mux.Vars(r)["requestID"], body.Reason)
+		if err != nil {
+			sendProvisioningERR(w, r, err)
+			return
+		}
+
+		sendOK(w, r, rq)
+	})
+}
+
+func sendProvisioningERR(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrBootstrapToken:
+		sendCode(w, r, http.StatusUnauthorized, err.Error())
+	case ErrUnknownRequest:
+		sendCode(w, r, http.StatusNotFound, err.Error())
+	case ErrRequestNotReady:
+		sendCode(w, r, http.StatusConflict, err.Error())
+	default:
+		sendCode(w, r, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,113 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// provisioner keeps requests in memory, devices bootstrap with token "boot"
+type provisioner map[string]*ProvisioningRequest
+
+func (pr provisioner) Request(bootstrap string, rq *ProvisioningRequest) (*ProvisioningRequest, error) {
+	if bootstrap != "boot" {
+		return nil, ErrBootstrapToken
+	}
+
+	rq.ID, rq.State = rq.Device, PROVISIONING_PENDING
+	pr[rq.ID] = rq
+	return rq, nil
+}
+
+func (pr provisioner) Status(bootstrap, requestID string) (*ProvisioningRequest, error) {
+	if bootstrap != "boot" {
+		return nil, ErrBootstrapToken
+	}
+
+	rq, ok := pr[requestID]
+	if !ok {
+		return nil, ErrUnknownRequest
+	}
+
+	return rq, nil
+}
+
+func (pr provisioner) Requests(state string) []*ProvisioningRequest {
+	requests := make([]*ProvisioningRequest, 0)
+	for _, rq := range pr {
+		if state == "" || rq.State == state {
+			requests = append(requests, rq)
+		}
+	}
+
+	return requests
+}
+
+func (pr provisioner) Approve(requestID string) (*ProvisioningRequest, error) {
+	rq, err := pr.get(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	rq.State = PROVISIONING_APPROVED
+	rq.Identity = &Identity{APIKey: "k-" + rq.Device, CtxPath: "/" + rq.Device}
+	return rq, nil
+}
+
+func (pr provisioner) Reject(requestID, reason string) (*ProvisioningRequest, error) {
+	rq, err := pr.get(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	rq.State, rq.Reason = PROVISIONING_REJECTED, reason
+	return rq, nil
+}
+
+func (pr provisioner) get(requestID string) (*ProvisioningRequest, error) {
+	rq, ok := pr[requestID]
+	if !ok {
+		return nil, ErrUnknownRequest
+	}
+	if rq.State != PROVISIONING_PENDING {
+		return rq, ErrRequestNotReady
+	}
+
+	return rq, nil
+}
+
+func TestCaseProvisioning(t *testing.T) {
+	p := adminHTTP()
+	p.EnableProvisioning(provisioner{})
+
+	body := `{"device": "dht-7", "description": {"name": "dht-7"}}`
+	w := serve(p, "POST", "/provisioning/requests", body, HEADER_BOOTSTRAP_TOKEN, "guess")
+	Equals("Invalid bootstrap", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "POST", "/provisioning/requests", body, HEADER_BOOTSTRAP_TOKEN, "boot")
+	Equals("Requested", t, http.StatusAccepted, w.Code)
+	rq := &ProvisioningRequest{}
+	json.Unmarshal(w.Body.Bytes(), rq)
+	Equals("Pending", t, PROVISIONING_PENDING, rq.State)
+
+	var pending []*ProvisioningRequest
+	json.Unmarshal(serve(p, "GET", "/admin/provisioning?state=pending", "", "X-API-Key", "k-viewer").Body.Bytes(), &pending)
+	Equals("Approval queue", t, 1, len(pending))
+
+	w = serve(p, "POST", "/admin/provisioning/"+rq.ID+"/approve", "", "X-API-Key", "k-viewer")
+	Equals("Approved by viewer", t, http.StatusForbidden, w.Code)
+
+	w = serve(p, "POST", "/admin/provisioning/"+rq.ID+"/approve", "", "X-API-Key", "k-admin")
+	Equals("Approved", t, http.StatusOK, w.Code)
+
+	w = serve(p, "GET", "/provisioning/requests/"+rq.ID, "", HEADER_BOOTSTRAP_TOKEN, "boot")
+	json.Unmarshal(w.Body.Bytes(), rq)
+	Equals("Device state", t, PROVISIONING_APPROVED, rq.State)
+	Equals("Issued identity", t, "/dht-7", rq.Identity.CtxPath)
+
+	w = serve(p, "POST", "/admin/provisioning/"+rq.ID+"/reject", `{"reason": "late"}`, "X-API-Key", "k-admin")
+	Equals("Rejected approved", t, http.StatusConflict, w.Code)
+
+	w = serve(p, "POST", "/admin/provisioning/unknown/approve", "", "X-API-Key", "k-admin")
+	Equals("Unknown request", t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,67 @@
+package frontend
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+)
+
+// proxies are reverse proxies terminating TLS in front of binding, their X-Forwarded-Proto is trusted
+type proxies struct {
+	nets []*net.IPNet
+}
+
+type forwardedTLSKey struct{}
+
+// configureProxies reads "trustedProxies", addresses or CIDR ranges of proxies terminating TLS, e.g.
+// "10.0.0.0/8". Requests forwarded by them as X-Forwarded-Proto https get wss:// and https:// links.
+// X-Forwarded-Proto is ignored when no proxy is trusted.
+func (p *Http) configureProxies(cfg map[string]interface{}) {
+	p.proxies = &proxies{}
+
+	for _, trusted := range stringList("trustedProxies", cfg["trustedProxies"]) {
+		if !strings.Contains(trusted, "/") {
+			if ip := net.ParseIP(trusted); ip != nil && ip.To4() != nil {
+				trusted = str.Concat(trusted, "/32")
+			} else {
+				trusted = str.Concat(trusted, "/128")
+			}
+		}
+
+		_, n, err := net.ParseCIDR(trusted)
+		if err != nil {
+			panic(str.Concat("Invalid trustedProxies: address or CIDR expected, got ", trusted))
+		}
+		p.proxies.nets = append(p.proxies.nets, n)
+	}
+}
+
+// forwarded marks request forwarded by trusted proxy which received it over TLS
+func (ps *proxies) forwarded(r *http.Request) *http.Request {
+	if ps == nil || len(ps.nets) == 0 || !strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		return r
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	for _, n := range ps.nets {
+		if ip != nil && n.Contains(ip) {
+			return r.WithContext(context.WithValue(r.Context(), forwardedTLSKey{}, true))
+		}
+	}
+
+	return r
+}
+
+// secure reports request was received over TLS, directly or by trusted proxy terminating TLS
+func secure(r *http.Request) bool {
+	forwarded, _ := r.Context().Value(forwardedTLSKey{}).(bool)
+	return r.TLS != nil || forwarded
+}
@@ -0,0 +1,27 @@
+package frontend
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaseTrustedProxies(t *testing.T) {
+	forwarded := func(p *Http, remoteAddr string) bool {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-Proto", "https")
+		return secure(p.proxies.forwarded(r))
+	}
+
+	untrusting := testHTTP(nil)
+	Equals("no trusted proxy", t, false, forwarded(untrusting, "10.0.0.1:4000"))
+
+	p := testHTTP(map[string]interface{}{"trustedProxies": []interface{}{"10.0.0.0/8", "192.168.1.1"}})
+	Equals("trusted range", t, true, forwarded(p, "10.0.0.1:4000"))
+	Equals("trusted address", t, true, forwarded(p, "192.168.1.1:4000"))
+	Equals("untrusted client", t, false, forwarded(p, "203.0.113.7:4000"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:4000"
+	Equals("not forwarded over TLS", t, false, secure(p.proxies.forwarded(r)))
+}
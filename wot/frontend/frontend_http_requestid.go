@@ -0,0 +1,60 @@
+package frontend
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/wot/server"
+)
+
+// HEADER_REQUEST_ID carries request identifier, accepted from client or generated, and returned in response
+const HEADER_REQUEST_ID = "X-Request-ID"
+
+// MAX_REQUEST_ID limits length of request ID accepted from client
+const MAX_REQUEST_ID = 128
+
+type requestIDKey struct{}
+
+// withRequestID identifies request by ID from X-Request-ID header or new one, when header is missing or
+// invalid. ID is returned in response header and attached to request context.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(HEADER_REQUEST_ID)
+	if !validRequestID(id) {
+		id, _ = sec.UUID4()
+	}
+
+	w.Header().Set(HEADER_REQUEST_ID, id)
+
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// requestID returns ID of request assigned by withRequestID
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// requested returns view of WotServer attributing calls to request r, so request ID is passed to
+// taps and backends
+func requested(r *http.Request, s *server.WotServer) *server.WotServer {
+	return s.Request(requestID(r))
+}
+
+// validRequestID accepts IDs safe to be logged and embedded in backend messages, letters, digits, "-",
+// "_" and "."
+func validRequestID(id string) bool {
+	if id == "" || len(id) > MAX_REQUEST_ID {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,35 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaseRequestID(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := lamp()
+	var served string
+	s.OnGetProperty("power", func() interface{} {
+		served = s.RequestID()
+		return 7.5
+	})
+	p.Bind("/lamp", s)
+
+	w := serve(p, "GET", "/lamp/property/power", "", HEADER_REQUEST_ID, "req-42.a_b")
+	Equals("Status", t, http.StatusOK, w.Code)
+	Equals("Returned", t, "req-42.a_b", w.Header().Get(HEADER_REQUEST_ID))
+	Equals("Passed to handler", t, "req-42.a_b", served)
+
+	w = serve(p, "GET", "/lamp/property/power", "")
+	generated := w.Header().Get(HEADER_REQUEST_ID)
+	Equals("Generated", t, true, generated != "")
+	Equals("Generated passed to handler", t, generated, served)
+
+	w = serve(p, "GET", "/lamp/property/power", "", HEADER_REQUEST_ID, "bad id\r")
+	Equals("Invalid replaced", t, true, w.Header().Get(HEADER_REQUEST_ID) != "bad id\r")
+
+	w = serve(p, "GET", "/lamp/property/power", "", HEADER_REQUEST_ID, strings.Repeat("a", MAX_REQUEST_ID+1))
+	Equals("Too long replaced", t, true, len(w.Header().Get(HEADER_REQUEST_ID)) <= MAX_REQUEST_ID)
+}
@@ -0,0 +1,115 @@
+package frontend
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// router is gorilla router aware of methods of its paths. Request of known path with other method is
+// answered 405 with Allow header and OPTIONS lists allowed methods for CORS preflight, unless route
+// handles OPTIONS itself. Requests of unknown paths are passed to notFound handler, if set. Routes of
+// Thing are removed by replacing router of Thing on rebind or unbind.
+type router struct {
+	*mux.Router
+	l        *sync.RWMutex
+	paths    *mux.Router
+	methods  map[string][]string
+	notFound http.Handler
+}
+
+func newRouter() *router {
+	return &router{
+		Router:  mux.NewRouter().StrictSlash(true),
+		l:       &sync.RWMutex{},
+		paths:   mux.NewRouter().StrictSlash(true),
+		methods: make(map[string][]string),
+	}
+}
+
+func (rt *router) handle(method, pattern string, handler http.Handler) {
+	rt.l.Lock()
+	defer rt.l.Unlock()
+
+	rt.Methods(method).Path(pattern).Name(pattern).Handler(handler)
+
+	if _, ok := rt.methods[pattern]; !ok {
+		rt.paths.Path(pattern).Name(pattern)
+	}
+	rt.methods[pattern] = append(rt.methods[pattern], method)
+}
+
+// allowed returns sorted methods of routes matching path of r, nil when no route matches the path
+func (rt *router) allowed(r *http.Request) []string {
+	rt.l.RLock()
+	defer rt.l.RUnlock()
+
+	seen := make(map[string]bool)
+	rt.paths.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		var match mux.RouteMatch
+		if route.Match(r, &match) {
+			for _, method := range rt.methods[route.GetName()] {
+				seen[method] = true
+			}
+		}
+		return nil
+	})
+
+	if len(seen) == 0 {
+		return nil
+	}
+
+	seen["OPTIONS"] = true
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+// serves reports whether router has route for path of r, with any method
+func (rt *router) serves(r *http.Request) bool {
+	var match mux.RouteMatch
+	return rt.Match(r, &match) || rt.allowed(r) != nil
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var match mux.RouteMatch
+	if rt.Match(r, &match) {
+		rt.Router.ServeHTTP(w, r)
+		return
+	}
+
+	methods := rt.allowed(r)
+	if methods == nil {
+		if rt.notFound != nil {
+			rt.notFound.ServeHTTP(w, r)
+		} else {
+			rt.Router.ServeHTTP(w, r)
+		}
+		return
+	}
+
+	allow := strings.Join(methods, ", ")
+	w.Header().Set("Allow", allow)
+
+	if r.Method == "OPTIONS" {
+		preflight(w, allow)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	sendCode(w, r, http.StatusMethodNotAllowed, "Method not allowed.")
+}
+
+func preflight(w http.ResponseWriter, methods string) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", methods)
+	w.Header().Set("Access-Control-Allow-Headers", "X-PINGOTHER, Content-Type, Authorization, X-API-Key, If-Match, If-None-Match, Range, Prefer")
+	w.Header().Set("Access-Control-Max-Age", "86400")
+}
@@ -0,0 +1,42 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaseMethodNotAllowed(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "DELETE", "/lamp/property/on", "")
+	Equals("Other method", t, http.StatusMethodNotAllowed, w.Code)
+
+	allow := w.Header().Get("Allow")
+	Equals("Allow GET", t, true, strings.Contains(allow, "GET"))
+	Equals("Allow PUT", t, true, strings.Contains(allow, "PUT"))
+	Equals("Allow OPTIONS", t, true, strings.Contains(allow, "OPTIONS"))
+
+	w = serve(p, "GET", "/lamp/property/unknown", "")
+	Equals("Unknown path", t, http.StatusNotFound, w.Code)
+}
+
+func TestCasePreflight(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "OPTIONS", "/lamp/property/on", "")
+	Equals("Preflight", t, http.StatusOK, w.Code)
+	Equals("Allowed methods", t, w.Header().Get("Allow"), w.Header().Get("Access-Control-Allow-Methods"))
+	Equals("Allowed origin", t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCaseUnboundRoutes(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+	p.Unbind("/lamp")
+
+	w := serve(p, "DELETE", "/lamp/property/on", "")
+	Equals("Routes removed", t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,133 @@
+package frontend
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// Interaction kinds passed to RouteStrategy, equal to collection names of interaction affordance URLs
+const (
+	AFFORDANCE_PROPERTY = "properties"
+	AFFORDANCE_ACTION   = "actions"
+	AFFORDANCE_EVENT    = "events"
+)
+
+// RouteStrategy maps interaction to paths of its routes, relative to Thing context path and unescaped,
+// e.g. "property/temp sensor". Interaction without paths is not served by the binding.
+type RouteStrategy interface {
+	Paths(kind, name string, hrefs []string) []string
+}
+
+// RouteStrategyFunc adapts function to RouteStrategy
+type RouteStrategyFunc func(kind, name string, hrefs []string) []string
+
+func (f RouteStrategyFunc) Paths(kind, name string, hrefs []string) []string {
+	return f(kind, name, hrefs)
+}
+
+// HrefRoutes serves interaction on all its relative hrefs, percent-encoded hrefs are decoded. Absolute
+// hrefs belong to other bindings and are advertised unchanged.
+var HrefRoutes = RouteStrategyFunc(func(kind, name string, hrefs []string) []string {
+	paths := make([]string, 0, len(hrefs))
+
+	for _, href := range hrefs {
+		u, err := url.Parse(href)
+		if err != nil || u.IsAbs() || u.Host != "" {
+			continue
+		}
+
+		paths = append(paths, strings.TrimPrefix(u.Path, "/"))
+	}
+
+	return paths
+})
+
+// AffordanceRoutes serves interaction on "properties/{name}", "actions/{name}" or "events/{name}" regardless of its hrefs
+var AffordanceRoutes = RouteStrategyFunc(func(kind, name string, hrefs []string) []string {
+	return []string{str.Concat(kind, "/", name)}
+})
+
+// configureRoutes reads "routes", either "hrefs" (default), "affordances", "spec" or RouteStrategy. Spec
+// layout serves affordance URLs together with {ctx}/properties, {ctx}/actions and {ctx}/events listings.
+func (p *Http) configureRoutes(cfg map[string]interface{}) {
+	switch routes := cfg["routes"].(type) {
+	case nil:
+		p.routes = HrefRoutes
+	case RouteStrategy:
+		p.routes = routes
+	case string:
+		switch routes {
+		case "hrefs":
+			p.routes = HrefRoutes
+		case "affordances":
+			p.routes = AffordanceRoutes
+		case "spec":
+			p.routes = AffordanceRoutes
+			p.collections = true
+		default:
+			panic(str.Concat("HTTP: unknown route strategy ", routes))
+		}
+	default:
+		panic("HTTP: routes must be strategy name or RouteStrategy")
+	}
+}
+
+// registerCollections exposes listings of Thing interactions, their hrefs are already advertised ones
+func (p *Http) registerCollections(rt *router, t *tenant, ctxPath string, s *server.WotServer) {
+	collections := map[string]func(td *model.ThingDescription) interface{}{
+		AFFORDANCE_PROPERTY: func(td *model.ThingDescription) interface{} { return td.Properties },
+		AFFORDANCE_ACTION:   func(td *model.ThingDescription) interface{} { return td.Actions },
+		AFFORDANCE_EVENT:    func(td *model.ThingDescription) interface{} { return td.Events },
+	}
+
+	for name, collection := range collections {
+		p.addRoute(rt, &route{
+			method:  "GET",
+			pattern: contextPath(ctxPath, name),
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				if !p.authenticated(w, r, t) {
+					return
+				}
+
+				sendTagged(w, r, collection(s.Description()))
+			},
+		})
+	}
+}
+
+// routePattern quotes braces of path, so they match literally instead of declaring route variables
+func routePattern(path string) string {
+	if !strings.ContainsAny(path, "{}") {
+		return path
+	}
+
+	var b strings.Builder
+	for i, c := range path {
+		switch c {
+		case '{':
+			b.WriteString(str.Concat("{_lb", strconv.Itoa(i), `:\x7B}`))
+		case '}':
+			b.WriteString(str.Concat("{_rb", strconv.Itoa(i), `:\x7D}`))
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// escapePath percent-encodes every segment of path for use in advertised hrefs
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,92 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// sensor is Thing with property served on escaped, templated-looking and foreign hrefs
+func sensor() *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "sensor",
+		Properties: []model.Property{{
+			Name:      "temp sensor",
+			ValueType: model.ValueType{Type: "number"},
+			Hrefs:     []string{"property/temp%20sensor", "props/{temp}", "coap://sensor.local/temp"},
+		}},
+	})
+	s.OnGetProperty("temp sensor", func() interface{} { return 21.5 })
+
+	return s
+}
+
+func TestCaseHrefRoutes(t *testing.T) {
+	p := testHTTP(nil)
+	s := sensor()
+	p.Bind("/sensor", s)
+
+	w := serve(p, "GET", "/sensor/property/temp%20sensor", "")
+	Equals("Escaped href", t, "21.5", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/sensor/props/%7Btemp%7D", "")
+	Equals("Braces", t, "21.5", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/sensor/props/x", "")
+	Equals("Braces are no variable", t, http.StatusNotFound, w.Code)
+
+	hrefs := s.Forms(p.binding).Properties["temp sensor"]
+	Equals("Advertised", t, 2, len(hrefs))
+	Equals("Advertised escaped", t, "http://localhost:8080/sensor/property/temp%20sensor", hrefs[0])
+}
+
+func TestCaseRouteStrategy(t *testing.T) {
+	strategy := RouteStrategyFunc(func(kind, name string, hrefs []string) []string {
+		if kind != AFFORDANCE_PROPERTY {
+			return nil
+		}
+		return []string{"v2/" + name}
+	})
+
+	p := testHTTP(map[string]interface{}{"routes": strategy})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/v2/power", "")
+	Equals("Strategy path", t, "7.5", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/lamp/property/power", "")
+	Equals("Href not served", t, http.StatusNotFound, w.Code)
+
+	w = serve(p, "POST", "/lamp/action/toggle", "null")
+	Equals("Action not served", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseSpecRoutes(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"routes": "spec"})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/properties/power", "")
+	Equals("Property", t, "7.5", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "POST", "/lamp/actions/toggle", "null")
+	Equals("Action", t, "true", strings.TrimSpace(w.Body.String()))
+
+	subscribe(t, p, "/lamp/events/property-change")
+
+	var properties []model.Property
+	json.Unmarshal(serve(p, "GET", "/lamp/properties", "").Body.Bytes(), &properties)
+	Equals("Properties", t, 2, len(properties))
+
+	var actions []model.Action
+	json.Unmarshal(serve(p, "GET", "/lamp/actions", "").Body.Bytes(), &actions)
+	Equals("Actions", t, 1, len(actions))
+	Equals("Action href", t, "http://localhost:8080/lamp/actions/toggle", actions[0].Hrefs[0])
+
+	var events []model.Event
+	json.Unmarshal(serve(p, "GET", "/lamp/events", "").Body.Bytes(), &events)
+	Equals("Events", t, 1, len(events))
+}
@@ -0,0 +1,255 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/schedule"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/mux"
+)
+
+var errStreamScheduled = errors.New("Streamed action input can not be scheduled")
+
+// scheduledAt parses ?at=<RFC 3339 timestamp> of deferred action invocation
+func scheduledAt(r *http.Request) (time.Time, bool, error) {
+	at := r.URL.Query().Get("at")
+
+	if at == "" {
+		return time.Time{}, false, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, false, errors.New(str.Concat("Invalid schedule time, RFC 3339 expected: ", at))
+	}
+
+	return t, true, nil
+}
+
+// scheduleAction defers invocation of task created by action handler. Task stays scheduled until executed
+// by scheduler, its links stay valid.
+func (p *Http) scheduleAction(w http.ResponseWriter, r *http.Request, ctxPath, actionName, taskID string, input interface{}, at time.Time, ph *server.WotProgressHandler) {
+	err := p.scheduler.Add(&schedule.Entry{
+		ID:     taskID,
+		Thing:  ctxPath,
+		Action: actionName,
+		Input:  input,
+		At:     at,
+	})
+
+	if err != nil {
+		ph.Fail(err.Error())
+		sendCode(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ph.Schedule(input)
+
+	hrefs := links(websocketSubURL(r, taskID), httpSubURL(r, taskID), scheduleURL(r, ctxPath, taskID))
+	sendOK(w, r, hrefs)
+}
+
+func scheduleURL(r *http.Request, ctxPath, taskID string) Link {
+	scheme := "http://"
+	if r.TLS != nil {
+		scheme = "https://"
+	}
+
+	return Link{
+		Rel:  "schedule",
+		Href: str.Concat(scheme, r.Host, mountPrefix(r), contextPath(ctxPath, "schedules/"), taskID),
+	}
+}
+
+// ScheduleResult is published when scheduled action execution finishes
+type ScheduleResult struct {
+	Schedule string                `json:"schedule"`
+	Thing    string                `json:"thing"`
+	Action   string                `json:"action"`
+	Task     string                `json:"task"`
+	Time     time.Time             `json:"time"`
+	Status   server.TaskStatusCode `json:"status"`
+	Data     interface{}           `json:"data,omitempty"`
+}
+
+// fireSchedule invokes scheduled action. Deferred invocation executes task created when it was scheduled,
+// its slot and subscription are restored if they were lost. Every execution of recurring schedule is new task.
+func (p *Http) fireSchedule(e *schedule.Entry) {
+	p.l.RLock()
+	wotServer, ok := p.wotServers[e.Thing]
+	t := p.tenantOf(e.Thing)
+	p.l.RUnlock()
+
+	if !ok {
+		log.Info("HTTP: scheduled action of unbound thing dropped -> ", e.Thing, " ", e.Action)
+		return
+	}
+
+	taskID := e.ID
+	var slot *atomic.Value
+
+	if e.Recurring() {
+		taskID, slot = t.actionResults.CreateSlot(e.Thing, e.Action)
+	} else {
+		slot = t.actionResults.RestoreSlot(e.ID, e.Thing, e.Action)
+	}
+
+	clients, ok := t.subscribers.Clients(taskID)
+	if !ok {
+		clients = async.NewFanOut()
+		t.subscribers.CreateSubscription(&server.Subscription{
+			ID:      taskID,
+			Thing:   e.Thing,
+			Name:    e.Action,
+			Clients: clients,
+		})
+	}
+
+	log.Info("HTTP: scheduled action invoked -> ", e.Thing, " ", e.Action)
+
+	ph := server.NewTaskProgressHandler(taskID, e.Action, slot, clients)
+	invocation := wotServer.InvokeAction(e.Action, e.Input, ph)
+
+	go func() {
+		result := &ScheduleResult{
+			Schedule: e.ID,
+			Thing:    e.Thing,
+			Action:   e.Action,
+			Task:     taskID,
+		}
+
+		if _, err := invocation.Wait(); err != nil {
+			result.Status, result.Data = server.TASK_FAILED, err.Error()
+		} else if status, ok := slot.Load().(*server.TaskStatus); ok {
+			result.Status, result.Data = status.Status, status.Data
+		}
+
+		result.Time = time.Now()
+		p.scheduleResults.Publish(result)
+	}()
+}
+
+// registerScheduleAdmin exposes recurring schedule management and stream of execution results
+func (p *Http) registerScheduleAdmin() {
+	p.adminRoute("GET", "/admin/schedules", auth.RIGHT_READ, "schedules", func(w http.ResponseWriter, r *http.Request) {
+		sendOK(w, r, p.scheduler.List(""))
+	})
+
+	p.adminRoute("POST", "/admin/schedules", auth.RIGHT_WRITE, "schedules", func(w http.ResponseWriter, r *http.Request) {
+		e := &schedule.Entry{}
+
+		if err := readBody(r, e); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		if !e.Recurring() {
+			sendCode(w, r, http.StatusBadRequest, "Cron expression required, use action ?at= for deferred invocation.")
+			return
+		}
+
+		p.l.RLock()
+		wotServer, ok := p.wotServers[e.Thing]
+		p.l.RUnlock()
+
+		if !ok {
+			sendCode(w, r, http.StatusNotFound, "Thing not bound.")
+			return
+		}
+
+		if _, ok := findAction(wotServer.GetDescription(), e.Action); !ok {
+			sendCode(w, r, http.StatusNotFound, "Unknown action.")
+			return
+		}
+
+		e.ID, _ = sec.UUID4()
+		if err := p.scheduler.Add(e); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		log.Info("HTTP admin: recurring schedule added -> ", e.Thing, " ", e.Action, " ", e.Cron)
+		sendCode(w, r, http.StatusCreated, e)
+	})
+
+	p.adminRoute("DELETE", "/admin/schedules/{id}", auth.RIGHT_WRITE, "schedules", func(w http.ResponseWriter, r *http.Request) {
+		if !p.scheduler.Cancel(mux.Vars(r)["id"]) {
+			sendCode(w, r, http.StatusNotFound, "Schedule not found.")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	p.adminRoute("GET", "/admin/schedules/ws", auth.RIGHT_READ, "schedules", func(w http.ResponseWriter, r *http.Request) {
+		p.fanOutWS(w, r, p.scheduleResults, func(interface{}) bool { return true })
+	})
+}
+
+func findAction(td *model.ThingDescription, name string) (*model.Action, bool) {
+	for i := range td.Actions {
+		if td.Actions[i].Name == name {
+			return &td.Actions[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// registerSchedules exposes pending deferred invocations of Thing, listing requires authentication,
+// cancellation invoke right on scheduled action
+func (p *Http) registerSchedules(rt *router, t *tenant, ctxPath string, s *server.WotServer) {
+	p.addRoute(rt, &route{
+		method:  "GET",
+		pattern: contextPath(ctxPath, "schedules"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			sendOK(w, r, p.scheduler.List(ctxPath))
+		},
+	})
+
+	p.addRoute(rt, &route{
+		method:  "DELETE",
+		pattern: contextPath(ctxPath, "schedules/{id}"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			e, ok := p.scheduler.Get(id)
+
+			if !ok || e.Thing != ctxPath {
+				sendCode(w, r, http.StatusNotFound, "Schedule not found.")
+				return
+			}
+
+			if !p.authorized(w, r, t, auth.RIGHT_INVOKE, s, e.Action) {
+				return
+			}
+
+			if !p.scheduler.Cancel(id) {
+				sendCode(w, r, http.StatusNotFound, "Schedule not found.")
+				return
+			}
+
+			if slot, ok := t.actionResults.GetSlot(id); ok {
+				clients, _ := t.subscribers.Clients(id)
+				if clients == nil {
+					clients = async.NewFanOut()
+				}
+				server.NewTaskProgressHandler(id, e.Action, slot, clients).Fail("Schedule cancelled")
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+}
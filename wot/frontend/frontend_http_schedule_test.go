@@ -0,0 +1,131 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/schedule"
+	"github.com/conas/tno2/wot/server"
+)
+
+// scheduled invokes action of converter at time at and returns its task and schedule paths
+func scheduled(t *testing.T, h http.Handler, at time.Time) (string, string) {
+	w := serve(h, "POST", "/converter/action/upper?at="+url.QueryEscape(at.Format(time.RFC3339Nano)), `"x"`)
+
+	var ls Links
+	json.Unmarshal(w.Body.Bytes(), &ls)
+	Equals("Links", t, 3, len(ls.Links))
+
+	u, _ := url.Parse(ls.Links[2].Href)
+	Equals("Schedule link", t, "schedule", ls.Links[2].Rel)
+
+	return taskPath(t, w), u.Path
+}
+
+func TestCaseDeferredAction(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/converter", converter())
+
+	task, _ := scheduled(t, p, time.Now().Add(200*time.Millisecond))
+
+	status := &server.TaskStatus{}
+	json.Unmarshal(serve(p, "GET", task, "").Body.Bytes(), status)
+	Equals("Pending", t, server.TASK_SCHEDULED, status.Status)
+
+	var pending []*schedule.Entry
+	json.Unmarshal(serve(p, "GET", "/converter/schedules", "").Body.Bytes(), &pending)
+	Equals("Listed", t, 1, len(pending))
+	Equals("Listed action", t, "upper", pending[0].Action)
+
+	status = finished(t, p, task)
+	Equals("Executed", t, server.TASK_DONE, status.Status)
+	Equals("Result", t, "x", status.Data)
+
+	w := serve(p, "POST", "/converter/action/upper?at=tomorrow", `"x"`)
+	Equals("Invalid time", t, http.StatusBadRequest, w.Code)
+}
+
+func TestCaseCancelDeferredAction(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/converter", converter())
+
+	task, sched := scheduled(t, p, time.Now().Add(time.Hour))
+	Equals("Schedule of task", t, path.Base(task), path.Base(sched))
+
+	w := serve(p, "DELETE", sched, "")
+	Equals("Cancelled", t, http.StatusNoContent, w.Code)
+
+	Equals("Task failed", t, server.TASK_FAILED, finished(t, p, task).Status)
+
+	w = serve(p, "GET", "/converter/schedules", "")
+	Equals("Empty", t, "[]", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "DELETE", sched, "")
+	Equals("Cancelled twice", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseRecurringSchedules(t *testing.T) {
+	p := adminHTTP()
+
+	w := serve(p, "POST", "/admin/schedules", `{"thing": "/lamp", "action": "toggle", "cron": "0 6 * * *"}`, "X-API-Key", "k-viewer")
+	Equals("Added by viewer", t, http.StatusForbidden, w.Code)
+
+	w = serve(p, "POST", "/admin/schedules", `{"thing": "/lamp", "action": "toggle"}`, "X-API-Key", "k-admin")
+	Equals("Without cron", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "POST", "/admin/schedules", `{"thing": "/lamp", "action": "dim", "cron": "0 6 * * *"}`, "X-API-Key", "k-admin")
+	Equals("Unknown action", t, http.StatusNotFound, w.Code)
+
+	w = serve(p, "POST", "/admin/schedules", `{"thing": "/lamp", "action": "toggle", "cron": "0 6 * * *"}`, "X-API-Key", "k-admin")
+	Equals("Added", t, http.StatusCreated, w.Code)
+	added := &schedule.Entry{}
+	json.Unmarshal(w.Body.Bytes(), added)
+	Equals("Next execution", t, 6, added.At.Hour())
+
+	var listed []*schedule.Entry
+	json.Unmarshal(serve(p, "GET", "/admin/schedules", "", "X-API-Key", "k-viewer").Body.Bytes(), &listed)
+	Equals("Listed", t, 1, len(listed))
+	Equals("Listed schedule", t, added.ID, listed[0].ID)
+
+	w = serve(p, "DELETE", "/admin/schedules/"+added.ID, "", "X-API-Key", "k-admin")
+	Equals("Cancelled", t, http.StatusNoContent, w.Code)
+
+	w = serve(p, "DELETE", "/admin/schedules/"+added.ID, "", "X-API-Key", "k-admin")
+	Equals("Cancelled twice", t, http.StatusNotFound, w.Code)
+}
+
+func TestCaseScheduleResults(t *testing.T) {
+	p := adminHTTP()
+
+	conn, closer := dial(t, p, "/admin/schedules/ws", http.Header{"X-Api-Key": {"k-viewer"}})
+	defer closer()
+
+	//subscriber is registered after upgrade, schedule fires until it receives result
+	received := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-received:
+				return
+			case <-time.After(20 * time.Millisecond):
+				p.fireSchedule(&schedule.Entry{ID: "morning", Thing: "/lamp", Action: "toggle", Cron: "0 6 * * *"})
+			}
+		}
+	}()
+
+	result := &ScheduleResult{}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	err := conn.ReadJSON(result)
+	close(received)
+	Equals("Read", t, nil, err)
+
+	Equals("Schedule", t, "morning", result.Schedule)
+	Equals("Action", t, "toggle", result.Action)
+	Equals("Status", t, server.TASK_DONE, result.Status)
+	Equals("New task", t, true, result.Task != "" && result.Task != "morning")
+}
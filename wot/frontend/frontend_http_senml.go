@@ -0,0 +1,64 @@
+package frontend
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/conas/tno2/util/senml"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// acceptsSenML returns SenML encoder of representation asked for in Accept header
+func acceptsSenML(r *http.Request) (*SenmlEncoder, bool) {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if encoder, ok := senmlEncoder(strings.TrimSpace(accept)); ok {
+			return encoder, true
+		}
+	}
+
+	return nil, false
+}
+
+// senmlBody returns SenML encoder of request body
+func senmlBody(r *http.Request) (*SenmlEncoder, bool) {
+	return senmlEncoder(r.Header.Get("Content-Type"))
+}
+
+func senmlEncoder(contentType string) (*SenmlEncoder, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	switch mt {
+	case senml.CONTENT_TYPE_JSON:
+		return NewSenmlJsonEncoder(), true
+	case senml.CONTENT_TYPE_CBOR:
+		return NewSenmlCborEncoder(), true
+	}
+
+	return nil, false
+}
+
+// sendSenML sends property value as record named by property with Thing name as base name and
+// declared unit of the property
+func sendSenML(w http.ResponseWriter, r *http.Request, encoder *SenmlEncoder, thing string, prop model.Property, payload interface{}) {
+	record := senml.Measurement(prop.Name, prop.Unit, time.Now(), payload)
+	record.BaseName = str.Concat(thing, "/")
+
+	var buf bytes.Buffer
+	if err := encoder.EncodePack(&buf, senml.Pack{record}); err != nil {
+		sendPlainERR(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,60 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/senml"
+	"github.com/gorilla/websocket"
+)
+
+func TestCasePropertySenML(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/lamp/property/power", "", "Accept", senml.CONTENT_TYPE_JSON)
+	Equals("JSON content type", t, senml.CONTENT_TYPE_JSON, w.Header().Get("Content-Type"))
+	var pack senml.Pack
+	json.Unmarshal(w.Body.Bytes(), &pack)
+	Equals("Records", t, 1, len(pack))
+	Equals("Name", t, "lamp/power", pack.Resolved()[0].Name)
+	Equals("Value", t, 7.5, *pack[0].Value)
+
+	w = serve(p, "GET", "/lamp/property/power", "", "Accept", senml.CONTENT_TYPE_CBOR)
+	Equals("CBOR content type", t, senml.CONTENT_TYPE_CBOR, w.Header().Get("Content-Type"))
+	pack, err := senml.UnmarshalCBOR(w.Body.Bytes())
+	Equals("CBOR", t, nil, err)
+	Equals("CBOR value", t, 7.5, *pack[0].Value)
+
+	serve(p, "PUT", "/lamp/property/on", `[{"n": "on", "vb": true}]`, "Content-Type", senml.CONTENT_TYPE_JSON)
+	w = serve(p, "GET", "/lamp/property/on", "")
+	Equals("Written", t, "true", strings.TrimSpace(w.Body.String()))
+}
+
+func TestCaseEventStreamSenML(t *testing.T) {
+	p := testHTTP(nil)
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	ws := subscribe(t, p, "/lamp/event/property-change")
+
+	w := serve(p, "GET", ws+"?encoding=xml", "")
+	Equals("Unknown encoding", t, http.StatusBadRequest, w.Code)
+
+	conn, closer := dial(t, p, ws+"?encoding=senml%2Bcbor", nil)
+	defer closer()
+
+	s.EmitPropertyChange("on", true)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	Equals("Read", t, nil, err)
+	Equals("Binary frame", t, websocket.BinaryMessage, messageType)
+
+	pack, err := senml.UnmarshalCBOR(data)
+	Equals("CBOR", t, nil, err)
+	Equals("Pack", t, true, len(pack) > 0)
+}
@@ -0,0 +1,151 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	// HEADER_JWS_SIGNATURE carries JWS with detached payload signing request body
+	HEADER_JWS_SIGNATURE = "X-JWS-Signature"
+	MAX_SIGNED_INPUT     = 1 << 20
+)
+
+// SignedInvocation is entry of signature trail. It keeps signed input with its signature, so anyone
+// holding public key of client can verify the client requested the invocation.
+type SignedInvocation struct {
+	Time      time.Time `json:"time"`
+	Thing     string    `json:"thing"`
+	Action    string    `json:"action"`
+	KeyID     string    `json:"kid"`
+	Input     []byte    `json:"input"`
+	Signature string    `json:"signature"`
+}
+
+// signatures verifies invocations of signed actions by public keys of clients, key id names the client
+type signatures struct {
+	l     *sync.RWMutex
+	keys  map[string]crypto.PublicKey
+	trail *json.Encoder
+}
+
+// configureSignatures loads client keys from cfg "signatureKeys" directory of <kid>.pem files and opens
+// trail of signed invocations at "signatureTrail", one JSON encoded SignedInvocation per line
+func (p *Http) configureSignatures(cfg map[string]interface{}) {
+	p.signatures = &signatures{
+		l:    &sync.RWMutex{},
+		keys: make(map[string]crypto.PublicKey),
+	}
+
+	if dir, ok := cfg["signatureKeys"].(string); ok {
+		keys, err := jose.LoadPublicKeys(dir)
+		if err != nil {
+			log.Error("HTTP: signature keys not loaded, signed actions are rejected -> ", err)
+		} else {
+			p.signatures.keys = keys
+		}
+	}
+
+	if path, ok := cfg["signatureTrail"].(string); ok {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Error("HTTP: signature trail not opened -> ", err)
+			return
+		}
+		p.signatures.trail = json.NewEncoder(f)
+	}
+}
+
+// SetSignatureKey adds or replaces public key of client verifying its signed invocations
+func (p *Http) SetSignatureKey(kid string, key crypto.PublicKey) {
+	p.signatures.l.Lock()
+	defer p.signatures.l.Unlock()
+
+	p.signatures.keys[kid] = key
+}
+
+func (s *signatures) key(kid string) (crypto.PublicKey, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// verifySignature checks signature of invocation of signed action before input is read, body is
+// buffered so it can be decoded after verification. Verified invocation is written to trail.
+func (p *Http) verifySignature(w http.ResponseWriter, r *http.Request, wotServer *server.WotServer, actionName string) bool {
+	if !signedAction(wotServer, actionName) {
+		return true
+	}
+
+	token := r.Header.Get(HEADER_JWS_SIGNATURE)
+	if token == "" {
+		sendCode(w, r, http.StatusUnauthorized, "Action requires signed invocation.")
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MAX_SIGNED_INPUT+1))
+	if err != nil {
+		sendPlainERR(w, err)
+		return false
+	}
+	if len(body) > MAX_SIGNED_INPUT {
+		sendCode(w, r, http.StatusRequestEntityTooLarge, "Signed input too large.")
+		return false
+	}
+
+	header, err := jose.VerifyDetached(token, body, p.signatures.key)
+	if err != nil {
+		sendCode(w, r, http.StatusUnauthorized, err.Error())
+		return false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	p.signatures.record(&SignedInvocation{
+		Time:      time.Now(),
+		Thing:     wotServer.Name(),
+		Action:    actionName,
+		KeyID:     header.Kid,
+		Input:     body,
+		Signature: token,
+	})
+
+	return true
+}
+
+func (s *signatures) record(i *SignedInvocation) {
+	log.Info("HTTP: signed invocation of ", i.Thing, "/", i.Action, " by ", i.KeyID)
+
+	if s.trail == nil {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.trail.Encode(i); err != nil {
+		log.Error("HTTP: signature trail write failed -> ", err)
+	}
+}
+
+func signedAction(wotServer *server.WotServer, actionName string) bool {
+	for _, a := range wotServer.GetDescription().Actions {
+		if a.Name == actionName {
+			return a.Signed
+		}
+	}
+
+	return false
+}
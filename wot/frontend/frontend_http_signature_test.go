@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseSignedAction(t *testing.T) {
+	trail := filepath.Join(t.TempDir(), "signatures.log")
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "breaker",
+		Actions: []model.Action{{Name: "trip", Hrefs: []string{"action/trip"}, Synchronous: true, Signed: true}},
+	})
+	s.OnInvokeAction("trip", func(input interface{}, ph async.ProgressHandler) interface{} {
+		return input
+	})
+
+	p := testHTTP(map[string]interface{}{"signatureTrail": trail})
+	p.Bind("/breaker", s)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p.SetSignatureKey("operator", key.Public())
+
+	w := serve(p, "POST", "/breaker/action/trip", `"now"`)
+	Equals("Unsigned", t, http.StatusUnauthorized, w.Code)
+
+	other, _ := jose.SignDetached(key, "operator", []byte(`"later"`))
+	w = serve(p, "POST", "/breaker/action/trip", `"now"`, HEADER_JWS_SIGNATURE, other)
+	Equals("Signature of other input", t, http.StatusUnauthorized, w.Code)
+
+	unknown, _ := jose.SignDetached(key, "intruder", []byte(`"now"`))
+	w = serve(p, "POST", "/breaker/action/trip", `"now"`, HEADER_JWS_SIGNATURE, unknown)
+	Equals("Unknown key", t, http.StatusUnauthorized, w.Code)
+
+	signature, _ := jose.SignDetached(key, "operator", []byte(`"now"`))
+	w = serve(p, "POST", "/breaker/action/trip", `"now"`, HEADER_JWS_SIGNATURE, signature)
+	Equals("Signed", t, http.StatusOK, w.Code)
+
+	data, _ := os.ReadFile(trail)
+	entry := &SignedInvocation{}
+	Equals("Trail", t, nil, json.Unmarshal(data, entry))
+	Equals("Trail key", t, "operator", entry.KeyID)
+	Equals("Trail input", t, `"now"`, string(entry.Input))
+	Equals("Trail signature", t, signature, entry.Signature)
+}
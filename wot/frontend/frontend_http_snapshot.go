@@ -0,0 +1,66 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+)
+
+// registerSnapshot exposes {ctx}/snapshot. GET returns Thing state document including pending tasks,
+// POST restores writable properties from such document, e.g. to clone device or restore backup. Restore
+// requires write right to each restored property, as their direct writes do.
+func (p *Http) registerSnapshot(rt *router, t *tenant, ctxPath string, s *server.WotServer) {
+	p.addRoute(rt, &route{
+		method:  "GET",
+		pattern: contextPath(ctxPath, "snapshot"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authorized(w, r, t, auth.RIGHT_READ, s, "snapshot") {
+				return
+			}
+
+			snap := s.Snapshot()
+			snap.Tasks = make([]*server.TaskInfo, 0)
+			for _, task := range t.actionResults.Pending() {
+				if task.Thing == ctxPath {
+					snap.Tasks = append(snap.Tasks, task)
+				}
+			}
+
+			sendOK(w, r, snap)
+		},
+	})
+
+	p.addRoute(rt, &route{
+		method:  "POST",
+		pattern: contextPath(ctxPath, "snapshot"),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authorized(w, r, t, auth.RIGHT_WRITE, s, "snapshot") {
+				return
+			}
+
+			limitBody(w, r, MAX_BODY)
+
+			snap := &server.Snapshot{}
+			if err := readBody(r, snap); err != nil {
+				sendPlainERR(w, err)
+				return
+			}
+
+			//no property is written unless all of them may be, read-only properties are skipped by restore
+			for _, prop := range s.GetDescription().Properties {
+				if _, ok := snap.Properties[prop.Name]; ok && prop.Writable && !p.authorized(w, r, t, auth.RIGHT_WRITE, s, prop.Name) {
+					return
+				}
+			}
+
+			result := s.Restore(snap)
+			if result.Err() != nil {
+				sendERR(w, r, result)
+				return
+			}
+
+			sendOK(w, r, result)
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package frontend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/conas/tno2/wot/auth"
+)
+
+func TestCaseSnapshotRestoreRights(t *testing.T) {
+	rbac := auth.NewRBAC().
+		AddRole("restorer", auth.Allow(auth.RIGHT_READ|auth.RIGHT_WRITE, "lamp/snapshot")).
+		AddRole("operator", auth.Allow(auth.RIGHT_WRITE, "lamp/on"))
+	keys := auth.NewAPIKeys().
+		Add("k-restore", "backup", "restorer").
+		Add("k-operate", "service", "restorer", "operator")
+
+	p := testHTTP(map[string]interface{}{"auth": auth.NewGuard(rbac, keys)})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	snapshot := `{"properties": {"on": true, "power": 1}}`
+
+	w := serve(p, "POST", "/lamp/snapshot", snapshot, "X-API-Key", "k-restore", "Content-Type", "application/json")
+	Equals("Denied status", t, http.StatusForbidden, w.Code)
+	Equals("Denied value", t, false, s.GetProperty("on").Get())
+
+	w = serve(p, "POST", "/lamp/snapshot", snapshot, "X-API-Key", "k-operate", "Content-Type", "application/json")
+	Equals("Restored status", t, http.StatusOK, w.Code)
+	Equals("Restored value", t, true, s.GetProperty("on").Get())
+}
@@ -0,0 +1,551 @@
+package frontend
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+// SOCKETIO_PATH is path of Engine.IO endpoint, default path of Socket.IO clients
+const SOCKETIO_PATH = "/socket.io/"
+
+const (
+	SOCKETIO_PING_INTERVAL = 25 * time.Second
+	SOCKETIO_PING_TIMEOUT  = 20 * time.Second
+	// SOCKETIO_MAX_PAYLOAD limits packets of clients, they only connect and disconnect namespaces
+	SOCKETIO_MAX_PAYLOAD = 64 * 1024
+	// SOCKETIO_QUEUE packets are buffered per session, session of client not reading them is closed
+	SOCKETIO_QUEUE = 256
+)
+
+// Engine.IO v4 packet types
+const (
+	eioOpen    = '0'
+	eioClose   = '1'
+	eioPing    = '2'
+	eioPong    = '3'
+	eioMessage = '4'
+	eioUpgrade = '5'
+	eioNoop    = '6'
+)
+
+// Socket.IO v5 packet types
+const (
+	sioConnect      = '0'
+	sioDisconnect   = '1'
+	sioEvent        = '2'
+	sioConnectError = '4'
+)
+
+// socketIO delivers Thing events to Socket.IO clients. Namespace of Thing is its context path, client of
+// io("http://gateway/conas/dht-1") receives events of /conas/dht-1 by their names. Clients may restrict
+// events by {"events": [...]} and authenticate by {"token": "..."} in auth payload of connect.
+type socketIO struct {
+	l        *sync.Mutex
+	sessions map[string]*sioSession
+}
+
+// sioSession is Engine.IO session of polling or WebSocket transport with connected namespaces
+type sioSession struct {
+	id         string
+	r          *http.Request
+	out        chan string
+	done       chan struct{}
+	once       *sync.Once
+	l          *sync.Mutex
+	upgraded   bool
+	lastPong   time.Time
+	namespaces map[string]*sioNamespace
+}
+
+// sioNamespace is Thing namespace of session, listeners follow Thing when it is rebound
+type sioNamespace struct {
+	s         *server.WotServer
+	events    []string
+	listeners map[string]*server.EventListener
+}
+
+// configureSocketIO enables Socket.IO adapter of events by "socketio"
+func (p *Http) configureSocketIO(cfg map[string]interface{}) {
+	if enabled, ok := cfg["socketio"].(bool); !ok || !enabled {
+		return
+	}
+
+	p.socketIO = &socketIO{
+		l:        &sync.Mutex{},
+		sessions: make(map[string]*sioSession),
+	}
+
+	p.addRoute(p.router, &route{method: "GET", pattern: SOCKETIO_PATH, handlerFunc: p.socketIOHandler})
+	p.addRoute(p.router, &route{method: "POST", pattern: SOCKETIO_PATH, handlerFunc: p.socketIOHandler})
+}
+
+func (p *Http) socketIOHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("EIO") != "4" {
+		sendCode(w, r, http.StatusBadRequest, "Unsupported Engine.IO protocol version.")
+		return
+	}
+
+	//polling clients of other origins send credentials
+	if origin := r.Header.Get("Origin"); origin != "" && p.ws.checkOrigin(r) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	var session *sioSession
+	if sid := q.Get("sid"); sid != "" {
+		p.socketIO.l.Lock()
+		session = p.socketIO.sessions[sid]
+		p.socketIO.l.Unlock()
+
+		if session == nil {
+			sendCode(w, r, http.StatusBadRequest, "Unknown session.")
+			return
+		}
+	}
+
+	switch {
+	case q.Get("transport") == "websocket":
+		p.socketIOWebSocket(w, r, session)
+	case q.Get("transport") != "polling":
+		sendCode(w, r, http.StatusBadRequest, "Unsupported transport.")
+	case session == nil && r.Method == "GET":
+		session = p.openSession(r)
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.Write([]byte(session.openPacket()))
+	case session == nil:
+		sendCode(w, r, http.StatusBadRequest, "Session required.")
+	case r.Method == "GET":
+		p.socketIOPoll(w, r, session)
+	default:
+		data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, SOCKETIO_MAX_PAYLOAD))
+		if err != nil {
+			sendCode(w, r, http.StatusRequestEntityTooLarge, "Payload too large.")
+			return
+		}
+		for _, packet := range strings.Split(string(data), "\x1e") {
+			p.socketIOPacket(session, packet)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.Write([]byte("ok"))
+	}
+}
+
+func (p *Http) openSession(r *http.Request) *sioSession {
+	id, _ := sec.UUID4()
+	session := &sioSession{
+		id:         id,
+		r:          r,
+		out:        make(chan string, SOCKETIO_QUEUE),
+		done:       make(chan struct{}),
+		once:       &sync.Once{},
+		l:          &sync.Mutex{},
+		lastPong:   time.Now(),
+		namespaces: make(map[string]*sioNamespace),
+	}
+
+	p.socketIO.l.Lock()
+	p.socketIO.sessions[id] = session
+	p.socketIO.l.Unlock()
+
+	go p.socketIOPing(session)
+
+	return session
+}
+
+func (s *sioSession) openPacket() string {
+	open, _ := json.Marshal(map[string]interface{}{
+		"sid":          s.id,
+		"upgrades":     []string{"websocket"},
+		"pingInterval": int(SOCKETIO_PING_INTERVAL / time.Millisecond),
+		"pingTimeout":  int(SOCKETIO_PING_TIMEOUT / time.Millisecond),
+		"maxPayload":   SOCKETIO_MAX_PAYLOAD,
+	})
+
+	return str.Concat(string(eioOpen), string(open))
+}
+
+// send queues packet, session of client not reading packets is closed
+func (s *sioSession) send(packet string) {
+	select {
+	case s.out <- packet:
+	case <-s.done:
+	default:
+		log.Warn("HTTP: Socket.IO session ", s.id, " not reading, closed")
+		s.close()
+	}
+}
+
+func (s *sioSession) close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// socketIOPing pings client, session is closed when client does not answer
+func (p *Http) socketIOPing(session *sioSession) {
+	ticker := time.NewTicker(SOCKETIO_PING_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			session.l.Lock()
+			alive := time.Since(session.lastPong) < SOCKETIO_PING_INTERVAL+SOCKETIO_PING_TIMEOUT
+			session.l.Unlock()
+
+			if !alive {
+				session.close()
+				continue
+			}
+			session.send(string(eioPing))
+		case <-session.done:
+			p.socketIO.l.Lock()
+			delete(p.socketIO.sessions, session.id)
+			p.socketIO.l.Unlock()
+
+			session.l.Lock()
+			for ctxPath, ns := range session.namespaces {
+				ns.detach()
+				delete(session.namespaces, ctxPath)
+				p.limits.subscriptions.release(ctxPath)
+			}
+			session.l.Unlock()
+			return
+		}
+	}
+}
+
+// socketIOPoll answers long polling request by queued packets, it waits for the first one
+func (p *Http) socketIOPoll(w http.ResponseWriter, r *http.Request, session *sioSession) {
+	packets := make([]string, 0)
+
+	select {
+	case packet := <-session.out:
+		packets = append(packets, packet)
+	case <-session.done:
+		packets = append(packets, string(eioClose))
+	case <-r.Context().Done():
+		return
+	}
+
+	session.l.Lock()
+	upgraded := session.upgraded
+	session.l.Unlock()
+
+	//packets of upgraded session are written by WebSocket, pending poll is finished by noop
+	if upgraded {
+		session.send(packets[0])
+		packets[0] = string(eioNoop)
+	}
+
+	for !upgraded && len(packets) < SOCKETIO_QUEUE {
+		select {
+		case packet := <-session.out:
+			packets = append(packets, packet)
+			continue
+		default:
+		}
+		break
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	w.Write([]byte(strings.Join(packets, "\x1e")))
+}
+
+// socketIOWebSocket opens session on WebSocket or upgrades polling session to it
+func (p *Http) socketIOWebSocket(w http.ResponseWriter, r *http.Request, session *sioSession) {
+	conn, err := p.ws.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error("HTTP: Socket.IO upgrade failed -> ", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(SOCKETIO_MAX_PAYLOAD)
+
+	//packets are written to WebSocket once polling of session is stopped
+	write := func() {
+		session.l.Lock()
+		session.upgraded = true
+		session.l.Unlock()
+
+		for {
+			select {
+			case packet := <-session.out:
+				if conn.WriteMessage(websocket.TextMessage, []byte(packet)) != nil {
+					session.close()
+					return
+				}
+			case <-session.done:
+				conn.WriteMessage(websocket.TextMessage, []byte{eioClose})
+				conn.Close()
+				return
+			}
+		}
+	}
+
+	if session == nil {
+		session = p.openSession(r)
+		if conn.WriteMessage(websocket.TextMessage, []byte(session.openPacket())) != nil {
+			session.close()
+			return
+		}
+		go write()
+	} else {
+		//probe confirms WebSocket works before polling is stopped
+		_, probe, err := conn.ReadMessage()
+		if err != nil || string(probe) != "2probe" {
+			return
+		}
+		if conn.WriteMessage(websocket.TextMessage, []byte("3probe")) != nil {
+			return
+		}
+		//pending poll is finished so client sends upgrade
+		session.send(string(eioNoop))
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			session.close()
+			return
+		}
+
+		if string(data) == string(eioUpgrade) {
+			go write()
+			continue
+		}
+		p.socketIOPacket(session, string(data))
+	}
+}
+
+// socketIOPacket handles Engine.IO packet of client
+func (p *Http) socketIOPacket(session *sioSession, packet string) {
+	if packet == "" {
+		return
+	}
+
+	switch packet[0] {
+	case eioPong:
+		session.l.Lock()
+		session.lastPong = time.Now()
+		session.l.Unlock()
+	case eioClose:
+		session.close()
+	case eioMessage:
+		p.socketIOMessage(session, packet[1:])
+	}
+}
+
+// socketIOMessage handles Socket.IO packet: connect and disconnect of namespaces. Events emitted by
+// clients are ignored.
+func (p *Http) socketIOMessage(session *sioSession, packet string) {
+	if packet == "" {
+		return
+	}
+
+	kind, rest := packet[0], packet[1:]
+	namespace := "/"
+	if strings.HasPrefix(rest, "/") {
+		if i := strings.Index(rest, ","); i >= 0 {
+			namespace, rest = rest[:i], rest[i+1:]
+		} else {
+			namespace, rest = rest, ""
+		}
+	}
+
+	switch kind {
+	case sioConnect:
+		p.socketIOConnect(session, namespace, rest)
+	case sioDisconnect:
+		session.l.Lock()
+		ns, ok := session.namespaces[namespace]
+		delete(session.namespaces, namespace)
+		session.l.Unlock()
+
+		if ok {
+			ns.detach()
+			p.limits.subscriptions.release(namespace)
+		}
+	}
+}
+
+// sioConnectAuth is auth payload of namespace connect
+type sioConnectAuth struct {
+	Token  string   `json:"token"`
+	Events []string `json:"events"`
+}
+
+func (p *Http) socketIOConnect(session *sioSession, namespace, payload string) {
+	reply := func(kind byte, v interface{}) {
+		data, _ := json.Marshal(v)
+		prefix := ""
+		if namespace != "/" {
+			prefix = str.Concat(namespace, ",")
+		}
+		session.send(str.Concat(string(eioMessage), string(kind), prefix, string(data)))
+	}
+	refuse := func(message string) {
+		reply(sioConnectError, map[string]string{"message": message})
+	}
+
+	var connectAuth sioConnectAuth
+	if payload != "" && json.Unmarshal([]byte(payload), &connectAuth) != nil {
+		refuse("Invalid auth payload.")
+		return
+	}
+
+	//root namespace carries no events
+	if namespace == "/" {
+		reply(sioConnect, map[string]string{"sid": session.id})
+		return
+	}
+
+	p.l.RLock()
+	s, ok := p.wotServers[namespace]
+	t := p.tenantOf(namespace)
+	p.l.RUnlock()
+
+	if !ok {
+		refuse("Unknown thing.")
+		return
+	}
+
+	//token of auth payload replaces credentials of handshake, browsers cannot set WebSocket headers
+	r := session.r
+	if connectAuth.Token != "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", str.Concat("Bearer ", connectAuth.Token))
+	}
+
+	requested := make(map[string]bool)
+	for _, name := range connectAuth.Events {
+		requested[name] = true
+	}
+
+	events := make([]string, 0)
+	for _, e := range s.GetDescription().Events {
+		if len(requested) > 0 && !requested[e.Name] {
+			continue
+		}
+		if t.guard != nil {
+			if _, status := t.guard.Check(r, auth.RIGHT_SUBSCRIBE, auth.Resource(s.Name(), e.Name)); status != auth.AUTH_OK {
+				continue
+			}
+		}
+		events = append(events, e.Name)
+	}
+
+	if len(events) == 0 {
+		refuse("Access to events denied.")
+		return
+	}
+
+	session.l.Lock()
+	_, connected := session.namespaces[namespace]
+	session.l.Unlock()
+
+	if !connected && !p.limits.subscriptions.acquire(namespace) {
+		refuse("Too many subscriptions.")
+		return
+	}
+
+	ns := &sioNamespace{events: events, listeners: make(map[string]*server.EventListener)}
+	ns.attach(session, namespace, s)
+
+	session.l.Lock()
+	if previous, ok := session.namespaces[namespace]; ok {
+		previous.detach()
+	}
+	session.namespaces[namespace] = ns
+	session.l.Unlock()
+
+	reply(sioConnect, map[string]string{"sid": session.id})
+}
+
+// attach adds listeners of namespace events declared by WotServer
+func (ns *sioNamespace) attach(session *sioSession, namespace string, s *server.WotServer) {
+	ns.s = s
+	ns.listeners = make(map[string]*server.EventListener)
+
+	declared := make(map[string]bool)
+	for _, e := range s.GetDescription().Events {
+		declared[e.Name] = true
+	}
+
+	for _, name := range ns.events {
+		if !declared[name] {
+			continue
+		}
+
+		id, _ := sec.UUID4()
+		listener := &server.EventListener{
+			ID: id,
+			CB: func(event interface{}) {
+				data := event
+				if e, ok := event.(*server.Event); ok {
+					data = e.Data
+				}
+
+				packet, err := json.Marshal([]interface{}{name, data})
+				if err != nil {
+					log.Error("HTTP: Socket.IO event ", name, " not sent -> ", err)
+					return
+				}
+				session.send(str.Concat(string(eioMessage), string(sioEvent), namespace, ",", string(packet)))
+			},
+		}
+		ns.listeners[name] = listener
+		s.AddListener(name, listener)
+	}
+}
+
+func (ns *sioNamespace) detach() {
+	for name, listener := range ns.listeners {
+		ns.s.RemoveListener(name, listener)
+	}
+}
+
+// rebind moves namespaces of Thing to its new WotServer, namespaces of unbound Thing are disconnected
+func (sio *socketIO) rebind(ctxPath string, s *server.WotServer, limits *limits) {
+	if sio == nil {
+		return
+	}
+
+	sio.l.Lock()
+	sessions := make([]*sioSession, 0, len(sio.sessions))
+	for _, session := range sio.sessions {
+		sessions = append(sessions, session)
+	}
+	sio.l.Unlock()
+
+	for _, session := range sessions {
+		session.l.Lock()
+		ns, ok := session.namespaces[ctxPath]
+		if ok {
+			ns.detach()
+			if s != nil {
+				ns.attach(session, ctxPath, s)
+			} else {
+				delete(session.namespaces, ctxPath)
+			}
+		}
+		session.l.Unlock()
+
+		if ok && s == nil {
+			limits.subscriptions.release(ctxPath)
+			session.send(str.Concat(string(eioMessage), string(sioDisconnect), ctxPath, ","))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+// sioOpen opens Engine.IO polling session and returns its id
+func sioOpen(t *testing.T, h http.Handler) string {
+	w := serve(h, "GET", "/socket.io/?EIO=4&transport=polling", "")
+	if !strings.HasPrefix(w.Body.String(), "0") {
+		t.Fatal("session not opened: ", w.Code, " ", w.Body.String())
+	}
+
+	var open struct {
+		Sid string `json:"sid"`
+	}
+	json.Unmarshal(w.Body.Bytes()[1:], &open)
+
+	return open.Sid
+}
+
+// sioPoll returns packets queued for polling session, there has to be at least one
+func sioPoll(h http.Handler, sid string) []string {
+	w := serve(h, "GET", "/socket.io/?EIO=4&transport=polling&sid="+sid, "")
+	return strings.Split(w.Body.String(), "\x1e")
+}
+
+func sioSend(h http.Handler, sid string, packets ...string) *http.Response {
+	return serve(h, "POST", "/socket.io/?EIO=4&transport=polling&sid="+sid, strings.Join(packets, "\x1e")).Result()
+}
+
+func TestCaseSocketIOPolling(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"socketio": true})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	sid := sioOpen(t, p)
+	Equals("Connect sent", t, http.StatusOK, sioSend(p, sid, "40/lamp,").StatusCode)
+	Equals("Connected", t, `40/lamp,{"sid":"`+sid+`"}`, sioPoll(p, sid)[0])
+
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "on", Value: true})
+	Equals("Event", t, `42/lamp,["property-change",{"name":"on","value":true}]`, sioPoll(p, sid)[0])
+
+	p.Unbind("/lamp")
+	Equals("Disconnected by unbind", t, "41/lamp,", sioPoll(p, sid)[0])
+}
+
+func TestCaseSocketIORefused(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"socketio": true})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/socket.io/?EIO=3&transport=polling", "")
+	Equals("Engine.IO v3", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "GET", "/socket.io/?EIO=4&transport=polling&sid=unknown", "")
+	Equals("Unknown session", t, http.StatusBadRequest, w.Code)
+
+	sid := sioOpen(t, p)
+	sioSend(p, sid, "40/unknown,", `40/lamp,{"events": ["other"]}`)
+
+	packets := sioPoll(p, sid)
+	Equals("Unknown thing", t, `44/unknown,{"message":"Unknown thing."}`, packets[0])
+	Equals("No events", t, `44/lamp,{"message":"Access to events denied."}`, packets[1])
+}
+
+func TestCaseSocketIOWebSocket(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"socketio": true})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	conn, closer := dial(t, p, "/socket.io/?EIO=4&transport=websocket", nil)
+	defer closer()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	_, open, err := conn.ReadMessage()
+	Equals("Read", t, nil, err)
+	Equals("Open", t, "0", string(open[:1]))
+
+	conn.WriteMessage(websocket.TextMessage, []byte("40/lamp,"))
+	_, connected, _ := conn.ReadMessage()
+	Equals("Connected", t, true, strings.HasPrefix(string(connected), "40/lamp,"))
+
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "on", Value: false})
+	_, event, _ := conn.ReadMessage()
+	Equals("Event", t, `42/lamp,["property-change",{"name":"on","value":false}]`, string(event))
+}
+
+func TestCaseSocketIOUpgrade(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"socketio": true})
+	s := lamp()
+	p.Bind("/lamp", s)
+
+	sid := sioOpen(t, p)
+	sioSend(p, sid, "40/lamp,")
+	sioPoll(p, sid)
+
+	conn, closer := dial(t, p, "/socket.io/?EIO=4&transport=websocket&sid="+sid, nil)
+	defer closer()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	conn.WriteMessage(websocket.TextMessage, []byte("2probe"))
+	_, probe, _ := conn.ReadMessage()
+	Equals("Probe", t, "3probe", string(probe))
+	Equals("Pending poll finished", t, "6", sioPoll(p, sid)[0])
+
+	conn.WriteMessage(websocket.TextMessage, []byte("5"))
+
+	//event queued meanwhile is written to WebSocket once client completes upgrade
+	s.EmitEvent(server.PROPERTY_CHANGE_EVENT, &server.PropertyChange{Name: "on", Value: true})
+
+	_, event, err := conn.ReadMessage()
+	Equals("Read", t, nil, err)
+	Equals("Event over WebSocket", t, `42/lamp,["property-change",{"name":"on","value":true}]`, string(event))
+}
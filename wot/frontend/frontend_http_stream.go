@@ -0,0 +1,66 @@
+package frontend
+
+import (
+	"io"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/server"
+)
+
+const CONTENT_TYPE_OCTET_STREAM = "application/octet-stream"
+
+// asStream recognizes streamed property values
+func asStream(v interface{}) (*server.Stream, bool) {
+	switch s := v.(type) {
+	case *server.Stream:
+		return s, true
+	case io.Reader:
+		return &server.Stream{Reader: s}, true
+	}
+
+	return nil, false
+}
+
+// sendStream writes stream without buffering it. Seekable streams are served with range
+// and conditional request support, others are sent with chunked transfer encoding. Stream is not
+// closed, it is up to caller whether the stream is read again.
+func sendStream(w http.ResponseWriter, r *http.Request, name string, s *server.Stream) {
+	contentType := s.ContentType
+	if contentType == "" {
+		contentType = CONTENT_TYPE_OCTET_STREAM
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if rs, ok := s.Reader.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, s.ModTime, rs)
+		return
+	}
+
+	w.Header().Set("Accept-Ranges", "none")
+	if !s.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", s.ModTime.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(flushWriter{w}, s.Reader); err != nil {
+		log.Info("HTTP: property stream interrupted ", name, " -> ", err)
+	}
+}
+
+// flushWriter flushes every chunk so slow producers are streamed to client as data comes
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return n, err
+}
@@ -0,0 +1,50 @@
+package frontend
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func streams() *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "camera",
+		Properties: []model.Property{
+			{Name: "log", ValueType: model.ValueType{Type: "string"}, Hrefs: []string{"property/log"}},
+			{Name: "live", ValueType: model.ValueType{Type: "string"}, Hrefs: []string{"property/live"}},
+		},
+	})
+
+	s.OnGetProperty("log", func() interface{} {
+		return &server.Stream{Reader: strings.NewReader("0123456789"), ContentType: "text/plain"}
+	})
+	s.OnGetProperty("live", func() interface{} {
+		return io.MultiReader(strings.NewReader("frame-1 "), strings.NewReader("frame-2"))
+	})
+
+	return s
+}
+
+func TestCasePropertyStream(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/camera", streams())
+
+	w := serve(p, "GET", "/camera/property/log", "")
+	Equals("Status", t, http.StatusOK, w.Code)
+	Equals("Content type", t, "text/plain", w.Header().Get("Content-Type"))
+	Equals("Body", t, "0123456789", w.Body.String())
+
+	w = serve(p, "GET", "/camera/property/log", "", "Range", "bytes=2-4")
+	Equals("Range status", t, http.StatusPartialContent, w.Code)
+	Equals("Range body", t, "234", w.Body.String())
+
+	w = serve(p, "GET", "/camera/property/live", "", "Range", "bytes=2-4")
+	Equals("Unseekable status", t, http.StatusOK, w.Code)
+	Equals("Unseekable ranges", t, "none", w.Header().Get("Accept-Ranges"))
+	Equals("Unseekable type", t, CONTENT_TYPE_OCTET_STREAM, w.Header().Get("Content-Type"))
+	Equals("Unseekable body", t, "frame-1 frame-2", w.Body.String())
+}
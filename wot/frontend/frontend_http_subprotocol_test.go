@@ -0,0 +1,76 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCaseEventSubprotocol(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	base := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	dialer := &websocket.Dialer{Subprotocols: []string{WS_SUBPROTOCOL_EVENT}}
+	conn, _, err := dialer.Dial(base+subscribe(t, p, "/lamp/event/property-change"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Equals("Negotiated", t, WS_SUBPROTOCOL_EVENT, conn.Subprotocol())
+	conn.Close()
+
+	dialer = &websocket.Dialer{Subprotocols: []string{"mqtt"}}
+	_, rs, err := dialer.Dial(base+subscribe(t, p, "/lamp/event/property-change"), nil)
+	Equals("Unsupported", t, true, err != nil && rs != nil && rs.StatusCode == http.StatusBadRequest)
+}
+
+func TestCaseEventCompression(t *testing.T) {
+	for compression, negotiated := range map[bool]bool{true: true, false: false} {
+		p := testHTTP(map[string]interface{}{"wsCompression": compression, "wsCompressionLevel": 9})
+		s := lamp()
+		p.Bind("/lamp", s)
+
+		srv := httptest.NewServer(p)
+		base := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+		dialer := &websocket.Dialer{EnableCompression: true}
+		conn, rs, err := dialer.Dial(base+subscribe(t, p, "/lamp/event/property-change"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		extensions := rs.Header.Get("Sec-WebSocket-Extensions")
+		Equals("Negotiated", t, negotiated, strings.HasPrefix(extensions, "permessage-deflate"))
+
+		s.EmitPropertyChange("on", true)
+
+		var e map[string]interface{}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		Equals("Event", t, nil, conn.ReadJSON(&e))
+
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func TestCaseSecureEventLinks(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"trustedProxies": []interface{}{"192.0.2.1"}})
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "POST", "/lamp/event/property-change", "")
+	var ls Links
+	json.Unmarshal(w.Body.Bytes(), &ls)
+	Equals("Plain", t, true, strings.HasPrefix(ls.Links[0].Href, "ws://"))
+
+	w = serve(p, "POST", "/lamp/event/property-change", "", "X-Forwarded-Proto", "https")
+	json.Unmarshal(w.Body.Bytes(), &ls)
+	Equals("Behind TLS proxy", t, true, strings.HasPrefix(ls.Links[0].Href, "wss://"))
+}
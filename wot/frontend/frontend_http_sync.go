@@ -0,0 +1,63 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	PREFER_REPRESENTATION = "return=representation"
+	PREFER_ASYNC          = "respond-async"
+)
+
+// synchronous reports whether invocation is answered by action result, action is synchronous by its
+// description or by Prefer: return=representation of request. Prefer: respond-async opts out of
+// synchronous action.
+func synchronous(r *http.Request, action *model.Action) bool {
+	prefer := preferences(r)
+
+	if prefer[PREFER_ASYNC] {
+		return false
+	}
+
+	return prefer[PREFER_REPRESENTATION] || (action != nil && action.Synchronous)
+}
+
+// preferences parses Prefer header (RFC 7240), preference parameters are ignored
+func preferences(r *http.Request) map[string]bool {
+	prefer := make(map[string]bool)
+
+	for _, header := range r.Header["Prefer"] {
+		for _, token := range strings.Split(header, ",") {
+			token, _, _ = strings.Cut(token, ";")
+			prefer[strings.ToLower(strings.TrimSpace(token))] = true
+		}
+	}
+
+	return prefer
+}
+
+// sendResult answers synchronous invocation by result of task taskid, 500 when action failed. Task not
+// finished within MAX_TASK_WAIT is answered 202 with its links, as asynchronous invocation.
+func (p *Http) sendResult(w http.ResponseWriter, r *http.Request, t *tenant, taskid string, slot *atomic.Value, hrefs *Links) {
+	state := p.awaitTask(r, t, taskid, slot, MAX_TASK_WAIT)
+
+	if !completed(state) {
+		sendCode(w, r, http.StatusAccepted, hrefs)
+		return
+	}
+
+	w.Header().Set("Preference-Applied", PREFER_REPRESENTATION)
+
+	status := state.(*server.TaskStatus)
+	if status.Status == server.TASK_FAILED {
+		sendCode(w, r, http.StatusInternalServerError, status.Data)
+		return
+	}
+
+	sendOK(w, r, status.Data)
+}
@@ -0,0 +1,91 @@
+package frontend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseSynchronousAction(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "POST", "/lamp/action/toggle", "null")
+	Equals("Answered by result", t, http.StatusOK, w.Code)
+	Equals("Result", t, "true", strings.TrimSpace(w.Body.String()))
+	Equals("Preference applied", t, PREFER_REPRESENTATION, w.Header().Get("Preference-Applied"))
+
+	w = serve(p, "POST", "/lamp/action/toggle", "null", "Prefer", PREFER_ASYNC)
+	Equals("Opted out", t, "", w.Header().Get("Preference-Applied"))
+	Equals("Task", t, server.TASK_DONE, finished(t, p, taskPath(t, w)).Status)
+}
+
+func TestCasePreferRepresentation(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/converter", converter())
+
+	w := serve(p, "POST", "/converter/action/upper", `"abc"`)
+	Equals("Asynchronous", t, server.TASK_DONE, finished(t, p, taskPath(t, w)).Status)
+
+	w = serve(p, "POST", "/converter/action/upper", `"abc"`, "Prefer", "wait=10, return=representation; x=1")
+	Equals("Answered by result", t, PREFER_REPRESENTATION, w.Header().Get("Preference-Applied"))
+	Equals("Result", t, `"abc"`, strings.TrimSpace(w.Body.String()))
+}
+
+func TestCaseSynchronousFailure(t *testing.T) {
+	p := testHTTP(nil)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "valve",
+		Actions: []model.Action{{Name: "close", Hrefs: []string{"action/close"}, Synchronous: true}},
+	})
+	s.OnInvokeAction("close", func(interface{}, async.ProgressHandler) interface{} {
+		return errors.New("valve jammed")
+	})
+	p.Bind("/valve", s)
+
+	w := serve(p, "POST", "/valve/action/close", "null")
+	Equals("Failed", t, http.StatusInternalServerError, w.Code)
+	Equals("Failure", t, true, strings.Contains(w.Body.String(), "valve jammed"))
+}
+
+func TestCaseProxyInvokesSynchronousAction(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	c, _ := proxy.NewHttpClient(srv.URL + "/lamp")
+
+	done := func(name string, task proxy.Task, err error) {
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			status, err := task.Status()
+			if err != nil {
+				t.Fatal(name, err)
+			}
+			if status.Status == server.TASK_DONE || time.Now().After(deadline) {
+				Equals(name, t, server.TASK_DONE, status.Status)
+				return
+			}
+		}
+	}
+
+	task, err := c.InvokeAction("toggle", nil)
+	done("Invoked", task, err)
+
+	task, err = c.InvokeActionStream("toggle", CONTENT_TYPE_JSON, strings.NewReader("null"))
+	done("Invoked by stream", task, err)
+}
@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/conas/tno2/wot/auth"
+	"github.com/gorilla/mux"
+)
+
+// TemplateInfo describes Thing Model Things can be instantiated from
+type TemplateInfo struct {
+	ID           string   `json:"id"`
+	CtxPath      string   `json:"ctxPath"`
+	Placeholders []string `json:"placeholders"`
+}
+
+// TemplateInstance is Thing requested to be instantiated from template
+type TemplateInstance struct {
+	ID     string                 `json:"id"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// Templates is implemented by platform instantiating Things from templates
+type Templates interface {
+	Templates() []*TemplateInfo
+	Instantiate(templateID, id string, params map[string]interface{}) (*ThingInfo, error)
+}
+
+// TemplateFrontend is implemented by admin frontends exposing template instantiation
+type TemplateFrontend interface {
+	AdminFrontend
+	EnableTemplates(templates Templates)
+}
+
+// EnableTemplates exposes /admin/templates. POST /admin/templates/{id}/instances creates Things listed
+// in body, instances are created in order and creation stops at the first failure. Requires admin API.
+func (p *Http) EnableTemplates(templates Templates) {
+	if p.admin == nil {
+		panic("Templates API requires admin API enabled.")
+	}
+
+	p.adminRoute("GET", "/admin/templates", auth.RIGHT_READ, "templates", func(w http.ResponseWriter, r *http.Request) {
+		sendOK(w, r, templates.Templates())
+	})
+
+	p.adminRoute("POST", "/admin/templates/{templateID}/instances", auth.RIGHT_WRITE, "templates", func(w http.ResponseWriter, r *http.Request) {
+		templateID := mux.Vars(r)["templateID"]
+
+		instances := make([]*TemplateInstance, 0)
+		if err := readBody(r, &instances); err != nil {
+			sendPlainERR(w, err)
+			return
+		}
+
+		created := make([]*ThingInfo, 0, len(instances))
+		for _, i := range instances {
+			info, err := templates.Instantiate(templateID, i.ID, i.Params)
+			if err != nil {
+				sendCode(w, r, http.StatusBadRequest, &struct {
+					Error   string       `json:"error"`
+					ID      string       `json:"id"`
+					Created []*ThingInfo `json:"created"`
+				}{err.Error(), i.ID, created})
+				return
+			}
+
+			created = append(created, info)
+		}
+
+		sendCode(w, r, http.StatusCreated, created)
+	})
+}
@@ -0,0 +1,55 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// lamps instantiates Things from template "lamp", parameter "room" is required
+type lamps struct{}
+
+func (lamps) Templates() []*TemplateInfo {
+	return []*TemplateInfo{{ID: "lamp", CtxPath: "/{{room}}/lamp", Placeholders: []string{"room"}}}
+}
+
+func (lamps) Instantiate(templateID, id string, params map[string]interface{}) (*ThingInfo, error) {
+	room, ok := params["room"].(string)
+	if templateID != "lamp" || !ok {
+		return nil, errors.New("room required")
+	}
+
+	return &ThingInfo{CtxPath: "/" + room + "/lamp", Name: id}, nil
+}
+
+func TestCaseTemplates(t *testing.T) {
+	p := adminHTTP()
+	p.EnableTemplates(lamps{})
+
+	var templates []*TemplateInfo
+	json.Unmarshal(serve(p, "GET", "/admin/templates", "", "X-API-Key", "k-viewer").Body.Bytes(), &templates)
+	Equals("Templates", t, 1, len(templates))
+	Equals("Placeholder", t, "room", templates[0].Placeholders[0])
+
+	instances := `[{"id": "lamp-1", "params": {"room": "kitchen"}}, {"id": "lamp-2", "params": {"room": "hall"}}]`
+	w := serve(p, "POST", "/admin/templates/lamp/instances", instances, "X-API-Key", "k-viewer")
+	Equals("Instantiated by viewer", t, http.StatusForbidden, w.Code)
+
+	w = serve(p, "POST", "/admin/templates/lamp/instances", instances, "X-API-Key", "k-admin")
+	Equals("Instantiated", t, http.StatusCreated, w.Code)
+	var created []*ThingInfo
+	json.Unmarshal(w.Body.Bytes(), &created)
+	Equals("Created", t, 2, len(created))
+	Equals("Instance", t, "/hall/lamp", created[1].CtxPath)
+
+	w = serve(p, "POST", "/admin/templates/lamp/instances", `[{"id": "lamp-3", "params": {"room": "attic"}}, {"id": "lamp-4"}]`, "X-API-Key", "k-admin")
+	Equals("Failed", t, http.StatusBadRequest, w.Code)
+	var failed struct {
+		ID      string       `json:"id"`
+		Created []*ThingInfo `json:"created"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &failed)
+	Equals("Failed instance", t, "lamp-4", failed.ID)
+	Equals("Created before failure", t, 1, len(failed.Created))
+}
@@ -0,0 +1,93 @@
+package frontend
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+)
+
+// tenant isolates Things of one customer. Each tenant has own authentication configuration
+// and own subscriptions and action results, so subscription or task IDs obtained on one tenant
+// can not be used to reach data of another tenant.
+type tenant struct {
+	name          string
+	guard         *auth.Guard
+	things        map[string]*server.WotServer
+	subscribers   *server.Subscribers
+	actionResults *server.ActionResults
+}
+
+func newTenant(name string, guard *auth.Guard) *tenant {
+	return &tenant{
+		name:          name,
+		guard:         guard,
+		things:        make(map[string]*server.WotServer),
+		subscribers:   server.NewSubscribers(),
+		actionResults: server.NewActionResults(),
+	}
+}
+
+// AddTenant registers tenant with its own auth configuration. Tenant Things are exposed under /t/{tenant}.
+func (p *Http) AddTenant(name string, guard *auth.Guard) {
+	p.l.Lock()
+	if _, ok := p.tenants[name]; ok {
+		p.l.Unlock()
+		panic(str.Concat("Tenant already defined: ", name))
+	}
+
+	t := newTenant(name, guard)
+	p.tenants[name] = t
+	p.l.Unlock()
+
+	p.registerTenantRoot(t)
+	p.registerAggregation(t, tenantPath(name, ""))
+	p.registerLifecycle(t)
+
+	if p.graphql {
+		p.registerGraphQL(t, tenantPath(name, ""))
+	}
+
+	log.Info("HTTP: tenant registered -> ", name)
+}
+
+// BindTenant binds WotServer to tenant scoped context path /t/{tenant}{ctxPath}
+func (p *Http) BindTenant(tenantName, ctxPath string, s *server.WotServer) {
+	p.l.RLock()
+	t, ok := p.tenants[tenantName]
+	p.l.RUnlock()
+
+	if !ok {
+		panic(str.Concat("Tenant not defined: ", tenantName))
+	}
+
+	p.bind(t, tenantPath(tenantName, ctxPath), s)
+}
+
+func (p *Http) registerTenantRoot(t *tenant) {
+	p.addRoute(p.router, &route{
+		method:  "GET",
+		pattern: tenantPath(t.name, ""),
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if !p.authenticated(w, r, t) {
+				return
+			}
+
+			ls := links()
+
+			p.l.RLock()
+			for path := range t.things {
+				ls.Links = append(ls.Links, httpSubURL(r, path[len(tenantPath(t.name, "")):]))
+			}
+			p.l.RUnlock()
+
+			sendOK(w, r, ls)
+		},
+	})
+}
+
+func tenantPath(tenantName, ctxPath string) string {
+	return str.Concat("/t/", tenantName, ctxPath)
+}
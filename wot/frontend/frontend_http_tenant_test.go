@@ -0,0 +1,41 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/auth"
+)
+
+func tenantGuard(key string) *auth.Guard {
+	return auth.NewGuard(auth.NewRBAC().AddRole("admin", auth.Allow(auth.RIGHT_ALL, "*")), auth.NewAPIKeys().Add(key, "user", "admin"))
+}
+
+func TestCaseTenants(t *testing.T) {
+	p := testHTTP(nil)
+	p.AddTenant("acme", tenantGuard("k-acme"))
+	p.AddTenant("other", tenantGuard("k-other"))
+	p.BindTenant("acme", "/lamp", lamp())
+	p.BindTenant("other", "/lamp", lamp())
+
+	w := serve(p, "GET", "/t/acme", "")
+	Equals("Unauthenticated", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "GET", "/t/acme", "", "X-API-Key", "k-other")
+	Equals("Key of other tenant", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "GET", "/t/acme", "", "X-API-Key", "k-acme")
+	Equals("Listing", t, http.StatusOK, w.Code)
+	Equals("Listed Thing", t, true, strings.Contains(w.Body.String(), "/t/acme/lamp"))
+
+	w = serve(p, "GET", "/t/acme/lamp/property/on", "", "X-API-Key", "k-acme")
+	Equals("Property", t, http.StatusOK, w.Code)
+	Equals("Property value", t, "false", strings.TrimSpace(w.Body.String()))
+
+	w = serve(p, "GET", "/t/acme/lamp/property/on", "", "X-API-Key", "k-other")
+	Equals("Property of other tenant", t, http.StatusUnauthorized, w.Code)
+
+	w = serve(p, "GET", "/", "")
+	Equals("Default tenant", t, false, strings.Contains(w.Body.String(), "/lamp"))
+}
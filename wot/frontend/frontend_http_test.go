@@ -0,0 +1,150 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
+
+// testHTTP creates HTTP binding configured by cfg, it is not started, requests are served by its ServeHTTP
+func testHTTP(cfg map[string]interface{}) *Http {
+	config := map[string]interface{}{"hostname": "localhost", "port": 8080}
+	for k, v := range cfg {
+		config[k] = v
+	}
+
+	return NewHTTP(config).(*Http)
+}
+
+// lamp is Thing with writable property "on", read-only "power" and action "toggle" flipping "on"
+func lamp() *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "lamp",
+		Properties: []model.Property{
+			{Name: "on", ValueType: model.ValueType{Type: "boolean"}, Writable: true, Hrefs: []string{"property/on"}},
+			{Name: "power", ValueType: model.ValueType{Type: "number"}, Hrefs: []string{"property/power"}},
+		},
+		Actions: []model.Action{
+			{Name: "toggle", Hrefs: []string{"action/toggle"}, Synchronous: true},
+		},
+		Events: []model.Event{
+			{Name: server.PROPERTY_CHANGE_EVENT, Hrefs: []string{"event/property-change"}},
+		},
+	})
+
+	on := false
+	s.OnGetProperty("on", func() interface{} { return on })
+	s.OnUpdateProperty("on", func(v interface{}) { on = v.(bool) })
+	s.OnGetProperty("power", func() interface{} { return 7.5 })
+	s.OnInvokeAction("toggle", func(interface{}, async.ProgressHandler) interface{} {
+		on = !on
+		return on
+	})
+
+	return s
+}
+
+// thermostat is Thing with writable object property "config" of mode, target and display settings
+func thermostat() *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "thermostat",
+		Properties: []model.Property{
+			{Name: "config", Writable: true, Hrefs: []string{"property/config"}, ValueType: model.ValueType{
+				Type: "object",
+				Properties: map[string]model.ValueType{
+					"mode":    {Type: "string"},
+					"target":  {Type: "number"},
+					"display": {Type: "object", Properties: map[string]model.ValueType{"unit": {Type: "string"}}},
+				},
+			}},
+		},
+		Events: []model.Event{
+			{Name: server.PROPERTY_CHANGE_EVENT, Hrefs: []string{"event/property-change"}},
+		},
+	})
+
+	config := interface{}(map[string]interface{}{"mode": "heat", "target": 21.0, "display": map[string]interface{}{"unit": "C"}})
+	s.OnGetProperty("config", func() interface{} { return config })
+	s.OnUpdateProperty("config", func(v interface{}) { config = v })
+
+	return s
+}
+
+// serve sends request to handler, header lists names and values of request headers
+func serve(h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+// subscribe subscribes event at href, path of subscription WebSocket is returned
+func subscribe(t *testing.T, h http.Handler, href string, header ...string) string {
+	w := serve(h, "POST", href, "", header...)
+
+	var ls Links
+	if err := json.Unmarshal(w.Body.Bytes(), &ls); err != nil || len(ls.Links) == 0 {
+		t.Fatal("not subscribed: ", w.Code, " ", w.Body.String())
+	}
+
+	u, _ := url.Parse(ls.Links[0].Href)
+	return u.Path
+}
+
+// taskPath returns path of task started by action invocation answered by w
+func taskPath(t *testing.T, w *httptest.ResponseRecorder) string {
+	var ls Links
+	json.Unmarshal(w.Body.Bytes(), &ls)
+
+	for _, l := range ls.Links {
+		if l.Rel == "rest" {
+			u, _ := url.Parse(l.Href)
+			return u.Path
+		}
+	}
+
+	t.Fatal("no task: ", w.Code, " ", w.Body.String())
+	return ""
+}
+
+// dial connects WebSocket of server started for handler at path, server is closed by returned func
+func dial(t *testing.T, h http.Handler, path string, header http.Header) (*websocket.Conn, func()) {
+	return dialWith(t, websocket.DefaultDialer, h, path, header)
+}
+
+// dialWith connects WebSocket by dialer, e.g. requesting subprotocol
+func dialWith(t *testing.T, dialer *websocket.Dialer, h http.Handler, path string, header http.Header) (*websocket.Conn, func()) {
+	srv := httptest.NewServer(h)
+
+	conn, _, err := dialer.Dial(str.Concat("ws", strings.TrimPrefix(srv.URL, "http"), path), header)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	return conn, func() {
+		conn.Close()
+		srv.Close()
+	}
+}
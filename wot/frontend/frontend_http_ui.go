@@ -0,0 +1,198 @@
+package frontend
+
+import "net/http"
+
+// registerUI serves operator console under /ui. Console lists bound Things, renders property values
+// live from property-change events of Things publishing them, allows to write properties, invoke
+// actions and watch events and action progress over WebSocket. Browsers pass API key of WebSockets
+// as query parameter, it has to be enabled by auth.APIKeys.AcceptQuery.
+func (p *Http) registerUI() {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(uiIndex))
+	}
+
+	p.addRoute(p.router, &route{method: "GET", pattern: "/ui", handlerFunc: handler})
+	p.addRoute(p.router, &route{method: "GET", pattern: "/ui/", handlerFunc: handler})
+}
+
+const uiIndex = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>TNO2 Console</title>
+<style>
+body { font-family: sans-serif; margin: 0; background: #f4f4f4; }
+header { background: #263238; color: #fff; padding: 10px 20px; }
+header input { margin-left: 20px; }
+main { padding: 20px; }
+.thing { background: #fff; border-radius: 4px; padding: 10px 20px; margin-bottom: 20px; }
+table { border-collapse: collapse; width: 100%; }
+td { border-bottom: 1px solid #eee; padding: 4px; vertical-align: top; }
+td:first-child { width: 25%; font-weight: bold; }
+.log { background: #fafafa; font-family: monospace; font-size: 12px; max-height: 150px; overflow: auto; white-space: pre; }
+</style>
+</head>
+<body>
+<header>TNO2 Console <input id="apikey" placeholder="API key"></header>
+<main id="things"></main>
+<script>
+var apikey = document.getElementById("apikey");
+apikey.value = localStorage.getItem("tno2.apikey") || "";
+apikey.onchange = function() { localStorage.setItem("tno2.apikey", apikey.value); load(); };
+
+function path(href) { return new URL(href, location.href).pathname; }
+
+function withKey(url) {
+	if (!apikey.value) { return url; }
+	return url + (url.indexOf("?") < 0 ? "?" : "&") + "api_key=" + encodeURIComponent(apikey.value);
+}
+
+function call(method, url, body) {
+	var opts = { method: method, headers: {} };
+	if (apikey.value) { opts.headers["X-API-Key"] = apikey.value; }
+	if (body !== undefined) {
+		opts.headers["Content-Type"] = "application/json";
+		opts.body = body;
+	}
+	return fetch(url, opts).then(function(r) {
+		return r.text().then(function(t) {
+			if (!r.ok) { throw new Error(r.status + " " + t); }
+			return t ? JSON.parse(t) : null;
+		});
+	});
+}
+
+// sockets of rendered Things, they are closed and their event subscriptions cancelled when console reloads
+var sockets = [], generation = 0;
+
+function unsubscribe(href) { call("DELETE", path(href)).catch(function() {}); }
+
+// connect opens WebSocket requested by load of generation, event subscription made before reload is
+// cancelled right away
+function connect(href, gen, subscription, onmessage) {
+	if (gen != generation) {
+		if (subscription) { unsubscribe(href); }
+		return;
+	}
+	var ws = new WebSocket(withKey(href));
+	ws.onmessage = onmessage;
+	sockets.push({ ws: ws, href: href, subscription: subscription });
+}
+
+function closeAll() {
+	sockets.forEach(function(s) {
+		s.ws.onmessage = null;
+		s.ws.close();
+		if (s.subscription) { unsubscribe(s.href); }
+	});
+	sockets = [];
+}
+
+function watch(href, gen, subscription, log) {
+	connect(href, gen, subscription, function(m) {
+		log.textContent = new Date().toLocaleTimeString() + " " + m.data + log.textContent;
+	});
+}
+
+// live subscribes to property-change event, values of changed properties are passed to their setters
+function live(td, setters) {
+	var changes = (td.events || []).filter(function(e) { return e.name == "property-change"; })[0];
+	if (!changes) { return; }
+
+	var gen = generation;
+	call("POST", path(changes.hrefs[0])).then(function(ls) {
+		connect(ls.links[0].href, gen, true, function(m) {
+			var e = JSON.parse(m.data), change = e.data || {};
+			if (setters[change.name]) { setters[change.name](change.value); }
+		});
+	}).catch(function(e) { console.log("property changes not watched: " + e.message); });
+}
+
+function el(tag, text) {
+	var e = document.createElement(tag);
+	if (text !== undefined) { e.textContent = text; }
+	return e;
+}
+
+function row(table, name) {
+	var tr = el("tr"), cell = el("td");
+	tr.appendChild(el("td", name));
+	tr.appendChild(cell);
+	table.appendChild(tr);
+	return cell;
+}
+
+function renderThing(td) {
+	var div = el("div"), props = el("table"), actions = el("table"), events = el("table");
+	div.className = "thing";
+	div.appendChild(el("h2", td.name));
+	div.appendChild(el("h3", "Properties")); div.appendChild(props);
+	div.appendChild(el("h3", "Actions")); div.appendChild(actions);
+	div.appendChild(el("h3", "Events")); div.appendChild(events);
+
+	var setters = {};
+	(td.properties || []).forEach(function(p) {
+		var cell = row(props, p.name), value = el("span", "-"), url = path(p.hrefs[0]);
+		cell.appendChild(value);
+		setters[p.name] = function(v) { value.textContent = JSON.stringify(v); };
+		var refresh = function() {
+			call("GET", url).then(setters[p.name])
+				.catch(function(e) { value.textContent = e.message; });
+		};
+		refresh();
+		if (p.writable) {
+			var input = el("input"), set = el("button", "Set");
+			set.onclick = function() { call("PUT", url, input.value).then(refresh).catch(alert); };
+			cell.appendChild(input); cell.appendChild(set);
+		}
+	});
+
+	(td.actions || []).forEach(function(a) {
+		var cell = row(actions, a.name), input = el("input"), invoke = el("button", "Invoke"), log = el("div", "");
+		input.value = "{}";
+		log.className = "log";
+		invoke.onclick = function() {
+			var gen = generation;
+			call("POST", path(a.hrefs[0]), input.value).then(function(ls) {
+				ls.links.forEach(function(l) { if (l.rel == "websocket") { watch(l.href, gen, false, log); } });
+			}).catch(alert);
+		};
+		cell.appendChild(input); cell.appendChild(invoke); cell.appendChild(log);
+	});
+
+	(td.events || []).forEach(function(e) {
+		var cell = row(events, e.name), subscribe = el("button", "Watch"), log = el("div", "");
+		log.className = "log";
+		subscribe.onclick = function() {
+			var gen = generation;
+			subscribe.disabled = true;
+			call("POST", path(e.hrefs[0])).then(function(ls) { watch(ls.links[0].href, gen, true, log); }).catch(alert);
+		};
+		cell.appendChild(subscribe); cell.appendChild(log);
+	});
+
+	live(td, setters);
+	document.getElementById("things").appendChild(div);
+}
+
+function load() {
+	var current = ++generation;
+	closeAll();
+	document.getElementById("things").innerHTML = "";
+	call("GET", "/").then(function(ls) {
+		ls.links.forEach(function(l) {
+			call("GET", path(l.href) + "/description").then(function(td) {
+				//Things of previous load are not rendered after reload
+				if (current == generation) { renderThing(td); }
+			});
+		});
+	}).catch(function(e) { document.getElementById("things").textContent = e.message; });
+}
+
+load();
+</script>
+</body>
+</html>
+`
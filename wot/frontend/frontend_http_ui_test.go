@@ -0,0 +1,23 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCaseUI(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"ui": true})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/ui", nil))
+	Equals("Status", t, http.StatusOK, rec.Code)
+	Equals("Content type", t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	Equals("Live values", t, true, strings.Contains(rec.Body.String(), `"property-change"`))
+	Equals("No polling", t, false, strings.Contains(rec.Body.String(), "setInterval"))
+
+	rec = httptest.NewRecorder()
+	testHTTP(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/ui", nil))
+	Equals("Disabled", t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,39 @@
+package frontend
+
+import (
+	"errors"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/util/unit"
+	"github.com/conas/tno2/wot/model"
+)
+
+// ConvertedValue is property value converted to unit requested by ?unit=
+type ConvertedValue struct {
+	Value interface{} `json:"value"`
+	Unit  string      `json:"unit"`
+}
+
+// convertUnit converts numeric property value from declared unit of the property to requested unit
+func convertUnit(prop model.Property, data interface{}, to string) (*ConvertedValue, error) {
+	if prop.Unit == "" {
+		return nil, errors.New(str.Concat("Property ", prop.Name, " declares no unit"))
+	}
+
+	n, ok := model.Number(data)
+	if !ok {
+		return nil, errors.New(str.Concat("Property ", prop.Name, " is not numeric"))
+	}
+
+	converted, err := unit.Convert(n, prop.Unit, to)
+	if err != nil {
+		return nil, err
+	}
+
+	target, _ := unit.Lookup(to)
+
+	return &ConvertedValue{
+		Value: converted,
+		Unit:  target.Code,
+	}, nil
+}
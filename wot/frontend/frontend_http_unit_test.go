@@ -0,0 +1,36 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseUnitConversion(t *testing.T) {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name: "sensor",
+		Properties: []model.Property{
+			{Name: "temperature", Unit: "Cel", ValueType: model.ValueType{Type: "number"}, Hrefs: []string{"property/temperature"}},
+		},
+	})
+	s.OnGetProperty("temperature", func() interface{} { return 25.0 })
+
+	p := testHTTP(nil)
+	p.Bind("/sensor", s)
+	p.Bind("/lamp", lamp())
+
+	w := serve(p, "GET", "/sensor/property/temperature?unit=degF", "")
+	converted := &ConvertedValue{}
+	json.Unmarshal(w.Body.Bytes(), converted)
+	Equals("Value", t, 77.0, converted.Value)
+	Equals("Unit", t, "[degF]", converted.Unit)
+
+	w = serve(p, "GET", "/sensor/property/temperature?unit=m", "")
+	Equals("Other dimension", t, http.StatusBadRequest, w.Code)
+
+	w = serve(p, "GET", "/lamp/property/power?unit=kW", "")
+	Equals("No declared unit", t, http.StatusBadRequest, w.Code)
+}
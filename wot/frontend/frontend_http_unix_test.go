@@ -0,0 +1,52 @@
+package frontend
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCaseServeUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tno2-http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "http.sock")
+
+	//socket file left by previous run
+	stale, _ := net.Listen("unix", socket)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	l, err := listenUnix(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+	go p.Serve(l)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+
+	resp, err := client.Get("http://tno2/lamp/property/on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	Equals("Status", t, http.StatusOK, resp.StatusCode)
+	Equals("Value", t, "false", strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,159 @@
+package frontend
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sync"
+
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/mux"
+)
+
+const MAX_FORM_FIELD = 64 << 10
+
+// readActionInput decodes action input. JSON bodies are decoded as before, multipart/form-data and
+// application/octet-stream bodies are passed to action as *server.Stream without buffering.
+// Returned channel is closed once stream is consumed, nil for decoded input. Other bodies are decoded
+// by decoder selected by contentType of action input, JSON when decoder is nil. Form fields buffered
+// in memory are limited to maxFields bytes in total, zero is unlimited.
+func readActionInput(r *http.Request, decoder Encoder, maxFields int64) (interface{}, <-chan struct{}, error) {
+	contentType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch contentType {
+	case "multipart/form-data":
+		return readMultipartInput(multipart.NewReader(r.Body, params["boundary"]), maxFields)
+	case CONTENT_TYPE_OCTET_STREAM:
+		body := newConsumedReader(r.Body)
+		return &server.Stream{Reader: body, ContentType: contentType}, body.done, nil
+	}
+
+	var wo interface{}
+	if decoder == nil {
+		return wo, nil, readBody(r, &wo)
+	}
+	return wo, nil, decodeBody(r, decoder, &wo)
+}
+
+// readMultipartInput collects form fields preceding first file part and streams the file part.
+// Form without file is passed as map of its fields, names and values of fields over maxFields bytes
+// in total are rejected as too large.
+func readMultipartInput(mr *multipart.Reader, maxFields int64) (interface{}, <-chan struct{}, error) {
+	fields := make(map[string]string)
+	var buffered int64
+
+	for {
+		part, err := mr.NextPart()
+
+		if err == io.EOF {
+			return fields, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, MAX_FORM_FIELD+1))
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(value) > MAX_FORM_FIELD {
+				return nil, nil, errors.New("Form field too large: " + part.FormName())
+			}
+			if buffered += int64(len(part.FormName()) + len(value)); maxFields > 0 && buffered > maxFields {
+				return nil, nil, &http.MaxBytesError{Limit: maxFields}
+			}
+			fields[part.FormName()] = string(value)
+			continue
+		}
+
+		contentType := part.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = CONTENT_TYPE_OCTET_STREAM
+		}
+
+		body := newConsumedReader(part)
+		return &server.Stream{
+			Reader:      body,
+			ContentType: contentType,
+			Name:        part.FileName(),
+			Params:      fields,
+		}, body.done, nil
+	}
+}
+
+// consumedReader signals when the reader has been read to the end, failed or was closed
+type consumedReader struct {
+	r    io.Reader
+	done chan struct{}
+	once *sync.Once
+}
+
+func newConsumedReader(r io.Reader) *consumedReader {
+	return &consumedReader{
+		r:    r,
+		done: make(chan struct{}),
+		once: &sync.Once{},
+	}
+}
+
+func (cr *consumedReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+
+	if err != nil {
+		cr.Close()
+	}
+
+	return n, err
+}
+
+func (cr *consumedReader) Close() error {
+	cr.once.Do(func() {
+		close(cr.done)
+	})
+
+	return nil
+}
+
+// actionResultHandler serves binary action result of finished task as download
+func (p *Http) actionResultHandler(t *tenant, ctxPath string, wotServer *server.WotServer, actionName string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorized(w, r, t, auth.RIGHT_INVOKE, wotServer, actionName) {
+			return
+		}
+
+		slot, ok := t.actionResults.GetActionSlot(mux.Vars(r)["taskid"], ctxPath, actionName)
+		if !ok {
+			sendCode(w, r, http.StatusNotFound, "Unknown task")
+			return
+		}
+
+		status := slot.Load().(*server.TaskStatus)
+		if status.Status != server.TASK_DONE {
+			sendCode(w, r, http.StatusConflict, status)
+			return
+		}
+
+		stream, ok := asStream(status.Data)
+		if !ok {
+			sendOK(w, r, status.Data)
+			return
+		}
+
+		//stored results are downloaded repeatedly and concurrently, each download reads its own section
+		if section, ok := stream.Section(); ok {
+			download := *stream
+			download.Reader = section
+			stream = &download
+		}
+
+		if stream.Name != "" {
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": stream.Name}))
+		}
+
+		sendStream(w, r, actionName, stream)
+	}
+}
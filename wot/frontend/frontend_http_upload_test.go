@@ -0,0 +1,173 @@
+package frontend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// converter is Thing with action "upper" converting uploaded stream to upper case file
+func converter() *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "converter",
+		Actions: []model.Action{{Name: "upper", Hrefs: []string{"action/upper"}}},
+	})
+
+	s.OnInvokeAction("upper", func(input interface{}, ph async.ProgressHandler) interface{} {
+		in, ok := input.(*server.Stream)
+		if !ok {
+			return input
+		}
+		data, _ := ioutil.ReadAll(in.Reader)
+		name := in.Name
+		if name == "" {
+			name = "out.txt"
+		}
+
+		return &server.Stream{
+			Reader:      bytes.NewReader(bytes.ToUpper(data)),
+			ContentType: "text/plain",
+			Name:        in.Params["prefix"] + name,
+		}
+	})
+
+	return s
+}
+
+// finished waits until task at path is finished and returns its status
+func finished(t *testing.T, h http.Handler, path string) *server.TaskStatus {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		status := &server.TaskStatus{}
+		json.Unmarshal(serve(h, "GET", path, "").Body.Bytes(), status)
+		if status.Status == server.TASK_DONE || status.Status == server.TASK_FAILED {
+			return status
+		}
+	}
+
+	t.Fatal("task not finished: ", path)
+	return nil
+}
+
+func TestCaseOctetStreamInput(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/converter", converter())
+
+	w := serve(p, "POST", "/converter/action/upper", "hello", "Content-Type", CONTENT_TYPE_OCTET_STREAM)
+	task := taskPath(t, w)
+	finished(t, p, task)
+
+	w = serve(p, "GET", task+"/result", "")
+	Equals("Result", t, "HELLO", w.Body.String())
+	Equals("Result type", t, "text/plain", w.Header().Get("Content-Type"))
+	Equals("Result name", t, `attachment; filename=out.txt`, w.Header().Get("Content-Disposition"))
+
+	w = serve(p, "GET", task+"/result", "")
+	Equals("Downloaded again", t, "HELLO", w.Body.String())
+}
+
+func TestCaseMultipartInput(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/converter", converter())
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("prefix", "upper-")
+	fw, _ := mw.CreateFormFile("file", "notes.txt")
+	fw.Write([]byte("notes"))
+	mw.Close()
+
+	w := serve(p, "POST", "/converter/action/upper", body.String(), "Content-Type", mw.FormDataContentType())
+	task := taskPath(t, w)
+	finished(t, p, task)
+
+	w = serve(p, "GET", task+"/result", "")
+	Equals("Result", t, "NOTES", w.Body.String())
+	Equals("Result name", t, true, strings.Contains(w.Header().Get("Content-Disposition"), "upper-notes.txt"))
+}
+
+// closingReader is seekable content which is not readable at offset and fails once closed
+type closingReader struct {
+	io.ReadSeeker
+	closed bool
+}
+
+func (cr *closingReader) Read(p []byte) (int, error) {
+	if cr.closed {
+		return 0, errors.New("read of closed reader")
+	}
+	return cr.ReadSeeker.Read(p)
+}
+
+func (cr *closingReader) Close() error {
+	cr.closed = true
+	return nil
+}
+
+func TestCaseActionResultDownloads(t *testing.T) {
+	result := &closingReader{ReadSeeker: strings.NewReader("0123456789")}
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:    "exporter",
+		Actions: []model.Action{{Name: "export", Hrefs: []string{"action/export"}}},
+	})
+	s.OnInvokeAction("export", func(input interface{}, ph async.ProgressHandler) interface{} {
+		return &server.Stream{Reader: result, ContentType: "text/plain"}
+	})
+
+	p := testHTTP(nil)
+	p.Bind("/exporter", s)
+
+	task := taskPath(t, serve(p, "POST", "/exporter/action/export", "{}"))
+	finished(t, p, task)
+
+	for i := 0; i < 2; i++ {
+		w := serve(p, "GET", task+"/result", "")
+		Equals("Downloaded", t, "0123456789", w.Body.String())
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 8)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = serve(p, "GET", task+"/result", "", "Range", "bytes=2-7").Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, body := range bodies {
+		Equals("Concurrent download", t, "234567", body)
+	}
+	Equals("Open while stored", t, false, result.closed)
+
+	p.Unbind("/exporter")
+	Equals("Closed once dropped", t, true, result.closed)
+}
+
+func TestCaseTaskOfOtherThing(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/a", converter())
+	p.Bind("/b", converter())
+
+	w := serve(p, "POST", "/a/action/upper", "hello", "Content-Type", CONTENT_TYPE_OCTET_STREAM)
+	task := taskPath(t, w)
+	finished(t, p, task)
+
+	other := "/b" + strings.TrimPrefix(task, "/a")
+	Equals("Own task", t, http.StatusOK, serve(p, "GET", task, "").Code)
+	Equals("Task of other Thing", t, http.StatusNotFound, serve(p, "GET", other, "").Code)
+	Equals("Result of other Thing", t, http.StatusNotFound, serve(p, "GET", other+"/result", "").Code)
+}
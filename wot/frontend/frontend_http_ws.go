@@ -0,0 +1,208 @@
+package frontend
+
+import (
+	"compress/flate"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/gorilla/websocket"
+)
+
+// WS_SUBPROTOCOL_EVENT is WebSocket subprotocol of event streams, messages are events encoded
+// by ?encoding= and sequenced events when subscription is acknowledged
+const WS_SUBPROTOCOL_EVENT = "wot.event.v1"
+
+const (
+	WS_BUFFER_SIZE = 1024
+	// WS_MAX_MESSAGE limits client messages, clients of event streams send acknowledgements only
+	WS_MAX_MESSAGE = 4096
+)
+
+// wsConfig configures WebSocket connections of binding. Read timeout closes connections of clients
+// which do not answer pings, write timeout connections of clients not reading events. MaxClients
+// limits connections sharing one subscription.
+type wsConfig struct {
+	upgrader     *websocket.Upgrader
+	maxMessage   int64
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	maxClients   int
+	// compressionLevel of messages written to clients which negotiated permessage-deflate
+	compressionLevel int
+}
+
+// configureWebSockets reads "wsOrigins" allowed to connect (same origin when not set, "self" for
+// same origin, "*" for any), "wsReadBuffer", "wsWriteBuffer", "wsCompression" (permessage-deflate
+// offered by client is used unless false), "wsCompressionLevel" (flate level), "wsMaxMessage",
+// "wsReadTimeout", "wsWriteTimeout" (e.g. "30s") and "wsMaxClients" per subscription
+func (p *Http) configureWebSockets(cfg map[string]interface{}) {
+	ws := &wsConfig{
+		upgrader: &websocket.Upgrader{
+			ReadBufferSize:    WS_BUFFER_SIZE,
+			WriteBufferSize:   WS_BUFFER_SIZE,
+			Subprotocols:      []string{WS_SUBPROTOCOL_EVENT, WS_SUBPROTOCOL_SYNC},
+			EnableCompression: true,
+		},
+		maxMessage:       WS_MAX_MESSAGE,
+		compressionLevel: flate.BestSpeed,
+	}
+
+	if origins, ok := cfg["wsOrigins"].([]interface{}); ok {
+		ws.upgrader.CheckOrigin = checkOrigins(origins)
+	}
+
+	if size, ok := cfg["wsReadBuffer"].(int); ok {
+		ws.upgrader.ReadBufferSize = size
+	}
+
+	if size, ok := cfg["wsWriteBuffer"].(int); ok {
+		ws.upgrader.WriteBufferSize = size
+	}
+
+	if compression, ok := cfg["wsCompression"].(bool); ok {
+		ws.upgrader.EnableCompression = compression
+	}
+
+	if level, ok := cfg["wsCompressionLevel"].(int); ok {
+		if level < flate.HuffmanOnly || level > flate.BestCompression {
+			panic(str.Concat("Invalid wsCompressionLevel: ", strconv.Itoa(level)))
+		}
+		ws.compressionLevel = level
+	}
+
+	if size, ok := cfg["wsMaxMessage"].(int); ok {
+		ws.maxMessage = int64(size)
+	}
+
+	if max, ok := cfg["wsMaxClients"].(int); ok {
+		ws.maxClients = max
+	}
+
+	ws.readTimeout = durationParam(cfg, "wsReadTimeout")
+	ws.writeTimeout = durationParam(cfg, "wsWriteTimeout")
+
+	p.ws = ws
+}
+
+func durationParam(cfg map[string]interface{}, key string) time.Duration {
+	s, ok := cfg[key].(string)
+	if !ok {
+		return 0
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(str.Concat("Invalid ", key, ": ", err.Error()))
+	}
+
+	return d
+}
+
+// checkOrigin reports whether client of origin of request r may connect, same origin unless configured
+func (ws *wsConfig) checkOrigin(r *http.Request) bool {
+	if ws.upgrader.CheckOrigin == nil {
+		return sameOrigin(r)
+	}
+
+	return ws.upgrader.CheckOrigin(r)
+}
+
+// sameOrigin allows requests without origin and of origin of the request host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// checkOrigins allows listed origins, "*" allows any and "self" origin of the request host
+func checkOrigins(origins []interface{}) func(r *http.Request) bool {
+	allowed := make(map[string]bool)
+	for _, o := range origins {
+		allowed[strings.ToLower(o.(string))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed["*"] {
+			return true
+		}
+
+		if allowed[strings.ToLower(origin)] {
+			return true
+		}
+
+		return allowed["self"] && sameOrigin(r)
+	}
+}
+
+// upgradeWS upgrades event stream or property sync request to WebSocket. permessage-deflate is used when
+// client offers it, client requesting subprotocols must request one of supported, clients without
+// subprotocol are accepted.
+func (p *Http) upgradeWS(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+	requested := websocket.Subprotocols(r)
+
+	supported := len(requested) == 0
+	for _, protocol := range requested {
+		supported = supported || protocol == WS_SUBPROTOCOL_EVENT || protocol == WS_SUBPROTOCOL_SYNC
+	}
+
+	if !supported {
+		err := errors.New(str.Concat("Unsupported WebSocket subprotocols: ", strings.Join(requested, ", ")))
+		sendPlainERR(w, err)
+		return nil, err
+	}
+
+	conn, err := p.ws.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetReadLimit(p.ws.maxMessage)
+	conn.SetCompressionLevel(p.ws.compressionLevel)
+	return conn, nil
+}
+
+// keepAlive pings client until closed, client not answering within read timeout is disconnected
+func (p *Http) keepAlive(conn *websocket.Conn, closed <-chan struct{}) {
+	timeout := p.ws.readTimeout
+	if timeout <= 0 {
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	go func() {
+		ticker := time.NewTicker(timeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout/2)) != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}()
+}
+
+// writeDeadline bounds next write to client, so slow client does not hold its subscription forever
+func (p *Http) writeDeadline(conn *websocket.Conn) {
+	if p.ws.writeTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(p.ws.writeTimeout))
+	}
+}
@@ -0,0 +1,124 @@
+package frontend
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCaseWebSocketOrigins(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"wsOrigins": []interface{}{"self", "https://console.example"}})
+	p.Bind("/lamp", lamp())
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	base := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	for origin, allowed := range map[string]bool{
+		"":                        true,
+		srv.URL:                   true,
+		"https://console.example": true,
+		"https://evil.example":    false,
+	} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+
+		conn, _, err := websocket.DefaultDialer.Dial(base+subscribe(t, p, "/lamp/event/property-change"), header)
+		Equals(origin, t, allowed, err == nil)
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
+
+func TestCaseWebSocketDefaultOrigins(t *testing.T) {
+	for origins, crossOrigin := range map[string]bool{"": false, "*": true} {
+		cfg := map[string]interface{}{}
+		if origins != "" {
+			cfg["wsOrigins"] = []interface{}{origins}
+		}
+
+		p := testHTTP(cfg)
+		p.Bind("/lamp", lamp())
+
+		srv := httptest.NewServer(p)
+		base := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+		for origin, allowed := range map[string]bool{srv.URL: true, "https://evil.example": crossOrigin} {
+			conn, _, err := websocket.DefaultDialer.Dial(base+subscribe(t, p, "/lamp/event/property-change"), http.Header{"Origin": {origin}})
+			Equals(origins+" "+origin, t, allowed, err == nil)
+			if conn != nil {
+				conn.Close()
+			}
+		}
+
+		srv.Close()
+	}
+}
+
+func TestCaseSubscriptionOfOtherThing(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/a", lamp())
+	p.Bind("/b", lamp())
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	ws := subscribe(t, p, "/a/event/property-change")
+	other := "/b" + strings.TrimPrefix(ws, "/a")
+
+	_, rs, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+other, nil)
+	Equals("Subscription of other Thing", t, true, err != nil && rs != nil && rs.StatusCode == http.StatusNotFound)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+ws, nil)
+	Equals("Own subscription", t, nil, err)
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+func TestCaseRemovedClient(t *testing.T) {
+	p := testHTTP(nil)
+	p.Bind("/lamp", lamp())
+
+	ws := subscribe(t, p, "/lamp/event/property-change")
+	conn, closer := dial(t, p, ws, nil)
+	defer closer()
+
+	//client removed from subscription, e.g. stalled, is disconnected
+	clients, _ := p.defaultTenant.subscribers.Clients(path.Base(ws))
+	clients.RemoveAllSubscribes()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	ne, timeout := err.(net.Error)
+	Equals("Disconnected", t, true, err != nil && !(timeout && ne.Timeout()))
+}
+
+func TestCaseWebSocketLimits(t *testing.T) {
+	p := testHTTP(map[string]interface{}{"wsMaxClients": 1, "wsMaxMessage": 64})
+	p.Bind("/lamp", lamp())
+
+	ws := subscribe(t, p, "/lamp/event/property-change")
+	conn, closer := dial(t, p, ws, nil)
+	defer closer()
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	_, rs, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+ws, nil)
+	Equals("Second client", t, true, err != nil && rs != nil && rs.StatusCode == http.StatusTooManyRequests)
+
+	//client message over limit closes connection
+	conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 128)))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	Equals("Closed", t, true, websocket.IsCloseError(err, websocket.CloseMessageTooBig))
+}
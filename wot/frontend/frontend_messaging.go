@@ -0,0 +1,142 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// MessageReply answers request of messaging frontend, Status is HTTP status code of equal HTTP interaction
+type MessageReply struct {
+	Status int         `json:"status"`
+	Value  interface{} `json:"value,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// brokerHref returns href of path at broker of url, credentials of url are not advertised
+func brokerHref(rawurl, path string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: str.Concat("/", path)}).String()
+}
+
+// interact serves request of subject td, property/<name>, property/<name>/set or action/<name>, data is
+// JSON of written value or action input
+func interact(s *server.WotServer, subject string, data []byte, timeout time.Duration) *MessageReply {
+	td := s.GetDescription()
+	kind, name := subject, ""
+	if i := strings.Index(subject, "/"); i >= 0 {
+		kind, name = subject[:i], subject[i+1:]
+	}
+
+	switch kind {
+	case "td":
+		return &MessageReply{Status: http.StatusOK, Value: s.Description()}
+	case "property":
+		set := strings.HasSuffix(name, "/set")
+		name = strings.TrimSuffix(name, "/set")
+		for _, p := range td.Properties {
+			switch {
+			case p.Name != name:
+			case !set:
+				return messageResult(s.GetProperty(name).Get())
+			case p.Writable:
+				return writeProperty(s, name, data)
+			default:
+				return messageError(http.StatusMethodNotAllowed, errors.New(str.Concat("Property ", name, " is not writable")))
+			}
+		}
+	case "action":
+		if action, ok := findAction(td, name); ok {
+			return invokeAction(s, *action, data, timeout)
+		}
+	}
+
+	return messageError(http.StatusNotFound, errors.New(str.Concat("Unknown interaction ", subject)))
+}
+
+// writeProperty sets property to JSON value of request
+func writeProperty(s *server.WotServer, name string, data []byte) *MessageReply {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return messageError(http.StatusBadRequest, err)
+	}
+
+	return messageResult(s.SetProperty(name, value).Get())
+}
+
+// invokeAction invokes action with JSON input of request and waits for its result at most timeout
+func invokeAction(s *server.WotServer, a model.Action, data []byte, timeout time.Duration) *MessageReply {
+	if a.Signed {
+		return messageError(http.StatusForbidden, errors.New(str.Concat("Action ", a.Name, " requires signed invocation")))
+	}
+
+	var input interface{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &input); err != nil {
+			return messageError(http.StatusBadRequest, err)
+		}
+	}
+
+	state := &atomic.Value{}
+	ph := server.NewWotProgressHandler(a.Name, state, async.NewFanOut())
+	result, err := s.InvokeAction(a.Name, input, ph).WaitTimeout(timeout)
+	if err != nil {
+		return messageError(http.StatusGatewayTimeout, err)
+	}
+	if status, ok := result.(server.Status); ok && status != server.WOT_OK {
+		return messageResult(result)
+	}
+
+	//result of action is reported to its progress handler
+	status, ok := state.Load().(*server.TaskStatus)
+	if !ok {
+		return &MessageReply{Status: http.StatusOK}
+	}
+	if status.Status == server.TASK_FAILED {
+		return &MessageReply{Status: http.StatusInternalServerError, Error: fmt.Sprint(status.Data)}
+	}
+	return &MessageReply{Status: http.StatusOK, Value: status.Data}
+}
+
+// messageResult converts result of interaction to reply, statuses and errors map to HTTP status codes
+func messageResult(result interface{}) *MessageReply {
+	switch v := result.(type) {
+	case server.Status:
+		switch v {
+		case server.WOT_OK:
+			return &MessageReply{Status: http.StatusOK}
+		case server.WOT_WRITE_QUEUED:
+			return &MessageReply{Status: http.StatusAccepted}
+		}
+		return messageError(http.StatusBadRequest, &server.StatusError{Status: v})
+	case error:
+		return messageError(http.StatusBadRequest, v)
+	}
+
+	return &MessageReply{Status: http.StatusOK, Value: result}
+}
+
+func messageError(code int, err error) *MessageReply {
+	switch {
+	case unavailable(err), overloaded(err):
+		code = http.StatusServiceUnavailable
+	case notFound(err):
+		code = http.StatusNotFound
+	}
+
+	return &MessageReply{Status: code, Error: err.Error()}
+}
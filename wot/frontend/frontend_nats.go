@@ -0,0 +1,208 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/nats"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+// NATS_PREFIX is first token of subjects of bound Things, override by "prefix" config
+const NATS_PREFIX = "wot"
+
+// NATS_TIMEOUT is default time to wait for action result, override by "timeout" config in seconds
+const NATS_TIMEOUT = 10 * time.Second
+
+// NATS frontend exposes bound Things on NATS subjects. Thing bound at /dev-1 answers requests at
+//
+//	wot.dev-1.td                   Thing Description
+//	wot.dev-1.property.<name>      property read
+//	wot.dev-1.property.<name>.set  property write, request carries value
+//	wot.dev-1.action.<name>        action invocation, answered with result when action completes
+//
+// and publishes its events at wot.dev-1.event.<name>. Payloads are JSON, replies are MessageReply. Access
+// to subjects is controlled by permissions of NATS server. Subjects are advertised in Thing Description as
+// hrefs nats://<server>/<subject>.
+type NATS struct {
+	url     string
+	binding string
+	conn    *nats.Conn
+	prefix  string
+	queue   string
+	timeout time.Duration
+	l       *sync.Mutex
+	things  map[string]*natsThing
+}
+
+// natsThing keeps subscriptions and event listeners of bound Thing so it can be unbound
+type natsThing struct {
+	s         *server.WotServer
+	subs      []*nats.Subscription
+	listeners map[string]*server.EventListener
+}
+
+// NewNATS connects to NATS server at "url", optional "queue" makes gateway instances sharing queue
+// group answer each request once
+func NewNATS(cfg map[string]interface{}) Frontend {
+	name, _ := cfg["hostname"].(string)
+	conn, err := nats.Connect(cfg["url"].(string), str.Concat("tno2 ", name))
+	if err != nil {
+		panic(err)
+	}
+
+	prefix := NATS_PREFIX
+	if p, ok := cfg["prefix"].(string); ok {
+		prefix = p
+	}
+
+	timeout := NATS_TIMEOUT
+	if t, ok := cfg["timeout"].(int); ok {
+		timeout = time.Duration(t) * time.Second
+	}
+
+	queue, _ := cfg["queue"].(string)
+	binding, _ := sec.UUID4()
+
+	return &NATS{
+		url:     cfg["url"].(string),
+		binding: str.Concat("NATS ", binding),
+		conn:    conn,
+		prefix:  prefix,
+		queue:   queue,
+		timeout: timeout,
+		l:       &sync.Mutex{},
+		things:  make(map[string]*natsThing),
+	}
+}
+
+func (n *NATS) Bind(ctxPath string, s *server.WotServer) {
+	n.Unbind(ctxPath)
+
+	thing := &natsThing{
+		s:         s,
+		subs:      make([]*nats.Subscription, 0),
+		listeners: make(map[string]*server.EventListener),
+	}
+	base := str.Concat(n.prefix, ".", nats.Subject(ctxPath))
+	td := s.GetDescription()
+
+	forms := &server.Forms{
+		URIs:       []string{brokerHref(n.url, str.Concat(base, ".td"))},
+		Properties: make(map[string][]string),
+		Actions:    make(map[string][]string),
+		Events:     make(map[string][]string),
+	}
+
+	n.handle(thing, str.Concat(base, ".td"), func(*nats.Msg) *MessageReply {
+		return &MessageReply{Status: http.StatusOK, Value: s.Description()}
+	})
+
+	for _, p := range td.Properties {
+		subject := str.Concat(base, ".property.", nats.Subject(p.Name))
+		forms.Properties[p.Name] = []string{brokerHref(n.url, subject)}
+
+		n.handle(thing, subject, func(*nats.Msg) *MessageReply {
+			return messageResult(s.GetProperty(p.Name).Get())
+		})
+
+		if p.Writable {
+			n.handle(thing, str.Concat(subject, ".set"), func(m *nats.Msg) *MessageReply {
+				return writeProperty(s, p.Name, m.Data)
+			})
+		}
+	}
+
+	for _, a := range td.Actions {
+		subject := str.Concat(base, ".action.", nats.Subject(a.Name))
+		forms.Actions[a.Name] = []string{brokerHref(n.url, subject)}
+
+		n.handle(thing, subject, func(m *nats.Msg) *MessageReply {
+			return invokeAction(s, a, m.Data, n.timeout)
+		})
+	}
+
+	for _, e := range td.Events {
+		subject := str.Concat(base, ".event.", nats.Subject(e.Name))
+		forms.Events[e.Name] = []string{brokerHref(n.url, subject)}
+		id, _ := sec.UUID4()
+
+		listener := &server.EventListener{
+			ID: id,
+			CB: func(event interface{}) {
+				data, err := json.Marshal(event)
+				if err == nil {
+					err = n.conn.Publish(subject, data)
+				}
+				if err != nil {
+					log.Error("NATS: event ", e.Name, " not published -> ", err)
+				}
+			},
+		}
+		thing.listeners[e.Name] = listener
+		s.AddListener(e.Name, listener)
+	}
+
+	n.l.Lock()
+	n.things[ctxPath] = thing
+	n.l.Unlock()
+
+	s.AddForms(n.binding, forms)
+
+	log.Info("NATS: bound thing -> ", base)
+}
+
+// handle subscribes request subject, requests are served concurrently so long actions do not block reads
+func (n *NATS) handle(thing *natsThing, subject string, serve func(*nats.Msg) *MessageReply) {
+	sub, err := n.conn.QueueSubscribe(subject, n.queue, func(m *nats.Msg) {
+		if m.Reply == "" {
+			return
+		}
+
+		go func() {
+			data, err := json.Marshal(serve(m))
+			if err == nil {
+				err = n.conn.Publish(m.Reply, data)
+			}
+			if err != nil {
+				log.Error("NATS: request at ", subject, " not answered -> ", err)
+			}
+		}()
+	})
+
+	if err != nil {
+		log.Error("NATS: subject ", subject, " not subscribed -> ", err)
+		return
+	}
+
+	thing.subs = append(thing.subs, sub)
+}
+
+// Unbind unsubscribes subjects of Thing and stops publishing its events
+func (n *NATS) Unbind(ctxPath string) bool {
+	n.l.Lock()
+	thing, ok := n.things[ctxPath]
+	delete(n.things, ctxPath)
+	n.l.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	for _, sub := range thing.subs {
+		sub.Unsubscribe()
+	}
+	for name, listener := range thing.listeners {
+		thing.s.RemoveListener(name, listener)
+	}
+	thing.s.RemoveForms(n.binding)
+
+	return true
+}
+
+func (n *NATS) Start() {}
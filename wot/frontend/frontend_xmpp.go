@@ -0,0 +1,230 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/util/xmpp"
+	"github.com/conas/tno2/wot/server"
+)
+
+// XMPP_NS is namespace of requests, replies and events of Things
+const XMPP_NS = "urn:tno2:wot"
+
+// XMPP_TIMEOUT is default time to wait for action result, override by "timeout" config in seconds
+const XMPP_TIMEOUT = 10 * time.Second
+
+// XMPP frontend exposes bound Things to XMPP entities, gateway connects out to XMPP server as "jid" so
+// neither gateway nor consumers need inbound connectivity. Consumer sends IQ to gateway JID
+//
+//	<iq type='get' to='gw@example.org/tno2' id='1'>
+//	  <wot xmlns='urn:tno2:wot' thing='dev-1' subject='property/temp'/>
+//	</iq>
+//
+// subject is one of td, property/<name>, property/<name>/set, action/<name> and text of wot element is
+// JSON of written value or action input. Result IQ carries wot element of status attribute and JSON of
+// MessageReply. Subject event/<name> subscribes full JID of consumer to event, event/<name>/cancel
+// unsubscribes it. Events are sent as messages of event element with thing and name attributes.
+type XMPP struct {
+	conn    *xmpp.Conn
+	timeout time.Duration
+	l       *sync.Mutex
+	things  map[string]*xmppThing
+}
+
+// xmppThing keeps event subscribers and listeners of bound Thing so it can be unbound
+type xmppThing struct {
+	s           *server.WotServer
+	name        string
+	listeners   map[string]*server.EventListener
+	subscribers map[string]map[string]bool
+}
+
+// NewXMPP connects to server of "jid" by "password", "server" overrides host:port of server and
+// "insecure" allows servers without TLS
+func NewXMPP(cfg map[string]interface{}) Frontend {
+	opts := xmpp.Options{JID: cfg["jid"].(string)}
+	opts.Password, _ = cfg["password"].(string)
+	opts.Server, _ = cfg["server"].(string)
+	opts.Insecure, _ = cfg["insecure"].(bool)
+
+	conn, err := xmpp.Connect(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	timeout := XMPP_TIMEOUT
+	if t, ok := cfg["timeout"].(int); ok {
+		timeout = time.Duration(t) * time.Second
+	}
+
+	x := &XMPP{
+		conn:    conn,
+		timeout: timeout,
+		l:       &sync.Mutex{},
+		things:  make(map[string]*xmppThing),
+	}
+
+	conn.HandleIQ(XMPP_NS, x.serve)
+	conn.OnMessage(x.bounced)
+	conn.OnPresence(x.presence)
+
+	return x
+}
+
+func (x *XMPP) Bind(ctxPath string, s *server.WotServer) {
+	x.Unbind(ctxPath)
+
+	thing := &xmppThing{
+		s:           s,
+		name:        strings.Trim(ctxPath, "/"),
+		listeners:   make(map[string]*server.EventListener),
+		subscribers: make(map[string]map[string]bool),
+	}
+
+	for _, e := range s.GetDescription().Events {
+		id, _ := sec.UUID4()
+		thing.subscribers[e.Name] = make(map[string]bool)
+
+		listener := &server.EventListener{
+			ID: id,
+			CB: func(event interface{}) {
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.Error("XMPP: event ", e.Name, " not sent -> ", err)
+					return
+				}
+
+				for _, jid := range x.subscribersOf(thing, e.Name) {
+					payload := xmpp.NewElement(XMPP_NS, "event", string(data)).With("thing", thing.name).With("name", e.Name)
+					if err := x.conn.Send(&xmpp.Message{To: jid, Extensions: []xmpp.Element{*payload}}); err != nil {
+						log.Error("XMPP: event ", e.Name, " not sent to ", jid, " -> ", err)
+					}
+				}
+			},
+		}
+		thing.listeners[e.Name] = listener
+		s.AddListener(e.Name, listener)
+	}
+
+	x.l.Lock()
+	x.things[thing.name] = thing
+	x.l.Unlock()
+
+	log.Info("XMPP: bound thing -> ", x.conn.JID(), " ", thing.name)
+}
+
+// serve answers request IQ, unknown Things are answered as item-not-found
+func (x *XMPP) serve(iq *xmpp.IQ) (*xmpp.Element, error) {
+	x.l.Lock()
+	thing, ok := x.things[iq.Payload.Attr("thing")]
+	x.l.Unlock()
+
+	if !ok {
+		return nil, xmpp.NewError("cancel", "item-not-found", str.Concat("Unknown thing ", iq.Payload.Attr("thing")))
+	}
+
+	subject := iq.Payload.Attr("subject")
+	var reply *MessageReply
+	if strings.HasPrefix(subject, "event/") {
+		reply = x.subscribe(thing, strings.TrimPrefix(subject, "event/"), iq.From)
+	} else {
+		reply = interact(thing.s, subject, []byte(iq.Payload.Text), x.timeout)
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return xmpp.NewElement(XMPP_NS, "wot", string(data)).With("status", strconv.Itoa(reply.Status)), nil
+}
+
+// subscribe subscribes JID to event or cancels its subscription by name suffix /cancel
+func (x *XMPP) subscribe(thing *xmppThing, name, jid string) *MessageReply {
+	cancel := strings.HasSuffix(name, "/cancel")
+	name = strings.TrimSuffix(name, "/cancel")
+
+	x.l.Lock()
+	defer x.l.Unlock()
+
+	subscribers, ok := thing.subscribers[name]
+	if !ok {
+		return messageError(http.StatusNotFound, errors.New(str.Concat("Unknown event ", name)))
+	}
+
+	if cancel {
+		delete(subscribers, jid)
+	} else {
+		subscribers[jid] = true
+	}
+
+	return &MessageReply{Status: http.StatusOK}
+}
+
+func (x *XMPP) subscribersOf(thing *xmppThing, event string) []string {
+	x.l.Lock()
+	defer x.l.Unlock()
+
+	jids := make([]string, 0, len(thing.subscribers[event]))
+	for jid := range thing.subscribers[event] {
+		jids = append(jids, jid)
+	}
+
+	return jids
+}
+
+// bounced drops subscriber whose server returned event as undeliverable
+func (x *XMPP) bounced(m *xmpp.Message) {
+	if m.Type == "error" {
+		x.unsubscribe(m.From)
+	}
+}
+
+// presence drops subscriber going offline
+func (x *XMPP) presence(p *xmpp.Presence) {
+	if p.Type == "unavailable" {
+		x.unsubscribe(p.From)
+	}
+}
+
+func (x *XMPP) unsubscribe(jid string) {
+	x.l.Lock()
+	defer x.l.Unlock()
+
+	for _, thing := range x.things {
+		for _, subscribers := range thing.subscribers {
+			delete(subscribers, jid)
+		}
+	}
+}
+
+// Unbind stops serving requests and events of Thing
+func (x *XMPP) Unbind(ctxPath string) bool {
+	name := strings.Trim(ctxPath, "/")
+
+	x.l.Lock()
+	thing, ok := x.things[name]
+	delete(x.things, name)
+	x.l.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	for name, listener := range thing.listeners {
+		thing.s.RemoveListener(name, listener)
+	}
+
+	return true
+}
+
+func (x *XMPP) Start() {}
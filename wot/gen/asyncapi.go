@@ -0,0 +1,154 @@
+package gen
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+)
+
+// ASYNCAPI_VERSION is version of AsyncAPI documents generated by AsyncAPI
+const ASYNCAPI_VERSION = "2.6.0"
+
+// AsyncAPI describes event channels of ThingDescription td as AsyncAPI document. Events served by HTTP
+// binding are WebSocket channels of their subscriptions, carrying event envelopes, events with mqtt hrefs
+// are topics of their brokers, carrying event data. Schemes are auth security schemes of WebSocket servers.
+func AsyncAPI(td *model.ThingDescription, schemes []string) map[string]interface{} {
+	servers := make(map[string]interface{})
+	channels := make(map[string]interface{})
+
+	wsServers := make([]string, 0, len(td.Uris))
+	for i, uri := range td.Uris {
+		u, err := url.Parse(uri)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+
+		protocol := "ws"
+		if u.Scheme == "https" {
+			protocol = "wss"
+		}
+
+		name := str.Concat(protocol, strconv.Itoa(i))
+		servers[name] = server(str.Concat(protocol, "://", u.Host, u.EscapedPath()), protocol, schemes)
+		wsServers = append(wsServers, name)
+	}
+
+	brokers := make(map[string]string)
+
+	for _, e := range td.Events {
+		if paths := relativePaths(td, e.Hrefs); len(paths) > 0 && len(wsServers) > 0 {
+			for _, path := range paths {
+				channels[str.Concat(path, "/ws/{subscriptionID}")] = map[string]interface{}{
+					"description": str.Concat("Subscription to ", e.Name, ", created by POST ", path),
+					"servers":     wsServers,
+					"parameters": map[string]interface{}{
+						"subscriptionID": map[string]interface{}{
+							"schema": map[string]interface{}{"type": "string"},
+						},
+					},
+					"subscribe": eventOperation(e, envelope(e)),
+				}
+			}
+		}
+
+		for _, href := range e.Hrefs {
+			u, err := url.Parse(href)
+			if err != nil || (u.Scheme != "mqtt" && u.Scheme != "mqtts") {
+				continue
+			}
+
+			name, ok := brokers[u.Host]
+			if !ok {
+				name = str.Concat("mqtt", strconv.Itoa(len(brokers)))
+				brokers[u.Host] = name
+				servers[name] = server(u.Host, u.Scheme, nil)
+			}
+
+			channels[strings.TrimPrefix(u.Path, "/")] = map[string]interface{}{
+				"servers":   []string{name},
+				"subscribe": eventOperation(e, JSONSchema(e.ValueType)),
+			}
+		}
+	}
+
+	doc := map[string]interface{}{
+		"asyncapi": ASYNCAPI_VERSION,
+		"info": map[string]interface{}{
+			"title":   td.Name,
+			"version": "1.0",
+		},
+		"servers":  servers,
+		"channels": channels,
+	}
+
+	if securitySchemes := asyncSecuritySchemes(schemes); len(securitySchemes) > 0 {
+		doc["components"] = map[string]interface{}{"securitySchemes": securitySchemes}
+	}
+
+	return doc
+}
+
+func server(u, protocol string, schemes []string) map[string]interface{} {
+	s := map[string]interface{}{
+		"url":      u,
+		"protocol": protocol,
+	}
+
+	known := asyncSecuritySchemes(schemes)
+	security := make([]interface{}, 0, len(known))
+	for _, scheme := range schemes {
+		if _, ok := known[scheme]; ok {
+			security = append(security, map[string]interface{}{scheme: []string{}})
+		}
+	}
+
+	if len(security) > 0 {
+		s["security"] = security
+	}
+
+	return s
+}
+
+func eventOperation(e model.Event, payload map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"operationId": str.Concat("on", Ident(e.Name)),
+		"message": map[string]interface{}{
+			"name":        e.Name,
+			"contentType": "application/json",
+			"payload":     payload,
+		},
+	}
+}
+
+// envelope is schema of server.Event delivered to WebSocket subscribers
+func envelope(e model.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"event":     map[string]interface{}{"type": "string", "const": e.Name},
+			"timestamp": map[string]interface{}{"type": "string", "format": "date-time"},
+			"data":      JSONSchema(e.ValueType),
+		},
+	}
+}
+
+func asyncSecuritySchemes(schemes []string) map[string]interface{} {
+	securitySchemes := make(map[string]interface{})
+
+	for _, scheme := range schemes {
+		switch scheme {
+		case auth.SCHEME_APIKEY:
+			securitySchemes[scheme] = map[string]interface{}{"type": "httpApiKey", "in": "header", "name": "X-API-Key"}
+		case auth.SCHEME_BEARER:
+			securitySchemes[scheme] = map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+		case auth.SCHEME_MTLS:
+			securitySchemes[scheme] = map[string]interface{}{"type": "X509"}
+		}
+	}
+
+	return securitySchemes
+}
@@ -0,0 +1,114 @@
+package gen
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"unicode"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+// Generate emits Go source of package pkg with typed server handler interface, server binding,
+// event emitter and client wrapper for ThingDescription td
+func Generate(td *model.ThingDescription, pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	data := &thing{
+		Package: pkg,
+		TD:      td,
+		Ident:   Ident(td.Name),
+	}
+
+	for _, p := range td.Properties {
+		data.Properties = append(data.Properties, &interaction{
+			Name:     p.Name,
+			Ident:    Ident(p.Name),
+			Type:     GoType(p.ValueType.Type),
+			Writable: p.Writable,
+		})
+	}
+
+	for _, a := range td.Actions {
+		data.Actions = append(data.Actions, &interaction{
+			Name:   a.Name,
+			Ident:  Ident(a.Name),
+			Type:   GoType(a.InputData.ValueType.Type),
+			Output: GoType(a.OutputData.ValueType.Type),
+			NoArg:  a.InputData.ValueType.Type == "",
+		})
+	}
+
+	for _, e := range td.Events {
+		data.Events = append(data.Events, &interaction{
+			Name:  e.Name,
+			Ident: Ident(e.Name),
+			Type:  GoType(e.ValueType.Type),
+		})
+	}
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+type thing struct {
+	Package    string
+	TD         *model.ThingDescription
+	Ident      string
+	Properties []*interaction
+	Actions    []*interaction
+	Events     []*interaction
+}
+
+type interaction struct {
+	Name     string
+	Ident    string
+	Type     string
+	Output   string
+	Writable bool
+	NoArg    bool
+}
+
+// GoType maps TD value type to Go type, unknown and structured types are kept as interface{}
+func GoType(valueType string) string {
+	switch valueType {
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "string":
+		return "string"
+	}
+
+	return "interface{}"
+}
+
+// Ident converts TD name such as "critical-temperature-event" to exported Go identifier
+func Ident(name string) string {
+	var b strings.Builder
+	upper := true
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteRune('T')
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseIdent(t *testing.T) {
+	Equals("critical temperature", t, "CriticalTemperatureEvent", Ident("critical-temperature-event"))
+	Equals("esp", t, "DthEsp82661", Ident("dth-esp8266-1"))
+	Equals("leading digit", t, "T1wire", Ident("1wire"))
+}
+
+func TestCaseGenerate(t *testing.T) {
+	td := model.Create("file://../model/testdata/reference-model.json")
+	src, err := Generate(td, "things")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = parser.ParseFile(token.NewFileSet(), "things.go", src, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ident := Ident(td.Name)
+	for _, decl := range []string{
+		"type " + ident + "Handler interface",
+		"func Bind" + ident + "(s *server.WotServer",
+		"type " + ident + "Client struct",
+	} {
+		Equals(decl, t, true, strings.Contains(string(src), decl))
+	}
+}
+
+func TestCaseGenerateProto(t *testing.T) {
+	td := model.Create("file://../model/testdata/reference-model.json")
+	src, err := GenerateProto(td, "things")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Equals("package", t, true, strings.Contains(string(src), "package things;"))
+	Equals("property", t, true, strings.Contains(string(src), "message TemperatureProperty {\n  double value = 1;\n}"))
+	Equals("no struct import", t, false, strings.Contains(string(src), "struct.proto"))
+}
+
+func TestCaseOpenAPI(t *testing.T) {
+	td := model.Create("file://../model/testdata/reference-model.json")
+	td.Uris = []string{"http://localhost:8080/thermo", "http://[::1]:8080/thermo"}
+	td.Properties[0].Hrefs = []string{
+		"http://localhost:8080/thermo/temp%20c",
+		"http://[::1]:8080/thermo/temp%20c",
+		"mqtt://broker/thermo/temp",
+	}
+
+	doc := OpenAPI(td, []string{auth.SCHEME_APIKEY, "unknown"})
+	paths := doc["paths"].(map[string]interface{})
+
+	Equals("paths", t, 1, len(paths))
+	ops, ok := paths["/temp c"].(map[string]interface{})
+	Equals("unescaped path", t, true, ok)
+	Equals("writable", t, true, ops["put"] != nil)
+
+	schema := ops["get"].(map[string]interface{})["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	Equals("type", t, "number", schema["type"])
+	Equals("maximum", t, 255, schema["maximum"])
+
+	Equals("servers", t, 2, len(doc["servers"].([]interface{})))
+	Equals("security", t, 1, len(doc["security"].([]interface{})))
+
+	schemes := doc["components"].(map[string]interface{})["securitySchemes"].(map[string]interface{})
+	Equals("api key header", t, "X-API-Key", schemes[auth.SCHEME_APIKEY].(map[string]interface{})["name"])
+}
+
+func TestCaseAsyncAPI(t *testing.T) {
+	td := model.Create("file://../model/testdata/reference-model.json")
+	td.Uris = []string{"https://localhost:8443/thermo"}
+	td.Events = []model.Event{{
+		Name:      "overheat",
+		ValueType: model.ValueType{Type: "number"},
+		Hrefs:     []string{"https://localhost:8443/thermo/event/overheat", "mqtt://broker:1883/thermo/overheat"},
+	}}
+
+	doc := AsyncAPI(td, []string{auth.SCHEME_BEARER})
+	servers := doc["servers"].(map[string]interface{})
+	channels := doc["channels"].(map[string]interface{})
+
+	Equals("servers", t, 2, len(servers))
+	Equals("wss", t, "wss://localhost:8443/thermo", servers["wss0"].(map[string]interface{})["url"])
+	Equals("broker", t, "mqtt", servers["mqtt0"].(map[string]interface{})["protocol"])
+
+	_, ok := channels["/event/overheat/ws/{subscriptionID}"]
+	Equals("ws channel", t, true, ok)
+
+	topic, ok := channels["thermo/overheat"].(map[string]interface{})
+	Equals("mqtt channel", t, true, ok)
+	payload := topic["subscribe"].(map[string]interface{})["message"].(map[string]interface{})["payload"].(map[string]interface{})
+	Equals("payload", t, "number", payload["type"])
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,259 @@
+package gen
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/model"
+)
+
+// OPENAPI_VERSION is version of OpenAPI documents generated by OpenAPI
+const OPENAPI_VERSION = "3.0.3"
+
+// OpenAPI describes HTTP binding of ThingDescription td as OpenAPI 3 document. Servers are TD URIs and paths
+// are hrefs relative to them, so TD should be bound, hrefs of unbound TD are taken as paths. Schemes are
+// auth security schemes, any of them authenticates requests.
+func OpenAPI(td *model.ThingDescription, schemes []string) map[string]interface{} {
+	paths := make(map[string]interface{})
+
+	for _, p := range td.Properties {
+		ops := map[string]interface{}{
+			"get": operation(str.Concat("read", Ident(p.Name)), p.Name, nil, response("Property value", JSONSchema(p.ValueType))),
+		}
+		if p.Writable {
+			ops["put"] = operation(str.Concat("write", Ident(p.Name)), p.Name, JSONSchema(p.ValueType), response("Property written", nil))
+		}
+		addPaths(paths, relativePaths(td, p.Hrefs), ops)
+	}
+
+	for _, a := range td.Actions {
+		var input map[string]interface{}
+		if a.InputData.ValueType.Type != "" {
+			input = JSONSchema(a.InputData.ValueType)
+		}
+		invoked := response("Links of action task", ref("Links"))
+		if a.Synchronous {
+			var output map[string]interface{}
+			if a.OutputData.ValueType.Type != "" {
+				output = JSONSchema(a.OutputData.ValueType)
+			}
+			invoked = response("Action result", output)
+		}
+		invoke := operation(str.Concat("invoke", Ident(a.Name)), a.Name, input, invoked)
+		invoke["parameters"] = []interface{}{map[string]interface{}{
+			"name":        "callback",
+			"in":          "query",
+			"description": "URL posted signed task status when action finishes",
+			"schema":      map[string]interface{}{"type": "string", "format": "uri"},
+		}}
+		addPaths(paths, relativePaths(td, a.Hrefs), map[string]interface{}{"post": invoke})
+
+		for _, path := range relativePaths(td, a.Hrefs) {
+			task := operation(str.Concat("task", Ident(a.Name)), a.Name, nil, response("Action task state", nil))
+			task["parameters"] = []interface{}{
+				map[string]interface{}{
+					"name":     "taskid",
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				},
+				map[string]interface{}{
+					"name":        "wait",
+					"in":          "query",
+					"description": "Duration to wait for task completion, e.g. 30s",
+					"schema":      map[string]interface{}{"type": "string"},
+				},
+			}
+			paths[str.Concat(path, "/{taskid}")] = map[string]interface{}{"get": task}
+		}
+	}
+
+	for _, e := range td.Events {
+		addPaths(paths, relativePaths(td, e.Hrefs), map[string]interface{}{
+			"post": operation(str.Concat("subscribe", Ident(e.Name)), e.Name, nil, response("Links of event subscription", ref("Links"))),
+		})
+	}
+
+	servers := make([]interface{}, 0, len(td.Uris))
+	for _, uri := range td.Uris {
+		servers = append(servers, map[string]interface{}{"url": uri})
+	}
+
+	doc := map[string]interface{}{
+		"openapi": OPENAPI_VERSION,
+		"info": map[string]interface{}{
+			"title":   td.Name,
+			"version": "1.0",
+		},
+		"servers": servers,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Links": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"links": map[string]interface{}{
+							"type": "array",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"rel":  map[string]interface{}{"type": "string"},
+									"href": map[string]interface{}{"type": "string"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if len(schemes) > 0 {
+		securitySchemes := make(map[string]interface{})
+		security := make([]interface{}, 0, len(schemes))
+
+		for _, scheme := range schemes {
+			if s, ok := securityScheme(scheme); ok {
+				securitySchemes[scheme] = s
+				security = append(security, map[string]interface{}{scheme: []string{}})
+			}
+		}
+
+		doc["components"].(map[string]interface{})["securitySchemes"] = securitySchemes
+		doc["security"] = security
+	}
+
+	return doc
+}
+
+// JSONSchema maps TD value type to JSON schema, range is set when maximum is greater than minimum.
+// Value types resolved from schemas of description are titled by schema name.
+func JSONSchema(vt model.ValueType) map[string]interface{} {
+	schema := make(map[string]interface{})
+
+	switch vt.Type {
+	case "boolean", "integer", "number", "string", "object", "array":
+		schema["type"] = vt.Type
+	}
+
+	if (vt.Type == "integer" || vt.Type == "number") && vt.Maximum > vt.Minimum {
+		schema["minimum"] = vt.Minimum
+		schema["maximum"] = vt.Maximum
+	}
+
+	if vt.Type == "object" && len(vt.Properties) > 0 {
+		fields := make(map[string]interface{})
+		for name, field := range vt.Properties {
+			fields[name] = JSONSchema(field)
+		}
+		schema["properties"] = fields
+	}
+
+	if vt.Type == "object" && len(vt.Required) > 0 {
+		schema["required"] = vt.Required
+	}
+
+	if vt.Type == "array" && vt.Items != nil {
+		schema["items"] = JSONSchema(*vt.Items)
+	}
+
+	if vt.Ref != "" {
+		schema["title"] = strings.TrimPrefix(vt.Ref, "#/schemaDefinitions/")
+	}
+
+	return schema
+}
+
+func securityScheme(scheme string) (map[string]interface{}, bool) {
+	switch scheme {
+	case auth.SCHEME_APIKEY:
+		return map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"}, true
+	case auth.SCHEME_BEARER:
+		return map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}, true
+	case auth.SCHEME_MTLS:
+		return map[string]interface{}{"type": "mutualTLS"}, true
+	}
+
+	return nil, false
+}
+
+func operation(id, summary string, body, response map[string]interface{}) map[string]interface{} {
+	op := map[string]interface{}{
+		"operationId": id,
+		"summary":     summary,
+		"responses":   response,
+	}
+
+	if body != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": body},
+			},
+		}
+	}
+
+	return op
+}
+
+func response(description string, schema map[string]interface{}) map[string]interface{} {
+	ok := map[string]interface{}{"description": description}
+
+	if schema != nil {
+		ok["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+
+	return map[string]interface{}{"200": ok}
+}
+
+func ref(schema string) map[string]interface{} {
+	return map[string]interface{}{"$ref": str.Concat("#/components/schemas/", schema)}
+}
+
+func addPaths(paths map[string]interface{}, relative []string, ops map[string]interface{}) {
+	for _, path := range relative {
+		paths[path] = ops
+	}
+}
+
+// relativePaths returns distinct paths of hrefs relative to TD URIs, hrefs of other servers are skipped
+func relativePaths(td *model.ThingDescription, hrefs []string) []string {
+	paths := make([]string, 0, len(hrefs))
+	seen := make(map[string]bool)
+
+	for _, href := range hrefs {
+		path, ok := relativePath(td.Uris, href)
+		if !ok || seen[path] {
+			continue
+		}
+
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+func relativePath(uris []string, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+
+	if !u.IsAbs() {
+		return str.Concat("/", strings.TrimPrefix(u.Path, "/")), true
+	}
+
+	for _, uri := range uris {
+		if strings.HasPrefix(href, str.Concat(uri, "/")) {
+			path, err := url.PathUnescape(href[len(uri):])
+			return path, err == nil
+		}
+	}
+
+	return "", false
+}
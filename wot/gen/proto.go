@@ -0,0 +1,97 @@
+package gen
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+// GenerateProto emits proto3 definitions of messages carrying values of ThingDescription td: one message
+// per property and event and Input/Output messages per action, each with single field value = 1.
+// Messages match encoding of util/pb used by frontend for application/x-protobuf payloads.
+func GenerateProto(td *model.ThingDescription, pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	data := &protoFile{
+		Package: strings.ToLower(Ident(pkg)),
+		TD:      td,
+	}
+
+	message := func(name string, valueType string) {
+		m := &protoMessage{Name: name, Type: ProtoType(valueType)}
+		if m.Type == PROTO_VALUE {
+			data.Struct = true
+		}
+		data.Messages = append(data.Messages, m)
+	}
+
+	for _, p := range td.Properties {
+		message(Ident(p.Name)+"Property", p.ValueType.Type)
+	}
+
+	for _, a := range td.Actions {
+		if a.InputData.ValueType.Type != "" {
+			message(Ident(a.Name)+"Input", a.InputData.ValueType.Type)
+		}
+		message(Ident(a.Name)+"Output", a.OutputData.ValueType.Type)
+	}
+
+	for _, e := range td.Events {
+		message(Ident(e.Name)+"Event", e.ValueType.Type)
+	}
+
+	if err := protoTmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// PROTO_VALUE is proto type of values without scalar TD type
+const PROTO_VALUE = "google.protobuf.Value"
+
+// ProtoType maps TD value type to proto3 scalar type, unknown and structured types are google.protobuf.Value
+func ProtoType(valueType string) string {
+	switch valueType {
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "sint64"
+	case "number":
+		return "double"
+	case "string":
+		return "string"
+	}
+
+	return PROTO_VALUE
+}
+
+type protoFile struct {
+	Package  string
+	TD       *model.ThingDescription
+	Struct   bool
+	Messages []*protoMessage
+}
+
+type protoMessage struct {
+	Name string
+	Type string
+}
+
+var protoTmpl = template.Must(template.New("proto").Parse(`// Code generated by tno2gen from ThingDescription {{.TD.Name}}. DO NOT EDIT.
+
+syntax = "proto3";
+
+package {{.Package}};
+{{- if .Struct}}
+
+import "google/protobuf/struct.proto";
+{{- end}}
+{{range .Messages}}
+message {{.Name}} {
+  {{.Type}} value = 1;
+}
+{{end -}}
+`))
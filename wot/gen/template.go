@@ -0,0 +1,99 @@
+package gen
+
+import "text/template"
+
+var tmpl = template.Must(template.New("thing").Parse(`// Code generated by tno2gen from ThingDescription {{.TD.Name}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+var _ async.ProgressHandler
+
+// ----- SERVER
+
+// {{.Ident}}Handler is implemented by application code serving {{.TD.Name}}
+type {{.Ident}}Handler interface {
+{{- range .Properties}}
+	Get{{.Ident}}() {{.Type}}
+{{- if .Writable}}
+	Set{{.Ident}}(value {{.Type}})
+{{- end}}
+{{- end}}
+{{- range .Actions}}
+	{{.Ident}}({{if not .NoArg}}input {{.Type}}, {{end}}ph async.ProgressHandler) {{.Output}}
+{{- end}}
+}
+
+// Bind{{.Ident}} registers handler h as property and action callbacks of s
+func Bind{{.Ident}}(s *server.WotServer, h {{.Ident}}Handler) *server.WotServer {
+{{- range .Properties}}
+	s.OnGetProperty("{{.Name}}", func() interface{} { return h.Get{{.Ident}}() })
+{{- if .Writable}}
+	server.OnUpdatePropertyAs(s, "{{.Name}}", h.Set{{.Ident}})
+{{- end}}
+{{- end}}
+{{- range .Actions}}
+{{- if .NoArg}}
+	s.OnInvokeAction("{{.Name}}", func(_ interface{}, ph async.ProgressHandler) interface{} {
+		return h.{{.Ident}}(ph)
+	})
+{{- else}}
+	server.OnInvokeActionAs(s, "{{.Name}}", func(input {{.Type}}, ph async.ProgressHandler) interface{} {
+		return h.{{.Ident}}(input, ph)
+	})
+{{- end}}
+{{- end}}
+
+	return s
+}
+
+// {{.Ident}}Events emits typed events of {{.TD.Name}}
+type {{.Ident}}Events struct {
+	s *server.WotServer
+}
+
+func New{{.Ident}}Events(s *server.WotServer) *{{.Ident}}Events {
+	return &{{.Ident}}Events{s: s}
+}
+{{- $ident := .Ident}}
+{{range .Events}}
+func (e *{{$ident}}Events) Emit{{.Ident}}(data {{.Type}}) error {
+	return server.EmitEventAs(e.s, "{{.Name}}", data)
+}
+{{end}}
+// ----- CLIENT
+
+// {{.Ident}}Client is typed client of {{.TD.Name}}
+type {{.Ident}}Client struct {
+	c proxy.Client
+}
+
+func New{{.Ident}}Client(c proxy.Client) *{{.Ident}}Client {
+	return &{{.Ident}}Client{c: c}
+}
+{{range .Properties}}
+func (c *{{$ident}}Client) Get{{.Ident}}() ({{.Type}}, error) {
+	return proxy.GetPropertyAs[{{.Type}}](c.c, "{{.Name}}")
+}
+{{if .Writable}}
+func (c *{{$ident}}Client) Set{{.Ident}}(value {{.Type}}) error {
+	return c.c.SetProperty("{{.Name}}", value)
+}
+{{end}}
+{{- end}}
+{{- range .Actions}}
+func (c *{{$ident}}Client) {{.Ident}}({{if not .NoArg}}input {{.Type}}{{end}}) (proxy.Task, error) {
+	return c.c.InvokeAction("{{.Name}}", {{if .NoArg}}nil{{else}}input{{end}})
+}
+{{end}}
+{{- range .Events}}
+func (c *{{$ident}}Client) On{{.Ident}}(listener func(data {{.Type}})) (proxy.Subscription, error) {
+	return proxy.AddListenerAs(c.c, "{{.Name}}", listener)
+}
+{{end}}
+`))
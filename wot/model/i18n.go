@@ -0,0 +1,93 @@
+package model
+
+import "strings"
+
+// Texts are human readable title and description of Thing or interaction. Titles and Descriptions are
+// their translations keyed by language tag, e.g. "de" or "cs-CZ".
+type Texts struct {
+	Title        string            `json:"title,omitempty"`
+	Titles       map[string]string `json:"titles,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+}
+
+// localize sets title and description in language, when translated
+func (t *Texts) localize(language string) {
+	if title, ok := t.Titles[language]; ok {
+		t.Title = title
+	}
+	if description, ok := t.Descriptions[language]; ok {
+		t.Description = description
+	}
+}
+
+func (t *Texts) languages(found map[string]bool) {
+	for language := range t.Titles {
+		found[language] = true
+	}
+	for language := range t.Descriptions {
+		found[language] = true
+	}
+}
+
+// Languages returns language tags description is translated to
+func (td *ThingDescription) Languages() map[string]bool {
+	found := make(map[string]bool)
+
+	td.Texts.languages(found)
+	for i := range td.Properties {
+		td.Properties[i].Texts.languages(found)
+	}
+	for i := range td.Actions {
+		td.Actions[i].Texts.languages(found)
+	}
+	for i := range td.Events {
+		td.Events[i].Texts.languages(found)
+	}
+
+	return found
+}
+
+// Localized returns copy of description with titles and descriptions in the first of preferred
+// languages it is translated to, and the language. Language tag matches its translation ignoring
+// case, or the translation to its primary language, e.g. "de-AT" matches "de". Description without
+// matching translation is returned as it is with empty language. Translations are kept.
+func (td *ThingDescription) Localized(preferred []string) (*ThingDescription, string) {
+	language := matchLanguage(td.Languages(), preferred)
+
+	if language == "" {
+		return td, ""
+	}
+
+	localized := *td
+	localized.Texts.localize(language)
+
+	localized.Properties = append([]Property{}, td.Properties...)
+	for i := range localized.Properties {
+		localized.Properties[i].Texts.localize(language)
+	}
+	localized.Actions = append([]Action{}, td.Actions...)
+	for i := range localized.Actions {
+		localized.Actions[i].Texts.localize(language)
+	}
+	localized.Events = append([]Event{}, td.Events...)
+	for i := range localized.Events {
+		localized.Events[i].Texts.localize(language)
+	}
+
+	return &localized, language
+}
+
+func matchLanguage(available map[string]bool, preferred []string) string {
+	for _, tag := range preferred {
+		for _, candidate := range []string{tag, strings.SplitN(tag, "-", 2)[0]} {
+			for language := range available {
+				if strings.EqualFold(language, candidate) {
+					return language
+				}
+			}
+		}
+	}
+
+	return ""
+}
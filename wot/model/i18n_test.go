@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCaseLocalized(t *testing.T) {
+	var td ThingDescription
+	err := json.Unmarshal([]byte(`{
+		"name": "boiler",
+		"title": "Boiler",
+		"titles": {"de": "Heizkessel", "cs-CZ": "Kotel"},
+		"properties": [
+			{"name": "temperature", "title": "Temperature", "titles": {"de": "Temperatur"},
+				"description": "Water temperature", "descriptions": {"cs-CZ": "Teplota vody"}}
+		]
+	}`), &td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	de, language := td.Localized([]string{"fr", "de-AT", "cs-CZ"})
+	Equals(t, "de", language)
+	Equals(t, "Heizkessel", de.Title)
+	Equals(t, "Temperatur", de.Properties[0].Title)
+	Equals(t, "Water temperature", de.Properties[0].Description)
+
+	cs, language := td.Localized([]string{"cs-cz"})
+	Equals(t, "cs-CZ", language)
+	Equals(t, "Teplota vody", cs.Properties[0].Description)
+
+	//original description is not modified
+	Equals(t, "Boiler", td.Title)
+	Equals(t, "Temperature", td.Properties[0].Title)
+
+	same, language := td.Localized([]string{"fr"})
+	Equals(t, "", language)
+	Equals(t, "Boiler", same.Title)
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,43 +11,203 @@ import (
 
 type Context []interface{}
 
+// Expand expands compact IRI by prefix declared in context, other values are returned unchanged
+func (c Context) Expand(iri string) string {
+	prefix, suffix, ok := strings.Cut(iri, ":")
+	if !ok || strings.HasPrefix(suffix, "//") {
+		return iri
+	}
+
+	for _, entry := range c {
+		if m, ok := entry.(map[string]interface{}); ok {
+			if ns, ok := m[prefix].(string); ok {
+				return ns + suffix
+			}
+		}
+	}
+
+	return iri
+}
+
+// Types are semantic types of Thing, @type is either single type or array of types
+type Types []string
+
+func (ts *Types) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*ts = Types{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return errors.New("@type is neither string nor array of strings")
+	}
+
+	*ts = Types(multiple)
+	return nil
+}
+
+// MarshalJSON keeps single type as string as TDs usually declare it
+func (ts Types) MarshalJSON() ([]byte, error) {
+	switch len(ts) {
+	case 0:
+		return json.Marshal("")
+	case 1:
+		return json.Marshal(ts[0])
+	default:
+		return json.Marshal([]string(ts))
+	}
+}
+
+// Has reports whether t is one of types
+func (ts Types) Has(t string) bool {
+	for _, typ := range ts {
+		if typ == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ts Types) String() string {
+	return strings.Join(ts, " ")
+}
+
 type ThingDescription struct {
-	AT_Context Context    `json:"@context"`
-	AT_Type    string     `json:"@type"`
-	Name       string     `json:"name"`
+	AT_Context Context `json:"@context"`
+	AT_Type    Types   `json:"@type"`
+	Name       string  `json:"name"`
+	Texts
 	Uris       []string   `json:"uris"`
 	Encodings  []string   `json:"encodings"`
 	Properties []Property `json:"properties"`
 	Actions    []Action   `json:"actions"`
 	Events     []Event    `json:"events"`
+	// Schemas are value types shared by interactions, referenced by name from Ref of value types
+	Schemas map[string]ValueType `json:"schemaDefinitions,omitempty"`
+	// Version of description, Number is counted by server, Instance is optional semantic version
+	// declared by author of description
+	Version *VersionInfo `json:"version,omitempty"`
+}
+
+// VersionInfo identifies revision of description. Number starts at 1 and is incremented by every change of
+// bound description, e.g. forms of new binding.
+type VersionInfo struct {
+	Number   int    `json:"number,omitempty"`
+	Instance string `json:"instance,omitempty"`
 }
 
 type Property struct {
-	Name      string    `json:"name"`
-	ValueType ValueType `json:"valueType"`
-	Unit      string    `json:"unit"`
-	Writable  bool      `json:"writable"`
-	Hrefs     []string  `json:"hrefs"`
+	Name string `json:"name"`
+	Texts
+	ValueType ValueType     `json:"valueType"`
+	Unit      string        `json:"unit"`
+	Writable  bool          `json:"writable"`
+	Hrefs     []string      `json:"hrefs"`
+	Notify    *NotifyPolicy `json:"notify,omitempty"`
+	// Transforms converts raw backend value to declared value, steps are applied in order on read
+	// and in reverse order on write
+	Transforms []Transform `json:"transforms,omitempty"`
+	// Encryption marks values encrypted end-to-end by device, they are forwarded as opaque ciphertext
+	Encryption *Encryption `json:"encryption,omitempty"`
+	// LastKnown answers reads failed by offline backend with last known value flagged as stale
+	LastKnown bool `json:"lastKnown,omitempty"`
+	// Push marks property pushed by device when it changes, reads are answered by the last pushed value
+	// and backend is asked only until the first value arrives
+	Push bool `json:"push,omitempty"`
+	// Poll refreshes property of pull-only backend periodically, reads are answered by the last polled value
+	Poll *PollPolicy `json:"poll,omitempty"`
+	// ContentType is media type values are exchanged in by bindings, e.g. "application/senml+json",
+	// JSON when not set
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// ENCRYPTION_JWE is JWE compact serialization of JSON encoded value
+const ENCRYPTION_JWE = "JWE"
+
+// Encryption describes envelope of encrypted values, KeyID names key shared by device and consumers
+type Encryption struct {
+	Scheme string `json:"scheme"`
+	KeyID  string `json:"kid,omitempty"`
+}
+
+// Transform is one step of property value conversion, it sets one of ByteSwap, Scale with Offset or Enum.
+// ByteSwap reverses byte order of integer of given size in bytes, Signed interprets the result as two's
+// complement. Scale and Offset compute value = raw * scale + offset, Enum maps raw codes to labels.
+type Transform struct {
+	ByteSwap int               `json:"byteSwap,omitempty"`
+	Signed   bool              `json:"signed,omitempty"`
+	Scale    float64           `json:"scale,omitempty"`
+	Offset   float64           `json:"offset,omitempty"`
+	Enum     map[string]string `json:"enum,omitempty"`
+}
+
+// NotifyPolicy limits property change notifications of noisy sensors. MinInterval (milliseconds) throttles
+// notifications, the latest suppressed value is notified when interval elapses. MinDelta is absolute and
+// Deadband percentual (of valueType range, or of last notified value if range is not set) change of numeric
+// value relative to the last notified value, smaller changes are not notified.
+type NotifyPolicy struct {
+	MinInterval int     `json:"minInterval,omitempty"`
+	MinDelta    float64 `json:"minDelta,omitempty"`
+	Deadband    float64 `json:"deadband,omitempty"`
+}
+
+// PollPolicy reads property every Interval (milliseconds) delayed by random Jitter (milliseconds), so
+// polls of many Things do not hit backend at once. Properties due at the same time are read one by one
+// in order of descending Priority.
+type PollPolicy struct {
+	Interval int `json:"interval"`
+	Jitter   int `json:"jitter,omitempty"`
+	Priority int `json:"priority,omitempty"`
 }
 
 type Action struct {
-	AT_Type    string     `json:"@type"`
-	Name       string     `json:"name"`
-	InputData  InputData  `json:"inputData"`
-	OutputData OutputData `json:"outputData"`
-	Hrefs      []string   `json:"hrefs"`
+	AT_Type string `json:"@type"`
+	Name    string `json:"name"`
+	Texts
+	InputData   InputData    `json:"inputData"`
+	OutputData  OutputData   `json:"outputData"`
+	Hrefs       []string     `json:"hrefs"`
+	Concurrency *Concurrency `json:"concurrency,omitempty"`
+	// Signed requires invocations to carry JWS detached signature over input by key of invoking client
+	Signed bool `json:"signed,omitempty"`
+	// Synchronous invocations are answered by action result instead of task links, for actions finishing
+	// in milliseconds
+	Synchronous bool `json:"synchronous,omitempty"`
+}
+
+const (
+	// CONCURRENCY_SERIAL queues invocations and executes them one by one
+	CONCURRENCY_SERIAL = "serial"
+	// CONCURRENCY_PARALLEL executes up to Max invocations at once, others are queued
+	CONCURRENCY_PARALLEL = "parallel"
+	// CONCURRENCY_REJECT executes up to Max invocations at once, others are rejected
+	CONCURRENCY_REJECT = "reject"
+)
+
+// Concurrency is action execution policy, e.g. physical actuator must not receive overlapping invocations.
+// Max defaults to 1, for parallel policy 0 means unlimited.
+type Concurrency struct {
+	Policy string `json:"policy"`
+	Max    int    `json:"max,omitempty"`
 }
 
 type Event struct {
-	AT_Type   string    `json:"@type"`
-	Name      string    `json:"name"`
-	ValueType ValueType `json:"valueType"`
-	Hrefs     []string  `json:"hrefs"`
+	AT_Type string `json:"@type"`
+	Name    string `json:"name"`
+	Texts
+	ValueType  ValueType   `json:"valueType"`
+	Hrefs      []string    `json:"hrefs"`
+	Encryption *Encryption `json:"encryption,omitempty"`
 }
 
 type InputData struct {
 	ValueType ValueType `json:"valueType"`
 	Unit      string    `json:"unit"`
+	// ContentType is media type of input, see Property
+	ContentType string `json:"contentType,omitempty"`
 }
 
 type OutputData struct {
@@ -54,36 +215,211 @@ type OutputData struct {
 	Unit      string    `json:"unit"`
 }
 
+// ValueType is schema of values of the value model: scalars "boolean", "integer", "number" and "string",
+// "object" of named fields and "array" of values. Empty type allows any value.
 type ValueType struct {
 	Type    string `json:"type"`
 	Minimum int    `json:"minimum"`
 	Maximum int    `json:"maximum"`
+	// Properties are value types of fields of object, Required fields must be present
+	Properties map[string]ValueType `json:"properties,omitempty"`
+	Required   []string             `json:"required,omitempty"`
+	// Items is value type of elements of array
+	Items *ValueType `json:"items,omitempty"`
+	// Ref is name of schema of description the value type stands for, e.g. "position" or
+	// "#/schemaDefinitions/position". It is replaced by the schema when description is loaded and kept
+	// to name the type.
+	Ref string `json:"$ref,omitempty"`
+	// ContentEncoding "base64" marks string carrying binary data, []byte values conform to it too
+	ContentEncoding string `json:"contentEncoding,omitempty"`
 }
 
 func Create(uri string) *ThingDescription {
-	sep := strings.Split(uri, "://")
+	td, e := Load(uri)
+
+	if e != nil {
+		fmt.Printf("File error: %v\n", e)
+		os.Exit(1)
+	}
+
+	return td
+}
+
+// HasType reports whether TD declares semantic type t, compact IRIs of both are expanded by TD context
+func (td *ThingDescription) HasType(t string) bool {
+	if td.AT_Type.Has(t) {
+		return true
+	}
+
+	expanded := td.AT_Context.Expand(t)
+	for _, typ := range td.AT_Type {
+		if td.AT_Context.Expand(typ) == expanded {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load reads ThingDescription from uri and validates it, contrary to Create errors are returned. Thing Models
+// are resolved to description, see ResolveModel.
+func Load(uri string) (*ThingDescription, error) {
+	sep := strings.SplitN(uri, "://", 2)
+
+	if len(sep) != 2 {
+		return nil, errors.New("Invalid description uri: " + uri)
+	}
+
 	method, path := sep[0], sep[1]
 
 	if method == "file" {
 		return fromFile(path)
 	}
 
-	return &ThingDescription{}
+	if method == "http" || method == "https" {
+		return fromURL(uri)
+	}
+
+	return &ThingDescription{}, nil
 }
 
-func fromFile(path string) *ThingDescription {
+func fromFile(path string) (*ThingDescription, error) {
 	file, e := ioutil.ReadFile(path)
 
 	if e != nil {
-		fmt.Printf("File error: %v\n", e)
-		os.Exit(1)
+		return nil, e
+	}
+
+	return parse(file, path)
+}
+
+func fromURL(uri string) (*ThingDescription, error) {
+	data, e := newModelResolver().read(uri)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return parse(data, uri)
+}
+
+func parse(data []byte, location string) (*ThingDescription, error) {
+	data, e := ResolveModel(data, location)
+
+	if e != nil {
+		return nil, e
 	}
 
 	var td ThingDescription
 
-	json.Unmarshal(file, &td)
+	if e = json.Unmarshal(data, &td); e != nil {
+		return nil, e
+	}
 
 	td.Uris = make([]string, 0)
 
-	return &td
+	if e = td.ResolveSchemas(); e != nil {
+		return nil, e
+	}
+
+	return &td, td.Validate()
+}
+
+// Validate checks ThingDescription is named and interaction names are unique
+func (td *ThingDescription) Validate() error {
+	if td.Name == "" {
+		return errors.New("Thing description has no name")
+	}
+
+	names := make(map[string]bool)
+	check := func(kind, name string) error {
+		if name == "" {
+			return errors.New("Thing description " + td.Name + " has unnamed " + kind)
+		}
+		if names[name] {
+			return errors.New("Thing description " + td.Name + " has duplicate interaction: " + name)
+		}
+		names[name] = true
+		return nil
+	}
+
+	for _, p := range td.Properties {
+		if e := check("property", p.Name); e != nil {
+			return e
+		}
+		if e := p.Notify.Validate(); e != nil {
+			return errors.New("Thing description " + td.Name + " property " + p.Name + ": " + e.Error())
+		}
+		if e := p.Encryption.Validate(); e != nil {
+			return errors.New("Thing description " + td.Name + " property " + p.Name + ": " + e.Error())
+		}
+		if p.Encryption != nil && (len(p.Transforms) > 0 || p.Notify != nil && (p.Notify.MinDelta > 0 || p.Notify.Deadband > 0)) {
+			return errors.New("Thing description " + td.Name + " property " + p.Name + ": encrypted value cannot be transformed or filtered")
+		}
+	}
+
+	for _, a := range td.Actions {
+		if e := check("action", a.Name); e != nil {
+			return e
+		}
+		if e := a.Concurrency.Validate(); e != nil {
+			return errors.New("Thing description " + td.Name + " action " + a.Name + ": " + e.Error())
+		}
+	}
+
+	for _, ev := range td.Events {
+		if e := check("event", ev.Name); e != nil {
+			return e
+		}
+		if e := ev.Encryption.Validate(); e != nil {
+			return errors.New("Thing description " + td.Name + " event " + ev.Name + ": " + e.Error())
+		}
+	}
+
+	return nil
+}
+
+// Validate checks concurrency policy is known, nil policy is valid
+func (c *Concurrency) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.Policy {
+	case CONCURRENCY_SERIAL, CONCURRENCY_PARALLEL, CONCURRENCY_REJECT:
+	default:
+		return errors.New("unknown concurrency policy: " + c.Policy)
+	}
+
+	if c.Max < 0 {
+		return errors.New("negative concurrency max")
+	}
+
+	return nil
+}
+
+// Validate checks notification policy limits are not negative, nil policy is valid
+func (np *NotifyPolicy) Validate() error {
+	if np == nil {
+		return nil
+	}
+
+	if np.MinInterval < 0 || np.MinDelta < 0 || np.Deadband < 0 {
+		return errors.New("negative notification policy limit")
+	}
+
+	return nil
+}
+
+// Validate checks encryption scheme is supported, nil encryption is valid
+func (e *Encryption) Validate() error {
+	if e == nil {
+		return nil
+	}
+
+	if e.Scheme != ENCRYPTION_JWE {
+		return errors.New("unknown encryption scheme: " + e.Scheme)
+	}
+
+	return nil
 }
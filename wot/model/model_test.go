@@ -1,10 +1,36 @@
 package model
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 func TestCase1(t *testing.T) {
 	model := Create("file://testdata/reference-model.json")
-	Equals(t, "Thing", model.AT_Type)
+	Equals(t, "Thing", model.AT_Type.String())
+}
+
+func TestCaseTypes(t *testing.T) {
+	var td ThingDescription
+	if err := json.Unmarshal([]byte(`{"name": "sensor", "@type": ["Thing", "iot:TemperatureSensor"]}`), &td); err != nil {
+		t.Fatal(err)
+	}
+
+	if !td.AT_Type.Has("iot:TemperatureSensor") || td.AT_Type.Has("iot:Light") {
+		t.Log("Unexpected types ", td.AT_Type)
+		t.Fail()
+	}
+
+	td.AT_Context = Context{map[string]interface{}{"iot": "http://iotschema.org/"}}
+	if !td.HasType("http://iotschema.org/TemperatureSensor") || !td.HasType("iot:TemperatureSensor") {
+		t.Log("Type not matched by expanded IRI")
+		t.Fail()
+	}
+
+	b, _ := json.Marshal(Types{"Thing"})
+	Equals(t, `"Thing"`, string(b))
+	b, _ = json.Marshal(td.AT_Type)
+	Equals(t, `["Thing","iot:TemperatureSensor"]`, string(b))
 }
 
 func Equals(t *testing.T, expected, actual string) {
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Template is Thing Model: ThingDescription with {{NAME}} placeholders and no instance URIs, from which
+// concrete Things are instantiated by substituting parameters
+type Template struct {
+	raw          []byte
+	placeholders []string
+}
+
+// LoadTemplate reads template from uri, only file:// uris are supported. Thing Models it extends or references
+// are resolved, see ResolveModel.
+func LoadTemplate(uri string) (*Template, error) {
+	sep := strings.SplitN(uri, "://", 2)
+
+	if len(sep) != 2 || sep[0] != "file" {
+		return nil, errors.New("Invalid template uri: " + uri)
+	}
+
+	data, err := ioutil.ReadFile(sep[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = ResolveModel(data, sep[1]); err != nil {
+		return nil, err
+	}
+
+	return ParseTemplate(data)
+}
+
+// ParseTemplate checks template is JSON document and collects its placeholders
+func ParseTemplate(data []byte) (*Template, error) {
+	if !json.Valid(data) {
+		return nil, errors.New("Template is not valid JSON")
+	}
+
+	seen := make(map[string]bool)
+	placeholders := make([]string, 0)
+
+	for _, m := range placeholder.FindAllSubmatch(data, -1) {
+		name := string(m[1])
+		if !seen[name] {
+			seen[name] = true
+			placeholders = append(placeholders, name)
+		}
+	}
+	sort.Strings(placeholders)
+
+	return &Template{
+		raw:          data,
+		placeholders: placeholders,
+	}, nil
+}
+
+// Placeholders lists parameter names the template expects
+func (t *Template) Placeholders() []string {
+	return t.placeholders
+}
+
+// Instantiate substitutes params and returns validated ThingDescription. String value consisting of single
+// placeholder is replaced by JSON value of the parameter, so numeric and boolean parameters keep their type,
+// placeholders inside longer strings are replaced by text of the parameter.
+func (t *Template) Instantiate(params map[string]interface{}) (*ThingDescription, error) {
+	missing := make([]string, 0)
+	for _, name := range t.placeholders {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, errors.New("Template parameters missing: " + strings.Join(missing, ", "))
+	}
+
+	var err error
+	data := placeholder.ReplaceAllFunc(t.raw, func(m []byte) []byte {
+		return []byte(placeholderMark(string(placeholder.FindSubmatch(m)[1])))
+	})
+
+	for _, name := range t.placeholders {
+		value := params[name]
+		mark := placeholderMark(name)
+
+		literal, e := json.Marshal(value)
+		if e != nil {
+			err = e
+			continue
+		}
+
+		text, _ := json.Marshal(fmt.Sprint(value))
+		if s, ok := value.(string); ok {
+			text, _ = json.Marshal(s)
+		}
+
+		data = bytes.ReplaceAll(data, []byte(`"`+mark+`"`), literal)
+		data = bytes.ReplaceAll(data, []byte(mark), text[1:len(text)-1])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	td := &ThingDescription{}
+	if err = json.Unmarshal(data, td); err != nil {
+		return nil, errors.New("Instantiated template is invalid: " + err.Error())
+	}
+
+	td.Uris = make([]string, 0)
+
+	if err = td.ResolveSchemas(); err != nil {
+		return nil, err
+	}
+
+	return td, td.Validate()
+}
+
+// Substitute replaces placeholders of s, e.g. context path pattern of template instances
+func Substitute(s string, params map[string]interface{}) string {
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := params[placeholder.FindStringSubmatch(m)[1]]; ok {
+			return fmt.Sprint(v)
+		}
+		return m
+	})
+}
+
+// placeholderMark is normalized placeholder, so spacing inside braces does not matter when substituting
+func placeholderMark(name string) string {
+	return "{{" + name + "}}"
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCaseTemplate(t *testing.T) {
+	tmpl, err := LoadTemplate("file://testdata/sensor-template.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Equals(t, "id,max,min,unit", strings.Join(tmpl.Placeholders(), ","))
+
+	td, err := tmpl.Instantiate(map[string]interface{}{"id": 7, "min": -40, "max": 125, "unit": "Cel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Equals(t, "sensor-7", td.Name)
+	Equals(t, "-40", strconv.Itoa(td.Properties[0].ValueType.Minimum))
+	Equals(t, "125", strconv.Itoa(td.Properties[0].ValueType.Maximum))
+	Equals(t, "Cel", td.Properties[0].Unit)
+	Equals(t, "/sensors/7", Substitute("/sensors/{{id}}", map[string]interface{}{"id": 7}))
+
+	_, err = tmpl.Instantiate(map[string]interface{}{"id": 1})
+	Equals(t, "Template parameters missing: max, min, unit", err.Error())
+}
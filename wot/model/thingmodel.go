@@ -0,0 +1,455 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TM_TYPE is @type of Thing Model, it is dropped from descriptions resolved from Thing Models
+	TM_TYPE = "tm:ThingModel"
+	// TM_EXTENDS is relation of link to Thing Model extended by the model, e.g.
+	// "links": [{"rel": "tm:extends", "href": "base-sensor.json"}]
+	TM_EXTENDS = "tm:extends"
+	// TM_REF is member of object replaced by object it references, e.g.
+	// {"tm:ref": "units.json#/properties/temperature", "unit": "Cel"}
+	TM_REF = "tm:ref"
+	// TM_NAMESPACE is IRI of tm prefix
+	TM_NAMESPACE = "https://www.w3.org/2022/wot/tm#"
+)
+
+// TM_MAX_DEPTH limits nesting of tm:ref within single document
+const TM_MAX_DEPTH = 16
+
+// TM_FETCH_TIMEOUT limits fetching of Thing Models referenced by URL
+const TM_FETCH_TIMEOUT = 10 * time.Second
+
+// modelResolver resolves Thing Models referenced by tm:extends and tm:ref. References are files or
+// http(s) URLs, relative references are relative to location of referencing document.
+type modelResolver struct {
+	loading map[string]bool
+	client  *http.Client
+}
+
+func newModelResolver() *modelResolver {
+	return &modelResolver{
+		loading: make(map[string]bool),
+		client:  &http.Client{Timeout: TM_FETCH_TIMEOUT},
+	}
+}
+
+// ResolveModel returns JSON document of description, location is path or URL of data. Models it
+// extends are merged under it, objects referencing other objects by tm:ref are replaced by them with
+// their own members kept, and tm:ThingModel type is dropped. Interactions declared by arrays are merged
+// by their name.
+func ResolveModel(data []byte, location string) ([]byte, error) {
+	r := newModelResolver()
+	r.loading[location] = true
+
+	doc, err := r.resolve(data, location)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(doc)
+}
+
+func (r *modelResolver) load(location string) (map[string]interface{}, error) {
+	if r.loading[location] {
+		return nil, errors.New("Thing Model " + location + " extends or references itself")
+	}
+
+	r.loading[location] = true
+	defer delete(r.loading, location)
+
+	data, err := r.read(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.resolve(data, location)
+}
+
+func (r *modelResolver) read(location string) ([]byte, error) {
+	if !isURL(location) {
+		return ioutil.ReadFile(location)
+	}
+
+	resp, err := r.client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Thing Model " + location + " not fetched: " + resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (r *modelResolver) resolve(data []byte, location string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.New("Thing Model " + location + " is invalid: " + err.Error())
+	}
+
+	bases, err := r.extended(doc, location)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged interface{} = doc
+	types := make([]interface{}, 0)
+	for i := len(bases) - 1; i >= 0; i-- {
+		merged = merge(bases[i], merged)
+	}
+	for _, base := range append(bases, doc) {
+		types = appendTypes(types, base.(map[string]interface{})["@type"])
+	}
+	if len(types) > 0 {
+		merged.(map[string]interface{})["@type"] = types
+	}
+
+	//references of the same document point to interactions of extended models too
+	resolved, err := r.refs(merged, merged.(map[string]interface{}), location, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	doc = resolved.(map[string]interface{})
+	dropModelType(doc)
+
+	return doc, nil
+}
+
+// extended loads models doc extends and removes tm:extends links from doc
+func (r *modelResolver) extended(doc map[string]interface{}, location string) ([]interface{}, error) {
+	links, _ := doc["links"].([]interface{})
+
+	bases := make([]interface{}, 0)
+	kept := make([]interface{}, 0)
+	for _, l := range links {
+		link, _ := l.(map[string]interface{})
+		if link["rel"] != TM_EXTENDS {
+			kept = append(kept, l)
+			continue
+		}
+
+		href, _ := link["href"].(string)
+		base, err := r.load(relative(location, href))
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+
+	if len(kept) > 0 {
+		doc["links"] = kept
+	} else {
+		delete(doc, "links")
+	}
+
+	return bases, nil
+}
+
+// refs replaces objects of v referencing other objects, root is document of v
+func (r *modelResolver) refs(v interface{}, root map[string]interface{}, location string, depth int) (interface{}, error) {
+	if depth > TM_MAX_DEPTH {
+		return nil, errors.New("Thing Model " + location + " tm:ref nested too deep")
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(value))
+		for k, member := range value {
+			if k == TM_REF {
+				continue
+			}
+			m, err := r.refs(member, root, location, depth)
+			if err != nil {
+				return nil, err
+			}
+			resolved[k] = m
+		}
+
+		ref, ok := value[TM_REF].(string)
+		if !ok {
+			return resolved, nil
+		}
+
+		target, err := r.referenced(ref, root, location, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		return merge(target, resolved), nil
+	case []interface{}:
+		resolved := make([]interface{}, len(value))
+		for i, element := range value {
+			e, err := r.refs(element, root, location, depth)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = e
+		}
+
+		return resolved, nil
+	}
+
+	return v, nil
+}
+
+// referenced returns object ref points to, e.g. "units.json#/properties/temperature" or "#/schemaDefinitions/level"
+func (r *modelResolver) referenced(ref string, root map[string]interface{}, location string, depth int) (interface{}, error) {
+	doc, pointer, _ := strings.Cut(ref, "#")
+
+	var target interface{}
+	if doc == "" {
+		t, err := lookup(root, pointer)
+		if err != nil {
+			return nil, errors.New("Thing Model " + location + " " + err.Error())
+		}
+		//references of the same document are resolved as they are found
+		if target, err = r.refs(t, root, location, depth+1); err != nil {
+			return nil, err
+		}
+	} else {
+		referenced := relative(location, doc)
+		loaded, err := r.load(referenced)
+		if err != nil {
+			return nil, err
+		}
+		if target, err = lookup(loaded, pointer); err != nil {
+			return nil, errors.New("Thing Model " + referenced + " " + err.Error())
+		}
+	}
+
+	if _, ok := target.(map[string]interface{}); !ok {
+		return nil, errors.New("Thing Model " + location + " tm:ref " + ref + " is not an object")
+	}
+
+	return target, nil
+}
+
+// lookup follows JSON pointer, elements of arrays are addressed by index or by their name
+func lookup(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" || pointer == "/" {
+		return doc, nil
+	}
+
+	v := doc
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		found := false
+		switch value := v.(type) {
+		case map[string]interface{}:
+			v, found = value[token]
+		case []interface{}:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(value) {
+				v, found = value[i], true
+				break
+			}
+			for _, element := range value {
+				if named, ok := element.(map[string]interface{}); ok && named["name"] == token {
+					v, found = element, true
+					break
+				}
+			}
+		}
+
+		if !found {
+			return nil, errors.New("has no " + pointer)
+		}
+	}
+
+	return v, nil
+}
+
+// merge returns over merged onto base, objects are merged by members, arrays of named objects by names
+func merge(base, over interface{}) interface{} {
+	switch o := over.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return over
+		}
+
+		merged := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			merged[k] = v
+		}
+		for k, v := range o {
+			if bv, ok := b[k]; ok {
+				merged[k] = merge(bv, v)
+			} else {
+				merged[k] = v
+			}
+		}
+
+		return merged
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || !named(b) || !named(o) {
+			return over
+		}
+
+		merged := append([]interface{}{}, b...)
+		for _, element := range o {
+			name := element.(map[string]interface{})["name"]
+
+			replaced := false
+			for i, existing := range merged {
+				if existing.(map[string]interface{})["name"] == name {
+					merged[i], replaced = merge(existing, element), true
+					break
+				}
+			}
+			if !replaced {
+				merged = append(merged, element)
+			}
+		}
+
+		return merged
+	}
+
+	return over
+}
+
+// named reports whether array consists of objects with name, as interactions of description
+func named(array []interface{}) bool {
+	for _, element := range array {
+		m, ok := element.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// appendTypes adds types of @type not listed yet, Thing extending models has types of all of them
+func appendTypes(types []interface{}, t interface{}) []interface{} {
+	add := []interface{}{t}
+	if multiple, ok := t.([]interface{}); ok {
+		add = multiple
+	}
+
+	for _, typ := range add {
+		if typ == nil {
+			continue
+		}
+		found := false
+		for _, existing := range types {
+			found = found || existing == typ
+		}
+		if !found {
+			types = append(types, typ)
+		}
+	}
+
+	return types
+}
+
+func dropModelType(doc map[string]interface{}) {
+	switch t := doc["@type"].(type) {
+	case string:
+		if t == TM_TYPE {
+			delete(doc, "@type")
+		}
+	case []interface{}:
+		types := make([]interface{}, 0, len(t))
+		for _, typ := range t {
+			if typ != TM_TYPE {
+				types = append(types, typ)
+			}
+		}
+		doc["@type"] = types
+	}
+}
+
+// relative resolves reference relative to location of referencing document
+func relative(location, ref string) string {
+	if isURL(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+
+	if isURL(location) {
+		base, err := url.Parse(location)
+		if err != nil {
+			return ref
+		}
+		target, err := base.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return target.String()
+	}
+
+	return filepath.Join(filepath.Dir(location), ref)
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// ThingModel returns copy of description exported as Thing Model: instance URIs, absolute hrefs of
+// bindings and version number are dropped and tm:ThingModel type is declared
+func (td *ThingDescription) ThingModel() *ThingDescription {
+	tm := *td
+	tm.Uris = make([]string, 0)
+	tm.Encodings = append([]string{}, td.Encodings...)
+
+	if !td.AT_Type.Has(TM_TYPE) {
+		tm.AT_Type = append(Types{TM_TYPE}, td.AT_Type...)
+	}
+	if td.AT_Context.Expand("tm:ThingModel") == "tm:ThingModel" {
+		tm.AT_Context = append(append(Context{}, td.AT_Context...), map[string]interface{}{"tm": TM_NAMESPACE})
+	}
+
+	tm.Version = nil
+	if td.Version != nil && td.Version.Instance != "" {
+		tm.Version = &VersionInfo{Instance: td.Version.Instance}
+	}
+
+	tm.Properties = append([]Property{}, td.Properties...)
+	for i := range tm.Properties {
+		tm.Properties[i].Hrefs = relativeHrefs(tm.Properties[i].Hrefs)
+	}
+	tm.Actions = append([]Action{}, td.Actions...)
+	for i := range tm.Actions {
+		tm.Actions[i].Hrefs = relativeHrefs(tm.Actions[i].Hrefs)
+	}
+	tm.Events = append([]Event{}, td.Events...)
+	for i := range tm.Events {
+		tm.Events[i].Hrefs = relativeHrefs(tm.Events[i].Hrefs)
+	}
+
+	return &tm
+}
+
+func relativeHrefs(hrefs []string) []string {
+	kept := make([]string, 0, len(hrefs))
+	for _, href := range hrefs {
+		if u, err := url.Parse(href); err == nil && !u.IsAbs() && u.Host == "" {
+			kept = append(kept, href)
+		}
+	}
+
+	return kept
+}
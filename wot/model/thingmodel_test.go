@@ -0,0 +1,46 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCaseThingModel(t *testing.T) {
+	td, err := Load("file://testdata/room-sensor.tm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Equals(t, "room-sensor", td.Name)
+	Equals(t, "Sensor", td.Title)
+	Equals(t, "Thing", td.AT_Type.String())
+
+	names := make([]string, 0)
+	for _, p := range td.Properties {
+		names = append(names, p.Name)
+	}
+	Equals(t, "battery,temperature,setpoint,humidity", strings.Join(names, ","))
+
+	//extending model overrides members of extended interactions
+	temperature := td.Properties[1]
+	Equals(t, "K", temperature.Unit)
+	Equals(t, "number 125", temperature.ValueType.Type+" "+strconv.Itoa(temperature.ValueType.Maximum))
+
+	setpoint := td.Properties[2]
+	Equals(t, "Cel true", setpoint.Unit+" "+strconv.FormatBool(setpoint.Writable))
+	Equals(t, "number", setpoint.ValueType.Type)
+	Equals(t, "%", td.Properties[3].Unit)
+
+	_, err = Load("file://testdata/cyclic.tm.json")
+	Equals(t, "Thing Model testdata/cyclic.tm.json extends or references itself", fmt.Sprint(err))
+
+	td.Uris = []string{"http://localhost:8080/room"}
+	td.Properties[0].Hrefs = []string{"battery", "http://localhost:8080/room/battery"}
+	tm := td.ThingModel()
+	Equals(t, "tm:ThingModel Thing", tm.AT_Type.String())
+	Equals(t, "https://www.w3.org/2022/wot/tm#ThingModel", tm.AT_Context.Expand(TM_TYPE))
+	Equals(t, "0 battery", strconv.Itoa(len(tm.Uris))+" "+strings.Join(tm.Properties[0].Hrefs, ","))
+	Equals(t, "2", strconv.Itoa(len(td.Properties[0].Hrefs)))
+}
@@ -0,0 +1,279 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// CONTENT_ENCODING_BASE64 is contentEncoding of strings carrying binary data
+const CONTENT_ENCODING_BASE64 = "base64"
+
+// Validate checks v conforms to value type. Minimum and maximum are enforced only when any of them is set.
+// Objects and arrays are validated field by field and element by element, Go structs, maps and slices are
+// validated by their JSON form.
+func (vt ValueType) Validate(v interface{}) error {
+	switch vt.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("expected boolean, got %T", v)
+		}
+		return nil
+	case "string":
+		if vt.ContentEncoding == CONTENT_ENCODING_BASE64 {
+			return validateBase64(v)
+		}
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("expected string, got %T", v)
+		}
+		return nil
+	case "integer", "number":
+		n, ok := Number(v)
+		if !ok {
+			return fmt.Errorf("expected %s, got %T", vt.Type, v)
+		}
+		if vt.Type == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("expected integer, got %v", v)
+		}
+		if (vt.Minimum != 0 || vt.Maximum != 0) && (n < float64(vt.Minimum) || n > float64(vt.Maximum)) {
+			return fmt.Errorf("value %v out of range <%d, %d>", v, vt.Minimum, vt.Maximum)
+		}
+		return nil
+	case "object":
+		fields, ok := Fields(v)
+		if !ok {
+			return fmt.Errorf("expected object, got %T", v)
+		}
+		for _, name := range vt.Required {
+			if _, ok := fields[name]; !ok {
+				return fmt.Errorf("missing field %s", name)
+			}
+		}
+		for name, field := range vt.Properties {
+			if value, ok := fields[name]; ok {
+				if err := field.Validate(value); err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+			}
+		}
+		return nil
+	case "array":
+		elements, ok := Elements(v)
+		if !ok {
+			return fmt.Errorf("expected array, got %T", v)
+		}
+		if vt.Items == nil {
+			return nil
+		}
+		for i, element := range elements {
+			if err := vt.Items.Validate(element); err != nil {
+				return fmt.Errorf("[%d]: %v", i, err)
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// Fields returns fields of object value, decoded JSON object or Go struct or map converted by its JSON form
+func Fields(v interface{}) (map[string]interface{}, bool) {
+	if fields, ok := v.(map[string]interface{}); ok {
+		return fields, true
+	}
+
+	if v == nil {
+		return nil, false
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Struct, reflect.Map:
+	default:
+		return nil, false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// Elements returns elements of array value, decoded JSON array or Go slice or array
+func Elements(v interface{}) ([]interface{}, bool) {
+	if elements, ok := v.([]interface{}); ok {
+		return elements, true
+	}
+
+	if v == nil {
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	//byte slices are encoded as strings, see JSON encoding of []byte
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	elements := make([]interface{}, rv.Len())
+	for i := range elements {
+		elements[i] = rv.Index(i).Interface()
+	}
+
+	return elements, true
+}
+
+// ResolveSchemas replaces value types of interactions referencing Schemas of description by referenced
+// schemas, Ref is kept to name the type. Unknown and recursive references are errors.
+func (td *ThingDescription) ResolveSchemas() error {
+	resolve := func(kind, name string, vt *ValueType) error {
+		resolved, err := td.resolve(*vt, nil)
+		if err != nil {
+			return errors.New("Thing description " + td.Name + " " + kind + " " + name + ": " + err.Error())
+		}
+		*vt = resolved
+		return nil
+	}
+
+	for i := range td.Properties {
+		if err := resolve("property", td.Properties[i].Name, &td.Properties[i].ValueType); err != nil {
+			return err
+		}
+	}
+
+	for i := range td.Actions {
+		if err := resolve("action", td.Actions[i].Name, &td.Actions[i].InputData.ValueType); err != nil {
+			return err
+		}
+		if err := resolve("action", td.Actions[i].Name, &td.Actions[i].OutputData.ValueType); err != nil {
+			return err
+		}
+	}
+
+	for i := range td.Events {
+		if err := resolve("event", td.Events[i].Name, &td.Events[i].ValueType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolve returns copy of vt with references replaced, path are names of schemas being resolved
+func (td *ThingDescription) resolve(vt ValueType, path []string) (ValueType, error) {
+	if vt.Ref != "" {
+		name := strings.TrimPrefix(vt.Ref, "#/schemaDefinitions/")
+		for _, resolving := range path {
+			if resolving == name {
+				return vt, errors.New("recursive schema " + name)
+			}
+		}
+
+		schema, ok := td.Schemas[name]
+		if !ok {
+			return vt, errors.New("unknown schema " + name)
+		}
+
+		resolved, err := td.resolve(schema, append(path, name))
+		resolved.Ref = vt.Ref
+		return resolved, err
+	}
+
+	if vt.Properties != nil {
+		fields := make(map[string]ValueType, len(vt.Properties))
+		for name, field := range vt.Properties {
+			resolved, err := td.resolve(field, path)
+			if err != nil {
+				return vt, err
+			}
+			fields[name] = resolved
+		}
+		vt.Properties = fields
+	}
+
+	if vt.Items != nil {
+		items, err := td.resolve(*vt.Items, path)
+		if err != nil {
+			return vt, err
+		}
+		vt.Items = &items
+	}
+
+	return vt, nil
+}
+
+// validateBase64 accepts raw bytes and their standard base64 encoding
+func validateBase64(v interface{}) error {
+	switch b := v.(type) {
+	case []byte:
+		return nil
+	case string:
+		if _, err := base64.StdEncoding.DecodeString(b); err != nil {
+			return fmt.Errorf("expected base64, %v", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("expected base64 string, got %T", v)
+}
+
+// Bytes returns binary data of value of base64 encoded string type, raw bytes are returned as they are
+func Bytes(v interface{}) ([]byte, bool) {
+	switch b := v.(type) {
+	case []byte:
+		return b, true
+	case string:
+		data, err := base64.StdEncoding.DecodeString(b)
+		return data, err == nil
+	}
+
+	return nil, false
+}
+
+// Number converts value of any numeric kind to float64
+func Number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestCaseValueSchemas(t *testing.T) {
+	var td ThingDescription
+	err := json.Unmarshal([]byte(`{
+		"name": "tracker",
+		"schemaDefinitions": {
+			"position": {"type": "object", "required": ["lat", "lon"], "properties": {
+				"lat": {"type": "number", "minimum": -90, "maximum": 90},
+				"lon": {"type": "number", "minimum": -180, "maximum": 180}
+			}}
+		},
+		"properties": [
+			{"name": "position", "valueType": {"$ref": "position"}},
+			{"name": "route", "valueType": {"type": "array", "items": {"$ref": "#/schemaDefinitions/position"}}}
+		]
+	}`), &td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = td.ResolveSchemas(); err != nil {
+		t.Fatal(err)
+	}
+
+	position := td.Properties[0].ValueType
+	Equals(t, "object position", position.Type+" "+position.Ref)
+	Equals(t, "<nil>", fmt.Sprint(position.Validate(map[string]interface{}{"lat": 50.1, "lon": 14.4})))
+	Equals(t, "missing field lon", fmt.Sprint(position.Validate(map[string]interface{}{"lat": 50.1})))
+	Equals(t, "lat: value 91 out of range <-90, 90>", fmt.Sprint(position.Validate(map[string]interface{}{"lat": 91, "lon": 0})))
+	Equals(t, "expected object, got string", fmt.Sprint(position.Validate("50.1,14.4")))
+
+	//Go values are validated by their JSON form
+	type fix struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	}
+	route := td.Properties[1].ValueType
+	Equals(t, "<nil>", fmt.Sprint(route.Validate([]fix{{50, 14}, {51, 15}})))
+	Equals(t, "[1]: lon: value 200 out of range <-180, 180>", fmt.Sprint(route.Validate([]fix{{50, 14}, {51, 200}})))
+	Equals(t, "expected array, got map[string]interface {}", fmt.Sprint(route.Validate(map[string]interface{}{})))
+
+	td.Schemas["loop"] = ValueType{Type: "array", Items: &ValueType{Ref: "loop"}}
+	td.Properties = append(td.Properties, Property{Name: "loop", ValueType: ValueType{Ref: "loop"}})
+	Equals(t, "Thing description tracker property loop: recursive schema loop", fmt.Sprint(td.ResolveSchemas()))
+
+	td.Properties[2].ValueType.Ref = "unknown"
+	Equals(t, "Thing description tracker property loop: unknown schema unknown", fmt.Sprint(td.ResolveSchemas()))
+}
+
+func TestCaseValueBase64(t *testing.T) {
+	snapshot := ValueType{Type: "string", ContentEncoding: CONTENT_ENCODING_BASE64}
+
+	Equals(t, "<nil>", fmt.Sprint(snapshot.Validate([]byte{0xff, 0xd8})))
+	Equals(t, "<nil>", fmt.Sprint(snapshot.Validate("/9g=")))
+	Equals(t, "expected base64, illegal base64 data at input byte 0", fmt.Sprint(snapshot.Validate("*")))
+	Equals(t, "expected base64 string, got int", fmt.Sprint(snapshot.Validate(1)))
+
+	data, ok := Bytes("/9g=")
+	Equals(t, "true [255 216]", fmt.Sprint(ok, data))
+}
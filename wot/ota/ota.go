@@ -0,0 +1,353 @@
+// Package ota adds firmware update workflow to Things. Update action accepts image reference (URL) or
+// uploaded image and drives update state machine: downloading, verifying, applying and rebooting.
+// State is exposed as observable property. Transfer to device is chunked and resumes from the offset
+// device already stored, so interrupted update continues where it stopped.
+package ota
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	STATE_IDLE        = "idle"
+	STATE_DOWNLOADING = "downloading"
+	STATE_VERIFYING   = "verifying"
+	STATE_APPLYING    = "applying"
+	STATE_REBOOTING   = "rebooting"
+	STATE_FAILED      = "failed"
+
+	ACTION_UPDATE   = "update"
+	PROPERTY_STATUS = "updateStatus"
+	// UPDATE_TYPE is @type of update action
+	UPDATE_TYPE = "FirmwareUpdate"
+
+	CHUNK_SIZE    = 64 << 10
+	CHUNK_RETRIES = 3
+)
+
+// Image references firmware image. SHA256 (hex) identifies image, device verifies it and uses it
+// to resume transfer of the same image.
+type Image struct {
+	URL     string `json:"url,omitempty"`
+	SHA256  string `json:"sha256"`
+	Version string `json:"version,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+}
+
+// Status is state of update, Version is firmware version installed by the last successful update
+type Status struct {
+	State    string    `json:"state"`
+	Target   string    `json:"target,omitempty"`
+	Version  string    `json:"version,omitempty"`
+	Received int64     `json:"received"`
+	Size     int64     `json:"size,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Device receives image and performs update, usually implemented by backend talking to device
+type Device interface {
+	// Offset returns number of bytes of image already stored by device
+	Offset(imageID string) (int64, error)
+	WriteChunk(imageID string, offset int64, chunk []byte) error
+	// Verify checks stored image has SHA-256 digest
+	Verify(imageID string, sha256 []byte) error
+	Apply(imageID string) error
+	Reboot() error
+}
+
+// Updater exposes update action and status property of WotServer
+type Updater struct {
+	s      *server.WotServer
+	dev    Device
+	client *http.Client
+	l      *sync.RWMutex
+	status Status
+}
+
+// Enable adds update action and status property to s. Only one update runs at a time, concurrent
+// invocations are rejected as busy. Action input is Image, or uploaded image stream with form fields
+// sha256 and version.
+func Enable(s *server.WotServer, dev Device) *Updater {
+	u := &Updater{
+		s:      s,
+		dev:    dev,
+		client: &http.Client{},
+		l:      &sync.RWMutex{},
+		status: Status{State: STATE_IDLE, Updated: time.Now()},
+	}
+
+	s.AddProperty(PROPERTY_STATUS, model.Property{
+		Name:      PROPERTY_STATUS,
+		ValueType: model.ValueType{Type: "object"},
+		Hrefs:     []string{PROPERTY_STATUS},
+	})
+	s.OnGetProperty(PROPERTY_STATUS, func() interface{} {
+		return u.Status()
+	})
+
+	s.DefineAction(model.Action{
+		AT_Type:     UPDATE_TYPE,
+		Name:        ACTION_UPDATE,
+		InputData:   model.InputData{ValueType: model.ValueType{Type: "object"}},
+		OutputData:  model.OutputData{ValueType: model.ValueType{Type: "object"}},
+		Hrefs:       []string{ACTION_UPDATE},
+		Concurrency: &model.Concurrency{Policy: model.CONCURRENCY_REJECT},
+	})
+	s.OnInvokeAction(ACTION_UPDATE, u.update)
+
+	if !hasEvent(s.GetDescription(), server.PROPERTY_CHANGE_EVENT) {
+		s.AddEvent(server.PROPERTY_CHANGE_EVENT, model.Event{
+			Name:  server.PROPERTY_CHANGE_EVENT,
+			Hrefs: []string{server.PROPERTY_CHANGE_EVENT},
+		})
+	}
+
+	return u
+}
+
+// WithHTTPClient replaces client downloading images referenced by URL
+func (u *Updater) WithHTTPClient(client *http.Client) *Updater {
+	u.client = client
+	return u
+}
+
+func (u *Updater) Status() Status {
+	u.l.RLock()
+	defer u.l.RUnlock()
+
+	return u.status
+}
+
+// setStatus changes status and notifies observers of status property
+func (u *Updater) setStatus(change func(*Status)) Status {
+	u.l.Lock()
+	change(&u.status)
+	u.status.Updated = time.Now()
+	status := u.status
+	u.l.Unlock()
+
+	u.s.EmitPropertyChange(PROPERTY_STATUS, status)
+	return status
+}
+
+func (u *Updater) update(arg interface{}, ph async.ProgressHandler) interface{} {
+	image, source, err := u.open(arg)
+	if err != nil {
+		return u.fail(err)
+	}
+	if source != nil {
+		defer source.Close()
+	}
+
+	digest, err := hex.DecodeString(image.SHA256)
+	if err != nil || len(digest) != 32 {
+		return u.fail(errors.New("Image sha256 must be hex encoded SHA-256 digest"))
+	}
+
+	u.setStatus(func(s *Status) {
+		*s = Status{State: STATE_DOWNLOADING, Target: image.Version, Version: s.Version, Size: image.Size}
+	})
+
+	if err = u.transfer(image, source, ph); err != nil {
+		return u.fail(err)
+	}
+
+	steps := []struct {
+		state string
+		run   func() error
+	}{
+		{STATE_VERIFYING, func() error { return u.dev.Verify(image.SHA256, digest) }},
+		{STATE_APPLYING, func() error { return u.dev.Apply(image.SHA256) }},
+		{STATE_REBOOTING, u.dev.Reboot},
+	}
+
+	for _, step := range steps {
+		ph.Update(u.setStatus(func(s *Status) { s.State = step.state }))
+
+		if err = step.run(); err != nil {
+			return u.fail(err)
+		}
+	}
+
+	return u.setStatus(func(s *Status) {
+		s.State = STATE_IDLE
+		s.Version = s.Target
+		s.Target = ""
+	})
+}
+
+func (u *Updater) fail(err error) error {
+	log.Error("OTA: update of ", u.s.Name(), " failed -> ", err)
+	u.setStatus(func(s *Status) {
+		s.State = STATE_FAILED
+		s.Error = err.Error()
+	})
+
+	return err
+}
+
+// open reads image reference or uploaded image from action input
+func (u *Updater) open(arg interface{}) (*Image, io.ReadCloser, error) {
+	if stream, ok := arg.(*server.Stream); ok {
+		image := &Image{
+			SHA256:  stream.Params["sha256"],
+			Version: stream.Params["version"],
+		}
+		image.Size, _ = strconv.ParseInt(stream.Params["size"], 10, 64)
+
+		return image, io.NopCloser(stream.Reader), nil
+	}
+
+	image, err := server.As[Image](arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if image.URL == "" {
+		return nil, nil, errors.New("Image url or upload required")
+	}
+
+	return &image, nil, nil
+}
+
+// transfer writes image to device in chunks starting at offset device already has, chunk write
+// failures are retried from offset reported by device
+func (u *Updater) transfer(image *Image, source io.ReadCloser, ph async.ProgressHandler) error {
+	offset, err := u.dev.Offset(image.SHA256)
+	if err != nil {
+		return err
+	}
+
+	if source == nil {
+		if source, image.Size, err = u.download(image, offset); err != nil {
+			return err
+		}
+		defer source.Close()
+	} else if _, err = io.CopyN(io.Discard, source, offset); err != nil {
+		return err
+	}
+
+	u.setStatus(func(s *Status) {
+		s.Received = offset
+		s.Size = image.Size
+	})
+
+	chunk := make([]byte, CHUNK_SIZE)
+
+	for {
+		n, rerr := io.ReadFull(source, chunk)
+		if n > 0 {
+			if err := u.writeChunk(image.SHA256, &offset, chunk[:n]); err != nil {
+				return err
+			}
+			ph.Update(u.setStatus(func(s *Status) { s.Received = offset }))
+		}
+
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+
+	if image.Size > 0 && offset != image.Size {
+		return errors.New(str.Concat("Image truncated at ", strconv.FormatInt(offset, 10), " bytes"))
+	}
+
+	return nil
+}
+
+// writeChunk writes chunk at offset, after failure device offset is queried again so already stored
+// part of chunk is not written twice
+func (u *Updater) writeChunk(imageID string, offset *int64, chunk []byte) error {
+	start := *offset
+	var err error
+
+	for attempt := 0; attempt <= CHUNK_RETRIES; attempt++ {
+		if attempt > 0 {
+			stored, oerr := u.dev.Offset(imageID)
+			if oerr != nil || stored < start || stored > start+int64(len(chunk)) {
+				continue
+			}
+			*offset = stored
+		}
+
+		if err = u.dev.WriteChunk(imageID, *offset, chunk[*offset-start:]); err == nil {
+			*offset = start + int64(len(chunk))
+			return nil
+		}
+	}
+
+	return err
+}
+
+// download requests image from offset, size of the whole image is returned
+func (u *Updater) download(image *Image, offset int64) (io.ReadCloser, int64, error) {
+	rq, err := http.NewRequest("GET", image.URL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		rq.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	rs, err := u.client.Do(rq)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch rs.StatusCode {
+	case http.StatusPartialContent:
+		return rs.Body, rangeSize(rs.Header.Get("Content-Range"), image.Size), nil
+	case http.StatusOK:
+		//server ignored range, already stored bytes are skipped
+		if _, err = io.CopyN(io.Discard, rs.Body, offset); err != nil {
+			rs.Body.Close()
+			return nil, 0, err
+		}
+		size := image.Size
+		if rs.ContentLength > 0 {
+			size = rs.ContentLength
+		}
+		return rs.Body, size, nil
+	}
+
+	rs.Body.Close()
+	return nil, 0, errors.New(str.Concat("Image download failed: ", rs.Status))
+}
+
+// rangeSize reads complete length from Content-Range "bytes 100-199/200"
+func rangeSize(contentRange string, size int64) int64 {
+	if i := strings.LastIndexByte(contentRange, '/'); i >= 0 {
+		if n, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+			return n
+		}
+	}
+
+	return size
+}
+
+func hasEvent(td *model.ThingDescription, name string) bool {
+	for _, e := range td.Events {
+		if e.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package ota
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// memoryDevice stores image in memory, writes fail once offset reaches failAt
+type memoryDevice struct {
+	l       sync.Mutex
+	image   []byte
+	written int
+	failAt  int
+	applied bool
+}
+
+func (d *memoryDevice) Offset(imageID string) (int64, error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	return int64(len(d.image)), nil
+}
+
+func (d *memoryDevice) WriteChunk(imageID string, offset int64, chunk []byte) error {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.failAt > 0 && int(offset)+len(chunk) > d.failAt {
+		return errors.New("link lost")
+	}
+
+	d.image = append(d.image[:offset], chunk...)
+	d.written += len(chunk)
+	return nil
+}
+
+func (d *memoryDevice) Verify(imageID string, digest []byte) error {
+	sum := sha256.Sum256(d.image)
+	if !bytes.Equal(sum[:], digest) {
+		return errors.New("digest mismatch")
+	}
+	return nil
+}
+
+func (d *memoryDevice) Apply(imageID string) error {
+	d.applied = true
+	return nil
+}
+
+func (d *memoryDevice) Reboot() error {
+	return nil
+}
+
+func firmware() ([]byte, string) {
+	image := bytes.Repeat([]byte("firmware"), CHUNK_SIZE/2)
+	sum := sha256.Sum256(image)
+	return image, hex.EncodeToString(sum[:])
+}
+
+func thing() *server.WotServer {
+	return server.CreateFromDescription(&model.ThingDescription{Name: "sensor"})
+}
+
+func invoke(s *server.WotServer, arg interface{}) *server.TaskStatus {
+	slot := &atomic.Value{}
+	s.InvokeAction(ACTION_UPDATE, arg, server.NewWotProgressHandler(ACTION_UPDATE, slot, async.NewFanOut())).Get()
+	return slot.Load().(*server.TaskStatus)
+}
+
+func TestCaseUploadResumes(t *testing.T) {
+	image, digest := firmware()
+	dev := &memoryDevice{failAt: CHUNK_SIZE * 2}
+	s := thing()
+	u := Enable(s, dev)
+
+	upload := func() *server.TaskStatus {
+		return invoke(s, &server.Stream{
+			Reader: bytes.NewReader(image),
+			Params: map[string]string{"sha256": digest, "version": "2.0"},
+		})
+	}
+
+	Equals("interrupted", t, server.TASK_FAILED, upload().Status)
+	Equals("failed state", t, STATE_FAILED, u.Status().State)
+	Equals("stored before failure", t, CHUNK_SIZE*2, len(dev.image))
+
+	dev.failAt = 0
+	Equals("resumed", t, server.TASK_DONE, upload().Status)
+	Equals("idle", t, STATE_IDLE, u.Status().State)
+	Equals("version", t, "2.0", u.Status().Version)
+	Equals("applied", t, true, dev.applied)
+	Equals("nothing written twice", t, len(image), dev.written)
+}
+
+func TestCaseDownloadResumes(t *testing.T) {
+	image, digest := firmware()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "fw.bin", time.Time{}, bytes.NewReader(image))
+	}))
+	defer ts.Close()
+
+	dev := &memoryDevice{image: append([]byte(nil), image[:CHUNK_SIZE+10]...)}
+	s := thing()
+	u := Enable(s, dev)
+
+	status := invoke(s, map[string]interface{}{"url": ts.URL, "sha256": digest, "version": "3.1"})
+	Equals("done", t, server.TASK_DONE, status.Status)
+	Equals("received", t, int64(len(image)), u.Status().Received)
+	Equals("size", t, int64(len(image)), u.Status().Size)
+	Equals("only missing part written", t, len(image)-CHUNK_SIZE-10, dev.written)
+
+	dev.image[0] ^= 0xff
+	dev.image = dev.image[:10]
+	status = invoke(s, map[string]interface{}{"url": ts.URL, "sha256": digest})
+	Equals("corrupted", t, server.TASK_FAILED, status.Status)
+	Equals("verify failed", t, "digest mismatch", u.Status().Error)
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
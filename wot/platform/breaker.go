@@ -0,0 +1,51 @@
+package platform
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/backend"
+)
+
+// BreakerEvent is published to breaker subscribers whenever circuit of device bound at CtxPath changes
+// state, State is one of backend.BREAKER_*
+type BreakerEvent struct {
+	Backend string    `json:"backend"`
+	CtxPath string    `json:"ctxPath"`
+	State   string    `json:"state"`
+	Time    time.Time `json:"time"`
+}
+
+// SubscribeBreakers registers channel receiving *BreakerEvent values of all backends, returns subscription ID.
+// Subscriber not reading its events falls behind and is removed, consumers have to stop waiting
+// for events once BreakersRemoved is closed.
+func (p *Platform) SubscribeBreakers(out chan<- interface{}) int {
+	return p.breakers.AddSubscriber(out)
+}
+
+// BreakersRemoved returns channel closed once breaker subscriber is removed
+func (p *Platform) BreakersRemoved(id int) <-chan struct{} {
+	return p.breakers.Removed(id)
+}
+
+func (p *Platform) UnsubscribeBreakers(id int) {
+	p.breakers.RemoveSubscriber(id)
+}
+
+// watchBreaker publishes state changes of circuits of backend guarding requests by circuit breaker
+func (p *Platform) watchBreaker(beID string, be backend.Backend) {
+	bb, ok := be.(backend.BreakerBackend)
+	if !ok {
+		return
+	}
+
+	bb.OnBreakerChange(func(ctxPath, state string) {
+		log.Warn("Platform: circuit of ", ctxPath, " at backend ", beID, " is ", state)
+		p.breakers.Publish(&BreakerEvent{
+			Backend: beID,
+			CtxPath: ctxPath,
+			State:   state,
+			Time:    time.Now(),
+		})
+	})
+}
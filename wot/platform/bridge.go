@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"errors"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+// bridge is remote Thing consumed over protocols of its description and exposed on platform frontends
+type bridge struct {
+	wotDescURI string
+	ctxPath    string
+	feIDs      []string
+	federated  *proxy.Federated
+}
+
+// AddBridge exposes Thing described by wotDescURI on frontends feIDs under ctxPath, e.g. CoAP or MQTT
+// only device over HTTP. Thing is consumed over protocols of its description forms tried in order
+// of protocols, default order when empty. Description served by frontends has forms rewritten
+// to frontend URIs. cfg is passed to protocol clients (see proxy.Consume).
+func (p *Platform) AddBridge(id, wotDescURI, ctxPath string, protocols, feIDs []string, opts *proxy.FederationOptions, cfg map[string]interface{}) error {
+	p.l.RLock()
+	_, exists := p.wots[id]
+	p.l.RUnlock()
+
+	if exists {
+		return errors.New(str.Concat("Thing already exists: ", id))
+	}
+
+	td, err := model.Load(wotDescURI)
+	if err != nil {
+		return err
+	}
+
+	client := proxy.NewCompositeClient(td, cfg)
+	if len(protocols) > 0 {
+		client.WithSelector(proxy.PreferProtocols(protocols...))
+	}
+
+	federated, err := proxy.Federate(client, opts)
+	if err != nil {
+		return err
+	}
+
+	b := &bridge{
+		wotDescURI: wotDescURI,
+		ctxPath:    ctxPath,
+		feIDs:      feIDs,
+		federated:  federated,
+	}
+
+	p.l.Lock()
+	if _, exists = p.wots[id]; exists {
+		p.l.Unlock()
+		federated.Close()
+		return errors.New(str.Concat("Thing already exists: ", id))
+	}
+	p.wots[id] = federated.Server
+	p.bridges[id] = b
+	p.l.Unlock()
+
+	for _, feID := range feIDs {
+		p.frontends[feID].Bind(ctxPath, federated.Server)
+	}
+
+	p.publishLifecycle(server.THING_CREATED, id, ctxPath, federated.Server)
+	return nil
+}
+
+// RemoveBridge unbinds bridged Thing from frontends supporting it and closes its event subscriptions
+func (p *Platform) RemoveBridge(id string) error {
+	p.l.Lock()
+	b, ok := p.bridges[id]
+	if ok {
+		delete(p.bridges, id)
+		delete(p.wots, id)
+	}
+	p.l.Unlock()
+
+	if !ok {
+		return ErrUnknownThing
+	}
+
+	for _, feID := range b.feIDs {
+		unbind(p.frontends[feID], b.ctxPath)
+	}
+
+	p.publishLifecycle(server.THING_REMOVED, id, b.ctxPath, nil)
+	return b.federated.Close()
+}
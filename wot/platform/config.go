@@ -0,0 +1,293 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/col"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/frontend"
+	"github.com/conas/tno2/wot/proxy"
+)
+
+// Config describes platform deployment: frontends (protocol bindings) with their ports,
+// backends with their options and Things binding ThingDescriptions to them.
+// Config is JSON or TOML (*.toml) document, string parameters may reference environment variables as ${NAME}.
+type Config struct {
+	Hostname  string            `json:"hostname"`
+	Frontends []ComponentConfig `json:"frontends"`
+	Backends  []ComponentConfig `json:"backends"`
+	Things    []ThingConfig     `json:"things"`
+	Templates []TemplateConfig  `json:"templates"`
+	Bridges   []BridgeConfig    `json:"bridges"`
+	// Watch (e.g. "2s") enables hot reload of ThingDescription files notified by file system, files are
+	// polled by this interval where notifications are not available, empty disables hot reload
+	Watch string `json:"watch"`
+}
+
+type ComponentConfig struct {
+	ID     string                 `json:"id"`
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params"`
+}
+
+type ThingConfig struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	CtxPath     string   `json:"ctxPath"`
+	Encoder     string   `json:"encoder"`
+	Backend     string   `json:"backend"`
+	Frontends   []string `json:"frontends"`
+}
+
+// TemplateConfig declares Thing Model and Things instantiated from it at start, Description is template URI
+// and CtxPath may contain placeholders
+type TemplateConfig struct {
+	ThingConfig
+	Instances []frontend.TemplateInstance `json:"instances"`
+}
+
+// BridgeConfig exposes remote Thing on frontends, Description is URI of its ThingDescription with absolute
+// forms. Protocols orders protocols the Thing is consumed over, Params configure protocol clients.
+// CacheTTL (e.g. "5s") caches property values, Expose and ReadOnly restrict exposed interactions.
+type BridgeConfig struct {
+	ID          string                 `json:"id"`
+	Description string                 `json:"description"`
+	CtxPath     string                 `json:"ctxPath"`
+	Protocols   []string               `json:"protocols"`
+	Frontends   []string               `json:"frontends"`
+	Params      map[string]interface{} `json:"params"`
+	CacheTTL    string                 `json:"cacheTTL"`
+	Expose      []string               `json:"expose"`
+	ReadOnly    bool                   `json:"readOnly"`
+}
+
+// Options returns federation options of bridge
+func (b *BridgeConfig) Options() (*proxy.FederationOptions, error) {
+	opts := &proxy.FederationOptions{
+		Expose:   b.Expose,
+		ReadOnly: b.ReadOnly,
+	}
+
+	if b.CacheTTL != "" {
+		ttl, err := time.ParseDuration(b.CacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		opts.CacheTTL = ttl
+	}
+
+	return opts, nil
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err = decodeConfig(path, data, cfg); err != nil {
+		return nil, errors.New(str.Concat("Invalid config ", path, ": ", err.Error()))
+	}
+
+	dir, _ := filepath.Abs(filepath.Dir(path))
+	for i := range cfg.Things {
+		cfg.Things[i].Description = resolveDescriptionURI(dir, cfg.Things[i].Description)
+	}
+	for i := range cfg.Templates {
+		cfg.Templates[i].Description = resolveDescriptionURI(dir, cfg.Templates[i].Description)
+	}
+	for i := range cfg.Bridges {
+		cfg.Bridges[i].Description = resolveDescriptionURI(dir, cfg.Bridges[i].Description)
+	}
+
+	return cfg, cfg.validate()
+}
+
+// decodeConfig decodes TOML config as JSON document of the same tables, so both formats share the json
+// field names and parameter values are normalized the same way
+func decodeConfig(path string, data []byte, cfg *Config) error {
+	if !strings.EqualFold(filepath.Ext(path), ".toml") {
+		return json.Unmarshal(data, cfg)
+	}
+
+	doc := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, cfg)
+}
+
+// WatchInterval returns parsed Watch interval, zero when hot reload is disabled
+func (cfg *Config) WatchInterval() (time.Duration, error) {
+	if cfg.Watch == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(cfg.Watch)
+}
+
+func (cfg *Config) validate() error {
+	ids := make(map[string]string)
+
+	if _, err := cfg.WatchInterval(); err != nil {
+		return errors.New(str.Concat("Invalid watch interval: ", err.Error()))
+	}
+
+	for _, fe := range cfg.Frontends {
+		if _, ok := feTypes[fe.Type]; !ok {
+			return errors.New(str.Concat("Unknown frontend type: ", fe.Type))
+		}
+		ids[fe.ID] = "frontend"
+	}
+
+	for _, be := range cfg.Backends {
+		if _, ok := beTypes[be.Type]; !ok {
+			return errors.New(str.Concat("Unknown backend type: ", be.Type))
+		}
+		ids[be.ID] = "backend"
+	}
+
+	things := append([]ThingConfig(nil), cfg.Things...)
+	for _, tmpl := range cfg.Templates {
+		things = append(things, tmpl.ThingConfig)
+	}
+
+	for _, t := range things {
+		if ids[t.Backend] != "backend" {
+			return errors.New(str.Concat("Thing ", t.ID, " references unknown backend: ", t.Backend))
+		}
+		for _, fe := range t.Frontends {
+			if ids[fe] != "frontend" {
+				return errors.New(str.Concat("Thing ", t.ID, " references unknown frontend: ", fe))
+			}
+		}
+	}
+
+	for _, b := range cfg.Bridges {
+		if _, err := b.Options(); err != nil {
+			return errors.New(str.Concat("Bridge ", b.ID, " has invalid cacheTTL: ", err.Error()))
+		}
+		for _, fe := range b.Frontends {
+			if ids[fe] != "frontend" {
+				return errors.New(str.Concat("Bridge ", b.ID, " references unknown frontend: ", fe))
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewPlatformFromConfig creates platform with all components and Things declared in config
+func NewPlatformFromConfig(cfg *Config) *Platform {
+	p := NewPlatform(cfg.Hostname)
+
+	for _, fe := range cfg.Frontends {
+		p.AddFrontend(fe.ID, fe.Type, params(fe.Params)...)
+	}
+
+	for _, be := range cfg.Backends {
+		p.AddBackend(be.ID, be.Type, params(be.Params)...)
+	}
+
+	for _, t := range cfg.Things {
+		p.AddWotServer(t.ID, t.Description, t.CtxPath, t.Encoder, t.Backend, t.Frontends)
+	}
+
+	for _, tmpl := range cfg.Templates {
+		if err := p.AddTemplate(tmpl.ID, tmpl.Description, tmpl.CtxPath, tmpl.Encoder, tmpl.Backend, tmpl.Frontends); err != nil {
+			log.Error("Platform: template ", tmpl.ID, " not added -> ", err)
+			continue
+		}
+
+		for _, i := range tmpl.Instances {
+			if _, err := p.Instantiate(tmpl.ID, i.ID, i.Params); err != nil {
+				log.Error("Platform: instance ", i.ID, " of ", tmpl.ID, " not created -> ", err)
+			}
+		}
+	}
+
+	for _, b := range cfg.Bridges {
+		opts, _ := b.Options()
+		if err := p.AddBridge(b.ID, b.Description, b.CtxPath, b.Protocols, b.Frontends, opts, normalize(b.Params).(map[string]interface{})); err != nil {
+			log.Error("Platform: bridge ", b.ID, " not added -> ", err)
+		}
+	}
+
+	return p
+}
+
+func params(m map[string]interface{}) []*col.KeyValue {
+	kvs := make([]*col.KeyValue, 0, len(m))
+
+	for k, v := range m {
+		kvs = append(kvs, col.KV(k, normalize(v)))
+	}
+
+	return kvs
+}
+
+// envReference is reference to environment variable in string parameter, other $ are literal,
+// e.g. in passwords
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references by values of environment variables
+func expandEnv(s string) string {
+	return envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		return os.Getenv(ref[2 : len(ref)-1])
+	})
+}
+
+// normalize converts JSON values to types components expect, whole numbers become int
+// and environment variables referenced as ${NAME} are expanded in strings
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case float64:
+		if t == math.Trunc(t) {
+			return int(t)
+		}
+	case string:
+		return expandEnv(t)
+	case []interface{}:
+		for i := range t {
+			t[i] = normalize(t[i])
+		}
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = normalize(t[k])
+		}
+	}
+
+	return v
+}
+
+// resolveDescriptionURI makes file:// description URIs relative to config file location
+func resolveDescriptionURI(dir, uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+
+	path := uri[len("file://"):]
+	if filepath.IsAbs(path) {
+		return uri
+	}
+
+	return str.Concat("file://", filepath.Join(dir, path))
+}
@@ -0,0 +1,50 @@
+package platform
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCaseTOMLConfig(t *testing.T) {
+	fromJSON, err := LoadConfig("../../examples/tno2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromTOML, err := LoadConfig("../../examples/tno2.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromTOML) {
+		t.Errorf("TOML config differs from JSON config:\n%+v\n%+v", fromTOML, fromJSON)
+	}
+
+	if err := decodeConfig("invalid.toml", []byte("[frontends\nid = 1"), &Config{}); err == nil {
+		t.Error("Invalid TOML config accepted")
+	}
+}
+
+func TestCaseConfigEnv(t *testing.T) {
+	os.Setenv("TNO2_BROKER", "tcp://broker:1883")
+	os.Setenv("PASS", "expanded")
+	defer os.Unsetenv("TNO2_BROKER")
+	defer os.Unsetenv("PASS")
+
+	cases := map[string]string{
+		"${TNO2_BROKER}":       "tcp://broker:1883",
+		"url=${TNO2_BROKER}/x": "url=tcp://broker:1883/x",
+		"pa$$word":             "pa$$word",
+		"$PASS":                "$PASS",
+		"p@$PASS$":             "p@$PASS$",
+		"${TNO2_UNSET}":        "",
+		"${not valid}":         "${not valid}",
+	}
+
+	for value, expected := range cases {
+		if actual := normalize(value); actual != expected {
+			t.Errorf("%s expanded to %v, expected %s", value, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package platform
+
+import (
+	"github.com/conas/tno2/wot/frontend"
+	"github.com/conas/tno2/wot/server"
+)
+
+// LifecycleEvent of type server.THING_* is published to lifecycle subscribers whenever Thing is bound, rebound or removed
+type LifecycleEvent struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	CtxPath string `json:"ctxPath"`
+	Name    string `json:"name"`
+	// WotServer currently serving the Thing, nil when Thing was removed. Callbacks registered
+	// on previous WotServer are not carried over on update and has to be registered again.
+	WotServer *server.WotServer `json:"-"`
+}
+
+// SubscribeLifecycle registers channel receiving *LifecycleEvent values, returns subscription ID.
+// Subscriber not reading its events falls behind and is removed, consumers have to stop waiting
+// for events once LifecycleRemoved is closed.
+func (p *Platform) SubscribeLifecycle(out chan<- interface{}) int {
+	return p.lifecycle.AddSubscriber(out)
+}
+
+// LifecycleRemoved returns channel closed once lifecycle subscriber is removed
+func (p *Platform) LifecycleRemoved(id int) <-chan struct{} {
+	return p.lifecycle.Removed(id)
+}
+
+func (p *Platform) UnsubscribeLifecycle(id int) {
+	p.lifecycle.RemoveSubscriber(id)
+}
+
+func (p *Platform) publishLifecycle(eventType, id, ctxPath string, s *server.WotServer) {
+	e := &LifecycleEvent{
+		Type:      eventType,
+		ID:        id,
+		CtxPath:   ctxPath,
+		WotServer: s,
+	}
+
+	if s != nil {
+		e.Name = s.Name()
+	}
+
+	p.lifecycle.Publish(e)
+}
+
+// unbind removes Thing from frontend if frontend supports removal
+func unbind(fe frontend.Frontend, ctxPath string) {
+	if u, ok := fe.(frontend.Unbinder); ok {
+		u.Unbind(ctxPath)
+	}
+}
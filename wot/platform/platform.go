@@ -2,8 +2,13 @@ package platform
 
 import (
 	"sync"
+	"time"
 
+	"github.com/conas/tno2/util/async"
 	"github.com/conas/tno2/util/col"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/audit"
+	"github.com/conas/tno2/wot/auth"
 	"github.com/conas/tno2/wot/backend"
 	"github.com/conas/tno2/wot/frontend"
 	"github.com/conas/tno2/wot/server"
@@ -17,11 +22,36 @@ type Platform struct {
 	frontends map[string]frontend.Frontend
 	backends  map[string]backend.Backend
 	wots      map[string]*server.WotServer
+	things    map[string]*thing
+	templates map[string]*template
+	bridges   map[string]*bridge
+	l         *sync.RWMutex
+	lifecycle *async.FanOut
+	breakers  *async.FanOut
+	// bulkheads cap interactions in flight of every Thing of backend, keyed by backend ID
+	bulkheads map[string]int
+}
+
+// thing keeps binding of WotServer so it can be rebound when its description changes
+type thing struct {
+	wotDescURI string
+	ctxPath    string
+	beEncID    string
+	beID       string
+	feIDs      []string
+	modTime    time.Time
+	// params of template instance, nil when Thing is not instantiated from template
+	params map[string]interface{}
 }
 
 func init() {
 	RegisterFrontendType("HTTP", frontend.NewHTTP)
+	RegisterFrontendType("NATS", frontend.NewNATS)
+	RegisterFrontendType("AMQP", frontend.NewAMQP)
+	RegisterFrontendType("XMPP", frontend.NewXMPP)
 	RegisterBackendType("MQTT-1", backend.NewMQTT_1)
+	RegisterBackendType("NATS", backend.NewNATS)
+	RegisterBackendType("XMPP", backend.NewXMPP)
 }
 
 func NewPlatform(hostname string) *Platform {
@@ -30,6 +60,13 @@ func NewPlatform(hostname string) *Platform {
 		frontends: make(map[string]frontend.Frontend),
 		backends:  make(map[string]backend.Backend),
 		wots:      make(map[string]*server.WotServer),
+		things:    make(map[string]*thing),
+		templates: make(map[string]*template),
+		bridges:   make(map[string]*bridge),
+		l:         &sync.RWMutex{},
+		lifecycle: async.NewFanOut(),
+		breakers:  async.NewFanOut(),
+		bulkheads: make(map[string]int),
 	}
 }
 
@@ -52,32 +89,134 @@ func (p *Platform) AddFrontend(feID, feType string, cfgParams ...*col.KeyValue)
 	p.frontends[feID] = fe
 }
 
+// AddBackend creates backend of type, "bulkhead" param caps interactions in flight of each Thing bound
+// to the backend, see server.Bulkhead
 func (p *Platform) AddBackend(bedID, beType string, cfgParams ...*col.KeyValue) {
 	params := col.AsMap(cfgParams)
 
 	be := beTypes[beType](params)
 	p.backends[bedID] = be
+	p.watchBreaker(bedID, be)
+
+	if max, ok := params["bulkhead"].(int); ok {
+		p.bulkheads[bedID] = max
+	}
 }
 
 func (p *Platform) AddWotServer(id, wotDescURI, ctxPath, beEncID, beID string, feIDs []string) {
 	wotServer := server.CreateFromDescriptionUri(wotDescURI)
+	t := &thing{
+		wotDescURI: wotDescURI,
+		ctxPath:    ctxPath,
+		beEncID:    beEncID,
+		beID:       beID,
+		feIDs:      feIDs,
+		modTime:    descModTime(wotDescURI),
+	}
+
+	p.l.Lock()
 	p.wots[id] = wotServer
-	be, _ := p.backends[beID]
-	encoder, error := backend.Encoders.Get(beEncID)
+	p.things[id] = t
+	p.l.Unlock()
+
+	p.bind(t, wotServer)
+	p.publishLifecycle(server.THING_CREATED, id, t.ctxPath, wotServer)
+}
+
+// bind binds WotServer to Thing backend and frontends, binding already bound Thing replaces it
+func (p *Platform) bind(t *thing, wotServer *server.WotServer) {
+	be, _ := p.backends[t.beID]
+	encoder, error := backend.Encoders.Get(t.beEncID)
 
 	if error != nil {
 		panic(error)
 	}
 
-	be.Bind(wotServer, ctxPath, encoder)
+	be.Bind(wotServer, t.ctxPath, encoder)
+	wotServer.Bulkhead(p.bulkheads[t.beID])
+	wotServer.PollProperties()
 
-	for _, feId := range feIDs {
+	for _, feId := range t.feIDs {
 		frontend, _ := p.frontends[feId]
-		frontend.Bind(ctxPath, wotServer)
+		frontend.Bind(t.ctxPath, wotServer)
 	}
 }
 
+// RemoveWotServer unbinds Thing from frontends supporting it and stops tracking its description
+func (p *Platform) RemoveWotServer(id string) error {
+	p.l.Lock()
+	t, ok := p.things[id]
+	wotServer := p.wots[id]
+	delete(p.things, id)
+	delete(p.wots, id)
+	p.l.Unlock()
+
+	if !ok {
+		return ErrUnknownThing
+	}
+
+	for _, feId := range t.feIDs {
+		unbind(p.frontends[feId], t.ctxPath)
+	}
+	wotServer.StopPolling()
+
+	p.publishLifecycle(server.THING_REMOVED, id, t.ctxPath, nil)
+	return nil
+}
+
+// EnableAdmin exposes management API on the frontend, including connection states of platform backends
+func (p *Platform) EnableAdmin(feID string, guard *auth.Guard) {
+	fe, ok := p.frontends[feID].(frontend.AdminFrontend)
+
+	if !ok {
+		panic(str.Concat("Frontend does not support admin API: ", feID))
+	}
+
+	fe.EnableAdmin(guard, p.backendStates)
+
+	if tf, ok := fe.(frontend.TemplateFrontend); ok {
+		tf.EnableTemplates(p)
+	}
+}
+
+// EnableAudit records state changing interactions on all frontends supporting audit
+func (p *Platform) EnableAudit(l *audit.Log) {
+	for _, fe := range p.frontends {
+		if af, ok := fe.(frontend.AuditFrontend); ok {
+			af.EnableAudit(l)
+		}
+	}
+}
+
+// UseHTTP appends middlewares to HTTP binding of frontend
+func (p *Platform) UseHTTP(feID string, mw ...frontend.Middleware) {
+	fe, ok := p.frontends[feID].(frontend.MiddlewareFrontend)
+
+	if !ok {
+		panic(str.Concat("Frontend does not support HTTP middlewares: ", feID))
+	}
+
+	fe.UseHTTP(mw...)
+}
+
+func (p *Platform) backendStates() map[string]string {
+	states := make(map[string]string)
+
+	for id, be := range p.backends {
+		if sr, ok := be.(backend.StateReporter); ok {
+			states[id] = sr.State()
+		} else {
+			states[id] = backend.BE_STATE_UNKNOWN
+		}
+	}
+
+	return states
+}
+
 func (p *Platform) WotServer(id string) *server.WotServer {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
 	return p.wots[id]
 }
 
@@ -0,0 +1,395 @@
+package platform
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/auth"
+	"github.com/conas/tno2/wot/backend"
+	"github.com/conas/tno2/wot/frontend"
+	"github.com/conas/tno2/wot/model"
+)
+
+const (
+	PROVISIONING_REGISTRY = "provisioning.json"
+	CERT_VALIDITY         = 365 * 24 * time.Hour
+)
+
+var ErrInvalidCSR = errors.New("Invalid certificate signing request")
+
+// device id names its description file, so it is restricted to safe characters
+var deviceID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// ProvisioningConfig configures onboarding of devices. Approved devices are bound as Things with
+// context path CtxPath (e.g. /devices/{{id}}) to backend and frontends, their descriptions and
+// registry of requests are stored in Dir. Devices get API key with Roles, and certificate signed
+// by CA when they sent CSR and CA is configured.
+type ProvisioningConfig struct {
+	Dir             string
+	BootstrapTokens []string
+	CtxPath         string
+	BeEncID         string
+	BeID            string
+	FeIDs           []string
+	Keys            *auth.APIKeys
+	Roles           []string
+	CACert          *x509.Certificate
+	CAKey           crypto.Signer
+	// CertValidity of issued certificates, CERT_VALIDITY when zero
+	CertValidity time.Duration
+}
+
+// provisioner keeps queue of provisioning requests, bootstrap token of request is kept so only
+// device which sent request can see its identity
+type provisioner struct {
+	p        *Platform
+	cfg      *ProvisioningConfig
+	l        *sync.Mutex
+	requests map[string]*provisioned
+}
+
+type provisioned struct {
+	*frontend.ProvisioningRequest
+	Bootstrap string `json:"bootstrap"`
+}
+
+// EnableProvisioning exposes provisioning API on admin frontend feID. Devices approved before
+// are bound again and their API keys restored.
+func (p *Platform) EnableProvisioning(feID string, cfg *ProvisioningConfig) error {
+	fe, ok := p.frontends[feID].(frontend.ProvisioningFrontend)
+
+	if !ok {
+		return errors.New(str.Concat("Frontend does not support provisioning: ", feID))
+	}
+
+	if _, err := backend.Encoders.Get(cfg.BeEncID); err != nil {
+		return err
+	}
+
+	if _, ok := p.backends[cfg.BeID]; !ok {
+		return errors.New(str.Concat("Provisioning references unknown backend: ", cfg.BeID))
+	}
+
+	for _, id := range cfg.FeIDs {
+		if _, ok := p.frontends[id]; !ok {
+			return errors.New(str.Concat("Provisioning references unknown frontend: ", id))
+		}
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return err
+	}
+
+	pr := &provisioner{
+		p:        p,
+		cfg:      cfg,
+		l:        &sync.Mutex{},
+		requests: make(map[string]*provisioned),
+	}
+
+	if err := pr.load(); err != nil {
+		return err
+	}
+
+	fe.EnableProvisioning(pr)
+	return nil
+}
+
+func (pr *provisioner) Request(bootstrap string, rq *frontend.ProvisioningRequest) (*frontend.ProvisioningRequest, error) {
+	if !pr.bootstrap(bootstrap) {
+		return nil, frontend.ErrBootstrapToken
+	}
+
+	if !deviceID.MatchString(rq.Device) || rq.Description == nil {
+		return nil, errors.New("Provisioning request requires device id and description")
+	}
+
+	if err := rq.Description.Validate(); err != nil {
+		return nil, err
+	}
+
+	if rq.CSR != "" {
+		if _, err := parseCSR(rq.CSR); err != nil {
+			return nil, err
+		}
+	}
+
+	pr.l.Lock()
+	defer pr.l.Unlock()
+
+	for _, other := range pr.requests {
+		if other.Device == rq.Device && other.State != frontend.PROVISIONING_REJECTED {
+			return nil, errors.New(str.Concat("Device already provisioned: ", rq.Device))
+		}
+	}
+
+	if pr.p.WotServer(rq.Device) != nil {
+		return nil, errors.New(str.Concat("Thing already exists: ", rq.Device))
+	}
+
+	created := &frontend.ProvisioningRequest{
+		ID:          randomHex(16),
+		Device:      rq.Device,
+		Description: rq.Description,
+		CSR:         rq.CSR,
+		State:       frontend.PROVISIONING_PENDING,
+		Created:     time.Now().UTC(),
+	}
+
+	pr.requests[created.ID] = &provisioned{created, bootstrap}
+	log.Info("Platform: provisioning of ", created.Device, " requested -> ", created.ID)
+
+	return created, pr.save()
+}
+
+func (pr *provisioner) Status(bootstrap, requestID string) (*frontend.ProvisioningRequest, error) {
+	pr.l.Lock()
+	defer pr.l.Unlock()
+
+	rq, ok := pr.requests[requestID]
+	if !ok || subtle.ConstantTimeCompare([]byte(rq.Bootstrap), []byte(bootstrap)) != 1 {
+		return nil, frontend.ErrUnknownRequest
+	}
+
+	return rq.ProvisioningRequest, nil
+}
+
+// Requests lists requests in state, all requests when state is empty, oldest first
+func (pr *provisioner) Requests(state string) []*frontend.ProvisioningRequest {
+	pr.l.Lock()
+	defer pr.l.Unlock()
+
+	rqs := make([]*frontend.ProvisioningRequest, 0, len(pr.requests))
+	for _, rq := range pr.requests {
+		if state == "" || rq.State == state {
+			rqs = append(rqs, rq.ProvisioningRequest)
+		}
+	}
+
+	sort.Slice(rqs, func(i, j int) bool { return rqs[i].Created.Before(rqs[j].Created) })
+	return rqs
+}
+
+// Approve issues identity to device, stores its description and binds it as Thing
+func (pr *provisioner) Approve(requestID string) (*frontend.ProvisioningRequest, error) {
+	pr.l.Lock()
+	defer pr.l.Unlock()
+
+	rq, err := pr.pending(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &frontend.Identity{
+		APIKey:  randomHex(32),
+		CtxPath: pr.ctxPath(rq.Device),
+	}
+
+	if rq.CSR != "" && pr.cfg.CACert != nil {
+		if identity.Certificate, err = pr.issue(rq.Device, rq.CSR); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(rq.Description, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ioutil.WriteFile(pr.descPath(rq.Device), data, 0600); err != nil {
+		return nil, err
+	}
+
+	rq.State = frontend.PROVISIONING_APPROVED
+	rq.Identity = identity
+
+	if err = pr.save(); err != nil {
+		return nil, err
+	}
+
+	pr.bind(rq.ProvisioningRequest)
+	log.Info("Platform: device ", rq.Device, " provisioned -> ", identity.CtxPath)
+
+	return rq.ProvisioningRequest, nil
+}
+
+func (pr *provisioner) Reject(requestID, reason string) (*frontend.ProvisioningRequest, error) {
+	pr.l.Lock()
+	defer pr.l.Unlock()
+
+	rq, err := pr.pending(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	rq.State = frontend.PROVISIONING_REJECTED
+	rq.Reason = reason
+	log.Info("Platform: provisioning of ", rq.Device, " rejected -> ", reason)
+
+	return rq.ProvisioningRequest, pr.save()
+}
+
+func (pr *provisioner) pending(requestID string) (*provisioned, error) {
+	rq, ok := pr.requests[requestID]
+
+	if !ok {
+		return nil, frontend.ErrUnknownRequest
+	}
+
+	if rq.State != frontend.PROVISIONING_PENDING {
+		return nil, frontend.ErrRequestNotReady
+	}
+
+	return rq, nil
+}
+
+// bind restores API key of approved device and binds its Thing
+func (pr *provisioner) bind(rq *frontend.ProvisioningRequest) {
+	if pr.cfg.Keys != nil {
+		pr.cfg.Keys.Add(rq.Identity.APIKey, rq.Device, pr.cfg.Roles...)
+	}
+
+	pr.p.AddWotServer(rq.Device, str.Concat("file://", pr.descPath(rq.Device)), rq.Identity.CtxPath, pr.cfg.BeEncID, pr.cfg.BeID, pr.cfg.FeIDs)
+}
+
+func (pr *provisioner) bootstrap(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	for _, t := range pr.cfg.BootstrapTokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// issue signs CSR of device by platform CA, certificate subject is device id
+func (pr *provisioner) issue(device, csrPEM string) (string, error) {
+	csr, err := parseCSR(csrPEM)
+	if err != nil {
+		return "", err
+	}
+
+	validity := pr.cfg.CertValidity
+	if validity == 0 {
+		validity = CERT_VALIDITY
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: device},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, pr.cfg.CACert, csr.PublicKey, pr.cfg.CAKey)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
+
+func (pr *provisioner) ctxPath(device string) string {
+	return model.Substitute(pr.cfg.CtxPath, map[string]interface{}{"id": device})
+}
+
+func (pr *provisioner) descPath(device string) string {
+	return filepath.Join(pr.cfg.Dir, str.Concat(device, ".json"))
+}
+
+// save writes registry of requests, it contains credentials so it is readable by owner only
+func (pr *provisioner) save() error {
+	rqs := make([]*provisioned, 0, len(pr.requests))
+	for _, rq := range pr.requests {
+		rqs = append(rqs, rq)
+	}
+
+	data, err := json.MarshalIndent(rqs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(pr.cfg.Dir, PROVISIONING_REGISTRY)
+	if err = ioutil.WriteFile(str.Concat(path, ".tmp"), data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(str.Concat(path, ".tmp"), path)
+}
+
+func (pr *provisioner) load() error {
+	data, err := ioutil.ReadFile(filepath.Join(pr.cfg.Dir, PROVISIONING_REGISTRY))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var rqs []*provisioned
+	if err = json.Unmarshal(data, &rqs); err != nil {
+		return errors.New(str.Concat("Invalid provisioning registry: ", err.Error()))
+	}
+
+	for _, rq := range rqs {
+		pr.requests[rq.ID] = rq
+
+		if rq.State == frontend.PROVISIONING_APPROVED && rq.Identity != nil {
+			pr.bind(rq.ProvisioningRequest)
+		}
+	}
+
+	return nil
+}
+
+func parseCSR(csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, ErrInvalidCSR
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil || csr.CheckSignature() != nil {
+		return nil, ErrInvalidCSR
+	}
+
+	return csr, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,140 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/server"
+)
+
+var ErrUnknownThing = errors.New("Unknown thing")
+
+// RELOAD_SETTLE delays reload after file system notification, editors save files in several steps
+const RELOAD_SETTLE = 100 * time.Millisecond
+
+// WatchDescriptions watches directories of ThingDescription files of Things bound when watching starts
+// by file system notifications and rebinds Things whose description changed. Where notifications are
+// not available, files are polled by interval. Invalid descriptions are logged and the Thing keeps
+// running with its last valid description. Returned channel stops watching when closed.
+func (p *Platform) WatchDescriptions(interval time.Duration) chan<- struct{} {
+	stop := make(chan struct{})
+
+	changes, err := watchDirs(p.descriptionDirs(), stop)
+	if err != nil {
+		log.Warn("Platform: description changes polled every ", interval, ", notifications not available -> ", err)
+		go p.pollDescriptions(interval, stop)
+		return stop
+	}
+
+	go func() {
+		for range changes {
+			time.Sleep(RELOAD_SETTLE)
+			p.reloadChanged()
+		}
+	}()
+
+	return stop
+}
+
+func (p *Platform) pollDescriptions(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.reloadChanged()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// descriptionDirs lists directories of description files of platform Things
+func (p *Platform) descriptionDirs() []string {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	dirs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, t := range p.things {
+		if !strings.HasPrefix(t.wotDescURI, "file://") {
+			continue
+		}
+
+		dir := filepath.Dir(t.wotDescURI[len("file://"):])
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func (p *Platform) reloadChanged() {
+	p.l.RLock()
+	changed := make(map[string]*thing)
+	for id, t := range p.things {
+		if mt := descModTime(t.wotDescURI); !mt.IsZero() && !mt.Equal(t.modTime) {
+			changed[id] = t
+		}
+	}
+	p.l.RUnlock()
+
+	for id := range changed {
+		p.Reload(id)
+	}
+}
+
+// Reload re-reads description of Thing id and rebinds Thing to its backend and frontends
+func (p *Platform) Reload(id string) error {
+	p.l.RLock()
+	t, ok := p.things[id]
+	p.l.RUnlock()
+
+	if !ok {
+		return ErrUnknownThing
+	}
+
+	modTime := descModTime(t.wotDescURI)
+	td, err := t.load()
+
+	p.l.Lock()
+	t.modTime = modTime
+	p.l.Unlock()
+
+	if err != nil {
+		log.Error("Platform: reload of ", id, " failed -> ", err)
+		return err
+	}
+
+	wotServer := server.CreateFromDescription(td)
+	p.bind(t, wotServer)
+
+	p.l.Lock()
+	p.wots[id] = wotServer
+	p.l.Unlock()
+
+	log.Info("Platform: reloaded thing ", id, " -> ", t.wotDescURI)
+	p.publishLifecycle(server.THING_UPDATED, id, t.ctxPath, wotServer)
+
+	return nil
+}
+
+func descModTime(uri string) time.Time {
+	if !strings.HasPrefix(uri, "file://") {
+		return time.Time{}
+	}
+
+	fi, err := os.Stat(uri[len("file://"):])
+	if err != nil {
+		return time.Time{}
+	}
+
+	return fi.ModTime()
+}
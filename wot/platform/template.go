@@ -0,0 +1,134 @@
+package platform
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/frontend"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+var ErrUnknownTemplate = errors.New("Unknown template")
+
+// template binds Thing Model to backend and frontends of its instances, ctxPath may contain placeholders
+type template struct {
+	uri     string
+	ctxPath string
+	beEncID string
+	beID    string
+	feIDs   []string
+}
+
+// AddTemplate registers Thing Model instantiated by Instantiate. Context path of instances is ctxPath
+// with placeholders substituted, e.g. /sensors/{{id}}.
+func (p *Platform) AddTemplate(id, templateURI, ctxPath, beEncID, beID string, feIDs []string) error {
+	if _, err := model.LoadTemplate(templateURI); err != nil {
+		return err
+	}
+
+	p.l.Lock()
+	p.templates[id] = &template{
+		uri:     templateURI,
+		ctxPath: ctxPath,
+		beEncID: beEncID,
+		beID:    beID,
+		feIDs:   feIDs,
+	}
+	p.l.Unlock()
+
+	return nil
+}
+
+// Instantiate creates Thing id from template, parameter "id" defaults to Thing id
+func (p *Platform) Instantiate(templateID, id string, params map[string]interface{}) (*frontend.ThingInfo, error) {
+	p.l.RLock()
+	tmpl, ok := p.templates[templateID]
+	_, exists := p.things[id]
+	p.l.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	if exists {
+		return nil, errors.New(str.Concat("Thing already exists: ", id))
+	}
+
+	values := map[string]interface{}{"id": id}
+	for k, v := range params {
+		values[k] = v
+	}
+
+	t := &thing{
+		wotDescURI: tmpl.uri,
+		params:     values,
+		ctxPath:    model.Substitute(tmpl.ctxPath, values),
+		beEncID:    tmpl.beEncID,
+		beID:       tmpl.beID,
+		feIDs:      tmpl.feIDs,
+		modTime:    descModTime(tmpl.uri),
+	}
+
+	td, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	wotServer := server.CreateFromDescription(td)
+
+	p.l.Lock()
+	if _, exists = p.things[id]; exists {
+		p.l.Unlock()
+		return nil, errors.New(str.Concat("Thing already exists: ", id))
+	}
+	p.wots[id] = wotServer
+	p.things[id] = t
+	p.l.Unlock()
+
+	p.bind(t, wotServer)
+	p.publishLifecycle(server.THING_CREATED, id, t.ctxPath, wotServer)
+
+	return &frontend.ThingInfo{
+		CtxPath: t.ctxPath,
+		Name:    wotServer.Name(),
+	}, nil
+}
+
+// Templates lists registered templates with their placeholders
+func (p *Platform) Templates() []*frontend.TemplateInfo {
+	p.l.RLock()
+	defer p.l.RUnlock()
+
+	infos := make([]*frontend.TemplateInfo, 0, len(p.templates))
+	for id, tmpl := range p.templates {
+		info := &frontend.TemplateInfo{
+			ID:      id,
+			CtxPath: tmpl.ctxPath,
+		}
+
+		if m, err := model.LoadTemplate(tmpl.uri); err == nil {
+			info.Placeholders = m.Placeholders()
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// load reads description of Thing, instances of templates are instantiated again with their parameters
+func (t *thing) load() (*model.ThingDescription, error) {
+	if t.params == nil {
+		return model.Load(t.wotDescURI)
+	}
+
+	tmpl, err := model.LoadTemplate(t.wotDescURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl.Instantiate(t.params)
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package platform
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchDirs notifies changes of files in dirs by inotify. Files replaced by rename, as editors save
+// them, are notified too. Notifications are closed when stop is closed.
+func watchDirs(dirs []string, stop <-chan struct{}) (<-chan struct{}, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	//non-blocking descriptor is read by runtime poller, so its Read returns when file is closed
+	f := os.NewFile(uintptr(fd), "inotify")
+	for _, dir := range dirs {
+		if _, err = unix.InotifyAddWatch(fd, dir, unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO|unix.IN_CREATE|unix.IN_DELETE); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		<-stop
+		f.Close()
+	}()
+
+	go func() {
+		defer close(changes)
+
+		buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+
+			//changes pending reload are notified once
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes, nil
+}
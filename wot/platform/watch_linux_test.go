@@ -0,0 +1,38 @@
+package platform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaseWatchDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tno2-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stop := make(chan struct{})
+	changes, err := watchDirs([]string{dir}, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//editors save to temporary file renamed over description
+	tmp := filepath.Join(dir, "td.json.tmp")
+	ioutil.WriteFile(tmp, []byte("{}"), 0644)
+	os.Rename(tmp, filepath.Join(dir, "td.json"))
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not notified")
+	}
+
+	close(stop)
+	for range changes {
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package platform
+
+import "errors"
+
+// watchDirs is not supported, descriptions are polled
+func watchDirs(dirs []string, stop <-chan struct{}) (<-chan struct{}, error) {
+	return nil, errors.New("File system notifications not supported")
+}
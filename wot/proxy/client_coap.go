@@ -0,0 +1,333 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+const COAP_DEFAULT_PORT = "5683"
+
+// CoapClient consumes Thing exposed over CoAP, e.g. constrained device. ThingDescription is read
+// from {root}/description, events and properties can be observed. Only plain coap:// is supported,
+// CoAP over DTLS (coaps://) is not.
+type CoapClient struct {
+	base *url.URL
+	conn *coapConn
+	l    *sync.Mutex
+	td   *model.ThingDescription
+}
+
+// NewCoapClient creates client for Thing with root at uri, e.g. coap://sensor.local/thing
+func NewCoapClient(uri string) (*CoapClient, error) {
+	base, err := url.Parse(strings.TrimSuffix(uri, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if base.Scheme != "coap" {
+		return nil, errors.New(str.Concat("Unsupported CoAP scheme: ", base.Scheme))
+	}
+
+	host := base.Host
+	if base.Port() == "" {
+		host = net.JoinHostPort(base.Hostname(), COAP_DEFAULT_PORT)
+	}
+
+	conn, err := dialCoap(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CoapClient{
+		base: base,
+		conn: conn,
+		l:    &sync.Mutex{},
+	}, nil
+}
+
+// Close releases UDP socket, observations are cancelled
+func (c *CoapClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *CoapClient) Name() string {
+	td, err := c.GetDescription()
+
+	if err != nil {
+		return ""
+	}
+
+	return td.Name
+}
+
+func (c *CoapClient) GetDescription() (*model.ThingDescription, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.td != nil {
+		return c.td, nil
+	}
+
+	td := &model.ThingDescription{}
+	if err := c.do(COAP_GET, str.Concat(c.base.Path, "/description"), nil, td); err != nil {
+		return nil, err
+	}
+
+	c.td = td
+	return td, nil
+}
+
+func (c *CoapClient) GetProperty(propertyName string) (interface{}, error) {
+	path, err := c.propertyPath(propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = c.do(COAP_GET, path, nil, &value)
+
+	return value, err
+}
+
+func (c *CoapClient) SetProperty(propertyName string, newValue interface{}) error {
+	path, err := c.propertyPath(propertyName)
+	if err != nil {
+		return err
+	}
+
+	return c.do(COAP_PUT, path, newValue, nil)
+}
+
+// InvokeAction posts arg to action resource. CoAP devices answer with action result, returned
+// task is therefore already finished.
+func (c *CoapClient) InvokeAction(actionName string, arg interface{}) (Task, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := findAction(td, actionName)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	if err = c.do(COAP_POST, c.path(action.Hrefs[0]), arg, &result); err != nil {
+		return nil, err
+	}
+
+	return doneTask(actionName, result), nil
+}
+
+// AddListener observes event resource, every notification is delivered as event
+func (c *CoapClient) AddListener(eventName string, listener func(*server.Event)) (Subscription, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := findEvent(td, eventName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.observe(c.path(event.Hrefs[0]), func(data interface{}) {
+		listener(&server.Event{
+			Event:     eventName,
+			Timestamp: time.Now(),
+			Data:      data,
+		})
+	})
+}
+
+// ObserveProperty registers listener of property value changes, listener is called with current value first
+func (c *CoapClient) ObserveProperty(propertyName string, listener func(interface{})) (Subscription, error) {
+	path, err := c.propertyPath(propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.observe(path, listener)
+}
+
+func (c *CoapClient) observe(path string, listener func(interface{})) (Subscription, error) {
+	token, notifications := c.conn.open()
+
+	rq := c.request(COAP_GET, path)
+	rq.token = token
+	rq.addUint(COAP_OPTION_OBSERVE, 0)
+
+	done := make(chan struct{})
+	if err := c.conn.transmit(rq, done); err != nil {
+		c.conn.close(token)
+		return nil, err
+	}
+
+	o := &coapObservation{
+		client: c,
+		path:   path,
+		token:  token,
+		quit:   done,
+		once:   &sync.Once{},
+	}
+
+	go o.run(notifications, listener)
+
+	return o, nil
+}
+
+func (c *CoapClient) propertyPath(propertyName string) (string, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return "", err
+	}
+
+	prop, err := findProperty(td, propertyName)
+	if err != nil {
+		return "", err
+	}
+
+	return c.path(prop.Hrefs[0]), nil
+}
+
+// path maps href from TD to resource path, relative hrefs are relative to Thing root
+func (c *CoapClient) path(href string) string {
+	u, err := url.Parse(href)
+
+	if err != nil || u.IsAbs() || strings.HasPrefix(href, "/") {
+		if err == nil {
+			return u.Path
+		}
+		return href
+	}
+
+	return str.Concat(c.base.Path, "/", href)
+}
+
+func (c *CoapClient) request(code uint8, path string) *coapMessage {
+	m := &coapMessage{code: code}
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment != "" {
+			m.options = append(m.options, coapOption{COAP_OPTION_URI_PATH, []byte(segment)})
+		}
+	}
+
+	for _, q := range strings.Split(c.base.RawQuery, "&") {
+		if q != "" {
+			m.options = append(m.options, coapOption{COAP_OPTION_URI_QUERY, []byte(q)})
+		}
+	}
+
+	m.addUint(COAP_OPTION_ACCEPT, COAP_FORMAT_JSON)
+
+	return m
+}
+
+func (c *CoapClient) do(code uint8, path string, body interface{}, result interface{}) error {
+	rq := c.request(code, path)
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		rq.payload = data
+		rq.addUint(COAP_OPTION_CONTENT_FORMAT, COAP_FORMAT_JSON)
+	}
+
+	rs, err := c.conn.exchange(rq)
+	if err != nil {
+		return err
+	}
+
+	if !rs.success() {
+		return &CoapError{
+			Code:    rs.code,
+			Message: str.Concat(path, ": ", CoapCode(rs.code), " ", strings.TrimSpace(string(rs.payload))),
+		}
+	}
+
+	if result == nil || len(rs.payload) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(rs.payload, result)
+}
+
+type coapObservation struct {
+	client *CoapClient
+	path   string
+	token  []byte
+	quit   chan struct{}
+	once   *sync.Once
+}
+
+func (o *coapObservation) run(notifications <-chan *coapMessage, listener func(interface{})) {
+	var last uint32
+	first := true
+
+	for {
+		select {
+		case m := <-notifications:
+			if !m.success() {
+				log.Info("CoAP: observation of ", o.path, " ended -> ", CoapCode(m.code))
+				o.client.conn.close(o.token)
+				return
+			}
+
+			//notifications may be reordered by network, older ones are dropped
+			if v, ok := m.option(COAP_OPTION_OBSERVE); ok {
+				seq := optionUint(v)
+				if !first && !observeNewer(last, seq) {
+					continue
+				}
+				last, first = seq, false
+			}
+
+			var data interface{}
+			if len(m.payload) > 0 {
+				if err := json.Unmarshal(m.payload, &data); err != nil {
+					data = string(m.payload)
+				}
+			}
+
+			listener(data)
+		case <-o.quit:
+			return
+		}
+	}
+}
+
+// observeNewer compares 24 bit Observe sequence numbers with wrap around as defined by RFC 7641
+func observeNewer(last, seq uint32) bool {
+	return (last < seq && seq-last < 1<<23) || (last > seq && last-seq > 1<<23)
+}
+
+// Close deregisters observation, server is told to stop sending notifications
+func (o *coapObservation) Close() error {
+	o.once.Do(func() {
+		close(o.quit)
+		o.client.conn.close(o.token)
+
+		rq := o.client.request(COAP_GET, o.path)
+		rq.token = o.token
+		rq.addUint(COAP_OPTION_OBSERVE, 1)
+		rq.typ = COAP_NON
+		rq.messageID = o.client.conn.nextID()
+		o.client.conn.send(rq)
+	})
+
+	return nil
+}
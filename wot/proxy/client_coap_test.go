@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	COAP_CHANGED   uint8 = 2<<5 | 4
+	COAP_CONTENT   uint8 = 2<<5 | 5
+	COAP_NOT_FOUND uint8 = 4<<5 | 4
+)
+
+// coapServer is in-process CoAP server answering confirmable requests by piggybacked responses of handle,
+// registered observers are passed to observers
+type coapServer struct {
+	conn      *net.UDPConn
+	observers chan *coapObserver
+}
+
+type coapObserver struct {
+	addr  *net.UDPAddr
+	token []byte
+}
+
+func newCoapServer(t *testing.T, handle func(path string, rq *coapMessage) (uint8, string)) *coapServer {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &coapServer{
+		conn:      conn,
+		observers: make(chan *coapObserver, 4),
+	}
+
+	go func() {
+		buf := make([]byte, COAP_MAX_MESSAGE)
+
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			//acknowledgements of notifications are not interesting
+			rq, err := unmarshalCoap(buf[:n])
+			if err != nil || rq.typ != COAP_CON {
+				continue
+			}
+
+			var segments []string
+			for _, o := range rq.options {
+				if o.number == COAP_OPTION_URI_PATH {
+					segments = append(segments, string(o.value))
+				}
+			}
+
+			code, payload := handle("/"+strings.Join(segments, "/"), rq)
+			rs := &coapMessage{typ: COAP_ACK, code: code, messageID: rq.messageID, token: rq.token, payload: []byte(payload)}
+
+			if v, ok := rq.option(COAP_OPTION_OBSERVE); ok && optionUint(v) == 0 {
+				rs.addUint(COAP_OPTION_OBSERVE, 1)
+				s.observers <- &coapObserver{addr, rq.token}
+			}
+
+			conn.WriteToUDP(rs.marshal(), addr)
+		}
+	}()
+
+	return s
+}
+
+func (s *coapServer) uri(path string) string {
+	return "coap://" + s.conn.LocalAddr().String() + path
+}
+
+// notify sends notification of observation, seq is Observe sequence number
+func (s *coapServer) notify(o *coapObserver, seq uint32, payload string) {
+	m := &coapMessage{typ: COAP_NON, code: COAP_CONTENT, messageID: uint16(seq), token: o.token, payload: []byte(payload)}
+	m.addUint(COAP_OPTION_OBSERVE, seq)
+	s.conn.WriteToUDP(m.marshal(), o.addr)
+}
+
+func (s *coapServer) Close() error {
+	return s.conn.Close()
+}
+
+func TestCaseCoapClient(t *testing.T) {
+	on := "false"
+	td, _ := json.Marshal(lampDescription(""))
+
+	s := newCoapServer(t, func(path string, rq *coapMessage) (uint8, string) {
+		switch {
+		case path == "/lamp/description":
+			return COAP_CONTENT, string(td)
+		case path == "/lamp/property/on" && rq.code == COAP_PUT:
+			on = string(rq.payload)
+			return COAP_CHANGED, ""
+		case path == "/lamp/property/on":
+			return COAP_CONTENT, on
+		case path == "/lamp/action/toggle":
+			return COAP_CONTENT, "true"
+		}
+		return COAP_NOT_FOUND, "not found"
+	})
+	defer s.Close()
+
+	c, err := NewCoapClient(s.uri("/lamp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	Equals("Name", t, "lamp", c.Name())
+
+	Equals("Set", t, nil, c.SetProperty("on", true))
+	value, err := c.GetProperty("on")
+	Equals("Get", t, nil, err)
+	Equals("Value", t, true, value)
+
+	task, err := c.InvokeAction("toggle", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, _ := task.Status()
+	Equals("Result", t, true, status.Data)
+
+	_, err = c.GetProperty("brightness")
+	Equals("Unknown property", t, true, err != nil)
+
+	err = c.do(COAP_GET, "/lamp/missing", nil, nil)
+	ce, ok := err.(*CoapError)
+	Equals("Not found", t, true, ok && ce.Code == COAP_NOT_FOUND)
+}
+
+func TestCaseCoapObservation(t *testing.T) {
+	td, _ := json.Marshal(lampDescription(""))
+
+	s := newCoapServer(t, func(path string, rq *coapMessage) (uint8, string) {
+		switch path {
+		case "/lamp/description":
+			return COAP_CONTENT, string(td)
+		case "/lamp/property/on":
+			return COAP_CONTENT, "0"
+		}
+		return COAP_NOT_FOUND, ""
+	})
+	defer s.Close()
+
+	c, err := NewCoapClient(s.uri("/lamp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	values := make(chan interface{}, 8)
+	sub, err := c.ObserveProperty("on", func(v interface{}) { values <- v })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	o := <-s.observers
+
+	//notification reordered by network is dropped
+	s.notify(o, 3, "3")
+	s.notify(o, 2, "2")
+	s.notify(o, 4, "4")
+
+	for _, expected := range []float64{0, 3, 4} {
+		select {
+		case v := <-values:
+			Equals("Notification "+strconv.FormatFloat(expected, 'f', 0, 64), t, expected, v)
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification not delivered: ", expected)
+		}
+	}
+
+	select {
+	case v := <-values:
+		t.Error("unexpected notification: ", v)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,253 @@
+package proxy
+
+import (
+	"net/url"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// ProtocolSelector orders protocols (href schemes) offered by interaction forms by preference
+type ProtocolSelector func(interaction string, schemes []string) []string
+
+// PreferProtocols selects protocols in given order, protocols not listed are tried last
+func PreferProtocols(preferred ...string) ProtocolSelector {
+	return func(interaction string, schemes []string) []string {
+		ordered := make([]string, 0, len(schemes))
+
+		for _, p := range preferred {
+			for _, s := range schemes {
+				if s == p {
+					ordered = append(ordered, s)
+				}
+			}
+		}
+
+		for _, s := range schemes {
+			if !contains(ordered, s) {
+				ordered = append(ordered, s)
+			}
+		}
+
+		return ordered
+	}
+}
+
+// DEFAULT_PROTOCOLS is order in which protocols are tried by default
+var DEFAULT_PROTOCOLS = PreferProtocols("https", "http", "coap", "mqtts", "mqtt")
+
+// CompositeClient consumes Thing advertising interactions over several protocols. For every
+// interaction protocols of its forms are tried in order given by selector, the next one is used
+// when client of preferred protocol cannot be created or the interaction fails.
+type CompositeClient struct {
+	td       *model.ThingDescription
+	cfg      map[string]interface{}
+	selector ProtocolSelector
+	l        *sync.Mutex
+	clients  map[string]Client
+}
+
+// NewCompositeClient creates client of td, cfg is passed to protocol clients (see Consume)
+func NewCompositeClient(td *model.ThingDescription, cfg map[string]interface{}) *CompositeClient {
+	return &CompositeClient{
+		td:       td,
+		cfg:      cfg,
+		selector: DEFAULT_PROTOCOLS,
+		l:        &sync.Mutex{},
+		clients:  make(map[string]Client),
+	}
+}
+
+// WithSelector replaces default protocol preference
+func (c *CompositeClient) WithSelector(selector ProtocolSelector) *CompositeClient {
+	c.selector = selector
+	return c
+}
+
+func (c *CompositeClient) Name() string {
+	return c.td.Name
+}
+
+func (c *CompositeClient) GetDescription() (*model.ThingDescription, error) {
+	return c.td, nil
+}
+
+func (c *CompositeClient) GetProperty(propertyName string) (interface{}, error) {
+	prop, err := findProperty(c.td, propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = c.try(propertyName, prop.Hrefs, func(client Client) (err error) {
+		value, err = client.GetProperty(propertyName)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *CompositeClient) SetProperty(propertyName string, newValue interface{}) error {
+	prop, err := findProperty(c.td, propertyName)
+	if err != nil {
+		return err
+	}
+
+	return c.try(propertyName, prop.Hrefs, func(client Client) error {
+		return client.SetProperty(propertyName, newValue)
+	})
+}
+
+func (c *CompositeClient) InvokeAction(actionName string, arg interface{}) (Task, error) {
+	action, err := findAction(c.td, actionName)
+	if err != nil {
+		return nil, err
+	}
+
+	var task Task
+	err = c.try(actionName, action.Hrefs, func(client Client) (err error) {
+		task, err = client.InvokeAction(actionName, arg)
+		return err
+	})
+
+	return task, err
+}
+
+func (c *CompositeClient) AddListener(eventName string, listener func(*server.Event)) (Subscription, error) {
+	event, err := findEvent(c.td, eventName)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub Subscription
+	err = c.try(eventName, event.Hrefs, func(client Client) (err error) {
+		sub, err = client.AddListener(eventName, listener)
+		return err
+	})
+
+	return sub, err
+}
+
+// try calls op with clients of interaction protocols in selector order until it succeeds,
+// error of the last attempt is returned
+func (c *CompositeClient) try(interaction string, hrefs []string, op func(Client) error) error {
+	err := ErrUnknownInteraction("form", interaction)
+
+	for _, scheme := range c.selector(interaction, c.schemes(hrefs)) {
+		client, cerr := c.client(scheme)
+		if cerr != nil {
+			log.Info("CompositeClient: ", scheme, " client of ", c.td.Name, " unavailable -> ", cerr)
+			err = cerr
+			continue
+		}
+
+		if err = op(client); err == nil {
+			return nil
+		}
+
+		log.Info("CompositeClient: ", interaction, " over ", scheme, " failed -> ", err)
+	}
+
+	return err
+}
+
+// client returns cached client of protocol, it is created from description restricted to forms of the protocol
+func (c *CompositeClient) client(scheme string) (Client, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if client, ok := c.clients[scheme]; ok {
+		return client, nil
+	}
+
+	client, err := Consume(project(c.td, scheme), c.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c.clients[scheme] = client
+	return client, nil
+}
+
+func (c *CompositeClient) schemes(hrefs []string) []string {
+	schemes := make([]string, 0)
+
+	for _, href := range hrefs {
+		if s := hrefScheme(c.td, href); s != "" && !contains(schemes, s) {
+			schemes = append(schemes, s)
+		}
+	}
+
+	return schemes
+}
+
+// hrefScheme is scheme of href, relative hrefs belong to protocol of the first Thing URI
+func hrefScheme(td *model.ThingDescription, href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	if !u.IsAbs() && len(td.Uris) > 0 {
+		if root, err := url.Parse(td.Uris[0]); err == nil {
+			return root.Scheme
+		}
+	}
+
+	return u.Scheme
+}
+
+// project copies td keeping only Thing URIs and interaction forms of given protocol
+func project(td *model.ThingDescription, scheme string) *model.ThingDescription {
+	p := *td
+	p.Uris = make([]string, 0)
+	p.Properties = make([]model.Property, 0)
+	p.Actions = make([]model.Action, 0)
+	p.Events = make([]model.Event, 0)
+
+	for _, uri := range td.Uris {
+		if hrefScheme(td, uri) == scheme {
+			p.Uris = append(p.Uris, uri)
+		}
+	}
+
+	forms := func(hrefs []string) []string {
+		matching := make([]string, 0)
+		for _, href := range hrefs {
+			if hrefScheme(td, href) == scheme {
+				matching = append(matching, href)
+			}
+		}
+		return matching
+	}
+
+	for _, prop := range td.Properties {
+		if prop.Hrefs = forms(prop.Hrefs); len(prop.Hrefs) > 0 {
+			p.Properties = append(p.Properties, prop)
+		}
+	}
+	for _, action := range td.Actions {
+		if action.Hrefs = forms(action.Hrefs); len(action.Hrefs) > 0 {
+			p.Actions = append(p.Actions, action)
+		}
+	}
+	for _, event := range td.Events {
+		if event.Hrefs = forms(event.Hrefs); len(event.Hrefs) > 0 {
+			p.Events = append(p.Events, event)
+		}
+	}
+
+	return &p
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
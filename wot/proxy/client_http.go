@@ -0,0 +1,483 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+var ErrConflict = errors.New("Property value changed")
+
+// invocations ask for task links, synchronous actions answer by result otherwise
+var asyncInvocation = http.Header{"Prefer": {"respond-async"}}
+
+// HttpError is returned for non 2xx responses
+type HttpError struct {
+	StatusCode int
+	Message    string
+	Body       []byte
+}
+
+func (e *HttpError) Error() string {
+	return e.Message
+}
+
+// HttpClient consumes Thing exposed by frontend.Http
+type HttpClient struct {
+	base         *url.URL
+	client       *http.Client
+	headers      http.Header
+	l            *sync.Mutex
+	td           *model.ThingDescription
+	tdETag       string
+	tdExpires    time.Time
+	tdListeners  []DescriptionListener
+	reconnectMin time.Duration
+	reconnectMax time.Duration
+	onState      ConnStateListener
+}
+
+// NewHttpClient creates client for Thing with root at uri, e.g. http://localhost:8080/01-basic-example
+func NewHttpClient(uri string) (*HttpClient, error) {
+	base, err := url.Parse(strings.TrimSuffix(uri, "/"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &HttpClient{
+		base:         base,
+		client:       sharedClient(),
+		headers:      make(http.Header),
+		l:            &sync.Mutex{},
+		reconnectMin: RECONNECT_MIN,
+		reconnectMax: RECONNECT_MAX,
+	}, nil
+}
+
+// WithHTTPClient replaces shared connection pool, e.g. by client created with NewPooledClient
+func (c *HttpClient) WithHTTPClient(client *http.Client) *HttpClient {
+	c.client = client
+	return c
+}
+
+func (c *HttpClient) WithBearer(token string) *HttpClient {
+	c.headers.Set("Authorization", str.Concat("Bearer ", token))
+	return c
+}
+
+func (c *HttpClient) WithAPIKey(key string) *HttpClient {
+	c.headers.Set("X-API-Key", key)
+	return c
+}
+
+// WithReconnectBackoff sets delay of the first event listener reconnect attempt, delay doubles
+// with every failed attempt up to max
+func (c *HttpClient) WithReconnectBackoff(min, max time.Duration) *HttpClient {
+	c.reconnectMin = min
+	c.reconnectMax = max
+	return c
+}
+
+// OnConnectionState registers listener of event listeners connection state
+func (c *HttpClient) OnConnectionState(listener ConnStateListener) *HttpClient {
+	c.onState = listener
+	return c
+}
+
+func (c *HttpClient) notifyState(eventName string, state ConnState, err error) {
+	if c.onState != nil {
+		c.onState(eventName, state, err)
+	}
+}
+
+// URL is root of consumed Thing
+func (c *HttpClient) URL() string {
+	return c.base.String()
+}
+
+func (c *HttpClient) Name() string {
+	td, err := c.GetDescription()
+
+	if err != nil {
+		return ""
+	}
+
+	return td.Name
+}
+
+func (c *HttpClient) GetProperty(propertyName string) (interface{}, error) {
+	href, err := c.propertyHref(propertyName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = c.do("GET", href, nil, &value)
+
+	return value, err
+}
+
+func (c *HttpClient) SetProperty(propertyName string, newValue interface{}) error {
+	href, err := c.propertyHref(propertyName)
+
+	if err != nil {
+		return err
+	}
+
+	return c.do("PUT", href, newValue, nil)
+}
+
+// GetPropertyStream reads streamed property value, e.g. blob served by server.Stream.
+// Caller is responsible for closing returned reader.
+func (c *HttpClient) GetPropertyStream(propertyName string) (io.ReadCloser, error) {
+	href, err := c.propertyHref(propertyName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.stream("GET", href, "", nil)
+}
+
+// CompareAndSetProperty writes newValue only if current property value equals expected,
+// ErrConflict is returned when property has changed
+func (c *HttpClient) CompareAndSetProperty(propertyName string, expected, newValue interface{}) error {
+	href, err := c.propertyHref(propertyName)
+
+	if err != nil {
+		return err
+	}
+
+	err = c.do("PUT", str.Concat(href, "/cas"), map[string]interface{}{
+		"expected": expected,
+		"value":    newValue,
+	}, nil)
+
+	if he, ok := err.(*HttpError); ok && he.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (c *HttpClient) InvokeAction(actionName string, arg interface{}) (Task, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := findAction(td, actionName)
+	if err != nil {
+		return nil, err
+	}
+
+	//actions without input are invoked with JSON null, frontend requires JSON body
+	if arg == nil {
+		arg = json.RawMessage("null")
+	}
+
+	ls := &links{}
+	if err = c.doWith("POST", c.resolve(action.Hrefs[0]), asyncInvocation, arg, ls); err != nil {
+		return nil, err
+	}
+
+	return &httpTask{
+		client: c,
+		rest:   ls.href("rest"),
+		ws:     ls.href("websocket"),
+	}, nil
+}
+
+// InvokeActionStream invokes action with binary input, e.g. firmware image. Content is streamed
+// to the Thing, the call returns after the action has consumed it.
+func (c *HttpClient) InvokeActionStream(actionName, contentType string, input io.Reader) (Task, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := findAction(td, actionName)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := c.streamWith("POST", c.resolve(action.Hrefs[0]), contentType, asyncInvocation, input)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	ls := &links{}
+	if err = json.NewDecoder(rs).Decode(ls); err != nil {
+		return nil, err
+	}
+
+	return &httpTask{
+		client: c,
+		rest:   ls.href("rest"),
+		ws:     ls.href("websocket"),
+	}, nil
+}
+
+// GetActionResult downloads binary result of finished task invoked by this client.
+// Caller is responsible for closing returned reader.
+func (c *HttpClient) GetActionResult(task Task) (io.ReadCloser, error) {
+	t, ok := task.(*httpTask)
+	if !ok {
+		return nil, errors.New("Task was not invoked by HttpClient")
+	}
+
+	return c.stream("GET", str.Concat(c.resolve(t.rest), "/result"), "", nil)
+}
+
+func (c *HttpClient) AddListener(eventName string, listener func(*server.Event)) (Subscription, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := findEvent(td, eventName)
+	if err != nil {
+		return nil, err
+	}
+
+	wl := &wsListener{
+		client:    c,
+		eventName: eventName,
+		eventHref: c.resolve(event.Hrefs[0]),
+		listener:  listener,
+		l:         &sync.Mutex{},
+		quit:      make(chan struct{}),
+		once:      &sync.Once{},
+	}
+
+	if err = wl.subscribe(); err != nil {
+		return nil, err
+	}
+
+	if _, err = wl.connect(); err != nil {
+		return nil, err
+	}
+
+	c.notifyState(eventName, CONN_CONNECTED, nil)
+	go wl.run()
+
+	return wl, nil
+}
+
+func (c *HttpClient) propertyHref(propertyName string) (string, error) {
+	td, err := c.GetDescription()
+	if err != nil {
+		return "", err
+	}
+
+	prop, err := findProperty(td, propertyName)
+	if err != nil {
+		return "", err
+	}
+
+	return c.resolve(prop.Hrefs[0]), nil
+}
+
+// resolve maps href from TD on host client is connected to. Frontend advertises hrefs using
+// its configured hostname which does not need to be reachable from the client.
+func (c *HttpClient) resolve(href string) string {
+	u, err := url.Parse(href)
+
+	if err != nil {
+		return href
+	}
+
+	if !u.IsAbs() {
+		return c.base.ResolveReference(u).String()
+	}
+
+	u.Scheme = c.base.Scheme
+	u.Host = c.base.Host
+
+	return u.String()
+}
+
+func (c *HttpClient) do(method, uri string, body interface{}, result interface{}) error {
+	return c.doWith(method, uri, nil, body, result)
+}
+
+// doWith sends request like do, header is added to headers of the client
+func (c *HttpClient) doWith(method, uri string, header http.Header, body interface{}, result interface{}) error {
+	var rd io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(data)
+	}
+
+	rq, err := http.NewRequest(method, uri, rd)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range c.headers {
+		rq.Header[k] = v
+	}
+	for k, v := range header {
+		rq.Header[k] = v
+	}
+	if body != nil {
+		rq.Header.Set("Content-Type", "application/json")
+	}
+
+	rs, err := c.client.Do(rq)
+	if err != nil {
+		return err
+	}
+	defer rs.Body.Close()
+
+	data, err := ioutil.ReadAll(rs.Body)
+	if err != nil {
+		return err
+	}
+
+	if rs.StatusCode < 200 || rs.StatusCode > 299 {
+		return &HttpError{
+			StatusCode: rs.StatusCode,
+			Message:    str.Concat(method, " ", uri, ": ", rs.Status, " ", strings.TrimSpace(string(data))),
+			Body:       data,
+		}
+	}
+
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// stream sends request with raw body and returns response body unread
+func (c *HttpClient) stream(method, uri, contentType string, body io.Reader) (io.ReadCloser, error) {
+	return c.streamWith(method, uri, contentType, nil, body)
+}
+
+// streamWith sends request like stream, header is added to headers of the client
+func (c *HttpClient) streamWith(method, uri, contentType string, header http.Header, body io.Reader) (io.ReadCloser, error) {
+	rq, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range c.headers {
+		rq.Header[k] = v
+	}
+	for k, v := range header {
+		rq.Header[k] = v
+	}
+	if contentType != "" {
+		rq.Header.Set("Content-Type", contentType)
+	}
+
+	rs, err := c.client.Do(rq)
+	if err != nil {
+		return nil, err
+	}
+
+	if rs.StatusCode < 200 || rs.StatusCode > 299 {
+		defer rs.Body.Close()
+		data, _ := ioutil.ReadAll(rs.Body)
+		return nil, &HttpError{
+			StatusCode: rs.StatusCode,
+			Message:    str.Concat(method, " ", uri, ": ", rs.Status, " ", strings.TrimSpace(string(data))),
+			Body:       data,
+		}
+	}
+
+	return rs.Body, nil
+}
+
+func (c *HttpClient) dial(href string) (*websocket.Conn, error) {
+	conn, _, err := c.dialResponse(href)
+	return conn, err
+}
+
+// dialResponse dials WebSocket, handshake response is returned to tell rejected handshake from network error
+func (c *HttpClient) dialResponse(href string) (*websocket.Conn, *http.Response, error) {
+	u, err := url.Parse(c.resolve(href))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.base.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+
+	dialer := &websocket.Dialer{}
+	return dialer.Dial(u.String(), c.headers)
+}
+
+type links struct {
+	Links []struct {
+		Rel  string `json:"rel"`
+		Href string `json:"href"`
+	} `json:"links"`
+}
+
+func (ls *links) href(rel string) string {
+	for _, l := range ls.Links {
+		if l.Rel == rel {
+			return l.Href
+		}
+	}
+
+	return ""
+}
+
+type httpTask struct {
+	client *HttpClient
+	rest   string
+	ws     string
+}
+
+func (t *httpTask) Status() (*server.TaskStatus, error) {
+	status := &server.TaskStatus{}
+	err := t.client.do("GET", t.client.resolve(t.rest), nil, status)
+
+	return status, err
+}
+
+func (t *httpTask) Watch(listener func(*server.TaskStatus)) error {
+	conn, err := t.client.dial(t.ws)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for {
+		status := &server.TaskStatus{}
+		if err := conn.ReadJSON(status); err != nil {
+			return err
+		}
+
+		listener(status)
+
+		if status.Status == server.TASK_DONE || status.Status == server.TASK_FAILED {
+			return nil
+		}
+	}
+}
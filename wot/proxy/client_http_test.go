@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+// lampDescription describes Thing with writable property "on", action "toggle" and event "change",
+// hrefs are relative to root
+func lampDescription(root string) *model.ThingDescription {
+	return &model.ThingDescription{
+		Name:       "lamp",
+		Properties: []model.Property{{Name: "on", Writable: true, ValueType: model.ValueType{Type: "boolean"}, Hrefs: []string{root + "property/on"}}},
+		Actions:    []model.Action{{Name: "toggle", Hrefs: []string{root + "action/toggle"}}},
+		Events:     []model.Event{{Name: "change", Hrefs: []string{root + "event/change"}}},
+	}
+}
+
+// lampHandler serves lamp like frontend.Http at /lamp, requests without bearer token are rejected.
+// Returned function counts requests of description.
+func lampHandler(t *testing.T) (http.Handler, func() int) {
+	l := &sync.Mutex{}
+	on := false
+	fetched := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lamp/description", func(w http.ResponseWriter, r *http.Request) {
+		l.Lock()
+		fetched++
+		l.Unlock()
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=0")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		//frontend advertises its configured hostname, client maps hrefs on host it is connected to
+		json.NewEncoder(w).Encode(lampDescription("http://gateway.local:8080/lamp/"))
+	})
+	mux.HandleFunc("/lamp/property/on", func(w http.ResponseWriter, r *http.Request) {
+		l.Lock()
+		defer l.Unlock()
+
+		if r.Method == "PUT" {
+			if err := json.NewDecoder(r.Body).Decode(&on); err != nil {
+				http.Error(w, "not boolean", http.StatusBadRequest)
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(on)
+	})
+	mux.HandleFunc("/lamp/property/on/cas", func(w http.ResponseWriter, r *http.Request) {
+		l.Lock()
+		defer l.Unlock()
+
+		cas := &struct {
+			Expected bool `json:"expected"`
+			Value    bool `json:"value"`
+		}{}
+		json.NewDecoder(r.Body).Decode(cas)
+		if cas.Expected != on {
+			http.Error(w, "changed", http.StatusConflict)
+			return
+		}
+		on = cas.Value
+	})
+	mux.HandleFunc("/lamp/action/toggle", func(w http.ResponseWriter, r *http.Request) {
+		Equals("Asynchronous invocation", t, "respond-async", r.Header.Get("Prefer"))
+		w.Write([]byte(`{"links": [{"rel": "rest", "href": "/lamp/action/toggle/1"}, {"rel": "websocket", "href": "/lamp/action/toggle/ws/1"}]}`))
+	})
+	mux.HandleFunc("/lamp/action/toggle/1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&server.TaskStatus{Name: "toggle", Status: server.TASK_DONE, Data: true})
+	})
+	mux.HandleFunc("/lamp/action/toggle/ws/1", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteJSON(&server.TaskStatus{Name: "toggle", Status: server.TASK_RUNNING})
+		conn.WriteJSON(&server.TaskStatus{Name: "toggle", Status: server.TASK_DONE, Data: true})
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			mux.ServeHTTP(w, r)
+		}), func() int {
+			l.Lock()
+			defer l.Unlock()
+			return fetched
+		}
+}
+
+func TestCaseHttpClient(t *testing.T) {
+	h, fetched := lampHandler(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	c, _ := NewHttpClient(srv.URL + "/lamp/")
+	c.WithBearer("secret")
+
+	Equals("Name", t, "lamp", c.Name())
+
+	Equals("Set", t, nil, c.SetProperty("on", true))
+	on, err := c.GetProperty("on")
+	Equals("Get", t, nil, err)
+	Equals("Value", t, true, on)
+
+	Equals("CAS", t, nil, c.CompareAndSetProperty("on", true, false))
+	Equals("CAS conflict", t, ErrConflict, c.CompareAndSetProperty("on", true, false))
+
+	_, err = c.GetProperty("brightness")
+	Equals("Unknown property", t, true, err != nil)
+
+	err = c.SetProperty("on", "yes")
+	he, ok := err.(*HttpError)
+	Equals("Rejected value", t, true, ok && he.StatusCode == http.StatusBadRequest)
+
+	//stale description is revalidated, not downloaded again
+	Equals("Revalidated", t, true, fetched() > 1)
+
+	task, err := c.InvokeAction("toggle", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := task.Status()
+	Equals("Task status", t, nil, err)
+	Equals("Task done", t, server.TASK_DONE, status.Status)
+
+	var watched []server.TaskStatusCode
+	Equals("Watch", t, nil, task.Watch(func(s *server.TaskStatus) {
+		watched = append(watched, s.Status)
+	}))
+	Equals("Watched", t, 2, len(watched))
+}
+
+func TestCaseHttpClientErrors(t *testing.T) {
+	h, _ := lampHandler(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	c, _ := NewHttpClient(srv.URL + "/lamp")
+	_, err := c.GetDescription()
+	he, ok := err.(*HttpError)
+	if !ok {
+		t.Fatal("HttpError expected: ", err)
+	}
+	Equals("Unauthorized", t, http.StatusUnauthorized, he.StatusCode)
+	Equals("Body", t, "unauthorized\n", string(he.Body))
+
+	c, _ = NewHttpClient(srv.URL + "/missing")
+	c.WithBearer("secret")
+	_, err = c.GetDescription()
+	he, ok = err.(*HttpError)
+	Equals("Not found", t, true, ok && he.StatusCode == http.StatusNotFound)
+
+	c, _ = NewHttpClient(srv.URL + "/lamp")
+	c.WithBearer("secret")
+	rs, err := c.stream("GET", srv.URL+"/lamp/property/on", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(rs)
+	rs.Close()
+	Equals("Stream", t, "false\n", string(data))
+}
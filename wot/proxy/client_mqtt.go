@@ -0,0 +1,332 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/sec"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+	"github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTT_CLIENT_TIMEOUT is default time to wait for property value and action reply, override by "timeout" config in seconds
+const MQTT_CLIENT_TIMEOUT = 10 * time.Second
+
+var ErrMqttTimeout = errors.New("MQTT reply timed out")
+
+// MqttRequest is action invocation published to action topic. Thing replies with MqttReply of the same
+// ID to ReplyTo topic.
+type MqttRequest struct {
+	ID      string      `json:"id"`
+	ReplyTo string      `json:"replyTo"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type MqttReply struct {
+	ID    string      `json:"id"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// MqttClient consumes Thing exposed over MQTT forms, hrefs are mqtt://broker/topic URIs. Property value
+// is read from retained message of its topic and written to {topic}/set, events are messages published
+// to event topic and actions are request-reply conversations on action topic.
+type MqttClient struct {
+	td      *model.ThingDescription
+	client  mqtt.Client
+	id      string
+	timeout time.Duration
+	l       *sync.Mutex
+	pending map[string]chan *MqttReply
+	replies map[string]bool
+}
+
+// NewMqttClient connects to broker of td hrefs. Optional cfg keys are "username", "password" and "timeout".
+func NewMqttClient(td *model.ThingDescription, cfg map[string]interface{}) (*MqttClient, error) {
+	broker, err := mqttBroker(td)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := sec.UUID4()
+	opts := mqtt.NewClientOptions().AddBroker(broker).SetClientID(id)
+	opts.SetKeepAlive(20 * time.Second)
+	opts.SetPingTimeout(1 * time.Second)
+
+	if username, ok := cfg["username"].(string); ok {
+		opts.SetUsername(username)
+	}
+	if password, ok := cfg["password"].(string); ok {
+		opts.SetPassword(password)
+	}
+
+	timeout := MQTT_CLIENT_TIMEOUT
+	if t, ok := cfg["timeout"].(int); ok {
+		timeout = time.Duration(t) * time.Second
+	}
+
+	c := mqtt.NewClient(opts)
+	if token := c.Connect(); token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	return &MqttClient{
+		td:      td,
+		client:  c,
+		id:      id,
+		timeout: timeout,
+		l:       &sync.Mutex{},
+		pending: make(map[string]chan *MqttReply),
+		replies: make(map[string]bool),
+	}, nil
+}
+
+// mqttBroker maps scheme of the first MQTT href to broker URI, mqtt is plain tcp and mqtts is ssl
+func mqttBroker(td *model.ThingDescription) (string, error) {
+	for _, href := range hrefs(td) {
+		u, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+
+		switch u.Scheme {
+		case "mqtt":
+			return str.Concat("tcp://", u.Host), nil
+		case "mqtts":
+			return str.Concat("ssl://", u.Host), nil
+		}
+	}
+
+	return "", errors.New(str.Concat("No MQTT form in description of ", td.Name))
+}
+
+// Close disconnects from broker
+func (c *MqttClient) Close() error {
+	c.client.Disconnect(250)
+	return nil
+}
+
+func (c *MqttClient) Name() string {
+	return c.td.Name
+}
+
+func (c *MqttClient) GetDescription() (*model.ThingDescription, error) {
+	return c.td, nil
+}
+
+// GetProperty returns retained value of property topic, ErrMqttTimeout is returned when the Thing
+// has not published any value
+func (c *MqttClient) GetProperty(propertyName string) (interface{}, error) {
+	prop, err := findProperty(c.td, propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := mqttTopic(prop.Hrefs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(chan interface{}, 1)
+	token := c.client.Subscribe(topic, 1, func(_ mqtt.Client, m mqtt.Message) {
+		select {
+		case values <- mqttPayload(m.Payload()):
+		default:
+		}
+	})
+	if token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+	defer c.client.Unsubscribe(topic)
+
+	select {
+	case v := <-values:
+		return v, nil
+	case <-time.After(c.timeout):
+		return nil, ErrMqttTimeout
+	}
+}
+
+func (c *MqttClient) SetProperty(propertyName string, newValue interface{}) error {
+	prop, err := findProperty(c.td, propertyName)
+	if err != nil {
+		return err
+	}
+
+	topic, err := mqttTopic(prop.Hrefs[0])
+	if err != nil {
+		return err
+	}
+
+	return c.publish(str.Concat(topic, "/set"), newValue)
+}
+
+// ObserveProperty registers listener of values published to property topic
+func (c *MqttClient) ObserveProperty(propertyName string, listener func(interface{})) (Subscription, error) {
+	prop, err := findProperty(c.td, propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.subscribe(prop.Hrefs[0], listener)
+}
+
+// InvokeAction publishes request to action topic and waits for the reply, returned task is already finished
+func (c *MqttClient) InvokeAction(actionName string, arg interface{}) (Task, error) {
+	action, err := findAction(c.td, actionName)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := mqttTopic(action.Hrefs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	replyTo := str.Concat(topic, "/reply/", c.id)
+	if err = c.subscribeReplies(replyTo); err != nil {
+		return nil, err
+	}
+
+	id, _ := sec.UUID4()
+	reply := make(chan *MqttReply, 1)
+
+	c.l.Lock()
+	c.pending[id] = reply
+	c.l.Unlock()
+
+	defer func() {
+		c.l.Lock()
+		delete(c.pending, id)
+		c.l.Unlock()
+	}()
+
+	if err = c.publish(topic, &MqttRequest{ID: id, ReplyTo: replyTo, Data: arg}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case rs := <-reply:
+		if rs.Error != "" {
+			return nil, errors.New(rs.Error)
+		}
+		return doneTask(actionName, rs.Data), nil
+	case <-time.After(c.timeout):
+		return nil, ErrMqttTimeout
+	}
+}
+
+func (c *MqttClient) AddListener(eventName string, listener func(*server.Event)) (Subscription, error) {
+	event, err := findEvent(c.td, eventName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.subscribe(event.Hrefs[0], func(data interface{}) {
+		listener(&server.Event{
+			Event:     eventName,
+			Timestamp: time.Now(),
+			Data:      data,
+		})
+	})
+}
+
+func (c *MqttClient) subscribe(href string, listener func(interface{})) (Subscription, error) {
+	topic, err := mqttTopic(href)
+	if err != nil {
+		return nil, err
+	}
+
+	token := c.client.Subscribe(topic, 1, func(_ mqtt.Client, m mqtt.Message) {
+		listener(mqttPayload(m.Payload()))
+	})
+	if token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	return &mqttSubscription{client: c.client, topic: topic}, nil
+}
+
+// subscribeReplies subscribes reply topic of action once, replies are dispatched to pending invocations by ID
+func (c *MqttClient) subscribeReplies(replyTo string) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.replies[replyTo] {
+		return nil
+	}
+
+	token := c.client.Subscribe(replyTo, 1, func(_ mqtt.Client, m mqtt.Message) {
+		rs := &MqttReply{}
+		if err := json.Unmarshal(m.Payload(), rs); err != nil {
+			log.Info("MqttClient: malformed reply on ", replyTo, " -> ", err)
+			return
+		}
+
+		c.l.Lock()
+		reply, ok := c.pending[rs.ID]
+		c.l.Unlock()
+
+		//late replies of timed out invocations are dropped
+		if ok {
+			reply <- rs
+		}
+	})
+	if token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+
+	c.replies[replyTo] = true
+	return nil
+}
+
+func (c *MqttClient) publish(topic string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	token := c.client.Publish(topic, 1, false, payload)
+	token.Wait()
+
+	return token.Error()
+}
+
+type mqttSubscription struct {
+	client mqtt.Client
+	topic  string
+}
+
+func (s *mqttSubscription) Close() error {
+	token := s.client.Unsubscribe(s.topic)
+	token.Wait()
+
+	return token.Error()
+}
+
+func mqttTopic(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// mqttPayload decodes JSON payload, devices publishing plain values are delivered as string
+func mqttPayload(payload []byte) interface{} {
+	var data interface{}
+
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return string(payload)
+	}
+
+	return data
+}
@@ -0,0 +1,427 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+)
+
+// CoAP message types and codes as defined by RFC 7252, Observe option by RFC 7641
+const (
+	COAP_CON uint8 = 0
+	COAP_NON uint8 = 1
+	COAP_ACK uint8 = 2
+	COAP_RST uint8 = 3
+
+	COAP_GET    uint8 = 1
+	COAP_POST   uint8 = 2
+	COAP_PUT    uint8 = 3
+	COAP_DELETE uint8 = 4
+
+	COAP_OPTION_OBSERVE        uint16 = 6
+	COAP_OPTION_URI_PATH       uint16 = 11
+	COAP_OPTION_CONTENT_FORMAT uint16 = 12
+	COAP_OPTION_URI_QUERY      uint16 = 15
+	COAP_OPTION_ACCEPT         uint16 = 17
+
+	COAP_FORMAT_JSON = 50
+)
+
+const (
+	COAP_ACK_TIMEOUT    = 2 * time.Second
+	COAP_MAX_RETRANSMIT = 4
+	COAP_EXCHANGE       = 30 * time.Second
+	COAP_MAX_MESSAGE    = 1152
+)
+
+var (
+	ErrCoapTimeout = errors.New("CoAP request timed out")
+	ErrCoapReset   = errors.New("CoAP request rejected by server")
+)
+
+// CoapError is returned for responses with other than 2.xx code
+type CoapError struct {
+	Code    uint8
+	Message string
+}
+
+func (e *CoapError) Error() string {
+	return e.Message
+}
+
+// CoapCode formats response code as class.detail, e.g. 4.04
+func CoapCode(code uint8) string {
+	detail := strconv.Itoa(int(code & 0x1f))
+	if len(detail) == 1 {
+		detail = "0" + detail
+	}
+
+	return str.Concat(strconv.Itoa(int(code>>5)), ".", detail)
+}
+
+type coapOption struct {
+	number uint16
+	value  []byte
+}
+
+type coapMessage struct {
+	typ       uint8
+	code      uint8
+	messageID uint16
+	token     []byte
+	options   []coapOption
+	payload   []byte
+}
+
+func (m *coapMessage) option(number uint16) ([]byte, bool) {
+	for _, o := range m.options {
+		if o.number == number {
+			return o.value, true
+		}
+	}
+
+	return nil, false
+}
+
+func (m *coapMessage) addUint(number uint16, v uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+
+	//uint options use minimal length, zero is encoded as empty value
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+
+	m.options = append(m.options, coapOption{number, b})
+}
+
+func optionUint(value []byte) uint32 {
+	v := uint32(0)
+	for _, b := range value {
+		v = v<<8 | uint32(b)
+	}
+
+	return v
+}
+
+func (m *coapMessage) success() bool {
+	return m.code>>5 == 2
+}
+
+func (m *coapMessage) marshal() []byte {
+	buf := []byte{1<<6 | m.typ<<4 | uint8(len(m.token)), m.code, 0, 0}
+	binary.BigEndian.PutUint16(buf[2:], m.messageID)
+	buf = append(buf, m.token...)
+
+	options := append([]coapOption(nil), m.options...)
+	sort.SliceStable(options, func(i, j int) bool { return options[i].number < options[j].number })
+
+	last := uint16(0)
+	for _, o := range options {
+		delta, deltaExt := coapNibble(int(o.number - last))
+		length, lengthExt := coapNibble(len(o.value))
+
+		buf = append(buf, delta<<4|length)
+		buf = append(buf, deltaExt...)
+		buf = append(buf, lengthExt...)
+		buf = append(buf, o.value...)
+		last = o.number
+	}
+
+	if len(m.payload) > 0 {
+		buf = append(buf, 0xff)
+		buf = append(buf, m.payload...)
+	}
+
+	return buf
+}
+
+// coapNibble encodes option delta or length, values over 12 use extended bytes
+func coapNibble(v int) (uint8, []byte) {
+	switch {
+	case v < 13:
+		return uint8(v), nil
+	case v < 269:
+		return 13, []byte{uint8(v - 13)}
+	default:
+		ext := make([]byte, 2)
+		binary.BigEndian.PutUint16(ext, uint16(v-269))
+		return 14, ext
+	}
+}
+
+var errCoapFormat = errors.New("Malformed CoAP message")
+
+func unmarshalCoap(data []byte) (*coapMessage, error) {
+	if len(data) < 4 || data[0]>>6 != 1 {
+		return nil, errCoapFormat
+	}
+
+	tkl := int(data[0] & 0x0f)
+	if tkl > 8 || len(data) < 4+tkl {
+		return nil, errCoapFormat
+	}
+
+	m := &coapMessage{
+		typ:       (data[0] >> 4) & 0x03,
+		code:      data[1],
+		messageID: binary.BigEndian.Uint16(data[2:]),
+		token:     append([]byte(nil), data[4:4+tkl]...),
+	}
+
+	data = data[4+tkl:]
+	number := 0
+
+	for len(data) > 0 {
+		if data[0] == 0xff {
+			m.payload = append([]byte(nil), data[1:]...)
+			break
+		}
+
+		delta, length := int(data[0]>>4), int(data[0]&0x0f)
+		data = data[1:]
+
+		var err error
+		if delta, data, err = coapExtended(delta, data); err != nil {
+			return nil, err
+		}
+		if length, data, err = coapExtended(length, data); err != nil {
+			return nil, err
+		}
+		if len(data) < length {
+			return nil, errCoapFormat
+		}
+
+		number += delta
+		m.options = append(m.options, coapOption{uint16(number), append([]byte(nil), data[:length]...)})
+		data = data[length:]
+	}
+
+	return m, nil
+}
+
+func coapExtended(v int, data []byte) (int, []byte, error) {
+	switch v {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, errCoapFormat
+		}
+		return int(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, errCoapFormat
+		}
+		return int(binary.BigEndian.Uint16(data)) + 269, data[2:], nil
+	case 15:
+		return 0, nil, errCoapFormat
+	}
+
+	return v, data, nil
+}
+
+// coapConn is UDP endpoint exchanging messages with single CoAP server. Responses and notifications
+// are matched to requests by token, confirmable requests are retransmitted until acknowledged.
+type coapConn struct {
+	conn      *net.UDPConn
+	l         *sync.Mutex
+	messageID uint16
+	exchanges map[string]chan *coapMessage
+	acks      map[uint16]chan bool
+	quit      chan struct{}
+}
+
+func dialCoap(addr string) (*coapConn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &coapConn{
+		conn:      conn,
+		l:         &sync.Mutex{},
+		messageID: uint16(time.Now().UnixNano()),
+		exchanges: make(map[string]chan *coapMessage),
+		acks:      make(map[uint16]chan bool),
+		quit:      make(chan struct{}),
+	}
+
+	go c.read()
+
+	return c, nil
+}
+
+func (c *coapConn) Close() error {
+	close(c.quit)
+	return c.conn.Close()
+}
+
+func (c *coapConn) read() {
+	buf := make([]byte, COAP_MAX_MESSAGE)
+
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			select {
+			case <-c.quit:
+				return
+			default:
+				log.Info("CoAP: read failed -> ", err)
+				continue
+			}
+		}
+
+		m, err := unmarshalCoap(buf[:n])
+		if err != nil {
+			log.Info("CoAP: ", err)
+			continue
+		}
+
+		c.dispatch(m)
+	}
+}
+
+func (c *coapConn) dispatch(m *coapMessage) {
+	c.l.Lock()
+	if ack, ok := c.acks[m.messageID]; ok && (m.typ == COAP_ACK || m.typ == COAP_RST) {
+		delete(c.acks, m.messageID)
+		ack <- m.typ == COAP_ACK
+	}
+	exchange, ok := c.exchanges[string(m.token)]
+	c.l.Unlock()
+
+	//empty ACK only stops retransmission, response follows separately
+	if m.code == 0 {
+		return
+	}
+
+	if !ok {
+		//nobody is interested, e.g. notification of cancelled observation
+		if m.typ == COAP_CON || m.typ == COAP_NON {
+			c.send(&coapMessage{typ: COAP_RST, messageID: m.messageID})
+		}
+		return
+	}
+
+	if m.typ == COAP_CON {
+		c.send(&coapMessage{typ: COAP_ACK, messageID: m.messageID})
+	}
+
+	select {
+	case exchange <- m:
+	default:
+		log.Info("CoAP: response dropped, exchange not keeping up -> ", CoapCode(m.code))
+	}
+}
+
+func (c *coapConn) send(m *coapMessage) error {
+	_, err := c.conn.Write(m.marshal())
+	return err
+}
+
+func (c *coapConn) nextID() uint16 {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.messageID++
+	return c.messageID
+}
+
+// open registers exchange of new token, responses are delivered to returned channel until close
+func (c *coapConn) open() ([]byte, chan *coapMessage) {
+	token := make([]byte, 8)
+	rand.Read(token)
+	exchange := make(chan *coapMessage, 16)
+
+	c.l.Lock()
+	c.exchanges[string(token)] = exchange
+	c.l.Unlock()
+
+	return token, exchange
+}
+
+func (c *coapConn) close(token []byte) {
+	c.l.Lock()
+	delete(c.exchanges, string(token))
+	c.l.Unlock()
+}
+
+// transmit sends confirmable message and retransmits it with exponential backoff until it is acknowledged
+// or done is closed
+func (c *coapConn) transmit(m *coapMessage, done <-chan struct{}) error {
+	m.typ = COAP_CON
+	m.messageID = c.nextID()
+	ack := make(chan bool, 1)
+
+	c.l.Lock()
+	c.acks[m.messageID] = ack
+	c.l.Unlock()
+
+	defer func() {
+		c.l.Lock()
+		delete(c.acks, m.messageID)
+		c.l.Unlock()
+	}()
+
+	timeout := COAP_ACK_TIMEOUT
+	for i := 0; i <= COAP_MAX_RETRANSMIT; i++ {
+		if err := c.send(m); err != nil {
+			return err
+		}
+
+		select {
+		case acked := <-ack:
+			if !acked {
+				return ErrCoapReset
+			}
+			return nil
+		case <-done:
+			return nil
+		case <-c.quit:
+			return ErrCoapTimeout
+		case <-time.After(timeout):
+			timeout *= 2
+		}
+	}
+
+	return ErrCoapTimeout
+}
+
+// exchange sends request and waits for its response
+func (c *coapConn) exchange(m *coapMessage) (*coapMessage, error) {
+	token, responses := c.open()
+	defer c.close(token)
+	m.token = token
+
+	done := make(chan struct{})
+	defer close(done)
+
+	errs := make(chan error, 1)
+	go func() { errs <- c.transmit(m, done) }()
+
+	timeout := time.After(COAP_EXCHANGE)
+	for {
+		select {
+		case rs := <-responses:
+			return rs, nil
+		case err := <-errs:
+			if err != nil {
+				return nil, err
+			}
+		case <-timeout:
+			return nil, ErrCoapTimeout
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCaseCoapRoundTrip(t *testing.T) {
+	long := []byte(strings.Repeat("x", 300))
+
+	cases := []*coapMessage{
+		{typ: COAP_ACK, messageID: 7},
+		{typ: COAP_CON, code: COAP_GET, messageID: 0xbeef, token: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			options: []coapOption{{COAP_OPTION_OBSERVE, []byte{}}, {COAP_OPTION_URI_PATH, []byte("lamp")}, {COAP_OPTION_URI_PATH, []byte("on")}}},
+		//deltas and lengths over 12 and over 268 use extended bytes
+		{typ: COAP_NON, code: COAP_PUT, messageID: 1, token: []byte{9},
+			options: []coapOption{{COAP_OPTION_URI_PATH, []byte("abcdefghijklmn")}, {60, long}, {2048, []byte{1}}},
+			payload: []byte(`{"on": true}`)},
+		{typ: COAP_CON, code: 2<<5 | 5, messageID: 2, payload: long},
+	}
+
+	for i, m := range cases {
+		name := fmt.Sprint("message ", i)
+		data := m.marshal()
+
+		decoded, err := unmarshalCoap(data)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		Equals(name+" type", t, m.typ, decoded.typ)
+		Equals(name+" code", t, m.code, decoded.code)
+		Equals(name+" ID", t, m.messageID, decoded.messageID)
+		Equals(name+" token", t, true, bytes.Equal(m.token, decoded.token))
+		Equals(name+" payload", t, true, bytes.Equal(m.payload, decoded.payload))
+		Equals(name+" options", t, len(m.options), len(decoded.options))
+		for j := 0; j < len(m.options) && j < len(decoded.options); j++ {
+			Equals(fmt.Sprint(name, " option ", j), t, m.options[j].number, decoded.options[j].number)
+			Equals(fmt.Sprint(name, " option ", j, " value"), t, true, bytes.Equal(m.options[j].value, decoded.options[j].value))
+		}
+
+		Equals(name+" encoded again", t, true, bytes.Equal(data, decoded.marshal()))
+	}
+}
+
+func TestCaseCoapOptionEncoding(t *testing.T) {
+	m := &coapMessage{typ: COAP_CON, code: COAP_GET, token: []byte{0xaa}}
+	m.addUint(COAP_OPTION_URI_QUERY, 0)
+	m.addUint(COAP_OPTION_CONTENT_FORMAT, COAP_FORMAT_JSON)
+	m.options = append(m.options, coapOption{300, []byte("v")})
+
+	//options are sorted, header 0x41 is version 1, CON, token of 1 byte
+	expected := []byte{0x41, COAP_GET, 0, 0, 0xaa,
+		0xc1, COAP_FORMAT_JSON, //content format 12, length 1
+		0x30,               //uri query 15, delta 3, zero is empty
+		0xe1, 0, 285 - 269, //delta 285 is 14 with the rest in two extended bytes
+		'v'}
+	Equals("Encoded", t, fmt.Sprint(expected), fmt.Sprint(m.marshal()))
+
+	value, _ := m.option(COAP_OPTION_CONTENT_FORMAT)
+	Equals("Uint option", t, uint32(COAP_FORMAT_JSON), optionUint(value))
+}
+
+func TestCaseCoapMalformed(t *testing.T) {
+	cases := [][]byte{
+		{},
+		{0x40, 1},
+		{0x80, 1, 0, 0},                 //version 2
+		{0x49, 1, 0, 0},                 //token over 8 bytes
+		{0x42, 1, 0, 0, 1},              //token cut
+		{0x40, 1, 0, 0, 0xd1},           //extended delta missing
+		{0x40, 1, 0, 0, 0xe1, 0},        //extended delta cut
+		{0x40, 1, 0, 0, 0xf0},           //reserved delta
+		{0x40, 1, 0, 0, 0xb3, 'a', 'b'}, //value cut
+	}
+
+	for _, data := range cases {
+		_, err := unmarshalCoap(data)
+		Equals(fmt.Sprint(data), t, errCoapFormat, err)
+	}
+}
+
+func TestCaseCoapCode(t *testing.T) {
+	Equals("Content", t, "2.05", CoapCode(2<<5|5))
+	Equals("Not found", t, "4.04", CoapCode(4<<5|4))
+	Equals("Gateway timeout", t, "5.04", CoapCode(5<<5|4))
+}
+
+func TestCaseObserveNewer(t *testing.T) {
+	Equals("Newer", t, true, observeNewer(5, 6))
+	Equals("Older", t, false, observeNewer(6, 5))
+	Equals("Same", t, false, observeNewer(6, 6))
+	Equals("Wrapped", t, true, observeNewer(1<<24-1, 2))
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// DescriptionListener is called when client notices changed ThingDescription
+type DescriptionListener func(td *model.ThingDescription)
+
+// OnDescriptionChange registers listener of ThingDescription changes noticed by refresh
+func (c *HttpClient) OnDescriptionChange(listener DescriptionListener) *HttpClient {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.tdListeners = append(c.tdListeners, listener)
+	return c
+}
+
+// GetDescription returns cached ThingDescription. Description is fetched again when Cache-Control
+// of the last response says it is stale, without Cache-Control it is kept until RefreshDescription.
+func (c *HttpClient) GetDescription() (*model.ThingDescription, error) {
+	c.l.Lock()
+
+	if c.td != nil && (c.tdExpires.IsZero() || time.Now().Before(c.tdExpires)) {
+		td := c.td
+		c.l.Unlock()
+		return td, nil
+	}
+
+	td, changed, err := c.fetchDescription()
+	c.l.Unlock()
+
+	if changed {
+		c.notifyDescription(td)
+	}
+
+	return td, err
+}
+
+// RefreshDescription revalidates cached ThingDescription with the Thing, listeners are notified when it changed
+func (c *HttpClient) RefreshDescription() (*model.ThingDescription, error) {
+	c.l.Lock()
+	td, changed, err := c.fetchDescription()
+	c.l.Unlock()
+
+	if changed {
+		c.notifyDescription(td)
+	}
+
+	return td, err
+}
+
+// WatchDescription refreshes ThingDescription every interval until returned channel is closed
+func (c *HttpClient) WatchDescription(interval time.Duration) chan<- struct{} {
+	quit := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := c.RefreshDescription(); err != nil {
+					log.Info("HttpClient: description refresh failed -> ", err)
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return quit
+}
+
+// SubscribeDescription listens to lifecycle events of frontend and refreshes ThingDescription when
+// the Thing is rebound, so consumer notices interface change without polling
+func (c *HttpClient) SubscribeDescription() (Subscription, error) {
+	conn, err := c.dial(c.lifecycleHref())
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer conn.Close()
+
+		for {
+			e := &struct {
+				Type    string `json:"type"`
+				CtxPath string `json:"ctxPath"`
+			}{}
+
+			if err := conn.ReadJSON(e); err != nil {
+				log.Info("HttpClient: description subscription closed -> ", err)
+				return
+			}
+
+			if e.CtxPath == c.base.Path && (e.Type == server.THING_UPDATED || e.Type == server.THING_CREATED) {
+				if _, err := c.RefreshDescription(); err != nil {
+					log.Info("HttpClient: description refresh failed -> ", err)
+				}
+			}
+		}
+	}()
+
+	return conn, nil
+}
+
+// lifecycleHref is lifecycle stream of tenant the Thing belongs to, /t/{tenant}/things/ws, or /things/ws
+func (c *HttpClient) lifecycleHref() string {
+	root := "/things/ws"
+	parts := strings.SplitN(strings.TrimPrefix(c.base.Path, "/"), "/", 3)
+
+	if len(parts) == 3 && parts[0] == "t" {
+		root = str.Concat("/t/", parts[1], root)
+	}
+
+	u := *c.base
+	u.Path = root
+
+	return u.String()
+}
+
+// fetchDescription does conditional GET of ThingDescription, caller holds lock. Changed is
+// reported when previously cached description was replaced.
+func (c *HttpClient) fetchDescription() (*model.ThingDescription, bool, error) {
+	href := c.base.String() + "/description"
+
+	rq, err := http.NewRequest("GET", href, nil)
+	if err != nil {
+		return c.td, false, err
+	}
+
+	for k, v := range c.headers {
+		rq.Header[k] = v
+	}
+	if c.td != nil && c.tdETag != "" {
+		rq.Header.Set("If-None-Match", c.tdETag)
+	}
+
+	rs, err := c.client.Do(rq)
+	if err != nil {
+		return c.td, false, err
+	}
+	defer rs.Body.Close()
+
+	if rs.StatusCode == http.StatusNotModified && c.td != nil {
+		c.tdExpires = expires(rs.Header)
+		return c.td, false, nil
+	}
+
+	data, err := ioutil.ReadAll(rs.Body)
+	if err != nil {
+		return c.td, false, err
+	}
+
+	if rs.StatusCode != http.StatusOK {
+		return c.td, false, &HttpError{
+			StatusCode: rs.StatusCode,
+			Message:    str.Concat("GET ", href, ": ", rs.Status, " ", strings.TrimSpace(string(data))),
+			Body:       data,
+		}
+	}
+
+	td := &model.ThingDescription{}
+	if err = json.Unmarshal(data, td); err != nil {
+		return c.td, false, err
+	}
+
+	etag := rs.Header.Get("ETag")
+	changed := c.td != nil && (etag == "" || etag != c.tdETag)
+
+	c.td = td
+	c.tdETag = etag
+	c.tdExpires = expires(rs.Header)
+
+	return td, changed, nil
+}
+
+func (c *HttpClient) notifyDescription(td *model.ThingDescription) {
+	c.l.Lock()
+	listeners := append([]DescriptionListener(nil), c.tdListeners...)
+	c.l.Unlock()
+
+	for _, listener := range listeners {
+		listener(td)
+	}
+}
+
+// expires evaluates Cache-Control of response. Zero time means description is cached until refreshed,
+// no-cache, no-store and max-age=0 make it stale immediately.
+func expires(h http.Header) time.Time {
+	cc := h.Get("Cache-Control")
+
+	if cc == "" {
+		return time.Time{}
+	}
+
+	now := time.Now()
+
+	for _, directive := range strings.Split(cc, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return now
+		case strings.HasPrefix(directive, "max-age="):
+			if age, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return now.Add(time.Duration(age) * time.Second)
+			}
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,310 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+const (
+	// WELL_KNOWN_WOT is URI probed on hosts for self-description or Thing listing
+	WELL_KNOWN_WOT = "/.well-known/wot"
+	// DNSSD_SERVICE is DNS-SD service of WoT Things and Directories
+	DNSSD_SERVICE = "wot"
+)
+
+// DiscoveryOptions selects discovery mechanisms and filters discovered Things. Directory is queried for
+// Thing listing, Hosts are probed at /.well-known/wot and Domains are browsed for _wot._tcp SRV records.
+// Filters are combined, Thing must match all of them.
+type DiscoveryOptions struct {
+	Directory  string
+	Hosts      []string
+	Domains    []string
+	Headers    http.Header
+	Type       string
+	Title      string
+	Properties []string
+}
+
+// Discovered is ThingDescription found by discovery together with URI the Thing was found at,
+// Href can be used to create HttpClient
+type Discovered struct {
+	Href string
+	TD   *model.ThingDescription
+}
+
+// Client creates HttpClient of discovered Thing
+func (d *Discovered) Client() (*HttpClient, error) {
+	return NewHttpClient(d.Href)
+}
+
+// Discover queries all configured sources concurrently and streams matching Things. Each Thing is
+// delivered once, channel is closed when all sources are exhausted or ctx is cancelled.
+func Discover(ctx context.Context, opts *DiscoveryOptions) <-chan *Discovered {
+	d := &discovery{
+		ctx:    ctx,
+		opts:   opts,
+		client: sharedClient(),
+		out:    make(chan *Discovered),
+		seen:   make(map[string]bool),
+		l:      &sync.Mutex{},
+		wg:     &sync.WaitGroup{},
+	}
+
+	if opts.Directory != "" {
+		d.run(func() { d.listing(opts.Directory) })
+	}
+
+	for _, host := range opts.Hosts {
+		host := strings.TrimSuffix(host, "/")
+		d.run(func() { d.wellKnown(host) })
+	}
+
+	for _, domain := range opts.Domains {
+		domain := domain
+		d.run(func() { d.browse(domain) })
+	}
+
+	go func() {
+		d.wg.Wait()
+		close(d.out)
+	}()
+
+	return d.out
+}
+
+type discovery struct {
+	ctx    context.Context
+	opts   *DiscoveryOptions
+	client *http.Client
+	out    chan *Discovered
+	seen   map[string]bool
+	l      *sync.Mutex
+	wg     *sync.WaitGroup
+}
+
+func (d *discovery) run(source func()) {
+	d.wg.Add(1)
+
+	go func() {
+		defer d.wg.Done()
+		source()
+	}()
+}
+
+// wellKnown probes host for self-description of single Thing or listing of Things it exposes
+func (d *discovery) wellKnown(host string) {
+	d.listing(str.Concat(host, WELL_KNOWN_WOT))
+}
+
+// browse looks up _wot._tcp SRV records of domain. TXT record "td" is path of ThingDescription or
+// Thing listing, /.well-known/wot is probed when it is missing.
+func (d *discovery) browse(domain string) {
+	_, srvs, err := net.DefaultResolver.LookupSRV(d.ctx, DNSSD_SERVICE, "tcp", domain)
+	if err != nil {
+		log.Info("Discovery: DNS-SD lookup failed -> ", err)
+		return
+	}
+
+	path := WELL_KNOWN_WOT
+	scheme := "http"
+
+	txts, _ := net.DefaultResolver.LookupTXT(d.ctx, str.Concat("_", DNSSD_SERVICE, "._tcp.", domain))
+	for _, txt := range txts {
+		kv := strings.SplitN(txt, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "td":
+			path = kv[1]
+		case "scheme":
+			scheme = kv[1]
+		}
+	}
+
+	for _, srv := range srvs {
+		host := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		d.listing(str.Concat(scheme, "://", host, path))
+	}
+}
+
+// listing fetches href which is either ThingDescription, array of ThingDescriptions or array
+// of Things listed by frontend /things resource
+func (d *discovery) listing(href string) {
+	data, err := d.get(href)
+	if err != nil {
+		log.Info("Discovery: ", href, " -> ", err)
+		return
+	}
+
+	data = []byte(strings.TrimSpace(string(data)))
+	if len(data) > 0 && data[0] == '{' {
+		td := &model.ThingDescription{}
+		if err := json.Unmarshal(data, td); err != nil {
+			log.Info("Discovery: ", href, " -> ", err)
+			return
+		}
+
+		d.found(thingHref(href, td), td)
+		return
+	}
+
+	entries := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		log.Info("Discovery: ", href, " -> ", err)
+		return
+	}
+
+	for _, entry := range entries {
+		info := &ThingEntry{}
+		if err := json.Unmarshal(entry, info); err != nil {
+			continue
+		}
+
+		if info.CtxPath != "" {
+			d.describe(href, info.CtxPath)
+			continue
+		}
+
+		td := &model.ThingDescription{}
+		if err := json.Unmarshal(entry, td); err == nil {
+			d.found(thingHref(href, td), td)
+		}
+	}
+}
+
+// ThingEntry is item of Thing listing referring to Thing by context path instead of embedding its description
+type ThingEntry struct {
+	CtxPath string `json:"ctxPath"`
+}
+
+func (d *discovery) describe(listing, ctxPath string) {
+	u, err := url.Parse(listing)
+	if err != nil {
+		return
+	}
+
+	u.Path = ctxPath
+	u.RawQuery = ""
+	href := u.String()
+
+	data, err := d.get(str.Concat(href, "/description"))
+	if err != nil {
+		log.Info("Discovery: ", href, " -> ", err)
+		return
+	}
+
+	td := &model.ThingDescription{}
+	if err := json.Unmarshal(data, td); err != nil {
+		log.Info("Discovery: ", href, " -> ", err)
+		return
+	}
+
+	d.found(href, td)
+}
+
+func (d *discovery) get(href string) ([]byte, error) {
+	rq, err := http.NewRequest("GET", href, nil)
+	if err != nil {
+		return nil, err
+	}
+	rq = rq.WithContext(d.ctx)
+
+	for k, v := range d.opts.Headers {
+		rq.Header[k] = v
+	}
+	rq.Header.Set("Accept", "application/json")
+
+	rs, err := d.client.Do(rq)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Body.Close()
+
+	data, err := ioutil.ReadAll(rs.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if rs.StatusCode != http.StatusOK {
+		return nil, &HttpError{
+			StatusCode: rs.StatusCode,
+			Message:    str.Concat("GET ", href, ": ", rs.Status),
+			Body:       data,
+		}
+	}
+
+	return data, nil
+}
+
+func (d *discovery) found(href string, td *model.ThingDescription) {
+	if href == "" || !d.matches(td) {
+		return
+	}
+
+	d.l.Lock()
+	seen := d.seen[href]
+	d.seen[href] = true
+	d.l.Unlock()
+
+	if seen {
+		return
+	}
+
+	select {
+	case d.out <- &Discovered{Href: href, TD: td}:
+	case <-d.ctx.Done():
+	}
+}
+
+func (d *discovery) matches(td *model.ThingDescription) bool {
+	if d.opts.Type != "" && !td.HasType(d.opts.Type) {
+		return false
+	}
+
+	if d.opts.Title != "" && !strings.EqualFold(td.Name, d.opts.Title) {
+		return false
+	}
+
+	for _, name := range d.opts.Properties {
+		if _, err := findProperty(td, name); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// thingHref is advertised Thing URI mapped on host it was discovered at
+func thingHref(source string, td *model.ThingDescription) string {
+	if len(td.Uris) == 0 {
+		return ""
+	}
+
+	s, err := url.Parse(source)
+	if err != nil {
+		return td.Uris[0]
+	}
+
+	u, err := url.Parse(td.Uris[0])
+	if err != nil {
+		return td.Uris[0]
+	}
+
+	u.Scheme = s.Scheme
+	u.Host = s.Host
+
+	return u.String()
+}
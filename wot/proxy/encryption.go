@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// Keys are keys shared with devices encrypting values end-to-end, by key id
+type Keys map[string][]byte
+
+// DecryptingClient decrypts values of properties and events encrypted end-to-end by device (see
+// model.Encryption) and encrypts values written to encrypted properties, so gateways between device
+// and consumer forward only ciphertext. Other interactions are passed to wrapped client as they are.
+type DecryptingClient struct {
+	Client
+	keys Keys
+}
+
+func NewDecryptingClient(c Client, keys Keys) *DecryptingClient {
+	return &DecryptingClient{
+		Client: c,
+		keys:   keys,
+	}
+}
+
+func (c *DecryptingClient) GetProperty(propertyName string) (interface{}, error) {
+	value, err := c.Client.GetProperty(propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := c.propertyEncryption(propertyName)
+	if err != nil || enc == nil {
+		return value, err
+	}
+
+	return c.decrypt(enc, value)
+}
+
+func (c *DecryptingClient) SetProperty(propertyName string, newValue interface{}) error {
+	enc, err := c.propertyEncryption(propertyName)
+	if err != nil {
+		return err
+	}
+
+	if enc != nil {
+		if newValue, err = c.encrypt(enc, newValue); err != nil {
+			return err
+		}
+	}
+
+	return c.Client.SetProperty(propertyName, newValue)
+}
+
+// AddListener decrypts data of encrypted events and values of encrypted properties in property changes,
+// events which cannot be decrypted are logged and dropped
+func (c *DecryptingClient) AddListener(eventName string, listener func(*server.Event)) (Subscription, error) {
+	td, err := c.Client.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Client.AddListener(eventName, func(e *server.Event) {
+		data, err := c.decryptEvent(td, eventName, e.Data)
+		if err != nil {
+			log.Error("Client: ", c.Name(), " event ", eventName, " not decrypted -> ", err)
+			return
+		}
+
+		listener(&server.Event{
+			Event:     e.Event,
+			Timestamp: e.Timestamp,
+			Data:      data,
+		})
+	})
+}
+
+func (c *DecryptingClient) decryptEvent(td *model.ThingDescription, eventName string, data interface{}) (interface{}, error) {
+	if eventName == server.PROPERTY_CHANGE_EVENT {
+		change, ok := asPropertyChange(data)
+		if !ok {
+			return data, nil
+		}
+
+		prop, err := findProperty(td, change.Name)
+		if err != nil || prop.Encryption == nil {
+			return change, nil
+		}
+
+		value, err := c.decrypt(prop.Encryption, change.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return &server.PropertyChange{Name: change.Name, Value: value}, nil
+	}
+
+	event, err := findEvent(td, eventName)
+	if err != nil || event.Encryption == nil {
+		return data, nil
+	}
+
+	return c.decrypt(event.Encryption, data)
+}
+
+func (c *DecryptingClient) propertyEncryption(propertyName string) (*model.Encryption, error) {
+	td, err := c.Client.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	prop, err := findProperty(td, propertyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return prop.Encryption, nil
+}
+
+// decrypt decodes JSON value encrypted by key named in JWE header, or by key of interaction
+func (c *DecryptingClient) decrypt(enc *model.Encryption, value interface{}) (interface{}, error) {
+	token, ok := value.(string)
+	if !ok {
+		return nil, errors.New("Encrypted value is not JWE")
+	}
+
+	header, err := jose.ParseHeader(token)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := header.Kid
+	if kid == "" {
+		kid = enc.KeyID
+	}
+
+	key, ok := c.keys[kid]
+	if !ok {
+		return nil, errors.New(str.Concat("Unknown key: ", kid))
+	}
+
+	plaintext, err := jose.Decrypt(key, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var decrypted interface{}
+	err = json.Unmarshal(plaintext, &decrypted)
+
+	return decrypted, err
+}
+
+func (c *DecryptingClient) encrypt(enc *model.Encryption, value interface{}) (interface{}, error) {
+	key, ok := c.keys[enc.KeyID]
+	if !ok {
+		return nil, errors.New(str.Concat("Unknown key: ", enc.KeyID))
+	}
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return jose.Encrypt(key, enc.KeyID, plaintext)
+}
+
+func asPropertyChange(data interface{}) (*server.PropertyChange, bool) {
+	switch change := data.(type) {
+	case *server.PropertyChange:
+		return change, true
+	case map[string]interface{}:
+		name, ok := change["name"].(string)
+		return &server.PropertyChange{Name: name, Value: change["value"]}, ok
+	}
+
+	return nil, false
+}
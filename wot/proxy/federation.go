@@ -0,0 +1,235 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// FederationOptions restricts and tunes re-exposure of remote Thing. Expose lists interaction names
+// exposed locally, all interactions are exposed when empty. ReadOnly exposes properties as not
+// writable and hides actions. Property values are served from cache for CacheTTL, 0 reads remote
+// Thing on every read.
+type FederationOptions struct {
+	Expose   []string
+	ReadOnly bool
+	CacheTTL time.Duration
+}
+
+// Federated is local WotServer serving remote Thing through its client, bind Server to frontends
+// to re-expose the Thing
+type Federated struct {
+	Server *server.WotServer
+	client Client
+	opts   *FederationOptions
+	l      *sync.Mutex
+	cache  map[string]*cachedValue
+	subs   []Subscription
+}
+
+type cachedValue struct {
+	value interface{}
+	read  time.Time
+}
+
+// Federate consumes Thing of client and creates WotServer forwarding interactions to it. Events of
+// remote Thing are subscribed immediately and emitted by local Server until Close.
+func Federate(client Client, opts *FederationOptions) (*Federated, error) {
+	if opts == nil {
+		opts = &FederationOptions{}
+	}
+
+	remote, err := client.GetDescription()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Federated{
+		client: client,
+		opts:   opts,
+		l:      &sync.Mutex{},
+		cache:  make(map[string]*cachedValue),
+		subs:   make([]Subscription, 0),
+	}
+
+	td, err := f.localDescription(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Server = server.CreateFromDescription(td)
+
+	for _, p := range td.Properties {
+		name := p.Name
+		f.Server.OnGetProperty(name, func() interface{} {
+			return f.getProperty(name)
+		})
+
+		if p.Writable {
+			f.Server.OnUpdateProperty(name, func(value interface{}) {
+				f.setProperty(name, value)
+			})
+		}
+	}
+
+	for _, a := range td.Actions {
+		name := a.Name
+		f.Server.OnInvokeAction(name, func(arg interface{}, ph async.ProgressHandler) interface{} {
+			return f.invokeAction(name, arg, ph)
+		})
+	}
+
+	for _, e := range td.Events {
+		name := e.Name
+		sub, err := client.AddListener(name, func(event *server.Event) {
+			f.Server.EmitEvent(name, event.Data)
+		})
+
+		if err != nil {
+			log.Error("Federation: ", remote.Name, " event ", name, " not subscribed -> ", err)
+			continue
+		}
+
+		f.subs = append(f.subs, sub)
+	}
+
+	return f, nil
+}
+
+// Close unsubscribes events of remote Thing
+func (f *Federated) Close() error {
+	f.l.Lock()
+	subs := f.subs
+	f.subs = nil
+	f.l.Unlock()
+
+	var err error
+	for _, sub := range subs {
+		if e := sub.Close(); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// localDescription copies remote description keeping exposed interactions, hrefs are replaced by
+// interaction names so frontends place interactions under the local context path
+func (f *Federated) localDescription(remote *model.ThingDescription) (*model.ThingDescription, error) {
+	data, err := json.Marshal(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := &model.ThingDescription{}
+	if err = json.Unmarshal(data, copied); err != nil {
+		return nil, err
+	}
+
+	td := *copied
+	td.Uris = make([]string, 0)
+	td.Properties = make([]model.Property, 0)
+	td.Actions = make([]model.Action, 0)
+	td.Events = make([]model.Event, 0)
+
+	for _, p := range copied.Properties {
+		if f.exposed(p.Name) {
+			p.Hrefs = []string{p.Name}
+			p.Writable = p.Writable && !f.opts.ReadOnly
+			td.Properties = append(td.Properties, p)
+		}
+	}
+
+	for _, a := range copied.Actions {
+		if f.exposed(a.Name) && !f.opts.ReadOnly {
+			a.Hrefs = []string{a.Name}
+			td.Actions = append(td.Actions, a)
+		}
+	}
+
+	for _, e := range copied.Events {
+		if f.exposed(e.Name) {
+			e.Hrefs = []string{e.Name}
+			td.Events = append(td.Events, e)
+		}
+	}
+
+	return &td, nil
+}
+
+func (f *Federated) exposed(name string) bool {
+	return len(f.opts.Expose) == 0 || contains(f.opts.Expose, name)
+}
+
+func (f *Federated) getProperty(name string) interface{} {
+	if f.opts.CacheTTL > 0 {
+		f.l.Lock()
+		cached, ok := f.cache[name]
+		f.l.Unlock()
+
+		if ok && time.Since(cached.read) < f.opts.CacheTTL {
+			return cached.value
+		}
+	}
+
+	value, err := f.client.GetProperty(name)
+	if err != nil {
+		return err
+	}
+
+	if f.opts.CacheTTL > 0 {
+		f.l.Lock()
+		f.cache[name] = &cachedValue{value: value, read: time.Now()}
+		f.l.Unlock()
+	}
+
+	return value
+}
+
+func (f *Federated) setProperty(name string, value interface{}) {
+	f.l.Lock()
+	delete(f.cache, name)
+	f.l.Unlock()
+
+	if err := f.client.SetProperty(name, value); err != nil {
+		log.Error("Federation: ", f.client.Name(), " property ", name, " not written -> ", err)
+	}
+}
+
+// invokeAction invokes remote action and follows its task, progress of remote task is reported
+// to local task
+func (f *Federated) invokeAction(name string, arg interface{}, ph async.ProgressHandler) interface{} {
+	task, err := f.client.InvokeAction(name, arg)
+	if err != nil {
+		return err
+	}
+
+	var last *server.TaskStatus
+	err = task.Watch(func(status *server.TaskStatus) {
+		last = status
+		if status.Status == server.TASK_RUNNING {
+			ph.Update(status.Data)
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if last == nil {
+		return errors.New("Remote task finished without status")
+	}
+
+	if last.Status == server.TASK_FAILED {
+		return errors.New(fmt.Sprint(last.Data))
+	}
+
+	return last.Data
+}
@@ -0,0 +1,198 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	RECONNECT_MIN = 500 * time.Millisecond
+	RECONNECT_MAX = 30 * time.Second
+)
+
+type ConnState int
+
+const (
+	CONN_CONNECTED ConnState = iota
+	CONN_DISCONNECTED
+	CONN_RECONNECTING
+	CONN_CLOSED
+)
+
+// ConnStateListener is notified about connection state of event listener, err is set for
+// disconnects and failed reconnects
+type ConnStateListener func(eventName string, state ConnState, err error)
+
+// wireEvent is event as sent by acknowledged subscription, Seq is 0 when server does not support it
+type wireEvent struct {
+	Seq uint64 `json:"seq"`
+	server.Event
+}
+
+// wsListener keeps event listener connected. Dropped WebSocket is reconnected with exponential backoff,
+// events missed meanwhile are redelivered by server from last acknowledged sequence number. Subscription
+// lost by server, e.g. on restart, is created again.
+type wsListener struct {
+	client    *HttpClient
+	eventName string
+	eventHref string
+	listener  func(*server.Event)
+	l         *sync.Mutex
+	ws        string
+	conn      *websocket.Conn
+	lastSeq   uint64
+	quit      chan struct{}
+	once      *sync.Once
+}
+
+func (wl *wsListener) subscribe() error {
+	ls := &links{}
+	if err := wl.client.do("POST", str.Concat(wl.eventHref, "?ack=true"), nil, ls); err != nil {
+		return err
+	}
+
+	wl.ws = ls.href("websocket")
+	wl.lastSeq = 0
+
+	return nil
+}
+
+// connect dials subscription WebSocket, server redelivers events after lastSeq
+func (wl *wsListener) connect() (int, error) {
+	href := wl.ws
+	if wl.lastSeq > 0 {
+		href = str.Concat(href, "?ack=", strconv.FormatUint(wl.lastSeq, 10))
+	}
+
+	conn, rs, err := wl.client.dialResponse(href)
+	if err != nil {
+		status := 0
+		if rs != nil {
+			status = rs.StatusCode
+		}
+		return status, err
+	}
+
+	wl.l.Lock()
+	defer wl.l.Unlock()
+
+	select {
+	case <-wl.quit:
+		conn.Close()
+	default:
+		wl.conn = conn
+	}
+
+	return http.StatusSwitchingProtocols, nil
+}
+
+func (wl *wsListener) run() {
+	backoff := wl.client.reconnectMin
+
+	for {
+		err := wl.read()
+
+		if wl.closed() {
+			wl.client.notifyState(wl.eventName, CONN_CLOSED, nil)
+			return
+		}
+
+		log.Info("HttpClient: event listener disconnected -> ", wl.eventName, ": ", err)
+		wl.client.notifyState(wl.eventName, CONN_DISCONNECTED, err)
+
+		for {
+			select {
+			case <-time.After(backoff):
+			case <-wl.quit:
+				wl.client.notifyState(wl.eventName, CONN_CLOSED, nil)
+				return
+			}
+
+			if backoff *= 2; backoff > wl.client.reconnectMax {
+				backoff = wl.client.reconnectMax
+			}
+
+			wl.client.notifyState(wl.eventName, CONN_RECONNECTING, nil)
+
+			status, err := wl.connect()
+			if status == http.StatusNotFound {
+				//subscription is gone, events since last sequence are lost
+				if err = wl.subscribe(); err == nil {
+					status, err = wl.connect()
+				}
+			}
+
+			if err == nil {
+				break
+			}
+
+			log.Info("HttpClient: event listener reconnect failed -> ", wl.eventName, ": ", err)
+			wl.client.notifyState(wl.eventName, CONN_DISCONNECTED, err)
+		}
+
+		backoff = wl.client.reconnectMin
+		wl.client.notifyState(wl.eventName, CONN_CONNECTED, nil)
+	}
+}
+
+// read delivers events until connection fails, events are acknowledged as they are delivered
+func (wl *wsListener) read() error {
+	wl.l.Lock()
+	conn := wl.conn
+	wl.l.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	defer conn.Close()
+
+	for {
+		e := &wireEvent{}
+		if err := conn.ReadJSON(e); err != nil {
+			return err
+		}
+
+		//redelivered event already seen before reconnect
+		if e.Seq > 0 && e.Seq <= wl.lastSeq {
+			continue
+		}
+
+		wl.listener(&e.Event)
+
+		if e.Seq > 0 {
+			wl.lastSeq = e.Seq
+			conn.WriteJSON(map[string]uint64{"ack": e.Seq})
+		}
+	}
+}
+
+func (wl *wsListener) closed() bool {
+	select {
+	case <-wl.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (wl *wsListener) Close() error {
+	wl.once.Do(func() {
+		close(wl.quit)
+	})
+
+	wl.l.Lock()
+	defer wl.l.Unlock()
+
+	if wl.conn != nil {
+		return wl.conn.Close()
+	}
+
+	return nil
+}
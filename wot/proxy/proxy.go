@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// ----- AS DEFINED BY WEB IDL
+// https://github.com/w3c/wot/tree/master/proposals/restructured-scripting-api#consumedthing
+
+// Client is consumer side counterpart of server.WotServer. It allows to interact with remote Thing
+// regardless of protocol used to expose it.
+type Client interface {
+	Name() string
+	GetDescription() (*model.ThingDescription, error)
+	GetProperty(propertyName string) (interface{}, error)
+	SetProperty(propertyName string, newValue interface{}) error
+	InvokeAction(actionName string, arg interface{}) (Task, error)
+	AddListener(eventName string, listener func(*server.Event)) (Subscription, error)
+}
+
+// Task is handle of invoked action
+type Task interface {
+	Status() (*server.TaskStatus, error)
+	// Watch calls listener for every task status change until task is done or failed
+	Watch(listener func(*server.TaskStatus)) error
+}
+
+// Subscription is handle of event listener registered on remote Thing
+type Subscription interface {
+	Close() error
+}
+
+// Consume creates client of Thing described by td, protocol is selected by scheme of its hrefs
+// (http, https, coap, mqtt or mqtts). Relative hrefs are resolved against the first Thing URI.
+func Consume(td *model.ThingDescription, cfg map[string]interface{}) (Client, error) {
+	u, err := url.Parse(firstHref(td))
+	if err != nil {
+		return nil, err
+	}
+
+	root := str.Concat(u.Scheme, "://", u.Host)
+	if len(td.Uris) > 0 {
+		root = td.Uris[0]
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		c, err := NewHttpClient(root)
+		if err != nil {
+			return nil, err
+		}
+		c.td = td
+		return c, nil
+	case "coap":
+		c, err := NewCoapClient(root)
+		if err != nil {
+			return nil, err
+		}
+		c.td = td
+		return c, nil
+	case "mqtt", "mqtts":
+		return NewMqttClient(td, cfg)
+	}
+
+	return nil, errors.New(str.Concat("No supported protocol in description of ", td.Name))
+}
+
+// firstHref is the first absolute interaction href, or the Thing URI when hrefs are relative
+func firstHref(td *model.ThingDescription) string {
+	for _, href := range hrefs(td) {
+		if u, err := url.Parse(href); err == nil && u.IsAbs() {
+			return href
+		}
+	}
+
+	if len(td.Uris) > 0 {
+		return td.Uris[0]
+	}
+
+	return ""
+}
+
+func hrefs(td *model.ThingDescription) []string {
+	all := make([]string, 0)
+
+	for _, p := range td.Properties {
+		all = append(all, p.Hrefs...)
+	}
+	for _, a := range td.Actions {
+		all = append(all, a.Hrefs...)
+	}
+	for _, e := range td.Events {
+		all = append(all, e.Hrefs...)
+	}
+
+	return all
+}
+
+// finishedTask is Task of action answered synchronously by protocol without task resource
+type finishedTask struct {
+	status *server.TaskStatus
+}
+
+func doneTask(actionName string, result interface{}) Task {
+	return &finishedTask{
+		status: &server.TaskStatus{
+			Name:      actionName,
+			Status:    server.TASK_DONE,
+			Timestamp: time.Now(),
+			Data:      result,
+		},
+	}
+}
+
+func (t *finishedTask) Status() (*server.TaskStatus, error) {
+	return t.status, nil
+}
+
+func (t *finishedTask) Watch(listener func(*server.TaskStatus)) error {
+	listener(t.status)
+	return nil
+}
+
+func ErrUnknownInteraction(kind, name string) error {
+	return errors.New(str.Concat("Unknown ", kind, ": ", name))
+}
+
+func findProperty(td *model.ThingDescription, name string) (*model.Property, error) {
+	for i := range td.Properties {
+		if td.Properties[i].Name == name {
+			return &td.Properties[i], nil
+		}
+	}
+
+	return nil, ErrUnknownInteraction("property", name)
+}
+
+func findAction(td *model.ThingDescription, name string) (*model.Action, error) {
+	for i := range td.Actions {
+		if td.Actions[i].Name == name {
+			return &td.Actions[i], nil
+		}
+	}
+
+	return nil, ErrUnknownInteraction("action", name)
+}
+
+func findEvent(td *model.ThingDescription, name string) (*model.Event, error) {
+	for i := range td.Events {
+		if td.Events[i].Name == name {
+			return &td.Events[i], nil
+		}
+	}
+
+	return nil, ErrUnknownInteraction("event", name)
+}
+
+// GetPropertyAs reads remote property and converts its value to T
+func GetPropertyAs[T any](c Client, propertyName string) (T, error) {
+	v, err := c.GetProperty(propertyName)
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return server.As[T](v)
+}
+
+// AddListenerAs registers listener receiving event data converted to T, events with data
+// not convertible to T are dropped
+func AddListenerAs[T any](c Client, eventName string, listener func(T)) (Subscription, error) {
+	return c.AddListener(eventName, func(e *server.Event) {
+		if data, err := server.As[T](e.Data); err == nil {
+			listener(data)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportConfig tunes connection pool of HTTP clients. Zero values keep defaults of DEFAULT_TRANSPORT.
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// RequestTimeout limits whole request including reading body, streams are not limited when 0
+	RequestTimeout time.Duration
+}
+
+// DEFAULT_TRANSPORT keeps enough idle connections per host for gateway consuming many Things on few hosts,
+// net/http default of 2 idle connections per host makes concurrent consumers reconnect and exhaust ports
+var DEFAULT_TRANSPORT = TransportConfig{
+	MaxIdleConns:        512,
+	MaxIdleConnsPerHost: 64,
+	IdleConnTimeout:     90 * time.Second,
+	DialTimeout:         10 * time.Second,
+	KeepAlive:           30 * time.Second,
+	TLSHandshakeTimeout: 10 * time.Second,
+}
+
+var pool = struct {
+	l      *sync.Mutex
+	client *http.Client
+}{l: &sync.Mutex{}}
+
+// ConfigurePool replaces shared connection pool used by HTTP clients created afterwards, idle
+// connections of the previous pool are closed
+func ConfigurePool(cfg TransportConfig) {
+	pool.l.Lock()
+	defer pool.l.Unlock()
+
+	if pool.client != nil {
+		pool.client.CloseIdleConnections()
+	}
+
+	pool.client = NewPooledClient(cfg)
+}
+
+// sharedClient returns client of shared pool, all Things are consumed over the same connections
+func sharedClient() *http.Client {
+	pool.l.Lock()
+	defer pool.l.Unlock()
+
+	if pool.client == nil {
+		pool.client = NewPooledClient(DEFAULT_TRANSPORT)
+	}
+
+	return pool.client
+}
+
+// NewPooledClient creates http.Client with own connection pool configured by cfg, use with HttpClient.WithHTTPClient
+// to isolate Things from shared pool
+func NewPooledClient(cfg TransportConfig) *http.Client {
+	cfg = cfg.withDefaults()
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+
+	return &http.Client{
+		Timeout: cfg.RequestTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          cfg.MaxIdleConns,
+			MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       cfg.MaxConnsPerHost,
+			IdleConnTimeout:       cfg.IdleConnTimeout,
+			TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+			ForceAttemptHTTP2:     true,
+		},
+	}
+}
+
+func (cfg TransportConfig) withDefaults() TransportConfig {
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = DEFAULT_TRANSPORT.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost == 0 {
+		cfg.MaxIdleConnsPerHost = DEFAULT_TRANSPORT.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout == 0 {
+		cfg.IdleConnTimeout = DEFAULT_TRANSPORT.IdleConnTimeout
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = DEFAULT_TRANSPORT.DialTimeout
+	}
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = DEFAULT_TRANSPORT.KeepAlive
+	}
+	if cfg.TLSHandshakeTimeout == 0 {
+		cfg.TLSHandshakeTimeout = DEFAULT_TRANSPORT.TLSHandshakeTimeout
+	}
+
+	return cfg
+}
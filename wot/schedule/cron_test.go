@@ -0,0 +1,41 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCaseCronNext(t *testing.T) {
+	from := time.Date(2024, 2, 28, 10, 7, 30, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 2, 28, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 2, 28, 10, 15, 0, 0, time.UTC)},
+		{"0 6-20/2 * * *", time.Date(2024, 2, 28, 12, 0, 0, 0, time.UTC)},
+		{"30 5 * * *", time.Date(2024, 2, 29, 5, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2024, 3, 3, 8, 0, 0, 0, time.UTC)},
+		{"0 8 15 * 5", time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		if err != nil {
+			t.Fatal(c.expr, err)
+		}
+		Equals(c.expr, t, c.next, cron.Next(from))
+	}
+}
+
+func TestCaseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		Equals(expr, t, true, err != nil)
+	}
+}
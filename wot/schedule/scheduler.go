@@ -0,0 +1,287 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+)
+
+var ErrDuplicate = errors.New("Schedule already exists")
+
+// Entry is action invocation scheduled for future execution. Entry with Cron expression is recurring,
+// At is then time of its next execution.
+type Entry struct {
+	ID     string      `json:"id"`
+	Thing  string      `json:"thing"`
+	Action string      `json:"action"`
+	Input  interface{} `json:"input,omitempty"`
+	At     time.Time   `json:"at"`
+	Cron   string      `json:"cron,omitempty"`
+	cron   *Cron
+}
+
+// Recurring reports entry scheduled by cron expression
+func (e *Entry) Recurring() bool {
+	return e.Cron != ""
+}
+
+// prepare parses cron expression of recurring entry and sets its next execution
+func (e *Entry) prepare(now time.Time) error {
+	if !e.Recurring() {
+		return nil
+	}
+
+	cron, err := ParseCron(e.Cron)
+	if err != nil {
+		return err
+	}
+
+	e.cron = cron
+	e.At = cron.Next(now)
+
+	if e.At.IsZero() {
+		return errors.New(str.Concat("Cron expression never matches: ", e.Cron))
+	}
+
+	return nil
+}
+
+// Scheduler executes entries at their time. Pending entries are persisted to file, if configured,
+// so schedules survive restart. Restored entries are executed only after Start, so their executors,
+// e.g. bound Things, are ready. Entries which became due while the process was down fire right away.
+type Scheduler struct {
+	l       *sync.Mutex
+	path    string
+	entries map[string]*Entry
+	timers  map[string]*time.Timer
+	fire    func(*Entry)
+}
+
+// NewScheduler creates scheduler calling fire for due entries and restores entries persisted at path.
+// Path may be empty for in memory schedules.
+func NewScheduler(path string, fire func(*Entry)) *Scheduler {
+	s := &Scheduler{
+		l:       &sync.Mutex{},
+		path:    path,
+		entries: make(map[string]*Entry),
+		timers:  make(map[string]*time.Timer),
+		fire:    fire,
+	}
+
+	if err := s.restore(); err != nil {
+		log.Error("Scheduler: schedules not restored -> ", err)
+	}
+
+	return s
+}
+
+func (s *Scheduler) restore() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*Entry, 0)
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return errors.New(str.Concat("Invalid schedule file ", s.path, ": ", err.Error()))
+	}
+
+	for _, e := range entries {
+		if err = e.prepare(time.Now()); err != nil {
+			log.Error("Scheduler: schedule not restored -> ", e.ID, ": ", err)
+			continue
+		}
+		s.entries[e.ID] = e
+	}
+
+	log.Info("Scheduler: restored schedules -> ", len(entries))
+
+	return nil
+}
+
+// Start arms restored entries, entries added by Add are armed immediately
+func (s *Scheduler) Start() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for id, e := range s.entries {
+		if _, ok := s.timers[id]; !ok {
+			s.arm(e)
+		}
+	}
+}
+
+// Add schedules entry and persists pending entries
+func (s *Scheduler) Add(e *Entry) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if _, ok := s.entries[e.ID]; ok {
+		return ErrDuplicate
+	}
+
+	if err := e.prepare(time.Now()); err != nil {
+		return err
+	}
+
+	s.arm(e)
+
+	return s.persist()
+}
+
+// Cancel removes pending entry, returns false if entry is unknown or already executed
+func (s *Scheduler) Cancel(id string) bool {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if !s.disarm(id) {
+		return false
+	}
+
+	s.persist()
+	return true
+}
+
+// CancelThing removes all pending entries of Thing
+func (s *Scheduler) CancelThing(thing string) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for id, e := range s.entries {
+		if e.Thing == thing {
+			s.disarm(id)
+		}
+	}
+
+	s.persist()
+}
+
+// Get returns copy of pending entry
+func (s *Scheduler) Get(id string) (*Entry, bool) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	c := *e
+	return &c, true
+}
+
+// List returns pending entries of Thing ordered by execution time, all entries for empty thing
+func (s *Scheduler) List(thing string) []*Entry {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	//recurring entries are rescheduled in place, copies are safe to use without lock
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if thing == "" || e.Thing == thing {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].At.Before(entries[j].At)
+	})
+
+	return entries
+}
+
+func (s *Scheduler) arm(e *Entry) {
+	s.entries[e.ID] = e
+	s.timers[e.ID] = time.AfterFunc(time.Until(e.At), func() {
+		s.execute(e)
+	})
+}
+
+func (s *Scheduler) disarm(id string) bool {
+	if _, ok := s.entries[id]; !ok {
+		return false
+	}
+
+	if timer, ok := s.timers[id]; ok {
+		timer.Stop()
+	}
+	delete(s.timers, id)
+	delete(s.entries, id)
+
+	return true
+}
+
+func (s *Scheduler) execute(e *Entry) {
+	s.l.Lock()
+	//entry cancelled while timer was firing
+	if s.entries[e.ID] != e {
+		s.l.Unlock()
+		return
+	}
+
+	fired := *e
+	next := time.Time{}
+
+	//next execution is computed from now, executions missed while down are not repeated
+	if e.Recurring() {
+		next = e.cron.Next(time.Now())
+	}
+
+	if !next.IsZero() {
+		e.At = next
+		s.arm(e)
+	} else {
+		delete(s.timers, e.ID)
+		delete(s.entries, e.ID)
+	}
+
+	s.persist()
+	s.l.Unlock()
+
+	s.fire(&fired)
+}
+
+// persist writes pending entries to temporary file renamed over schedule file, so crash while
+// writing does not corrupt schedules. Caller holds the lock.
+func (s *Scheduler) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		log.Error("Scheduler: schedules not persisted -> ", err)
+		return err
+	}
+
+	tmp := str.Concat(s.path, ".tmp")
+	if err = ioutil.WriteFile(tmp, data, 0600); err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+
+	if err != nil {
+		log.Error("Scheduler: schedules not persisted -> ", err)
+	}
+
+	return err
+}
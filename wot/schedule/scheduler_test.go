@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaseSchedulerFire(t *testing.T) {
+	fired := make(chan *Entry, 1)
+	s := NewScheduler("", func(e *Entry) { fired <- e })
+
+	s.Add(&Entry{ID: "1", Thing: "/x", Action: "water", At: time.Now().Add(10 * time.Millisecond)})
+	Equals("duplicate", t, ErrDuplicate, s.Add(&Entry{ID: "1", Thing: "/x", Action: "water"}))
+
+	select {
+	case e := <-fired:
+		Equals("fired", t, "1", e.ID)
+	case <-time.After(time.Second):
+		t.Fatal("Schedule not fired")
+	}
+
+	Equals("executed removed", t, 0, len(s.List("")))
+}
+
+func TestCaseSchedulerCancel(t *testing.T) {
+	s := NewScheduler("", func(e *Entry) { t.Error("Cancelled schedule fired") })
+
+	s.Add(&Entry{ID: "1", Thing: "/x", At: time.Now().Add(50 * time.Millisecond)})
+	s.Add(&Entry{ID: "2", Thing: "/y", At: time.Now().Add(50 * time.Millisecond)})
+	Equals("thing list", t, 1, len(s.List("/x")))
+
+	Equals("cancel", t, true, s.Cancel("1"))
+	Equals("cancel twice", t, false, s.Cancel("1"))
+	s.CancelThing("/y")
+
+	time.Sleep(100 * time.Millisecond)
+	Equals("all cancelled", t, 0, len(s.List("")))
+}
+
+func TestCaseSchedulerRestore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "schedule")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "schedules.json")
+
+	s := NewScheduler(path, func(*Entry) {})
+	s.Add(&Entry{ID: "past", Thing: "/x", Input: 5.0, At: time.Now().Add(time.Hour)})
+	s.Add(&Entry{ID: "future", Thing: "/x", At: time.Now().Add(2 * time.Hour)})
+
+	fired := make(chan *Entry, 2)
+	restored := NewScheduler(path, func(e *Entry) { fired <- e })
+	Equals("restored", t, 2, len(restored.List("/x")))
+
+	//restored entry due in the past fires on start only
+	restored.entries["past"].At = time.Now().Add(-time.Minute)
+	select {
+	case <-fired:
+		t.Fatal("Restored schedule fired before start")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	restored.Start()
+	select {
+	case e := <-fired:
+		Equals("due fired", t, "past", e.ID)
+		Equals("input", t, 5.0, e.Input)
+	case <-time.After(time.Second):
+		t.Fatal("Due schedule not fired")
+	}
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
+
+func TestCaseSchedulerRecurring(t *testing.T) {
+	s := NewScheduler("", func(*Entry) {})
+
+	Equals("invalid cron", t, true, s.Add(&Entry{ID: "bad", Cron: "* *"}) != nil)
+	Equals("never matching cron", t, true, s.Add(&Entry{ID: "never", Cron: "0 0 30 2 *"}) != nil)
+
+	s.Add(&Entry{ID: "hourly", Thing: "/x", Cron: "@hourly"})
+	e, _ := s.Get("hourly")
+	Equals("next run", t, 0, e.At.Minute())
+	Equals("next run future", t, true, e.At.After(time.Now()))
+
+	Equals("cancel recurring", t, true, s.Cancel("hourly"))
+}
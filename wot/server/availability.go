@@ -0,0 +1,190 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+const (
+	AVAILABILITY_ONLINE   = "online"
+	AVAILABILITY_DEGRADED = "degraded"
+	AVAILABILITY_OFFLINE  = "offline"
+)
+
+// PROPERTY_AVAILABILITY is read-only property of Thing tracking its availability
+const PROPERTY_AVAILABILITY = "availability"
+
+// AVAILABILITY_CHANGE_EVENT carries Availability whenever state of Thing changes
+const AVAILABILITY_CHANGE_EVENT = "availability-change"
+
+// Availability is state of Thing driven by heartbeats of its backend. Thing is degraded when heartbeat
+// is late by more than one interval and offline after three intervals. RetryAfter is time until Thing
+// may be expected back, it is the heartbeat interval.
+type Availability struct {
+	State      string        `json:"state"`
+	Since      time.Time     `json:"since"`
+	LastSeen   time.Time     `json:"lastSeen"`
+	RetryAfter time.Duration `json:"-"`
+}
+
+type availability struct {
+	l        *sync.Mutex
+	interval time.Duration
+	current  Availability
+	timer    *time.Timer
+}
+
+// TrackAvailability starts tracking availability of Thing by backend heartbeats expected every interval,
+// see Heartbeat. Property PROPERTY_AVAILABILITY and event AVAILABILITY_CHANGE_EVENT are added to Thing.
+// Offline Thing resolves interactions with WOT_THING_OFFLINE, except reads of availability and metrics.
+func (s *WotServer) TrackAvailability(interval time.Duration) *WotServer {
+	now := time.Now()
+	a := &availability{
+		l:        &sync.Mutex{},
+		interval: interval,
+		current: Availability{
+			State:      AVAILABILITY_ONLINE,
+			Since:      now,
+			LastSeen:   now,
+			RetryAfter: interval,
+		},
+	}
+
+	//Thing rebound by backend replaces tracking, timer of previous one must not change state
+	if previous := s.core.tracked(); previous != nil {
+		previous.l.Lock()
+		if previous.timer != nil {
+			previous.timer.Stop()
+		}
+		previous.l.Unlock()
+	}
+
+	s.core.l.Lock()
+	s.core.availability = a
+	s.core.l.Unlock()
+
+	if !s.core.checkProperty(PROPERTY_AVAILABILITY) {
+		s.AddProperty(PROPERTY_AVAILABILITY, model.Property{
+			Name:      PROPERTY_AVAILABILITY,
+			ValueType: model.ValueType{Type: "object"},
+			Hrefs:     []string{PROPERTY_AVAILABILITY},
+		})
+	}
+	s.OnGetProperty(PROPERTY_AVAILABILITY, func() interface{} {
+		return s.Availability()
+	})
+
+	if !s.core.checkEvent(AVAILABILITY_CHANGE_EVENT) {
+		s.AddEvent(AVAILABILITY_CHANGE_EVENT, model.Event{
+			Name:  AVAILABILITY_CHANGE_EVENT,
+			Hrefs: []string{AVAILABILITY_CHANGE_EVENT},
+		})
+	}
+
+	a.l.Lock()
+	a.schedule(s, now)
+	a.l.Unlock()
+
+	return s
+}
+
+// Heartbeat reports Thing alive, backends call it on every message received from device
+func (s *WotServer) Heartbeat() {
+	a := s.core.tracked()
+	if a == nil {
+		return
+	}
+
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	now := time.Now()
+	a.current.LastSeen = now
+	a.change(s, AVAILABILITY_ONLINE, now)
+	a.schedule(s, now)
+}
+
+// Availability returns state of Thing, Thing not tracking availability is always online
+func (s *WotServer) Availability() Availability {
+	a := s.core.tracked()
+	if a == nil {
+		return Availability{State: AVAILABILITY_ONLINE}
+	}
+
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	return a.current
+}
+
+// schedule arms timer degrading Thing when next heartbeat is late, timer is re-armed by every transition
+// until Thing is offline
+func (a *availability) schedule(s *WotServer, now time.Time) {
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+
+	var next time.Time
+	switch a.current.State {
+	case AVAILABILITY_ONLINE:
+		next = a.current.LastSeen.Add(a.interval)
+	case AVAILABILITY_DEGRADED:
+		next = a.current.LastSeen.Add(3 * a.interval)
+	default:
+		return
+	}
+
+	a.timer = time.AfterFunc(next.Sub(now), func() {
+		a.l.Lock()
+		defer a.l.Unlock()
+
+		now := time.Now()
+		age := now.Sub(a.current.LastSeen)
+		switch {
+		case age >= 3*a.interval:
+			a.change(s, AVAILABILITY_OFFLINE, now)
+		case age >= a.interval:
+			a.change(s, AVAILABILITY_DEGRADED, now)
+		}
+		a.schedule(s, now)
+	})
+}
+
+func (a *availability) change(s *WotServer, state string, now time.Time) {
+	if a.current.State == state {
+		return
+	}
+
+	a.current.State = state
+	a.current.Since = now
+
+	change := a.current
+	go s.EmitEvent(AVAILABILITY_CHANGE_EVENT, &change)
+
+	if state == AVAILABILITY_ONLINE {
+		go s.flushWrites()
+	}
+}
+
+func (wc *WotCore) tracked() *availability {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	return wc.availability
+}
+
+// offline reports whether interaction name of offline Thing has to be refused
+func (wc *WotCore) offline(name string) bool {
+	a := wc.tracked()
+	if a == nil || name == PROPERTY_AVAILABILITY || strings.HasPrefix(name, META_PREFIX) {
+		return false
+	}
+
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	return a.current.State == AVAILABILITY_OFFLINE
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseAvailability(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "pump"})
+	s.AddProperty("flow", model.Property{Name: "flow"})
+	s.AddAction("start", model.InputData{}, model.OutputData{})
+	s.OnGetProperty("flow", func() interface{} { return 3 })
+	s.OnInvokeAction("start", func(interface{}, async.ProgressHandler) interface{} { return nil })
+
+	s.TrackAvailability(20 * time.Millisecond)
+
+	changes := make(chan interface{}, 16)
+	s.AddListener(AVAILABILITY_CHANGE_EVENT, &EventListener{ID: "test", CB: func(e interface{}) {
+		changes <- e.(*Event).Data.(*Availability).State
+	}})
+
+	Equals("online", t, AVAILABILITY_ONLINE, s.Availability().State)
+	Equals("online read", t, 3, s.GetProperty("flow").Get())
+
+	time.Sleep(30 * time.Millisecond)
+	Equals("degraded", t, AVAILABILITY_DEGRADED, s.Availability().State)
+	Equals("degraded read", t, 3, s.GetProperty("flow").Get())
+
+	time.Sleep(50 * time.Millisecond)
+	Equals("offline", t, AVAILABILITY_OFFLINE, s.Availability().State)
+	Equals("offline read", t, WOT_THING_OFFLINE, s.GetProperty("flow").Get())
+
+	ph := &recordingHandler{}
+	Equals("offline invoke", t, WOT_THING_OFFLINE, s.InvokeAction("start", nil, ph).Get())
+	Equals("offline invoke failed", t, true, ph.IsFailed())
+
+	state := s.GetProperty(PROPERTY_AVAILABILITY).Get().(Availability)
+	Equals("availability readable", t, AVAILABILITY_OFFLINE, state.State)
+
+	_, err := GetPropertyAs[int](s, "flow")
+	Equals("offline error", t, true, errors.Is(err, ErrOffline))
+
+	s.Heartbeat()
+	Equals("back online", t, AVAILABILITY_ONLINE, s.Availability().State)
+	Equals("back online read", t, 3, s.GetProperty("flow").Get())
+
+	//collected before Thing degrades again
+	events := received(changes, 10*time.Millisecond)
+	Equals("events", t, 3, len(events))
+}
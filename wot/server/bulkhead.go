@@ -0,0 +1,60 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/conas/tno2/util/async"
+)
+
+// bulkhead caps interactions of one Thing in flight, so slow backend or hot loop of requests of one
+// Thing does not hold goroutines of bindings serving other Things of the gateway
+type bulkhead struct {
+	l        *sync.Mutex
+	max      int
+	inflight int
+}
+
+// Bulkhead caps interactions of Thing in flight, i.e. queued for or executed by backend, at max.
+// Interactions over the cap are refused immediately with WOT_THING_BUSY, reads answered from cache
+// are not counted. Zero max removes the cap.
+func (s *WotServer) Bulkhead(max int) *WotServer {
+	var b *bulkhead
+	if max > 0 {
+		b = &bulkhead{l: &sync.Mutex{}, max: max}
+	}
+
+	s.core.l.Lock()
+	s.core.bulkhead = b
+	s.core.l.Unlock()
+
+	return s
+}
+
+// admit takes slot of bulkhead, release returns it once interaction settles
+func (wc *WotCore) admit() (release func(*async.Promise), ok bool) {
+	wc.l.RLock()
+	b := wc.bulkhead
+	wc.l.RUnlock()
+
+	if b == nil {
+		return func(*async.Promise) {}, true
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.inflight >= b.max {
+		return nil, false
+	}
+	b.inflight++
+
+	return func(p *async.Promise) {
+		go func() {
+			<-p.Done()
+
+			b.l.Lock()
+			b.inflight--
+			b.l.Unlock()
+		}()
+	}, true
+}
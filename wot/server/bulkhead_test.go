@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseBulkhead(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:    "slow",
+		Actions: []model.Action{{Name: "calibrate"}},
+	})
+	s.AddProperty("level", model.Property{Name: "level", Writable: true})
+	s.AddProperty("mode", model.Property{Name: "mode", Push: true})
+
+	release := make(chan struct{})
+	s.OnGetProperty("level", func() interface{} { <-release; return 1 })
+	s.OnUpdateProperty("level", func(interface{}) {})
+	s.OnInvokeAction("calibrate", func(interface{}, async.ProgressHandler) interface{} { return nil })
+	s.Bulkhead(2)
+
+	read := s.GetProperty("level")
+	write := s.SetProperty("level", 2)
+
+	Equals("busy read", t, WOT_THING_BUSY, s.GetProperty("level").Get())
+	Equals("busy write", t, WOT_THING_BUSY, s.SetProperty("level", 3).Get())
+
+	ph := &recordingHandler{}
+	Equals("busy action", t, WOT_THING_BUSY, s.InvokeAction("calibrate", nil, ph).Get())
+	Equals("busy action failed", t, true, ph.IsFailed())
+
+	err := &StatusError{Status: WOT_THING_BUSY}
+	Equals("ErrBusy", t, true, errors.Is(err, ErrBusy))
+
+	//cached reads do not hit backend
+	s.PushProperty("mode", "auto")
+	Equals("cached read", t, "auto", s.GetProperty("mode").Get())
+
+	close(release)
+	Equals("read", t, 1, read.Get())
+	Equals("write", t, WOT_OK, write.Get())
+
+	//settled interactions release their slots
+	var result interface{}
+	for i := 0; i < 100; i++ {
+		if result = s.GetProperty("level").Get(); result != WOT_THING_BUSY {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	Equals("admitted again", t, 1, result)
+
+	s.Bulkhead(0)
+	for i := 0; i < 4; i++ {
+		Equals("uncapped", t, 1, s.GetProperty("level").Get())
+	}
+}
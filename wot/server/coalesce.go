@@ -0,0 +1,78 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/conas/tno2/util/async"
+)
+
+// reads are property reads in flight, keyed by property name. Concurrent reads of the same property
+// join the read in flight instead of asking backend again, so slow serial backends are asked once.
+type reads struct {
+	l        *sync.Mutex
+	inflight map[string]*async.Promise
+}
+
+func newReads() *reads {
+	return &reads{
+		l:        &sync.Mutex{},
+		inflight: make(map[string]*async.Promise),
+	}
+}
+
+// join returns promise of read of property in flight, read is started by call when there is none.
+// Read is forgotten once resolved, the next read asks backend again. Streams are read once, so joined
+// read resolved with stream is read again by call for every joined caller.
+func (r *reads) join(propertyName string, call func() *async.Promise) *async.Promise {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if p, ok := r.inflight[propertyName]; ok {
+		select {
+		case <-p.Done():
+		default:
+			return unshared(p, call)
+		}
+	}
+
+	p := call()
+	r.inflight[propertyName] = p
+
+	go func() {
+		<-p.Done()
+		r.forget(propertyName, p)
+	}()
+
+	return p
+}
+
+// unshared resolves with result of joined read unless it is stream, caller then gets stream of its own read
+func unshared(joined *async.Promise, call func() *async.Promise) *async.Promise {
+	p := async.NewPromise()
+
+	go func() {
+		value, err := joined.Wait()
+		if err == nil && isStream(value) {
+			value, err = call().Wait()
+		}
+
+		if err != nil {
+			p.Reject(err)
+			return
+		}
+		p.Set(value)
+	}()
+
+	return p
+}
+
+// forget lets reads of property started later ask backend, e.g. after write, so they do not answer
+// value read before it. Read p is forgotten only when still in flight, nil forgets any.
+func (r *reads) forget(propertyName string, p *async.Promise) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if current, ok := r.inflight[propertyName]; ok && (p == nil || current == p) {
+		delete(r.inflight, propertyName)
+	}
+}
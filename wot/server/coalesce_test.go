@@ -0,0 +1,74 @@
+package server
+
+import (
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseCoalesceReads(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "modbus"})
+	s.AddProperty("level", model.Property{Name: "level", Writable: true})
+
+	var reads int32
+	release := make(chan struct{})
+	level := 1
+	s.OnGetProperty("level", func() interface{} {
+		atomic.AddInt32(&reads, 1)
+		<-release
+		return level
+	})
+	s.OnUpdateProperty("level", func(v interface{}) { level = v.(int) })
+
+	first := s.GetProperty("level")
+	joined := make([]*async.Promise, 0)
+	for i := 0; i < 8; i++ {
+		joined = append(joined, s.GetProperty("level"))
+	}
+
+	//read started after write does not answer value read before it
+	written := s.SetProperty("level", 2)
+	after := s.GetProperty("level")
+
+	close(release)
+	Equals("first", t, 1, first.Get())
+	for _, read := range joined {
+		Equals("joined", t, 1, read.Get())
+	}
+	Equals("written", t, WOT_OK, written.Get())
+	Equals("after write", t, 2, after.Get())
+	Equals("backend asked", t, int32(2), atomic.LoadInt32(&reads))
+
+	//resolved read is forgotten
+	<-s.GetProperty("level").Done()
+	Equals("asked again", t, int32(3), atomic.LoadInt32(&reads))
+}
+
+func TestCaseCoalesceStreams(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "camera"})
+	s.AddProperty("snapshot", model.Property{Name: "snapshot"})
+
+	var reads int32
+	release := make(chan struct{})
+	s.OnGetProperty("snapshot", func() interface{} {
+		if atomic.AddInt32(&reads, 1) == 1 {
+			<-release
+		}
+		return &Stream{Reader: strings.NewReader("jpeg")}
+	})
+
+	first := s.GetProperty("snapshot")
+	joined := s.GetProperty("snapshot")
+	close(release)
+
+	//every caller reads its own stream
+	for _, read := range []*async.Promise{first, joined} {
+		data, _ := ioutil.ReadAll(read.Get().(*Stream).Reader)
+		Equals("stream", t, "jpeg", string(data))
+	}
+	Equals("backend asked", t, int32(2), atomic.LoadInt32(&reads))
+}
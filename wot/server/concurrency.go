@@ -0,0 +1,61 @@
+package server
+
+import "github.com/conas/tno2/wot/model"
+
+// actionLimiter enforces action concurrency policy by counting execution slots
+type actionLimiter struct {
+	slots  chan struct{}
+	reject bool
+}
+
+func newActionLimiter(c *model.Concurrency) *actionLimiter {
+	max := c.Max
+
+	switch {
+	case c.Policy == model.CONCURRENCY_SERIAL:
+		max = 1
+	case max == 0 && c.Policy == model.CONCURRENCY_REJECT:
+		max = 1
+	}
+
+	al := &actionLimiter{
+		reject: c.Policy == model.CONCURRENCY_REJECT,
+	}
+
+	//unlimited parallel execution needs no slots
+	if max > 0 {
+		al.slots = make(chan struct{}, max)
+	}
+
+	return al
+}
+
+// admit reserves execution slot for rejecting policy, returns false when all slots are taken.
+// Invocations of queuing policies are always admitted.
+func (al *actionLimiter) admit() bool {
+	if !al.reject {
+		return true
+	}
+
+	select {
+	case al.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire waits for execution slot, slot of rejecting policy is already reserved by admit
+func (al *actionLimiter) acquire() {
+	if al.reject || al.slots == nil {
+		return
+	}
+
+	al.slots <- struct{}{}
+}
+
+func (al *actionLimiter) release() {
+	if al.slots != nil {
+		<-al.slots
+	}
+}
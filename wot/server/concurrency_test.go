@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseSerialAction(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "serial", Actions: []model.Action{
+		{Name: "move", Concurrency: &model.Concurrency{Policy: model.CONCURRENCY_SERIAL}},
+	}})
+
+	var running, overlaps int32
+	s.OnInvokeAction("move", func(interface{}, async.ProgressHandler) interface{} {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		defer atomic.AddInt32(&running, -1)
+
+		for i := 0; i < 1000; i++ {
+			async.Run(func() interface{} { return nil }).Get()
+		}
+		return nil
+	})
+
+	invocations := make([]*async.Promise, 0)
+	for i := 0; i < 4; i++ {
+		invocations = append(invocations, s.InvokeAction("move", nil, &recordingHandler{}))
+	}
+	for _, invocation := range invocations {
+		Equals("serial status", t, WOT_OK, invocation.Get())
+	}
+
+	Equals("serial overlaps", t, int32(0), atomic.LoadInt32(&overlaps))
+}
+
+func TestCaseRejectBusyAction(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "reject", Actions: []model.Action{
+		{Name: "move", Concurrency: &model.Concurrency{Policy: model.CONCURRENCY_REJECT}},
+	}})
+
+	release := make(chan struct{})
+	s.OnInvokeAction("move", func(interface{}, async.ProgressHandler) interface{} {
+		<-release
+		return nil
+	})
+
+	first := s.InvokeAction("move", nil, &recordingHandler{})
+
+	ph := &recordingHandler{}
+	Equals("busy status", t, WOT_ACTION_BUSY, s.InvokeAction("move", nil, ph).Get())
+	Equals("busy failed", t, true, ph.failed)
+
+	close(release)
+	Equals("first status", t, WOT_OK, first.Get())
+	Equals("admitted after release", t, WOT_OK, s.InvokeAction("move", nil, &recordingHandler{}).Get())
+}
+
+func TestCaseConcurrencyValidate(t *testing.T) {
+	Equals("nil policy", t, nil, (*model.Concurrency)(nil).Validate())
+	Equals("unknown policy", t, true, (&model.Concurrency{Policy: "exclusive"}).Validate() != nil)
+}
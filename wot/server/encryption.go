@@ -0,0 +1,24 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/conas/tno2/util/jose"
+)
+
+// ErrPlaintext is returned when value written to property encrypted end-to-end is not ciphertext
+var ErrPlaintext = errors.New("Value of encrypted property must be JWE")
+
+// checkCiphertext rejects plaintext written to encrypted property, Thing forwards ciphertext only
+// so plaintext would reach device unprotected and unreadable
+func (wc *WotCore) checkCiphertext(name string, value interface{}) error {
+	wc.l.RLock()
+	p := wc.properties[name]
+	wc.l.RUnlock()
+
+	if p.Encryption != nil && !jose.IsCompact(value) {
+		return ErrPlaintext
+	}
+
+	return nil
+}
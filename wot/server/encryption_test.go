@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conas/tno2/util/jose"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseEncryptedProperty(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name: "meter",
+		Properties: []model.Property{
+			{Name: "reading", Writable: true, Encryption: &model.Encryption{Scheme: model.ENCRYPTION_JWE, KeyID: "k1"}},
+		},
+	})
+
+	var stored interface{}
+	s.OnGetProperty("reading", func() interface{} { return stored })
+	s.OnUpdateProperty("reading", func(v interface{}) { stored = v })
+
+	Equals("Plaintext rejected", t, ErrPlaintext, s.SetProperty("reading", 42).Get())
+
+	token, _ := jose.Encrypt([]byte("0123456789abcdef"), "k1", []byte("42"))
+	Equals("Ciphertext forwarded", t, WOT_OK, s.SetProperty("reading", token).Get())
+	Equals("Ciphertext read", t, token, s.GetProperty("reading").Get())
+}
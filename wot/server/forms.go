@@ -0,0 +1,192 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+// Forms are URIs of Thing and hrefs of its interactions served by one protocol binding, interactions are
+// keyed by name
+type Forms struct {
+	URIs       []string
+	Encodings  []string
+	Properties map[string][]string
+	Actions    map[string][]string
+	Events     map[string][]string
+}
+
+// bindings keeps forms of every binding the WotServer is bound to, in order of binding. Description
+// declared hrefs are kept in properties, actions and events of WotCore.
+type bindings struct {
+	ids   []string
+	forms map[string]*Forms
+	uris  []string
+	// encodings declared by description, captured when first binding adds forms
+	encodings []string
+	captured  bool
+}
+
+func newBindings() *bindings {
+	return &bindings{forms: make(map[string]*Forms)}
+}
+
+// AddForms advertises forms of binding in description, forms previously added by the binding are
+// replaced. The same WotServer may be bound to several bindings at once, e.g. HTTP and NATS, description
+// then lists hrefs of all of them. Relative hrefs of description are replaced by hrefs of bindings
+// serving the interaction, absolute ones are kept.
+func (s *WotServer) AddForms(binding string, f *Forms) *WotServer {
+	s.core.l.Lock()
+
+	b := s.core.bindings
+	if !b.captured {
+		b.uris = append([]string{}, s.core.td.Uris...)
+		b.encodings = append([]string{}, s.core.td.Encodings...)
+		b.captured = true
+	}
+
+	if _, ok := b.forms[binding]; !ok {
+		b.ids = append(b.ids, binding)
+	}
+	b.forms[binding] = f
+
+	s.core.advertise()
+	s.core.revise()
+	s.core.l.Unlock()
+
+	s.tdChanged()
+	return s
+}
+
+// RemoveForms withdraws forms of binding, e.g. when Thing is unbound from it
+func (s *WotServer) RemoveForms(binding string) *WotServer {
+	s.core.l.Lock()
+
+	b := s.core.bindings
+	if _, ok := b.forms[binding]; !ok {
+		s.core.l.Unlock()
+		return s
+	}
+
+	delete(b.forms, binding)
+	for i, id := range b.ids {
+		if id == binding {
+			b.ids = append(b.ids[:i:i], b.ids[i+1:]...)
+			break
+		}
+	}
+
+	s.core.advertise()
+	s.core.revise()
+	s.core.l.Unlock()
+
+	s.tdChanged()
+	return s
+}
+
+// Forms returns forms added by binding, nil when binding has not added any
+func (s *WotServer) Forms(binding string) *Forms {
+	s.core.l.RLock()
+	defer s.core.l.RUnlock()
+
+	return s.core.bindings.forms[binding]
+}
+
+// DeclaredHrefs returns hrefs of interaction as declared by description, regardless of forms of bindings.
+// Bindings derive their routes from them, so every binding serves declared paths.
+func (s *WotServer) DeclaredHrefs(name string) []string {
+	s.core.l.RLock()
+	defer s.core.l.RUnlock()
+
+	if p, ok := s.core.properties[name]; ok {
+		return p.Hrefs
+	}
+	if a, ok := s.core.actions[name]; ok {
+		return a.Hrefs
+	}
+
+	return s.core.events[name].Hrefs
+}
+
+// Description returns copy of description safe to serialize while bindings add and remove forms.
+// GetDescription returns description shared by bindings, its hrefs, URIs and encodings are replaced
+// whenever forms change and must not be modified by bindings.
+func (s *WotServer) Description() *model.ThingDescription {
+	s.core.l.RLock()
+	defer s.core.l.RUnlock()
+
+	return s.core.snapshot()
+}
+
+// snapshot copies description, core lock is held
+func (wc *WotCore) snapshot() *model.ThingDescription {
+	td := *wc.td
+	td.Uris = append([]string{}, td.Uris...)
+	td.Encodings = append([]string{}, td.Encodings...)
+	td.Properties = append([]model.Property{}, td.Properties...)
+	td.Actions = append([]model.Action{}, td.Actions...)
+	td.Events = append([]model.Event{}, td.Events...)
+
+	return &td
+}
+
+// advertise rebuilds URIs, encodings and hrefs of description from forms of bindings, core lock is held.
+// Slices are replaced, never modified in place, so copies taken by Description stay intact.
+func (wc *WotCore) advertise() {
+	b := wc.bindings
+
+	uris := append([]string{}, b.uris...)
+	encodings := append([]string{}, b.encodings...)
+	for _, id := range b.ids {
+		uris = appendUnique(uris, b.forms[id].URIs...)
+		encodings = appendUnique(encodings, b.forms[id].Encodings...)
+	}
+	wc.td.Uris, wc.td.Encodings = uris, encodings
+
+	hrefs := func(declared []string, served func(f *Forms) []string) []string {
+		merged := make([]string, 0)
+		for _, id := range b.ids {
+			merged = appendUnique(merged, served(b.forms[id])...)
+		}
+
+		//interaction not served by any binding keeps its declared hrefs
+		if len(merged) == 0 {
+			return declared
+		}
+
+		for _, href := range declared {
+			if u, err := url.Parse(href); err == nil && (u.IsAbs() || u.Host != "") {
+				merged = appendUnique(merged, href)
+			}
+		}
+
+		return merged
+	}
+
+	for i, p := range wc.td.Properties {
+		wc.td.Properties[i].Hrefs = hrefs(wc.properties[p.Name].Hrefs, func(f *Forms) []string { return f.Properties[p.Name] })
+	}
+	for i, a := range wc.td.Actions {
+		wc.td.Actions[i].Hrefs = hrefs(wc.actions[a.Name].Hrefs, func(f *Forms) []string { return f.Actions[a.Name] })
+	}
+	for i, e := range wc.td.Events {
+		wc.td.Events[i].Hrefs = hrefs(wc.events[e.Name].Hrefs, func(f *Forms) []string { return f.Events[e.Name] })
+	}
+}
+
+func appendUnique(values []string, add ...string) []string {
+	for _, v := range add {
+		found := false
+		for _, existing := range values {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
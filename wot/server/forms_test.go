@@ -0,0 +1,42 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseForms(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "dht"})
+	s.AddProperty("temp", model.Property{Name: "temp", Hrefs: []string{"temp", "coap://dev-1/temp"}})
+	s.AddEvent("alarm", model.Event{Name: "alarm", Hrefs: []string{"alarm"}})
+
+	s.AddForms("HTTP", &Forms{
+		URIs:       []string{"http://gw/dht"},
+		Properties: map[string][]string{"temp": {"http://gw/dht/temp"}},
+	})
+	s.AddForms("NATS", &Forms{
+		URIs:       []string{"nats://broker/wot.dht.td"},
+		Properties: map[string][]string{"temp": {"nats://broker/wot.dht.property.temp"}},
+		Events:     map[string][]string{"alarm": {"nats://broker/wot.dht.event.alarm"}},
+	})
+
+	td := s.Description()
+	Equals("Forms.uris", t, "http://gw/dht nats://broker/wot.dht.td", strings.Join(td.Uris, " "))
+	Equals("Forms.property", t, "http://gw/dht/temp nats://broker/wot.dht.property.temp coap://dev-1/temp", strings.Join(td.Properties[0].Hrefs, " "))
+	Equals("Forms.event", t, "nats://broker/wot.dht.event.alarm", strings.Join(td.Events[0].Hrefs, " "))
+	Equals("Forms.declared", t, "temp coap://dev-1/temp", strings.Join(s.DeclaredHrefs("temp"), " "))
+
+	//rebinding replaces forms of binding, removing restores declared hrefs
+	s.AddForms("HTTP", &Forms{Properties: map[string][]string{"temp": {"http://gw2/dht/temp"}}})
+	s.RemoveForms("NATS")
+
+	Equals("Forms.replaced", t, "http://gw2/dht/temp coap://dev-1/temp", strings.Join(s.GetDescription().Properties[0].Hrefs, " "))
+	Equals("Forms.removed", t, "alarm", strings.Join(s.GetDescription().Events[0].Hrefs, " "))
+	Equals("Forms.uris removed", t, 0, len(s.GetDescription().Uris))
+	Equals("Forms.binding", t, "http://gw2/dht/temp", s.Forms("HTTP").Properties["temp"][0])
+
+	//copies are not changed by later forms
+	Equals("Forms.snapshot", t, "http://gw/dht/temp nats://broker/wot.dht.property.temp coap://dev-1/temp", strings.Join(td.Properties[0].Hrefs, " "))
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/model"
+)
+
+// HISTORY_CAPACITY is default number of samples kept per property by MemoryHistory
+const HISTORY_CAPACITY = 10000
+
+// Sample is numeric property value observed at Time
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// HistoryStore keeps time series of property values of one Thing. Samples are appended in time order.
+type HistoryStore interface {
+	Append(property string, s Sample) error
+	// Query returns samples of property observed in <from, to) in time order
+	Query(property string, from, to time.Time) ([]Sample, error)
+}
+
+// RecordHistory attaches store recording numeric values of properties whenever they are read, written,
+// pushed or notified as changed
+func (s *WotServer) RecordHistory(store HistoryStore) *WotServer {
+	s.core.l.Lock()
+	s.core.history = store
+	s.core.l.Unlock()
+
+	return s.AddTap(func(i *Interaction) {
+		var name string
+		var value interface{}
+
+		switch i.Kind {
+		case INTERACTION_READ:
+			name, value = i.Name, i.Output
+		case INTERACTION_WRITE, INTERACTION_PUSH:
+			name, value = i.Name, i.Input
+		case INTERACTION_EVENT:
+			change, ok := i.Input.(*PropertyChange)
+			if !ok || i.Name != PROPERTY_CHANGE_EVENT || change.observed {
+				return
+			}
+			name, value = change.Name, change.Value
+		default:
+			return
+		}
+
+		n, ok := model.Number(value)
+		if !ok {
+			return
+		}
+
+		if err := store.Append(name, Sample{Time: i.Time, Value: n}); err != nil {
+			log.Error("History: ", name, " sample not recorded -> ", err)
+		}
+	})
+}
+
+// History returns attached history store, nil if history is not recorded
+func (s *WotServer) History() HistoryStore {
+	s.core.l.RLock()
+	defer s.core.l.RUnlock()
+
+	return s.core.history
+}
+
+// MemoryHistory keeps the latest samples of each property in memory
+type MemoryHistory struct {
+	l        *sync.RWMutex
+	capacity int
+	series   map[string][]Sample
+}
+
+func NewMemoryHistory(capacity int) *MemoryHistory {
+	if capacity < 1 {
+		capacity = HISTORY_CAPACITY
+	}
+
+	return &MemoryHistory{
+		l:        &sync.RWMutex{},
+		capacity: capacity,
+		series:   make(map[string][]Sample),
+	}
+}
+
+func (h *MemoryHistory) Append(property string, s Sample) error {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	series := h.series[property]
+	if len(series) == h.capacity {
+		series = append(series[:0:0], series[1:]...)
+	}
+	h.series[property] = append(series, s)
+
+	return nil
+}
+
+func (h *MemoryHistory) Query(property string, from, to time.Time) ([]Sample, error) {
+	h.l.RLock()
+	defer h.l.RUnlock()
+
+	series := h.series[property]
+	start := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(from) })
+	end := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(to) })
+
+	return append([]Sample(nil), series[start:end]...), nil
+}
+
+// Bucket aggregates samples observed in <Start, Start + resolution)
+type Bucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+	Avg   float64   `json:"avg"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+}
+
+// Downsample aggregates samples into buckets of resolution aligned to from, buckets without samples are omitted
+func Downsample(samples []Sample, from time.Time, resolution time.Duration) []Bucket {
+	buckets := make([]Bucket, 0)
+
+	for _, s := range samples {
+		start := from.Add(s.Time.Sub(from) / resolution * resolution)
+
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			buckets = append(buckets, Bucket{Start: start, Min: math.Inf(1), Max: math.Inf(-1)})
+		}
+
+		b := &buckets[len(buckets)-1]
+		b.Avg += s.Value
+		b.Count++
+		b.Min = math.Min(b.Min, s.Value)
+		b.Max = math.Max(b.Max, s.Value)
+	}
+
+	for i := range buckets {
+		buckets[i].Avg /= float64(buckets[i].Count)
+	}
+
+	return buckets
+}
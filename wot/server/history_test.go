@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseRecordHistory(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:       "sensor",
+		Properties: []model.Property{{Name: "temperature", Writable: true}},
+	})
+
+	var value interface{} = 20
+	s.OnGetProperty("temperature", func() interface{} { return value })
+	s.OnUpdateProperty("temperature", func(v interface{}) { value = v })
+
+	store := NewMemoryHistory(2)
+	s.RecordHistory(store)
+
+	start := time.Now()
+	s.GetProperty("temperature").Get()
+	s.SetProperty("temperature", 22).Get()
+	s.SetProperty("temperature", 24).Get()
+
+	samples, _ := store.Query("temperature", start, time.Now().Add(time.Second))
+	Equals("Capacity", t, 2, len(samples))
+	Equals("Oldest kept", t, 22.0, samples[0].Value)
+}
+
+func TestCaseDownsample(t *testing.T) {
+	from := time.Unix(1000, 0)
+	samples := []Sample{
+		{from, 1},
+		{from.Add(30 * time.Second), 3},
+		{from.Add(3 * time.Minute), 10},
+	}
+
+	buckets := Downsample(samples, from, time.Minute)
+	Equals("Buckets", t, 2, len(buckets))
+	Equals("Avg", t, 2.0, buckets[0].Avg)
+	Equals("Min", t, 1.0, buckets[0].Min)
+	Equals("Max", t, 3.0, buckets[0].Max)
+	Equals("Start", t, true, buckets[1].Start.Equal(from.Add(3*time.Minute)))
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// KnownValue is last value of property read, written or notified as changed, Time is when it was seen
+type KnownValue struct {
+	Value interface{} `json:"value"`
+	Time  time.Time   `json:"time"`
+}
+
+// lastKnown keeps values of properties declaring LastKnown, so reads failed by offline backend can be
+// answered by stale value
+type lastKnown struct {
+	l      *sync.RWMutex
+	values map[string]*KnownValue
+}
+
+func newLastKnown() *lastKnown {
+	return &lastKnown{
+		l:      &sync.RWMutex{},
+		values: make(map[string]*KnownValue),
+	}
+}
+
+// LastKnown returns last known value of property declaring LastKnown or writable property of Thing
+// queueing writes, false when no value was seen yet
+func (s *WotServer) LastKnown(propertyName string) (*KnownValue, bool) {
+	s.core.known.l.RLock()
+	defer s.core.known.l.RUnlock()
+
+	v, ok := s.core.known.values[propertyName]
+	return v, ok
+}
+
+// remember keeps value of successful interaction of property declaring LastKnown
+func (wc *WotCore) remember(kind, name string, input, output interface{}, now time.Time) {
+	var value interface{}
+
+	switch kind {
+	case INTERACTION_READ:
+		value = output
+	case INTERACTION_WRITE, INTERACTION_PUSH:
+		value = input
+	case INTERACTION_EVENT:
+		change, ok := input.(*PropertyChange)
+		if !ok || name != PROPERTY_CHANGE_EVENT {
+			return
+		}
+		name, value = change.Name, change.Value
+	default:
+		return
+	}
+
+	switch value.(type) {
+	case nil, error, Status:
+		return
+	}
+
+	//base values of queued writes are remembered too, see CONFLICT_REJECT
+	if p, ok := wc.property(name); !ok || !(p.LastKnown || (p.Writable && wc.writeQueue() != nil)) {
+		return
+	}
+
+	wc.known.l.Lock()
+	wc.known.values[name] = &KnownValue{Value: value, Time: now}
+	wc.known.l.Unlock()
+}
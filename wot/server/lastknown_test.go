@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseLastKnown(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:   "boiler",
+		Events: []model.Event{{Name: PROPERTY_CHANGE_EVENT}},
+	})
+	s.AddProperty("pressure", model.Property{Name: "pressure", LastKnown: true})
+	s.AddProperty("valve", model.Property{Name: "valve", Writable: true})
+
+	var reading interface{} = 2.5
+	s.OnGetProperty("pressure", func() interface{} { return reading })
+	s.OnGetProperty("valve", func() interface{} { return "open" })
+	s.OnUpdateProperty("valve", func(interface{}) {})
+
+	_, ok := s.LastKnown("pressure")
+	Equals("nothing seen", t, false, ok)
+
+	s.GetProperty("pressure").Get()
+	known, _ := s.LastKnown("pressure")
+	Equals("read", t, 2.5, known.Value)
+
+	reading = errors.New("device not responding")
+	s.GetProperty("pressure").Get()
+	known, _ = s.LastKnown("pressure")
+	Equals("failed read kept", t, 2.5, known.Value)
+
+	s.EmitPropertyChange("pressure", 3.1)
+	known, _ = s.LastKnown("pressure")
+	Equals("notified", t, 3.1, known.Value)
+
+	s.GetProperty("valve").Get()
+	s.SetProperty("valve", "closed").Get()
+	_, ok = s.LastKnown("valve")
+	Equals("not declared", t, false, ok)
+}
@@ -0,0 +1,8 @@
+package server
+
+// Thing lifecycle event types published when Things are bound, rebound or removed
+const (
+	THING_CREATED = "created"
+	THING_UPDATED = "updated"
+	THING_REMOVED = "removed"
+)
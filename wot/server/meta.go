@@ -0,0 +1,123 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// META_PREFIX prefixes names of synthetic properties exposing metrics of interactions, metrics of
+// interaction "temperature" are read from property "_meta.temperature" at href "_meta/temperature"
+const META_PREFIX = "_meta."
+
+// Metrics is operational metadata of interaction. Reads and Writes count property interactions,
+// Invocations actions and Emits events. Latency is of the last handler call, e.g. backend round trip,
+// AvgLatency is average of all calls, both in milliseconds.
+type Metrics struct {
+	LastUpdate  *time.Time `json:"lastUpdate,omitempty"`
+	Reads       int64      `json:"reads"`
+	Writes      int64      `json:"writes"`
+	Invocations int64      `json:"invocations"`
+	Emits       int64      `json:"emits"`
+	Errors      int64      `json:"errors"`
+	Latency     float64    `json:"latency"`
+	AvgLatency  float64    `json:"avgLatency"`
+}
+
+type metrics struct {
+	l     *sync.RWMutex
+	stats map[string]*Metrics
+	total map[string]time.Duration
+}
+
+// ExposeMetrics adds read-only META_PREFIX property with Metrics of every property, action and event
+// declared so far, so Thing can be monitored through its own interface
+func (s *WotServer) ExposeMetrics() *WotServer {
+	m := &metrics{
+		l:     &sync.RWMutex{},
+		stats: make(map[string]*Metrics),
+		total: make(map[string]time.Duration),
+	}
+
+	td := s.GetDescription()
+	names := make([]string, 0)
+	for _, p := range td.Properties {
+		names = append(names, p.Name)
+	}
+	for _, a := range td.Actions {
+		names = append(names, a.Name)
+	}
+	for _, e := range td.Events {
+		names = append(names, e.Name)
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, META_PREFIX) || s.core.checkProperty(META_PREFIX+name) {
+			continue
+		}
+
+		m.stats[name] = &Metrics{}
+
+		property := META_PREFIX + name
+		s.AddProperty(property, model.Property{
+			Name:      property,
+			ValueType: model.ValueType{Type: "object"},
+			Hrefs:     []string{str.Concat("_meta/", name)},
+		})
+		s.OnGetProperty(property, func() interface{} {
+			return m.get(name)
+		})
+	}
+
+	return s.AddTap(m.record)
+}
+
+func (m *metrics) record(i *Interaction) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	stats, ok := m.stats[i.Name]
+	if !ok {
+		return
+	}
+
+	switch i.Kind {
+	case INTERACTION_READ:
+		stats.Reads++
+	case INTERACTION_WRITE:
+		stats.Writes++
+	case INTERACTION_INVOKE:
+		stats.Invocations++
+	case INTERACTION_EVENT:
+		stats.Emits++
+	}
+
+	if _, failed := i.Output.(error); failed {
+		stats.Errors++
+	} else {
+		updated := i.Time
+		stats.LastUpdate = &updated
+	}
+
+	if i.Kind != INTERACTION_EVENT {
+		m.total[i.Name] += i.Latency
+		calls := stats.Reads + stats.Writes + stats.Invocations
+		stats.Latency = milliseconds(i.Latency)
+		stats.AvgLatency = milliseconds(m.total[i.Name]) / float64(calls)
+	}
+}
+
+func (m *metrics) get(name string) *Metrics {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	stats := *m.stats[name]
+	return &stats
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseExposeMetrics(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "meter"})
+	s.AddProperty("level", model.Property{Name: "level", Writable: true})
+	s.AddAction("calibrate", model.InputData{}, model.OutputData{})
+
+	failing := false
+	s.OnGetProperty("level", func() interface{} {
+		time.Sleep(10 * time.Millisecond)
+		if failing {
+			return errors.New("device not responding")
+		}
+		return 1
+	})
+	s.OnUpdateProperty("level", func(interface{}) {})
+	s.OnInvokeAction("calibrate", func(interface{}, async.ProgressHandler) interface{} {
+		return nil
+	})
+
+	s.ExposeMetrics()
+	Equals("meta properties", t, 3, len(s.GetDescription().Properties))
+
+	s.GetProperty("level").Get()
+	s.GetProperty("level").Get()
+	failing = true
+	s.GetProperty("level").Get()
+	s.SetProperty("level", 2).Get()
+	s.InvokeAction("calibrate", nil, &recordingHandler{}).Get()
+
+	level := s.GetProperty(META_PREFIX + "level").Get().(*Metrics)
+	Equals("reads", t, int64(3), level.Reads)
+	Equals("writes", t, int64(1), level.Writes)
+	Equals("errors", t, int64(1), level.Errors)
+	Equals("latency", t, true, level.AvgLatency >= 5)
+	Equals("last update", t, true, level.LastUpdate != nil)
+
+	calibrate := s.GetProperty(META_PREFIX + "calibrate").Get().(*Metrics)
+	Equals("invocations", t, int64(1), calibrate.Invocations)
+
+	//metrics are not collected for metrics
+	level = s.GetProperty(META_PREFIX + "level").Get().(*Metrics)
+	Equals("unchanged", t, int64(3), level.Reads)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+// PROPERTY_CHANGE_EVENT is event carrying PropertyChange notifications, Thing exposing property
+// changes declares it in its description
+const PROPERTY_CHANGE_EVENT = "property-change"
+
+type PropertyChange struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	// changes of pushed or polled values are observed by taps as INTERACTION_PUSH or INTERACTION_READ
+	// already
+	observed bool
+}
+
+// notifier applies property notification policy before change is emitted to event listeners
+type notifier struct {
+	l        *sync.Mutex
+	policy   *model.NotifyPolicy
+	valueMin float64
+	valueMax float64
+	notified bool
+	last     interface{}
+	lastTime time.Time
+	pending  *PropertyChange
+	timer    *time.Timer
+}
+
+func newNotifier(p model.Property) *notifier {
+	return &notifier{
+		l:        &sync.Mutex{},
+		policy:   p.Notify,
+		valueMin: float64(p.ValueType.Minimum),
+		valueMax: float64(p.ValueType.Maximum),
+	}
+}
+
+// offer returns true if change should be emitted now. Change suppressed by min interval is kept
+// and passed to emit when interval elapses, unless newer change replaces it.
+func (n *notifier) offer(change *PropertyChange, emit func(*PropertyChange)) bool {
+	n.l.Lock()
+	defer n.l.Unlock()
+
+	if n.notified && !n.significant(change.Value) {
+		return false
+	}
+
+	interval := time.Duration(n.policy.MinInterval) * time.Millisecond
+	wait := interval - time.Since(n.lastTime)
+
+	if n.notified && wait > 0 {
+		if n.pending == nil {
+			n.timer = time.AfterFunc(wait, func() {
+				n.flush(emit)
+			})
+		}
+		n.pending = change
+		return false
+	}
+
+	n.mark(change)
+	return true
+}
+
+func (n *notifier) flush(emit func(*PropertyChange)) {
+	n.l.Lock()
+	change := n.pending
+	n.pending = nil
+	if change != nil {
+		n.mark(change)
+	}
+	n.l.Unlock()
+
+	if change != nil {
+		emit(change)
+	}
+}
+
+func (n *notifier) mark(change *PropertyChange) {
+	n.notified = true
+	n.last = change.Value
+	n.lastTime = time.Now()
+}
+
+// significant checks numeric change against min delta and deadband, non numeric values always pass
+func (n *notifier) significant(value interface{}) bool {
+	v, ok := numeric(value)
+	last, lastOk := numeric(n.last)
+
+	if !ok || !lastOk {
+		return true
+	}
+
+	delta := math.Abs(v - last)
+
+	if n.policy.MinDelta > 0 && delta < n.policy.MinDelta {
+		return false
+	}
+
+	if n.policy.Deadband > 0 {
+		base := math.Abs(last)
+		if n.valueMax > n.valueMin {
+			base = n.valueMax - n.valueMin
+		}
+
+		if delta <= base*n.policy.Deadband/100 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// numeric converts numbers and numeric text payloads, e.g. sensor readings received over MQTT
+func numeric(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil
+	}
+
+	return model.Number(v)
+}
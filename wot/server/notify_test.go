@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func notifyThing(policy *model.NotifyPolicy) (*WotServer, chan interface{}) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name: "sensor",
+		Properties: []model.Property{
+			{Name: "temperature", ValueType: model.ValueType{Type: "number", Minimum: 0, Maximum: 100}, Notify: policy},
+		},
+		Events: []model.Event{{Name: PROPERTY_CHANGE_EVENT}},
+	})
+
+	changes := make(chan interface{}, 16)
+	s.AddListener(PROPERTY_CHANGE_EVENT, &EventListener{ID: "test", CB: func(e interface{}) {
+		changes <- e.(*Event).Data.(*PropertyChange).Value
+	}})
+
+	return s, changes
+}
+
+func received(changes chan interface{}, wait time.Duration) []interface{} {
+	values := make([]interface{}, 0)
+
+	for {
+		select {
+		case v := <-changes:
+			values = append(values, v)
+		case <-time.After(wait):
+			return values
+		}
+	}
+}
+
+func TestCaseNotifyMinDelta(t *testing.T) {
+	s, changes := notifyThing(&model.NotifyPolicy{MinDelta: 0.5})
+
+	for _, v := range []interface{}{20.0, 20.2, 20.4, 20.6, "21.5", 21.4} {
+		s.EmitPropertyChange("temperature", v)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	values := received(changes, 50*time.Millisecond)
+	Equals("min delta count", t, 3, len(values))
+	Equals("min delta last", t, "21.5", values[len(values)-1])
+}
+
+func TestCaseNotifyDeadband(t *testing.T) {
+	//2% of range <0, 100>
+	s, changes := notifyThing(&model.NotifyPolicy{Deadband: 2})
+
+	for _, v := range []float64{50, 51, 52, 52.5, 49.9} {
+		s.EmitPropertyChange("temperature", v)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	values := received(changes, 50*time.Millisecond)
+	Equals("deadband count", t, 3, len(values))
+}
+
+func TestCaseNotifyMinInterval(t *testing.T) {
+	s, changes := notifyThing(&model.NotifyPolicy{MinInterval: 100})
+
+	for _, v := range []float64{1, 2, 3, 4} {
+		s.EmitPropertyChange("temperature", v)
+	}
+
+	values := received(changes, 200*time.Millisecond)
+	Equals("min interval count", t, 2, len(values))
+	Equals("min interval first", t, 1.0, values[0])
+	Equals("min interval latest", t, 4.0, values[1])
+}
+
+func TestCaseNotifyUnknown(t *testing.T) {
+	s, _ := notifyThing(nil)
+	Equals("unknown property", t, WOT_UNKNOWN_PROPERTY, s.EmitPropertyChange("humidity", 1))
+
+	s = CreateFromDescription(&model.ThingDescription{Name: "sensor", Properties: []model.Property{{Name: "humidity"}}})
+	Equals("undeclared event", t, WOT_UNKNOWN_EVENT, s.EmitPropertyChange("humidity", 1))
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// POLL_MAX_BACKOFF limits how many times interval of failing polls is doubled
+const POLL_MAX_BACKOFF = 3
+
+// poller reads properties declaring Poll by one goroutine, so backend is asked by one read at a time
+type poller struct {
+	stop  chan struct{}
+	polls []*poll
+}
+
+type poll struct {
+	name     string
+	policy   *model.PollPolicy
+	due      time.Time
+	failures uint
+}
+
+// PollProperties starts polling properties declaring Poll, polling started before is replaced. Polled
+// values answer reads and their changes are notified to listeners of PROPERTY_CHANGE_EVENT when Thing
+// declares it. Polling adapts to Thing, it is deferred by values pushed by device, paused while Thing
+// is offline and interval of failing reads is doubled up to POLL_MAX_BACKOFF times.
+func (s *WotServer) PollProperties() *WotServer {
+	s.StopPolling()
+
+	now := time.Now()
+	pl := &poller{stop: make(chan struct{})}
+
+	s.core.l.Lock()
+	defer s.core.l.Unlock()
+
+	for name, p := range s.core.properties {
+		if p.Poll == nil || p.Poll.Interval <= 0 {
+			continue
+		}
+
+		polled := &poll{name: name, policy: p.Poll}
+		polled.schedule(now, 0)
+		pl.polls = append(pl.polls, polled)
+	}
+
+	if len(pl.polls) == 0 {
+		return s
+	}
+
+	s.core.poller = pl
+	go pl.run(s)
+
+	return s
+}
+
+// StopPolling stops polling properties, cached values keep answering reads
+func (s *WotServer) StopPolling() {
+	s.core.l.Lock()
+	pl := s.core.poller
+	s.core.poller = nil
+	s.core.l.Unlock()
+
+	if pl != nil {
+		close(pl.stop)
+	}
+}
+
+func (pl *poller) run(s *WotServer) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-pl.stop:
+			return
+		case <-timer.C:
+		}
+
+		for _, p := range pl.due(time.Now()) {
+			select {
+			case <-pl.stop:
+				return
+			default:
+			}
+
+			p.refresh(s)
+		}
+
+		timer.Reset(time.Until(pl.next()))
+	}
+}
+
+// due returns polls due at now, higher priority first
+func (pl *poller) due(now time.Time) []*poll {
+	due := make([]*poll, 0)
+	for _, p := range pl.polls {
+		if !p.due.After(now) {
+			due = append(due, p)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].policy.Priority != due[j].policy.Priority {
+			return due[i].policy.Priority > due[j].policy.Priority
+		}
+		return due[i].due.Before(due[j].due)
+	})
+
+	return due
+}
+
+func (pl *poller) next() time.Time {
+	next := pl.polls[0].due
+	for _, p := range pl.polls[1:] {
+		if p.due.Before(next) {
+			next = p.due
+		}
+	}
+
+	return next
+}
+
+// refresh reads property unless value seen within interval is cached, e.g. pushed by device
+func (p *poll) refresh(s *WotServer) {
+	now := time.Now()
+
+	if s.core.offline(p.name) {
+		p.schedule(now, p.interval())
+		return
+	}
+
+	if seen, ok := s.core.cachedTime(p.name); ok && now.Sub(seen) < p.interval() {
+		p.schedule(seen, p.interval())
+		return
+	}
+
+	started := time.Now()
+	value, err := s.core.reads.join(p.name, func() *async.Promise {
+		return s.gs.Call(GET_PROPERTY, &GetPropertyMsg{name: p.name})
+	}).Wait()
+
+	if err == nil {
+		switch value.(type) {
+		case nil, error, Status:
+			err = errors.New(str.Concat(value))
+		}
+	}
+
+	if err != nil {
+		if p.failures < POLL_MAX_BACKOFF {
+			p.failures++
+		}
+		log.Warn("WotServer: poll of ", p.name, " failed -> ", err)
+		p.schedule(time.Now(), p.interval()<<p.failures)
+		return
+	}
+
+	p.failures = 0
+	p.schedule(time.Now(), p.interval())
+
+	//value read before write of property is not cached
+	previous, known := s.core.cachedValue(p.name)
+	if !s.core.cacheRead(p.name, value, started, time.Now()) {
+		return
+	}
+
+	if known && !reflect.DeepEqual(previous, value) && s.core.checkEvent(PROPERTY_CHANGE_EVENT) {
+		s.notifyChange(&PropertyChange{Name: p.name, Value: value, observed: true})
+	}
+}
+
+func (p *poll) interval() time.Duration {
+	return time.Duration(p.policy.Interval) * time.Millisecond
+}
+
+// schedule sets next poll after interval from, delayed by random jitter
+func (p *poll) schedule(from time.Time, interval time.Duration) {
+	p.due = from.Add(interval)
+	if p.policy.Jitter > 0 {
+		p.due = p.due.Add(time.Duration(rand.Int63n(int64(p.policy.Jitter))) * time.Millisecond)
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCasePollProperties(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:   "plc",
+		Events: []model.Event{{Name: PROPERTY_CHANGE_EVENT}},
+	})
+	s.AddProperty("pressure", model.Property{Name: "pressure", Poll: &model.PollPolicy{Interval: 20, Priority: 1}})
+	s.AddProperty("flow", model.Property{Name: "flow", Poll: &model.PollPolicy{Interval: 20}})
+	s.AddProperty("mode", model.Property{Name: "mode"})
+
+	l := &sync.Mutex{}
+	order := make([]string, 0)
+	pressure := 1
+	read := func(name string, value func() interface{}) {
+		s.OnGetProperty(name, func() interface{} {
+			l.Lock()
+			defer l.Unlock()
+			order = append(order, name)
+			return value()
+		})
+	}
+	read("pressure", func() interface{} { return pressure })
+	read("flow", func() interface{} { return 5 })
+	read("mode", func() interface{} { return "auto" })
+
+	changes := make(chan *PropertyChange, 4)
+	s.AddListener(PROPERTY_CHANGE_EVENT, &EventListener{ID: "l", CB: func(e interface{}) {
+		changes <- e.(*Event).Data.(*PropertyChange)
+	}})
+
+	s.PollProperties()
+	defer s.StopPolling()
+
+	time.Sleep(10 * time.Millisecond)
+	l.Lock()
+	Equals("priority first", t, "pressure flow", order[0]+" "+order[1])
+	pressure = 2
+	l.Unlock()
+
+	select {
+	case change := <-changes:
+		Equals("change notified", t, 2, change.Value)
+	case <-time.After(time.Second):
+		t.Fatal("polled change not notified")
+	}
+
+	Equals("cached read", t, 2, s.GetProperty("pressure").Get())
+	Equals("not polled", t, "auto", s.GetProperty("mode").Get())
+
+	//written value is read from backend, not from value polled before write
+	_, cached := s.core.cachedValue("pressure")
+	Equals("cached", t, true, cached)
+	s.core.uncache("pressure", time.Now())
+	Equals("read before write not cached", t, false, s.core.cacheRead("pressure", 1, time.Now().Add(-time.Second), time.Now()))
+
+	//stopped polling keeps cached values
+	s.StopPolling()
+	time.Sleep(50 * time.Millisecond)
+	l.Lock()
+	stopped := len(order)
+	l.Unlock()
+	time.Sleep(50 * time.Millisecond)
+
+	l.Lock()
+	Equals("stopped", t, stopped, len(order))
+	l.Unlock()
+	Equals("cached after stop", t, 5, s.GetProperty("flow").Get())
+}
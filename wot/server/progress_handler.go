@@ -1,6 +1,8 @@
 package server
 
 import (
+	"io"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,21 +21,48 @@ const (
 )
 
 type TaskStatus struct {
+	Task      string         `json:"task,omitempty"`
 	Name      string         `json:"name,omitempty"`
 	Status    TaskStatusCode `json:"status"`
 	Timestamp time.Time      `json:"timestamp,omitempty"`
+	Progress  *Progress      `json:"progress,omitempty"`
 	Data      interface{}    `json:"data"`
 }
 
+// Progress is intermediate progress of running action reported by backend, Percent is kept in 0..100
+type Progress struct {
+	Percent float64 `json:"percent"`
+	Stage   string  `json:"stage,omitempty"`
+	Message string  `json:"message,omitempty"`
+}
+
+// ACTION_STATUS_EVENT is event carrying ActionStatus on every status change of action task, Thing
+// exposing progress of its actions declares it in its description
+const ACTION_STATUS_EVENT = "actionstatus"
+
+// ActionStatus is status of task of action invoked by request RequestID
+type ActionStatus struct {
+	RequestID string `json:"requestId,omitempty"`
+	*TaskStatus
+}
+
 // WotProgressHandler implements async.ProgressHandler
 type WotProgressHandler struct {
+	task        string
 	name        string
 	state       *atomic.Value
 	subscribers *async.FanOut
+	observer    func(*TaskStatus)
 }
 
 func NewWotProgressHandler(name string, state *atomic.Value, subscribers *async.FanOut) *WotProgressHandler {
+	return NewTaskProgressHandler("", name, state, subscribers)
+}
+
+// NewTaskProgressHandler creates progress handler of task identified by task, ID is part of reported statuses
+func NewTaskProgressHandler(task, name string, state *atomic.Value, subscribers *async.FanOut) *WotProgressHandler {
 	return &WotProgressHandler{
+		task:        task,
 		name:        name,
 		state:       state,
 		subscribers: subscribers,
@@ -41,51 +70,64 @@ func NewWotProgressHandler(name string, state *atomic.Value, subscribers *async.
 }
 
 func (ph *WotProgressHandler) Schedule(data interface{}) {
-	status := &TaskStatus{
-		Name:      ph.name,
-		Status:    TASK_SCHEDULED,
-		Timestamp: time.Now(),
-		Data:      data,
-	}
-
-	ph.state.Store(status)
-	ph.subscribers.Publish(status)
+	ph.report(TASK_SCHEDULED, data, nil)
 }
 
 func (ph *WotProgressHandler) Update(data interface{}) {
-	status := &TaskStatus{
-		Name:      ph.name,
-		Status:    TASK_RUNNING,
-		Timestamp: time.Now(),
-		Data:      data,
+	ph.report(TASK_RUNNING, data, nil)
+}
+
+// Progress reports running task with progress p, data of previous status are kept
+func (ph *WotProgressHandler) Progress(p Progress) {
+	p.Percent = math.Max(0, math.Min(100, p.Percent))
+
+	var data interface{}
+	if last, ok := ph.state.Load().(*TaskStatus); ok && last.Status == TASK_RUNNING {
+		data = last.Data
 	}
 
-	ph.state.Store(status)
-	ph.subscribers.Publish(status)
+	ph.report(TASK_RUNNING, data, &p)
 }
 
 func (ph *WotProgressHandler) Done(data interface{}) {
+	ph.report(TASK_DONE, storedStream(data), nil)
+}
+
+func (ph *WotProgressHandler) Fail(data interface{}) {
+	ph.report(TASK_FAILED, data, nil)
+}
+
+func (ph *WotProgressHandler) report(code TaskStatusCode, data interface{}, p *Progress) {
 	status := &TaskStatus{
+		Task:      ph.task,
 		Name:      ph.name,
-		Status:    TASK_DONE,
+		Status:    code,
 		Timestamp: time.Now(),
+		Progress:  p,
 		Data:      data,
 	}
 
 	ph.state.Store(status)
 	ph.subscribers.Publish(status)
+
+	if ph.observer != nil {
+		ph.observer(status)
+	}
 }
 
-func (ph *WotProgressHandler) Fail(data interface{}) {
-	status := &TaskStatus{
-		Name:      ph.name,
-		Status:    TASK_FAILED,
-		Timestamp: time.Now(),
-		Data:      data,
+// ReportProgress reports progress of action to progress handler ph passed to action handler, handlers
+// unable to carry progress receive it as update data
+func ReportProgress(ph async.ProgressHandler, p Progress) {
+	if rp, ok := ph.(*requestProgress); ok {
+		ph = rp.ProgressHandler
 	}
 
-	ph.state.Store(status)
-	ph.subscribers.Publish(status)
+	if wph, ok := ph.(*WotProgressHandler); ok {
+		wph.Progress(p)
+		return
+	}
+
+	ph.Update(&p)
 }
 
 func (ph *WotProgressHandler) IsFailed() bool {
@@ -95,32 +137,145 @@ func (ph *WotProgressHandler) IsFailed() bool {
 
 type ActionResults struct {
 	rwmut  *sync.RWMutex
-	states map[string]*atomic.Value
+	states map[string]*actionSlot
+}
+
+type actionSlot struct {
+	thing  string
+	action string
+	state  *atomic.Value
+}
+
+// close releases stream result of finished task, it is not downloaded any more
+func (slot *actionSlot) close() {
+	status, ok := slot.state.Load().(*TaskStatus)
+	if !ok || status.Status != TASK_DONE {
+		return
+	}
+
+	switch data := status.Data.(type) {
+	case *Stream:
+		closeStream(data.Reader)
+	case io.Reader:
+		closeStream(data)
+	}
+}
+
+type TaskInfo struct {
+	ID     string      `json:"id"`
+	Thing  string      `json:"thing"`
+	Status *TaskStatus `json:"status"`
 }
 
 func NewActionResults() *ActionResults {
 	return &ActionResults{
 		rwmut:  &sync.RWMutex{},
-		states: make(map[string]*atomic.Value),
+		states: make(map[string]*actionSlot),
 	}
 }
 
-func (ar *ActionResults) CreateSlot() (string, *atomic.Value) {
+// CreateSlot creates slot of task of action of thing, see GetActionSlot
+func (ar *ActionResults) CreateSlot(thing, action string) (string, *atomic.Value) {
 	stateID, _ := sec.UUID4()
 
 	ar.rwmut.Lock()
 	defer ar.rwmut.Unlock()
 
-	ar.states[stateID] = &atomic.Value{}
+	ar.states[stateID] = &actionSlot{
+		thing:  thing,
+		action: action,
+		state:  &atomic.Value{},
+	}
+
+	return stateID, ar.states[stateID].state
+}
+
+// RestoreSlot returns slot stateID, slot is created if it was removed, e.g. by Thing rebind
+func (ar *ActionResults) RestoreSlot(stateID, thing, action string) *atomic.Value {
+	ar.rwmut.Lock()
+	defer ar.rwmut.Unlock()
+
+	if slot, ok := ar.states[stateID]; ok {
+		return slot.state
+	}
+
+	ar.states[stateID] = &actionSlot{
+		thing:  thing,
+		action: action,
+		state:  &atomic.Value{},
+	}
 
-	return stateID, ar.states[stateID]
+	return ar.states[stateID].state
 }
 
 func (ar *ActionResults) GetSlot(stateID string) (*atomic.Value, bool) {
 	ar.rwmut.RLock()
 	defer ar.rwmut.RUnlock()
 
-	state, rc := ar.states[stateID]
+	slot, rc := ar.states[stateID]
+
+	if !rc {
+		return nil, rc
+	}
+
+	return slot.state, rc
+}
+
+// GetActionSlot returns slot stateID only when it is slot of task of action of thing, so task is
+// reachable only through URL of its own interaction
+func (ar *ActionResults) GetActionSlot(stateID, thing, action string) (*atomic.Value, bool) {
+	ar.rwmut.RLock()
+	defer ar.rwmut.RUnlock()
+
+	slot, ok := ar.states[stateID]
+	if !ok || slot.thing != thing || slot.action != action {
+		return nil, false
+	}
+
+	return slot.state, true
+}
+
+// Pending lists tasks which are neither done nor failed
+func (ar *ActionResults) Pending() []*TaskInfo {
+	ar.rwmut.RLock()
+	defer ar.rwmut.RUnlock()
+
+	tasks := make([]*TaskInfo, 0)
+	for id, slot := range ar.states {
+		status, _ := slot.state.Load().(*TaskStatus)
+
+		if status != nil && (status.Status == TASK_DONE || status.Status == TASK_FAILED) {
+			continue
+		}
+
+		tasks = append(tasks, &TaskInfo{
+			ID:     id,
+			Thing:  slot.thing,
+			Status: status,
+		})
+	}
+
+	return tasks
+}
+
+func (ar *ActionResults) RemoveSlot(stateID string) {
+	ar.rwmut.Lock()
+	defer ar.rwmut.Unlock()
 
-	return state, rc
+	if slot, ok := ar.states[stateID]; ok {
+		slot.close()
+	}
+	delete(ar.states, stateID)
+}
+
+func (ar *ActionResults) RemoveThing(thing string) {
+	ar.rwmut.Lock()
+	defer ar.rwmut.Unlock()
+
+	for id, slot := range ar.states {
+		if slot.thing == thing {
+			slot.close()
+			delete(ar.states, id)
+		}
+	}
 }
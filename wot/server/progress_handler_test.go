@@ -0,0 +1,58 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseActionProgress(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:   "installer",
+		Events: []model.Event{{Name: ACTION_STATUS_EVENT}},
+	})
+	s.AddAction("install", model.InputData{}, model.OutputData{})
+	s.OnInvokeAction("install", func(arg interface{}, ph async.ProgressHandler) interface{} {
+		ReportProgress(ph, Progress{Percent: 40, Stage: "download"})
+		ReportProgress(ph, Progress{Percent: 140, Stage: "install", Message: "almost there"})
+		return "installed"
+	})
+
+	statuses := make(chan interface{}, 16)
+	s.AddListener(ACTION_STATUS_EVENT, &EventListener{ID: "test", CB: func(e interface{}) {
+		statuses <- e.(*Event).Data
+	}})
+
+	slot := &atomic.Value{}
+	published := make(chan interface{}, 16)
+	fo := async.NewFanOut()
+	fo.AddSubscriber(published)
+
+	ph := NewTaskProgressHandler("task-1", "install", slot, fo)
+	s.Request("rq-1").InvokeAction("install", nil, ph).Get()
+
+	progress := make([]*TaskStatus, 0)
+	for _, status := range received(published, 50*time.Millisecond) {
+		if ts := status.(*TaskStatus); ts.Progress != nil {
+			progress = append(progress, ts)
+		}
+	}
+
+	Equals("reported", t, 2, len(progress))
+	Equals("stage", t, "download", progress[0].Progress.Stage)
+	Equals("clamped", t, 100.0, progress[1].Progress.Percent)
+	Equals("message", t, "almost there", progress[1].Progress.Message)
+	Equals("task", t, "task-1", progress[1].Task)
+
+	//scheduled, 2x running, done
+	events := received(statuses, 50*time.Millisecond)
+	Equals("events", t, 4, len(events))
+	for _, e := range events {
+		status := e.(*ActionStatus)
+		Equals("event request", t, "rq-1", status.RequestID)
+		Equals("event task", t, "task-1", status.Task)
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// PushProperty takes property value device sent without being asked. Value is converted by property
+// transform, property declaring Push keeps it so reads are answered without asking backend, and change
+// is notified to listeners of PROPERTY_CHANGE_EVENT when Thing declares it, by notification policy of
+// property. Pushed values are observed by taps as INTERACTION_PUSH.
+func (s *WotServer) PushProperty(propertyName string, value interface{}) Status {
+	p, ok := s.core.property(propertyName)
+	if !ok {
+		return WOT_UNKNOWN_PROPERTY
+	}
+
+	converted := s.core.forward(propertyName, value)
+	if err, ok := converted.(error); ok {
+		log.Error("Property ", propertyName, " push dropped -> ", err)
+		return WOT_INVALID_VALUE
+	}
+
+	now := time.Now()
+	if p.Push {
+		s.core.cache(propertyName, converted, now)
+	}
+
+	s.core.tap(INTERACTION_PUSH, propertyName, converted, nil, s.requestID, 0)
+
+	if !s.core.checkEvent(PROPERTY_CHANGE_EVENT) {
+		return WOT_OK
+	}
+
+	s.notifyChange(&PropertyChange{Name: propertyName, Value: converted, observed: true})
+	return WOT_OK
+}
+
+// cache keeps value reads of property are answered by
+func (wc *WotCore) cache(propertyName string, value interface{}, now time.Time) {
+	wc.cached.l.Lock()
+	wc.cached.values[propertyName] = &KnownValue{Value: value, Time: now}
+	wc.cached.l.Unlock()
+}
+
+// uncache drops cached value of property written by Thing, reads ask backend until device pushes or
+// poll reads the value again
+func (wc *WotCore) uncache(propertyName string, now time.Time) {
+	wc.cached.l.Lock()
+	delete(wc.cached.values, propertyName)
+	wc.uncached[propertyName] = now
+	wc.cached.l.Unlock()
+}
+
+// cacheRead caches value read since, unless property was written meanwhile, so value read before write
+// does not answer reads after it
+func (wc *WotCore) cacheRead(propertyName string, value interface{}, since, now time.Time) bool {
+	wc.cached.l.Lock()
+	defer wc.cached.l.Unlock()
+
+	if written, ok := wc.uncached[propertyName]; ok && !written.Before(since) {
+		return false
+	}
+
+	wc.cached.values[propertyName] = &KnownValue{Value: value, Time: now}
+	return true
+}
+
+// current returns value writes of property are conditioned on, cached value when reads are answered by
+// it, so writes conditioned on value read, e.g. by its ETag, compare the same value
+func (wc *WotCore) current(propertyName, requestID string, getHandler func() interface{}) interface{} {
+	if value, ok := wc.cachedValue(propertyName); ok {
+		return value
+	}
+
+	return wc.forward(propertyName, wc.serve(requestID, getHandler))
+}
+
+// cachedValue returns last value pushed by device for property declaring Push or polled for property
+// declaring Poll
+func (wc *WotCore) cachedValue(propertyName string) (interface{}, bool) {
+	wc.cached.l.RLock()
+	defer wc.cached.l.RUnlock()
+
+	v, ok := wc.cached.values[propertyName]
+	if !ok {
+		return nil, false
+	}
+
+	return v.Value, true
+}
+
+// cachedTime returns when cached value of property was pushed or polled
+func (wc *WotCore) cachedTime(propertyName string) (time.Time, bool) {
+	wc.cached.l.RLock()
+	defer wc.cached.l.RUnlock()
+
+	v, ok := wc.cached.values[propertyName]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return v.Time, true
+}
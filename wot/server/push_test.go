@@ -0,0 +1,77 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCasePushProperty(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:   "meter",
+		Events: []model.Event{{Name: PROPERTY_CHANGE_EVENT}},
+	})
+	s.AddProperty("power", model.Property{Name: "power", Push: true})
+	s.AddProperty("energy", model.Property{Name: "energy"})
+
+	reads := 0
+	s.OnGetProperty("power", func() interface{} { reads++; return 1 })
+	s.OnGetProperty("energy", func() interface{} { return 10 })
+
+	history := NewMemoryHistory(10)
+	s.RecordHistory(history)
+
+	changes := make(chan *PropertyChange, 4)
+	s.AddListener(PROPERTY_CHANGE_EVENT, &EventListener{ID: "l", CB: func(e interface{}) {
+		changes <- e.(*Event).Data.(*PropertyChange)
+	}})
+
+	Equals("asked until pushed", t, 1, s.GetProperty("power").Get())
+
+	Equals("push", t, WOT_OK, s.PushProperty("power", 42))
+	Equals("pushed read", t, 42, s.GetProperty("power").Get())
+	Equals("backend not asked", t, 1, reads)
+
+	select {
+	case change := <-changes:
+		Equals("notified", t, 42, change.Value)
+	case <-time.After(time.Second):
+		t.Fatal("push not notified")
+	}
+
+	//property not declaring Push is notified but read from backend
+	s.PushProperty("energy", 11)
+	Equals("not cached", t, 10, s.GetProperty("energy").Get())
+
+	samples, _ := history.Query("power", time.Time{}, time.Now().Add(time.Second))
+	Equals("recorded once", t, 2, len(samples))
+
+	Equals("unknown", t, WOT_UNKNOWN_PROPERTY, s.PushProperty("voltage", 230))
+}
+
+func TestCasePushedWrite(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "thermostat"})
+	s.AddProperty("setpoint", model.Property{Name: "setpoint", Push: true, Writable: true})
+
+	setpoint := 20
+	s.OnGetProperty("setpoint", func() interface{} { return setpoint })
+	s.OnUpdateProperty("setpoint", func(v interface{}) { setpoint = v.(int) })
+
+	s.PushProperty("setpoint", 20)
+
+	//conditional write compares value reads are answered by
+	Equals("conflict", t, WOT_PROPERTY_CONFLICT, s.CompareAndSetProperty("setpoint", 19, 21).Get())
+	Equals("cas", t, WOT_OK, s.CompareAndSetProperty("setpoint", 20, 21).Get())
+	Equals("read after cas", t, 21, s.GetProperty("setpoint").Get())
+
+	s.PushProperty("setpoint", 21)
+	Equals("write", t, WOT_OK, s.SetProperty("setpoint", 22).Get())
+	Equals("read after write", t, 22, s.GetProperty("setpoint").Get())
+
+	s.PushProperty("setpoint", 22)
+	Equals("update", t, 23, s.UpdateProperty("setpoint", func(current interface{}) (interface{}, error) {
+		return current.(int) + 1, nil
+	}).Get())
+	Equals("read after update", t, 23, s.GetProperty("setpoint").Get())
+}
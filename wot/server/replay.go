@@ -0,0 +1,74 @@
+package server
+
+import "sync"
+
+// REPLAY_BUFFER is default number of unacknowledged messages kept for redelivery
+const REPLAY_BUFFER = 256
+
+// Sequenced is subscription message numbered by its ReplayBuffer
+type Sequenced struct {
+	Seq     uint64
+	Message interface{}
+}
+
+// ReplayBuffer numbers messages of one subscription and keeps them until client acknowledges them,
+// so client reconnecting after connection loss gets messages it missed. Buffer is bounded, when it is
+// full the oldest message is dropped and client detects the gap from sequence numbers.
+type ReplayBuffer struct {
+	l       *sync.Mutex
+	size    int
+	seq     uint64
+	unacked []*Sequenced
+}
+
+func NewReplayBuffer(size int) *ReplayBuffer {
+	if size < 1 {
+		size = REPLAY_BUFFER
+	}
+
+	return &ReplayBuffer{
+		l:       &sync.Mutex{},
+		size:    size,
+		unacked: make([]*Sequenced, 0),
+	}
+}
+
+// Record numbers message and keeps it until acknowledged. Sequence starts at 1.
+func (rb *ReplayBuffer) Record(message interface{}) *Sequenced {
+	rb.l.Lock()
+	defer rb.l.Unlock()
+
+	rb.seq++
+	s := &Sequenced{
+		Seq:     rb.seq,
+		Message: message,
+	}
+
+	if len(rb.unacked) == rb.size {
+		rb.unacked = rb.unacked[1:]
+	}
+	rb.unacked = append(rb.unacked, s)
+
+	return s
+}
+
+// Ack acknowledges all messages up to seq
+func (rb *ReplayBuffer) Ack(seq uint64) {
+	rb.l.Lock()
+	defer rb.l.Unlock()
+
+	i := 0
+	for i < len(rb.unacked) && rb.unacked[i].Seq <= seq {
+		i++
+	}
+
+	rb.unacked = append(make([]*Sequenced, 0, len(rb.unacked)-i), rb.unacked[i:]...)
+}
+
+// Unacked returns messages not acknowledged yet in sequence order
+func (rb *ReplayBuffer) Unacked() []*Sequenced {
+	rb.l.Lock()
+	defer rb.l.Unlock()
+
+	return append([]*Sequenced(nil), rb.unacked...)
+}
@@ -0,0 +1,25 @@
+package server
+
+import "testing"
+
+func TestCaseReplayBuffer(t *testing.T) {
+	rb := NewReplayBuffer(3)
+
+	for i := 1; i <= 4; i++ {
+		Equals("sequence", t, uint64(i), rb.Record(i).Seq)
+	}
+
+	//oldest message dropped when buffer is full
+	unacked := rb.Unacked()
+	Equals("bounded", t, 3, len(unacked))
+	Equals("oldest kept", t, uint64(2), unacked[0].Seq)
+
+	rb.Ack(3)
+	unacked = rb.Unacked()
+	Equals("acked removed", t, 1, len(unacked))
+	Equals("unacked message", t, 4, unacked[0].Message)
+
+	rb.Ack(10)
+	Equals("all acked", t, 0, len(rb.Unacked()))
+	Equals("sequence continues", t, uint64(5), rb.Record(5).Seq)
+}
@@ -0,0 +1,41 @@
+package server
+
+import "github.com/conas/tno2/util/async"
+
+// Request returns view of WotServer whose calls are attributed to request id, e.g. ID of HTTP request.
+// ID is passed to taps and to handlers, so backends can correlate their conversations with the request.
+func (s *WotServer) Request(id string) *WotServer {
+	view := *s
+	view.requestID = id
+	return &view
+}
+
+// RequestID returns ID of request served by property handler, it is valid only in property handlers,
+// which are executed by Thing goroutine one by one. Action handlers use ActionRequestID.
+func (s *WotServer) RequestID() string {
+	id, _ := s.core.requestID.Load().(string)
+	return id
+}
+
+// ActionRequestID returns ID of request which invoked action, ph is progress handler passed to action handler
+func ActionRequestID(ph async.ProgressHandler) string {
+	if rp, ok := ph.(*requestProgress); ok {
+		return rp.requestID
+	}
+
+	return ""
+}
+
+// requestProgress carries request ID to action handler with progress handler of the invocation
+type requestProgress struct {
+	async.ProgressHandler
+	requestID string
+}
+
+// serve runs property handler call as call of request requestID
+func (wc *WotCore) serve(requestID string, call func() interface{}) interface{} {
+	wc.requestID.Store(requestID)
+	defer wc.requestID.Store("")
+
+	return call()
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseRequestID(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "requests"})
+	s.AddProperty("level", model.Property{Name: "level", Writable: true})
+	s.AddAction("reset", model.InputData{}, model.OutputData{})
+
+	var readBy, writtenBy, invokedBy string
+	s.OnGetProperty("level", func() interface{} {
+		readBy = s.RequestID()
+		return 1
+	})
+	s.OnUpdateProperty("level", func(interface{}) {
+		writtenBy = s.RequestID()
+	})
+	s.OnInvokeAction("reset", func(arg interface{}, ph async.ProgressHandler) interface{} {
+		invokedBy = ActionRequestID(ph)
+		return nil
+	})
+
+	tapped := make([]string, 0)
+	s.AddTap(func(i *Interaction) {
+		tapped = append(tapped, i.RequestID)
+	})
+
+	s.Request("rq-1").GetProperty("level").Get()
+	s.Request("rq-2").SetProperty("level", 2).Get()
+	s.Request("rq-3").InvokeAction("reset", nil, &recordingHandler{}).Get()
+
+	Equals("read", t, "rq-1", readBy)
+	Equals("written", t, "rq-2", writtenBy)
+	Equals("invoked", t, "rq-3", invokedBy)
+
+	s.GetProperty("level").Get()
+	Equals("unattributed read", t, "", readBy)
+	Equals("taps", t, "rq-1,rq-2,rq-3,", strings.Join(tapped, ","))
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// Snapshot is state of Thing at one moment: its description, values of all readable properties and
+// pending action tasks. Properties which could not be read are reported in Errors, streamed
+// properties are not part of snapshot.
+type Snapshot struct {
+	Time        time.Time               `json:"time"`
+	Description *model.ThingDescription `json:"description,omitempty"`
+	Properties  map[string]interface{}  `json:"properties"`
+	Errors      map[string]string       `json:"errors,omitempty"`
+	Tasks       []*TaskInfo             `json:"tasks,omitempty"`
+}
+
+// RestoreResult lists properties written by Restore, read-only and unknown properties of snapshot
+// are skipped
+type RestoreResult struct {
+	Restored []string          `json:"restored"`
+	Skipped  []string          `json:"skipped,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// Snapshot reads all properties of the Thing, tasks are tracked by frontends and are filled by them
+func (s *WotServer) Snapshot() *Snapshot {
+	snap := &Snapshot{
+		Time:        time.Now(),
+		Description: s.GetDescription(),
+		Properties:  make(map[string]interface{}),
+		Errors:      make(map[string]string),
+	}
+
+	for _, p := range s.GetDescription().Properties {
+		value := s.GetProperty(p.Name).Get()
+
+		switch v := value.(type) {
+		case Status:
+			if v != WOT_OK {
+				snap.Errors[p.Name] = fmt.Sprint("status ", int(v))
+			}
+		case error:
+			snap.Errors[p.Name] = v.Error()
+		case *Stream:
+			closeStream(v.Reader)
+		case io.Reader:
+			closeStream(v)
+		default:
+			snap.Properties[p.Name] = value
+		}
+	}
+
+	return snap
+}
+
+// Restore writes values of writable properties kept by snapshot, e.g. taken from other instance of
+// the same Thing Model when cloning device
+func (s *WotServer) Restore(snap *Snapshot) *RestoreResult {
+	result := &RestoreResult{
+		Restored: make([]string, 0),
+		Skipped:  make([]string, 0),
+		Errors:   make(map[string]string),
+	}
+
+	names := make([]string, 0, len(snap.Properties))
+	for name := range snap.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := snap.Properties[name]
+		p, ok := s.core.property(name)
+		if !ok || !p.Writable {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+
+		if err := p.ValueType.Validate(value); err != nil {
+			result.Errors[name] = err.Error()
+			continue
+		}
+
+		switch v := s.SetProperty(name, value).Get().(type) {
+		case Status:
+			if v != WOT_OK {
+				result.Errors[name] = fmt.Sprint("status ", int(v))
+				continue
+			}
+		case error:
+			result.Errors[name] = v.Error()
+			continue
+		}
+
+		result.Restored = append(result.Restored, name)
+	}
+
+	return result
+}
+
+// Err summarizes failed property writes, nil when all writes succeeded
+func (r *RestoreResult) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(r.Errors))
+	for name := range r.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msg := "Restore failed:"
+	for _, name := range names {
+		msg = str.Concat(msg, " ", name, " -> ", r.Errors[name], ";")
+	}
+
+	return errors.New(msg)
+}
+
+func closeStream(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		c.Close()
+	}
+}
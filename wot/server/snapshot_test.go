@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func snapshotThing(values map[string]interface{}) *WotServer {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name: "thermostat",
+		Properties: []model.Property{
+			{Name: "setpoint", Writable: true, ValueType: model.ValueType{Type: "number"}},
+			{Name: "temperature"},
+		},
+	})
+
+	for _, name := range []string{"setpoint", "temperature"} {
+		name := name
+		s.OnGetProperty(name, func() interface{} { return values[name] })
+		s.OnUpdateProperty(name, func(v interface{}) { values[name] = v })
+	}
+
+	return s
+}
+
+func TestCaseSnapshotRestore(t *testing.T) {
+	source := snapshotThing(map[string]interface{}{"setpoint": 21.5, "temperature": 19.0})
+	snap := source.Snapshot()
+
+	Equals("Snapshot setpoint", t, 21.5, snap.Properties["setpoint"])
+	Equals("Snapshot temperature", t, 19.0, snap.Properties["temperature"])
+
+	values := map[string]interface{}{"setpoint": 18.0, "temperature": 25.0}
+	result := snapshotThing(values).Restore(snap)
+
+	Equals("Restore error", t, nil, result.Err())
+	Equals("Restored", t, 1, len(result.Restored))
+	Equals("Skipped read-only", t, "temperature", result.Skipped[0])
+	Equals("Setpoint cloned", t, 21.5, values["setpoint"])
+	Equals("Temperature kept", t, 25.0, values["temperature"])
+
+	snap.Properties["setpoint"] = "warm"
+	result = snapshotThing(values).Restore(snap)
+	Equals("Invalid value", t, true, result.Err() != nil)
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Stream is property value streamed to clients instead of being encoded, e.g. camera snapshot
+// or log dump. Property getter may return *Stream or plain io.Reader. Readers implementing
+// io.Seeker support range requests, readers implementing io.Closer are closed after use.
+// Stream results of finished actions are kept to be downloaded repeatedly and are closed once
+// their task is dropped.
+//
+// Streams are also used for binary action input (e.g. firmware image upload) and output.
+// Action handler receiving Stream input has to consume it before returning, the upload is
+// held open only until the handler returns.
+type Stream struct {
+	Reader      io.Reader
+	ContentType string
+	ModTime     time.Time
+	// Name is file name of uploaded or downloaded content, if known
+	Name string
+	// Params are form fields sent along with multipart upload
+	Params map[string]string
+}
+
+// isStream reports whether value is streamed, as *Stream or plain io.Reader returned by property getter
+func isStream(v interface{}) bool {
+	switch v.(type) {
+	case *Stream, io.Reader:
+		return true
+	}
+
+	return false
+}
+
+// MarshalJSON describes stream in encoded messages, such as task status, content is never encoded
+func (s *Stream) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		ContentType string `json:"contentType,omitempty"`
+		Name        string `json:"name,omitempty"`
+		Stream      bool   `json:"stream"`
+	}{s.ContentType, s.Name, true})
+}
+
+// Section returns reader of stream content independent of other readers of the stream, so stored
+// content, e.g. action result, may be read repeatedly and concurrently. Content has to be readable
+// at offset and of known size.
+func (s *Stream) Section() (*io.SectionReader, bool) {
+	ra, ok := s.Reader.(io.ReaderAt)
+	if !ok {
+		return nil, false
+	}
+
+	switch sized := s.Reader.(type) {
+	case interface{ Size() int64 }:
+		return io.NewSectionReader(ra, 0, sized.Size()), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := sized.Stat()
+		if err != nil {
+			return nil, false
+		}
+		return io.NewSectionReader(ra, 0, fi.Size()), true
+	}
+
+	return nil, false
+}
+
+// storedStream prepares stream result of finished task for Section, seekable content which is not
+// readable at offset is read at offset by seeking under lock
+func storedStream(v interface{}) interface{} {
+	var s *Stream
+	switch r := v.(type) {
+	case *Stream:
+		s = r
+	case io.Reader:
+		s = &Stream{Reader: r}
+	default:
+		return v
+	}
+
+	if _, ok := s.Section(); ok {
+		return v
+	}
+
+	rs, ok := s.Reader.(io.ReadSeeker)
+	if !ok {
+		return v
+	}
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return v
+	}
+
+	stored := *s
+	stored.Reader = &seekReaderAt{rs: rs, size: size}
+	return &stored
+}
+
+// seekReaderAt reads seekable content at offset, seeks of concurrent readers are serialized
+type seekReaderAt struct {
+	mu   sync.Mutex
+	rs   io.ReadSeeker
+	size int64
+	off  int64
+}
+
+func (sr *seekReaderAt) Read(p []byte) (int, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, err := sr.rs.Seek(sr.off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := sr.rs.Read(p)
+	sr.off += int64(n)
+	return n, err
+}
+
+func (sr *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, err := sr.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return io.ReadFull(sr.rs, p)
+}
+
+func (sr *seekReaderAt) Seek(offset int64, whence int) (int64, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += sr.off
+	case io.SeekEnd:
+		offset += sr.size
+	}
+
+	if offset < 0 {
+		return 0, errors.New("Seek before start of stream")
+	}
+
+	sr.off = offset
+	return offset, nil
+}
+
+func (sr *seekReaderAt) Size() int64 {
+	return sr.size
+}
+
+func (sr *seekReaderAt) Close() error {
+	if c, ok := sr.rs.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
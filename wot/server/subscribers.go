@@ -13,41 +13,178 @@ import (
 // then contains all connected clients
 type Subscribers struct {
 	rwmut        *sync.RWMutex
-	subscription map[string]*async.FanOut
+	subscription map[string]*Subscription
+}
+
+// Subscription is one real subscription. Thing and Name identify interaction subscription belongs to,
+// OnCancel is called when subscription is cancelled, e.g. to remove event listener from WotServer.
+// Replay is set for subscriptions with acknowledged delivery.
+type Subscription struct {
+	ID       string
+	Thing    string
+	Name     string
+	Clients  *async.FanOut
+	OnCancel func()
+	Replay   *ReplayBuffer
+	done     chan struct{}
+}
+
+type SubscriptionInfo struct {
+	ID      string `json:"id"`
+	Thing   string `json:"thing"`
+	Name    string `json:"name"`
+	Clients int    `json:"clients"`
 }
 
 func NewSubscribers() *Subscribers {
 	return &Subscribers{
 		rwmut:        &sync.RWMutex{},
-		subscription: make(map[string]*async.FanOut),
+		subscription: make(map[string]*Subscription),
 	}
 }
 
-func (wss *Subscribers) CreateSubscription(subscriptionID string, clients *async.FanOut) {
+func (wss *Subscribers) CreateSubscription(sub *Subscription) {
 	wss.rwmut.Lock()
 	defer wss.rwmut.Unlock()
 
-	wss.subscription[subscriptionID] = clients
+	sub.done = make(chan struct{})
+	wss.subscription[sub.ID] = sub
 }
 
-func (wss *Subscribers) CancelSubscription(subscriptionID string) {
+func (wss *Subscribers) CancelSubscription(subscriptionID string) bool {
 	wss.rwmut.Lock()
-	defer wss.rwmut.Unlock()
-
-	wss.subscription[subscriptionID].RemoveAllSubscribes()
+	sub, ok := wss.subscription[subscriptionID]
 	delete(wss.subscription, subscriptionID)
+	wss.rwmut.Unlock()
+
+	if ok {
+		sub.cancel()
+	}
+
+	return ok
+}
+
+// CancelThing cancels all subscriptions of the Thing
+func (wss *Subscribers) CancelThing(thing string) {
+	wss.rwmut.Lock()
+	cancelled := make([]*Subscription, 0)
+	for id, sub := range wss.subscription {
+		if sub.Thing == thing {
+			cancelled = append(cancelled, sub)
+			delete(wss.subscription, id)
+		}
+	}
+	wss.rwmut.Unlock()
+
+	for _, sub := range cancelled {
+		sub.cancel()
+	}
+}
+
+func (sub *Subscription) cancel() {
+	sub.Clients.RemoveAllSubscribes()
+	close(sub.done)
+
+	if sub.OnCancel != nil {
+		sub.OnCancel()
+	}
+}
+
+// Subscription returns subscription by its ID
+func (wss *Subscribers) Subscription(subscriptionID string) (*Subscription, bool) {
+	wss.rwmut.RLock()
+	defer wss.rwmut.RUnlock()
+
+	sub, ok := wss.subscription[subscriptionID]
+	return sub, ok
+}
+
+// Clients returns clients of subscription, e.g. to report progress of task started later
+func (wss *Subscribers) Clients(subscriptionID string) (*async.FanOut, bool) {
+	wss.rwmut.RLock()
+	defer wss.rwmut.RUnlock()
+
+	if sub, ok := wss.subscription[subscriptionID]; ok {
+		return sub.Clients, true
+	}
+
+	return nil, false
 }
 
-func (wss *Subscribers) AddClient(subscriptionID string, client chan<- interface{}) int {
+// Replay returns replay buffer of subscription with acknowledged delivery
+func (wss *Subscribers) Replay(subscriptionID string) (*ReplayBuffer, bool) {
 	wss.rwmut.RLock()
 	defer wss.rwmut.RUnlock()
 
-	return wss.subscription[subscriptionID].AddSubscriber(client)
+	if sub, ok := wss.subscription[subscriptionID]; ok && sub.Replay != nil {
+		return sub.Replay, true
+	}
+
+	return nil, false
+}
+
+// AddClientLimit adds client unless subscription is unknown or already has max clients, max <= 0 is unlimited
+func (wss *Subscribers) AddClientLimit(subscriptionID string, client chan<- interface{}, max int) (int, bool) {
+	wss.rwmut.Lock()
+	defer wss.rwmut.Unlock()
+
+	sub, ok := wss.subscription[subscriptionID]
+	if !ok || (max > 0 && sub.Clients.Len() >= max) {
+		return 0, false
+	}
+
+	return sub.Clients.AddSubscriber(client), true
 }
 
 func (wss *Subscribers) RemoveClient(subscriptionID string, clientID int) {
 	wss.rwmut.RLock()
 	defer wss.rwmut.RUnlock()
 
-	wss.subscription[subscriptionID].RemoveSubscriber(clientID)
+	if sub, ok := wss.subscription[subscriptionID]; ok {
+		sub.Clients.RemoveSubscriber(clientID)
+	}
+}
+
+// ClientRemoved returns channel closed once client is removed from subscription, e.g. because it did not keep up
+// with events, see async.FanOut.Removed
+func (wss *Subscribers) ClientRemoved(subscriptionID string, clientID int) <-chan struct{} {
+	wss.rwmut.RLock()
+	defer wss.rwmut.RUnlock()
+
+	if sub, ok := wss.subscription[subscriptionID]; ok {
+		return sub.Clients.Removed(clientID)
+	}
+
+	removed := make(chan struct{})
+	close(removed)
+	return removed
+}
+
+// Done returns channel closed when subscription is cancelled
+func (wss *Subscribers) Done(subscriptionID string) <-chan struct{} {
+	wss.rwmut.RLock()
+	defer wss.rwmut.RUnlock()
+
+	if sub, ok := wss.subscription[subscriptionID]; ok {
+		return sub.done
+	}
+
+	return nil
+}
+
+func (wss *Subscribers) List() []*SubscriptionInfo {
+	wss.rwmut.RLock()
+	defer wss.rwmut.RUnlock()
+
+	infos := make([]*SubscriptionInfo, 0, len(wss.subscription))
+	for _, sub := range wss.subscription {
+		infos = append(infos, &SubscriptionInfo{
+			ID:      sub.ID,
+			Thing:   sub.Thing,
+			Name:    sub.Name,
+			Clients: sub.Clients.Len(),
+		})
+	}
+
+	return infos
 }
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+)
+
+func TestCaseSubscriptionClientLimit(t *testing.T) {
+	subs := NewSubscribers()
+	subs.CreateSubscription(&Subscription{ID: "s", Clients: async.NewFanOut()})
+
+	_, ok := subs.AddClientLimit("unknown", make(chan interface{}), 0)
+	Equals("unknown subscription", t, false, ok)
+
+	first, ok := subs.AddClientLimit("s", make(chan interface{}), 2)
+	Equals("first", t, true, ok)
+	_, ok = subs.AddClientLimit("s", make(chan interface{}), 2)
+	Equals("second", t, true, ok)
+	_, ok = subs.AddClientLimit("s", make(chan interface{}), 2)
+	Equals("over limit", t, false, ok)
+
+	subs.RemoveClient("s", first)
+	_, ok = subs.AddClientLimit("s", make(chan interface{}), 2)
+	Equals("after remove", t, true, ok)
+
+	_, ok = subs.AddClientLimit("s", make(chan interface{}), 0)
+	Equals("unlimited", t, true, ok)
+}
@@ -0,0 +1,66 @@
+package server
+
+import "time"
+
+const (
+	INTERACTION_READ   = "readproperty"
+	INTERACTION_WRITE  = "writeproperty"
+	INTERACTION_INVOKE = "invokeaction"
+	INTERACTION_EVENT  = "event"
+	// INTERACTION_PUSH is property value pushed by device, Input is the value
+	INTERACTION_PUSH = "pushproperty"
+)
+
+// Interaction is completed property read or write, action invocation or emitted event. Input is written
+// value, action argument or event data, Output is read value or action result. RequestID identifies
+// request the interaction was called by, see WotServer.Request.
+type Interaction struct {
+	Time      time.Time   `json:"time"`
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Input     interface{} `json:"input,omitempty"`
+	Output    interface{} `json:"output,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+	// Latency is duration of handler call, e.g. round trip to device
+	Latency time.Duration `json:"latency,omitempty"`
+}
+
+// Tap observes interactions of WotServer, e.g. to record traffic. Taps are called synchronously
+// by goroutine executing the interaction, usually Thing goroutine, so they must not block.
+type Tap func(*Interaction)
+
+// AddTap registers tap observing all interactions of the Thing
+func (s *WotServer) AddTap(tap Tap) *WotServer {
+	s.core.l.Lock()
+	s.core.taps = append(s.core.taps, tap)
+	s.core.l.Unlock()
+
+	return s
+}
+
+func (wc *WotCore) tap(kind, name string, input, output interface{}, requestID string, latency time.Duration) {
+	now := time.Now()
+	wc.remember(kind, name, input, output, now)
+
+	wc.l.RLock()
+	taps := wc.taps
+	wc.l.RUnlock()
+
+	if len(taps) == 0 {
+		return
+	}
+
+	i := &Interaction{
+		Time:      now,
+		Kind:      kind,
+		Name:      name,
+		Input:     input,
+		Output:    output,
+		RequestID: requestID,
+		Latency:   latency,
+	}
+
+	for _, tap := range taps {
+		tap(i)
+	}
+}
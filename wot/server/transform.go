@@ -0,0 +1,305 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/model"
+)
+
+// Transformer converts raw value of backend to value declared by ThingDescription and back
+type Transformer interface {
+	Forward(raw interface{}) (interface{}, error)
+	Inverse(value interface{}) (interface{}, error)
+}
+
+// Transform is chain of transformers, Forward applies them in order and Inverse in reverse order
+type Transform []Transformer
+
+func (t Transform) Forward(raw interface{}) (interface{}, error) {
+	v := raw
+
+	for _, step := range t {
+		var err error
+		if v, err = step.Forward(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func (t Transform) Inverse(value interface{}) (interface{}, error) {
+	v := value
+
+	for i := len(t) - 1; i >= 0; i-- {
+		var err error
+		if v, err = t[i].Inverse(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// NewTransform creates chain configured by transforms of property description
+func NewTransform(specs []model.Transform) (Transform, error) {
+	t := make(Transform, 0, len(specs))
+
+	for _, spec := range specs {
+		switch {
+		case spec.ByteSwap != 0:
+			step, err := ByteSwap(spec.ByteSwap, spec.Signed)
+			if err != nil {
+				return nil, err
+			}
+			t = append(t, step)
+		case spec.Enum != nil:
+			t = append(t, EnumMap(spec.Enum))
+		case spec.Scale != 0 || spec.Offset != 0:
+			scale := spec.Scale
+			if scale == 0 {
+				scale = 1
+			}
+			t = append(t, Scale(scale, spec.Offset))
+		default:
+			return nil, errors.New("Transform sets none of byteSwap, scale, offset or enum")
+		}
+	}
+
+	return t, nil
+}
+
+// TransformProperty converts values of property between backend and TD representation, reads and
+// notified changes are converted forward and written values inverse. Chain replaces transforms of
+// property description.
+func (s *WotServer) TransformProperty(propertyName string, chain ...Transformer) *WotServer {
+	if s.core.checkProperty(propertyName) == false {
+		panic("Property not defined.")
+	}
+
+	s.core.l.Lock()
+	s.core.transforms[propertyName] = Transform(chain)
+	s.core.l.Unlock()
+
+	return s
+}
+
+// ----- SCALE
+
+type scale struct {
+	factor float64
+	offset float64
+}
+
+// Scale converts raw numeric value to raw * factor + offset, e.g. tenths of degree to degrees
+func Scale(factor, offset float64) Transformer {
+	return &scale{factor: factor, offset: offset}
+}
+
+func (s *scale) Forward(raw interface{}) (interface{}, error) {
+	n, err := transformNumber(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return n*s.factor + s.offset, nil
+}
+
+// Inverse returns integer when raw value is whole number, backends typically expect register values
+func (s *scale) Inverse(value interface{}) (interface{}, error) {
+	n, err := transformNumber(value)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := (n - s.offset) / s.factor
+	if r := math.Round(raw); math.Abs(raw-r) < 1e-9 {
+		return int64(r), nil
+	}
+
+	return raw, nil
+}
+
+// ----- ENUM
+
+type enumMap struct {
+	labels map[string]string
+	codes  map[string]string
+}
+
+// EnumMap converts raw codes to labels, codes are matched by their decimal or string form
+func EnumMap(labels map[string]string) Transformer {
+	codes := make(map[string]string)
+	for code, label := range labels {
+		codes[label] = code
+	}
+
+	return &enumMap{labels: labels, codes: codes}
+}
+
+func (e *enumMap) Forward(raw interface{}) (interface{}, error) {
+	code := enumCode(raw)
+
+	label, ok := e.labels[code]
+	if !ok {
+		return nil, errors.New(str.Concat("Unknown enum code: ", code))
+	}
+
+	return label, nil
+}
+
+// Inverse returns numeric codes as int64
+func (e *enumMap) Inverse(value interface{}) (interface{}, error) {
+	label := fmt.Sprint(value)
+
+	code, ok := e.codes[label]
+	if !ok {
+		return nil, errors.New(str.Concat("Unknown enum label: ", label))
+	}
+
+	if n, err := strconv.ParseInt(code, 10, 64); err == nil {
+		return n, nil
+	}
+
+	return code, nil
+}
+
+func enumCode(raw interface{}) string {
+	if n, ok := model.Number(raw); ok && n == math.Trunc(n) {
+		return strconv.FormatInt(int64(n), 10)
+	}
+
+	return strings.TrimSpace(fmt.Sprint(raw))
+}
+
+// ----- BYTE SWAP
+
+type byteSwap struct {
+	size   int
+	signed bool
+}
+
+// ByteSwap reverses byte order of integer of size bytes (2, 4 or 8), e.g. little endian register
+// read by big endian backend. Signed interprets swapped value as two's complement.
+func ByteSwap(size int, signed bool) (Transformer, error) {
+	if size != 2 && size != 4 && size != 8 {
+		return nil, errors.New(str.Concat("Unsupported byteSwap size: ", strconv.Itoa(size)))
+	}
+
+	return &byteSwap{size: size, signed: signed}, nil
+}
+
+func (b *byteSwap) Forward(raw interface{}) (interface{}, error) {
+	u, err := b.bits(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped := b.swap(u)
+	if b.signed {
+		shift := uint(64 - 8*b.size)
+		return int64(swapped<<shift) >> shift, nil
+	}
+
+	return int64(swapped), nil
+}
+
+// Inverse returns swapped bits as unsigned integer
+func (b *byteSwap) Inverse(value interface{}) (interface{}, error) {
+	u, err := b.bits(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return int64(b.swap(u)), nil
+}
+
+func (b *byteSwap) bits(v interface{}) (uint64, error) {
+	n, err := transformNumber(v)
+	if err != nil {
+		return 0, err
+	}
+
+	if n != math.Trunc(n) {
+		return 0, fmt.Errorf("byteSwap of non integer value %v", v)
+	}
+
+	u := uint64(int64(n))
+	if b.size < 8 {
+		u &= 1<<uint(8*b.size) - 1
+	}
+
+	return u, nil
+}
+
+func (b *byteSwap) swap(u uint64) uint64 {
+	var swapped uint64
+
+	for i := 0; i < b.size; i++ {
+		swapped = swapped<<8 | u&0xff
+		u >>= 8
+	}
+
+	return swapped
+}
+
+// transformNumber accepts numbers and numeric strings, backends such as MQTT deliver raw text payloads
+func transformNumber(v interface{}) (float64, error) {
+	if n, ok := model.Number(v); ok {
+		return n, nil
+	}
+
+	if s, ok := v.(string); ok {
+		if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("expected number, got %v", v)
+}
+
+func (wc *WotCore) transform(name string) (Transform, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	t, ok := wc.transforms[name]
+	return t, ok
+}
+
+// forward converts raw value read from backend, errors are returned as value like other read failures
+func (wc *WotCore) forward(name string, raw interface{}) interface{} {
+	t, ok := wc.transform(name)
+	if !ok {
+		return raw
+	}
+
+	switch raw.(type) {
+	case Status, error:
+		return raw
+	}
+
+	v, err := t.Forward(raw)
+	if err != nil {
+		return err
+	}
+
+	return v
+}
+
+func (wc *WotCore) inverse(name string, value interface{}) (interface{}, error) {
+	if err := wc.checkCiphertext(name, value); err != nil {
+		return nil, err
+	}
+
+	t, ok := wc.transform(name)
+	if !ok {
+		return value, nil
+	}
+
+	return t.Inverse(value)
+}
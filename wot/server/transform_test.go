@@ -0,0 +1,62 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseTransformFromDescription(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name: "boiler",
+		Properties: []model.Property{
+			{Name: "temperature", Writable: true, Transforms: []model.Transform{{Scale: 0.1, Offset: -40}}},
+			{Name: "mode", Writable: true, Transforms: []model.Transform{{Enum: map[string]string{"0": "off", "1": "heating"}}}},
+		},
+	})
+
+	var raw interface{} = "650"
+	s.OnGetProperty("temperature", func() interface{} { return raw })
+	s.OnUpdateProperty("temperature", func(v interface{}) { raw = v })
+
+	var mode interface{} = 1
+	s.OnGetProperty("mode", func() interface{} { return mode })
+	s.OnUpdateProperty("mode", func(v interface{}) { mode = v })
+
+	Equals("Scaled read", t, 25.0, s.GetProperty("temperature").Get())
+
+	s.SetProperty("temperature", 21.5).Get()
+	Equals("Scaled write", t, int64(615), raw)
+
+	Equals("Enum read", t, "heating", s.GetProperty("mode").Get())
+
+	s.SetProperty("mode", "off").Get()
+	Equals("Enum write", t, int64(0), mode)
+
+	_, isErr := s.SetProperty("mode", "cooling").Get().(error)
+	Equals("Unknown label", t, true, isErr)
+}
+
+func TestCaseByteSwap(t *testing.T) {
+	swap, _ := ByteSwap(2, true)
+
+	v, _ := swap.Forward(0x18FC)
+	Equals("Signed swap", t, int64(-1000), v)
+
+	v, _ = swap.Inverse(-1000)
+	Equals("Inverse swap", t, int64(0x18FC), v)
+
+	_, err := ByteSwap(3, false)
+	Equals("Invalid size", t, true, err != nil)
+}
+
+func TestCaseTransformChain(t *testing.T) {
+	swap, _ := ByteSwap(2, false)
+	chain := Transform{swap, Scale(0.5, 0)}
+
+	v, _ := chain.Forward(0x0A00)
+	Equals("Chain forward", t, 5.0, v)
+
+	v, _ = chain.Inverse(5.0)
+	Equals("Chain inverse", t, int64(0x0A00), v)
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+)
+
+// Typed helpers decode interface{} values exchanged with WotServer to concrete types, values
+// are validated against ThingDescription value types before conversion.
+
+var statusText = map[Status]string{
+	WOT_UNKNOWN_ACTION:          "unknown action",
+	WOT_NO_ACTION_HANDLER:       "no action handler",
+	WOT_NO_PROPERTY_GET_HANDLER: "no property get handler",
+	WOT_NO_PROPERTY_SET_HANDLER: "no property set handler",
+	WOT_UNKNOWN_PROPERTY:        "unknown property",
+	WOT_UNKNOWN_EVENT:           "unknown event",
+	WOT_PROPERTY_CONFLICT:       "property value changed",
+	WOT_ACTION_BUSY:             "action busy",
+	WOT_INVALID_VALUE:           "invalid value",
+	WOT_THING_OFFLINE:           "thing offline",
+	WOT_WRITE_QUEUED:            "write queued",
+	WOT_THING_BUSY:              "thing busy",
+}
+
+// ErrNotFound matches StatusError of interaction not declared by Thing, see errors.Is
+var ErrNotFound = errors.New("interaction not found")
+
+// ErrOffline matches StatusError of interaction refused by offline Thing, see TrackAvailability
+var ErrOffline = errors.New("thing offline")
+
+// ErrBusy matches StatusError of interaction refused by full bulkhead of Thing, see Bulkhead
+var ErrBusy = errors.New("thing busy")
+
+// ErrInvalid matches ValueError and StatusError of value not conforming to value type of interaction
+var ErrInvalid = errors.New("invalid value")
+
+// ValueError reports value written to property or passed to action not conforming to its value type
+type ValueError struct {
+	Name string
+	Err  error
+}
+
+func (e *ValueError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e *ValueError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError reports non WOT_OK status of WotServer call
+type StatusError struct {
+	Status Status
+}
+
+func (e *StatusError) Is(target error) bool {
+	return (target == ErrNotFound && e.Status.NotFound()) ||
+		(target == ErrOffline && e.Status == WOT_THING_OFFLINE) ||
+		(target == ErrBusy && e.Status == WOT_THING_BUSY) ||
+		(target == ErrInvalid && e.Status == WOT_INVALID_VALUE)
+}
+
+// NotFound reports whether status is result of call of interaction not declared by Thing
+func (s Status) NotFound() bool {
+	return s == WOT_UNKNOWN_PROPERTY || s == WOT_UNKNOWN_ACTION || s == WOT_UNKNOWN_EVENT
+}
+
+func (e *StatusError) Error() string {
+	if text, ok := statusText[e.Status]; ok {
+		return text
+	}
+
+	return fmt.Sprintf("wot status %d", int(e.Status))
+}
+
+// As converts v to T. Numbers are converted between numeric kinds, when it is lossless
+// for integers, other values not assignable to T are converted through their JSON form.
+func As[T any](v interface{}) (T, error) {
+	var zero T
+
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+
+	if v == nil {
+		return zero, errors.New(str.Concat("expected ", reflect.TypeOf((*T)(nil)).Elem().String(), ", got nil"))
+	}
+
+	target := reflect.ValueOf(&zero).Elem()
+	value := reflect.ValueOf(v)
+
+	if isNumber(target.Kind()) && isNumber(value.Kind()) {
+		converted := value.Convert(target.Type())
+		if !converted.Convert(value.Type()).Equal(value) {
+			return zero, fmt.Errorf("%v does not fit %s", v, target.Type())
+		}
+		target.Set(converted)
+		return zero, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return zero, err
+	}
+
+	if err = json.Unmarshal(data, &zero); err != nil {
+		return zero, fmt.Errorf("expected %s, got %T", target.Type(), v)
+	}
+
+	return zero, nil
+}
+
+// SameValue reports whether a and b have the same JSON form
+func SameValue(a, b interface{}) bool {
+	da, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	db, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(da, db)
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// GetPropertyAs reads property and converts its value to T
+func GetPropertyAs[T any](s *WotServer, propertyName string) (T, error) {
+	var zero T
+
+	if !s.core.checkProperty(propertyName) {
+		return zero, &StatusError{WOT_UNKNOWN_PROPERTY}
+	}
+
+	v := s.GetProperty(propertyName).Get()
+	if status, ok := v.(Status); ok {
+		return zero, &StatusError{status}
+	}
+	if err, ok := v.(error); ok {
+		return zero, err
+	}
+
+	return As[T](v)
+}
+
+// SetPropertyAs writes value, value not conforming to property value type is returned *ValueError
+func SetPropertyAs[T any](s *WotServer, propertyName string, value T) error {
+	switch result := s.SetProperty(propertyName, value).Get().(type) {
+	case error:
+		return result
+	case Status:
+		if result != WOT_OK {
+			return &StatusError{result}
+		}
+	}
+
+	return nil
+}
+
+// OnUpdatePropertyAs registers typed property update listener, values failing validation are dropped
+func OnUpdatePropertyAs[T any](s *WotServer, propertyName string, propUpdateListener func(newValue T)) *WotServer {
+	prop, _ := s.core.property(propertyName)
+
+	return s.OnUpdateProperty(propertyName, func(v interface{}) {
+		if prop.ValueType.Validate(v) != nil {
+			return
+		}
+		if value, err := As[T](v); err == nil {
+			propUpdateListener(value)
+		}
+	})
+}
+
+// OnInvokeActionAs registers typed action handler, invalid input fails the task without calling handler
+func OnInvokeActionAs[I any](s *WotServer, actionName string, actionHandler func(I, async.ProgressHandler) interface{}) *WotServer {
+	action, _ := s.core.action(actionName)
+
+	return s.OnInvokeAction(actionName, func(arg interface{}, ph async.ProgressHandler) interface{} {
+		if err := action.InputData.ValueType.Validate(arg); err != nil {
+			ph.Fail(err.Error())
+			return nil
+		}
+
+		input, err := As[I](arg)
+		if err != nil {
+			ph.Fail(err.Error())
+			return nil
+		}
+
+		return actionHandler(input, ph)
+	})
+}
+
+// EmitEventAs validates data against event value type and emits event
+func EmitEventAs[T any](s *WotServer, eventName string, data T) error {
+	if event, ok := s.core.event(eventName); ok {
+		if err := event.ValueType.Validate(data); err != nil {
+			return err
+		}
+	}
+
+	if status := s.EmitEvent(eventName, data); status != WOT_OK {
+		return &StatusError{status}
+	}
+
+	return nil
+}
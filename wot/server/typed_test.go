@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseAsNumbers(t *testing.T) {
+	i, err := As[int](float64(42))
+	Equals("float to int", t, 42, i)
+	Equals("float to int err", t, nil, err)
+
+	_, err = As[int](1.5)
+	Equals("fraction to int", t, true, err != nil)
+
+	_, err = As[uint8](float64(300))
+	Equals("overflow", t, true, err != nil)
+
+	_, err = As[bool]("true")
+	Equals("string to bool", t, true, err != nil)
+}
+
+func TestCaseAsStruct(t *testing.T) {
+	type reading struct {
+		Name string  `json:"name"`
+		Data float64 `json:"data"`
+	}
+
+	r, err := As[reading](map[string]interface{}{"name": "temperature", "data": 21.5})
+	Equals("struct err", t, nil, err)
+	Equals("struct", t, reading{"temperature", 21.5}, r)
+}
+
+func TestCaseTypedAction(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "typed"})
+	s.AddAction("move", model.InputData{ValueType: model.ValueType{Type: "integer", Minimum: 0, Maximum: 49}}, model.OutputData{})
+
+	var got int
+	OnInvokeActionAs(s, "move", func(in int, ph async.ProgressHandler) interface{} {
+		got = in
+		return nil
+	})
+
+	ph := &recordingHandler{}
+	s.InvokeAction("move", float64(7), ph).Get()
+	Equals("valid input", t, 7, got)
+
+	ph = &recordingHandler{}
+	s.InvokeAction("move", float64(50), ph).Get()
+	Equals("out of range", t, true, ph.failed)
+}
+
+type recordingHandler struct {
+	failed bool
+}
+
+func (ph *recordingHandler) Schedule(interface{}) {}
+func (ph *recordingHandler) Update(interface{})   {}
+func (ph *recordingHandler) Done(interface{})     {}
+func (ph *recordingHandler) Fail(interface{})     { ph.failed = true }
+func (ph *recordingHandler) IsFailed() bool       { return ph.failed }
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
+
+func TestCaseCompareAndSet(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "cas"})
+	s.AddProperty("limit", model.Property{Name: "limit", Writable: true})
+
+	var limit interface{} = 1
+	s.OnGetProperty("limit", func() interface{} { return limit }).
+		OnUpdateProperty("limit", func(v interface{}) { limit = v })
+
+	Equals("stale expected", t, WOT_PROPERTY_CONFLICT, s.CompareAndSetProperty("limit", 2, 3).Get())
+	Equals("number forms", t, WOT_OK, s.CompareAndSetProperty("limit", float64(1), 3).Get())
+	Equals("written", t, 3, limit)
+}
+
+func TestCaseUnknownInteraction(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "unknown"})
+
+	Equals("get", t, WOT_UNKNOWN_PROPERTY, s.GetProperty("nope").Get())
+	Equals("set", t, WOT_UNKNOWN_PROPERTY, s.SetProperty("nope", 1).Get())
+
+	ph := &recordingHandler{}
+	Equals("invoke", t, WOT_UNKNOWN_ACTION, s.InvokeAction("nope", nil, ph).Get())
+	Equals("invoke failed", t, true, ph.failed)
+
+	err := error(&StatusError{WOT_UNKNOWN_PROPERTY})
+	Equals("not found", t, true, errors.Is(err, ErrNotFound))
+	Equals("busy found", t, false, errors.Is(&StatusError{WOT_ACTION_BUSY}, ErrNotFound))
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"github.com/conas/tno2/wot/model"
+)
+
+// TD_CHANGE_EVENT is event carrying TDChange notifications, Thing announcing changes of its description
+// declares it in its description
+const TD_CHANGE_EVENT = "tdchange"
+
+// TD_VERSIONS is number of the latest revisions of description kept
+const TD_VERSIONS = 16
+
+// TDChange announces new revision of description, as update event of TD directory
+type TDChange struct {
+	Name     string `json:"name"`
+	Version  int    `json:"version"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// versions are the latest revisions of description, older first
+type versions struct {
+	number int
+	kept   []*model.ThingDescription
+}
+
+// revise numbers changed description as the next version and keeps its copy, core lock is held.
+// Version of td is replaced, not modified, so copies taken before keep their number.
+func (wc *WotCore) revise() {
+	v := wc.versions
+	v.number++
+
+	version := model.VersionInfo{Number: v.number}
+	if wc.td.Version != nil {
+		version.Instance = wc.td.Version.Instance
+	}
+	wc.td.Version = &version
+
+	v.kept = append(v.kept, wc.snapshot())
+	if len(v.kept) > TD_VERSIONS {
+		v.kept = append([]*model.ThingDescription{}, v.kept[len(v.kept)-TD_VERSIONS:]...)
+	}
+}
+
+// Version returns number of current revision of description
+func (s *WotServer) Version() int {
+	s.core.l.RLock()
+	defer s.core.l.RUnlock()
+
+	return s.core.versions.number
+}
+
+// DescriptionVersion returns copy of description of version number, false when version is not kept
+func (s *WotServer) DescriptionVersion(number int) (*model.ThingDescription, bool) {
+	s.core.l.RLock()
+	defer s.core.l.RUnlock()
+
+	for _, td := range s.core.versions.kept {
+		if td.Version.Number == number {
+			copied := *td
+			return &copied, true
+		}
+	}
+
+	return nil, false
+}
+
+// tdChanged notifies listeners of TD_CHANGE_EVENT about current revision of description
+func (s *WotServer) tdChanged() {
+	if !s.core.checkEvent(TD_CHANGE_EVENT) {
+		return
+	}
+
+	s.core.l.RLock()
+	change := &TDChange{Name: s.core.td.Name, Version: s.core.versions.number, Instance: s.core.td.Version.Instance}
+	s.core.l.RUnlock()
+
+	s.EmitEvent(TD_CHANGE_EVENT, change)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+func TestCaseDescriptionVersions(t *testing.T) {
+	s := CreateFromDescription(&model.ThingDescription{
+		Name:    "lamp",
+		Version: &model.VersionInfo{Instance: "1.2.0"},
+		Events:  []model.Event{{Name: TD_CHANGE_EVENT}},
+	})
+	Equals("loaded", t, 1, s.Version())
+
+	changes := make(chan *TDChange, 4)
+	s.AddListener(TD_CHANGE_EVENT, &EventListener{ID: "l", CB: func(e interface{}) {
+		changes <- e.(*Event).Data.(*TDChange)
+	}})
+
+	s.AddProperty("on", model.Property{Name: "on"})
+	s.AddForms("http", &Forms{URIs: []string{"http://lamp/"}})
+
+	//events are delivered asynchronously, in any order
+	notified := make(map[int]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case change := <-changes:
+			notified[change.Version] = true
+			Equals("instance", t, "1.2.0", change.Instance)
+		case <-time.After(time.Second):
+			t.Fatal("description change not notified")
+		}
+	}
+	Equals("changes", t, true, notified[2] && notified[3])
+
+	first, ok := s.DescriptionVersion(1)
+	Equals("first kept", t, true, ok)
+	Equals("first properties", t, 0, len(first.Properties))
+	Equals("first number", t, 1, first.Version.Number)
+
+	current := s.Description()
+	Equals("current number", t, 3, current.Version.Number)
+	Equals("current uris", t, 1, len(current.Uris))
+
+	for i := 0; i < TD_VERSIONS; i++ {
+		s.RemoveForms("http").AddForms("http", &Forms{})
+	}
+	_, ok = s.DescriptionVersion(1)
+	Equals("oldest dropped", t, false, ok)
+	_, ok = s.DescriptionVersion(s.Version())
+	Equals("latest kept", t, true, ok)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -17,7 +18,33 @@ type WotCore struct {
 	propGetCB  map[string]func() interface{}
 	propSetCB  map[string]func(interface{})
 	actionCB   map[string]ActionHandler
+	limiters   map[string]*actionLimiter
+	notifiers  map[string]*notifier
+	transforms map[string]Transform
 	eventsCB   map[string][]*EventListener
+	taps       []Tap
+	history    HistoryStore
+	requestID  *atomic.Value
+	// availability is tracked by heartbeats, nil when Thing does not track availability
+	availability *availability
+	known        *lastKnown
+	// queue keeps writes of offline Thing, nil when writes are not queued
+	queue *writeQueue
+	// bindings are forms of protocol bindings advertised in td
+	bindings *bindings
+	// cached are last values pushed by device for properties declaring Push or polled for properties
+	// declaring Poll
+	cached *lastKnown
+	// uncached are times cached values were dropped by writes, guarded by lock of cached
+	uncached map[string]time.Time
+	// poller reads properties declaring Poll, nil when Thing is not polled
+	poller *poller
+	// reads are property reads in flight joined by concurrent reads of the same property
+	reads *reads
+	// bulkhead caps interactions in flight, nil when they are not capped
+	bulkhead *bulkhead
+	// versions are the latest revisions of td
+	versions *versions
 }
 
 type EventListener struct {
@@ -29,6 +56,7 @@ type Event struct {
 	Event     string      `json:"event,omitempty"`
 	Timestamp time.Time   `json:"timestamp,omitempty"`
 	Data      interface{} `json:"data,omitempty"`
+	memo      *sync.Map
 }
 
 func newEvent(eventName string, data interface{}) *Event {
@@ -36,9 +64,25 @@ func newEvent(eventName string, data interface{}) *Event {
 		Event:     eventName,
 		Timestamp: time.Now(),
 		Data:      data,
+		memo:      &sync.Map{},
 	}
 }
 
+// Memo returns value stored under key, calling produce to store it first if missing. The same Event
+// is passed to all listeners, so they can share derived data such as serialized payload.
+func (e *Event) Memo(key string, produce func() interface{}) interface{} {
+	if e.memo == nil {
+		return produce()
+	}
+
+	if v, ok := e.memo.Load(key); ok {
+		return v
+	}
+
+	v, _ := e.memo.LoadOrStore(key, produce())
+	return v
+}
+
 type eventsListeners struct {
 	lock     *sync.RWMutex
 	eventsCB map[string][]*EventListener
@@ -53,7 +97,17 @@ func NewWotCore() *WotCore {
 		propGetCB:  make(map[string]func() interface{}),
 		propSetCB:  make(map[string]func(interface{})),
 		actionCB:   make(map[string]ActionHandler),
+		limiters:   make(map[string]*actionLimiter),
+		notifiers:  make(map[string]*notifier),
+		transforms: make(map[string]Transform),
 		eventsCB:   make(map[string][]*EventListener),
+		requestID:  &atomic.Value{},
+		known:      newLastKnown(),
+		bindings:   newBindings(),
+		cached:     newLastKnown(),
+		uncached:   make(map[string]time.Time),
+		reads:      newReads(),
+		versions:   &versions{},
 	}
 }
 
@@ -64,15 +118,16 @@ func NewWotCoreFromTD(td *model.ThingDescription) *WotCore {
 	wc.td = td
 
 	for _, p := range td.Properties {
-		wc.properties[p.Name] = p
+		wc.addProperty(p)
 	}
 	for _, a := range td.Actions {
-		wc.actions[a.Name] = a
+		wc.addAction(a)
 	}
 	for _, e := range td.Events {
 		wc.events[e.Name] = e
 		wc.eventsCB[e.Name] = make([]*EventListener, 0)
 	}
+	wc.revise()
 
 	return wc
 }
@@ -82,7 +137,33 @@ func (wc *WotCore) PropertyAdd(p model.Property) {
 	defer wc.l.Unlock()
 
 	wc.td.Properties = append(wc.td.Properties, p)
+	wc.addProperty(p)
+	wc.revise()
+}
+
+func (wc *WotCore) addProperty(p model.Property) {
 	wc.properties[p.Name] = p
+
+	if p.Notify != nil {
+		wc.notifiers[p.Name] = newNotifier(p)
+	}
+
+	if len(p.Transforms) > 0 {
+		t, err := NewTransform(p.Transforms)
+		if err != nil {
+			log.Error("Property ", p.Name, " transforms ignored -> ", err)
+			return
+		}
+		wc.transforms[p.Name] = t
+	}
+}
+
+func (wc *WotCore) notifier(name string) (*notifier, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	n, ok := wc.notifiers[name]
+	return n, ok
 }
 
 func (wc *WotCore) ActionAdd(a model.Action) {
@@ -90,7 +171,32 @@ func (wc *WotCore) ActionAdd(a model.Action) {
 	defer wc.l.Unlock()
 
 	wc.td.Actions = append(wc.td.Actions, a)
+	wc.addAction(a)
+	wc.revise()
+}
+
+func (wc *WotCore) addAction(a model.Action) {
 	wc.actions[a.Name] = a
+
+	if a.Concurrency != nil {
+		wc.limiters[a.Name] = newActionLimiter(a.Concurrency)
+	}
+}
+
+func (wc *WotCore) actionHandler(name string) (ActionHandler, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	handler, ok := wc.actionCB[name]
+	return handler, ok
+}
+
+func (wc *WotCore) actionLimiter(name string) (*actionLimiter, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	limiter, ok := wc.limiters[name]
+	return limiter, ok
 }
 
 func (wc *WotCore) EventAdd(e model.Event) {
@@ -100,6 +206,7 @@ func (wc *WotCore) EventAdd(e model.Event) {
 	wc.td.Events = append(wc.td.Events, e)
 	wc.events[e.Name] = e
 	wc.eventsCB[e.Name] = make([]*EventListener, 0)
+	wc.revise()
 }
 
 func (wc *WotCore) checkProperty(name string) bool {
@@ -126,6 +233,30 @@ func (wc *WotCore) checkEvent(name string) bool {
 	return ok
 }
 
+func (wc *WotCore) property(name string) (model.Property, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	p, ok := wc.properties[name]
+	return p, ok
+}
+
+func (wc *WotCore) action(name string) (model.Action, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	a, ok := wc.actions[name]
+	return a, ok
+}
+
+func (wc *WotCore) event(name string) (model.Event, bool) {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	e, ok := wc.events[name]
+	return e, ok
+}
+
 func (wc *WotCore) addListener(eventName string, listener *EventListener) Status {
 	wc.l.Lock()
 	defer wc.l.Unlock()
@@ -141,6 +272,29 @@ func (wc *WotCore) addListener(eventName string, listener *EventListener) Status
 	return WOT_OK
 }
 
+func (wc *WotCore) removeListener(eventName, listenerID string) {
+	wc.l.Lock()
+	defer wc.l.Unlock()
+
+	listeners := make([]*EventListener, 0)
+	for _, l := range wc.eventsCB[eventName] {
+		if l.ID != listenerID {
+			listeners = append(listeners, l)
+		}
+	}
+
+	wc.eventsCB[eventName] = listeners
+}
+
+func (wc *WotCore) removeAllListeners(eventName string) {
+	wc.l.Lock()
+	defer wc.l.Unlock()
+
+	if _, ok := wc.eventsCB[eventName]; ok {
+		wc.eventsCB[eventName] = make([]*EventListener, 0)
+	}
+}
+
 func (wc *WotCore) listeners(eventName string) ([]*EventListener, Status) {
 	wc.l.RLock()
 	defer wc.l.RUnlock()
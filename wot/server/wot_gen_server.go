@@ -1,6 +1,10 @@
 package server
 
-import "github.com/conas/tno2/util/async"
+import (
+	"time"
+
+	"github.com/conas/tno2/util/async"
+)
 
 type Status int
 
@@ -12,51 +16,90 @@ const (
 	WOT_NO_PROPERTY_SET_HANDLER
 	WOT_UNKNOWN_PROPERTY
 	WOT_UNKNOWN_EVENT
+	WOT_PROPERTY_CONFLICT
+	WOT_ACTION_BUSY
+	WOT_INVALID_VALUE
+	WOT_THING_OFFLINE
+	WOT_WRITE_QUEUED
+	WOT_THING_BUSY
 )
 
 const (
 	ACTION_CALL async.MessageType = iota
 	GET_PROPERTY
 	SET_PROPERTY
+	SET_PROPERTY_IF
+	UPDATE_PROPERTY
 )
 
 type ActionHandlerCallMsg struct {
-	name string
-	arg  interface{}
-	ph   async.ProgressHandler
+	name      string
+	arg       interface{}
+	ph        async.ProgressHandler
+	requestID string
 }
 
 type GetPropertyMsg struct {
-	name string
+	name      string
+	requestID string
 }
 
 type SetPropertyMsg struct {
-	name  string
-	value interface{}
+	name      string
+	value     interface{}
+	requestID string
+}
+
+type SetPropertyIfMsg struct {
+	name      string
+	cond      func(current interface{}) bool
+	value     interface{}
+	requestID string
+}
+
+type UpdatePropertyMsg struct {
+	name      string
+	update    func(current interface{}) (interface{}, error)
+	requestID string
 }
 
 type ActionHandler func(interface{}, async.ProgressHandler) interface{}
 
-// WotGentServer provides process isolation for device represented by one goroutine
-func newGenServer(wc *WotCore) *async.GenServer {
-	gs := async.NewGenServer().
-		HandleCall(ACTION_CALL, func(arg interface{}) interface{} {
-			msg := arg.(*ActionHandlerCallMsg)
+func callAction(wc *WotCore, msg *ActionHandlerCallMsg) Status {
+	handler, ok := wc.actionHandler(msg.name)
 
-			handler, ok := wc.actionCB[msg.name]
+	if !ok {
+		return WOT_NO_ACTION_HANDLER
+	}
 
-			if !ok {
-				return WOT_NO_ACTION_HANDLER
-			}
+	//Progress handler scheduled status is set at WotServer level.
+	ph := msg.ph
+	if msg.requestID != "" {
+		ph = &requestProgress{ProgressHandler: msg.ph, requestID: msg.requestID}
+	}
+	start := time.Now()
+	result := handler(msg.arg, ph)
+	latency := time.Since(start)
 
-			//Progress handler scheduled status is set at WotServer level.
-			result := handler(msg.arg, msg.ph)
+	//handlers may report failure by returning error, e.g. backend not responding
+	if err, ok := result.(error); ok && false == msg.ph.IsFailed() {
+		msg.ph.Fail(err.Error())
+	}
 
-			if false == msg.ph.IsFailed() {
-				msg.ph.Done(result)
-			}
+	if false == msg.ph.IsFailed() {
+		msg.ph.Done(result)
+	}
 
-			return WOT_OK
+	wc.tap(INTERACTION_INVOKE, msg.name, msg.arg, result, msg.requestID, latency)
+
+	return WOT_OK
+}
+
+// WotGentServer provides process isolation for device represented by one goroutine
+func newGenServer(wc *WotCore) *async.GenServer {
+	gs := async.NewGenServer().
+		HandleCall(ACTION_CALL, func(arg interface{}) interface{} {
+			return callAction(wc, arg.(*ActionHandlerCallMsg))
 		}).
 		HandleCall(GET_PROPERTY, func(arg interface{}) interface{} {
 			msg := arg.(*GetPropertyMsg)
@@ -66,7 +109,11 @@ func newGenServer(wc *WotCore) *async.GenServer {
 				return WOT_NO_PROPERTY_GET_HANDLER
 			}
 
-			return handler()
+			start := time.Now()
+			value := wc.forward(msg.name, wc.serve(msg.requestID, handler))
+			wc.tap(INTERACTION_READ, msg.name, nil, value, msg.requestID, time.Since(start))
+
+			return value
 		}).
 		HandleCall(SET_PROPERTY, func(arg interface{}) interface{} {
 			msg := arg.(*SetPropertyMsg)
@@ -76,9 +123,88 @@ func newGenServer(wc *WotCore) *async.GenServer {
 				return WOT_NO_PROPERTY_SET_HANDLER
 			}
 
-			handler(msg.value)
+			raw, err := wc.inverse(msg.name, msg.value)
+			if err != nil {
+				return err
+			}
+
+			start := time.Now()
+			wc.serve(msg.requestID, func() interface{} {
+				handler(raw)
+				return nil
+			})
+			wc.uncache(msg.name, time.Now())
+			wc.tap(INTERACTION_WRITE, msg.name, msg.value, nil, msg.requestID, time.Since(start))
 
 			return WOT_OK
+		}).
+		HandleCall(SET_PROPERTY_IF, func(arg interface{}) interface{} {
+			msg := arg.(*SetPropertyIfMsg)
+			getHandler, ok := wc.propGetCB[msg.name]
+
+			if !ok {
+				return WOT_NO_PROPERTY_GET_HANDLER
+			}
+
+			setHandler, ok := wc.propSetCB[msg.name]
+
+			if !ok {
+				return WOT_NO_PROPERTY_SET_HANDLER
+			}
+
+			//read and write are done in one call, so no other call can change property in between
+			start := time.Now()
+			if !msg.cond(wc.current(msg.name, msg.requestID, getHandler)) {
+				return WOT_PROPERTY_CONFLICT
+			}
+
+			raw, err := wc.inverse(msg.name, msg.value)
+			if err != nil {
+				return err
+			}
+
+			wc.serve(msg.requestID, func() interface{} {
+				setHandler(raw)
+				return nil
+			})
+			wc.uncache(msg.name, time.Now())
+			wc.tap(INTERACTION_WRITE, msg.name, msg.value, nil, msg.requestID, time.Since(start))
+
+			return WOT_OK
+		}).
+		HandleCall(UPDATE_PROPERTY, func(arg interface{}) interface{} {
+			msg := arg.(*UpdatePropertyMsg)
+			getHandler, ok := wc.propGetCB[msg.name]
+
+			if !ok {
+				return WOT_NO_PROPERTY_GET_HANDLER
+			}
+
+			setHandler, ok := wc.propSetCB[msg.name]
+
+			if !ok {
+				return WOT_NO_PROPERTY_SET_HANDLER
+			}
+
+			start := time.Now()
+			value, err := msg.update(wc.current(msg.name, msg.requestID, getHandler))
+			if err != nil {
+				return err
+			}
+
+			raw, err := wc.inverse(msg.name, value)
+			if err != nil {
+				return err
+			}
+
+			wc.serve(msg.requestID, func() interface{} {
+				setHandler(raw)
+				return nil
+			})
+			wc.uncache(msg.name, time.Now())
+			wc.tap(INTERACTION_WRITE, msg.name, value, nil, msg.requestID, time.Since(start))
+
+			return value
 		})
 
 	gs.Start()
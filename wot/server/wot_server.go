@@ -1,6 +1,7 @@
 package server
 
 import (
+	log "github.com/Sirupsen/logrus"
 	"github.com/conas/tno2/util/async"
 	"github.com/conas/tno2/wot/model"
 )
@@ -10,8 +11,9 @@ import (
 // https://github.com/w3c/wot/tree/master/proposals/restructured-scripting-api#exposedthing
 
 type WotServer struct {
-	core *WotCore
-	gs   *async.GenServer
+	core      *WotCore
+	gs        *async.GenServer
+	requestID string
 }
 
 func CreateThing(name string) *WotServer {
@@ -40,6 +42,7 @@ func (s *WotServer) Name() string {
 
 func (s *WotServer) AddProperty(propertyName string, property model.Property) *WotServer {
 	s.core.PropertyAdd(property)
+	s.tdChanged()
 	return s
 }
 
@@ -66,6 +69,14 @@ func (s *WotServer) AddAction(actionName string, inputType model.InputData, outp
 		OutputData: outputType,
 	}
 	s.core.ActionAdd(action)
+	s.tdChanged()
+	return s
+}
+
+// DefineAction adds action with its full description, e.g. concurrency policy
+func (s *WotServer) DefineAction(action model.Action) *WotServer {
+	s.core.ActionAdd(action)
+	s.tdChanged()
 	return s
 }
 
@@ -79,6 +90,7 @@ func (s *WotServer) OnInvokeAction(actionName string, actionHandler ActionHandle
 
 func (s *WotServer) AddEvent(eventName string, event model.Event) *WotServer {
 	s.core.EventAdd(event)
+	s.tdChanged()
 	return s
 }
 
@@ -90,44 +102,281 @@ func (s *WotServer) AddListener(eventName string, listener *EventListener) *WotS
 	return s
 }
 
-func (s *WotServer) RemoveListener(eventName string, listener func(interface{})) *WotServer {
-	//FIXME: How to identify which listener to remove
-	panic("Remove Listener not implemented!")
+// RemoveListener removes listener identified by its ID
+func (s *WotServer) RemoveListener(eventName string, listener *EventListener) *WotServer {
+	s.core.removeListener(eventName, listener.ID)
 	return s
 }
 
 func (s *WotServer) RemoveAllListeners(eventName string) *WotServer {
-	panic("Remove All Listeners not implemented!")
+	s.core.removeAllListeners(eventName)
 	return s
 }
 
 // ----- CALLS
 
+// GetDescription returns description shared by all bindings of WotServer. Bindings read interactions
+// from it and change hrefs and URIs only by AddForms, serialized description is taken by Description.
 func (s *WotServer) GetDescription() *model.ThingDescription {
 	return s.core.td
 }
 
+// GetProperty reads property, reads arriving while read of the same property is in flight resolve with
+// its result instead of asking backend again
 func (s *WotServer) GetProperty(propertyName string) *async.Promise {
-	return s.gs.Call(GET_PROPERTY, &GetPropertyMsg{
-		name: propertyName,
+	if !s.core.checkProperty(propertyName) {
+		return resolved(WOT_UNKNOWN_PROPERTY)
+	}
+
+	if s.core.offline(propertyName) {
+		return resolved(WOT_THING_OFFLINE)
+	}
+
+	//values pushed by device or polled are answered without asking backend
+	if value, ok := s.core.cachedValue(propertyName); ok {
+		p := async.NewPromise()
+		p.Set(value)
+		return p
+	}
+
+	release, ok := s.core.admit()
+	if !ok {
+		return resolved(WOT_THING_BUSY)
+	}
+
+	//concurrent reads share single backend request
+	p := s.core.reads.join(propertyName, func() *async.Promise {
+		return s.gs.Call(GET_PROPERTY, &GetPropertyMsg{
+			name:      propertyName,
+			requestID: s.requestID,
+		})
 	})
+	release(p)
+
+	return p
 }
 
+// SetProperty writes property, write of offline Thing queueing writes resolves with WOT_WRITE_QUEUED.
+// Value not conforming to property value type is not written, promise resolves with *ValueError.
 func (s *WotServer) SetProperty(propertyName string, newValue interface{}) *async.Promise {
-	return s.gs.Call(SET_PROPERTY, &SetPropertyMsg{
-		name:  propertyName,
-		value: newValue,
+	prop, ok := s.core.property(propertyName)
+	if !ok {
+		return resolved(WOT_UNKNOWN_PROPERTY)
+	}
+
+	if err := validProperty(prop, newValue); err != nil {
+		return rejectedValue(propertyName, err)
+	}
+
+	offline := s.core.offline(propertyName)
+	queued, err := s.enqueue(propertyName, newValue, offline)
+	if err != nil {
+		log.Error("WotServer: write of ", propertyName, " not queued -> ", err)
+		p := async.NewPromise()
+		p.Set(err)
+		return p
+	}
+	if queued {
+		if !offline {
+			go s.flushWrites()
+		}
+		return resolved(WOT_WRITE_QUEUED)
+	}
+	if offline {
+		return resolved(WOT_THING_OFFLINE)
+	}
+
+	release, ok := s.core.admit()
+	if !ok {
+		return resolved(WOT_THING_BUSY)
+	}
+
+	s.core.reads.forget(propertyName, nil)
+	p := s.gs.Call(SET_PROPERTY, &SetPropertyMsg{
+		name:      propertyName,
+		value:     newValue,
+		requestID: s.requestID,
+	})
+	release(p)
+
+	return p
+}
+
+// SetPropertyIf sets property only when cond holds for its current value. Promise resolves with
+// WOT_PROPERTY_CONFLICT when cond does not hold.
+func (s *WotServer) SetPropertyIf(propertyName string, cond func(current interface{}) bool, newValue interface{}) *async.Promise {
+	prop, ok := s.core.property(propertyName)
+	if !ok {
+		return resolved(WOT_UNKNOWN_PROPERTY)
+	}
+
+	if err := validProperty(prop, newValue); err != nil {
+		return rejectedValue(propertyName, err)
+	}
+
+	if s.core.offline(propertyName) {
+		return resolved(WOT_THING_OFFLINE)
+	}
+
+	release, ok := s.core.admit()
+	if !ok {
+		return resolved(WOT_THING_BUSY)
+	}
+
+	s.core.reads.forget(propertyName, nil)
+	p := s.gs.Call(SET_PROPERTY_IF, &SetPropertyIfMsg{
+		name:      propertyName,
+		cond:      cond,
+		value:     newValue,
+		requestID: s.requestID,
+	})
+	release(p)
+
+	return p
+}
+
+// CompareAndSetProperty sets property only when its current value equals expected. Values are
+// compared by their JSON form, so e.g. int 1 equals float64 1.
+func (s *WotServer) CompareAndSetProperty(propertyName string, expected, newValue interface{}) *async.Promise {
+	return s.SetPropertyIf(propertyName, func(current interface{}) bool {
+		return SameValue(current, expected)
+	}, newValue)
+}
+
+// UpdateProperty atomically replaces property value with result of update applied to current value.
+// Promise resolves with the new value, or error returned by update.
+func (s *WotServer) UpdateProperty(propertyName string, update func(current interface{}) (interface{}, error)) *async.Promise {
+	if !s.core.checkProperty(propertyName) {
+		return resolved(WOT_UNKNOWN_PROPERTY)
+	}
+
+	if s.core.offline(propertyName) {
+		return resolved(WOT_THING_OFFLINE)
+	}
+
+	release, ok := s.core.admit()
+	if !ok {
+		return resolved(WOT_THING_BUSY)
+	}
+
+	p := s.gs.Call(UPDATE_PROPERTY, &UpdatePropertyMsg{
+		name:      propertyName,
+		update:    update,
+		requestID: s.requestID,
 	})
+	release(p)
+
+	return p
 }
 
+// InvokeAction executes action handler. Actions without concurrency policy are executed by Thing
+// goroutine, actions with policy in their own goroutine limited by the policy. Invocation rejected
+// by policy fails immediately and promise resolves with WOT_ACTION_BUSY, invocation refused by bulkhead of Thing
+// with WOT_THING_BUSY. Input not conforming to action input type fails the task and promise resolves with
+// *ValueError, streamed input is not validated. Thing declaring ACTION_STATUS_EVENT emits status changes of
+// the task to its listeners.
 func (s *WotServer) InvokeAction(actionName string, arg interface{}, ph async.ProgressHandler) *async.Promise {
+	action, ok := s.core.action(actionName)
+	if !ok {
+		ph.Fail(statusText[WOT_UNKNOWN_ACTION])
+		return resolved(WOT_UNKNOWN_ACTION)
+	}
+
+	if _, stream := arg.(*Stream); !stream {
+		if err := action.InputData.ValueType.Validate(arg); err != nil {
+			ph.Fail(err.Error())
+			return rejectedValue(actionName, err)
+		}
+	}
+
+	if s.core.offline(actionName) {
+		ph.Fail(statusText[WOT_THING_OFFLINE])
+		return resolved(WOT_THING_OFFLINE)
+	}
+
+	if wph, ok := ph.(*WotProgressHandler); ok && s.core.checkEvent(ACTION_STATUS_EVENT) {
+		requestID := s.requestID
+		wph.observer = func(status *TaskStatus) {
+			s.EmitEvent(ACTION_STATUS_EVENT, &ActionStatus{RequestID: requestID, TaskStatus: status})
+		}
+	}
+
+	msg := &ActionHandlerCallMsg{
+		name:      actionName,
+		arg:       arg,
+		ph:        ph,
+		requestID: s.requestID,
+	}
+
+	release, ok := s.core.admit()
+	if !ok {
+		ph.Fail(statusText[WOT_THING_BUSY])
+		return resolved(WOT_THING_BUSY)
+	}
+
+	limiter, limited := s.core.actionLimiter(actionName)
+
+	if limited && !limiter.admit() {
+		ph.Fail(statusText[WOT_ACTION_BUSY])
+		p := resolved(WOT_ACTION_BUSY)
+		release(p)
+		return p
+	}
+
 	ph.Schedule(arg)
 
-	return s.gs.Call(ACTION_CALL, &ActionHandlerCallMsg{
-		name: actionName,
-		arg:  arg,
-		ph:   ph,
+	var p *async.Promise
+	if limited {
+		p = async.Run(func() interface{} {
+			limiter.acquire()
+			defer limiter.release()
+
+			return callAction(s.core, msg)
+		})
+	} else {
+		p = s.gs.Call(ACTION_CALL, msg)
+	}
+	release(p)
+
+	return p
+}
+
+// EmitPropertyChange notifies listeners of PROPERTY_CHANGE_EVENT about new property value. Value reported
+// by backend is converted by property transform and notification policy is applied, so change may be
+// dropped or delayed.
+func (s *WotServer) EmitPropertyChange(propertyName string, value interface{}) Status {
+	if !s.core.checkProperty(propertyName) {
+		return WOT_UNKNOWN_PROPERTY
+	}
+
+	if !s.core.checkEvent(PROPERTY_CHANGE_EVENT) {
+		return WOT_UNKNOWN_EVENT
+	}
+
+	value = s.core.forward(propertyName, value)
+	if err, ok := value.(error); ok {
+		log.Error("Property ", propertyName, " change not emitted -> ", err)
+		return WOT_INVALID_VALUE
+	}
+
+	s.notifyChange(&PropertyChange{
+		Name:  propertyName,
+		Value: value,
 	})
+	return WOT_OK
+}
+
+// notifyChange emits change to listeners of PROPERTY_CHANGE_EVENT by notification policy of property
+func (s *WotServer) notifyChange(change *PropertyChange) {
+	emit := func(change *PropertyChange) {
+		s.EmitEvent(PROPERTY_CHANGE_EVENT, change)
+	}
+
+	if n, ok := s.core.notifier(change.Name); ok && !n.offer(change, emit) {
+		return
+	}
+
+	emit(change)
 }
 
 func (s *WotServer) EmitEvent(eventName string, data interface{}) Status {
@@ -137,6 +386,8 @@ func (s *WotServer) EmitEvent(eventName string, data interface{}) Status {
 		return status
 	}
 
+	s.core.tap(INTERACTION_EVENT, eventName, data, nil, "", 0)
+
 	async.Run(func() interface{} {
 		event := newEvent(eventName, data)
 		for _, eventListener := range listeners {
@@ -147,3 +398,26 @@ func (s *WotServer) EmitEvent(eventName string, data interface{}) Status {
 
 	return WOT_OK
 }
+
+func resolved(status Status) *async.Promise {
+	p := async.NewPromise()
+	p.Set(status)
+	return p
+}
+
+// validProperty checks value written to property conforms to its value type, value of encrypted property
+// is ciphertext checked by checkCiphertext instead
+func validProperty(prop model.Property, value interface{}) error {
+	if prop.Encryption != nil {
+		return nil
+	}
+
+	return prop.ValueType.Validate(value)
+}
+
+// rejectedValue returns promise resolved with ValueError of interaction name
+func rejectedValue(name string, err error) *async.Promise {
+	p := async.NewPromise()
+	p.Set(&ValueError{Name: name, Err: err})
+	return p
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/str"
+)
+
+const (
+	// CONFLICT_LAST_WRITE_WINS flushes queued writes regardless of changes made by device meanwhile
+	CONFLICT_LAST_WRITE_WINS = "last-write-wins"
+	// CONFLICT_REJECT drops queued write when property changed since the write was queued
+	CONFLICT_REJECT = "reject"
+)
+
+// QueuedWrite is property write accepted while Thing was offline. Base is value of property known when
+// write was queued, it is compared to current value by CONFLICT_REJECT policy.
+type QueuedWrite struct {
+	Property  string      `json:"property"`
+	Value     interface{} `json:"value"`
+	Base      *KnownValue `json:"base,omitempty"`
+	Queued    time.Time   `json:"queued"`
+	RequestID string      `json:"requestId,omitempty"`
+}
+
+type writeQueue struct {
+	l        *sync.Mutex
+	path     string
+	policy   string
+	writes   []*QueuedWrite
+	flushing bool
+}
+
+// QueueWrites accepts property writes of offline Thing, they are resolved with WOT_WRITE_QUEUED and written
+// in order once Thing is online again, see TrackAvailability. Queue is persisted at path, writes queued
+// before restart are restored. Path may be empty for in memory queue.
+func (s *WotServer) QueueWrites(path, policy string) error {
+	if policy != CONFLICT_LAST_WRITE_WINS && policy != CONFLICT_REJECT {
+		return errors.New(str.Concat("Unknown conflict policy: ", policy))
+	}
+
+	q := &writeQueue{
+		l:      &sync.Mutex{},
+		path:   path,
+		policy: policy,
+		writes: make([]*QueuedWrite, 0),
+	}
+
+	if err := q.restore(); err != nil {
+		return err
+	}
+
+	s.core.l.Lock()
+	s.core.queue = q
+	s.core.l.Unlock()
+
+	if s.Availability().State != AVAILABILITY_OFFLINE {
+		go s.flushWrites()
+	}
+
+	return nil
+}
+
+// QueuedWrites lists writes waiting for Thing to be online
+func (s *WotServer) QueuedWrites() []*QueuedWrite {
+	q := s.core.writeQueue()
+	if q == nil {
+		return nil
+	}
+
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	return append([]*QueuedWrite(nil), q.writes...)
+}
+
+// enqueue queues write of offline Thing, false is returned when Thing does not queue writes. Writes of
+// online Thing are queued too while queue is not empty, so they are not overwritten by older queued
+// writes. Write is not queued when queue cannot be persisted.
+func (s *WotServer) enqueue(propertyName string, value interface{}, offline bool) (bool, error) {
+	q := s.core.writeQueue()
+	if q == nil {
+		return false, nil
+	}
+
+	w := &QueuedWrite{
+		Property:  propertyName,
+		Value:     value,
+		Queued:    time.Now(),
+		RequestID: s.requestID,
+	}
+	if known, ok := s.LastKnown(propertyName); ok {
+		w.Base = known
+	}
+
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if !offline && len(q.writes) == 0 && !q.flushing {
+		return false, nil
+	}
+
+	q.writes = append(q.writes, w)
+	if err := q.persist(); err != nil {
+		q.writes = q.writes[:len(q.writes)-1]
+		return false, err
+	}
+
+	return true, nil
+}
+
+// flushWrites writes queued writes in order, flush stops when Thing goes offline again and remaining
+// writes wait for next flush
+func (s *WotServer) flushWrites() {
+	q := s.core.writeQueue()
+	if q == nil {
+		return
+	}
+
+	q.l.Lock()
+	if q.flushing {
+		q.l.Unlock()
+		return
+	}
+	q.flushing = true
+	q.l.Unlock()
+
+	for {
+		//flushing ends together with check of empty queue, so writes enqueued meanwhile are flushed
+		q.l.Lock()
+		if len(q.writes) == 0 {
+			q.flushing = false
+			q.l.Unlock()
+			return
+		}
+		w := q.writes[0]
+		q.l.Unlock()
+
+		result := s.flush(q.policy, w)
+		if result == WOT_THING_OFFLINE {
+			q.l.Lock()
+			q.flushing = false
+			q.l.Unlock()
+			return
+		}
+
+		if result != WOT_OK {
+			log.Warn("WotServer: queued write of ", w.Property, " dropped -> ", result)
+		}
+
+		q.l.Lock()
+		q.writes = q.writes[1:]
+		if err := q.persist(); err != nil {
+			log.Error("WotServer: write queue not persisted -> ", err)
+		}
+		q.l.Unlock()
+	}
+}
+
+func (s *WotServer) flush(policy string, w *QueuedWrite) interface{} {
+	if s.core.offline(w.Property) {
+		return WOT_THING_OFFLINE
+	}
+
+	if policy == CONFLICT_REJECT && w.Base != nil {
+		return s.gs.Call(SET_PROPERTY_IF, &SetPropertyIfMsg{
+			name: w.Property,
+			cond: func(current interface{}) bool {
+				return SameValue(current, w.Base.Value)
+			},
+			value:     w.Value,
+			requestID: w.RequestID,
+		}).Get()
+	}
+
+	return s.gs.Call(SET_PROPERTY, &SetPropertyMsg{
+		name:      w.Property,
+		value:     w.Value,
+		requestID: w.RequestID,
+	}).Get()
+}
+
+func (wc *WotCore) writeQueue() *writeQueue {
+	wc.l.RLock()
+	defer wc.l.RUnlock()
+
+	return wc.queue
+}
+
+func (q *writeQueue) restore() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, &q.writes); err != nil {
+		return errors.New(str.Concat("Invalid write queue file ", q.path, ": ", err.Error()))
+	}
+
+	return nil
+}
+
+// persist writes queue to temporary file renamed over queue file, caller holds the lock
+func (q *writeQueue) persist() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(q.writes)
+	if err != nil {
+		return err
+	}
+
+	tmp := str.Concat(q.path, ".tmp")
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, q.path)
+}
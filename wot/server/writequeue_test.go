@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+)
+
+// queueingThing returns Thing queueing writes, writes reaching device are sent to returned channel
+func queueingThing(t *testing.T, path, policy string) (*WotServer, chan string, chan interface{}) {
+	s := CreateFromDescription(&model.ThingDescription{Name: "field-unit"})
+	s.AddProperty("setpoint", model.Property{Name: "setpoint", Writable: true})
+
+	//current value is owned by Thing goroutine, tests change it by sending to the channel
+	var current interface{} = "20"
+	local := make(chan interface{}, 1)
+	written := make(chan string, 8)
+	s.OnGetProperty("setpoint", func() interface{} {
+		select {
+		case current = <-local:
+		default:
+		}
+		return current
+	})
+	s.OnUpdateProperty("setpoint", func(v interface{}) {
+		current = v
+		written <- v.(string)
+	})
+
+	s.TrackAvailability(10 * time.Millisecond)
+	if err := s.QueueWrites(path, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	return s, written, local
+}
+
+func waitOffline(t *testing.T, s *WotServer) {
+	deadline := time.Now().Add(time.Second)
+	for s.Availability().State != AVAILABILITY_OFFLINE {
+		if time.Now().After(deadline) {
+			t.Fatal("Thing not offline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitFlushed waits until queued writes are flushed or dropped
+func waitFlushed(s *WotServer) {
+	deadline := time.Now().Add(time.Second)
+	for len(s.QueuedWrites()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waitWritten(t *testing.T, written chan string) string {
+	select {
+	case v := <-written:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("write not flushed")
+		return ""
+	}
+}
+
+func TestCaseWriteQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	s, written, _ := queueingThing(t, path, CONFLICT_LAST_WRITE_WINS)
+
+	waitOffline(t, s)
+	Equals("queued", t, WOT_WRITE_QUEUED, s.SetProperty("setpoint", "21").Get())
+	s.SetProperty("setpoint", "22").Get()
+	Equals("pending", t, 2, len(s.QueuedWrites()))
+	Equals("conditional write refused", t, WOT_THING_OFFLINE, s.CompareAndSetProperty("setpoint", "20", "23").Get())
+
+	//queue survives restart
+	restored := &writeQueue{path: path}
+	Equals("restore", t, nil, restored.restore())
+	Equals("restored", t, 2, len(restored.writes))
+
+	s.Heartbeat()
+	Equals("flushed first", t, "21", waitWritten(t, written))
+
+	//live write waits for older queued writes
+	s.SetProperty("setpoint", "24").Get()
+	Equals("flushed second", t, "22", waitWritten(t, written))
+	Equals("live write last", t, "24", waitWritten(t, written))
+	Equals("current", t, "24", s.GetProperty("setpoint").Get())
+	waitFlushed(s)
+	Equals("empty", t, 0, len(s.QueuedWrites()))
+}
+
+func TestCaseWriteQueueReject(t *testing.T) {
+	s, written, local := queueingThing(t, "", CONFLICT_REJECT)
+
+	s.GetProperty("setpoint").Get()
+	waitOffline(t, s)
+	s.SetProperty("setpoint", "21").Get()
+
+	//device changed setpoint locally while offline
+	local <- "18"
+
+	s.Heartbeat()
+	waitFlushed(s)
+	Equals("dropped", t, 0, len(s.QueuedWrites()))
+	Equals("rejected", t, 0, len(written))
+}
+
+func TestCaseWriteQueueNotPersisted(t *testing.T) {
+	dir := t.TempDir()
+	s, _, _ := queueingThing(t, filepath.Join(dir, "queue.json"), CONFLICT_LAST_WRITE_WINS)
+
+	waitOffline(t, s)
+	os.RemoveAll(dir)
+
+	result := s.SetProperty("setpoint", "21").Get()
+	err, ok := result.(error)
+	Equals("error returned", t, true, ok && errors.Is(err, os.ErrNotExist))
+	Equals("not queued", t, 0, len(s.QueuedWrites()))
+}
@@ -0,0 +1,444 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/wot/server"
+)
+
+const (
+	FORWARD_BATCH = 100
+	FORWARD_RETRY = 10 * time.Second
+
+	segment_EXT = ".jsonl"
+)
+
+// ForwarderOptions configure store-and-forward. Records which cannot be delivered are spooled to Dir in
+// segments of at most BatchSize records. Retention caps limit spooled records, whole oldest segments are
+// dropped first when any cap is exceeded, zero cap is unlimited.
+type ForwarderOptions struct {
+	Dir           string
+	BatchSize     int
+	RetryInterval time.Duration
+	MaxRecords    int
+	MaxBytes      int64
+	MaxAge        time.Duration
+}
+
+// Forwarder delivers records to sink in batches. While sink fails records are spooled to disk and
+// delivery is retried every RetryInterval, spooled records are replayed in order before new ones.
+// Spool survives restart, records spooled by previous run are replayed first.
+type Forwarder struct {
+	sink    Sink
+	opts    ForwarderOptions
+	l       *sync.Mutex
+	pending []*Record
+	spool   []*segment
+	seq     uint64
+	dropped int
+	offline bool
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// segment is spool file of records, named by sequence number and record count
+type segment struct {
+	path    string
+	records int
+	size    int64
+	created time.Time
+}
+
+func NewForwarder(sink Sink, opts ForwarderOptions) (*Forwarder, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("Spool directory not set")
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = FORWARD_BATCH
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = FORWARD_RETRY
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	f := &Forwarder{
+		sink:    sink,
+		opts:    opts,
+		l:       &sync.Mutex{},
+		pending: make([]*Record, 0),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if err := f.loadSpool(); err != nil {
+		return nil, err
+	}
+
+	go f.run()
+	f.signal()
+
+	return f, nil
+}
+
+// Forward taps property reads, writes and events of s, so sink receives events and property history
+func (f *Forwarder) Forward(s *server.WotServer) *Forwarder {
+	thing := s.Name()
+
+	s.AddTap(func(i *server.Interaction) {
+		switch i.Kind {
+		case server.INTERACTION_READ, server.INTERACTION_WRITE, server.INTERACTION_EVENT:
+			f.Offer(&Record{Thing: thing, Interaction: i})
+		}
+	})
+
+	return f
+}
+
+// Offer queues record for delivery, it does not block
+func (f *Forwarder) Offer(r *Record) {
+	f.l.Lock()
+	f.pending = append(f.pending, r)
+	f.l.Unlock()
+
+	f.signal()
+}
+
+// Buffered returns number of records not delivered yet
+func (f *Forwarder) Buffered() int {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	n := len(f.pending)
+	for _, seg := range f.spool {
+		n += seg.records
+	}
+
+	return n
+}
+
+// Dropped returns number of spooled records dropped by retention caps
+func (f *Forwarder) Dropped() int {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	return f.dropped
+}
+
+// Close stops delivery and spools records not delivered yet
+func (f *Forwarder) Close() error {
+	close(f.stop)
+	<-f.done
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	err := f.spill(f.pending)
+	f.pending = f.pending[:0]
+
+	return err
+}
+
+func (f *Forwarder) signal() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+
+	retry := time.NewTicker(f.opts.RetryInterval)
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-f.wake:
+			f.flush(false)
+		case <-retry.C:
+			f.flush(true)
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// flush replays spool and delivers pending records. While sink is offline only retries deliver, other
+// flushes spool full batches of pending records.
+func (f *Forwarder) flush(retry bool) {
+	if f.offline && !retry {
+		f.l.Lock()
+		if len(f.pending) >= f.opts.BatchSize {
+			f.spillPending()
+		}
+		f.l.Unlock()
+		return
+	}
+
+	for {
+		f.l.Lock()
+		f.retain()
+		var seg *segment
+		if len(f.spool) > 0 {
+			seg = f.spool[0]
+		}
+		f.l.Unlock()
+
+		if seg == nil {
+			break
+		}
+
+		records, err := readSegment(seg.path)
+		if err != nil {
+			log.Error("Forwarder: dropping unreadable spool segment ", seg.path, " -> ", err)
+			f.remove(seg, true)
+			continue
+		}
+
+		if err = f.sink.Send(records); err != nil {
+			f.fail(err)
+			return
+		}
+
+		f.remove(seg, false)
+	}
+
+	for {
+		f.l.Lock()
+		n := len(f.pending)
+		if n > f.opts.BatchSize {
+			n = f.opts.BatchSize
+		}
+		batch := f.pending[:n:n]
+		f.pending = f.pending[n:]
+		f.l.Unlock()
+
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := f.sink.Send(batch); err != nil {
+			f.l.Lock()
+			f.pending = append(batch, f.pending...)
+			f.l.Unlock()
+
+			f.fail(err)
+			return
+		}
+	}
+
+	if f.offline {
+		log.Info("Forwarder: sink recovered")
+		f.offline = false
+	}
+}
+
+func (f *Forwarder) fail(err error) {
+	if !f.offline {
+		log.Warn("Forwarder: sink unreachable, spooling records -> ", err)
+		f.offline = true
+	}
+
+	f.l.Lock()
+	f.spillPending()
+	f.l.Unlock()
+}
+
+// spillPending spools pending records, records are kept pending when spool cannot be written
+func (f *Forwarder) spillPending() {
+	if err := f.spill(f.pending); err != nil {
+		log.Error("Forwarder: spool write failed -> ", err)
+		return
+	}
+
+	f.pending = f.pending[:0]
+}
+
+// spill writes records to new segments and applies retention, caller holds lock
+func (f *Forwarder) spill(records []*Record) error {
+	for len(records) > 0 {
+		n := len(records)
+		if n > f.opts.BatchSize {
+			n = f.opts.BatchSize
+		}
+
+		seg, err := f.writeSegment(records[:n])
+		if err != nil {
+			return err
+		}
+
+		f.spool = append(f.spool, seg)
+		records = records[n:]
+	}
+
+	f.retain()
+	return nil
+}
+
+// retain drops the oldest segments while retention caps are exceeded, caller holds lock
+func (f *Forwarder) retain() {
+	records, size := 0, int64(0)
+	for _, seg := range f.spool {
+		records += seg.records
+		size += seg.size
+	}
+
+	for len(f.spool) > 0 {
+		oldest := f.spool[0]
+		expired := f.opts.MaxAge > 0 && time.Since(oldest.created) > f.opts.MaxAge
+		over := (f.opts.MaxRecords > 0 && records > f.opts.MaxRecords) || (f.opts.MaxBytes > 0 && size > f.opts.MaxBytes)
+
+		if !expired && !over {
+			return
+		}
+
+		os.Remove(oldest.path)
+		f.spool = f.spool[1:]
+		f.dropped += oldest.records
+		records -= oldest.records
+		size -= oldest.size
+	}
+}
+
+func (f *Forwarder) remove(seg *segment, dropped bool) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	os.Remove(seg.path)
+
+	for i, s := range f.spool {
+		if s == seg {
+			f.spool = append(f.spool[:i:i], f.spool[i+1:]...)
+			break
+		}
+	}
+
+	if dropped {
+		f.dropped += seg.records
+	}
+}
+
+// writeSegment writes records to temporary file renamed when complete, so crash never leaves partial segment
+func (f *Forwarder) writeSegment(records []*Record) (*segment, error) {
+	f.seq++
+	path := filepath.Join(f.opts.Dir, fmt.Sprintf("%020d-%d%s", f.seq, len(records), segment_EXT))
+
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err = enc.Encode(r); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &segment{
+		path:    path,
+		records: len(records),
+		size:    fi.Size(),
+		created: fi.ModTime(),
+	}, nil
+}
+
+// loadSpool finds segments left by previous run, leftover temporary files are removed
+func (f *Forwarder) loadSpool() error {
+	files, err := ioutil.ReadDir(f.opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range files {
+		name := fi.Name()
+
+		if strings.HasSuffix(name, segment_EXT+".tmp") {
+			os.Remove(filepath.Join(f.opts.Dir, name))
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(name, segment_EXT), "-", 2)
+		if fi.IsDir() || !strings.HasSuffix(name, segment_EXT) || len(parts) != 2 {
+			continue
+		}
+
+		seq, err1 := strconv.ParseUint(parts[0], 10, 64)
+		records, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		if seq > f.seq {
+			f.seq = seq
+		}
+
+		f.spool = append(f.spool, &segment{
+			path:    filepath.Join(f.opts.Dir, name),
+			records: records,
+			size:    fi.Size(),
+			created: fi.ModTime(),
+		})
+	}
+
+	sort.Slice(f.spool, func(i, j int) bool { return f.spool[i].path < f.spool[j].path })
+	return nil
+}
+
+func readSegment(path string) ([]*Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]*Record, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		r := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+
+	return records, scanner.Err()
+}
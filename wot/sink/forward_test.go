@@ -0,0 +1,181 @@
+package sink
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+// flaky sink fails while down and collects delivered records
+type flaky struct {
+	l         sync.Mutex
+	down      bool
+	delivered []*Record
+}
+
+func (s *flaky) Send(records []*Record) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.down {
+		return errors.New("uplink down")
+	}
+
+	s.delivered = append(s.delivered, records...)
+	return nil
+}
+
+func (s *flaky) setDown(down bool) {
+	s.l.Lock()
+	s.down = down
+	s.l.Unlock()
+}
+
+func (s *flaky) names() []string {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	names := make([]string, 0, len(s.delivered))
+	for _, r := range s.delivered {
+		names = append(names, r.Name)
+	}
+
+	return names
+}
+
+func record(name string) *Record {
+	return &Record{Thing: "t", Interaction: &server.Interaction{Time: time.Now(), Kind: server.INTERACTION_EVENT, Name: name}}
+}
+
+func spoolDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func eventually(cond func() bool) bool {
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestCaseStoreAndForward(t *testing.T) {
+	dir := spoolDir(t)
+	defer os.RemoveAll(dir)
+
+	sink := &flaky{down: true}
+	f, err := NewForwarder(sink, ForwarderOptions{Dir: dir, BatchSize: 2, RetryInterval: 20 * time.Millisecond})
+	Equals("created", t, nil, err)
+	defer f.Close()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		f.Offer(record(name))
+	}
+
+	Equals("spooled", t, true, eventually(func() bool {
+		files, _ := ioutil.ReadDir(dir)
+		return len(files) >= 3
+	}))
+	Equals("buffered", t, 5, f.Buffered())
+
+	f.Offer(record("f"))
+	sink.setDown(false)
+
+	Equals("replayed", t, true, eventually(func() bool { return f.Buffered() == 0 && len(sink.names()) == 6 }))
+	Equals("order", t, "[a b c d e f]", fmtNames(sink.names()))
+}
+
+func TestCaseRetention(t *testing.T) {
+	dir := spoolDir(t)
+	defer os.RemoveAll(dir)
+
+	sink := &flaky{down: true}
+	f, _ := NewForwarder(sink, ForwarderOptions{Dir: dir, BatchSize: 2, RetryInterval: 20 * time.Millisecond, MaxRecords: 4})
+	defer f.Close()
+
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		f.Offer(record(name))
+	}
+
+	Equals("dropped", t, true, eventually(func() bool { return f.Dropped() == 2 }))
+	Equals("buffered", t, 4, f.Buffered())
+
+	sink.setDown(false)
+	Equals("replayed", t, true, eventually(func() bool { return len(sink.names()) == 4 }))
+	Equals("oldest dropped", t, "[c d e f]", fmtNames(sink.names()))
+}
+
+func TestCaseSpoolSurvivesRestart(t *testing.T) {
+	dir := spoolDir(t)
+	defer os.RemoveAll(dir)
+
+	sink := &flaky{down: true}
+	f, _ := NewForwarder(sink, ForwarderOptions{Dir: dir, RetryInterval: time.Hour})
+	f.Offer(record("a"))
+	f.Offer(record("b"))
+	Equals("closed", t, nil, f.Close())
+
+	sink.setDown(false)
+	f, _ = NewForwarder(sink, ForwarderOptions{Dir: dir, RetryInterval: time.Hour})
+	defer f.Close()
+
+	Equals("replayed", t, true, eventually(func() bool { return len(sink.names()) == 2 }))
+	Equals("order", t, "[a b]", fmtNames(sink.names()))
+}
+
+func TestCaseForwardThing(t *testing.T) {
+	dir := spoolDir(t)
+	defer os.RemoveAll(dir)
+
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:       "meter",
+		Properties: []model.Property{{Name: "power", Hrefs: []string{"power"}}},
+		Actions:    []model.Action{{Name: "reset", Hrefs: []string{"reset"}}},
+		Events:     []model.Event{{Name: "alarm", Hrefs: []string{"alarm"}}},
+	})
+	s.OnGetProperty("power", func() interface{} { return 42 })
+
+	sink := &flaky{}
+	f, _ := NewForwarder(sink, ForwarderOptions{Dir: dir})
+	defer f.Close()
+	f.Forward(s)
+
+	s.GetProperty("power").Wait()
+	s.EmitEvent("alarm", "overload")
+
+	Equals("forwarded", t, true, eventually(func() bool { return len(sink.names()) == 2 }))
+	Equals("thing", t, "meter", sink.delivered[0].Thing)
+	Equals("history", t, 42, sink.delivered[0].Output)
+}
+
+func fmtNames(names []string) string {
+	s := "["
+	for i, n := range names {
+		if i > 0 {
+			s += " "
+		}
+		s += n
+	}
+	return s + "]"
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,91 @@
+// Package sink forwards interactions of Things to cloud sinks, e.g. time series databases or message brokers.
+// Forwarder buffers records on local disk while sink is unreachable and replays them when it recovers.
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/server"
+)
+
+// Record is interaction of Thing forwarded to sink
+type Record struct {
+	Thing string `json:"thing"`
+	*server.Interaction
+}
+
+// Sink delivers batches of records in order. Error means batch was not delivered and it is sent again later,
+// so sinks should tolerate duplicates of partially delivered batches.
+type Sink interface {
+	Send(records []*Record) error
+}
+
+// SinkFunc adapts function to Sink
+type SinkFunc func(records []*Record) error
+
+func (f SinkFunc) Send(records []*Record) error {
+	return f(records)
+}
+
+// HttpSink posts batches as JSON array of records, e.g. to ingestion endpoint of cloud service or gateway
+// of database. Any response other than 2xx fails the batch.
+type HttpSink struct {
+	url     string
+	client  *http.Client
+	headers http.Header
+}
+
+func NewHttpSink(url string) *HttpSink {
+	return &HttpSink{
+		url:     url,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		headers: make(http.Header),
+	}
+}
+
+// WithHeader adds header sent with every batch, e.g. Authorization
+func (s *HttpSink) WithHeader(name, value string) *HttpSink {
+	s.headers.Add(name, value)
+	return s
+}
+
+// WithHTTPClient replaces default client with 30s timeout
+func (s *HttpSink) WithHTTPClient(client *http.Client) *HttpSink {
+	s.client = client
+	return s
+}
+
+func (s *HttpSink) Send(records []*Record) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	rq, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for name, values := range s.headers {
+		rq.Header[name] = values
+	}
+	rq.Header.Set("Content-Type", "application/json")
+
+	rs, err := s.client.Do(rq)
+	if err != nil {
+		return err
+	}
+	rs.Body.Close()
+
+	if rs.StatusCode/100 != 2 {
+		return errors.New(str.Concat("Sink responded ", strconv.Itoa(rs.StatusCode)))
+	}
+
+	return nil
+}
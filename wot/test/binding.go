@@ -0,0 +1,68 @@
+package test
+
+import (
+	"net"
+	"net/http/httptest"
+
+	"github.com/conas/tno2/wot/frontend"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+// BINDING_CTX_PATH is context path of Thing mounted by NewBinding
+const BINDING_CTX_PATH = "/thing"
+
+// Binding is WotServer exposed by HTTP frontend on in-process httptest.Server. Server is listening
+// when binding is returned, Client can be used right away.
+type Binding struct {
+	Server   *httptest.Server
+	Frontend *frontend.Http
+	Client   *proxy.HttpClient
+}
+
+// NewBinding mounts s at BINDING_CTX_PATH of new frontend
+func NewBinding(s *server.WotServer) *Binding {
+	return NewBindingAt(BINDING_CTX_PATH, s, nil)
+}
+
+// NewBindingAt mounts s at ctxPath of new frontend configured by cfg (see frontend.NewHTTP),
+// hostname and port are set to address of the test server
+func NewBindingAt(ctxPath string, s *server.WotServer, cfg map[string]interface{}) *Binding {
+	ts := httptest.NewUnstartedServer(nil)
+	addr := ts.Listener.Addr().(*net.TCPAddr)
+
+	feCfg := map[string]interface{}{}
+	for k, v := range cfg {
+		feCfg[k] = v
+	}
+	feCfg["hostname"] = addr.IP.String()
+	feCfg["port"] = addr.Port
+
+	fe := frontend.NewHTTP(feCfg).(*frontend.Http)
+	fe.Bind(ctxPath, s)
+
+	ts.Config.Handler = fe
+	ts.Start()
+
+	client, err := proxy.NewHttpClient(ts.URL + ctxPath)
+	if err != nil {
+		ts.Close()
+		panic(err)
+	}
+
+	return &Binding{
+		Server:   ts,
+		Frontend: fe,
+		Client:   client,
+	}
+}
+
+// URL is root of bound Thing
+func (b *Binding) URL() string {
+	return b.Client.URL()
+}
+
+// Close stops test server
+func (b *Binding) Close() {
+	b.Server.Close()
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCaseBinding(t *testing.T) {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:       "lamp",
+		Properties: []model.Property{{Name: "on", Writable: true, Hrefs: []string{"on"}}},
+		Actions:    []model.Action{{Name: "toggle", Hrefs: []string{"toggle"}}},
+	})
+
+	on := false
+	s.OnGetProperty("on", func() interface{} { return on })
+	s.OnUpdateProperty("on", func(v interface{}) { on = v.(bool) })
+	s.OnInvokeAction("toggle", func(arg interface{}, ph async.ProgressHandler) interface{} {
+		on = !on
+		return on
+	})
+
+	b := NewBinding(s)
+	defer b.Close()
+
+	Equals("name", t, "lamp", b.Client.Name())
+	Equals("set", t, nil, b.Client.SetProperty("on", true))
+
+	v, err := b.Client.GetProperty("on")
+	Equals("get error", t, nil, err)
+	Equals("get", t, true, v)
+
+	task, err := b.Client.InvokeAction("toggle", map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if status, _ := task.Status(); status.Status == server.TASK_DONE {
+			Equals("result", t, false, status.Data)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Action not finished")
+}
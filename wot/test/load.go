@@ -0,0 +1,242 @@
+// Package test contains tools for testing Things and applications consuming them
+package test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+// LOAD_CONCURRENCY is default limit of requests in flight
+const LOAD_CONCURRENCY = 64
+
+// LoadConfig describes load generated against a Thing. Reads and Writes are requested rates per second
+// of Property reads and writes, Subscribers listeners of Event are registered for the whole run.
+// Requests exceeding Concurrency in flight are not sent and are reported as skipped.
+type LoadConfig struct {
+	Duration    time.Duration
+	Concurrency int
+	Property    string
+	Reads       int
+	Writes      int
+	// WriteValue returns value of i-th write, written values are sequence numbers when nil
+	WriteValue  func(i int) interface{}
+	Event       string
+	Subscribers int
+}
+
+// Stats summarizes latencies of one kind of operation
+type Stats struct {
+	Count   int           `json:"count"`
+	Errors  int           `json:"errors"`
+	Skipped int           `json:"skipped"`
+	Mean    time.Duration `json:"mean"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+}
+
+// Report is result of load run. Event latency is measured from event timestamp set by the Thing.
+type Report struct {
+	Duration time.Duration `json:"duration"`
+	Reads    *Stats        `json:"reads"`
+	Writes   *Stats        `json:"writes"`
+	Events   *Stats        `json:"events"`
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "duration %v\n", r.Duration)
+	for _, s := range []struct {
+		name  string
+		stats *Stats
+	}{{"reads", r.Reads}, {"writes", r.Writes}, {"events", r.Events}} {
+		fmt.Fprintf(&b, "%-6s count %d errors %d skipped %d rate %.1f/s mean %v p50 %v p90 %v p99 %v max %v\n",
+			s.name, s.stats.Count, s.stats.Errors, s.stats.Skipped, s.stats.Rate(r.Duration),
+			s.stats.Mean, s.stats.P50, s.stats.P90, s.stats.P99, s.stats.Max)
+	}
+
+	return b.String()
+}
+
+// Rate is number of successful operations per second
+func (s *Stats) Rate(d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(s.Count-s.Errors) / d.Seconds()
+}
+
+// RunLoad drives Thing consumed by c as configured until cfg.Duration elapses or ctx is cancelled
+func RunLoad(ctx context.Context, c proxy.Client, cfg LoadConfig) (*Report, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = LOAD_CONCURRENCY
+	}
+	if cfg.WriteValue == nil {
+		cfg.WriteValue = func(i int) interface{} { return i }
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	reads, writes, events := newRecorder(), newRecorder(), newRecorder()
+
+	for i := 0; i < cfg.Subscribers; i++ {
+		sub, err := c.AddListener(cfg.Event, func(e *server.Event) {
+			events.record(time.Since(e.Timestamp), nil)
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer sub.Close()
+	}
+
+	slots := make(chan struct{}, cfg.Concurrency)
+	wg := &sync.WaitGroup{}
+	start := time.Now()
+
+	drive := func(rate int, rec *recorder, op func(i int) error) {
+		defer wg.Done()
+
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+
+		for i := 0; ; i++ {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case slots <- struct{}{}:
+			default:
+				rec.skip()
+				continue
+			}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				t := time.Now()
+				err := op(i)
+				rec.record(time.Since(t), err)
+			}(i)
+		}
+	}
+
+	if cfg.Reads > 0 {
+		wg.Add(1)
+		go drive(cfg.Reads, reads, func(int) error {
+			_, err := c.GetProperty(cfg.Property)
+			return err
+		})
+	}
+
+	if cfg.Writes > 0 {
+		wg.Add(1)
+		go drive(cfg.Writes, writes, func(i int) error {
+			return c.SetProperty(cfg.Property, cfg.WriteValue(i))
+		})
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	return &Report{
+		Duration: time.Since(start),
+		Reads:    reads.stats(),
+		Writes:   writes.stats(),
+		Events:   events.stats(),
+	}, nil
+}
+
+type recorder struct {
+	l         *sync.Mutex
+	latencies []time.Duration
+	errors    int
+	skipped   int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		l:         &sync.Mutex{},
+		latencies: make([]time.Duration, 0),
+	}
+}
+
+func (r *recorder) record(latency time.Duration, err error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.latencies = append(r.latencies, latency)
+	if err != nil {
+		r.errors++
+	}
+}
+
+func (r *recorder) skip() {
+	r.l.Lock()
+	r.skipped++
+	r.l.Unlock()
+}
+
+func (r *recorder) stats() *Stats {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	s := &Stats{
+		Count:   len(r.latencies),
+		Errors:  r.errors,
+		Skipped: r.skipped,
+	}
+
+	if s.Count == 0 {
+		return s
+	}
+
+	sorted := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	total := time.Duration(0)
+	for _, l := range sorted {
+		total += l
+	}
+
+	s.Mean = total / time.Duration(s.Count)
+	s.P50 = Percentile(sorted, 50)
+	s.P90 = Percentile(sorted, 90)
+	s.P99 = Percentile(sorted, 99)
+	s.Max = sorted[s.Count-1]
+
+	return s
+}
+
+// Percentile returns p-th percentile of sorted latencies using nearest rank method
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+func TestCasePercentile(t *testing.T) {
+	sorted := make([]time.Duration, 0)
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+
+	Equals("p50", t, time.Duration(50), Percentile(sorted, 50))
+	Equals("p99", t, time.Duration(99), Percentile(sorted, 99))
+	Equals("p100", t, time.Duration(100), Percentile(sorted, 100))
+	Equals("single", t, time.Duration(7), Percentile([]time.Duration{7}, 90))
+	Equals("empty", t, time.Duration(0), Percentile(nil, 90))
+}
+
+func TestCaseRunLoad(t *testing.T) {
+	c := &fakeClient{l: &sync.Mutex{}}
+
+	r, err := RunLoad(context.Background(), c, LoadConfig{
+		Duration:    300 * time.Millisecond,
+		Property:    "p",
+		Reads:       100,
+		Writes:      50,
+		Event:       "e",
+		Subscribers: 2,
+	})
+
+	Equals("error", t, nil, err)
+	Equals("reads", t, true, r.Reads.Count > 10)
+	Equals("writes", t, true, r.Writes.Count > 5)
+	Equals("write errors", t, r.Writes.Count, r.Writes.Errors)
+	Equals("events", t, 2, r.Events.Count)
+	Equals("subscriptions closed", t, 0, c.subscribers)
+	Equals("p50 <= p99", t, true, r.Reads.P50 <= r.Reads.P99)
+}
+
+var errReadOnly = errors.New("read only")
+
+type fakeClient struct {
+	l           *sync.Mutex
+	subscribers int
+}
+
+func (c *fakeClient) Name() string { return "fake" }
+
+func (c *fakeClient) GetDescription() (*model.ThingDescription, error) {
+	return &model.ThingDescription{Name: "fake"}, nil
+}
+
+func (c *fakeClient) GetProperty(propertyName string) (interface{}, error) {
+	time.Sleep(time.Millisecond)
+	return 1, nil
+}
+
+func (c *fakeClient) SetProperty(propertyName string, newValue interface{}) error {
+	return errReadOnly
+}
+
+func (c *fakeClient) InvokeAction(actionName string, arg interface{}) (proxy.Task, error) {
+	return nil, errReadOnly
+}
+
+func (c *fakeClient) AddListener(eventName string, listener func(*server.Event)) (proxy.Subscription, error) {
+	c.l.Lock()
+	c.subscribers++
+	c.l.Unlock()
+
+	listener(&server.Event{Event: eventName, Timestamp: time.Now().Add(-time.Millisecond)})
+
+	return c, nil
+}
+
+func (c *fakeClient) Close() error {
+	c.l.Lock()
+	c.subscribers--
+	c.l.Unlock()
+
+	return nil
+}
+
+func Equals(assetName string, t *testing.T, expected, actual interface{}) {
+	if expected != actual {
+		t.Log(assetName, ": ", expected, " != ", actual)
+		t.Fail()
+	}
+}
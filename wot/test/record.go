@@ -0,0 +1,300 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/util/str"
+	"github.com/conas/tno2/wot/proxy"
+	"github.com/conas/tno2/wot/server"
+)
+
+// Recorder writes interactions of WotServers to trace, one JSON encoded server.Interaction per line
+type Recorder struct {
+	l   *sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+	err error
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		l:   &sync.Mutex{},
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// RecordFile creates recorder appending to trace file at path
+func RecordFile(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecorder(f), nil
+}
+
+// Record taps all interactions of s
+func (r *Recorder) Record(s *server.WotServer) *Recorder {
+	s.AddTap(r.write)
+	return r
+}
+
+func (r *Recorder) write(i *server.Interaction) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	if r.err = r.enc.Encode(i); r.err != nil {
+		log.Error("Recorder: trace write failed, recording stopped -> ", r.err)
+	}
+}
+
+// Err returns error which stopped recording
+func (r *Recorder) Err() error {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	return r.err
+}
+
+// Close closes underlying writer if it is closer
+func (r *Recorder) Close() error {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.err == nil {
+		r.err = errors.New("Recorder closed")
+	}
+
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// ReadTrace decodes recorded interactions
+func ReadTrace(r io.Reader) ([]*server.Interaction, error) {
+	trace := make([]*server.Interaction, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		i := &server.Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), i); err != nil {
+			return nil, errors.New(str.Concat("Trace line ", strconv.Itoa(line), ": ", err.Error()))
+		}
+
+		trace = append(trace, i)
+	}
+
+	return trace, scanner.Err()
+}
+
+// ReadTraceFile decodes trace file at path
+func ReadTraceFile(path string) ([]*server.Interaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadTrace(f)
+}
+
+// Target receives replayed interactions, see ServerTarget and ClientTarget
+type Target interface {
+	ReadProperty(name string) (interface{}, error)
+	WriteProperty(name string, value interface{}) error
+	InvokeAction(name string, input interface{}) (interface{}, error)
+	EmitEvent(name string, data interface{}) error
+}
+
+// ErrNotReplayable is returned by Target not able to replay kind of interaction, such interactions are skipped
+var ErrNotReplayable = errors.New("Interaction not replayable on target")
+
+// ReplayOptions controls replay timing. Speed 1 keeps recorded delays between interactions, 2 halves them,
+// 0 replays as fast as possible.
+type ReplayOptions struct {
+	Speed float64
+}
+
+// Mismatch is replayed read or invocation with output different from recording, or failed interaction
+type Mismatch struct {
+	Index       int                 `json:"index"`
+	Interaction *server.Interaction `json:"interaction"`
+	Actual      interface{}         `json:"actual,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+type ReplayReport struct {
+	Replayed   int         `json:"replayed"`
+	Skipped    int         `json:"skipped"`
+	Mismatches []*Mismatch `json:"mismatches"`
+}
+
+// Replay feeds trace to target in recorded order, outputs of reads and invocations are compared to recording
+func Replay(trace []*server.Interaction, target Target, opts ReplayOptions) *ReplayReport {
+	report := &ReplayReport{Mismatches: make([]*Mismatch, 0)}
+
+	for idx, i := range trace {
+		if idx > 0 && opts.Speed > 0 {
+			time.Sleep(time.Duration(float64(i.Time.Sub(trace[idx-1].Time)) / opts.Speed))
+		}
+
+		var actual interface{}
+		var err error
+		compare := false
+
+		switch i.Kind {
+		case server.INTERACTION_READ:
+			actual, err = target.ReadProperty(i.Name)
+			compare = true
+		case server.INTERACTION_WRITE:
+			err = target.WriteProperty(i.Name, i.Input)
+		case server.INTERACTION_INVOKE:
+			actual, err = target.InvokeAction(i.Name, i.Input)
+			compare = true
+		case server.INTERACTION_EVENT:
+			err = target.EmitEvent(i.Name, i.Input)
+		default:
+			err = ErrNotReplayable
+		}
+
+		if err == ErrNotReplayable {
+			report.Skipped++
+			continue
+		}
+
+		report.Replayed++
+
+		if err != nil {
+			report.Mismatches = append(report.Mismatches, &Mismatch{Index: idx, Interaction: i, Error: err.Error()})
+			continue
+		}
+
+		if compare && !server.SameValue(i.Output, actual) {
+			report.Mismatches = append(report.Mismatches, &Mismatch{Index: idx, Interaction: i, Actual: actual})
+		}
+	}
+
+	return report
+}
+
+// ServerTarget replays interactions directly on WotServer, as if they came from frontend and backend
+func ServerTarget(s *server.WotServer) Target {
+	return &serverTarget{s: s}
+}
+
+type serverTarget struct {
+	s *server.WotServer
+}
+
+func (t *serverTarget) ReadProperty(name string) (interface{}, error) {
+	return result(t.s.GetProperty(name).Wait())
+}
+
+func (t *serverTarget) WriteProperty(name string, value interface{}) error {
+	_, err := result(t.s.SetProperty(name, value).Wait())
+	return err
+}
+
+func (t *serverTarget) InvokeAction(name string, input interface{}) (interface{}, error) {
+	state := &atomic.Value{}
+	ph := server.NewWotProgressHandler(name, state, async.NewFanOut())
+
+	if _, err := result(t.s.InvokeAction(name, input, ph).Wait()); err != nil {
+		return nil, err
+	}
+
+	status, _ := state.Load().(*server.TaskStatus)
+	if status == nil || status.Status == server.TASK_FAILED {
+		return nil, errors.New("Action failed")
+	}
+
+	return status.Data, nil
+}
+
+func (t *serverTarget) EmitEvent(name string, data interface{}) error {
+	if status := t.s.EmitEvent(name, data); status != server.WOT_OK {
+		return &server.StatusError{Status: status}
+	}
+
+	return nil
+}
+
+// result converts WotServer call result reporting failure by Status or error to error
+func result(v interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	switch r := v.(type) {
+	case server.Status:
+		if r != server.WOT_OK {
+			return nil, &server.StatusError{Status: r}
+		}
+		return nil, nil
+	case error:
+		return nil, r
+	}
+
+	return v, nil
+}
+
+// ClientTarget replays interactions on Thing consumed by c. Events are emitted by the Thing, they are skipped.
+func ClientTarget(c proxy.Client) Target {
+	return &clientTarget{c: c}
+}
+
+type clientTarget struct {
+	c proxy.Client
+}
+
+func (t *clientTarget) ReadProperty(name string) (interface{}, error) {
+	return t.c.GetProperty(name)
+}
+
+func (t *clientTarget) WriteProperty(name string, value interface{}) error {
+	return t.c.SetProperty(name, value)
+}
+
+func (t *clientTarget) InvokeAction(name string, input interface{}) (interface{}, error) {
+	task, err := t.c.InvokeAction(name, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *server.TaskStatus
+	if err = task.Watch(func(status *server.TaskStatus) { last = status }); err != nil {
+		return nil, err
+	}
+
+	if last == nil || last.Status == server.TASK_FAILED {
+		return nil, errors.New("Action failed")
+	}
+
+	return last.Data, nil
+}
+
+func (t *clientTarget) EmitEvent(name string, data interface{}) error {
+	return ErrNotReplayable
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/conas/tno2/util/async"
+	"github.com/conas/tno2/wot/model"
+	"github.com/conas/tno2/wot/server"
+)
+
+func counter(step int) *server.WotServer {
+	s := server.CreateFromDescription(&model.ThingDescription{
+		Name:       "counter",
+		Properties: []model.Property{{Name: "value", Writable: true, Hrefs: []string{"value"}}},
+		Actions:    []model.Action{{Name: "inc", Hrefs: []string{"inc"}}},
+		Events:     []model.Event{{Name: "overflow", Hrefs: []string{"overflow"}}},
+	})
+
+	value := 0
+	s.OnGetProperty("value", func() interface{} { return value })
+	s.OnUpdateProperty("value", func(v interface{}) { value, _ = server.As[int](v) })
+	s.OnInvokeAction("inc", func(arg interface{}, ph async.ProgressHandler) interface{} {
+		value += step
+		return value
+	})
+
+	return s
+}
+
+func exercise(s *server.WotServer) {
+	s.SetProperty("value", 5).Wait()
+	s.GetProperty("value").Wait()
+	ServerTarget(s).InvokeAction("inc", nil)
+	s.EmitEvent("overflow", 7)
+}
+
+func TestCaseRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+
+	s := counter(1)
+	rec.Record(s)
+	exercise(s)
+
+	trace, err := ReadTrace(&buf)
+	Equals("read error", t, nil, err)
+	Equals("recorded", t, 4, len(trace))
+	Equals("write", t, server.INTERACTION_WRITE, trace[0].Kind)
+	Equals("invoke output", t, float64(6), trace[2].Output)
+	Equals("event", t, server.INTERACTION_EVENT, trace[3].Kind)
+
+	same := Replay(trace, ServerTarget(counter(1)), ReplayOptions{})
+	Equals("replayed", t, 4, same.Replayed)
+	Equals("no mismatch", t, 0, len(same.Mismatches))
+
+	//regression, action increments by 2
+	changed := Replay(trace, ServerTarget(counter(2)), ReplayOptions{})
+	Equals("mismatch", t, 1, len(changed.Mismatches))
+	Equals("mismatch index", t, 2, changed.Mismatches[0].Index)
+	Equals("mismatch actual", t, 7, changed.Mismatches[0].Actual)
+}
+
+func TestCaseReplayClient(t *testing.T) {
+	var buf bytes.Buffer
+	s := counter(1)
+	NewRecorder(&buf).Record(s)
+	exercise(s)
+
+	trace, _ := ReadTrace(&buf)
+
+	b := NewBinding(counter(1))
+	defer b.Close()
+
+	r := Replay(trace, ClientTarget(b.Client), ReplayOptions{})
+	Equals("replayed", t, 3, r.Replayed)
+	Equals("events skipped", t, 1, r.Skipped)
+	Equals("no mismatch", t, 0, len(r.Mismatches))
+}